@@ -0,0 +1,31 @@
+" Vim syntax file
+" Language: mgol
+" Generated from the scanner with: go run ./src/cmd/gensyntax -format vim
+
+if exists("b:current_syntax")
+  finish
+endif
+
+syntax match mgolDelimiter /\v\;/
+syntax match mgolDelimiter /\v\)/
+syntax match mgolDelimiter /\v\(/
+syntax match mgolOperator #\v[*+\-/]#
+syntax match mgolOperator /\v\=|\>\=?|\<[=>]?/
+syntax match mgolOperator /\v\<\-/
+syntax match mgolNumber /\v[0-9][0-9]*%(%([Ee]%([0-9][0-9]*|[+\-][0-9][0-9]*))?|\.[0-9][0-9]*%([Ee]%([0-9][0-9]*|[+\-][0-9][0-9]*))?)/
+syntax match mgolIdentifier /\v[A-Za-z][0-9A-Z_a-z]*/
+syntax match mgolString /\v\"[\t !(-?A-\]_a-{}]*\"/
+syntax match mgolComment /\v\{[\t -"(-?A-\]_a-{]*\}/
+syntax keyword mgolKeyword enquanto entao escreva fim fimenquanto fimrepita fimse inicio leia repita se senao varfim varinicio
+syntax keyword mgolType inteiro literal real
+
+highlight default link mgolKeyword Keyword
+highlight default link mgolType Type
+highlight default link mgolDelimiter Delimiter
+highlight default link mgolOperator Operator
+highlight default link mgolNumber Number
+highlight default link mgolIdentifier Identifier
+highlight default link mgolString String
+highlight default link mgolComment Comment
+
+let b:current_syntax = "mgol"
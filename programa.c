@@ -0,0 +1,68 @@
+#include <stdio.h>
+#include <stdbool.h>
+#include <string.h>
+
+typedef char literal[256];
+
+/* leia: a value that can not be read leaves the variable zeroed */
+static void leia_inteiro(int *valor) {
+	if (scanf("%d", valor) != 1) {
+		*valor = 0;
+	}
+}
+
+static void leia_real(double *valor) {
+	if (scanf("%lf", valor) != 1) {
+		*valor = 0;
+	}
+}
+
+/* a literal is read up to the end of the line, spaces included */
+static void leia_literal(literal valor) {
+	if (scanf(" %255[^\n]", valor) != 1) {
+		valor[0] = '\0';
+	}
+}
+
+static void escreva_inteiro(int valor) {
+	printf("%d", valor);
+}
+
+static void escreva_real(double valor) {
+	printf("%lf", valor);
+}
+
+static void escreva_literal(const char *valor) {
+	printf("%s", valor);
+}
+
+int main(void) {
+/*----Variaveis temporarias----*/
+int T0;
+bool T1;
+/*------------------------------*/
+int A;
+int B;
+double C;
+leia_inteiro(&A);
+T0 = A * 2;
+T0 = T0 + 1;
+B = T0;
+T0 = B / 2;
+C = T0;
+T1 = B > 3;
+if (T1) {
+escreva_literal("grande");
+} else {
+escreva_literal("pequeno");
+}
+T1 = A > 0;
+while (T1) {
+escreva_inteiro(A);
+T0 = A - 1;
+A = T0;
+T1 = A > 0;
+}
+escreva_real(C);
+return 0;
+}
@@ -0,0 +1,556 @@
+// Package asmgen is a backend that writes a program as x86-64
+// assembly, in the AT&T syntax of the GNU assembler, for the lessons
+// on how a program maps to the machine. gcc assembles and links it:
+//
+//	gcc programa.s -o programa
+//
+// It is generated from the three-address code of the ir package,
+// each instruction written as a comment before its assembly. The code
+// is kept simple rather than fast: the variables are globals, each
+// temporary has a slot on the stack of main, and every operation
+// loads its operands on registers and stores its result back. leia
+// and escreva call small functions written along with the program,
+// which pass the values on to scanf and printf following the System V
+// calling convention, like the C backend does
+package asmgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"strconv"
+	"strings"
+)
+
+// literalSize is the size of the buffer of a literal
+// variable, which the C backend declares as char[256]
+const literalSize = 256
+
+// slotSize is the size of the slot of a temporary on the stack,
+// large enough for a double or the address of a literal
+const slotSize = 8
+
+// readers and writers hold the function of the runtime
+// that reads and writes a value of each type
+var (
+	readers = map[lexer.DataType]string{
+		lexer.INTEGER: "leia_inteiro",
+		lexer.REAL:    "leia_real",
+		lexer.LITERAL: "leia_literal",
+	}
+	writers = map[lexer.DataType]string{
+		lexer.INTEGER: "escreva_inteiro",
+		lexer.REAL:    "escreva_real",
+		lexer.LITERAL: "escreva_literal",
+	}
+)
+
+// runtime holds the functions leia and escreva are translated to. Each
+// takes its argument where the System V convention puts it, the address
+// of the variable read or the integer written on %rdi and the real
+// written on %xmm0, and calls the C library with the format of its type.
+// The stack must be aligned to 16 bytes on each call, and the call
+// that got here pushed 8 bytes, so each one moves it by 8 more
+const runtime = `	.section .rodata
+.Lformato_inteiro:
+	.string "%d"
+.Lformato_real:
+	.string "%lf"
+.Lformato_literal:
+	.string "%s"
+# A literal is read up to the end of the line, spaces included
+.Lleia_literal:
+	.string " %255[^\n]"
+
+	.text
+# leia_inteiro(int *valor): a value that can not be read leaves it zeroed
+leia_inteiro:
+	pushq   %rbx                    # saves %rbx, which the caller keeps, aligning the stack
+	movq    %rdi, %rbx              # keeps valor while scanf runs
+	movl    $0, (%rbx)              # *valor = 0, for when nothing is read
+	movq    %rbx, %rsi              # second argument: valor
+	leaq    .Lformato_inteiro(%rip), %rdi # first argument: the format
+	movl    $0, %eax                # %al tells a variadic function how many vector registers hold arguments
+	call    scanf@PLT
+	popq    %rbx
+	ret
+
+# leia_real(double *valor)
+leia_real:
+	pushq   %rbx
+	movq    %rdi, %rbx
+	movq    $0, (%rbx)              # all 8 bytes zeroed are the double 0.0
+	movq    %rbx, %rsi
+	leaq    .Lformato_real(%rip), %rdi
+	movl    $0, %eax
+	call    scanf@PLT
+	popq    %rbx
+	ret
+
+# leia_literal(char *valor)
+leia_literal:
+	pushq   %rbx
+	movq    %rdi, %rbx
+	movb    $0, (%rbx)              # an empty string ends on its first byte
+	movq    %rbx, %rsi
+	leaq    .Lleia_literal(%rip), %rdi
+	movl    $0, %eax
+	call    scanf@PLT
+	popq    %rbx
+	ret
+
+# escreva_inteiro(int valor)
+escreva_inteiro:
+	subq    $8, %rsp                # aligns the stack
+	movl    %edi, %esi              # valor becomes the second argument
+	leaq    .Lformato_inteiro(%rip), %rdi
+	movl    $0, %eax
+	call    printf@PLT
+	addq    $8, %rsp
+	ret
+
+`
+
+// writeReal is escreva_real, which printf writes with a point
+const writeReal = `# escreva_real(double valor)
+escreva_real:
+	subq    $8, %rsp
+	leaq    .Lformato_real(%rip), %rdi # valor stays on %xmm0, the first vector argument
+	movl    $1, %eax                # one vector register holds an argument
+	call    printf@PLT
+	addq    $8, %rsp
+	ret
+
+`
+
+// writeRealComma is escreva_real writing a comma instead of the point:
+// snprintf writes the number on a buffer on the stack, where strchr
+// finds the point. 320 bytes hold the 309 digits of the largest double
+const writeRealComma = `# escreva_real(double valor), with a comma as the decimal separator
+escreva_real:
+	subq    $328, %rsp              # 320 bytes for the text, 8 more to align the stack
+	movq    %rsp, %rdi              # first argument: the buffer
+	movl    $320, %esi              # second: its size
+	leaq    .Lformato_real(%rip), %rdx # third: the format, valor still on %xmm0
+	movl    $1, %eax
+	call    snprintf@PLT
+	movq    %rsp, %rdi
+	movl    $46, %esi               # '.'
+	call    strchr@PLT              # %rax = the address of the point, or 0
+	testq   %rax, %rax
+	je      1f                      # no point, like on inf
+	movb    $44, (%rax)             # ','
+1:
+	movq    %rsp, %rsi
+	leaq    .Lformato_literal(%rip), %rdi
+	movl    $0, %eax
+	call    printf@PLT
+	addq    $328, %rsp
+	ret
+
+`
+
+// writeLiteral is escreva_literal, the last function of the runtime
+const writeLiteral = `# escreva_literal(const char *valor)
+escreva_literal:
+	subq    $8, %rsp
+	movq    %rdi, %rsi
+	leaq    .Lformato_literal(%rip), %rdi
+	movl    $0, %eax
+	call    printf@PLT
+	addq    $8, %rsp
+	ret
+`
+
+// reserved holds the names of the symbols a variable can not take
+var reserved = map[string]bool{
+	"main": true, "scanf": true, "printf": true, "snprintf": true,
+	"strchr": true, "strcmp": true, "strcpy": true,
+	"leia_inteiro": true, "leia_real": true, "leia_literal": true,
+	"escreva_inteiro": true, "escreva_real": true, "escreva_literal": true,
+}
+
+// symbol returns the symbol of the variable named name
+func symbol(name string) string {
+	if reserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// integerJumps holds the jump taken when each comparison of integers
+// does not hold, after cmpl, which compares the left operand, on %eax,
+// with the right one, on %ecx. The literals are compared the same way,
+// by the sign of strcmp
+var integerJumps = map[string]string{
+	"<":  "jge",
+	">":  "jle",
+	"<=": "jg",
+	">=": "jl",
+	"=":  "jne",
+	"<>": "je",
+}
+
+// integerOperations and realOperations hold the
+// instruction of each arithmetic operator
+var integerOperations = map[string]string{
+	"+": "addl",
+	"-": "subl",
+	"*": "imull",
+}
+
+var realOperations = map[string]string{
+	"+": "addsd",
+	"-": "subsd",
+	"*": "mulsd",
+	"/": "divsd",
+}
+
+type generator struct {
+	options backend.Options
+	text    strings.Builder
+	// literals and reals hold the constants, written on .rodata once
+	// each, and constants the label of each one by how it is written
+	literals  []string
+	reals     []float64
+	constants map[string]string
+	err       error
+}
+
+// emit writes an instruction, with comment after it if not empty
+func (g *generator) emit(instruction, comment string) {
+	g.text.WriteString(layout(instruction, comment))
+}
+
+// layout lines an instruction up with the ones of the runtime: the
+// mnemonic is separated from the operands by a tab, which becomes
+// spaces up to the ninth column, and the comment starts on the 33rd
+func layout(instruction, comment string) string {
+	line := instruction
+	if index := strings.IndexByte(instruction, '\t'); index >= 0 {
+		line = fmt.Sprintf("%-7s %s", instruction[:index], instruction[index+1:])
+	}
+	if comment != "" {
+		line = fmt.Sprintf("%-31s # %s", line, comment)
+	}
+	return "\t" + line + "\n"
+}
+
+// Generate writes program as x86-64 assembly. info holds what the sem
+// package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	return GenerateWithOptions(w, program, info, backend.Options{})
+}
+
+// GenerateWithOptions writes program like Generate, following options
+func GenerateWithOptions(w io.Writer, program *ast.Program, info *sem.Info, options backend.Options) error {
+	lowered, err := ir.Lower(program, info)
+	if err != nil {
+		return err
+	}
+	return GenerateIRWithOptions(w, lowered, options)
+}
+
+// GenerateIR writes a program already lowered to
+// three-address code, like after its optimizations
+func GenerateIR(w io.Writer, lowered *ir.Program) error {
+	return GenerateIRWithOptions(w, lowered, backend.Options{})
+}
+
+// GenerateIRWithOptions writes lowered like GenerateIR, following options
+func GenerateIRWithOptions(w io.Writer, lowered *ir.Program, options backend.Options) error {
+	g := &generator{options: options, constants: make(map[string]string)}
+	for _, instruction := range lowered.Instructions {
+		g.instruction(instruction)
+	}
+	if g.err != nil {
+		return g.err
+	}
+
+	var module strings.Builder
+	module.WriteString(runtime)
+	if options.DecimalComma {
+		module.WriteString(writeRealComma)
+	} else {
+		module.WriteString(writeReal)
+	}
+	module.WriteString(writeLiteral)
+
+	if len(lowered.Declarations) > 0 {
+		module.WriteString("\n# The variables, zeroed when the program starts\n\t.data\n")
+	}
+	for _, declaration := range lowered.Declarations {
+		fmt.Fprintf(&module, "# %s %s\n", typeNames[declaration.Type], declaration.Name)
+		switch declaration.Type {
+		case lexer.INTEGER:
+			fmt.Fprintf(&module, "\t.align 4\n%s:\n\t.long 0\n", symbol(declaration.Name))
+		case lexer.REAL:
+			fmt.Fprintf(&module, "\t.align 8\n%s:\n\t.quad 0\n", symbol(declaration.Name))
+		case lexer.LITERAL:
+			fmt.Fprintf(&module, "%s:\n\t.zero %d\n", symbol(declaration.Name), literalSize)
+		}
+	}
+
+	if len(g.literals) > 0 || len(g.reals) > 0 {
+		module.WriteString("\n# The constants of the program\n\t.section .rodata\n")
+	}
+	for index, text := range g.literals {
+		fmt.Fprintf(&module, ".LC%d:\n\t.string \"%s\"\n", index, escape(text))
+	}
+	for index, value := range g.reals {
+		// A double has no immediate form, so it is loaded from memory,
+		// written by its bits for the assembler to take it exactly
+		fmt.Fprintf(&module, "\t.align 8\n.LR%d:\n\t.quad 0x%016X\t\t# %s\n", index, math.Float64bits(value),
+			strconv.FormatFloat(value, 'g', -1, 64))
+	}
+
+	// The temporaries live on the stack frame of main, below the
+	// saved %rbp, which must be kept aligned to 16 bytes for the calls
+	frame := len(lowered.Temporaries) * slotSize
+	frame += frame % 16
+	module.WriteString("\n\t.text\n\t.globl main\nmain:\n")
+	module.WriteString(layout("pushq\t%rbp", "saves the frame of the caller"))
+	module.WriteString(layout("movq\t%rsp, %rbp", "the frame of main starts here"))
+	if frame > 0 {
+		module.WriteString(layout(fmt.Sprintf("subq\t$%d, %%rsp", frame),
+			fmt.Sprintf("room for %d temporaries, %d bytes each", len(lowered.Temporaries), slotSize)))
+	}
+	module.WriteString(g.text.String())
+	module.WriteString(layout("movl\t$0, %eax", "main returns 0"))
+	module.WriteString(layout("leave", "movq %rbp, %rsp; popq %rbp"))
+	module.WriteString(layout("ret", ""))
+	// Tells the linker the stack does not need to be executable
+	module.WriteString("\n\t.section .note.GNU-stack,\"\",@progbits\n")
+
+	_, err := io.WriteString(w, module.String())
+	return err
+}
+
+// typeNames holds how the type of a variable is written on the source
+var typeNames = map[lexer.DataType]string{
+	lexer.INTEGER: "inteiro",
+	lexer.REAL:    "real",
+	lexer.LITERAL: "literal",
+}
+
+// escape writes text as the contents of a .string directive
+func escape(text string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(text) {
+		if b < ' ' || b > '~' || b == '"' || b == '\\' {
+			fmt.Fprintf(&escaped, "\\%03o", b)
+			continue
+		}
+		escaped.WriteByte(b)
+	}
+	return escaped.String()
+}
+
+func (g *generator) instruction(instruction ir.Instruction) {
+	if instruction.Op == ir.Mark {
+		fmt.Fprintf(&g.text, ".%s:\n", instruction.Label)
+		return
+	}
+	fmt.Fprintf(&g.text, "# %s\n", instruction)
+
+	dest, dataType := instruction.Dest, instruction.Dest.Type
+	switch instruction.Op {
+	case ir.Read:
+		g.emit(fmt.Sprintf("leaq\t%s, %%rdi", g.location(dest)), "the address of "+dest.Name)
+		g.emit("call\t"+readers[dataType], "")
+	case ir.Write:
+		written := instruction.Left.Type
+		switch written {
+		case lexer.INTEGER:
+			g.load(instruction.Left, "%edi")
+		case lexer.REAL:
+			g.load(instruction.Left, "%xmm0")
+		case lexer.LITERAL:
+			g.load(instruction.Left, "%rdi")
+		}
+		g.emit("call\t"+writers[written], "")
+	case ir.Copy:
+		if dataType == lexer.LITERAL && dest.Kind == ir.Variable {
+			g.load(instruction.Left, "%rsi")
+			g.emit(fmt.Sprintf("leaq\t%s, %%rdi", g.location(dest)), "")
+			g.emit("call\tstrcpy@PLT", "the characters are copied, not the address")
+			return
+		}
+		g.load(instruction.Left, accumulators[dataType])
+		g.store(dest)
+	case ir.Binary:
+		g.binary(instruction)
+	case ir.Convert:
+		if dataType == lexer.REAL {
+			g.load(instruction.Left, "%eax")
+			g.emit("cvtsi2sdl\t%eax, %xmm0", "the integer as a double")
+		} else {
+			g.load(instruction.Left, "%xmm0")
+			// Like on a C int, the fractional part is dropped
+			g.emit("cvttsd2si\t%xmm0, %eax", "the double truncated to an integer")
+		}
+		g.store(dest)
+	case ir.Jump:
+		g.emit(fmt.Sprintf("jmp\t.%s", instruction.Label), "")
+	case ir.JumpUnless:
+		g.jumpUnless(instruction)
+	}
+}
+
+// accumulators holds the register each type is computed on
+var accumulators = map[lexer.DataType]string{
+	lexer.INTEGER: "%eax",
+	lexer.REAL:    "%xmm0",
+	lexer.LITERAL: "%rax",
+}
+
+// binary computes an arithmetic operation on the accumulator,
+// with the right operand on the second register
+func (g *generator) binary(instruction ir.Instruction) {
+	if instruction.Dest.Type == lexer.REAL {
+		g.load(instruction.Left, "%xmm0")
+		g.load(instruction.Right, "%xmm1")
+		g.emit(fmt.Sprintf("%s\t%%xmm1, %%xmm0", realOperations[instruction.Operator]), "%xmm0 "+instruction.Operator+"= %xmm1")
+		g.store(instruction.Dest)
+		return
+	}
+	g.load(instruction.Left, "%eax")
+	g.load(instruction.Right, "%ecx")
+	if instruction.Operator == "/" {
+		g.emit("cltd", "sign-extends %eax to %edx:%eax, the dividend")
+		g.emit("idivl\t%ecx", "the quotient goes to %eax and the remainder to %edx")
+	} else {
+		g.emit(fmt.Sprintf("%s\t%%ecx, %%eax", integerOperations[instruction.Operator]), "%eax "+instruction.Operator+"= %ecx")
+	}
+	g.store(instruction.Dest)
+}
+
+// jumpUnless jumps to the label of instruction when its comparison does
+// not hold, the only way se and repita leave the code that follows
+func (g *generator) jumpUnless(instruction ir.Instruction) {
+	label := "." + instruction.Label.String()
+	switch instruction.Left.Type {
+	case lexer.REAL:
+		g.realJump(instruction, label)
+		return
+	case lexer.LITERAL:
+		g.load(instruction.Left, "%rdi")
+		g.load(instruction.Right, "%rsi")
+		g.emit("call\tstrcmp@PLT", "%eax < 0, = 0 or > 0, as the left literal comes before, is or comes after the right")
+		g.emit("movl\t$0, %ecx", "")
+	default:
+		g.load(instruction.Left, "%eax")
+		g.load(instruction.Right, "%ecx")
+	}
+	g.emit("cmpl\t%ecx, %eax", "sets the flags as for %eax - %ecx")
+	g.emit(fmt.Sprintf("%s\t%s", integerJumps[instruction.Operator], label), "leaves unless "+instruction.Operator+" holds")
+}
+
+// realJump is jumpUnless for reals. ucomisd sets the flags like
+// an unsigned comparison, and the parity flag when either is nan,
+// when no comparison holds but <>, like on C. The operands are
+// swapped for < and <=, so both become the jumps of > and >=, which
+// already leave on nan
+func (g *generator) realJump(instruction ir.Instruction, label string) {
+	g.load(instruction.Left, "%xmm0")
+	g.load(instruction.Right, "%xmm1")
+	switch instruction.Operator {
+	case "<", "<=":
+		g.emit("ucomisd\t%xmm0, %xmm1", "compares the right operand with the left")
+	default:
+		g.emit("ucomisd\t%xmm1, %xmm0", "compares the left operand with the right")
+	}
+	switch instruction.Operator {
+	case "<", ">":
+		g.emit("jbe\t"+label, "leaves unless "+instruction.Operator+" holds")
+	case "<=", ">=":
+		g.emit("jb\t"+label, "leaves unless "+instruction.Operator+" holds")
+	case "=":
+		g.emit("jne\t"+label, "leaves when they differ")
+		g.emit("jp\t"+label, "or either is nan")
+	case "<>":
+		g.emit("jp\t1f", "either is nan, so they differ")
+		g.emit("je\t"+label, "leaves when they are equal")
+		g.text.WriteString("1:\n")
+	}
+}
+
+// location returns where the value of a variable or temporary is:
+// the globals relative to the instruction pointer, so the program can
+// be loaded anywhere, and the temporaries on the frame of main
+func (g *generator) location(operand ir.Operand) string {
+	if operand.Kind == ir.Temporary {
+		return fmt.Sprintf("-%d(%%rbp)", operand.Temporary*slotSize)
+	}
+	return symbol(operand.Name) + "(%rip)"
+}
+
+// load puts the value of operand on register. A literal
+// is loaded as the address of its first character
+func (g *generator) load(operand ir.Operand, register string) {
+	switch {
+	case operand.Kind == ir.Constant && operand.Type == lexer.LITERAL:
+		g.emit(fmt.Sprintf("leaq\t%s(%%rip), %s", g.literal(operand), register), operand.Name)
+	case operand.Kind == ir.Constant && operand.Type == lexer.REAL:
+		g.emit(fmt.Sprintf("movsd\t%s(%%rip), %s", g.real(operand), register), operand.Name)
+	case operand.Kind == ir.Constant:
+		number, err := lexer.ParseNumber(operand.Name, operand.Type)
+		if err != nil {
+			g.err = err
+		}
+		g.emit(fmt.Sprintf("movl\t$%d, %s", int32(number), register), "")
+	case operand.Type == lexer.LITERAL && operand.Kind == ir.Variable:
+		g.emit(fmt.Sprintf("leaq\t%s, %s", g.location(operand), register), operand.Name)
+	default:
+		g.emit(fmt.Sprintf("%s\t%s, %s", moves[operand.Type], g.location(operand), register), operand.String())
+	}
+}
+
+// store stores the accumulator of the type of dest on dest
+func (g *generator) store(dest ir.Operand) {
+	g.emit(fmt.Sprintf("%s\t%s, %s", moves[dest.Type], accumulators[dest.Type], g.location(dest)), dest.String()+" = "+accumulators[dest.Type])
+}
+
+// moves holds the instruction moving a value of each type. A
+// literal on a temporary is the address of its first character
+var moves = map[lexer.DataType]string{
+	lexer.INTEGER: "movl",
+	lexer.REAL:    "movsd",
+	lexer.LITERAL: "movq",
+}
+
+// literal returns the label of a literal constant, added to the
+// constants once
+func (g *generator) literal(operand ir.Operand) string {
+	text := lexer.LiteralText(operand.Name)
+	key := "literal " + text
+	if label, found := g.constants[key]; found {
+		return label
+	}
+	label := fmt.Sprintf(".LC%d", len(g.literals))
+	g.constants[key] = label
+	g.literals = append(g.literals, text)
+	return label
+}
+
+// real returns the label of a real constant, added to the constants once
+func (g *generator) real(operand ir.Operand) string {
+	number, err := lexer.ParseNumber(operand.Name, operand.Type)
+	if err != nil {
+		g.err = err
+		return ".LR0"
+	}
+	key := "real " + strconv.FormatUint(math.Float64bits(number), 16)
+	if label, found := g.constants[key]; found {
+		return label
+	}
+	label := fmt.Sprintf(".LR%d", len(g.reals))
+	g.constants[key] = label
+	g.reals = append(g.reals, number)
+	return label
+}
@@ -0,0 +1,152 @@
+package asmgen
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// inteiro A; real main;
+	// leia A;
+	// se (A > 1) entao main <- A * 2.5; fimse
+	// escreva "ok";
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A"), asttest.Declaration(lexer.REAL, "main")},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.If{
+				Condition: &ast.BinaryExpr{Operator: ">", Left: asttest.Ident("A"), Right: asttest.Literal("1", lexer.INTEGER)},
+				Body: []ast.Stmt{&ast.Assign{
+					Target: asttest.Ident("main"),
+					Value:  &ast.BinaryExpr{Operator: "*", Left: asttest.Ident("A"), Right: asttest.Literal("2.5", lexer.REAL)},
+				}},
+			},
+			&ast.Write{Value: asttest.Literal(`"ok"`, lexer.LITERAL)},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	require.Equal(t, runtime+writeReal+writeLiteral+`
+# The variables, zeroed when the program starts
+	.data
+# inteiro A
+	.align 4
+A:
+	.long 0
+# real main
+	.align 8
+main_:
+	.quad 0
+
+# The constants of the program
+	.section .rodata
+.LC0:
+	.string "ok"
+	.align 8
+.LR0:
+	.quad 0x4004000000000000		# 2.5
+
+	.text
+	.globl main
+main:
+	pushq   %rbp                    # saves the frame of the caller
+	movq    %rsp, %rbp              # the frame of main starts here
+	subq    $16, %rsp               # room for 2 temporaries, 8 bytes each
+# leia A
+	leaq    A(%rip), %rdi           # the address of A
+	call    leia_inteiro
+# ifFalse A > 1 goto L1
+	movl    A(%rip), %eax           # A
+	movl    $1, %ecx
+	cmpl    %ecx, %eax              # sets the flags as for %eax - %ecx
+	jle     .L1                     # leaves unless > holds
+# %t1 = (real) A
+	movl    A(%rip), %eax           # A
+	cvtsi2sdl %eax, %xmm0           # the integer as a double
+	movsd   %xmm0, -8(%rbp)         # %t1 = %xmm0
+# %t2 = %t1 * 2.5
+	movsd   -8(%rbp), %xmm0         # %t1
+	movsd   .LR0(%rip), %xmm1       # 2.5
+	mulsd   %xmm1, %xmm0            # %xmm0 *= %xmm1
+	movsd   %xmm0, -16(%rbp)        # %t2 = %xmm0
+# main = %t2
+	movsd   -16(%rbp), %xmm0        # %t2
+	movsd   %xmm0, main_(%rip)      # main = %xmm0
+.L1:
+# escreva "ok"
+	leaq    .LC0(%rip), %rdi        # "ok"
+	call    escreva_literal
+	movl    $0, %eax                # main returns 0
+	leave                           # movq %rbp, %rsp; popq %rbp
+	ret
+
+	.section .note.GNU-stack,"",@progbits
+`, out.String())
+}
+
+func TestGenerateIR(t *testing.T) {
+	program, err := ir.Parse(`real B
+literal C
+	leia B
+	ifFalse B <> 0.5 goto L1
+	ifFalse C = "fim" goto L1
+	escreva B
+L1:
+`)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, GenerateIRWithOptions(&out, program, backend.Options{DecimalComma: true}))
+	require.Contains(t, out.String(), writeRealComma)
+	require.Contains(t, out.String(), `
+main:
+	pushq   %rbp                    # saves the frame of the caller
+	movq    %rsp, %rbp              # the frame of main starts here
+# leia B
+	leaq    B(%rip), %rdi           # the address of B
+	call    leia_real
+# ifFalse B <> 0.5 goto L1
+	movsd   B(%rip), %xmm0          # B
+	movsd   .LR0(%rip), %xmm1       # 0.5
+	ucomisd %xmm1, %xmm0            # compares the left operand with the right
+	jp      1f                      # either is nan, so they differ
+	je      .L1                     # leaves when they are equal
+1:
+# ifFalse C = "fim" goto L1
+	leaq    C(%rip), %rdi           # C
+	leaq    .LC0(%rip), %rsi        # "fim"
+	call    strcmp@PLT              # %eax < 0, = 0 or > 0, as the left literal comes before, is or comes after the right
+	movl    $0, %ecx
+	cmpl    %ecx, %eax              # sets the flags as for %eax - %ecx
+	jne     .L1                     # leaves unless = holds
+# escreva B
+	movsd   B(%rip), %xmm0          # B
+	call    escreva_real
+.L1:
+	movl    $0, %eax                # main returns 0
+`)
+}
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `a \042b\042\012\134`, escape("a \"b\"\n\\"))
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.BadStmt{}},
+	}
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, sem.NewChecker(nil).Check(program)), ir.ErrorBadNode)
+}
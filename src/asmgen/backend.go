@@ -0,0 +1,39 @@
+package asmgen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(asmBackend{})
+}
+
+// asmBackend is the x86-64 assembly target on the registry of backends
+type asmBackend struct {
+	options backend.Options
+}
+
+func (asmBackend) Name() string {
+	return "asm"
+}
+
+func (asmBackend) FileExtension() string {
+	return ".s"
+}
+
+func (b asmBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return GenerateWithOptions(w, program, info, b.options)
+}
+
+func (b asmBackend) GenerateIR(program *ir.Program, w io.Writer) error {
+	return GenerateIRWithOptions(w, program, b.options)
+}
+
+func (b asmBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
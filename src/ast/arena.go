@@ -0,0 +1,154 @@
+package ast
+
+// arenaChunk is how many nodes of a kind each allocation of an arena holds
+const arenaChunk = 256
+
+// Arena allocates the nodes of a tree in chunks, one allocation for
+// many nodes of the same kind, which cuts the work of the garbage
+// collector on very large programs. A chunk is only freed when none
+// of its nodes is referenced anymore, so an arena should be used for
+// a single tree, not shared by trees that live for different times.
+// A nil arena allocates each node on its own, like new does. The
+// nodes are copied from a value instead of taking its address, which
+// would make the value be allocated on every call, even on an arena
+type Arena struct {
+	decls    []VarDecl
+	assigns  []Assign
+	ifs      []If
+	whiles   []While
+	reads    []Read
+	writes   []Write
+	binaries []BinaryExpr
+	idents   []Ident
+	literals []Literal
+}
+
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// NewVarDecl returns a node with the fields of node
+func (a *Arena) NewVarDecl(node VarDecl) *VarDecl {
+	if a == nil {
+		allocated := new(VarDecl)
+		*allocated = node
+		return allocated
+	}
+	if len(a.decls) == cap(a.decls) {
+		a.decls = make([]VarDecl, 0, arenaChunk)
+	}
+	a.decls = append(a.decls, node)
+	return &a.decls[len(a.decls)-1]
+}
+
+// NewAssign returns a node with the fields of node
+func (a *Arena) NewAssign(node Assign) *Assign {
+	if a == nil {
+		allocated := new(Assign)
+		*allocated = node
+		return allocated
+	}
+	if len(a.assigns) == cap(a.assigns) {
+		a.assigns = make([]Assign, 0, arenaChunk)
+	}
+	a.assigns = append(a.assigns, node)
+	return &a.assigns[len(a.assigns)-1]
+}
+
+// NewIf returns a node with the fields of node
+func (a *Arena) NewIf(node If) *If {
+	if a == nil {
+		allocated := new(If)
+		*allocated = node
+		return allocated
+	}
+	if len(a.ifs) == cap(a.ifs) {
+		a.ifs = make([]If, 0, arenaChunk)
+	}
+	a.ifs = append(a.ifs, node)
+	return &a.ifs[len(a.ifs)-1]
+}
+
+// NewWhile returns a node with the fields of node
+func (a *Arena) NewWhile(node While) *While {
+	if a == nil {
+		allocated := new(While)
+		*allocated = node
+		return allocated
+	}
+	if len(a.whiles) == cap(a.whiles) {
+		a.whiles = make([]While, 0, arenaChunk)
+	}
+	a.whiles = append(a.whiles, node)
+	return &a.whiles[len(a.whiles)-1]
+}
+
+// NewRead returns a node with the fields of node
+func (a *Arena) NewRead(node Read) *Read {
+	if a == nil {
+		allocated := new(Read)
+		*allocated = node
+		return allocated
+	}
+	if len(a.reads) == cap(a.reads) {
+		a.reads = make([]Read, 0, arenaChunk)
+	}
+	a.reads = append(a.reads, node)
+	return &a.reads[len(a.reads)-1]
+}
+
+// NewWrite returns a node with the fields of node
+func (a *Arena) NewWrite(node Write) *Write {
+	if a == nil {
+		allocated := new(Write)
+		*allocated = node
+		return allocated
+	}
+	if len(a.writes) == cap(a.writes) {
+		a.writes = make([]Write, 0, arenaChunk)
+	}
+	a.writes = append(a.writes, node)
+	return &a.writes[len(a.writes)-1]
+}
+
+// NewBinaryExpr returns a node with the fields of node
+func (a *Arena) NewBinaryExpr(node BinaryExpr) *BinaryExpr {
+	if a == nil {
+		allocated := new(BinaryExpr)
+		*allocated = node
+		return allocated
+	}
+	if len(a.binaries) == cap(a.binaries) {
+		a.binaries = make([]BinaryExpr, 0, arenaChunk)
+	}
+	a.binaries = append(a.binaries, node)
+	return &a.binaries[len(a.binaries)-1]
+}
+
+// NewIdent returns a node with the fields of node
+func (a *Arena) NewIdent(node Ident) *Ident {
+	if a == nil {
+		allocated := new(Ident)
+		*allocated = node
+		return allocated
+	}
+	if len(a.idents) == cap(a.idents) {
+		a.idents = make([]Ident, 0, arenaChunk)
+	}
+	a.idents = append(a.idents, node)
+	return &a.idents[len(a.idents)-1]
+}
+
+// NewLiteral returns a node with the fields of node
+func (a *Arena) NewLiteral(node Literal) *Literal {
+	if a == nil {
+		allocated := new(Literal)
+		*allocated = node
+		return allocated
+	}
+	if len(a.literals) == cap(a.literals) {
+		a.literals = make([]Literal, 0, arenaChunk)
+	}
+	a.literals = append(a.literals, node)
+	return &a.literals[len(a.literals)-1]
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArena(t *testing.T) {
+	arenas := map[string]*Arena{
+		"Arena":     NewArena(),
+		"Nil arena": nil,
+	}
+
+	for name, arena := range arenas {
+		t.Run(name, func(t *testing.T) {
+			// More nodes than a chunk holds
+			idents := []*Ident{}
+			for i := 0; i < 2*arenaChunk+1; i++ {
+				idents = append(idents, arena.NewIdent(Ident{Name: string(rune('A' + i%26))}))
+			}
+			for i, ident := range idents {
+				require.Equal(t, string(rune('A'+i%26)), ident.Name)
+			}
+
+			// Each node is a distinct one
+			idents[1].Name = "Z"
+			require.Equal(t, "A", idents[0].Name)
+			require.Equal(t, "C", idents[2].Name)
+
+			binary := arena.NewBinaryExpr(BinaryExpr{Operator: "+", Left: idents[0], Right: idents[2]})
+			assign := arena.NewAssign(Assign{Target: idents[1], Value: binary})
+			require.Same(t, binary, assign.Value)
+			require.Same(t, idents[0], binary.Left)
+		})
+	}
+}
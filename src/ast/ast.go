@@ -0,0 +1,162 @@
+package ast
+
+import "mgol-go/src/lexer"
+
+// Span is the region of the source file covered by a node,
+// from the first character of Start to the last one of End
+type Span struct {
+	Start lexer.Position `json:"start"`
+	End   lexer.Position `json:"end"`
+}
+
+func (s Span) GetSpan() Span {
+	return s
+}
+
+// Node is implemented by every node of the tree. Its
+// span covers the whole construct, from its first token
+// to its last one, keywords and terminators included
+type Node interface {
+	GetSpan() Span
+	// Pos returns the position of the first character of the node
+	Pos() lexer.Position
+	// End returns the position of the last character of the node
+	End() lexer.Position
+}
+
+// Stmt is implemented by the nodes that are commands
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Expr is implemented by the nodes that produce a value
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Program is the root of the tree: inicio varinicio ... varfim; ... fim
+type Program struct {
+	Span
+	Declarations []*VarDecl
+	Body         []Stmt
+}
+
+// VarDecl declares the variable Name with the type Type
+type VarDecl struct {
+	Span
+	Type lexer.DataType
+	Name *Ident
+}
+
+// Assign stores Value on Target: Target <- Value;
+type Assign struct {
+	Span
+	Target *Ident
+	Value  Expr
+}
+
+// If runs Body when Condition holds and Else otherwise:
+// se (Condition) entao Body senao Else fimse. Else is nil
+// when there is no senao
+type If struct {
+	Span
+	Condition Expr
+	Body      []Stmt
+	Else      []Stmt
+}
+
+// While runs Body while Condition holds: repita (Condition) Body fimrepita,
+// or enquanto (Condition) Body fimenquanto
+type While struct {
+	Span
+	Condition Expr
+	Body      []Stmt
+	// Enquanto tells the loop was written with enquanto,
+	// which runs the same as repita
+	Enquanto bool
+}
+
+// Read reads a value into Target: leia Target;
+type Read struct {
+	Span
+	Target *Ident
+}
+
+// Write writes Value: escreva Value;
+type Write struct {
+	Span
+	Value Expr
+}
+
+// BinaryExpr is an arithmetic or relational operation
+type BinaryExpr struct {
+	Span
+	Operator string
+	Left     Expr
+	Right    Expr
+}
+
+// Literal is a number or a literal constant, as written on the source
+type Literal struct {
+	Span
+	Value string
+	Type  lexer.DataType
+}
+
+// Ident is a reference to a variable
+type Ident struct {
+	Span
+	Name string
+}
+
+// BadStmt marks a statement that could not be parsed. It is
+// only found on the partial trees of sources with syntax errors
+type BadStmt struct {
+	Span
+}
+
+// BadExpr marks an expression that could not be parsed. It is
+// only found on the partial trees of sources with syntax errors
+type BadExpr struct {
+	Span
+}
+
+func (*Assign) stmtNode()  {}
+func (*If) stmtNode()      {}
+func (*While) stmtNode()   {}
+func (*Read) stmtNode()    {}
+func (*Write) stmtNode()   {}
+func (*BadStmt) stmtNode() {}
+
+func (*BinaryExpr) exprNode() {}
+func (*Literal) exprNode()    {}
+func (*Ident) exprNode()      {}
+func (*BadExpr) exprNode()    {}
+
+func (n *Program) Pos() lexer.Position    { return n.Span.Start }
+func (n *VarDecl) Pos() lexer.Position    { return n.Span.Start }
+func (n *Assign) Pos() lexer.Position     { return n.Span.Start }
+func (n *If) Pos() lexer.Position         { return n.Span.Start }
+func (n *While) Pos() lexer.Position      { return n.Span.Start }
+func (n *Read) Pos() lexer.Position       { return n.Span.Start }
+func (n *Write) Pos() lexer.Position      { return n.Span.Start }
+func (n *BinaryExpr) Pos() lexer.Position { return n.Span.Start }
+func (n *Literal) Pos() lexer.Position    { return n.Span.Start }
+func (n *Ident) Pos() lexer.Position      { return n.Span.Start }
+func (n *BadStmt) Pos() lexer.Position    { return n.Span.Start }
+func (n *BadExpr) Pos() lexer.Position    { return n.Span.Start }
+
+func (n *Program) End() lexer.Position    { return n.Span.End }
+func (n *VarDecl) End() lexer.Position    { return n.Span.End }
+func (n *Assign) End() lexer.Position     { return n.Span.End }
+func (n *If) End() lexer.Position         { return n.Span.End }
+func (n *While) End() lexer.Position      { return n.Span.End }
+func (n *Read) End() lexer.Position       { return n.Span.End }
+func (n *Write) End() lexer.Position      { return n.Span.End }
+func (n *BinaryExpr) End() lexer.Position { return n.Span.End }
+func (n *Literal) End() lexer.Position    { return n.Span.End }
+func (n *Ident) End() lexer.Position      { return n.Span.End }
+func (n *BadStmt) End() lexer.Position    { return n.Span.End }
+func (n *BadExpr) End() lexer.Position    { return n.Span.End }
@@ -0,0 +1,47 @@
+// Package asttest builds the nodes of syntax trees for the tests of
+// the packages that work on them, with no spans unless given one:
+//
+//	program := &ast.Program{
+//		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+//		Body: []ast.Stmt{
+//			&ast.Write{Value: asttest.Binary("*", asttest.Ident("A"), asttest.Literal("2", lexer.INTEGER))},
+//		},
+//	}
+package asttest
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+)
+
+// At returns the span of a single character on line and column
+func At(line, column int) ast.Span {
+	position := lexer.Position{Line: line, Column: column}
+	return ast.Span{Start: position, End: position}
+}
+
+// Ident returns a reference to the variable named name
+func Ident(name string) *ast.Ident {
+	return &ast.Ident{Name: name}
+}
+
+// Literal returns the constant value of dataType, written as on
+// the source, like "2.5" or `"texto"` with its quotes
+func Literal(value string, dataType lexer.DataType) *ast.Literal {
+	return &ast.Literal{Value: value, Type: dataType}
+}
+
+// Binary returns left operator right, spanning from left to right
+func Binary(operator string, left, right ast.Expr) *ast.BinaryExpr {
+	return &ast.BinaryExpr{
+		Span:     ast.Span{Start: left.Pos(), End: right.End()},
+		Operator: operator,
+		Left:     left,
+		Right:    right,
+	}
+}
+
+// Declaration returns the declaration of the variable name of dataType
+func Declaration(dataType lexer.DataType, name string) *ast.VarDecl {
+	return &ast.VarDecl{Type: dataType, Name: Ident(name)}
+}
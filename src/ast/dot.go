@@ -0,0 +1,88 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/dot"
+	"reflect"
+)
+
+// dotBuilder adds the nodes of the tree to a graph
+type dotBuilder struct {
+	graph *dot.Graph
+}
+
+// add adds node and its children to the graph and returns its id
+func (b *dotBuilder) add(node Node) int {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return b.graph.AddNode("nil", dot.Box)
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		id := b.graph.AddNode("Program", dot.Ellipse)
+		for _, declaration := range n.Declarations {
+			b.graph.AddEdge(id, b.add(declaration), "declarations")
+		}
+		b.addStmts(id, n.Body, "body")
+		return id
+	case *VarDecl:
+		id := b.graph.AddNode(fmt.Sprintf("VarDecl\n%s", n.Type), dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Name), "name")
+		return id
+	case *Assign:
+		id := b.graph.AddNode("Assign", dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Target), "target")
+		b.graph.AddEdge(id, b.add(n.Value), "value")
+		return id
+	case *If:
+		id := b.graph.AddNode("If", dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Condition), "condition")
+		b.addStmts(id, n.Body, "body")
+		b.addStmts(id, n.Else, "else")
+		return id
+	case *While:
+		id := b.graph.AddNode("While", dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Condition), "condition")
+		b.addStmts(id, n.Body, "body")
+		return id
+	case *Read:
+		id := b.graph.AddNode("Read", dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Target), "target")
+		return id
+	case *Write:
+		id := b.graph.AddNode("Write", dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Value), "value")
+		return id
+	case *BinaryExpr:
+		id := b.graph.AddNode(fmt.Sprintf("BinaryExpr\n%s", n.Operator), dot.Ellipse)
+		b.graph.AddEdge(id, b.add(n.Left), "left")
+		b.graph.AddEdge(id, b.add(n.Right), "right")
+		return id
+	case *Literal:
+		return b.graph.AddNode(fmt.Sprintf("Literal\n%s", n.Value), dot.Box)
+	case *Ident:
+		return b.graph.AddNode(fmt.Sprintf("Ident\n%s", n.Name), dot.Box)
+	case *BadStmt:
+		return b.graph.AddNode("BadStmt", dot.Box)
+	case *BadExpr:
+		return b.graph.AddNode("BadExpr", dot.Box)
+	default:
+		panic(fmt.Sprintf("ast.EncodeDOT: unexpected node type %T", n))
+	}
+}
+
+func (b *dotBuilder) addStmts(parent int, stmts []Stmt, label string) {
+	for _, stmt := range stmts {
+		b.graph.AddEdge(parent, b.add(stmt), label)
+	}
+}
+
+// EncodeDOT writes the tree rooted at node as a Graphviz graph.
+// Each node is labeled with its kind and its main field, and
+// each edge with the name of the field holding the child
+func EncodeDOT(w io.Writer, node Node) error {
+	builder := &dotBuilder{graph: dot.NewGraph("ast")}
+	builder.add(node)
+	return builder.graph.Write(w)
+}
@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDOT(t *testing.T) {
+	output := &bytes.Buffer{}
+	require.NoError(t, EncodeDOT(output, testProgram()))
+	require.Equal(t, `digraph "ast" {
+	ordering="out";
+	node [fontname="monospace"];
+	n0 [label="Program", shape=ellipse];
+	n1 [label="VarDecl\ninteiro", shape=ellipse];
+	n2 [label="Ident\nA", shape=box];
+	n3 [label="Read", shape=ellipse];
+	n4 [label="Ident\nA", shape=box];
+	n5 [label="If", shape=ellipse];
+	n6 [label="BinaryExpr\n>", shape=ellipse];
+	n7 [label="Ident\nA", shape=box];
+	n8 [label="Literal\n1", shape=box];
+	n9 [label="Write", shape=ellipse];
+	n10 [label="Ident\nA", shape=box];
+	n11 [label="Read", shape=ellipse];
+	n12 [label="Ident\nA", shape=box];
+	n1 -> n2 [label="name"];
+	n0 -> n1 [label="declarations"];
+	n3 -> n4 [label="target"];
+	n0 -> n3 [label="body"];
+	n6 -> n7 [label="left"];
+	n6 -> n8 [label="right"];
+	n5 -> n6 [label="condition"];
+	n9 -> n10 [label="value"];
+	n5 -> n9 [label="body"];
+	n11 -> n12 [label="target"];
+	n5 -> n11 [label="else"];
+	n0 -> n5 [label="body"];
+}
+`, output.String())
+}
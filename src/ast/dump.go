@@ -0,0 +1,99 @@
+package ast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// dumper writes the nodes of the tree, one per line
+type dumper struct {
+	w *bufio.Writer
+}
+
+// node writes node, labeled with field when not empty, and
+// its children one level deeper than depth
+func (d *dumper) node(depth int, field string, node Node) {
+	indent := strings.Repeat("  ", depth)
+	if field != "" {
+		field += ": "
+	}
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		fmt.Fprintf(d.w, "%s%snil\n", indent, field)
+		return
+	}
+
+	line := func(label string) {
+		fmt.Fprintf(d.w, "%s%s%s %s\n", indent, field, label, node.Pos())
+	}
+	switch n := node.(type) {
+	case *Program:
+		line("Program")
+		d.list(depth+1, "declarations", len(n.Declarations), func(index int) Node { return n.Declarations[index] })
+		d.stmts(depth+1, "body", n.Body)
+	case *VarDecl:
+		line("VarDecl " + string(n.Type))
+		d.node(depth+1, "name", n.Name)
+	case *Assign:
+		line("Assign")
+		d.node(depth+1, "target", n.Target)
+		d.node(depth+1, "value", n.Value)
+	case *If:
+		line("If")
+		d.node(depth+1, "condition", n.Condition)
+		d.stmts(depth+1, "body", n.Body)
+		d.stmts(depth+1, "else", n.Else)
+	case *While:
+		line("While")
+		d.node(depth+1, "condition", n.Condition)
+		d.stmts(depth+1, "body", n.Body)
+	case *Read:
+		line("Read")
+		d.node(depth+1, "target", n.Target)
+	case *Write:
+		line("Write")
+		d.node(depth+1, "value", n.Value)
+	case *BinaryExpr:
+		line("BinaryExpr " + n.Operator)
+		d.node(depth+1, "left", n.Left)
+		d.node(depth+1, "right", n.Right)
+	case *Literal:
+		line(fmt.Sprintf("Literal %s %s", n.Type, n.Value))
+	case *Ident:
+		line("Ident " + n.Name)
+	case *BadStmt:
+		line("BadStmt")
+	case *BadExpr:
+		line("BadExpr")
+	default:
+		panic(fmt.Sprintf("ast.Dump: unexpected node type %T", n))
+	}
+}
+
+// list writes the field holding size nodes, each given by at,
+// unless it is empty
+func (d *dumper) list(depth int, field string, size int, at func(index int) Node) {
+	if size == 0 {
+		return
+	}
+	fmt.Fprintf(d.w, "%s%s:\n", strings.Repeat("  ", depth), field)
+	for index := 0; index < size; index++ {
+		d.node(depth+1, "", at(index))
+	}
+}
+
+func (d *dumper) stmts(depth int, field string, stmts []Stmt) {
+	d.list(depth, field, len(stmts), func(index int) Node { return stmts[index] })
+}
+
+// Dump writes the tree rooted at node as indented text, a node per
+// line with its kind, its main field and the position where it
+// starts, and its children below it, each labeled with the name
+// of the field holding it. Empty lists are left out
+func Dump(w io.Writer, node Node) error {
+	d := &dumper{w: bufio.NewWriter(w)}
+	d.node(0, "", node)
+	return d.w.Flush()
+}
@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"bytes"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	program := testProgram()
+	program.Body[0].(*Read).Span = Span{Start: lexer.Position{Line: 4, Column: 3}}
+
+	output := &bytes.Buffer{}
+	require.NoError(t, Dump(output, program))
+	require.Equal(t, `Program 0:0
+  declarations:
+    VarDecl inteiro 0:0
+      name: Ident A 0:0
+  body:
+    Read 4:3
+      target: Ident A 0:0
+    If 0:0
+      condition: BinaryExpr > 0:0
+        left: Ident A 0:0
+        right: Literal inteiro 1 0:0
+      body:
+        Write 0:0
+          value: Ident A 0:0
+      else:
+        Read 0:0
+          target: Ident A 0:0
+`, output.String())
+}
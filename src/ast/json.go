@@ -0,0 +1,106 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// jsonNode is the JSON representation of a node: its kind,
+// its span and its fields. Maps are encoded with sorted
+// keys, so the output is stable
+type jsonNode map[string]interface{}
+
+func newJSONNode(kind string, span Span) jsonNode {
+	return jsonNode{"kind": kind, "span": span}
+}
+
+func stmtsToJSON(stmts []Stmt) []interface{} {
+	nodes := make([]interface{}, len(stmts))
+	for idx, stmt := range stmts {
+		nodes[idx] = toJSON(stmt)
+	}
+	return nodes
+}
+
+func toJSON(node Node) interface{} {
+	// Every node is a pointer, so a missing
+	// child may be a typed nil as well
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		declarations := make([]interface{}, len(n.Declarations))
+		for idx, declaration := range n.Declarations {
+			declarations[idx] = toJSON(declaration)
+		}
+		object := newJSONNode("Program", n.Span)
+		object["declarations"] = declarations
+		object["body"] = stmtsToJSON(n.Body)
+		return object
+	case *VarDecl:
+		object := newJSONNode("VarDecl", n.Span)
+		object["type"] = n.Type
+		object["name"] = toJSON(n.Name)
+		return object
+	case *Assign:
+		object := newJSONNode("Assign", n.Span)
+		object["target"] = toJSON(n.Target)
+		object["value"] = toJSON(n.Value)
+		return object
+	case *If:
+		object := newJSONNode("If", n.Span)
+		object["condition"] = toJSON(n.Condition)
+		object["body"] = stmtsToJSON(n.Body)
+		if n.Else != nil {
+			object["else"] = stmtsToJSON(n.Else)
+		}
+		return object
+	case *While:
+		object := newJSONNode("While", n.Span)
+		object["condition"] = toJSON(n.Condition)
+		object["body"] = stmtsToJSON(n.Body)
+		return object
+	case *Read:
+		object := newJSONNode("Read", n.Span)
+		object["target"] = toJSON(n.Target)
+		return object
+	case *Write:
+		object := newJSONNode("Write", n.Span)
+		object["value"] = toJSON(n.Value)
+		return object
+	case *BinaryExpr:
+		object := newJSONNode("BinaryExpr", n.Span)
+		object["operator"] = n.Operator
+		object["left"] = toJSON(n.Left)
+		object["right"] = toJSON(n.Right)
+		return object
+	case *Literal:
+		object := newJSONNode("Literal", n.Span)
+		object["value"] = n.Value
+		object["type"] = n.Type
+		return object
+	case *Ident:
+		object := newJSONNode("Ident", n.Span)
+		object["name"] = n.Name
+		return object
+	case *BadStmt:
+		return newJSONNode("BadStmt", n.Span)
+	case *BadExpr:
+		return newJSONNode("BadExpr", n.Span)
+	default:
+		panic(fmt.Sprintf("ast.EncodeJSON: unexpected node type %T", n))
+	}
+}
+
+// EncodeJSON writes the tree rooted at node as indented JSON. Each
+// node is an object with its "kind", its "span" and its fields,
+// named as on the Go types in lower case, with keys in sorted order
+func EncodeJSON(w io.Writer, node Node) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toJSON(node))
+}
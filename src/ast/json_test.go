@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"bytes"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	span := func(startLine, startColumn, endLine, endColumn int) Span {
+		return Span{
+			Start: lexer.Position{Line: startLine, Column: startColumn},
+			End:   lexer.Position{Line: endLine, Column: endColumn},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		node         Node
+		expectedJSON string
+	}{
+		{
+			name:         "Nil node",
+			node:         nil,
+			expectedJSON: "null\n",
+		},
+		{
+			name: "Assignment",
+			node: &Assign{
+				Span:   span(1, 1, 1, 7),
+				Target: &Ident{Span: span(1, 1, 1, 1), Name: "A"},
+				Value:  &Literal{Span: span(1, 6, 1, 6), Value: "1", Type: lexer.INTEGER},
+			},
+			expectedJSON: `{
+  "kind": "Assign",
+  "span": {
+    "start": {
+      "line": 1,
+      "column": 1
+    },
+    "end": {
+      "line": 1,
+      "column": 7
+    }
+  },
+  "target": {
+    "kind": "Ident",
+    "name": "A",
+    "span": {
+      "start": {
+        "line": 1,
+        "column": 1
+      },
+      "end": {
+        "line": 1,
+        "column": 1
+      }
+    }
+  },
+  "value": {
+    "kind": "Literal",
+    "span": {
+      "start": {
+        "line": 1,
+        "column": 6
+      },
+      "end": {
+        "line": 1,
+        "column": 6
+      }
+    },
+    "type": "inteiro",
+    "value": "1"
+  }
+}
+`,
+		},
+		{
+			name: "Empty program and missing children",
+			node: &Program{Declarations: []*VarDecl{{Type: lexer.REAL}}},
+			expectedJSON: `{
+  "body": [],
+  "declarations": [
+    {
+      "kind": "VarDecl",
+      "name": null,
+      "span": {
+        "start": {
+          "line": 0,
+          "column": 0
+        },
+        "end": {
+          "line": 0,
+          "column": 0
+        }
+      },
+      "type": "real"
+    }
+  ],
+  "kind": "Program",
+  "span": {
+    "start": {
+      "line": 0,
+      "column": 0
+    },
+    "end": {
+      "line": 0,
+      "column": 0
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			require.NoError(t, EncodeJSON(output, tc.node))
+			require.Equal(t, tc.expectedJSON, output.String())
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// precedences of the binary operators: a higher one binds tighter
+var precedences = map[string]int{
+	"+": 2,
+	"-": 2,
+	"*": 3,
+	"/": 3,
+}
+
+// relationalPrecedence is the precedence of
+// the operators missing on precedences
+const relationalPrecedence = 1
+
+func precedence(operator string) int {
+	if value, found := precedences[operator]; found {
+		return value
+	}
+	return relationalPrecedence
+}
+
+// printer renders the tree back into source,
+// indenting each block with a tab
+type printer struct {
+	builder strings.Builder
+	depth   int
+}
+
+func (p *printer) line(format string, args ...interface{}) {
+	p.builder.WriteString(strings.Repeat("\t", p.depth))
+	fmt.Fprintf(&p.builder, format, args...)
+	p.builder.WriteByte('\n')
+}
+
+func (p *printer) block(stmts []Stmt) {
+	p.depth++
+	for _, stmt := range stmts {
+		p.node(stmt)
+	}
+	p.depth--
+}
+
+func (p *printer) node(node Node) {
+	switch n := node.(type) {
+	case *Program:
+		p.line("inicio")
+		p.line("varinicio")
+		p.depth++
+		for _, declaration := range n.Declarations {
+			p.node(declaration)
+		}
+		p.depth--
+		p.line("varfim;")
+		for _, stmt := range n.Body {
+			p.node(stmt)
+		}
+		p.line("fim")
+	case *VarDecl:
+		p.line("%s %s;", n.Type, n.Name.Name)
+	case *Assign:
+		p.line("%s <- %s;", n.Target.Name, exprSource(n.Value, 0))
+	case *If:
+		p.line("se (%s) entao", exprSource(n.Condition, 0))
+		p.block(n.Body)
+		if n.Else != nil {
+			p.line("senao")
+			p.block(n.Else)
+		}
+		p.line("fimse")
+	case *While:
+		keyword, end := "repita", "fimrepita"
+		if n.Enquanto {
+			keyword, end = "enquanto", "fimenquanto"
+		}
+		p.line("%s (%s)", keyword, exprSource(n.Condition, 0))
+		p.block(n.Body)
+		p.line(end)
+	case *Read:
+		p.line("leia %s;", n.Target.Name)
+	case *Write:
+		p.line("escreva %s;", exprSource(n.Value, 0))
+	case *BadStmt:
+		p.line(badSource)
+	case Expr:
+		p.builder.WriteString(exprSource(n, 0))
+	default:
+		panic(fmt.Sprintf("ast.Fprint: unexpected node type %T", n))
+	}
+}
+
+// badSource is written in place of the nodes that could not be
+// parsed, a comment so the source is still read by the scanner
+const badSource = "{ erro de sintaxe }"
+
+// exprSource writes expr with the parentheses needed for it to be
+// parsed back the same way as an operand of an operation whose
+// precedence is parent. The right operand of an operation is given
+// one more precedence, since operations are left associative
+func exprSource(expr Expr, parent int) string {
+	switch e := expr.(type) {
+	case *BinaryExpr:
+		own := precedence(e.Operator)
+		source := fmt.Sprintf("%s %s %s", exprSource(e.Left, own), e.Operator, exprSource(e.Right, own+1))
+		if own < parent {
+			return "(" + source + ")"
+		}
+		return source
+	case *Literal:
+		return e.Value
+	case *Ident:
+		return e.Name
+	case *BadExpr:
+		return badSource
+	default:
+		panic(fmt.Sprintf("ast.Fprint: unexpected expression type %T", e))
+	}
+}
+
+// Fprint writes node to w as canonical mgol source: one statement
+// per line, the blocks indented with tabs, one space around the
+// operators and only the parentheses the precedence requires.
+// An expression is written without a line break
+func Fprint(w io.Writer, node Node) error {
+	p := &printer{}
+	p.node(node)
+	_, err := io.WriteString(w, p.builder.String())
+	return err
+}
+
+// Header returns the source of stmt on a single line: the
+// statement itself, or the line that opens a se or repita
+func Header(stmt Stmt) string {
+	var source strings.Builder
+	Fprint(&source, stmt)
+	return strings.SplitN(source.String(), "\n", 2)[0]
+}
@@ -0,0 +1,91 @@
+package ast
+
+import (
+	"bytes"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFprint(t *testing.T) {
+	ident := func(name string) *Ident { return &Ident{Name: name} }
+	number := func(value string) *Literal { return &Literal{Value: value, Type: lexer.INTEGER} }
+	binary := func(left Expr, operator string, right Expr) *BinaryExpr {
+		return &BinaryExpr{Operator: operator, Left: left, Right: right}
+	}
+
+	testCases := []struct {
+		name           string
+		node           Node
+		expectedSource string
+	}{
+		{
+			name: "Program",
+			node: testProgram(),
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\n" +
+				"se (A > 1) entao\n\tescreva A;\nsenao\n\tleia A;\nfimse\nfim\n",
+		},
+		{
+			name: "Nested blocks",
+			node: &While{
+				Condition: binary(ident("A"), "<", number("10")),
+				Body: []Stmt{
+					&If{
+						Condition: binary(ident("A"), "<>", number("5")),
+						Body:      []Stmt{&Write{Value: &Literal{Value: "\"a\"", Type: lexer.LITERAL}}},
+					},
+					&Assign{Target: ident("A"), Value: binary(ident("A"), "+", number("1"))},
+				},
+			},
+			expectedSource: "repita (A < 10)\n\tse (A <> 5) entao\n\t\tescreva \"a\";\n\tfimse\n" +
+				"\tA <- A + 1;\nfimrepita\n",
+		},
+		{
+			name: "Error nodes",
+			node: &While{
+				Condition: &BadExpr{},
+				Body:      []Stmt{&BadStmt{}, &Read{Target: ident("A")}},
+			},
+			expectedSource: "repita ({ erro de sintaxe })\n\t{ erro de sintaxe }\n\tleia A;\nfimrepita\n",
+		},
+		{
+			name:           "Empty else",
+			node:           &If{Condition: binary(ident("A"), "=", number("1")), Body: []Stmt{}, Else: []Stmt{}},
+			expectedSource: "se (A = 1) entao\nsenao\nfimse\n",
+		},
+		{
+			name:           "Precedence without parentheses",
+			node:           binary(ident("A"), "+", binary(number("2"), "*", ident("B"))),
+			expectedSource: "A + 2 * B",
+		},
+		{
+			name:           "Precedence with parentheses",
+			node:           binary(binary(ident("A"), "+", number("2")), "*", ident("B")),
+			expectedSource: "(A + 2) * B",
+		},
+		{
+			name:           "Left associative operations",
+			node:           binary(binary(ident("A"), "-", number("1")), "-", number("2")),
+			expectedSource: "A - 1 - 2",
+		},
+		{
+			name:           "Grouping on the right",
+			node:           binary(ident("A"), "-", binary(number("1"), "-", number("2"))),
+			expectedSource: "A - (1 - 2)",
+		},
+		{
+			name:           "Relational operation",
+			node:           binary(binary(ident("A"), "*", number("2")), ">=", binary(ident("B"), "/", number("3"))),
+			expectedSource: "A * 2 >= B / 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			require.NoError(t, Fprint(output, tc.node))
+			require.Equal(t, tc.expectedSource, output.String())
+		})
+	}
+}
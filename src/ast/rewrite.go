@@ -0,0 +1,91 @@
+package ast
+
+import "fmt"
+
+// Rewrite traverses the tree in depth-first order, replacing each
+// node by f(node) after its children were rewritten, so f sees a
+// node whose children are already the ones it returned for them.
+// The fields of the nodes are updated in place and the rewritten
+// root is returned. Returning nil for a statement removes it from
+// its list; the replacement of any other node must have the same
+// kind: an Expr for an Expr, an *Ident for an *Ident, and so on
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		for idx, declaration := range n.Declarations {
+			n.Declarations[idx] = rewriteDecl(declaration, f)
+		}
+		n.Body = rewriteStmts(n.Body, f)
+	case *VarDecl:
+		n.Name = rewriteIdent(n.Name, f)
+	case *Assign:
+		n.Target = rewriteIdent(n.Target, f)
+		n.Value = rewriteExpr(n.Value, f)
+	case *If:
+		n.Condition = rewriteExpr(n.Condition, f)
+		n.Body = rewriteStmts(n.Body, f)
+		if n.Else != nil {
+			n.Else = rewriteStmts(n.Else, f)
+		}
+	case *While:
+		n.Condition = rewriteExpr(n.Condition, f)
+		n.Body = rewriteStmts(n.Body, f)
+	case *Read:
+		n.Target = rewriteIdent(n.Target, f)
+	case *Write:
+		n.Value = rewriteExpr(n.Value, f)
+	case *BinaryExpr:
+		n.Left = rewriteExpr(n.Left, f)
+		n.Right = rewriteExpr(n.Right, f)
+	case *Literal, *Ident, *BadStmt, *BadExpr:
+		// no children
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+	return f(node)
+}
+
+// rewriteStmts rewrites each statement of stmts,
+// leaving out the ones replaced by nil
+func rewriteStmts(stmts []Stmt, f func(Node) Node) []Stmt {
+	rewritten := make([]Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		replacement := Rewrite(stmt, f)
+		if replacement == nil {
+			continue
+		}
+		newStmt, ok := replacement.(Stmt)
+		if !ok {
+			panic(fmt.Sprintf("ast.Rewrite: %T replaced by %T, which is not a statement", stmt, replacement))
+		}
+		rewritten = append(rewritten, newStmt)
+	}
+	return rewritten
+}
+
+func rewriteExpr(expr Expr, f func(Node) Node) Expr {
+	replacement := Rewrite(expr, f)
+	newExpr, ok := replacement.(Expr)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T replaced by %T, which is not an expression", expr, replacement))
+	}
+	return newExpr
+}
+
+func rewriteIdent(ident *Ident, f func(Node) Node) *Ident {
+	replacement := Rewrite(ident, f)
+	newIdent, ok := replacement.(*Ident)
+	if !ok || newIdent == nil {
+		panic(fmt.Sprintf("ast.Rewrite: *ast.Ident replaced by %T", replacement))
+	}
+	return newIdent
+}
+
+func rewriteDecl(declaration *VarDecl, f func(Node) Node) *VarDecl {
+	replacement := Rewrite(declaration, f)
+	newDeclaration, ok := replacement.(*VarDecl)
+	if !ok || newDeclaration == nil {
+		panic(fmt.Sprintf("ast.Rewrite: *ast.VarDecl replaced by %T", replacement))
+	}
+	return newDeclaration
+}
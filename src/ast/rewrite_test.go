@@ -0,0 +1,136 @@
+package ast
+
+import (
+	"bytes"
+	"mgol-go/src/lexer"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// foldConstants replaces the additions of two integer literals by their sum
+func foldConstants(node Node) Node {
+	binary, ok := node.(*BinaryExpr)
+	if !ok || binary.Operator != "+" {
+		return node
+	}
+	left, leftIsLiteral := binary.Left.(*Literal)
+	right, rightIsLiteral := binary.Right.(*Literal)
+	if !leftIsLiteral || !rightIsLiteral || left.Type != lexer.INTEGER || right.Type != lexer.INTEGER {
+		return node
+	}
+	leftValue, _ := strconv.Atoi(left.Value)
+	rightValue, _ := strconv.Atoi(right.Value)
+	return &Literal{Span: binary.Span, Value: strconv.Itoa(leftValue + rightValue), Type: lexer.INTEGER}
+}
+
+func TestRewrite(t *testing.T) {
+	number := func(value string) *Literal { return &Literal{Value: value, Type: lexer.INTEGER} }
+
+	testCases := []struct {
+		name           string
+		node           Node
+		rewrite        func(Node) Node
+		expectedSource string
+	}{
+		{
+			name: "Identity",
+			node: testProgram(),
+			rewrite: func(node Node) Node {
+				return node
+			},
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\n" +
+				"se (A > 1) entao\n\tescreva A;\nsenao\n\tleia A;\nfimse\nfim\n",
+		},
+		{
+			name: "Children are rewritten first",
+			node: &Assign{
+				Target: &Ident{Name: "A"},
+				Value: &BinaryExpr{
+					Operator: "+",
+					Left:     &BinaryExpr{Operator: "+", Left: number("1"), Right: number("2")},
+					Right:    number("3"),
+				},
+			},
+			rewrite:        foldConstants,
+			expectedSource: "A <- 6;\n",
+		},
+		{
+			name: "Renaming identifiers",
+			node: testProgram(),
+			rewrite: func(node Node) Node {
+				if ident, ok := node.(*Ident); ok {
+					return &Ident{Span: ident.Span, Name: ident.Name + "2"}
+				}
+				return node
+			},
+			expectedSource: "inicio\nvarinicio\n\tinteiro A2;\nvarfim;\nleia A2;\n" +
+				"se (A2 > 1) entao\n\tescreva A2;\nsenao\n\tleia A2;\nfimse\nfim\n",
+		},
+		{
+			name: "Removing statements",
+			node: testProgram(),
+			rewrite: func(node Node) Node {
+				if _, ok := node.(*Read); ok {
+					return nil
+				}
+				return node
+			},
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"se (A > 1) entao\n\tescreva A;\nsenao\nfimse\nfim\n",
+		},
+		{
+			name: "Replacing a statement by another",
+			node: &While{
+				Condition: &BinaryExpr{Operator: "<", Left: &Ident{Name: "A"}, Right: number("2")},
+				Body:      []Stmt{&Read{Target: &Ident{Name: "A"}}},
+			},
+			rewrite: func(node Node) Node {
+				if read, ok := node.(*Read); ok {
+					return &Write{Value: read.Target}
+				}
+				return node
+			},
+			expectedSource: "repita (A < 2)\n\tescreva A;\nfimrepita\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			require.NoError(t, Fprint(output, Rewrite(tc.node, tc.rewrite)))
+			require.Equal(t, tc.expectedSource, output.String())
+		})
+	}
+}
+
+func TestRewriteKeepsKinds(t *testing.T) {
+	// An expression can not take the place of a statement
+	require.Panics(t, func() {
+		Rewrite(testProgram(), func(node Node) Node {
+			if _, ok := node.(*Read); ok {
+				return &Ident{Name: "A"}
+			}
+			return node
+		})
+	})
+	// nor be removed
+	require.Panics(t, func() {
+		Rewrite(testProgram(), func(node Node) Node {
+			if _, ok := node.(*Literal); ok {
+				return nil
+			}
+			return node
+		})
+	})
+	// and an identifier must stay an identifier
+	require.Panics(t, func() {
+		Rewrite(testProgram(), func(node Node) Node {
+			if _, ok := node.(*Ident); ok {
+				return &Literal{Value: "1", Type: lexer.INTEGER}
+			}
+			return node
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package ast
+
+import "fmt"
+
+// Visitor has its Visit method called for each node found by Walk.
+// If the returned visitor w is not nil, Walk visits each of the
+// children of node with w, followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+// Walk traverses the tree in depth-first order: it starts by
+// calling v.Visit(node) and then walks the children of node,
+// in the order they appear on the source
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, declaration := range n.Declarations {
+			Walk(v, declaration)
+		}
+		walkStmts(v, n.Body)
+	case *VarDecl:
+		Walk(v, n.Name)
+	case *Assign:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+	case *If:
+		Walk(v, n.Condition)
+		walkStmts(v, n.Body)
+		walkStmts(v, n.Else)
+	case *While:
+		Walk(v, n.Condition)
+		walkStmts(v, n.Body)
+	case *Read:
+		Walk(v, n.Target)
+	case *Write:
+		Walk(v, n.Value)
+	case *BinaryExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *Literal, *Ident, *BadStmt, *BadExpr:
+		// no children
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree in depth-first order calling f(node)
+// for each node. If f returns true, Inspect also visits the
+// children of node, followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// CountNodes returns how many nodes the tree of node has, node included
+func CountNodes(node Node) int {
+	nodes := 0
+	Inspect(node, func(node Node) bool {
+		if node != nil {
+			nodes++
+		}
+		return true
+	})
+	return nodes
+}
@@ -0,0 +1,108 @@
+package ast
+
+import (
+	"fmt"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testProgram is the tree of:
+//
+//	inicio varinicio inteiro A; varfim;
+//	leia A;
+//	se (A > 1) entao escreva A; senao leia A; fimse
+//	fim
+func testProgram() *Program {
+	return &Program{
+		Declarations: []*VarDecl{
+			{Type: lexer.INTEGER, Name: &Ident{Name: "A"}},
+		},
+		Body: []Stmt{
+			&Read{Target: &Ident{Name: "A"}},
+			&If{
+				Condition: &BinaryExpr{
+					Operator: ">",
+					Left:     &Ident{Name: "A"},
+					Right:    &Literal{Value: "1", Type: lexer.INTEGER},
+				},
+				Body: []Stmt{
+					&Write{Value: &Ident{Name: "A"}},
+				},
+				Else: []Stmt{
+					&Read{Target: &Ident{Name: "A"}},
+				},
+			},
+		},
+	}
+}
+
+func nodeName(node Node) string {
+	if node == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T", node)[len("*ast."):]
+}
+
+type recorder struct {
+	visited *[]string
+}
+
+func (r recorder) Visit(node Node) Visitor {
+	*r.visited = append(*r.visited, nodeName(node))
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	visited := []string{}
+	Walk(recorder{&visited}, testProgram())
+
+	require.Equal(t, []string{
+		"Program",
+		"VarDecl", "Ident", "nil", "nil",
+		"Read", "Ident", "nil", "nil",
+		"If",
+		"BinaryExpr", "Ident", "nil", "Literal", "nil", "nil",
+		"Write", "Ident", "nil", "nil",
+		"Read", "Ident", "nil", "nil",
+		"nil",
+		"nil",
+	}, visited)
+}
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		name          string
+		skip          string
+		expectedNodes []string
+	}{
+		{
+			name:          "Visit every node",
+			expectedNodes: []string{"Program", "VarDecl", "Ident", "Read", "Ident", "If", "BinaryExpr", "Ident", "Literal", "Write", "Ident", "Read", "Ident"},
+		},
+		{
+			name:          "Skip the children of the conditionals",
+			skip:          "If",
+			expectedNodes: []string{"Program", "VarDecl", "Ident", "Read", "Ident", "If"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := []string{}
+			Inspect(testProgram(), func(node Node) bool {
+				if node == nil {
+					return false
+				}
+				nodes = append(nodes, nodeName(node))
+				return nodeName(node) != tc.skip
+			})
+			require.Equal(t, tc.expectedNodes, nodes)
+		})
+	}
+}
+
+func TestCountNodes(t *testing.T) {
+	require.Equal(t, 13, CountNodes(testProgram()))
+}
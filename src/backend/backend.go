@@ -0,0 +1,101 @@
+// Package backend is the registry of the targets a program can be
+// generated for. Each target registers itself when its package is
+// imported, so one defined out of this tree is selected by name like
+// the others:
+//
+//	import _ "example.com/mgol-python"
+//
+//	target, found := backend.Lookup("python")
+package backend
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/ir"
+	"mgol-go/src/sem"
+	"sort"
+	"sync"
+)
+
+var ErrorDuplicateBackend = fmt.Errorf("já há um alvo com esse nome")
+
+// Backend generates the source of a program for a target
+type Backend interface {
+	// Name is how the target is selected, like "go"
+	Name() string
+	// FileExtension is the one of the files generated, like ".go"
+	FileExtension() string
+	// Generate writes program, whose types are on info. The program
+	// must have been checked by the sem package without errors
+	Generate(program *ast.Program, info *sem.Info, w io.Writer) error
+}
+
+// WithRuntime is implemented by the backends whose output runs
+// along with fixed files, like the JavaScript loading a module
+type WithRuntime interface {
+	Backend
+	// Runtime returns the contents of each of those files by name
+	Runtime() map[string]string
+}
+
+// FromIR is implemented by the backends that generate the program
+// from its three-address code, so it can be optimized before
+type FromIR interface {
+	Backend
+	// GenerateIR writes program like Generate writes it once lowered
+	GenerateIR(program *ir.Program, w io.Writer) error
+}
+
+// Options changes how a program is generated, on the
+// targets that implement Configurable
+type Options struct {
+	// DecimalComma makes escreva write the reals with a comma between
+	// the integer and the fractional digits, like 3,140000, as written
+	// in Brazil, instead of the point the automated judges expect
+	DecimalComma bool
+}
+
+// Configurable is implemented by the backends that follow Options
+type Configurable interface {
+	Backend
+	// WithOptions returns the backend generating with options
+	WithOptions(options Options) Backend
+}
+
+var (
+	mutex    sync.RWMutex
+	backends = make(map[string]Backend)
+)
+
+// Register adds backend to the registry. It is meant to be called by
+// the init function of the package of the backend, so it panics when
+// the name was already registered
+func Register(backend Backend) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, found := backends[backend.Name()]; found {
+		panic(fmt.Errorf("%w: %s", ErrorDuplicateBackend, backend.Name()))
+	}
+	backends[backend.Name()] = backend
+}
+
+// Lookup returns the backend registered with name
+func Lookup(name string) (Backend, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	backend, found := backends[name]
+	return backend, found
+}
+
+// Names returns the names of the registered backends, sorted
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
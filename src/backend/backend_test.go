@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"errors"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	name string
+}
+
+func (f fakeBackend) Name() string          { return f.name }
+func (f fakeBackend) FileExtension() string { return ".txt" }
+
+func (f fakeBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	_, err := io.WriteString(w, f.name)
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	Register(fakeBackend{"fake-b"})
+	Register(fakeBackend{"fake-a"})
+
+	registered, found := Lookup("fake-a")
+	require.True(t, found)
+	require.Equal(t, "fake-a", registered.Name())
+	_, found = Lookup("fake-c")
+	require.False(t, found)
+	require.Equal(t, []string{"fake-a", "fake-b"}, Names())
+
+	defer func() {
+		err, isError := recover().(error)
+		require.True(t, isError)
+		require.True(t, errors.Is(err, ErrorDuplicateBackend))
+	}()
+	Register(fakeBackend{"fake-a"})
+}
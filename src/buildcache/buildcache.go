@@ -0,0 +1,423 @@
+// Package buildcache keeps what building a program gave, keyed by the
+// hash of its sources, so a build of sources already built, like the
+// programs of a batch left unchanged while -watch rebuilds another, or
+// a submission sent again, reads it instead:
+//
+//	cache, err := buildcache.Open(buildcache.DefaultDir())
+//	key := buildcache.KeyOf([]string{"target=python"}, buildcache.Source{Name: path, Text: text})
+//	entry, found := cache.Get(key)
+//	if !found {
+//		entry = build(text)
+//		err = cache.Put(key, entry)
+//	}
+//	err = cache.Close()
+//
+// The key also hashes the compiler running, its version and its
+// executable, so the entries of another compiler are never read. An
+// entry is a file of its own on the directory, written whole before
+// it is renamed there, so several builds can share the directory
+package buildcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/version"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrorInvalidKey = fmt.Errorf("chave de cache inválida")
+
+// Key is the hexadecimal SHA-256 of the sources of
+// a build and of everything else that changes it
+type Key string
+
+// Source is a file of the program built
+type Source struct {
+	Name string
+	Text string
+}
+
+// KeyOf returns the key of the build of sources with settings, one
+// for each option that changes what is built, like "target=python"
+func KeyOf(settings []string, sources ...Source) Key {
+	digest := sha256.New()
+	info := version.Get()
+	fmt.Fprintf(digest, "%s\n%s\n%d\n%s\n%s\n", info.Version, info.Commit, info.Language, info.Grammar, compilerDigest())
+	for _, setting := range settings {
+		fmt.Fprintf(digest, "%q\n", setting)
+	}
+	// The lengths keep the sources from running into each other
+	for _, source := range sources {
+		fmt.Fprintf(digest, "%q %d\n%s", source.Name, len(source.Text), source.Text)
+	}
+	return Key(hex.EncodeToString(digest.Sum(nil)))
+}
+
+var (
+	compilerOnce   sync.Once
+	compilerHashed string
+)
+
+// compilerDigest returns the SHA-256 of the executable running,
+// which changes whenever the compiler is built from other sources,
+// even while its version is still unknown
+func compilerDigest() string {
+	compilerOnce.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		digest := sha256.New()
+		if _, err := io.Copy(digest, file); err == nil {
+			compilerHashed = hex.EncodeToString(digest.Sum(nil))
+		}
+	})
+	return compilerHashed
+}
+
+// Entry is what a build gave
+type Entry struct {
+	// Tokens and AST are the token stream and the syntax tree as
+	// they were written, in the format the build was asked for
+	Tokens      []byte                     `json:"tokens,omitempty"`
+	AST         []byte                     `json:"ast,omitempty"`
+	Diagnostics []errorhandling.Diagnostic `json:"diagnostics"`
+	// Listing is the listing of the compilation, when it was asked for
+	Listing []byte `json:"listing,omitempty"`
+	// Status is the one of the exitcode package the program was
+	// rejected with, or exitcode.Success
+	Status int `json:"status"`
+	// Output is the program generated, when it was not rejected
+	Output []byte `json:"output,omitempty"`
+	// IR is its three-address code, when it was asked for
+	IR []byte `json:"ir,omitempty"`
+	// Runtime holds the files the program runs along with, by name
+	Runtime map[string]string `json:"runtime,omitempty"`
+}
+
+// Stats tells how much the cache holds and how often it was used
+type Stats struct {
+	// Hits and Misses count the entries found and not found by Get,
+	// since the cache was last cleaned
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Entries and Bytes are what the cache holds now
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Cache holds the entries on a directory or, for NewMemory, in
+// memory. It is safe for concurrent use
+type Cache struct {
+	dir   string
+	mutex sync.Mutex
+	// memory holds the elements of recent by their keys, the
+	// entries used last being at the front of recent
+	memory map[Key]*list.Element
+	recent *list.List
+	// bytes is what the entries in memory take, which
+	// are dropped over maxBytes when it is not 0
+	bytes, maxBytes int64
+	// hits and misses are the ones since the cache was opened,
+	// added to the ones of the directory by Close
+	hits, misses int64
+}
+
+// statsFile holds the hits and misses of the directory
+const statsFile = "stats.json"
+
+// DefaultDir returns the directory of the cache of the user, like
+// ~/.cache/mgol, or one on the temporary directory when the system
+// tells none
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "mgol")
+}
+
+// Open returns the cache on dir, creating it when missing
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// memoryEntry is an entry of a cache in memory
+type memoryEntry struct {
+	key   Key
+	entry Entry
+}
+
+// NewMemory returns a cache held in memory, lost when the process ends
+func NewMemory() *Cache {
+	return NewBoundedMemory(0)
+}
+
+// NewBoundedMemory returns a cache held in memory like NewMemory,
+// which drops the entries used least recently once they take more
+// than maxBytes, as told by Stats, or never when it is 0
+func NewBoundedMemory(maxBytes int64) *Cache {
+	return &Cache{memory: make(map[Key]*list.Element), recent: list.New(), maxBytes: maxBytes}
+}
+
+// size returns the bytes entry takes in memory, the ones of its
+// code and of the files of its runtime
+func size(entry Entry) int64 {
+	bytes := int64(len(entry.Tokens) + len(entry.AST) + len(entry.Output))
+	for name, file := range entry.Runtime {
+		bytes += int64(len(name) + len(file))
+	}
+	return bytes
+}
+
+// Dir returns the directory of the cache, empty for one in memory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// path returns the file of the entry of key, on a directory named by
+// its first two digits so that none holds too many of them
+func (c *Cache) path(key Key) (string, error) {
+	if len(key) != sha256.Size*2 || strings.Trim(string(key), "0123456789abcdef") != "" {
+		return "", fmt.Errorf("%w: %q", ErrorInvalidKey, key)
+	}
+	return filepath.Join(c.dir, string(key[:2]), string(key)+".json"), nil
+}
+
+// Get returns the entry of key, if the cache holds it. An entry that
+// can not be read, like one cut short, is taken as missing
+func (c *Cache) Get(key Key) (Entry, bool) {
+	entry, found := c.get(key)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if found {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return entry, found
+}
+
+func (c *Cache) get(key Key) (Entry, bool) {
+	if c.memory != nil {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		element, found := c.memory[key]
+		if !found {
+			return Entry{}, false
+		}
+		c.recent.MoveToFront(element)
+		return element.Value.(*memoryEntry).entry, true
+	}
+	path, err := c.path(key)
+	if err != nil {
+		return Entry{}, false
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		return Entry{}, false
+	}
+	// Clean tells the entries not used for a while by their time
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return entry, true
+}
+
+// Put stores entry as the one of key
+func (c *Cache) Put(key Key, entry Entry) error {
+	if c.memory != nil {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if element, found := c.memory[key]; found {
+			c.remove(element)
+		}
+		c.memory[key] = c.recent.PushFront(&memoryEntry{key, entry})
+		c.bytes += size(entry)
+		for c.maxBytes > 0 && c.bytes > c.maxBytes {
+			c.remove(c.recent.Back())
+		}
+		return nil
+	}
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeAtomically(path, contents)
+}
+
+// remove drops the entry of element from memory
+func (c *Cache) remove(element *list.Element) {
+	removed := c.recent.Remove(element).(*memoryEntry)
+	delete(c.memory, removed.key)
+	c.bytes -= size(removed.entry)
+}
+
+// writeAtomically writes contents on a temporary file renamed to
+// path, so that no build reads the file while it is written
+func writeAtomically(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// entries calls visit with the path and the information of each
+// entry on the directory
+func (c *Cache) entries(visit func(path string, info os.FileInfo) error) error {
+	return filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Dir(filepath.Dir(path)) != filepath.Clean(c.dir) || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		return visit(path, info)
+	})
+}
+
+// Stats returns how much the cache holds and how often it was used,
+// the uses of the builds that closed the directory included
+func (c *Cache) Stats() (Stats, error) {
+	c.mutex.Lock()
+	stats := Stats{Hits: c.hits, Misses: c.misses}
+	if c.memory != nil {
+		defer c.mutex.Unlock()
+		stats.Entries = len(c.memory)
+		stats.Bytes = c.bytes
+		return stats, nil
+	}
+	c.mutex.Unlock()
+
+	saved, err := c.readStats()
+	if err != nil {
+		return Stats{}, err
+	}
+	stats.Hits += saved.Hits
+	stats.Misses += saved.Misses
+	err = c.entries(func(path string, info os.FileInfo) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// readStats returns the hits and misses saved on the directory
+func (c *Cache) readStats() (Stats, error) {
+	var stats Stats
+	contents, err := ioutil.ReadFile(filepath.Join(c.dir, statsFile))
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := json.Unmarshal(contents, &stats); err != nil {
+		// The counts are only informative, so they start again
+		return Stats{}, nil
+	}
+	return stats, nil
+}
+
+// Clean removes the entries not used for olderThan, or all of them,
+// and the counts of hits and misses along, when it is 0. A cache in
+// memory is always emptied whole. It returns how many entries were
+// removed
+func (c *Cache) Clean(olderThan time.Duration) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.memory != nil {
+		removed := len(c.memory)
+		c.memory = make(map[Key]*list.Element)
+		c.recent.Init()
+		c.bytes = 0
+		c.hits, c.misses = 0, 0
+		return removed, nil
+	}
+
+	removed := 0
+	deadline := time.Now().Add(-olderThan)
+	err := c.entries(func(path string, info os.FileInfo) error {
+		if olderThan > 0 && info.ModTime().After(deadline) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil || olderThan > 0 {
+		return removed, err
+	}
+	c.hits, c.misses = 0, 0
+	if err := os.Remove(filepath.Join(c.dir, statsFile)); err != nil && !os.IsNotExist(err) {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// Close saves the hits and misses since the cache was opened on its
+// directory, to be told by Stats later
+func (c *Cache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.memory != nil || (c.hits == 0 && c.misses == 0) {
+		return nil
+	}
+	saved, err := c.readStats()
+	if err != nil {
+		return err
+	}
+	saved.Hits += c.hits
+	saved.Misses += c.misses
+	contents, err := json.Marshal(Stats{Hits: saved.Hits, Misses: saved.Misses})
+	if err != nil {
+		return err
+	}
+	c.hits, c.misses = 0, 0
+	return writeAtomically(filepath.Join(c.dir, statsFile), contents)
+}
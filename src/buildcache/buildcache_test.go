@@ -0,0 +1,153 @@
+package buildcache
+
+import (
+	"io/ioutil"
+	errorhandling "mgol-go/src/error_handling"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyOf(t *testing.T) {
+	source := Source{"a.mgol", "inicio varinicio varfim; fim"}
+	key := KeyOf([]string{"target=python"}, source)
+	require.Len(t, key, 64)
+	require.Equal(t, key, KeyOf([]string{"target=python"}, source))
+
+	for _, other := range []Key{
+		KeyOf([]string{"target=go"}, source),
+		KeyOf(nil, source),
+		KeyOf([]string{"target=python"}, Source{"a.mgol", "inicio varinicio varfim; fim\n"}),
+		KeyOf([]string{"target=python"}, Source{"b.mgol", source.Text}),
+		KeyOf([]string{"target=python"}, Source{"a.mgol", "inicio"}, Source{"", " varinicio varfim; fim"}),
+	} {
+		require.False(t, key == other)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildcache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	entry := Entry{
+		Tokens:      []byte("id,A\n"),
+		Diagnostics: []errorhandling.Diagnostic{errorhandling.NewDiagnostic(errorhandling.Warning, 1, 2, "aviso")},
+		Output:      []byte("print(1)\n"),
+		Runtime:     map[string]string{"runtime.js": "//"},
+	}
+	first := KeyOf(nil, Source{Text: "1"})
+	second := KeyOf(nil, Source{Text: "2"})
+
+	for name, open := range map[string]func() *Cache{
+		"directory": func() *Cache {
+			cache, err := Open(dir)
+			require.NoError(t, err)
+			return cache
+		},
+		"memory": NewMemory,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cache := open()
+			_, found := cache.Get(first)
+			require.False(t, found)
+			require.NoError(t, cache.Put(first, entry))
+			read, found := cache.Get(first)
+			require.True(t, found)
+			require.Equal(t, entry, read)
+			require.NoError(t, cache.Put(second, Entry{Status: 1}))
+			require.NoError(t, cache.Close())
+
+			stats, err := cache.Stats()
+			require.NoError(t, err)
+			require.Equal(t, int64(1), stats.Hits)
+			require.Equal(t, int64(1), stats.Misses)
+			require.Equal(t, 2, stats.Entries)
+			require.True(t, stats.Bytes > 0)
+
+			removed, err := cache.Clean(0)
+			require.NoError(t, err)
+			require.Equal(t, 2, removed)
+			stats, err = cache.Stats()
+			require.NoError(t, err)
+			require.Equal(t, Stats{}, stats)
+		})
+	}
+}
+
+func TestBoundedMemory(t *testing.T) {
+	cache := NewBoundedMemory(8)
+	first := KeyOf(nil, Source{Text: "1"})
+	second := KeyOf(nil, Source{Text: "2"})
+	third := KeyOf(nil, Source{Text: "3"})
+	require.NoError(t, cache.Put(first, Entry{Output: []byte("abc")}))
+	require.NoError(t, cache.Put(second, Entry{Output: []byte("def")}))
+	// Reading first leaves second as the one used least recently
+	_, found := cache.Get(first)
+	require.True(t, found)
+	require.NoError(t, cache.Put(third, Entry{Output: []byte("ghi")}))
+
+	_, found = cache.Get(second)
+	require.False(t, found)
+	for _, key := range []Key{first, third} {
+		_, found = cache.Get(key)
+		require.True(t, found)
+	}
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Entries)
+	require.Equal(t, int64(6), stats.Bytes)
+
+	// An entry larger than the bound is not kept at all
+	require.NoError(t, cache.Put(first, Entry{Output: []byte("abcdefghi")}))
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 0, stats.Entries)
+	require.Equal(t, int64(0), stats.Bytes)
+}
+
+func TestCleanOlderThan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildcache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache, err := Open(dir)
+	require.NoError(t, err)
+
+	old := KeyOf(nil, Source{Text: "antigo"})
+	recent := KeyOf(nil, Source{Text: "recente"})
+	require.NoError(t, cache.Put(old, Entry{}))
+	require.NoError(t, cache.Put(recent, Entry{}))
+	path, err := cache.path(old)
+	require.NoError(t, err)
+	long := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(path, long, long))
+
+	removed, err := cache.Clean(24 * time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	_, found := cache.Get(old)
+	require.False(t, found)
+	_, found = cache.Get(recent)
+	require.True(t, found)
+}
+
+func TestInvalidEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildcache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache, err := Open(dir)
+	require.NoError(t, err)
+
+	key := KeyOf(nil, Source{Text: "cortado"})
+	path, err := cache.path(key)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"tokens":`), 0644))
+	_, found := cache.Get(key)
+	require.False(t, found)
+
+	require.ErrorIs(t, cache.Put("../fora", Entry{}), ErrorInvalidKey)
+}
@@ -0,0 +1,263 @@
+package bytecode
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidListing = fmt.Errorf("listagem de bytecode inválida")
+
+// typeNames holds how the type of a global is written, and
+// dataTypes the type of each of those names
+var (
+	typeNames = map[lexer.DataType]string{
+		lexer.INTEGER: "inteiro",
+		lexer.REAL:    "real",
+		lexer.LITERAL: "literal",
+	}
+	dataTypes = map[string]lexer.DataType{
+		"inteiro": lexer.INTEGER,
+		"real":    lexer.REAL,
+		"literal": lexer.LITERAL,
+	}
+)
+
+// mnemonics holds the opcode of each mnemonic
+var mnemonics = func() map[string]Opcode {
+	byName := make(map[string]Opcode)
+	for op, opcode := range opcodes {
+		byName[opcode.mnemonic] = Opcode(op)
+	}
+	return byName
+}()
+
+// Disassemble writes program as text, which Assemble reads back: the
+// globals, each on a line with its type and name, the number of locals
+// and then the instructions. The globals are written by their names,
+// the constants by their values and the addresses the jumps go to as
+// labels, named after them:
+//
+//	global inteiro A
+//	locals 1
+//
+//	L0:
+//		load A
+//		int 0
+//		cmp.inteiro
+//		jump.unless > L26
+//		...
+func Disassemble(w io.Writer, program *Program) error {
+	instructions, err := program.Instructions()
+	if err != nil {
+		return err
+	}
+	targets := make(map[int]bool)
+	for _, instruction := range instructions {
+		if instruction.Op == Jump || instruction.Op == JumpUnless {
+			targets[int(instruction.Operand)] = true
+		}
+	}
+
+	var listing strings.Builder
+	for _, global := range program.Globals {
+		fmt.Fprintf(&listing, "global %s %s\n", typeNames[global.Type], global.Name)
+	}
+	fmt.Fprintf(&listing, "locals %d\n\n", program.Locals)
+	for _, instruction := range instructions {
+		if targets[instruction.Address] {
+			fmt.Fprintf(&listing, "L%d:\n", instruction.Address)
+		}
+		listing.WriteString("\t" + instruction.Op.String())
+		switch instruction.Op {
+		case PushInt, LoadLocal, StoreLocal:
+			fmt.Fprintf(&listing, " %d", instruction.Operand)
+		case PushConst:
+			listing.WriteString(" " + constantText(program.Constants[instruction.Operand]))
+		case Load, Store:
+			listing.WriteString(" " + program.Globals[instruction.Operand].Name)
+		case Jump:
+			fmt.Fprintf(&listing, " L%d", instruction.Operand)
+		case JumpUnless:
+			fmt.Fprintf(&listing, " %s L%d", instruction.Relation, instruction.Operand)
+		}
+		listing.WriteByte('\n')
+	}
+	if targets[len(program.Code)] {
+		fmt.Fprintf(&listing, "L%d:\n", len(program.Code))
+	}
+	_, err = io.WriteString(w, listing.String())
+	return err
+}
+
+// constantText writes a constant as on the listing, the literals quoted
+func constantText(constant Constant) string {
+	if constant.Type == lexer.LITERAL {
+		return strconv.Quote(constant.Literal)
+	}
+	return strconv.FormatFloat(constant.Real, 'g', -1, 64)
+}
+
+// Assemble reads a program written like Disassemble writes it. The
+// lines starting with ; are comments, and so is the rest of a line
+// after a ; out of a literal. The constants are numbered in the order
+// they first appear, so the listing of a program assembles back to it
+func Assemble(listing string) (*Program, error) {
+	a := &assembler{builder: newBuilder(&Program{}), globals: make(map[string]int)}
+	for number, line := range strings.Split(listing, "\n") {
+		fields, err := splitFields(line)
+		if err == nil && len(fields) > 0 {
+			err = a.line(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %s: %v", ErrorInvalidListing, number+1, strings.TrimSpace(line), err)
+		}
+	}
+	return a.finish()
+}
+
+// splitFields splits line on its spaces, but the ones of the
+// literals, dropping the comment at its end, if any
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	for line = strings.TrimSpace(line); line != "" && line[0] != ';'; line = strings.TrimSpace(line) {
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t;")
+			if end < 0 {
+				end = len(line)
+			}
+			fields = append(fields, line[:end])
+			line = line[end:]
+			continue
+		}
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, fmt.Errorf("literal sem fim")
+		}
+		fields = append(fields, line[:end+1])
+		line = line[end+1:]
+	}
+	return fields, nil
+}
+
+// assembler reads the lines of a listing
+type assembler struct {
+	*builder
+	globals map[string]int
+}
+
+func (a *assembler) line(fields []string) error {
+	if len(fields) == 1 && strings.HasSuffix(fields[0], ":") {
+		return a.mark(strings.TrimSuffix(fields[0], ":"))
+	}
+	switch fields[0] {
+	case "global":
+		dataType, found := dataTypes[safeField(fields, 1)]
+		if len(fields) != 3 || !found {
+			return fmt.Errorf("esperado global, o tipo e o nome")
+		}
+		if _, found := a.globals[fields[2]]; found {
+			return fmt.Errorf("variável %s repetida", fields[2])
+		}
+		a.globals[fields[2]] = len(a.program.Globals)
+		a.program.Globals = append(a.program.Globals, Global{fields[2], dataType})
+		return nil
+	case "locals":
+		locals, err := number(fields, math.MaxUint16)
+		a.program.Locals = int(locals)
+		return err
+	}
+
+	op, found := mnemonics[fields[0]]
+	if !found {
+		return fmt.Errorf("instrução %s desconhecida", fields[0])
+	}
+	switch op {
+	case PushInt:
+		if len(fields) != 2 {
+			return fmt.Errorf("esperado um inteiro")
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 32)
+		if err != nil {
+			return err
+		}
+		a.emit(op, value, 0, "")
+	case PushConst:
+		constant, err := parseConstant(fields)
+		if err != nil {
+			return err
+		}
+		a.emit(op, a.constant(constant), 0, "")
+	case Load, Store:
+		global, found := a.globals[safeField(fields, 1)]
+		if len(fields) != 2 || !found {
+			return fmt.Errorf("variável %s inexistente", safeField(fields, 1))
+		}
+		a.emit(op, int64(global), 0, "")
+	case LoadLocal, StoreLocal:
+		local, err := number(fields, a.program.Locals-1)
+		if err != nil {
+			return err
+		}
+		a.emit(op, local, 0, "")
+	case Jump:
+		if len(fields) != 2 {
+			return fmt.Errorf("esperado o rótulo")
+		}
+		a.emit(op, 0, 0, fields[1])
+	case JumpUnless:
+		relation, found := ParseRelation(safeField(fields, 1))
+		if len(fields) != 3 || !found {
+			return fmt.Errorf("esperado o operador relacional e o rótulo")
+		}
+		a.emit(op, 0, relation, fields[2])
+	default:
+		if len(fields) != 1 {
+			return fmt.Errorf("%s não tem operandos", op)
+		}
+		a.emit(op, 0, 0, "")
+	}
+	return nil
+}
+
+// safeField returns the field at position, or "" past the last one
+func safeField(fields []string, position int) string {
+	if position < len(fields) {
+		return fields[position]
+	}
+	return ""
+}
+
+// number reads the only operand of a line, a number from 0 to maximum
+func number(fields []string, maximum int) (int64, error) {
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("esperado um número")
+	}
+	value, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || value < 0 || value > int64(maximum) {
+		return 0, fmt.Errorf("esperado um número de 0 a %d", maximum)
+	}
+	return value, nil
+}
+
+// parseConstant reads the operand of const, a literal when quoted
+func parseConstant(fields []string) (Constant, error) {
+	if len(fields) != 2 {
+		return Constant{}, fmt.Errorf("esperada a constante")
+	}
+	if strings.HasPrefix(fields[1], "\"") {
+		text, err := strconv.Unquote(fields[1])
+		return Constant{Type: lexer.LITERAL, Literal: text}, err
+	}
+	value, err := strconv.ParseFloat(fields[1], 64)
+	return Constant{Type: lexer.REAL, Real: value}, err
+}
@@ -0,0 +1,40 @@
+package bytecode
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(bytecodeBackend{})
+}
+
+// bytecodeBackend writes the program compiled to bytecode, as a file
+type bytecodeBackend struct{}
+
+func (bytecodeBackend) Name() string {
+	return "bytecode"
+}
+
+func (bytecodeBackend) FileExtension() string {
+	return ".mgb"
+}
+
+func (b bytecodeBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	lowered, err := ir.Lower(program, info)
+	if err != nil {
+		return err
+	}
+	return b.GenerateIR(lowered, w)
+}
+
+func (bytecodeBackend) GenerateIR(lowered *ir.Program, w io.Writer) error {
+	program, err := Compile(lowered)
+	if err != nil {
+		return err
+	}
+	return Encode(w, program)
+}
@@ -0,0 +1,465 @@
+// Package bytecode is the instruction set of the virtual machine of
+// mgol, compiled from the three-address code of the ir package. The
+// machine works on a stack of values: the instructions push constants
+// and variables on it, operate on the values at its top and store
+// them back, like on
+//
+//	load A
+//	int 1
+//	add.inteiro
+//	store A
+//
+// The variables of the program are globals, by their index, and the
+// temporaries of the three-address code are the locals of the frame
+// the program runs on. Each instruction is an opcode byte followed by
+// its operand, if any, in little endian. Assemble and Disassemble read
+// and write the instructions as text, and Encode and Decode the whole
+// program as a file
+package bytecode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"mgol-go/src/lexer"
+	"sort"
+)
+
+var (
+	ErrorInvalidBytecode = fmt.Errorf("bytecode inválido")
+	ErrorTooLarge        = fmt.Errorf("programa grande demais para o bytecode")
+)
+
+// Opcode is the first byte of an instruction. The numbers are part of
+// the file format, so a new opcode is added at the end
+type Opcode byte
+
+const (
+	// Halt ends the program
+	Halt Opcode = iota
+	// PushInt pushes its operand, an inteiro
+	PushInt
+	// PushConst pushes the constant of index operand, a real or a literal
+	PushConst
+	// Load pushes the global of index operand, and Store pops into it
+	Load
+	Store
+	// LoadLocal pushes the local of index operand, and StoreLocal pops into it
+	LoadLocal
+	StoreLocal
+	// The arithmetic instructions pop the right operand and then the
+	// left one, pushing the result. The division of inteiros drops the
+	// fractional part
+	AddInt
+	SubInt
+	MulInt
+	DivInt
+	AddReal
+	SubReal
+	MulReal
+	DivReal
+	// ToReal converts the inteiro at the top to a real, and ToInt
+	// a real to an inteiro, dropping its fractional part
+	ToReal
+	ToInt
+	// The comparisons pop the right operand and then the left one,
+	// pushing an inteiro, their order: -1 when the left one comes
+	// before, 0 when they are equal and 1 when it comes after. Two
+	// reals are Unordered when either is nan
+	CmpInt
+	CmpReal
+	CmpLiteral
+	// Jump goes on to the address of its operand
+	Jump
+	// JumpUnless pops an order and goes on to its address unless
+	// its Relation holds for it
+	JumpUnless
+	// The reads push a value read from the input, and
+	// the writes pop one and write it on the output
+	ReadInt
+	ReadReal
+	ReadLiteral
+	WriteInt
+	WriteReal
+	WriteLiteral
+)
+
+// Unordered is the order of two reals when either is nan,
+// for which no Relation holds but NotEqual
+const Unordered = 2
+
+// operandKind tells what follows an opcode
+type operandKind int
+
+const (
+	noOperand operandKind = iota
+	// immediate is an inteiro, on 4 bytes
+	immediate
+	// index is the index of a global, local or constant, on 2 bytes
+	index
+	// address is the address of an instruction, on 4 bytes
+	address
+	// relationAddress is a Relation, on a byte, and an address
+	relationAddress
+)
+
+// operandSizes holds how many bytes the operand of each kind takes
+var operandSizes = map[operandKind]int{
+	noOperand:       0,
+	immediate:       4,
+	index:           2,
+	address:         4,
+	relationAddress: 5,
+}
+
+// opcodes holds the mnemonic and the operand of each opcode
+var opcodes = []struct {
+	mnemonic string
+	operand  operandKind
+}{
+	Halt:         {"halt", noOperand},
+	PushInt:      {"int", immediate},
+	PushConst:    {"const", index},
+	Load:         {"load", index},
+	Store:        {"store", index},
+	LoadLocal:    {"load.local", index},
+	StoreLocal:   {"store.local", index},
+	AddInt:       {"add.inteiro", noOperand},
+	SubInt:       {"sub.inteiro", noOperand},
+	MulInt:       {"mul.inteiro", noOperand},
+	DivInt:       {"div.inteiro", noOperand},
+	AddReal:      {"add.real", noOperand},
+	SubReal:      {"sub.real", noOperand},
+	MulReal:      {"mul.real", noOperand},
+	DivReal:      {"div.real", noOperand},
+	ToReal:       {"to.real", noOperand},
+	ToInt:        {"to.inteiro", noOperand},
+	CmpInt:       {"cmp.inteiro", noOperand},
+	CmpReal:      {"cmp.real", noOperand},
+	CmpLiteral:   {"cmp.literal", noOperand},
+	Jump:         {"jump", address},
+	JumpUnless:   {"jump.unless", relationAddress},
+	ReadInt:      {"read.inteiro", noOperand},
+	ReadReal:     {"read.real", noOperand},
+	ReadLiteral:  {"read.literal", noOperand},
+	WriteInt:     {"write.inteiro", noOperand},
+	WriteReal:    {"write.real", noOperand},
+	WriteLiteral: {"write.literal", noOperand},
+}
+
+func (o Opcode) String() string {
+	if int(o) < len(opcodes) {
+		return opcodes[o].mnemonic
+	}
+	return fmt.Sprintf("opcode(%d)", o)
+}
+
+// Size returns how many bytes an instruction with the opcode takes
+func (o Opcode) Size() int {
+	return 1 + operandSizes[opcodes[o].operand]
+}
+
+// Relation is the comparison of a JumpUnless
+type Relation byte
+
+const (
+	Less Relation = iota
+	Greater
+	LessEqual
+	GreaterEqual
+	Equal
+	NotEqual
+)
+
+// relations holds the operator of each relation, as written on the source
+var relations = []string{
+	Less:         "<",
+	Greater:      ">",
+	LessEqual:    "<=",
+	GreaterEqual: ">=",
+	Equal:        "=",
+	NotEqual:     "<>",
+}
+
+func (r Relation) String() string {
+	if int(r) < len(relations) {
+		return relations[r]
+	}
+	return fmt.Sprintf("relation(%d)", r)
+}
+
+// ParseRelation returns the relation of a relational operator, like "<="
+func ParseRelation(operator string) (Relation, bool) {
+	for relation, text := range relations {
+		if text == operator {
+			return Relation(relation), true
+		}
+	}
+	return 0, false
+}
+
+// Holds tells whether the relation holds for order,
+// pushed by a comparison
+func (r Relation) Holds(order int32) bool {
+	switch r {
+	case Less:
+		return order == -1
+	case Greater:
+		return order == 1
+	case LessEqual:
+		return order == -1 || order == 0
+	case GreaterEqual:
+		return order == 0 || order == 1
+	case Equal:
+		return order == 0
+	}
+	return order != 0
+}
+
+// Instruction is an instruction decoded from the code of a program
+type Instruction struct {
+	// Address is where the instruction starts on the code
+	Address int
+	Op      Opcode
+	// Operand is the inteiro pushed by PushInt, the index of the
+	// constant, global or local of the others or the address a jump
+	// goes to
+	Operand  int64
+	Relation Relation
+}
+
+func (i Instruction) String() string {
+	switch opcodes[i.Op].operand {
+	case immediate, index, address:
+		return fmt.Sprintf("%s %d", i.Op, i.Operand)
+	case relationAddress:
+		return fmt.Sprintf("%s %s %d", i.Op, i.Relation, i.Operand)
+	}
+	return i.Op.String()
+}
+
+// Global is a variable of the program
+type Global struct {
+	Name string
+	Type lexer.DataType
+}
+
+// Constant is a real or literal pushed by PushConst. The inteiros
+// are on the instructions that push them instead
+type Constant struct {
+	Type    lexer.DataType
+	Real    float64
+	Literal string
+}
+
+// SourcePosition tells that the instructions from Address on, up to
+// the next one, come from Line and Column of the source, unknown
+// when Line is 0
+type SourcePosition struct {
+	Address int
+	Line    int
+	Column  int
+}
+
+// Program is a program compiled to bytecode
+type Program struct {
+	Globals []Global
+	// Locals is the number of locals of the frame of the program
+	Locals    int
+	Constants []Constant
+	Code      []byte
+	// Positions holds where the code comes from on the source, by
+	// address, for the runtime errors. It is empty when not known
+	Positions []SourcePosition
+}
+
+// PositionAt returns where the instruction at address comes from
+// on the source, telling whether it is known
+func (p *Program) PositionAt(address int) (SourcePosition, bool) {
+	index := sort.Search(len(p.Positions), func(index int) bool { return p.Positions[index].Address > address }) - 1
+	if index < 0 || p.Positions[index].Line == 0 {
+		return SourcePosition{}, false
+	}
+	return p.Positions[index], true
+}
+
+// append encodes instruction at the end of code
+func (i Instruction) append(code []byte) []byte {
+	code = append(code, byte(i.Op))
+	var operand [4]byte
+	switch opcodes[i.Op].operand {
+	case immediate, address:
+		binary.LittleEndian.PutUint32(operand[:], uint32(i.Operand))
+		code = append(code, operand[:]...)
+	case index:
+		binary.LittleEndian.PutUint16(operand[:], uint16(i.Operand))
+		code = append(code, operand[:2]...)
+	case relationAddress:
+		binary.LittleEndian.PutUint32(operand[:], uint32(i.Operand))
+		code = append(append(code, byte(i.Relation)), operand[:]...)
+	}
+	return code
+}
+
+// Instructions decodes the code of the program, checking that each
+// opcode exists and each operand refers to something on the program:
+// the globals, locals and constants by their index and the jumps to
+// the start of an instruction, or to the end of the code
+func (p *Program) Instructions() ([]Instruction, error) {
+	var instructions []Instruction
+	starts := map[int]bool{len(p.Code): true}
+	for at := 0; at < len(p.Code); {
+		op := Opcode(p.Code[at])
+		if int(op) >= len(opcodes) {
+			return nil, fmt.Errorf("%w: opcode %d desconhecido no endereço %d", ErrorInvalidBytecode, op, at)
+		}
+		if at+op.Size() > len(p.Code) {
+			return nil, fmt.Errorf("%w: %s incompleto no endereço %d", ErrorInvalidBytecode, op, at)
+		}
+		instruction := Instruction{Address: at, Op: op}
+		operand := p.Code[at+1 : at+op.Size()]
+		switch opcodes[op].operand {
+		case immediate:
+			instruction.Operand = int64(int32(binary.LittleEndian.Uint32(operand)))
+		case index:
+			instruction.Operand = int64(binary.LittleEndian.Uint16(operand))
+		case address:
+			instruction.Operand = int64(binary.LittleEndian.Uint32(operand))
+		case relationAddress:
+			instruction.Relation = Relation(operand[0])
+			instruction.Operand = int64(binary.LittleEndian.Uint32(operand[1:]))
+		}
+		if err := p.check(instruction); err != nil {
+			return nil, fmt.Errorf("%w: %s no endereço %d: %v", ErrorInvalidBytecode, op, at, err)
+		}
+		instructions = append(instructions, instruction)
+		starts[at] = true
+		at += op.Size()
+	}
+	for _, instruction := range instructions {
+		if op := instruction.Op; (op == Jump || op == JumpUnless) && !starts[int(instruction.Operand)] {
+			return nil, fmt.Errorf("%w: %s no endereço %d para o meio de uma instrução", ErrorInvalidBytecode, op, instruction.Address)
+		}
+	}
+	return instructions, nil
+}
+
+// check checks the operand of instruction against the program
+func (p *Program) check(instruction Instruction) error {
+	operand := instruction.Operand
+	switch instruction.Op {
+	case PushConst:
+		if operand >= int64(len(p.Constants)) {
+			return fmt.Errorf("constante %d inexistente", operand)
+		}
+	case Load, Store:
+		if operand >= int64(len(p.Globals)) {
+			return fmt.Errorf("variável %d inexistente", operand)
+		}
+	case LoadLocal, StoreLocal:
+		if operand >= int64(p.Locals) {
+			return fmt.Errorf("local %d inexistente", operand)
+		}
+	case JumpUnless:
+		if int(instruction.Relation) >= len(relations) {
+			return fmt.Errorf("relação %d desconhecida", instruction.Relation)
+		}
+	}
+	return nil
+}
+
+// builder puts a program together from instructions whose jumps go
+// to labels, numbering the constants and then placing the labels
+type builder struct {
+	program      *Program
+	instructions []Instruction
+	// targets holds the label each instruction jumps to, if any,
+	// and labels the index of the instruction after each label
+	targets   []string
+	labels    map[string]int
+	constants map[constantKey]int
+	// position is where the instructions emitted come from, and
+	// positions the index of the first instruction of each one
+	position  lexer.Position
+	positions []positionMark
+}
+
+type positionMark struct {
+	instruction int
+	position    lexer.Position
+}
+
+// constantKey tells the constants apart by the bits of the reals,
+// as -0.0 equals 0.0 but is written differently
+type constantKey struct {
+	dataType lexer.DataType
+	bits     uint64
+	literal  string
+}
+
+func newBuilder(program *Program) *builder {
+	return &builder{program: program, labels: make(map[string]int), constants: make(map[constantKey]int)}
+}
+
+// emit adds an instruction, jumping to target if not empty
+func (b *builder) emit(op Opcode, operand int64, relation Relation, target string) {
+	last := lexer.Position{}
+	if len(b.positions) > 0 {
+		last = b.positions[len(b.positions)-1].position
+	}
+	if b.position != last {
+		b.positions = append(b.positions, positionMark{len(b.instructions), b.position})
+	}
+	b.instructions = append(b.instructions, Instruction{Op: op, Operand: operand, Relation: relation})
+	b.targets = append(b.targets, target)
+}
+
+// constant returns the index of constant, added once
+func (b *builder) constant(constant Constant) int64 {
+	key := constantKey{constant.Type, math.Float64bits(constant.Real), constant.Literal}
+	if found, exists := b.constants[key]; exists {
+		return int64(found)
+	}
+	b.constants[key] = len(b.program.Constants)
+	b.program.Constants = append(b.program.Constants, constant)
+	return int64(len(b.program.Constants) - 1)
+}
+
+// mark places label before the next instruction
+func (b *builder) mark(label string) error {
+	if _, found := b.labels[label]; found {
+		return fmt.Errorf("rótulo %s repetido", label)
+	}
+	b.labels[label] = len(b.instructions)
+	return nil
+}
+
+// finish encodes the instructions, now that the address of each label is known
+func (b *builder) finish() (*Program, error) {
+	if len(b.program.Globals) > math.MaxUint16 || b.program.Locals > math.MaxUint16 || len(b.program.Constants) > math.MaxUint16 {
+		return nil, ErrorTooLarge
+	}
+	addresses := make([]int, len(b.instructions)+1)
+	for position, instruction := range b.instructions {
+		addresses[position+1] = addresses[position] + instruction.Op.Size()
+	}
+	if addresses[len(b.instructions)] > math.MaxUint32 {
+		return nil, ErrorTooLarge
+	}
+	code := make([]byte, 0, addresses[len(b.instructions)])
+	for position, instruction := range b.instructions {
+		if target := b.targets[position]; target != "" {
+			label, found := b.labels[target]
+			if !found {
+				return nil, fmt.Errorf("rótulo %s inexistente", target)
+			}
+			instruction.Operand = int64(addresses[label])
+		}
+		code = instruction.append(code)
+	}
+	b.program.Code = code
+	for _, mark := range b.positions {
+		b.program.Positions = append(b.program.Positions, SourcePosition{addresses[mark.instruction], mark.position.Line, mark.position.Column})
+	}
+	return b.program, nil
+}
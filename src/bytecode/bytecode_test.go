@@ -0,0 +1,225 @@
+package bytecode
+
+import (
+	"bytes"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	lowered, err := ir.Parse(`literal N
+real B
+inteiro A
+	leia N
+	leia A
+	ifFalse N = "fim" goto L1
+	%t1 = (real) A
+	%t2 = %t1 * 2.5
+	B = %t2
+	escreva B
+	%t3 = (inteiro) 0.75
+	A = %t3
+L1:
+	escreva "\tok\n"
+	escreva 2.5
+`)
+	require.NoError(t, err)
+
+	program, err := Compile(lowered)
+	require.NoError(t, err)
+	require.Equal(t, []Global{{"N", lexer.LITERAL}, {"B", lexer.REAL}, {"A", lexer.INTEGER}}, program.Globals)
+	require.Equal(t, 3, program.Locals)
+	// 2.5 is pushed twice from the same constant
+	require.Len(t, program.Constants, 4)
+
+	var listing bytes.Buffer
+	require.NoError(t, Disassemble(&listing, program))
+	require.Equal(t, `global literal N
+global real B
+global inteiro A
+locals 3
+
+	read.literal
+	store N
+	read.inteiro
+	store A
+	load N
+	const "fim"
+	cmp.literal
+	jump.unless = L61
+	load A
+	to.real
+	store.local 0
+	load.local 0
+	const 2.5
+	mul.real
+	store.local 1
+	load.local 1
+	store B
+	load B
+	write.real
+	const 0.75
+	to.inteiro
+	store.local 2
+	load.local 2
+	store A
+L61:
+	const "\\tok\\n"
+	write.literal
+	const 2.5
+	write.real
+	halt
+`, listing.String())
+}
+
+func TestCompilePositions(t *testing.T) {
+	lowered, err := ir.Parse("inteiro A\n\tleia A\n\t%t1 = 10 / A\n\tescreva %t1\n")
+	require.NoError(t, err)
+	lowered.Instructions[1].Pos = lexer.Position{Line: 6, Column: 9}
+
+	program, err := Compile(lowered)
+	require.NoError(t, err)
+	// read.inteiro and store A take 4 bytes, the division 12
+	require.Equal(t, []SourcePosition{{4, 6, 9}, {16, 0, 0}}, program.Positions)
+
+	for address, line := range map[int]int{0: 0, 4: 6, 12: 6, 15: 6, 16: 0} {
+		position, found := program.PositionAt(address)
+		require.Equal(t, line != 0, found, "endereço %d", address)
+		require.Equal(t, line, position.Line, "endereço %d", address)
+	}
+}
+
+func TestCompileTooLarge(t *testing.T) {
+	lowered, err := ir.Parse(`inteiro A
+	A = 3000000000
+`)
+	require.NoError(t, err)
+	_, err = Compile(lowered)
+	require.ErrorIs(t, err, ErrorTooLarge)
+}
+
+func TestRoundTrip(t *testing.T) {
+	listing := `global inteiro A
+global real B
+locals 1
+
+	read.inteiro
+	store A
+L4:
+	load A
+	int -1
+	cmp.inteiro
+	jump.unless >= L48
+	load A
+	int 1
+	sub.inteiro
+	store.local 0
+	load.local 0
+	store A
+	const -0
+	store B
+	jump L4
+L48:
+	const "a \"b\""
+	write.literal
+	halt
+`
+	program, err := Assemble(listing)
+	require.NoError(t, err)
+
+	var disassembled bytes.Buffer
+	require.NoError(t, Disassemble(&disassembled, program))
+	require.Equal(t, listing, disassembled.String())
+
+	program.Positions = []SourcePosition{{4, 2, 1}, {21, 0, 0}}
+	var file bytes.Buffer
+	require.NoError(t, Encode(&file, program))
+	decoded, err := Decode(&file)
+	require.NoError(t, err)
+	require.Equal(t, program, decoded)
+
+	// The files of version 1 end on the code, without the positions
+	require.NoError(t, Encode(&file, program))
+	old := file.Bytes()
+	old = old[:len(old)-4-3*4*len(program.Positions)]
+	old[len(Magic)] = 1
+	decoded, err = Decode(bytes.NewReader(old))
+	require.NoError(t, err)
+	require.Empty(t, decoded.Positions)
+	require.Equal(t, program.Code, decoded.Code)
+}
+
+func TestAssemble(t *testing.T) {
+	testCases := []struct {
+		name    string
+		listing string
+		// code is the code assembled, unless err is set
+		code []byte
+		err  bool
+	}{
+		{"comments", "; a comment\n\tint 7 ; another\n\tconst \"a;b\"\n", []byte{byte(PushInt), 7, 0, 0, 0, byte(PushConst), 0, 0}, false},
+		{"jump to the end", "\tjump fim\nfim:\n", []byte{byte(Jump), 5, 0, 0, 0}, false},
+		{"unknown instruction", "\tpush 1\n", nil, true},
+		{"missing operand", "\tint\n", nil, true},
+		{"extra operand", "\thalt 1\n", nil, true},
+		{"integer out of range", "\tint 3000000000\n", nil, true},
+		{"undeclared global", "\tload A\n", nil, true},
+		{"repeated global", "global inteiro A\nglobal real A\n", nil, true},
+		{"unknown type", "global logico A\n", nil, true},
+		{"local out of range", "locals 1\n\tload.local 1\n", nil, true},
+		{"unknown relation", "\tjump.unless => fim\nfim:\n", nil, true},
+		{"missing label", "\tjump fim\n", nil, true},
+		{"repeated label", "fim:\nfim:\n", nil, true},
+		{"unterminated literal", "\tconst \"ok\n", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := Assemble(tc.listing)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.code, program.Code)
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := func(code ...byte) []byte {
+		var file bytes.Buffer
+		program := &Program{Globals: []Global{{"A", lexer.INTEGER}}, Locals: 1, Code: code}
+		require.NoError(t, Encode(&file, program))
+		return file.Bytes()
+	}
+	require.NotEmpty(t, valid(byte(Halt)))
+
+	testCases := []struct {
+		name string
+		file []byte
+		err  error
+	}{
+		{"empty", nil, ErrorNotBytecode},
+		{"wrong magic", []byte("MGBX\x01"), ErrorNotBytecode},
+		{"newer version", []byte("MGBC\x03"), ErrorNotBytecode},
+		{"truncated", valid(byte(Halt))[:8], ErrorInvalidBytecode},
+		{"unknown opcode", valid(200), ErrorInvalidBytecode},
+		{"incomplete instruction", valid(byte(PushInt), 1), ErrorInvalidBytecode},
+		{"global out of range", valid(byte(Load), 1, 0), ErrorInvalidBytecode},
+		{"constant out of range", valid(byte(PushConst), 0, 0), ErrorInvalidBytecode},
+		{"unknown relation", valid(byte(JumpUnless), 9, 0, 0, 0, 0), ErrorInvalidBytecode},
+		{"jump into an instruction", valid(byte(Jump), 2, 0, 0, 0), ErrorInvalidBytecode},
+		{"position out of the code", append(valid(byte(Halt))[:len(valid(byte(Halt)))-4], 1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0), ErrorInvalidBytecode},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tc.file))
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
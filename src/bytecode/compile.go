@@ -0,0 +1,143 @@
+package bytecode
+
+import (
+	"fmt"
+	"math"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+)
+
+// arithmetic holds the opcode of each arithmetic operator, by the
+// type of the operands, and comparisons the one comparing each type
+var (
+	arithmetic = map[lexer.DataType]map[string]Opcode{
+		lexer.INTEGER: {"+": AddInt, "-": SubInt, "*": MulInt, "/": DivInt},
+		lexer.REAL:    {"+": AddReal, "-": SubReal, "*": MulReal, "/": DivReal},
+	}
+	comparisons = map[lexer.DataType]Opcode{
+		lexer.INTEGER: CmpInt,
+		lexer.REAL:    CmpReal,
+		lexer.LITERAL: CmpLiteral,
+	}
+	reads = map[lexer.DataType]Opcode{
+		lexer.INTEGER: ReadInt,
+		lexer.REAL:    ReadReal,
+		lexer.LITERAL: ReadLiteral,
+	}
+	writes = map[lexer.DataType]Opcode{
+		lexer.INTEGER: WriteInt,
+		lexer.REAL:    WriteReal,
+		lexer.LITERAL: WriteLiteral,
+	}
+)
+
+// compiler compiles the instructions of the three-address code
+type compiler struct {
+	*builder
+	globals map[string]int
+	err     error
+}
+
+// Compile compiles a program lowered to three-address code. Each
+// instruction pushes its operands, operates on them and stores the
+// result, ending with Halt. Where the instructions came from on the
+// source is kept on Positions
+func Compile(lowered *ir.Program) (*Program, error) {
+	program := &Program{Locals: len(lowered.Temporaries)}
+	c := &compiler{builder: newBuilder(program), globals: make(map[string]int)}
+	for position, declaration := range lowered.Declarations {
+		program.Globals = append(program.Globals, Global{declaration.Name, declaration.Type})
+		c.globals[declaration.Name] = position
+	}
+	for _, instruction := range lowered.Instructions {
+		c.position = instruction.Pos
+		c.instruction(instruction)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.position = lexer.Position{}
+	c.emit(Halt, 0, 0, "")
+	return c.finish()
+}
+
+func (c *compiler) instruction(instruction ir.Instruction) {
+	switch instruction.Op {
+	case ir.Mark:
+		if err := c.mark(instruction.Label.String()); err != nil {
+			c.err = err
+		}
+	case ir.Copy:
+		c.push(instruction.Left)
+		c.store(instruction.Dest)
+	case ir.Binary:
+		c.push(instruction.Left)
+		c.push(instruction.Right)
+		c.emit(arithmetic[instruction.Dest.Type][instruction.Operator], 0, 0, "")
+		c.store(instruction.Dest)
+	case ir.Convert:
+		c.push(instruction.Left)
+		if instruction.Dest.Type == lexer.REAL {
+			c.emit(ToReal, 0, 0, "")
+		} else {
+			c.emit(ToInt, 0, 0, "")
+		}
+		c.store(instruction.Dest)
+	case ir.Read:
+		c.emit(reads[instruction.Dest.Type], 0, 0, "")
+		c.store(instruction.Dest)
+	case ir.Write:
+		c.push(instruction.Left)
+		c.emit(writes[instruction.Left.Type], 0, 0, "")
+	case ir.Jump:
+		c.emit(Jump, 0, 0, instruction.Label.String())
+	case ir.JumpUnless:
+		relation, found := ParseRelation(instruction.Operator)
+		if !found {
+			c.err = fmt.Errorf("%w: operador %s", ErrorInvalidBytecode, instruction.Operator)
+			return
+		}
+		c.push(instruction.Left)
+		c.push(instruction.Right)
+		c.emit(comparisons[instruction.Left.Type], 0, 0, "")
+		c.emit(JumpUnless, 0, relation, instruction.Label.String())
+	}
+}
+
+// push pushes the value of operand
+func (c *compiler) push(operand ir.Operand) {
+	switch operand.Kind {
+	case ir.Temporary:
+		c.emit(LoadLocal, int64(operand.Temporary-1), 0, "")
+	case ir.Variable:
+		c.emit(Load, int64(c.globals[operand.Name]), 0, "")
+	case ir.Constant:
+		if operand.Type == lexer.LITERAL {
+			c.emit(PushConst, c.constant(Constant{Type: lexer.LITERAL, Literal: lexer.LiteralText(operand.Name)}), 0, "")
+			return
+		}
+		number, err := lexer.ParseNumber(operand.Name, operand.Type)
+		if err != nil {
+			c.err = err
+			return
+		}
+		if operand.Type == lexer.REAL {
+			c.emit(PushConst, c.constant(Constant{Type: lexer.REAL, Real: number}), 0, "")
+			return
+		}
+		if number < math.MinInt32 || number > math.MaxInt32 {
+			c.err = fmt.Errorf("%w: %s não cabe em um inteiro", ErrorTooLarge, operand.Name)
+			return
+		}
+		c.emit(PushInt, int64(number), 0, "")
+	}
+}
+
+// store pops the value at the top into dest
+func (c *compiler) store(dest ir.Operand) {
+	if dest.Kind == ir.Temporary {
+		c.emit(StoreLocal, int64(dest.Temporary-1), 0, "")
+		return
+	}
+	c.emit(Store, int64(c.globals[dest.Name]), 0, "")
+}
@@ -0,0 +1,192 @@
+package bytecode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/lexer"
+)
+
+// Magic starts each file of bytecode, followed by the Version of the
+// format. The files of version 1, without the positions, are still read
+const (
+	Magic   = "MGBC"
+	Version = 2
+)
+
+var ErrorNotBytecode = fmt.Errorf("o arquivo não é de bytecode do mgol")
+
+// fileTypes holds the byte each type is written as on the file
+var fileTypes = []lexer.DataType{lexer.INTEGER, lexer.REAL, lexer.LITERAL}
+
+func typeCode(dataType lexer.DataType) byte {
+	for code, fileType := range fileTypes {
+		if fileType == dataType {
+			return byte(code)
+		}
+	}
+	return byte(len(fileTypes))
+}
+
+// Encode writes program as a file. After Magic and Version come the
+// globals, each with its type and name, the number of locals, the
+// constants, each with its type and value, and the code. Each count
+// and size goes before what it counts, in little endian:
+//
+//	globals:   u16, then type u8, name length u16 and name each,
+//	           the types being 0 for inteiro, 1 for real and 2 for literal
+//	locals:    u16
+//	constants: u16, then type u8 and a real on 8 bytes, or
+//	           the length of the literal on a u16 and the literal
+//	code:      u32, then the code
+//	positions: u32, then the address, line and column of each on u32s
+func Encode(w io.Writer, program *Program) error {
+	var file bytes.Buffer
+	file.WriteString(Magic)
+	file.WriteByte(Version)
+	writeU16(&file, len(program.Globals))
+	for _, global := range program.Globals {
+		file.WriteByte(typeCode(global.Type))
+		writeU16(&file, len(global.Name))
+		file.WriteString(global.Name)
+	}
+	writeU16(&file, program.Locals)
+	writeU16(&file, len(program.Constants))
+	for _, constant := range program.Constants {
+		file.WriteByte(typeCode(constant.Type))
+		if constant.Type == lexer.LITERAL {
+			writeU16(&file, len(constant.Literal))
+			file.WriteString(constant.Literal)
+			continue
+		}
+		var real [8]byte
+		binary.LittleEndian.PutUint64(real[:], math.Float64bits(constant.Real))
+		file.Write(real[:])
+	}
+	writeU32(&file, len(program.Code))
+	file.Write(program.Code)
+	writeU32(&file, len(program.Positions))
+	for _, position := range program.Positions {
+		writeU32(&file, position.Address)
+		writeU32(&file, position.Line)
+		writeU32(&file, position.Column)
+	}
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+func writeU16(file *bytes.Buffer, value int) {
+	var encoded [2]byte
+	binary.LittleEndian.PutUint16(encoded[:], uint16(value))
+	file.Write(encoded[:])
+}
+
+func writeU32(file *bytes.Buffer, value int) {
+	var encoded [4]byte
+	binary.LittleEndian.PutUint32(encoded[:], uint32(value))
+	file.Write(encoded[:])
+}
+
+// Decode reads a program written by Encode, checking its code
+// like Instructions, so a program decoded can be run as is
+func Decode(r io.Reader) (*Program, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, len(Magic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil || string(header[:len(Magic)]) != Magic {
+		return nil, ErrorNotBytecode
+	}
+	version := header[len(Magic)]
+	if version < 1 || version > Version {
+		return nil, fmt.Errorf("%w: versão %d", ErrorNotBytecode, version)
+	}
+
+	d := &decoder{reader: reader}
+	program := &Program{}
+	for count := d.u16(); count > 0 && d.err == nil; count-- {
+		global := Global{Type: d.dataType()}
+		global.Name = string(d.bytes(d.u16()))
+		program.Globals = append(program.Globals, global)
+	}
+	program.Locals = d.u16()
+	for count := d.u16(); count > 0 && d.err == nil; count-- {
+		constant := Constant{Type: d.dataType()}
+		if constant.Type == lexer.LITERAL {
+			constant.Literal = string(d.bytes(d.u16()))
+		} else {
+			constant.Real = math.Float64frombits(binary.LittleEndian.Uint64(d.bytes(8)))
+		}
+		program.Constants = append(program.Constants, constant)
+	}
+	program.Code = d.bytes(d.u32())
+	if version > 1 {
+		d.positions(program)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidBytecode, d.err)
+	}
+	if _, err := program.Instructions(); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// decoder reads the fields of a file, keeping the first error
+// and giving zeros from then on
+type decoder struct {
+	reader *bufio.Reader
+	err    error
+}
+
+// bytes reads size bytes, growing as they arrive, as a
+// broken file can tell any size
+func (d *decoder) bytes(size int) []byte {
+	var read bytes.Buffer
+	if d.err == nil {
+		if _, err := io.CopyN(&read, d.reader, int64(size)); err != nil {
+			d.err = fmt.Errorf("arquivo incompleto")
+		}
+	}
+	if d.err != nil {
+		// Zeros as wide as the widest number read
+		return make([]byte, 8)
+	}
+	return read.Bytes()
+}
+
+func (d *decoder) u16() int {
+	return int(binary.LittleEndian.Uint16(d.bytes(2)))
+}
+
+func (d *decoder) u32() int {
+	return int(binary.LittleEndian.Uint32(d.bytes(4)))
+}
+
+// positions reads the positions of program, which must be in the
+// order of their addresses, all of them on its code
+func (d *decoder) positions(program *Program) {
+	for count := d.u32(); count > 0 && d.err == nil; count-- {
+		position := SourcePosition{Address: d.u32(), Line: d.u32(), Column: d.u32()}
+		last := len(program.Positions) - 1
+		if position.Address >= len(program.Code) || last >= 0 && position.Address <= program.Positions[last].Address {
+			if d.err == nil {
+				d.err = fmt.Errorf("posição no endereço %d fora de ordem", position.Address)
+			}
+			return
+		}
+		program.Positions = append(program.Positions, position)
+	}
+}
+
+func (d *decoder) dataType() lexer.DataType {
+	code := d.bytes(1)[0]
+	if int(code) >= len(fileTypes) {
+		if d.err == nil {
+			d.err = fmt.Errorf("tipo %d desconhecido", code)
+		}
+		return ""
+	}
+	return fileTypes[code]
+}
@@ -0,0 +1,120 @@
+// Package cfg builds the control flow graph of a program: its
+// statements grouped on basic blocks, run from the first to the
+// last, and the edges each block can go on through
+package cfg
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+)
+
+// Block is a basic block: statements always run together, in order
+type Block struct {
+	// Index is the position of the block on Graph.Blocks
+	Index int
+	// Stmts holds the leia, escreva and assignments of the block
+	Stmts []ast.Stmt
+	// Condition is evaluated after the statements, on the blocks
+	// that end on a se or on the header of a repita. The block then
+	// goes on to Succs[0] when it holds and to Succs[1] otherwise
+	Condition ast.Expr
+	// Succs holds the blocks run after this one and
+	// Preds the ones this one can be run after
+	Succs []*Block
+	Preds []*Block
+}
+
+func (b *Block) String() string {
+	return fmt.Sprintf("B%d", b.Index)
+}
+
+// Graph is the control flow graph of a program
+type Graph struct {
+	// Blocks holds every block in the order they appear on the
+	// source. Entry is the first one and Exit, which is empty,
+	// the last one
+	Blocks []*Block
+	Entry  *Block
+	Exit   *Block
+}
+
+// New builds the graph of program. The error nodes of a partial
+// tree are kept as statements of their blocks
+func New(program *ast.Program) *Graph {
+	g := &Graph{}
+	g.Entry = g.newBlock()
+	last := g.stmts(program.Body, g.Entry)
+	g.Exit = g.newBlock()
+	link(last, g.Exit)
+	return g
+}
+
+func (g *Graph) newBlock() *Block {
+	block := &Block{Index: len(g.Blocks)}
+	g.Blocks = append(g.Blocks, block)
+	return block
+}
+
+func link(from, to *Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// stmts adds stmts to the graph starting on current, returning
+// the block the statements after them go on
+func (g *Graph) stmts(stmts []ast.Stmt, current *Block) *Block {
+	for _, stmt := range stmts {
+		switch node := stmt.(type) {
+		case *ast.If:
+			current.Condition = node.Condition
+			body := g.newBlock()
+			link(current, body)
+			bodyEnd := g.stmts(node.Body, body)
+			if node.Else == nil {
+				join := g.newBlock()
+				link(current, join)
+				link(bodyEnd, join)
+				current = join
+				continue
+			}
+			otherwise := g.newBlock()
+			link(current, otherwise)
+			otherwiseEnd := g.stmts(node.Else, otherwise)
+			join := g.newBlock()
+			link(bodyEnd, join)
+			link(otherwiseEnd, join)
+			current = join
+		case *ast.While:
+			header := g.newBlock()
+			header.Condition = node.Condition
+			link(current, header)
+			body := g.newBlock()
+			link(header, body)
+			link(g.stmts(node.Body, body), header)
+			after := g.newBlock()
+			link(header, after)
+			current = after
+		default:
+			current.Stmts = append(current.Stmts, stmt)
+		}
+	}
+	return current
+}
+
+// Reachable returns the blocks that can be run
+// from Entry, following the edges of the graph
+func (g *Graph) Reachable() map[*Block]bool {
+	reached := map[*Block]bool{g.Entry: true}
+	pending := []*Block{g.Entry}
+	for len(pending) > 0 {
+		block := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, succ := range block.Succs {
+			if !reached[succ] {
+				reached[succ] = true
+				pending = append(pending, succ)
+			}
+		}
+	}
+	return reached
+}
@@ -0,0 +1,102 @@
+package cfg
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func compare(operator string, name string, value string) *ast.BinaryExpr {
+	return asttest.Binary(operator, asttest.Ident(name), asttest.Literal(value, lexer.INTEGER))
+}
+
+// testProgram is
+//
+//	leia A;
+//	repita (A > 0)
+//		se (A > 5) entao escreva A; senao escreva 0; fimse
+//		A <- A - 1;
+//	fimrepita
+//	se (A = 0) entao escreva A; fimse
+//	leia A;
+func testProgram() *ast.Program {
+	return &ast.Program{Body: []ast.Stmt{
+		&ast.Read{Target: asttest.Ident("A")},
+		&ast.While{Condition: compare(">", "A", "0"), Body: []ast.Stmt{
+			&ast.If{
+				Condition: compare(">", "A", "5"),
+				Body:      []ast.Stmt{&ast.Write{Value: asttest.Ident("A")}},
+				Else:      []ast.Stmt{&ast.Write{Value: asttest.Literal("0", lexer.INTEGER)}},
+			},
+			&ast.Assign{Target: asttest.Ident("A"), Value: compare("-", "A", "1")},
+		}},
+		&ast.If{Condition: compare("=", "A", "0"), Body: []ast.Stmt{&ast.Write{Value: asttest.Ident("A")}}},
+		&ast.Read{Target: asttest.Ident("A")},
+	}}
+}
+
+func TestNew(t *testing.T) {
+	g := New(testProgram())
+
+	succs := map[string][]string{}
+	for _, block := range g.Blocks {
+		for _, succ := range block.Succs {
+			succs[block.String()] = append(succs[block.String()], succ.String())
+		}
+		for _, pred := range block.Preds {
+			require.Contains(t, pred.Succs, block)
+		}
+	}
+	require.Equal(t, map[string][]string{
+		"B0": {"B1"},
+		"B1": {"B2", "B6"},
+		"B2": {"B3", "B4"},
+		"B3": {"B5"},
+		"B4": {"B5"},
+		"B5": {"B1"},
+		"B6": {"B7", "B8"},
+		"B7": {"B8"},
+		"B8": {"B9"},
+	}, succs)
+
+	require.Equal(t, g.Blocks[0], g.Entry)
+	require.Equal(t, g.Blocks[9], g.Exit)
+	require.Len(t, g.Blocks[0].Stmts, 1)
+	require.Len(t, g.Blocks[5].Stmts, 1)
+	require.Len(t, g.Blocks[8].Stmts, 1)
+	require.Nil(t, g.Blocks[5].Condition)
+	require.Len(t, g.Reachable(), len(g.Blocks))
+}
+
+func TestNewEmptyProgram(t *testing.T) {
+	g := New(&ast.Program{})
+	require.Len(t, g.Blocks, 2)
+	require.Equal(t, []*Block{g.Exit}, g.Entry.Succs)
+}
+
+func TestEncodeDOT(t *testing.T) {
+	program := &ast.Program{Body: []ast.Stmt{
+		&ast.Read{Target: asttest.Ident("A")},
+		&ast.If{Condition: compare(">", "A", "0"), Body: []ast.Stmt{&ast.Write{Value: asttest.Ident("A")}}},
+	}}
+
+	output := &bytes.Buffer{}
+	require.NoError(t, EncodeDOT(output, New(program)))
+	require.Equal(t, `digraph "cfg" {
+	ordering="out";
+	node [fontname="monospace"];
+	n0 [label="B0\nleia A;\n(A > 0)?", shape=ellipse];
+	n1 [label="B1\nescreva A;", shape=box];
+	n2 [label="B2", shape=box];
+	n3 [label="B3", shape=ellipse];
+	n0 -> n1 [label="verdadeiro"];
+	n0 -> n2 [label="falso"];
+	n1 -> n2;
+	n2 -> n3;
+}
+`, output.String())
+}
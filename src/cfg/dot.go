@@ -0,0 +1,56 @@
+package cfg
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/dot"
+	"strings"
+)
+
+// source returns node written as mgol source, on a single line
+func source(node ast.Node) string {
+	var builder strings.Builder
+	ast.Fprint(&builder, node)
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// label lists the statements of block, one per line, and
+// its condition, if it has one, after the name of the block
+func label(block *Block) string {
+	lines := []string{block.String()}
+	for _, stmt := range block.Stmts {
+		lines = append(lines, source(stmt))
+	}
+	if block.Condition != nil {
+		lines = append(lines, "("+source(block.Condition)+")?")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EncodeDOT writes g as a Graphviz graph, each block as a box with
+// its statements. The edges leaving a block with a condition are
+// labeled with whether it holds
+func EncodeDOT(w io.Writer, g *Graph) error {
+	graph := dot.NewGraph("cfg")
+	for _, block := range g.Blocks {
+		shape := dot.Box
+		if block == g.Entry || block == g.Exit {
+			shape = dot.Ellipse
+		}
+		graph.AddNode(label(block), shape)
+	}
+	for _, block := range g.Blocks {
+		for idx, succ := range block.Succs {
+			edgeLabel := ""
+			switch {
+			case block.Condition == nil:
+			case idx == 0:
+				edgeLabel = "verdadeiro"
+			default:
+				edgeLabel = "falso"
+			}
+			graph.AddEdge(block.Index, succ.Index, edgeLabel)
+		}
+	}
+	return graph.Write(w)
+}
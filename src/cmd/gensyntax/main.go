@@ -0,0 +1,51 @@
+// Command gensyntax writes the files editors use to highlight mgol,
+// built from the automaton of the scanner and its reserved words, so
+// they change with the language. The ones on editors/ are regenerated
+// with:
+//
+//	go run ./src/cmd/gensyntax -format textmate -o editors/vscode/syntaxes/mgol.tmLanguage.json
+//	go run ./src/cmd/gensyntax -format vim -o editors/vim/syntax/mgol.vim
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"mgol-go/src/lexer"
+	"os"
+)
+
+// writers write the files of each -format
+var writers = map[string]func(io.Writer) error{
+	"textmate": lexer.WriteTextMateGrammar,
+	"vim":      lexer.WriteVimSyntax,
+}
+
+func main() {
+	log.SetFlags(0)
+	format := flag.String("format", "textmate", "formato de saída: textmate, a gramática json do VS Code, ou vim")
+	outputPath := flag.String("o", "", "arquivo de saída, a saída padrão se vazio")
+	flag.Parse()
+
+	write, found := writers[*format]
+	if !found {
+		log.Fatalf("formato desconhecido: %s", *format)
+	}
+	if *outputPath == "" {
+		if err := write(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	file, err := os.Create(*outputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
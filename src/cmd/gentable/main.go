@@ -0,0 +1,209 @@
+// Command gentable builds the SLR action and goto tables of a
+// grammar and reports its conflicts. The grammar is read from a
+// json file, like grammar.json, or from a BNF file, like grammar.bnf.
+//
+// The tables are written as Go source, or as the tsv files read by
+// the parser. Error codes of the action table can be carried over
+// from the tables of a previous version of the grammar:
+//
+//	go run ./src/cmd/gentable -grammar src/parser/grammar.bnf -format tsv -o src/parser/tables \
+//		-previous-grammar old.bnf -previous-action old_action.tsv -error-codes LD=7,OPRD=7 \
+//		-aliases opmul=opm
+//
+// Generation fails while the grammar has conflicts. Each one is shown
+// with the items that cause it and an input that reaches it, and can
+// be resolved by choosing the action of its cell with -resolve.
+//
+// With -sets, the FIRST and FOLLOW sets of each non terminal, and
+// whether it is nullable, are written instead of the tables:
+//
+//	go run ./src/cmd/gentable -grammar src/parser/grammar.bnf -sets json
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mgol-go/src/grammar"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	grammarPath := flag.String("grammar", "./src/parser/grammar.json", "arquivo com as regras da gramática, em json ou BNF")
+	format := flag.String("format", "go", "formato de saída: go ou tsv")
+	outputPath := flag.String("o", "", "arquivo de saída em go, a saída padrão se vazio, ou diretório das tabelas em tsv")
+	packageName := flag.String("package", "parser", "pacote do código gerado")
+	actionVar := flag.String("action", "actionTableRecords", "nome da variável da tabela action")
+	gotoVar := flag.String("goto", "gotoTableRecords", "nome da variável da tabela goto")
+	previousGrammar := flag.String("previous-grammar", "", "versão anterior da gramática, de onde vêm os códigos de erro")
+	previousAction := flag.String("previous-action", "", "tabela action em tsv da versão anterior da gramática")
+	errorCodes := flag.String("error-codes", "", "códigos de erro dos estados novos, por não terminal: LD=7,OPRD=7")
+	aliases := flag.String("aliases", "", "terminais novos que herdam os códigos de erro de outro: opmul=opm")
+	defaultError := flag.String("default-error", "1", "código de erro das células sem outro código")
+	resolve := flag.String("resolve", "", "ação escolhida para cada conflito, por estado e terminal: 4:+=s3,7:id=r2")
+	sets := flag.String("sets", "", "escreve os conjuntos FIRST e FOLLOW em vez das tabelas: text ou json")
+	flag.Parse()
+
+	g, err := grammar.LoadFile(*grammarPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *sets != "" {
+		writeSets(g, *outputPath, *sets)
+		return
+	}
+
+	table := g.SLRTable()
+	resolutions := []grammar.Resolution{}
+	for _, text := range strings.Split(*resolve, ",") {
+		if text == "" {
+			continue
+		}
+		resolution, err := grammar.ParseResolution(text)
+		if err != nil {
+			log.Fatal(err)
+		}
+		resolutions = append(resolutions, resolution)
+	}
+	conflicts, err := table.Resolve(resolutions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintln(os.Stderr, conflict)
+	}
+	if len(conflicts) > 0 {
+		log.Fatalf("%d conflitos não resolvidos, as tabelas não foram geradas", len(conflicts))
+	}
+
+	if *previousGrammar != "" || *errorCodes != "" || *format == "tsv" {
+		fillErrorCodes(table, *previousGrammar, *previousAction, *errorCodes, *aliases, *defaultError)
+	}
+
+	switch *format {
+	case "go":
+		writeGoSource(table, *outputPath, *packageName, *actionVar, *gotoVar, *grammarPath)
+	case "tsv":
+		writeTSV(table, *outputPath)
+	default:
+		log.Fatalf("formato desconhecido: %s", *format)
+	}
+}
+
+func fillErrorCodes(table *grammar.Table, previousGrammarPath, previousActionPath, errorCodes, aliases, defaultError string) {
+	codes := grammar.ErrorCodes{
+		ByNonTerminal: make(map[string]string),
+		Aliases:       parsePairs(aliases),
+		Default:       "e" + defaultError,
+	}
+	for nonTerminal, code := range parsePairs(errorCodes) {
+		codes.ByNonTerminal[nonTerminal] = "e" + code
+	}
+
+	if previousGrammarPath != "" {
+		var err error
+		if codes.Previous, err = grammar.LoadFile(previousGrammarPath); err != nil {
+			log.Fatal(err)
+		}
+		if codes.PreviousAction, err = readTSV(previousActionPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	table.FillErrorCodes(codes)
+}
+
+// parsePairs reads a list like a=1,b=2
+func parsePairs(list string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("par inválido: %s", pair)
+		}
+		pairs[parts[0]] = parts[1]
+	}
+	return pairs
+}
+
+func readTSV(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = '\t'
+	return reader.ReadAll()
+}
+
+func writeSets(g *grammar.Grammar, outputPath, format string) {
+	var output io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	var err error
+	switch format {
+	case "text":
+		err = g.WriteSets(output)
+	case "json":
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(g.Sets())
+	default:
+		log.Fatalf("formato desconhecido: %s", format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeGoSource(table *grammar.Table, outputPath, packageName, actionVar, gotoVar, origin string) {
+	var output io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if err := table.WriteGoSource(output, packageName, actionVar, gotoVar, origin); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeTSV(table *grammar.Table, directory string) {
+	actionFile, err := os.Create(filepath.Join(directory, "action.tsv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer actionFile.Close()
+
+	gotoFile, err := os.Create(filepath.Join(directory, "goto.tsv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gotoFile.Close()
+
+	if err := table.WriteTSV(actionFile, gotoFile); err != nil {
+		log.Fatal(err)
+	}
+}
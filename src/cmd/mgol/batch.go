@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/exitcode"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sourcePaths returns the files given to flags, the glob patterns,
+// like *.mgol, expanded in order. A pattern must match some file
+func sourcePaths(flags *flag.FlagSet) ([]string, error) {
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(exitcode.Usage)
+	}
+	var paths []string
+	for _, pattern := range flags.Args() {
+		if pattern == stdinPath || !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, usageErrorf("nenhum arquivo corresponde a %s", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// programPaths returns the files given to flags or, when none is
+// and the configuration file of the project lists its sources,
+// those, telling they are the files of a single program
+func programPaths(flags *flag.FlagSet) ([]string, bool, error) {
+	if flags.NArg() == 0 && len(project.Sources) > 0 {
+		return project.SourcePaths(), true, nil
+	}
+	paths, err := sourcePaths(flags)
+	return paths, false, err
+}
+
+// outcome is what compiling a program of a batch gave
+type outcome struct {
+	diagnostics []errorhandling.Diagnostic
+	err         error
+}
+
+// compileAll runs compileFile on each of paths, which returns the
+// diagnostics of the program and a rejectedError when it has errors.
+// A single program has its diagnostics shown like the other commands
+// do. Many are compiled in parallel, and then have their diagnostics
+// shown with their file, in the order given, and a summary written on
+// the standard output: a line for each program and one with how many
+// were compiled, failing if any was not with the status of the
+// first one that failed
+func compileAll(paths []string, compileFile func(path string) ([]errorhandling.Diagnostic, error)) error {
+	if len(paths) == 1 {
+		diagnostics, err := compileFile(paths[0])
+		showDiagnostics(paths[0], diagnostics)
+		return err
+	}
+
+	outcomes := make([]outcome, len(paths))
+	workers := make(chan struct{}, runtime.NumCPU())
+	var group sync.WaitGroup
+	for index, path := range paths {
+		group.Add(1)
+		go func(index int, path string) {
+			defer group.Done()
+			defer exitcode.Recover()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			outcomes[index].diagnostics, outcomes[index].err = compileFile(path)
+		}(index, path)
+	}
+	group.Wait()
+
+	passed := 0
+	var failure error
+	for index, path := range paths {
+		summary, state := "ok", "ok"
+		errors, warnings := 0, 0
+		for _, diagnostic := range outcomes[index].diagnostics {
+			if porcelain != 0 {
+				logDiagnostic(path, diagnostic)
+			} else {
+				log.Printf("%s: %s", path, diagnostic)
+			}
+			if diagnostic.Severity == errorhandling.Error {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+		err := outcomes[index].err
+		failed := ""
+		if _, shown := err.(rejectedError); shown {
+			summary = "falhou, " + plural(errors, "erro", "erros")
+		} else if err != nil {
+			summary = "falhou, " + err.Error()
+			failed = err.Error()
+		}
+		if err == nil {
+			passed++
+		} else {
+			state = "falhou"
+			if failure == nil {
+				// The error was shown on the summary
+				failure = rejectedError{status(err)}
+			}
+		}
+		if porcelain != 0 {
+			writeRecord(os.Stdout, "programa", path, state, strconv.Itoa(errors), strconv.Itoa(warnings), failed)
+			continue
+		}
+		if warnings > 0 {
+			summary += ", " + plural(warnings, "aviso", "avisos")
+		}
+		fmt.Printf("%s: %s\n", path, summary)
+	}
+	if porcelain != 0 {
+		writeRecord(os.Stdout, "total", strconv.Itoa(passed), strconv.Itoa(len(paths)))
+	} else {
+		fmt.Printf("%d de %d programas compilados\n", passed, len(paths))
+	}
+	return failure
+}
+
+// plural returns count followed by singular or plural
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"mgol-go/src/buildcache"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// cacheDirUsage describes the -cache-dir flag
+const cacheDirUsage = "diretório do cache de compilação"
+
+// cacheCommand shows how much the cache of build -cache holds and how
+// often it was used, or removes its entries, so that the programs are
+// built again
+func cacheCommand(args []string) error {
+	flags := newFlagSet("cache", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol cache [opções] stats|clean")
+		flags.PrintDefaults()
+	}
+	dir := flags.String("cache-dir", buildcache.DefaultDir(), cacheDirUsage)
+	olderThan := flags.Duration("older-than", 0, "com clean, remove apenas as entradas não usadas há esse tempo, como 720h, em vez de todas")
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		return usageErrorf("mgol cache recebe stats ou clean")
+	}
+	if *olderThan < 0 {
+		return usageErrorf("-older-than não pode ser negativo")
+	}
+	cache, err := buildcache.Open(*dir)
+	if err != nil {
+		return err
+	}
+
+	switch flags.Arg(0) {
+	case "stats":
+		stats, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+		if porcelain != 0 {
+			writeRecord(os.Stdout, "cache", cache.Dir(), strconv.Itoa(stats.Entries), strconv.FormatInt(stats.Bytes, 10), strconv.FormatInt(stats.Hits, 10), strconv.FormatInt(stats.Misses, 10))
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(writer, "diretório\t%s\n", cache.Dir())
+		fmt.Fprintf(writer, "entradas\t%d\n", stats.Entries)
+		fmt.Fprintf(writer, "bytes\t%d\n", stats.Bytes)
+		fmt.Fprintf(writer, "acertos\t%d\n", stats.Hits)
+		fmt.Fprintf(writer, "faltas\t%d\n", stats.Misses)
+		if total := stats.Hits + stats.Misses; total > 0 {
+			fmt.Fprintf(writer, "taxa de acertos\t%.1f%%\n", 100*float64(stats.Hits)/float64(total))
+		}
+		return writer.Flush()
+	case "clean":
+		removed, err := cache.Clean(*olderThan)
+		if err != nil {
+			return err
+		}
+		if porcelain != 0 {
+			writeRecord(os.Stdout, "removidas", strconv.Itoa(removed))
+		} else {
+			fmt.Printf("%s\n", plural(removed, "entrada removida", "entradas removidas"))
+		}
+		return nil
+	}
+	return usageErrorf("subcomando de cache desconhecido: %s, os disponíveis são stats e clean", flags.Arg(0))
+}
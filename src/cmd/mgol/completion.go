@@ -0,0 +1,289 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"mgol-go/src/backend"
+	"mgol-go/src/explain"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/lint"
+	"mgol-go/src/sem"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The command is registered here, as it looks at the others
+func init() {
+	commands["completion"] = command{"escreve o script de completação do bash, zsh ou fish", completion}
+}
+
+// shells write the completion scripts of each shell
+var shells = map[string]func(io.Writer, []completedCommand) error{
+	"bash": writeBashCompletion,
+	"zsh":  writeZshCompletion,
+	"fish": writeFishCompletion,
+}
+
+func completion(args []string) error {
+	flags := newFlagSet("completion", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol completion bash|zsh|fish")
+	}
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		return usageErrorf("mgol completion recebe o nome do shell: bash, zsh ou fish")
+	}
+	write, found := shells[flags.Arg(0)]
+	if !found {
+		return usageErrorf("shell desconhecido: %s, os disponíveis são bash, zsh e fish", flags.Arg(0))
+	}
+	return write(os.Stdout, completedCommands())
+}
+
+// completedCommand is a command with what its flags take
+type completedCommand struct {
+	name        string
+	description string
+	flags       []completedFlag
+	// arguments holds what the command takes instead of files
+	arguments []string
+}
+
+type completedFlag struct {
+	name        string
+	description string
+	// takesValue is false for the flags that are booleans, and
+	// values holds the ones a flag can take, if they are known
+	takesValue bool
+	values     []string
+}
+
+// collectingFlags makes parseFlags hand the flags of a command to
+// flagsOf instead of parsing them
+var collectingFlags bool
+
+// collectedFlags is what parseFlags panics with while collectingFlags
+type collectedFlags struct {
+	flags *flag.FlagSet
+}
+
+// flagsOf returns the flags of the command name, running it only up
+// to parseFlags, as each command declares its own flags
+func flagsOf(name string) (flags *flag.FlagSet) {
+	collectingFlags = true
+	defer func() {
+		collectingFlags = false
+		if r := recover(); r != nil {
+			collected, ok := r.(collectedFlags)
+			if !ok {
+				panic(r)
+			}
+			flags = collected.flags
+		}
+	}()
+	commands[name].run(nil)
+	return nil
+}
+
+// completedCommands returns the commands sorted by their name
+func completedCommands() []completedCommand {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	completed := make([]completedCommand, len(names))
+	for index, name := range names {
+		completed[index] = completedCommand{name: name, description: commands[name].description, arguments: commandArguments(name)}
+		flags := flagsOf(name)
+		if flags == nil {
+			continue
+		}
+		flags.VisitAll(func(f *flag.Flag) {
+			boolean, ok := f.Value.(interface{ IsBoolFlag() bool })
+			completed[index].flags = append(completed[index].flags, completedFlag{
+				name:        f.Name,
+				description: f.Usage,
+				takesValue:  !ok || !boolean.IsBoolFlag(),
+				values:      flagValues(name, f.Name),
+			})
+		})
+	}
+	return completed
+}
+
+// commandArguments returns what the command name takes instead of files
+func commandArguments(name string) []string {
+	switch name {
+	case "cache":
+		return []string{"clean", "stats"}
+	case "completion":
+		shellNames := make([]string, 0, len(shells))
+		for shell := range shells {
+			shellNames = append(shellNames, shell)
+		}
+		sort.Strings(shellNames)
+		return shellNames
+	case "explain":
+		var codes []string
+		for _, explanation := range explain.Explanations() {
+			codes = append(codes, explanation.Code)
+		}
+		return codes
+	}
+	return nil
+}
+
+// flagValues returns the values the flag name of command takes
+func flagValues(command, name string) []string {
+	switch name {
+	case "target":
+		return append([]string{targetC}, backend.Names()...)
+	case "format":
+		if command == "testsuite" {
+			return []string{"tap", "json"}
+		}
+		return []string{string(lexer.HTMLHighlight), string(lexer.ANSIHighlight)}
+	case "tokens":
+		return []string{string(lexer.TableFormat), string(lexer.JSONFormat), string(lexer.CSVFormat)}
+	case "ast":
+		formats := make([]string, 0, len(astFormats))
+		for format := range astFormats {
+			formats = append(formats, format)
+		}
+		sort.Strings(formats)
+		return formats
+	case "narrowing", "promotion":
+		return []string{sem.Permissive.String(), sem.Warn.String(), sem.Strict.String()}
+	case "O":
+		return []string{ir.O0.String(), ir.O1.String(), ir.O2.String()}
+	case "enable", "disable":
+		var names []string
+		for _, rule := range lint.Rules() {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, completed []completedCommand) error {
+	var script strings.Builder
+	names := make([]string, len(completed))
+	for index, command := range completed {
+		names[index] = command.name
+	}
+	script.WriteString("# Completação do mgol no bash, gerada por mgol completion bash\n")
+	script.WriteString("_mgol() {\n")
+	script.WriteString("\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} flags= arguments=\n")
+	script.WriteString("\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&script, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	script.WriteString("\t\treturn\n\tfi\n")
+
+	script.WriteString("\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, command := range completed {
+		flags := []string{}
+		valued := []string{}
+		for _, f := range command.flags {
+			flags = append(flags, "-"+f.name)
+			if len(f.values) > 0 {
+				valued = append(valued, fmt.Sprintf("\t\t-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.values, " ")))
+			}
+		}
+		fmt.Fprintf(&script, "\t%s)\n\t\tflags=%q\n", command.name, strings.Join(flags, " "))
+		if len(command.arguments) > 0 {
+			fmt.Fprintf(&script, "\t\targuments=%q\n", strings.Join(command.arguments, " "))
+		}
+		if len(valued) > 0 {
+			script.WriteString("\t\tcase \"$prev\" in\n")
+			for _, line := range valued {
+				script.WriteString("\t" + line)
+			}
+			script.WriteString("\t\tesac\n")
+		}
+		script.WriteString("\t\t;;\n")
+	}
+	script.WriteString("\tesac\n")
+	script.WriteString("\tif [[ $cur == -* ]]; then\n")
+	script.WriteString("\t\tCOMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	script.WriteString("\telif [ -n \"$arguments\" ]; then\n")
+	script.WriteString("\t\tCOMPREPLY=($(compgen -W \"$arguments\" -- \"$cur\"))\n")
+	script.WriteString("\telse\n")
+	script.WriteString("\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	script.WriteString("\tfi\n}\n")
+	script.WriteString("complete -o filenames -F _mgol mgol\n")
+	_, err := io.WriteString(w, script.String())
+	return err
+}
+
+// zshEscaper escapes a description for _describe and _arguments,
+// on a string between single quotes
+var zshEscaper = strings.NewReplacer(`\`, `\\`, `'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+
+func writeZshCompletion(w io.Writer, completed []completedCommand) error {
+	var script strings.Builder
+	script.WriteString("#compdef mgol\n")
+	script.WriteString("# Completação do mgol no zsh, gerada por mgol completion zsh\n\n")
+	script.WriteString("_mgol() {\n")
+	script.WriteString("\tlocal -a commands\n\tcommands=(\n")
+	for _, command := range completed {
+		fmt.Fprintf(&script, "\t\t'%s:%s'\n", command.name, zshEscaper.Replace(command.description))
+	}
+	script.WriteString("\t)\n")
+	script.WriteString("\tif (( CURRENT == 2 )); then\n\t\t_describe comando commands\n\t\treturn\n\tfi\n")
+	script.WriteString("\tshift words\n\t(( CURRENT-- ))\n")
+	script.WriteString("\tcase $words[1] in\n")
+	for _, command := range completed {
+		fmt.Fprintf(&script, "\t%s)\n\t\t_arguments", command.name)
+		for _, f := range command.flags {
+			spec := fmt.Sprintf("-%s[%s]", f.name, zshEscaper.Replace(f.description))
+			switch {
+			case len(f.values) > 0:
+				spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.values, " "))
+			case f.takesValue:
+				spec += fmt.Sprintf(":%s:_files", f.name)
+			}
+			fmt.Fprintf(&script, " \\\n\t\t\t'%s'", spec)
+		}
+		if len(command.arguments) > 0 {
+			fmt.Fprintf(&script, " \\\n\t\t\t'1:argumento:(%s)'\n\t\t;;\n", strings.Join(command.arguments, " "))
+		} else {
+			script.WriteString(" \\\n\t\t\t'*:arquivo:_files'\n\t\t;;\n")
+		}
+	}
+	script.WriteString("\tesac\n}\n\n_mgol \"$@\"\n")
+	_, err := io.WriteString(w, script.String())
+	return err
+}
+
+// fishEscaper escapes a text between single quotes for fish
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func writeFishCompletion(w io.Writer, completed []completedCommand) error {
+	var script strings.Builder
+	script.WriteString("# Completação do mgol no fish, gerada por mgol completion fish\n")
+	for _, command := range completed {
+		fmt.Fprintf(&script, "complete -c mgol -n __fish_use_subcommand -f -a %s -d '%s'\n", command.name, fishEscaper.Replace(command.description))
+	}
+	for _, command := range completed {
+		if len(command.arguments) > 0 {
+			fmt.Fprintf(&script, "complete -c mgol -n '__fish_seen_subcommand_from %s' -f -a '%s'\n", command.name, strings.Join(command.arguments, " "))
+		}
+		for _, f := range command.flags {
+			fmt.Fprintf(&script, "complete -c mgol -n '__fish_seen_subcommand_from %s' -o %s", command.name, f.name)
+			switch {
+			case len(f.values) > 0:
+				fmt.Fprintf(&script, " -x -a '%s'", strings.Join(f.values, " "))
+			case f.takesValue:
+				script.WriteString(" -r")
+			}
+			fmt.Fprintf(&script, " -d '%s'\n", fishEscaper.Replace(f.description))
+		}
+	}
+	_, err := io.WriteString(w, script.String())
+	return err
+}
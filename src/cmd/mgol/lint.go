@@ -0,0 +1,79 @@
+package main
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lint"
+	"strings"
+)
+
+func lintPrograms(args []string) error {
+	options := &checkOptions{}
+	flags := newFlagSet("lint", options)
+	configPath := flags.String("config", "", "arquivo json com a configuração das regras, como {\"disable\": [\"numero-magico\"], \"max-line-length\": 80}")
+	enable := flags.String("enable", "", "regras ativadas, separadas por vírgula, após as da configuração")
+	disable := flags.String("disable", "", "regras desativadas, separadas por vírgula, após as da configuração: "+ruleNames())
+	maxLineLength := flags.Int("max-line-length", 0, "número máximo de caracteres de uma linha, 0 para o da configuração")
+	tabWidth := flags.Int("tab-width", 0, "número de colunas de uma tabulação no tamanho das linhas, 0 para o da configuração")
+	parseFlags(flags, args)
+	paths, err := sourcePaths(flags)
+	if err != nil {
+		return err
+	}
+
+	config := project.Lint
+	if *configPath != "" {
+		if err := config.Load(*configPath); err != nil {
+			return usageError{err}
+		}
+	}
+	if err := config.Enable(splitNames(*enable)...); err != nil {
+		return usageError{err}
+	}
+	if err := config.Disable(splitNames(*disable)...); err != nil {
+		return usageError{err}
+	}
+	if *maxLineLength > 0 {
+		config.MaxLineLength = *maxLineLength
+	}
+	if *tabWidth > 0 {
+		config.TabWidth = *tabWidth
+	}
+	return compileAll(paths, func(path string) ([]errorhandling.Diagnostic, error) {
+		return lintFile(path, options, config)
+	})
+}
+
+// lintFile returns the warnings of the linter on the program on path.
+// The ones of the type checker are left to check, unless the program
+// has errors, which are returned instead
+func lintFile(path string, options *checkOptions, config lint.Config) ([]errorhandling.Diagnostic, error) {
+	c, diagnostics, err := compile([]string{path}, options, false)
+	if err != nil {
+		return diagnostics, err
+	}
+	source, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return lint.Lint(c.result.Program, c.info, source, config), nil
+}
+
+// ruleNames lists the names of the rules of the linter
+func ruleNames() string {
+	var names []string
+	for _, rule := range lint.Rules() {
+		names = append(names, rule.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitNames returns the names of list, separated by commas
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
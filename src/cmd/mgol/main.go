@@ -0,0 +1,1426 @@
+// Command mgol compiles and runs programs of mgol, with a subcommand
+// for each phase of the compiler:
+//
+//	go run ./src/cmd/mgol lex programa.mgol
+//	go run ./src/cmd/mgol parse programa.mgol
+//	go run ./src/cmd/mgol check programa.mgol
+//	go run ./src/cmd/mgol build -target c programa.mgol
+//	go run ./src/cmd/mgol lint -disable numero-magico programa.mgol
+//	go run ./src/cmd/mgol run programa.mgol
+//
+// lex lists the tokens, parse writes the syntax tree, check also
+// checks the types, build writes the program for a target, lint
+// warns about what could be written better, and run runs it on the
+// interpreter. The errors are written on the standard error, ending
+// the command with the status of the exitcode package: 1 for lexical
+// or syntax errors, 2 for semantic ones. The rules of the grammar are
+// kept on the code, so it runs from any directory.
+//
+// build -watch generates the program again each time its file changes,
+// for an edit and compile loop:
+//
+//	go run ./src/cmd/mgol build -watch programa.mgol
+//
+// build -cache reads the programs built before, unchanged, from the
+// cache of the buildcache package, which cache stats and cache clean
+// look at and empty:
+//
+//	go run ./src/cmd/mgol build -cache 'lista3/*.mgol'
+//	go run ./src/cmd/mgol cache clean -older-than 720h
+//
+// build and run write profiles of the command with -cpuprofile,
+// -memprofile and -trace, to be sent along with the reports of
+// programs that take too long to compile:
+//
+//	go run ./src/cmd/mgol build -cpuprofile cpu.pprof grande.mgol
+//	go tool pprof cpu.pprof
+//
+// lsp is run by an editor, to which it shows the errors as the
+// program is written, with the lsp package:
+//
+//	go run ./src/cmd/mgol lsp
+//
+// explain describes a code of the diagnostics at length, with a
+// program that has the problem and the same program fixed:
+//
+//	go run ./src/cmd/mgol explain S013
+//
+// lex -dfa-trace writes each step the automaton of the scanner takes,
+// to see why a text splits into the tokens it does:
+//
+//	go run ./src/cmd/mgol lex -dfa-trace programa.mgol
+//
+// highlight writes the program with its tokens colored, as html for
+// reports and course pages or for the terminal:
+//
+//	go run ./src/cmd/mgol highlight -page -o programa.html programa.mgol
+//
+// The defaults of the flags come from the mgol.toml or .mgolrc of
+// the current directory, or of the nearest one above it, read with
+// the config package. When it lists sources, build and check with
+// no files take them as the files of one program:
+//
+//	go run ./src/cmd/mgol build
+//
+// completion writes the script that completes the commands, their
+// flags and the names of the targets on bash, zsh or fish:
+//
+//	source <(go run ./src/cmd/mgol completion bash)
+//
+// -lang-profile, before the command, picks the dialect of mgol the
+// programs are written on, one known by the lexer package or a file
+// with its keywords and the states added to the automaton:
+//
+//	go run ./src/cmd/mgol -lang-profile mgol-en run programa.mgol
+//
+// -porcelain, before the command, writes the diagnostics and the
+// summaries as lines of fields separated by tabs, which stay the same
+// on each version of the output, for scripts and golden tests:
+//
+//	go run ./src/cmd/mgol -porcelain=v1 check 'lista3/*.mgol'
+//
+// serve answers the playground, a web page where the programs are
+// written and run, with the playground package:
+//
+//	go run ./src/cmd/mgol serve -addr :8080 -allow-origin '*'
+//
+// daemon keeps a process running for the plugins of the editors and
+// the machines that grade the exercises, which send it the programs
+// over JSON-RPC, with the daemon package, on the standard input and
+// output or on the address of -addr:
+//
+//	go run ./src/cmd/mgol daemon -addr :7070 -max-concurrent 8 -timeout 10s
+//
+// testsuite checks the programs of a directory, like the exercises
+// of a course, against the outputs and diagnostics expected of them,
+// on the files next to them that the corpus package reads:
+//
+//	go run ./src/cmd/mgol testsuite -format json exercicios
+//
+// version tells which compiler is running: its version, the commit it
+// was built from and the revisions of the language and the grammar,
+// which the version package reads:
+//
+//	go run ./src/cmd/mgol version -json
+//
+// The program is read from the standard input when its file is -,
+// leaving nothing for the leia of run to read:
+//
+//	cat programa.mgol | go run ./src/cmd/mgol build -o - -
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	_ "mgol-go/src/asmgen"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/buildcache"
+	_ "mgol-go/src/bytecode"
+	"mgol-go/src/config"
+	"mgol-go/src/corpus"
+	"mgol-go/src/daemon"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/exitcode"
+	"mgol-go/src/explain"
+	_ "mgol-go/src/gogen"
+	"mgol-go/src/grade"
+	"mgol-go/src/ir"
+	_ "mgol-go/src/jsgen"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	_ "mgol-go/src/llvmgen"
+	"mgol-go/src/lsp"
+	"mgol-go/src/metrics"
+	"mgol-go/src/mgol"
+	"mgol-go/src/parser"
+	"mgol-go/src/pipeline"
+	"mgol-go/src/playground"
+	_ "mgol-go/src/pygen"
+	"mgol-go/src/sem"
+	"mgol-go/src/version"
+	_ "mgol-go/src/wasmgen"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// targetC is the target of the code written by the semantic actions
+const targetC = "c"
+
+// command is a subcommand, run with the arguments after its name
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"lex":       {"lista os tokens do programa", lex},
+	"highlight": {"escreve o programa colorido, em html ou para o terminal", highlight},
+	"parse":     {"verifica a sintaxe e escreve a árvore sintática", parse},
+	"check":     {"verifica a sintaxe e os tipos", check},
+	"build":     {"gera o programa para um alvo", build},
+	"cache":     {"mostra as estatísticas do cache de compilação ou o limpa", cacheCommand},
+	"daemon":    {"serviço de JSON-RPC que compila e executa os programas, na entrada e saída padrão ou em TCP", daemonCommand},
+	"lint":      {"aponta o que pode ser escrito melhor no programa", lintPrograms},
+	"explain":   {"explica um código de erro ou aviso, como S001, com exemplos", explainCode},
+	"lsp":       {"servidor do Language Server Protocol para editores, na entrada e saída padrão", languageServer},
+	"run":       {"executa o programa com o interpretador", run},
+	"serve":     {"serviço HTTP do playground, que compila e executa os programas enviados em json", serve},
+	"testsuite": {"verifica os programas de um diretório com as saídas e os diagnósticos esperados", testsuite},
+	"version":   {"mostra a versão, o commit e a revisão da linguagem e da gramática", showVersion},
+}
+
+// rejectedError ends a command whose program has errors, which were
+// already shown, with the status of the phase that found them
+type rejectedError struct {
+	status int
+}
+
+func (rejectedError) Error() string {
+	return "o programa tem erros"
+}
+
+// rejected returns the error of a program with diagnostics,
+// which has errors
+func rejected(diagnostics []errorhandling.Diagnostic) error {
+	status := exitcode.ForDiagnostics(diagnostics)
+	if status == exitcode.Success {
+		// The parser stopped without telling why
+		status = exitcode.Syntax
+	}
+	return rejectedError{status}
+}
+
+// usageError is an error on the arguments of a command
+type usageError struct {
+	error
+}
+
+// usageErrorf returns a usageError with a message in format
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// failedError stops a program that was run
+type failedError struct {
+	error
+}
+
+// status returns the status of a command that failed with err. The
+// errors that are none of the ones above, like the ones writing a
+// file, are internal
+func status(err error) int {
+	var rejected rejectedError
+	var usage usageError
+	var failed failedError
+	switch {
+	case errors.As(err, &rejected):
+		return rejected.status
+	case errors.As(err, &usage), errors.Is(err, os.ErrNotExist), errors.Is(err, lexer.ErrorUnknownDumpFormat), errors.Is(err, lexer.ErrorUnknownHighlightFormat):
+		return exitcode.Usage
+	case errors.As(err, &failed):
+		return exitcode.Runtime
+	}
+	return exitcode.Internal
+}
+
+func main() {
+	defer exitcode.Recover()
+	log.SetFlags(0)
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.Usage = usage
+	flag.CommandLine.Var(&porcelain, "porcelain", "escreve os diagnósticos e resumos em linhas estáveis para scripts, na versão dada, v1 por padrão")
+	profile := flag.CommandLine.String("lang-profile", "", "dialeto do mgol em que os programas são escritos: "+strings.Join(lexer.ProfileNames(), ", ")+", ou o arquivo do seu perfil")
+	parseFlags(flag.CommandLine, os.Args[1:])
+	var err error
+	if project, err = config.ForDirectory("."); err != nil {
+		showFailure(err, exitcode.Usage)
+		os.Exit(exitcode.Usage)
+	}
+	if *profile != "" {
+		language, err = config.LoadLanguage(*profile)
+	} else {
+		language, err = project.LoadLanguage()
+	}
+	if err != nil {
+		showFailure(err, exitcode.Usage)
+		os.Exit(exitcode.Usage)
+	}
+	found := false
+	var selected command
+	if flag.NArg() > 0 {
+		selected, found = commands[flag.Arg(0)]
+	}
+	if !found {
+		usage()
+		os.Exit(exitcode.Usage)
+	}
+	if err := selected.run(flag.Args()[1:]); err != nil {
+		if _, shown := err.(rejectedError); !shown {
+			showFailure(err, status(err))
+		}
+		os.Exit(status(err))
+	}
+}
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), "uso: mgol [-porcelain] [-lang-profile PERFIL] COMANDO [opções] ARQUIVO")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s %s\n", name, commands[name].description)
+	}
+}
+
+// project holds the defaults of the configuration file of the
+// current directory, which the flags override
+var project = config.Default()
+
+// language is the dialect of -lang-profile, or else of the project
+var language = lexer.DefaultLanguage()
+
+// checkOptions are the flags of the commands that check the types
+type checkOptions struct {
+	narrowing sem.Strictness
+	promotion sem.Strictness
+	implicit  bool
+}
+
+// parseFlags parses args on flags, ending the command with
+// exitcode.Usage when they are wrong, once flags told why
+func parseFlags(flags *flag.FlagSet, args []string) {
+	if collectingFlags {
+		panic(collectedFlags{flags})
+	}
+	switch err := flags.Parse(args); {
+	case err == flag.ErrHelp:
+		os.Exit(exitcode.Success)
+	case err != nil:
+		os.Exit(exitcode.Usage)
+	}
+}
+
+// newFlagSet returns the flags of the command name, with the ones of
+// the type checker set on options when it is not nil
+func newFlagSet(name string, options *checkOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "uso: mgol %s [opções] ARQUIVO\n", name)
+		flags.PrintDefaults()
+	}
+	if options != nil {
+		options.narrowing, options.promotion = sem.Warn, sem.Warn
+		flags.Var(strictnessFlag{&options.narrowing}, "narrowing", "atribuição de um real a uma variável inteiro: permitir, avisar ou proibir")
+		flags.Var(strictnessFlag{&options.promotion}, "promotion", "uso de um inteiro como real, em operações ou atribuições: permitir, avisar ou proibir")
+		flags.BoolVar(&options.implicit, "implicit", false, "declara as variáveis na primeira atribuição, com o tipo do valor atribuído")
+	}
+	return flags
+}
+
+// strictnessFlag is a flag holding a sem.Strictness by its name
+type strictnessFlag struct {
+	strictness *sem.Strictness
+}
+
+func (f strictnessFlag) String() string {
+	if f.strictness == nil {
+		return ""
+	}
+	return f.strictness.String()
+}
+
+func (f strictnessFlag) Set(name string) error {
+	strictness, err := sem.ParseStrictness(name)
+	if err == nil {
+		*f.strictness = strictness
+	}
+	return err
+}
+
+// levelFlag is a flag holding an ir.Level by its name, or, when
+// fixed is set, a boolean one setting that level, like -O2
+type levelFlag struct {
+	level *ir.Level
+	fixed string
+}
+
+func (f levelFlag) String() string {
+	if f.level == nil || f.fixed != "" {
+		return ""
+	}
+	return f.level.String()
+}
+
+func (f levelFlag) Set(name string) error {
+	if f.fixed != "" {
+		if name != "true" {
+			return fmt.Errorf("-O%s não recebe valor", f.fixed)
+		}
+		name = f.fixed
+	}
+	level, err := ir.ParseLevel(name)
+	if err == nil {
+		*f.level = level
+	}
+	return err
+}
+
+func (f levelFlag) IsBoolFlag() bool {
+	return f.fixed != ""
+}
+
+// registerLevel adds -O to flags, along with -O0, -O1 and -O2,
+// setting level
+func registerLevel(flags *flag.FlagSet, level *ir.Level) {
+	*level = project.Optimization
+	flags.Var(levelFlag{level: level}, "O", "nível de otimização do código de três endereços, nos alvos gerados a partir dele: 0, nenhuma, 1, propagação de constantes, ou 2, também remoção do código sem uso e peephole")
+	for _, fixed := range []ir.Level{ir.O0, ir.O1, ir.O2} {
+		flags.Var(levelFlag{level: level, fixed: fixed.String()}, "O"+fixed.String(), "o mesmo que -O "+fixed.String())
+	}
+}
+
+// stdinPath is the file name that reads the program from the
+// standard input, like when it is piped
+const stdinPath = "-"
+
+// stdinSource holds the program read from the standard input,
+// as it can only be read once
+var (
+	stdinSource *string
+	stdinMutex  sync.Mutex
+)
+
+// readSource returns the program on path, or the one on the standard
+// input if path is stdinPath. The scanners go back on the text, which
+// a pipe does not allow, so it is read whole
+func readSource(path string) (string, error) {
+	if path != stdinPath {
+		source, err := ioutil.ReadFile(path)
+		return string(source), err
+	}
+	stdinMutex.Lock()
+	defer stdinMutex.Unlock()
+	if stdinSource == nil {
+		source, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		text := string(source)
+		stdinSource = &text
+	}
+	return *stdinSource, nil
+}
+
+// sourcePath returns the file given to flags, which must be one
+func sourcePath(flags *flag.FlagSet) string {
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(exitcode.Usage)
+	}
+	return flags.Arg(0)
+}
+
+// compiled is a program parsed and, maybe, checked
+type compiled struct {
+	parser      *parser.RecursiveDescentParser
+	result      *parser.ParseResult
+	info        *sem.Info
+	symbolTable *lexer.SymbolTable
+}
+
+// compile parses the program on paths, usually one, read in order as
+// a single program, and, with options, checks its types. The C code is
+// only generated with generate. The diagnostics are returned, along
+// with a rejectedError if any is an error. The program rejected is
+// still returned, for the listing of its errors
+func compile(paths []string, options *checkOptions, generate bool) (*compiled, []errorhandling.Diagnostic, error) {
+	return compileMeasured(paths, options, generate, nil)
+}
+
+// compileMeasured compiles like compile, adding to report, when
+// it is not nil, what each phase took and how many tokens and
+// nodes of the syntax tree the program has
+func compileMeasured(paths []string, options *checkOptions, generate bool, report *metrics.Report) (*compiled, []errorhandling.Diagnostic, error) {
+	files := make([]pipeline.File, len(paths))
+	for index, path := range paths {
+		source, err := readSource(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[index].Text = source
+		if len(paths) > 1 {
+			files[index].Name = path
+		}
+	}
+
+	c := &compiled{}
+	s := &stages{compiled: c, options: options, generate: generate, report: report, scanStats: &lexer.ScanStats{}}
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	// The semantic actions already logged the operands with
+	// different types when they failed
+	operandTypes := sem.Code(sem.ErrorOperandTypes)
+	_, err := pipeline.Pipeline{
+		Language: language,
+		Parser:   s,
+		Checker:  s,
+		// The time of the scanners is taken from the one of the
+		// parser, so they only scan as it reads their tokens
+		Sequential: report != nil,
+		Configure: func(scanner *lexer.Scanner) {
+			scanner.SetKeywordCaseWarning(project.KeywordCaseWarning())
+			if report != nil {
+				scanner.SetStats(s.scanStats)
+			}
+		},
+		Diagnostics: func(diagnostic pipeline.Diagnostic) {
+			if c.result == nil || !c.result.SemanticErrorFound || diagnostic.Code != operandTypes {
+				diagnostics.Add(diagnostic.Diagnostic)
+			}
+		},
+	}.Compile(context.Background(), files, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := diagnostics.Diagnostics()
+	if exitcode.ForDiagnostics(found) != exitcode.Success || !c.result.Accepted {
+		return c, found, rejected(found)
+	}
+	if c.result.SemanticErrorFound {
+		return c, found, rejectedError{exitcode.Semantic}
+	}
+	return c, found, nil
+}
+
+// stages are the parser and the checker the pipeline compiles
+// a program through, which keep what they give on compiled
+type stages struct {
+	*compiled
+	options  *checkOptions
+	generate bool
+	report   *metrics.Report
+	// The scanners add what they took to scanStats
+	scanStats *lexer.ScanStats
+}
+
+// Parse parses the program, writing its C code with generate
+func (s *stages) Parse(symbolTable *lexer.SymbolTable, sources []lexer.TokenSource) *parser.ParseResult {
+	s.symbolTable = symbolTable
+	s.parser = parser.NewSourceParser(symbolTable, parser.DefaultRules(), sources...)
+	s.parser.SetQuiet(true)
+	s.parser.SetSemanticActions(s.generate)
+	if s.options != nil {
+		s.parser.SetImplicitDeclarations(s.options.implicit)
+	}
+	parsing := measure(s.report, func() { s.result = s.parser.Parse() })
+	if s.report != nil {
+		s.report.AddPhase("léxica", s.scanStats.Sample)
+		s.report.AddPhase("sintática", parsing.Sub(s.scanStats.Sample))
+		s.report.AddCount("tokens", s.scanStats.Tokens)
+		if s.result.Program != nil {
+			s.report.AddCount("nós da árvore sintática", ast.CountNodes(s.result.Program))
+		}
+	}
+	return s.result
+}
+
+// Check checks the types of the program with the options, unless
+// there are none or the program has syntax errors
+func (s *stages) Check(program *ast.Program, symbolTable *lexer.SymbolTable) *sem.Info {
+	if s.options == nil || len(s.result.Errors) > 0 {
+		return &sem.Info{}
+	}
+	checker := pipeline.TypeChecker{
+		Narrowing: s.options.narrowing,
+		Promotion: s.options.promotion,
+		Implicit:  s.options.implicit,
+	}
+	checking := measure(s.report, func() { s.info = checker.Check(program, symbolTable) })
+	if s.report != nil {
+		s.report.AddPhase("semântica", checking)
+	}
+	return s.info
+}
+
+// measure runs run, returning what it took when report is not nil,
+// as measuring stops the program for a moment
+func measure(report *metrics.Report, run func()) metrics.Sample {
+	if report == nil {
+		run()
+		return metrics.Sample{}
+	}
+	return metrics.Measure(run)
+}
+
+// listingUsage describes the -listing flag
+const listingUsage = "arquivo onde é escrita a listagem da compilação: as linhas numeradas do programa com os seus erros e avisos, a contagem dos tokens e a tabela de símbolos, - para a saída padrão. Apenas com um programa"
+
+// listing returns the listing of the program on path compiled into
+// c, with its diagnostics under the lines they are on
+func listing(path string, c *compiled, diagnostics []errorhandling.Diagnostic) ([]byte, error) {
+	source, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+	var written bytes.Buffer
+	err = lexer.WriteSourceListing(&written, source, diagnostics, c.symbolTable)
+	return written.Bytes(), err
+}
+
+// showDiagnostics writes the diagnostics of the program on path on
+// the standard error, telling whether any of them is an error. The
+// path is only written on the porcelain output
+func showDiagnostics(path string, diagnostics []errorhandling.Diagnostic) bool {
+	failed := false
+	for _, diagnostic := range diagnostics {
+		if porcelain != 0 {
+			logDiagnostic(path, diagnostic)
+		} else {
+			log.Print(diagnostic)
+		}
+		failed = failed || diagnostic.Severity == errorhandling.Error
+	}
+	return failed
+}
+
+// scan returns the tokens of the program on path, the comments and
+// errors included, along with the lexical diagnostics
+func scan(path string, trace lexer.TraceHandler) ([]lexer.ScannedToken, []errorhandling.Diagnostic, error) {
+	source, err := readSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	scanner := lexer.NewStringScanner(source, symbolTable)
+	scanner.SetLanguage(language)
+	scanner.SetKeywordCaseWarning(project.KeywordCaseWarning())
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+	scanner.SetTrace(trace)
+	var tokens []lexer.ScannedToken
+	for {
+		token, line, column := scanner.Scan()
+		if token == lexer.EOF_TOKEN {
+			return tokens, diagnostics.Diagnostics(), nil
+		}
+		tokens = append(tokens, lexer.ScannedToken{Token: token, Start: scanner.TokenStart(), End: lexer.Position{Line: line, Column: column}})
+	}
+}
+
+// tokensUsage describes the -tokens flag
+const tokensUsage = "formato em que os tokens são escritos na saída padrão: tabela, json ou csv"
+
+func lex(args []string) error {
+	flags := newFlagSet("lex", nil)
+	// The columns of the table depend on the widest token
+	defaultFormat := lexer.TableFormat
+	if porcelain != 0 {
+		defaultFormat = lexer.CSVFormat
+	}
+	format := flags.String("tokens", string(defaultFormat), tokensUsage)
+	dfaTrace := flags.Bool("dfa-trace", false, "escreve cada passo do autômato do analisador léxico, com o estado, o caractere lido, o próximo estado e a ação, em vez dos tokens")
+	parseFlags(flags, args)
+	path := sourcePath(flags)
+	var trace lexer.TraceHandler
+	if *dfaTrace {
+		trace = lexer.NewTraceWriter(os.Stdout)
+	}
+	tokens, diagnostics, err := scan(path, trace)
+	if err != nil {
+		return err
+	}
+	if !*dfaTrace {
+		if err := lexer.DumpTokens(os.Stdout, tokens, lexer.DumpFormat(*format)); err != nil {
+			return err
+		}
+	}
+	if showDiagnostics(path, diagnostics) {
+		return rejected(diagnostics)
+	}
+	return nil
+}
+
+func highlight(args []string) error {
+	flags := newFlagSet("highlight", nil)
+	format := flags.String("format", string(lexer.HTMLHighlight), "formato do programa colorido: html, com uma classe por categoria de token, ou ansi, para o terminal")
+	page := flags.Bool("page", false, "escreve uma página html completa, com as cores, em vez de apenas o trecho <pre>")
+	output := flags.String("o", "-", "arquivo onde o programa colorido é escrito, - para a saída padrão")
+	parseFlags(flags, args)
+	if *page && lexer.HighlightFormat(*format) != lexer.HTMLHighlight {
+		return usageErrorf("-page só pode ser usado com -format html")
+	}
+	path := sourcePath(flags)
+	source, err := readSource(path)
+	if err != nil {
+		return err
+	}
+	return writeFile(*output, func(w io.Writer) error {
+		if !*page {
+			return lexer.Highlight(w, source, lexer.HighlightFormat(*format))
+		}
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n", html.EscapeString(filepath.Base(path)), lexer.HighlightCSS)
+		if err := lexer.Highlight(w, source, lexer.HTMLHighlight); err != nil {
+			return err
+		}
+		_, err := fmt.Fprint(w, "</body>\n</html>\n")
+		return err
+	})
+}
+
+// astFormats write the syntax tree on the formats of -ast: as an
+// indented tree, as json or back as source
+var astFormats = map[string]func(io.Writer, ast.Node) error{
+	"texto": ast.Dump,
+	"json":  ast.EncodeJSON,
+	"fonte": ast.Fprint,
+}
+
+// astUsage describes the -ast flag
+const astUsage = "formato em que a árvore sintática é escrita na saída padrão: texto, indentada, json ou fonte, de volta como programa"
+
+// writeAST writes program on the standard output with the -ast format
+func writeAST(program *ast.Program, format string) error {
+	encode, found := astFormats[format]
+	if !found {
+		return usageErrorf("formato de árvore sintática desconhecido: %s", format)
+	}
+	return encode(os.Stdout, program)
+}
+
+func parse(args []string) error {
+	flags := newFlagSet("parse", nil)
+	format := flags.String("ast", "texto", astUsage)
+	parseFlags(flags, args)
+	path := sourcePath(flags)
+	c, diagnostics, err := compile([]string{path}, nil, false)
+	showDiagnostics(path, diagnostics)
+	if err != nil {
+		return err
+	}
+	return writeAST(c.result.Program, *format)
+}
+
+func check(args []string) error {
+	options := &checkOptions{}
+	flags := newFlagSet("check", options)
+	listingPath := flags.String("listing", "", listingUsage)
+	parseFlags(flags, args)
+	paths, manifest, err := programPaths(flags)
+	if err != nil {
+		return err
+	}
+	if *listingPath != "" && len(paths) > 1 {
+		return usageErrorf("-listing só pode ser usado com um programa de um arquivo")
+	}
+	checkProgram := func(paths []string) ([]errorhandling.Diagnostic, error) {
+		// The semantic actions tell the lines of the declarations
+		// on the symbol table of the listing
+		c, diagnostics, err := compile(paths, options, *listingPath != "")
+		if *listingPath == "" || c == nil {
+			return diagnostics, err
+		}
+		// The listing is written for the program rejected as well
+		written, listingErr := listing(paths[0], c, diagnostics)
+		if listingErr == nil {
+			listingErr = writeFile(*listingPath, func(w io.Writer) error {
+				_, err := w.Write(written)
+				return err
+			})
+		}
+		return diagnostics, keepFirst(listingErr, err)
+	}
+	if manifest {
+		// The diagnostics of a program split among files tell no file
+		diagnostics, err := checkProgram(paths)
+		showDiagnostics("", diagnostics)
+		return err
+	}
+	return compileAll(paths, func(path string) ([]errorhandling.Diagnostic, error) {
+		return checkProgram([]string{path})
+	})
+}
+
+// buildSettings are the flags of build
+type buildSettings struct {
+	options        *checkOptions
+	target         backend.Backend
+	extension      string
+	output         string
+	decimalComma   bool
+	sourceComments bool
+	tokens         string
+	ast            string
+	listing        string
+	emitIR         string
+	optimization   ir.Level
+	// stats, when set, gets what the phases of the program took
+	stats *metrics.Report
+	// cache, when set, holds the programs already built
+	cache *buildcache.Cache
+}
+
+func build(args []string) (err error) {
+	settings := buildSettings{options: &checkOptions{}, extension: ".c"}
+	flags := newFlagSet("build", settings.options)
+	targetName := flags.String("target", project.Target, "alvo para o qual o programa é gerado: "+strings.Join(append([]string{targetC}, backend.Names()...), ", "))
+	flags.StringVar(&settings.output, "o", "", "arquivo onde o programa é escrito, - para a saída padrão, apenas com um programa. Por padrão, o do programa com a extensão do alvo")
+	flags.BoolVar(&settings.decimalComma, "decimal-comma", project.DecimalComma(), "escreve os reais com vírgula, como 3,140000, em vez de ponto")
+	flags.BoolVar(&settings.sourceComments, "source-comments", false, "escreve cada comando do programa como comentário antes do seu código em C, com a sua linha, apenas com o alvo c")
+	flags.StringVar(&settings.tokens, "tokens", "", tokensUsage+", antes de gerar o programa, apenas com um programa")
+	flags.StringVar(&settings.ast, "ast", "", astUsage+", antes de gerar o programa, apenas com um programa")
+	flags.StringVar(&settings.listing, "listing", "", listingUsage)
+	flags.StringVar(&settings.emitIR, "emit-ir", "", "arquivo onde o código de três endereços é escrito, após as otimizações de -O, - para a saída padrão, apenas com um programa")
+	registerLevel(flags, &settings.optimization)
+	showStats := flags.Bool("stats", false, "mostra na saída de erros o tempo e as alocações de cada fase, e quantos tokens, nós da árvore sintática e instruções do código de três endereços o programa tem, apenas com um programa. Os programas lidos do cache não são medidos")
+	watching := flags.Bool("watch", false, "gera o programa de novo a cada alteração dos arquivos, até ser interrompido")
+	cached := flags.Bool("cache", false, "reaproveita os programas já gerados do cache de compilação, guardando os novos nele")
+	cacheDir := flags.String("cache-dir", buildcache.DefaultDir(), cacheDirUsage)
+	profiling := &profiles{}
+	profiling.register(flags)
+	parseFlags(flags, args)
+	paths, manifest, err := programPaths(flags)
+	if err != nil {
+		return err
+	}
+	if !manifest && len(paths) > 1 && (settings.output != "" || settings.tokens != "" || settings.ast != "") {
+		return usageErrorf("-o, -tokens e -ast só podem ser usados com um programa")
+	}
+	if settings.listing != "" && len(paths) > 1 {
+		return usageErrorf("-listing só pode ser usado com um programa de um arquivo")
+	}
+	if settings.emitIR != "" && !manifest && len(paths) > 1 {
+		return usageErrorf("-emit-ir só pode ser usado com um programa")
+	}
+	// The allocations are counted on the whole process, so
+	// the programs compiled in parallel can not be told apart
+	if *showStats && !manifest && len(paths) > 1 {
+		return usageErrorf("-stats só pode ser usado com um programa")
+	}
+	// The profiles are written as the command returns, which -watch never does
+	if *watching && profiling.enabled() {
+		return usageErrorf("-cpuprofile, -memprofile e -trace não podem ser usados com -watch")
+	}
+
+	if *targetName != targetC {
+		var found bool
+		if settings.target, found = backend.Lookup(*targetName); !found {
+			return usageErrorf("alvo desconhecido: %s, os disponíveis são %s, %s", *targetName, targetC, strings.Join(backend.Names(), ", "))
+		}
+		if configurable, found := settings.target.(backend.Configurable); found {
+			settings.target = configurable.WithOptions(backend.Options{DecimalComma: settings.decimalComma})
+		}
+		settings.extension = settings.target.FileExtension()
+	}
+	if settings.sourceComments && settings.target != nil {
+		return usageErrorf("-source-comments só pode ser usado com o alvo %s", targetC)
+	}
+	if manifest && settings.output == "" {
+		settings.output = project.OutputPath()
+		if settings.output == "" {
+			settings.output = filepath.Join(project.Dir(), "programa"+settings.extension)
+		}
+		// The manifest may build the program on a directory of its own
+		if err := os.MkdirAll(filepath.Dir(settings.output), 0755); err != nil {
+			return err
+		}
+	}
+	switch {
+	case *cached:
+		if settings.cache, err = buildcache.Open(*cacheDir); err != nil {
+			return err
+		}
+		defer func() { err = keepFirst(err, settings.cache.Close()) }()
+	case *watching:
+		// Only the programs changed are built again
+		settings.cache = buildcache.NewMemory()
+	}
+	buildAll := func() error {
+		if *showStats {
+			settings.stats = &metrics.Report{}
+		}
+		var err error
+		if manifest {
+			var diagnostics []errorhandling.Diagnostic
+			diagnostics, err = buildProgram(paths, settings)
+			showDiagnostics("", diagnostics)
+		} else {
+			err = compileAll(paths, func(path string) ([]errorhandling.Diagnostic, error) {
+				return buildFile(path, settings)
+			})
+		}
+		if settings.stats != nil && !settings.stats.Empty() {
+			err = keepFirst(err, settings.stats.Write(os.Stderr))
+		}
+		return err
+	}
+	if *watching {
+		return watch(paths, func() error {
+			// The runtime files may have been removed meanwhile
+			runtimeMutex.Lock()
+			runtimeWritten = make(map[string]bool)
+			runtimeMutex.Unlock()
+			return buildAll()
+		})
+	}
+	stop, err := profiling.start()
+	if err != nil {
+		return err
+	}
+	defer func() { err = keepFirst(err, stop()) }()
+	return buildAll()
+}
+
+// buildFile writes the program on path for the target of settings,
+// by default on a file named like it with the extension of the target
+func buildFile(path string, settings buildSettings) ([]errorhandling.Diagnostic, error) {
+	if settings.output == "" {
+		name := strings.TrimSuffix(path, filepath.Ext(path))
+		if path == stdinPath {
+			name = "programa"
+		}
+		settings.output = name + settings.extension
+	}
+	return buildProgram([]string{path}, settings)
+}
+
+// buildProgram writes the program split among paths for the
+// target of settings on its output, reading it from the cache
+// of settings when it was built before
+func buildProgram(paths []string, settings buildSettings) ([]errorhandling.Diagnostic, error) {
+	var key buildcache.Key
+	var entry buildcache.Entry
+	found := false
+	if settings.cache != nil {
+		var err error
+		if key, err = buildKey(paths, settings); err != nil {
+			return nil, err
+		}
+		entry, found = settings.cache.Get(key)
+	}
+	if !found {
+		var err error
+		if entry, err = buildEntry(paths, settings); err != nil {
+			return entry.Diagnostics, err
+		}
+		if settings.cache != nil {
+			if err := settings.cache.Put(key, entry); err != nil {
+				return entry.Diagnostics, err
+			}
+		}
+	}
+
+	if _, err := os.Stdout.Write(entry.Tokens); err != nil {
+		return entry.Diagnostics, err
+	}
+	if settings.listing != "" {
+		err := writeFile(settings.listing, func(w io.Writer) error {
+			_, err := w.Write(entry.Listing)
+			return err
+		})
+		if err != nil {
+			return entry.Diagnostics, err
+		}
+	}
+	if entry.Status != exitcode.Success {
+		return entry.Diagnostics, rejectedError{entry.Status}
+	}
+	if _, err := os.Stdout.Write(entry.AST); err != nil {
+		return entry.Diagnostics, err
+	}
+	output := settings.output
+	err := writeFile(output, func(w io.Writer) error {
+		_, err := w.Write(entry.Output)
+		return err
+	})
+	if err == nil && settings.emitIR != "" {
+		err = writeFile(settings.emitIR, func(w io.Writer) error {
+			_, err := w.Write(entry.IR)
+			return err
+		})
+	}
+	for runtimePath, contents := range entry.Runtime {
+		if err != nil {
+			break
+		}
+		err = writeRuntime(filepath.Join(filepath.Dir(output), runtimePath), contents)
+	}
+	return entry.Diagnostics, err
+}
+
+// buildEntry builds the program split among paths for the target of
+// settings. The program rejected is not an error, but has the status
+// of its errors on the entry
+func buildEntry(paths []string, settings buildSettings) (buildcache.Entry, error) {
+	var entry buildcache.Entry
+	// The lexical diagnostics are returned by compile
+	var tokens bytes.Buffer
+	for index := 0; settings.tokens != "" && index < len(paths); index++ {
+		scanned, _, err := scan(paths[index], nil)
+		if err == nil {
+			err = lexer.DumpTokens(&tokens, scanned, lexer.DumpFormat(settings.tokens))
+		}
+		if err != nil {
+			return entry, err
+		}
+	}
+	entry.Tokens = tokens.Bytes()
+
+	target := settings.target
+	c, diagnostics, err := compileMeasured(paths, settings.options, target == nil, settings.stats)
+	entry.Diagnostics = diagnostics
+	if settings.listing != "" && c != nil {
+		// The listing is written for the program rejected as well
+		var listingErr error
+		if entry.Listing, listingErr = listing(paths[0], c, diagnostics); listingErr != nil {
+			return entry, listingErr
+		}
+	}
+	var rejection rejectedError
+	if errors.As(err, &rejection) {
+		entry.Status = rejection.status
+		return entry, nil
+	}
+	if err != nil {
+		return entry, err
+	}
+	if settings.ast != "" {
+		encode, found := astFormats[settings.ast]
+		if !found {
+			return entry, usageErrorf("formato de árvore sintática desconhecido: %s", settings.ast)
+		}
+		var written bytes.Buffer
+		if err := encode(&written, c.result.Program); err != nil {
+			return entry, err
+		}
+		entry.AST = written.Bytes()
+	}
+
+	// The targets generated from three-address code take it
+	// optimized, while the others lower the program on their
+	// own, if at all, so it is lowered again for -emit-ir and
+	// -stats
+	fromIR, generatesIR := target.(backend.FromIR)
+	optimized := generatesIR && settings.optimization > ir.O0
+	var lowered *ir.Program
+	if optimized || settings.emitIR != "" || settings.stats != nil {
+		lowering := measure(settings.stats, func() { lowered, err = lower(c, settings.optimization) })
+		if err != nil {
+			return entry, err
+		}
+		if settings.stats != nil {
+			settings.stats.AddPhase("código de três endereços", lowering)
+			settings.stats.AddCount("instruções do código de três endereços", lowered.InstructionCount())
+		}
+	}
+	if settings.emitIR != "" {
+		entry.IR = []byte(lowered.String())
+	}
+
+	var code bytes.Buffer
+	generation := measure(settings.stats, func() {
+		switch {
+		case target == nil:
+			c.parser.SetDecimalComma(settings.decimalComma)
+			c.parser.SetSourceComments(settings.sourceComments)
+			err = c.parser.WriteCode(&code)
+			return
+		case optimized:
+			err = fromIR.GenerateIR(lowered, &code)
+		default:
+			err = target.Generate(c.result.Program, c.info, &code)
+		}
+		if withRuntime, found := target.(backend.WithRuntime); found {
+			entry.Runtime = withRuntime.Runtime()
+		}
+	})
+	if settings.stats != nil {
+		settings.stats.AddPhase("geração de código", generation)
+	}
+	entry.Output = code.Bytes()
+	return entry, err
+}
+
+// lower lowers the program compiled into c to three-address
+// code, optimized on level
+func lower(c *compiled, level ir.Level) (*ir.Program, error) {
+	lowered, err := ir.Lower(c.result.Program, c.info)
+	if err != nil {
+		return nil, err
+	}
+	lowered, _ = ir.Optimize(lowered, level)
+	return lowered, nil
+}
+
+// buildKey returns the key of the program split among paths on the
+// cache, which changes with every setting that changes what is built
+func buildKey(paths []string, settings buildSettings) (buildcache.Key, error) {
+	targetName := targetC
+	if settings.target != nil {
+		targetName = settings.target.Name()
+	}
+	keywords := []string{}
+	for keyword, class := range language.ReservedWords().Keywords() {
+		keywords = append(keywords, keyword+"="+string(class))
+	}
+	sort.Strings(keywords)
+	var automaton strings.Builder
+	if err := language.WriteAutomatonDOT(&automaton); err != nil {
+		return "", err
+	}
+	key := []string{
+		"build",
+		"target=" + targetName,
+		"decimal-comma=" + strconv.FormatBool(settings.decimalComma),
+		"source-comments=" + strconv.FormatBool(settings.sourceComments),
+		"optimization=" + settings.optimization.String(),
+		"narrowing=" + settings.options.narrowing.String(),
+		"promotion=" + settings.options.promotion.String(),
+		"implicit=" + strconv.FormatBool(settings.options.implicit),
+		"tokens=" + settings.tokens,
+		"ast=" + settings.ast,
+		"listing=" + strconv.FormatBool(settings.listing != ""),
+		"emit-ir=" + strconv.FormatBool(settings.emitIR != ""),
+		"keyword-case-warning=" + strconv.FormatBool(project.KeywordCaseWarning()),
+		"lang-profile=" + language.Name(),
+		"keywords=" + strings.Join(keywords, " "),
+		"automaton=" + automaton.String(),
+	}
+
+	// The names of the files are on the positions of a
+	// program split among them, but not of a single one
+	sources := make([]buildcache.Source, len(paths))
+	for index, path := range paths {
+		text, err := readSource(path)
+		if err != nil {
+			return "", err
+		}
+		sources[index].Text = text
+		if len(paths) > 1 {
+			sources[index].Name = path
+		}
+	}
+	return buildcache.KeyOf(key, sources...), nil
+}
+
+// runtimeWritten holds the runtime files written, as the programs
+// of a batch on the same directory share them
+var (
+	runtimeWritten = make(map[string]bool)
+	runtimeMutex   sync.Mutex
+)
+
+// writeRuntime writes contents on path, unless it was already written
+func writeRuntime(path, contents string) error {
+	runtimeMutex.Lock()
+	defer runtimeMutex.Unlock()
+	if runtimeWritten[path] {
+		return nil
+	}
+	runtimeWritten[path] = true
+	return writeFile(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, contents)
+		return err
+	})
+}
+
+func run(args []string) (err error) {
+	options := &checkOptions{}
+	flags := newFlagSet("run", options)
+	decimalComma := flags.Bool("decimal-comma", project.DecimalComma(), "escreve os reais com vírgula, como 3,140000, em vez de ponto")
+	maxSteps := flags.Int64("max-steps", 0, "número máximo de comandos executados, 0 para não haver limite")
+	timeout := flags.Duration("timeout", 0, "tempo máximo de execução, como 2s, 0 para não haver limite")
+	sandbox := flags.Bool("sandbox", false, "executa no perfil restrito, para servidores que recebem programas de qualquer um")
+	profiling := &profiles{}
+	profiling.register(flags)
+	parseFlags(flags, args)
+	path := sourcePath(flags)
+	source, err := readSource(path)
+	if err != nil {
+		return err
+	}
+	stop, err := profiling.start()
+	if err != nil {
+		return err
+	}
+	defer func() { err = keepFirst(err, stop()) }()
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	result := mgol.Run(ctx, source, os.Stdin, os.Stdout, mgol.Options{
+		Narrowing:            options.narrowing,
+		Promotion:            options.promotion,
+		Implicit:             options.implicit,
+		DecimalComma:         *decimalComma,
+		Limits:               limits.Limits{Steps: *maxSteps},
+		Sandbox:              *sandbox,
+		NoKeywordCaseWarning: !project.KeywordCaseWarning(),
+		Language:             language,
+	})
+	showDiagnostics(path, result.Diagnostics)
+	switch result.Status {
+	case mgol.Rejected:
+		return rejected(result.Diagnostics)
+	case mgol.Failed:
+		return failedError{result.Err}
+	}
+	return nil
+}
+
+// languageServer answers an editor on the standard input and output,
+// where nothing else may be written
+func languageServer(args []string) error {
+	flags := newFlagSet("lsp", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol lsp")
+	}
+	parseFlags(flags, args)
+	if flags.NArg() > 0 {
+		return usageErrorf("mgol lsp não recebe arquivos, eles são enviados pelo editor")
+	}
+	return lsp.NewServer(os.Stdin, os.Stdout).Run()
+}
+
+// serve answers the playground on address until it is interrupted.
+// The programs run for up to limits.SandboxTimeout, which the
+// timeouts of the server leave room for
+func serve(args []string) error {
+	flags := newFlagSet("serve", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol serve [opções]")
+		flags.PrintDefaults()
+	}
+	address := flags.String("addr", "localhost:8080", "endereço em que o serviço escuta, como :8080 para todas as interfaces")
+	allowOrigin := flags.String("allow-origin", "", "origem das páginas que podem chamar o serviço, como * ou https://curso.exemplo.br, nenhuma por padrão")
+	maxOutput := flags.Int("max-output", limits.DefaultMaxOutput, "número máximo de bytes da saída de um programa executado")
+	maxConcurrent := flags.Int("max-concurrent", runtime.NumCPU(), "número máximo de programas atendidos ao mesmo tempo, os excedentes recebem 503")
+	parseFlags(flags, args)
+	if flags.NArg() > 0 {
+		return usageErrorf("mgol serve não recebe arquivos, eles são enviados ao serviço")
+	}
+	if *maxOutput <= 0 {
+		return usageErrorf("-max-output deve ser positivo")
+	}
+	if *maxConcurrent <= 0 {
+		return usageErrorf("-max-concurrent deve ser positivo")
+	}
+	handler := playground.NewHandler()
+	handler.AllowOrigin = *allowOrigin
+	handler.MaxOutput = *maxOutput
+	handler.MaxConcurrent = *maxConcurrent
+	server := &http.Server{
+		Addr:         *address,
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	log.Printf("playground em http://%s", *address)
+	return server.ListenAndServe()
+}
+
+// daemonCommand answers the requests of JSON-RPC on the standard input
+// and output, where nothing else may be written, or on the
+// connections to address
+func daemonCommand(args []string) error {
+	flags := newFlagSet("daemon", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol daemon [opções]")
+		flags.PrintDefaults()
+	}
+	address := flags.String("addr", "", "endereço TCP em que o serviço escuta, como :7070, em vez da entrada e saída padrão")
+	maxConcurrent := flags.Int("max-concurrent", runtime.NumCPU(), "número máximo de requisições atendidas ao mesmo tempo")
+	timeout := flags.Duration("timeout", 0, "tempo máximo de cada requisição, como 10s, sem limite quando 0")
+	maxOutput := flags.Int("max-output", limits.DefaultMaxOutput, "número máximo de bytes da saída de um programa executado")
+	maxCache := flags.Int64("max-cache", daemon.DefaultMaxCacheBytes, "número máximo de bytes guardados no cache de check e build, descartando os usados há mais tempo")
+	parseFlags(flags, args)
+	if flags.NArg() > 0 {
+		return usageErrorf("mgol daemon não recebe arquivos, eles são enviados nas requisições")
+	}
+	if *maxConcurrent <= 0 {
+		return usageErrorf("-max-concurrent deve ser positivo")
+	}
+	if *timeout < 0 {
+		return usageErrorf("-timeout não pode ser negativo")
+	}
+	if *maxOutput <= 0 {
+		return usageErrorf("-max-output deve ser positivo")
+	}
+	if *maxCache <= 0 {
+		return usageErrorf("-max-cache deve ser positivo")
+	}
+	server := daemon.NewServer()
+	server.MaxConcurrent = *maxConcurrent
+	server.Timeout = *timeout
+	server.MaxOutput = *maxOutput
+	server.MaxCacheBytes = *maxCache
+	if *address == "" {
+		return server.Serve(os.Stdin, os.Stdout)
+	}
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		return err
+	}
+	log.Printf("daemon em %s", listener.Addr())
+	return server.Listen(listener)
+}
+
+// testsuite checks the programs of a directory against the files next
+// to them, writing the report on TAP or json. It fails with the status
+// of the first program that did not pass
+func testsuite(args []string) error {
+	options := &checkOptions{}
+	flags := newFlagSet("testsuite", options)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol testsuite [opções] DIRETÓRIO")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "tap", "formato do relatório: tap, do Test Anything Protocol, ou json")
+	useVM := flags.Bool("vm", false, "executa os programas compilados para bytecode na máquina virtual, em vez de no interpretador")
+	timeout := flags.Duration("timeout", 10*time.Second, "tempo máximo de execução de cada programa, 0 para não haver limite")
+	maxSteps := flags.Int64("max-steps", 0, "número máximo de comandos executados por programa, 0 para não haver limite")
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		return usageErrorf("mgol testsuite recebe um diretório só")
+	}
+	if *format != "tap" && *format != "json" {
+		return usageErrorf("formato de relatório desconhecido: %s, os disponíveis são tap e json", *format)
+	}
+
+	report, err := corpus.Run(flags.Arg(0), corpus.Options{
+		Check: mgol.Options{
+			Narrowing:            options.narrowing,
+			Promotion:            options.promotion,
+			Implicit:             options.implicit,
+			NoKeywordCaseWarning: !project.KeywordCaseWarning(),
+			Language:             language,
+		},
+		Grade: grade.Options{VM: *useVM, Limits: limits.Limits{Steps: *maxSteps}, Timeout: *timeout},
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case porcelain != 0:
+		for _, result := range report.Results {
+			state := "ok"
+			if !result.Passed {
+				state = "falhou"
+			}
+			writeRecord(os.Stdout, "teste", result.Name, state, strconv.Itoa(result.Status))
+			for _, failure := range result.Failures {
+				writeRecord(os.Stdout, "motivo", result.Name, failure)
+			}
+		}
+		writeRecord(os.Stdout, "total", strconv.Itoa(report.Passed), strconv.Itoa(len(report.Results)))
+	case *format == "json":
+		err = report.WriteJSON(os.Stdout)
+	default:
+		err = report.WriteTAP(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	for _, result := range report.Results {
+		if !result.Passed {
+			// The failures are on the report
+			return rejectedError{result.Status}
+		}
+	}
+	return nil
+}
+
+// explainCode writes the explanation of a diagnostic code,
+// or lists the codes when none is given
+func explainCode(args []string) error {
+	flags := newFlagSet("explain", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol explain [CÓDIGO]")
+	}
+	parseFlags(flags, args)
+	switch flags.NArg() {
+	case 0:
+		if porcelain != 0 {
+			for _, explanation := range explain.Explanations() {
+				writeRecord(os.Stdout, "codigo", explanation.Code, explanation.Title)
+			}
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, explanation := range explain.Explanations() {
+			fmt.Fprintf(writer, "%s\t%s\n", explanation.Code, explanation.Title)
+		}
+		return writer.Flush()
+	case 1:
+		explanation, err := explain.Lookup(flags.Arg(0))
+		if err != nil {
+			return usageError{err}
+		}
+		return explanation.Write(os.Stdout)
+	}
+	return usageErrorf("mgol explain recebe um código só")
+}
+
+// showVersion writes which compiler is running, for the reports of
+// problems and the graders that keep it along with the programs
+func showVersion(args []string) error {
+	flags := newFlagSet("version", nil)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "uso: mgol version [-json]")
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "escreve as informações como um objeto json")
+	parseFlags(flags, args)
+	if flags.NArg() > 0 {
+		return usageErrorf("mgol version não recebe arquivos")
+	}
+	info := version.Get()
+	switch {
+	case *asJSON:
+		return info.EncodeJSON(os.Stdout)
+	case porcelain != 0:
+		writeRecord(os.Stdout, "versao", info.Version, info.Commit, strconv.Itoa(info.Language), info.Grammar, info.Go)
+		return nil
+	}
+	return info.Write(os.Stdout)
+}
+
+// writeFile creates the file on path and writes on it with encode,
+// or writes on the standard output if path is -
+func writeFile(path string, encode func(io.Writer) error) error {
+	if path == "-" {
+		return encode(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encode(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mgol-go/src/exitcode"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// commandEnv tells the test binary to run the command
+// instead of the tests, so that mgol runs as a process
+const commandEnv = "MGOL_TEST_COMMAND"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(commandEnv) != "" {
+		main()
+		os.Exit(exitcode.Success)
+	}
+	os.Exit(m.Run())
+}
+
+// runMgol runs mgol with args on testdata, with stdin on the standard
+// input, returning what it wrote and the status it ended with
+func runMgol(t *testing.T, stdin string, args ...string) (string, string, int) {
+	command := exec.Command(os.Args[0], args...)
+	command.Dir = "testdata"
+	command.Env = append(os.Environ(), commandEnv+"=1")
+	command.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	command.Stdout, command.Stderr = &stdout, &stderr
+	err := command.Run()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return stdout.String(), stderr.String(), exitError.ExitCode()
+	}
+	require.NoError(t, err)
+	return stdout.String(), stderr.String(), exitcode.Success
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		stderr string
+		status int
+	}{
+		{
+			name:   "Program without errors",
+			args:   []string{"check", "ok.mgol"},
+			status: exitcode.Success,
+		},
+		{
+			name:   "Lexical error",
+			args:   []string{"check", "lexico.mgol"},
+			stderr: "erro na linha 6 coluna 11, palavra $ inexistente na linguagem\n",
+			status: exitcode.Syntax,
+		},
+		{
+			name:   "Syntax error",
+			args:   []string{"parse", "sintaxe.mgol"},
+			stderr: "erro na linha 6 coluna 13, esperava um operando na expressão",
+			status: exitcode.Syntax,
+		},
+		{
+			name:   "Semantic error",
+			args:   []string{"check", "semantico.mgol"},
+			stderr: "erro na linha 5 coluna 6, variável não declarada: 'B'\n",
+			status: exitcode.Semantic,
+		},
+		{
+			name:   "Runtime error",
+			args:   []string{"run", "divisao.mgol"},
+			stdin:  "0\n",
+			stderr: "divisão de inteiro por zero na linha 6, coluna 9\n",
+			status: exitcode.Runtime,
+		},
+		{
+			name:   "Unknown command",
+			args:   []string{"compila", "ok.mgol"},
+			stderr: "uso: mgol [-porcelain] [-lang-profile PERFIL] COMANDO [opções] ARQUIVO\n",
+			status: exitcode.Usage,
+		},
+		{
+			name:   "Missing file",
+			args:   []string{"check"},
+			stderr: "uso: mgol check [opções] ARQUIVO\n",
+			status: exitcode.Usage,
+		},
+		{
+			name:   "File not found",
+			args:   []string{"check", "inexistente.mgol"},
+			stderr: "inexistente.mgol",
+			status: exitcode.Usage,
+		},
+		{
+			name:   "Source comments on another target",
+			args:   []string{"build", "-source-comments", "-target", "go", "ok.mgol"},
+			stderr: "-source-comments só pode ser usado com o alvo c\n",
+			status: exitcode.Usage,
+		},
+		{
+			name:   "Unknown target",
+			args:   []string{"build", "-target", "cobol", "ok.mgol"},
+			stderr: "alvo desconhecido: cobol",
+			status: exitcode.Usage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, status := runMgol(t, tt.stdin, tt.args...)
+			require.Equal(t, tt.status, status)
+			if tt.stderr == "" {
+				require.Empty(t, stderr)
+			}
+			require.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+func TestStdin(t *testing.T) {
+	source, err := ioutil.ReadFile(filepath.Join("testdata", "ok.mgol"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+	}{
+		{
+			name:   "build",
+			args:   []string{"build", "-o", "-", "-"},
+			stdout: "escreva_inteiro(T0);\n",
+		},
+		{
+			name:   "build with the source as comments",
+			args:   []string{"build", "-source-comments", "-o", "-", "-"},
+			stdout: "/* linha 6: escreva A * 2 */\nT0 = A * 2;\n",
+		},
+		{
+			name:   "build of another target",
+			args:   []string{"build", "-target", "python", "-o", "-", "-"},
+			stdout: "print(",
+		},
+		{
+			// The program takes the input, so leia reads nothing
+			name:   "run",
+			args:   []string{"run", "-"},
+			stdout: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runMgol(t, string(source), tt.args...)
+			require.Equal(t, exitcode.Success, status, stderr)
+			require.Contains(t, stdout, tt.stdout)
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+		stderr string
+		status int
+	}{
+		{
+			name:   "All compiled",
+			args:   []string{"check", "ok.mgol", "divisao.mgol"},
+			stdout: "ok.mgol: ok\ndivisao.mgol: ok\n2 de 2 programas compilados\n",
+			status: exitcode.Success,
+		},
+		{
+			name: "Status of the first failure",
+			args: []string{"check", "semantico.mgol", "ok.mgol", "sintaxe.mgol"},
+			stdout: "semantico.mgol: falhou, 1 erro, 1 aviso\n" +
+				"ok.mgol: ok\n" +
+				"sintaxe.mgol: falhou, 1 erro\n" +
+				"1 de 3 programas compilados\n",
+			stderr: "semantico.mgol: erro na linha 5 coluna 6, variável não declarada: 'B'\n" +
+				"semantico.mgol: aviso na linha 6 coluna 9, variável pode ser lida antes de receber um valor: 'A'\n" +
+				"sintaxe.mgol: erro na linha 6 coluna 13, esperava um operando na expressão, esperava identificador, número ou '(', encontrou ';'\n",
+			status: exitcode.Semantic,
+		},
+		{
+			name:   "Glob patterns",
+			args:   []string{"check", "[o]k.mgol", "div*.mgol"},
+			stdout: "ok.mgol: ok\ndivisao.mgol: ok\n2 de 2 programas compilados\n",
+			status: exitcode.Success,
+		},
+		{
+			name:   "Pattern matching nothing",
+			args:   []string{"check", "ok.mgol", "nada*.mgol"},
+			stderr: "nenhum arquivo corresponde a nada*.mgol\n",
+			status: exitcode.Usage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runMgol(t, "", tt.args...)
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.stdout, stdout)
+			require.Equal(t, tt.stderr, stderr)
+		})
+	}
+}
+
+func TestPorcelain(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+		stderr string
+		status int
+	}{
+		{
+			name:   "Single program",
+			args:   []string{"-porcelain", "check", "sintaxe.mgol"},
+			stderr: "diagnostico\tsintaxe.mgol\t6\t13\terro\t\t1\tesperava um operando na expressão, esperava identificador, número ou '(', encontrou ';'\n",
+			status: exitcode.Syntax,
+		},
+		{
+			name: "Batch",
+			args: []string{"-porcelain=v1", "check", "ok.mgol", "semantico.mgol"},
+			stdout: "programa\tok.mgol\tok\t0\t0\t\n" +
+				"programa\tsemantico.mgol\tfalhou\t1\t1\t\n" +
+				"total\t1\t2\n",
+			stderr: "diagnostico\tsemantico.mgol\t5\t6\terro\tS001\t1\tvariável não declarada: 'B'\n" +
+				"diagnostico\tsemantico.mgol\t6\t9\taviso\tS013\t1\tvariável pode ser lida antes de receber um valor: 'A'\n",
+			status: exitcode.Semantic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runMgol(t, "", tt.args...)
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.stdout, stdout)
+			require.Equal(t, tt.stderr, stderr)
+		})
+	}
+}
+
+func TestListing(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdout []string
+		status int
+	}{
+		{
+			name: "check",
+			args: []string{"check", "-listing", "-", "semantico.mgol"},
+			stdout: []string{
+				"   5  leia B;\n           ^ erro S001: variável não declarada: 'B'\n",
+				"1 erro(s), 1 aviso(s)\n",
+				"A            id           inteiro      3\n",
+			},
+			status: exitcode.Semantic,
+		},
+		{
+			name:   "check of a syntax error",
+			args:   []string{"check", "-listing", "-", "sintaxe.mgol"},
+			stdout: []string{"   6  escreva A * ;\n                  ^ erro: esperava um operando na expressão"},
+			status: exitcode.Syntax,
+		},
+		{
+			name:   "build",
+			args:   []string{"build", "-listing", "-", "-o", "-", "ok.mgol"},
+			stdout: []string{"0 erro(s), 0 aviso(s)\n", "Total      16\n", "escreva_inteiro(T0);\n"},
+			status: exitcode.Success,
+		},
+		{
+			name:   "build of a program rejected",
+			args:   []string{"build", "-listing", "-", "-target", "go", "semantico.mgol"},
+			stdout: []string{"   6  escreva A;\n              ^ aviso S013:"},
+			status: exitcode.Semantic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, status := runMgol(t, "", tt.args...)
+			require.Equal(t, tt.status, status)
+			for _, expected := range tt.stdout {
+				require.Contains(t, stdout, expected)
+			}
+		})
+	}
+
+	_, stderr, status := runMgol(t, "", "check", "-listing", "-", "ok.mgol", "semantico.mgol")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "-listing só pode ser usado com um programa de um arquivo")
+}
+
+func TestStats(t *testing.T) {
+	stdout, stderr, status := runMgol(t, "", "build", "-stats", "-o", "-", "ok.mgol")
+	require.Equal(t, exitcode.Success, status, stderr)
+	require.Contains(t, stdout, "escreva_inteiro(T0);\n")
+	for _, phase := range []string{"\nléxica ", "\nsintática ", "\nsemântica ", "\ncódigo de três endereços ", "\ngeração de código ", "\nTotal "} {
+		require.Contains(t, stderr, phase)
+	}
+	require.Contains(t, stderr, "instruções do código de três endereços  3\n")
+
+	_, stderr, status = runMgol(t, "", "build", "-stats", "ok.mgol", "divisao.mgol")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "-stats só pode ser usado com um programa")
+}
+
+func TestOptimization(t *testing.T) {
+	const source = "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nA <- 2 * 3;\nescreva A;\nfim\n"
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Not optimized",
+			args:     []string{"build", "-target", "llvm", "-o", "-", "-"},
+			expected: "mul i32 2, 3",
+		},
+		{
+			name:     "Level as a value",
+			args:     []string{"build", "-target", "llvm", "-O", "1", "-o", "-", "-"},
+			expected: "i32 6)",
+		},
+		{
+			name:     "Level on the flag",
+			args:     []string{"build", "-target", "llvm", "-O2", "-o", "-", "-"},
+			expected: "i32 6)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runMgol(t, source, tt.args...)
+			require.Equal(t, exitcode.Success, status, stderr)
+			require.Contains(t, stdout, tt.expected)
+		})
+	}
+
+	stdout, stderr, status := runMgol(t, source, "build", "-emit-ir", "-", "-O2", "-o", os.DevNull, "-")
+	require.Equal(t, exitcode.Success, status, stderr)
+	require.Equal(t, "inteiro A\n\tescreva 6\n", stdout)
+
+	_, stderr, status = runMgol(t, source, "build", "-O", "3", "-")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "nível de otimização desconhecido: 3")
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{
+			shell:    "bash",
+			expected: []string{"complete -o filenames -F _mgol mgol\n", "-target) COMPREPLY=($(compgen -W \"c asm bytecode go js llvm python wasm\""},
+		},
+		{
+			shell:    "zsh",
+			expected: []string{"#compdef mgol\n", "_mgol() {\n"},
+		},
+		{
+			shell:    "fish",
+			expected: []string{"complete -c mgol -n __fish_use_subcommand -f -a build -d 'gera o programa para um alvo'\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			stdout, stderr, status := runMgol(t, "", "completion", tt.shell)
+			require.Equal(t, exitcode.Success, status)
+			require.Empty(t, stderr)
+			for _, expected := range tt.expected {
+				require.Contains(t, stdout, expected)
+			}
+		})
+	}
+
+	_, stderr, status := runMgol(t, "", "completion", "powershell")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "shell desconhecido: powershell")
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stderr string
+		status int
+	}{
+		{
+			name:   "Warnings",
+			args:   []string{"lint", "ok.mgol"},
+			stderr: "aviso na linha 6 coluna 13, número mágico 2, guarde-o em uma variável com um nome que diga o que ele é\n",
+			status: exitcode.Success,
+		},
+		{
+			name:   "Rule disabled",
+			args:   []string{"lint", "-disable", "numero-magico", "ok.mgol"},
+			status: exitcode.Success,
+		},
+		{
+			name:   "Unknown rule",
+			args:   []string{"lint", "-enable", "nada", "ok.mgol"},
+			stderr: "regra desconhecida: nada",
+			status: exitcode.Usage,
+		},
+		{
+			name:   "Program with errors",
+			args:   []string{"lint", "semantico.mgol"},
+			stderr: "erro na linha 5 coluna 6, variável não declarada: 'B'\n",
+			status: exitcode.Semantic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, status := runMgol(t, "", tt.args...)
+			require.Equal(t, tt.status, status)
+			if tt.stderr == "" {
+				require.Empty(t, stderr)
+			}
+			require.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	steps := []struct {
+		args   []string
+		stdout string
+	}{
+		{
+			args:   []string{"cache", "-cache-dir", dir, "stats"},
+			stdout: "diretório  " + dir + "\nentradas   0\nbytes      0\nacertos    0\nfaltas     0\n",
+		},
+		{
+			args:   []string{"build", "-cache", "-cache-dir", dir, "-o", "-", "ok.mgol"},
+			stdout: "escreva_inteiro(T0);\n",
+		},
+		{
+			// Built again from the cache
+			args:   []string{"build", "-cache", "-cache-dir", dir, "-o", "-", "ok.mgol"},
+			stdout: "escreva_inteiro(T0);\n",
+		},
+		{
+			args:   []string{"-porcelain", "cache", "-cache-dir", dir, "stats"},
+			stdout: "cache\t" + dir + "\t1\t",
+		},
+		{
+			args:   []string{"cache", "-cache-dir", dir, "stats"},
+			stdout: "acertos          1\nfaltas           1\ntaxa de acertos  50.0%\n",
+		},
+		{
+			args:   []string{"cache", "-cache-dir", dir, "clean"},
+			stdout: "1 entrada removida\n",
+		},
+		{
+			args:   []string{"cache", "-cache-dir", dir, "clean"},
+			stdout: "0 entradas removidas\n",
+		},
+	}
+	for _, step := range steps {
+		stdout, stderr, status := runMgol(t, "", step.args...)
+		require.Equal(t, exitcode.Success, status, stderr)
+		require.Contains(t, stdout, step.stdout)
+	}
+
+	_, stderr, status := runMgol(t, "", "cache", "-cache-dir", dir, "limpa")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "subcomando de cache desconhecido: limpa")
+	_, stderr, status = runMgol(t, "", "cache", "-cache-dir", dir, "-older-than", "-1h", "clean")
+	require.Equal(t, exitcode.Usage, status)
+	require.Contains(t, stderr, "-older-than não pode ser negativo")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	errorhandling "mgol-go/src/error_handling"
+	"strconv"
+	"strings"
+)
+
+// porcelainVersion is the version of the output for scripts asked
+// for with -porcelain, 0 when the output is the one for people.
+// The lines of a version never change: a field or a record that is
+// added, removed or written some other way makes a new version, so
+// the scripts and golden tests of a version keep working
+type porcelainVersion int
+
+// latestPorcelain is the version of a -porcelain given no version
+const latestPorcelain porcelainVersion = 1
+
+// porcelain is the version asked for on the command line
+var porcelain porcelainVersion
+
+func (v *porcelainVersion) String() string {
+	if v == nil || *v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("v%d", *v)
+}
+
+func (v *porcelainVersion) Set(value string) error {
+	switch value {
+	case "true":
+		*v = latestPorcelain
+	case "false":
+		*v = 0
+	case "v1", "1":
+		*v = 1
+	default:
+		return fmt.Errorf("versão desconhecida: %s, a disponível é v1", value)
+	}
+	return nil
+}
+
+// IsBoolFlag lets -porcelain be given without a version
+func (v *porcelainVersion) IsBoolFlag() bool {
+	return true
+}
+
+// porcelainEscaper keeps each record on a line and its fields apart
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeRecord writes a line of the porcelain output on w, its kind
+// and then its fields, separated by tabs
+func writeRecord(w io.Writer, kind string, fields ...string) {
+	var line strings.Builder
+	line.WriteString(kind)
+	for _, field := range fields {
+		line.WriteByte('\t')
+		line.WriteString(porcelainEscaper.Replace(field))
+	}
+	line.WriteByte('\n')
+	io.WriteString(w, line.String())
+}
+
+// logRecord writes a line of the porcelain output on the standard
+// error, where the diagnostics and failures go
+func logRecord(kind string, fields ...string) {
+	writeRecord(log.Writer(), kind, fields...)
+}
+
+// logDiagnostic writes diagnostic of the program on path as a
+// record, with every field even when empty
+func logDiagnostic(path string, diagnostic errorhandling.Diagnostic) {
+	logRecord("diagnostico", path, strconv.Itoa(diagnostic.Line), strconv.Itoa(diagnostic.Column), string(diagnostic.Severity), diagnostic.Code, strconv.Itoa(diagnostic.Count), diagnostic.Message)
+}
+
+// showFailure writes err, which ended the command with status, on the
+// standard error, with the status on the porcelain output
+func showFailure(err error, status int) {
+	if porcelain != 0 {
+		logRecord("falha", strconv.Itoa(status), err.Error())
+		return
+	}
+	log.Print(err)
+}
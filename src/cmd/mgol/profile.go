@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiles are the flags of build and run that profile the command
+// itself, to be sent along with the reports of slow programs
+type profiles struct {
+	cpu    string
+	memory string
+	trace  string
+}
+
+func (p *profiles) register(flags *flag.FlagSet) {
+	flags.StringVar(&p.cpu, "cpuprofile", "", "arquivo onde o perfil de CPU do comando é escrito, para go tool pprof")
+	flags.StringVar(&p.memory, "memprofile", "", "arquivo onde o perfil de memória do comando é escrito ao fim, para go tool pprof")
+	flags.StringVar(&p.trace, "trace", "", "arquivo onde o rastro da execução do comando é escrito, para go tool trace")
+}
+
+// enabled tells whether any profile was asked for
+func (p *profiles) enabled() bool {
+	return p.cpu != "" || p.memory != "" || p.trace != ""
+}
+
+// start begins the CPU profile and the trace asked for, returning
+// the function that ends them and writes the memory profile
+func (p *profiles) start() (func() error, error) {
+	var cpuFile, traceFile *os.File
+	stop := func() error {
+		var err error
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			err = keepFirst(err, cpuFile.Close())
+		}
+		if traceFile != nil {
+			trace.Stop()
+			err = keepFirst(err, traceFile.Close())
+		}
+		if p.memory != "" {
+			// The statistics are only up to date after a collection
+			runtime.GC()
+			err = keepFirst(err, writeFile(p.memory, pprof.WriteHeapProfile))
+		}
+		return err
+	}
+
+	if p.cpu != "" {
+		file, err := os.Create(p.cpu)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+		cpuFile = file
+	}
+	if p.trace != "" {
+		file, err := os.Create(p.trace)
+		if err == nil {
+			if err = trace.Start(file); err != nil {
+				file.Close()
+			}
+		}
+		if err != nil {
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				cpuFile.Close()
+			}
+			return nil, err
+		}
+		traceFile = file
+	}
+	return stop, nil
+}
+
+// keepFirst returns err, or next if err is nil
+func keepFirst(err, next error) error {
+	if err != nil {
+		return err
+	}
+	return next
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// watchInterval is how often the files watched are looked at
+const watchInterval = 500 * time.Millisecond
+
+// modTimes returns when each of paths was last changed, the zero
+// time for the ones that can not be read, like while an editor
+// writes them again
+func modTimes(paths []string) []time.Time {
+	times := make([]time.Time, len(paths))
+	for index, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			times[index] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// changed returns the first of paths whose time is not on before
+func changed(paths []string, before, after []time.Time) (string, bool) {
+	for index, path := range paths {
+		if !before[index].Equal(after[index]) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// watch runs compile, and again each time one of paths changes, until
+// the command is interrupted. The files are looked at every
+// watchInterval, which needs nothing from the system and works the
+// same on a directory shared with a virtual machine. The errors of
+// compile are shown and the files watched again
+func watch(paths []string, compile func() error) error {
+	for _, path := range paths {
+		if path == stdinPath {
+			return usageErrorf("-watch não pode ser usado com a entrada padrão")
+		}
+	}
+	times := modTimes(paths)
+	for {
+		err := compile()
+		if porcelain != 0 {
+			logCompiled(err)
+		} else if _, shown := err.(rejectedError); shown {
+			log.Print("o programa tem erros, aguardando alterações")
+		} else if err != nil {
+			log.Printf("%s, aguardando alterações", err)
+		} else {
+			log.Print("compilado, aguardando alterações")
+		}
+
+		for {
+			time.Sleep(watchInterval)
+			current := modTimes(paths)
+			path, found := changed(paths, times, current)
+			times = current
+			if found {
+				if porcelain != 0 {
+					logRecord("alterado", path)
+				} else {
+					log.Printf("%s alterado, compilando de novo", path)
+				}
+				break
+			}
+		}
+	}
+}
+
+// logCompiled writes the record of a compilation that ended with err,
+// after which the files are watched again
+func logCompiled(err error) {
+	if _, shown := err.(rejectedError); shown {
+		logRecord("compilado", "erros")
+	} else if err != nil {
+		logRecord("compilado", "falhou", err.Error())
+	} else {
+		logRecord("compilado", "ok")
+	}
+}
@@ -0,0 +1,89 @@
+// Command mgolbc assembles a listing of bytecode into a file the
+// virtual machine of mgol runs, or, with -d, disassembles a file
+// back to its listing. With -run, it runs the file on the machine
+// instead, on the standard input and output:
+//
+//	go run src/main.go -target bytecode programa.mgol
+//	go run ./src/cmd/mgolbc -d programa.mgb > programa.mgbs
+//	go run ./src/cmd/mgolbc -o programa.mgb programa.mgbs
+//	go run ./src/cmd/mgolbc -run programa.mgb
+//
+// The input is read from the standard input when no file is given, and
+// the output written to the standard output when -o is empty
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/vm"
+	"os"
+)
+
+func main() {
+	disassemble := flag.Bool("d", false, "desmonta um arquivo de bytecode em vez de montar uma listagem")
+	outputPath := flag.String("o", "", "arquivo de saída, a saída padrão se vazio")
+	run := flag.Bool("run", false, "executa um arquivo de bytecode na máquina virtual")
+	decimalComma := flag.Bool("decimal-comma", false, "com -run, escreve os reais com vírgula, como 3,140000")
+	flag.Parse()
+
+	if *run {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		program, err := bytecode.Decode(file)
+		if err == nil {
+			err = vm.RunWithOptions(program, os.Stdin, os.Stdout, backend.Options{DecimalComma: *decimalComma})
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	input, err := readInput(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	if *disassemble {
+		program, err := bytecode.Decode(bytes.NewReader(input))
+		if err == nil {
+			err = bytecode.Disassemble(&output, program)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		program, err := bytecode.Assemble(string(input))
+		if err == nil {
+			err = bytecode.Encode(&output, program)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *outputPath == "" {
+		_, err = os.Stdout.Write(output.Bytes())
+	} else {
+		err = ioutil.WriteFile(*outputPath, output.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readInput reads the file at path, or the standard input if empty
+func readInput(path string) ([]byte, error) {
+	if path == "" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
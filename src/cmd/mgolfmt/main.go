@@ -0,0 +1,139 @@
+// Command mgolfmt formats programs of mgol: one statement per line,
+// the blocks indented with tabs and one space around the operators,
+// keeping the comments and parentheses of the source:
+//
+//	go run ./src/cmd/mgolfmt programa.mgol
+//	go run ./src/cmd/mgolfmt -w 'lista3/*.mgol'
+//
+// The programs are written on the standard output, or back on their
+// files with -w. -l lists the files that are not formatted instead,
+// for a check before a commit. Without files, or with -, the program
+// is read from the standard input. A program with errors is left as
+// it is, ending the command with the status of the exitcode package
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mgol-go/src/exitcode"
+	"mgol-go/src/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdinPath is the file name of the standard input
+const stdinPath = "-"
+
+func main() {
+	defer exitcode.Recover()
+	log.SetFlags(0)
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	write := flag.Bool("w", false, "escreve cada programa formatado de volta no seu arquivo")
+	list := flag.Bool("l", false, "lista os arquivos que não estão formatados, sem alterá-los")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "uso: mgolfmt [opções] [ARQUIVO...]")
+		flag.PrintDefaults()
+	}
+	switch err := flag.CommandLine.Parse(os.Args[1:]); {
+	case err == flag.ErrHelp:
+		os.Exit(exitcode.Success)
+	case err != nil:
+		os.Exit(exitcode.Usage)
+	}
+
+	paths, err := sourcePaths(flag.Args())
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	status := exitcode.Success
+	for _, path := range paths {
+		fileStatus := formatFile(path, *write, *list)
+		if status == exitcode.Success {
+			status = fileStatus
+		}
+	}
+	os.Exit(status)
+}
+
+// sourcePaths returns the files of args, the glob patterns expanded,
+// or the standard input if there are none
+func sourcePaths(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{stdinPath}, nil
+	}
+	var paths []string
+	for _, pattern := range args {
+		if pattern == stdinPath || !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("nenhum arquivo corresponde a %s", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// formatFile formats the program on path, returning the status
+// of the exitcode package it ends with
+func formatFile(path string, write, list bool) int {
+	var source []byte
+	var err error
+	if path == stdinPath {
+		source, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		source, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		log.Print(err)
+		if os.IsNotExist(err) {
+			return exitcode.Usage
+		}
+		return exitcode.Internal
+	}
+
+	formatted, diagnostics, err := parser.Format(string(source))
+	for _, diagnostic := range diagnostics {
+		if path == stdinPath {
+			log.Print(diagnostic)
+		} else {
+			log.Printf("%s: %s", path, diagnostic)
+		}
+	}
+	if err != nil {
+		if status := exitcode.ForDiagnostics(diagnostics); status != exitcode.Success {
+			return status
+		}
+		return exitcode.Syntax
+	}
+
+	switch {
+	case list:
+		if formatted != string(source) {
+			fmt.Println(path)
+		}
+	case write && path != stdinPath:
+		if formatted == string(source) {
+			return exitcode.Success
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(formatted), info.Mode().Perm())
+		}
+		if err != nil {
+			log.Print(err)
+			return exitcode.Internal
+		}
+	default:
+		fmt.Print(formatted)
+	}
+	return exitcode.Success
+}
@@ -0,0 +1,147 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command mgolwasm is the compiler built for the browser, so a
+// playground compiles and runs the programs with no server:
+//
+//	GOOS=js GOARCH=wasm go build -o mgol.wasm ./src/cmd/mgolwasm
+//	cp "$(go env GOROOT)/misc/wasm/wasm_exec.js" .
+//
+// wasm_exec.js is on lib/wasm since Go 1.24.
+//
+// Once loaded by wasm_exec.js, it sets a global mgol object, whose
+// functions take the source and an object with the options of
+// mgolc.Options, and return the json of what mgolc returned, parsed:
+//
+//	const go = new Go();
+//	const wasm = await WebAssembly.instantiateStreaming(fetch("mgol.wasm"), go.importObject);
+//	go.run(wasm.instance);
+//	mgol.compile(source, {target: "js", conversions: "avisar"}); // {tokens, ast, diagnostics, accepted, code}
+//	mgol.lex(source, {});                                         // {tokens, diagnostics, accepted}
+//	mgol.run(source, "21\n", {});                                 // {status, diagnostics, error, output}
+//
+// The programs are always run on the sandbox of the limits package.
+// Its timeout cannot end them on the page, whose timers wait for the
+// call to return, so its limit of steps is what does.
+// When the options are wrong, like an unknown target, the returned
+// object only holds the error
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mgol-go/src/mgolc"
+	"strings"
+	"syscall/js"
+)
+
+// options are those of mgolc.Options, as written on json
+type options struct {
+	Target string `json:"target"`
+	// Conversions is named like the strictness of the flags, like avisar
+	Conversions  string `json:"conversions"`
+	Implicit     bool   `json:"implicit"`
+	DecimalComma bool   `json:"decimal_comma"`
+	Language     string `json:"language"`
+}
+
+// readOptions returns the options on the object value, which
+// may be undefined
+func readOptions(value js.Value) (mgolc.Options, error) {
+	var read options
+	if value.Type() == js.TypeObject {
+		text := js.Global().Get("JSON").Call("stringify", value).String()
+		if err := json.Unmarshal([]byte(text), &read); err != nil {
+			return mgolc.Options{}, err
+		}
+	}
+	converted := mgolc.Options{Target: read.Target, Implicit: read.Implicit, DecimalComma: read.DecimalComma, Language: read.Language}
+	if read.Conversions != "" {
+		conversions, err := mgolc.ParseConversions(read.Conversions)
+		if err != nil {
+			return mgolc.Options{}, err
+		}
+		converted.Conversions = conversions
+	}
+	return converted, nil
+}
+
+// toJS returns value as a JavaScript object, through its json,
+// or an object holding err alone
+func toJS(value interface{}, err error) interface{} {
+	var encoded []byte
+	if err == nil {
+		encoded, err = json.Marshal(value)
+	}
+	if err != nil {
+		encoded, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return js.Global().Get("JSON").Call("parse", string(encoded))
+}
+
+// argument returns the argument at index, or undefined when missing
+func argument(args []js.Value, index int) js.Value {
+	if index < len(args) {
+		return args[index]
+	}
+	return js.Undefined()
+}
+
+func compile(this js.Value, args []js.Value) interface{} {
+	options, err := readOptions(argument(args, 1))
+	if err != nil {
+		return toJS(nil, err)
+	}
+	result, err := mgolc.Compile(context.Background(), mgolc.Source{Text: argument(args, 0).String()}, options)
+	if err != nil {
+		return toJS(nil, err)
+	}
+	// The code is sent as text, as the targets that write binary
+	// files, like wasm, are of no use on the page
+	return toJS(struct {
+		mgolc.Result
+		Code string `json:"code,omitempty"`
+	}{result, string(result.Code)}, nil)
+}
+
+func lex(this js.Value, args []js.Value) interface{} {
+	options, err := readOptions(argument(args, 1))
+	if err != nil {
+		return toJS(nil, err)
+	}
+	return toJS(mgolc.Lex(mgolc.Source{Text: argument(args, 0).String()}, options))
+}
+
+func run(this js.Value, args []js.Value) interface{} {
+	options, err := readOptions(argument(args, 2))
+	if err != nil {
+		return toJS(nil, err)
+	}
+	input := ""
+	if value := argument(args, 1); value.Type() == js.TypeString {
+		input = value.String()
+	}
+	var output bytes.Buffer
+	result, err := mgolc.Run(context.Background(), mgolc.Source{Text: argument(args, 0).String()}, strings.NewReader(input), &output, options)
+	if err != nil {
+		return toJS(nil, err)
+	}
+	return toJS(struct {
+		mgolc.RunResult
+		Output string `json:"output"`
+	}{result, output.String()}, nil)
+}
+
+func main() {
+	js.Global().Set("mgol", js.ValueOf(map[string]interface{}{
+		"compile": js.FuncOf(compile),
+		"lex":     js.FuncOf(lex),
+		"run":     js.FuncOf(run),
+		"targets": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return toJS(mgolc.Targets(), nil)
+		}),
+	}))
+	// The functions are called by the page until it is closed
+	select {}
+}
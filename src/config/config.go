@@ -0,0 +1,383 @@
+// Package config reads the defaults of a project of mgol from its
+// mgol.toml or .mgolrc, looked for on a directory and on the ones
+// above it, so every program of a course folder shares them:
+//
+//	# mgol.toml
+//	sources = ["declaracoes.mgol", "corpo.mgol"]
+//	output = "build/media.py"
+//	target = "python"
+//	optimization = "2"
+//	locale = "pt-BR"
+//	keywords = "estrito"
+//	lang-profile = "mgol-en"
+//	tab-width = 4
+//
+//	[lint]
+//	disable = ["numero-magico"]
+//	max-line-length = 80
+//
+// Both files take the same subset of TOML: strings, integers and
+// lists of strings, on one line each. The flags of the commands
+// override what the file says.
+//
+// With sources, the file is also the manifest of a project whose
+// program is split among those files, read in order as one program
+// when no file is given to the commands
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mgol-go/src/ir"
+	"mgol-go/src/lint"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorInvalidLine           = fmt.Errorf("linha inválida")
+	ErrorUnknownKey            = fmt.Errorf("chave desconhecida")
+	ErrorInvalidValue          = fmt.Errorf("valor inválido")
+	ErrorUnknownLocale         = fmt.Errorf("localidade desconhecida")
+	ErrorUnknownKeywordProfile = fmt.Errorf("perfil de palavras-chave desconhecido")
+)
+
+// FileNames are the names of the configuration files, in the
+// order they are looked for on each directory
+var FileNames = []string{"mgol.toml", ".mgolrc"}
+
+// KeywordProfile tells how the scanner treats the identifiers
+// that differ from a reserved word only by case
+type KeywordProfile string
+
+// Available keyword profiles
+const (
+	// DefaultKeywords warns about them, as they are likely a
+	// misspelled keyword, like ESCREVA
+	DefaultKeywords KeywordProfile = "padrao"
+	// StrictKeywords takes them as identifiers silently, for
+	// courses that want the keywords only in lowercase
+	StrictKeywords KeywordProfile = "estrito"
+)
+
+// locales maps the locales known to whether the reals are written
+// with a decimal comma on them
+var locales = map[string]bool{
+	"C":     false,
+	"en-US": false,
+	"pt-BR": true,
+	"pt-PT": true,
+}
+
+// Config holds the defaults of a project
+type Config struct {
+	// Path is the file the configuration was read from,
+	// empty when none was found
+	Path string
+	// Sources are the files of the program of the project, and
+	// Output the file it is built to, both as written on the file.
+	// SourcePaths and OutputPath resolve them
+	Sources []string
+	Output  string
+	// Target is the name of the target programs are built for
+	Target       string
+	Optimization ir.Level
+	// Locale tells how the reals are written, with a point on C
+	// and en-US, as the automated judges expect, or with a comma
+	// on pt-BR and pt-PT
+	Locale   string
+	Keywords KeywordProfile
+	// Language is the dialect of mgol the programs are written on,
+	// either the name of one known by the lexer package or the path
+	// of a profile file, see LoadProfile
+	Language string
+	Lint     lint.Config
+}
+
+// Default returns the configuration used when there is no file
+func Default() Config {
+	return Config{Target: "c", Optimization: ir.O0, Locale: "C", Keywords: DefaultKeywords, Lint: lint.DefaultConfig()}
+}
+
+// DecimalComma tells whether the reals are written with a comma
+func (c Config) DecimalComma() bool {
+	return locales[c.Locale]
+}
+
+// KeywordCaseWarning tells whether the scanner warns about the
+// identifiers that differ from a reserved word only by case
+func (c Config) KeywordCaseWarning() bool {
+	return c.Keywords != StrictKeywords
+}
+
+// SourcePaths returns Sources relative to the directory of
+// the configuration file, as they are written relative to it
+func (c Config) SourcePaths() []string {
+	paths := make([]string, len(c.Sources))
+	for index, source := range c.Sources {
+		paths[index] = c.resolve(source)
+	}
+	return paths
+}
+
+// OutputPath returns Output relative to the directory of the
+// configuration file, or "" if it is not set
+func (c Config) OutputPath() string {
+	if c.Output == "" {
+		return ""
+	}
+	return c.resolve(c.Output)
+}
+
+// Dir returns the directory of the project, the one of the configuration file
+func (c Config) Dir() string {
+	if c.Path == "" {
+		return "."
+	}
+	return filepath.Dir(c.Path)
+}
+
+func (c Config) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.Dir(), filepath.FromSlash(path))
+}
+
+// Find returns the path of the configuration file on dir or on the
+// nearest directory above it, and false if there is none
+func Find(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ForDirectory returns the configuration of the project dir is on,
+// or Default if it has no file
+func ForDirectory(dir string) (Config, error) {
+	path, found := Find(dir)
+	if !found {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Load returns the configuration on the file on path, over Default
+func Load(path string) (Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+	config := Default()
+	if err := config.Read(file); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	config.Path = path
+	return config, nil
+}
+
+// Read changes c by the configuration read from r
+func (c *Config) Read(r io.Reader) error {
+	return readEntries(r, []string{"lint"}, c.set)
+}
+
+// readEntries calls set with each key of r and its value as written,
+// the keys of a section prefixed by its name and a dot, as lint.disable.
+// Only the given sections may be on r
+func readEntries(r io.Reader, sections []string, set func(key, value string) error) error {
+	scanner := bufio.NewScanner(r)
+	section := ""
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if !containsString(sections, section) {
+				return fmt.Errorf("linha %d: %w: [%s]", line, ErrorUnknownKey, section)
+			}
+			continue
+		}
+		equals := strings.Index(text, "=")
+		if equals < 0 {
+			return fmt.Errorf("linha %d: %w: %s", line, ErrorInvalidLine, text)
+		}
+		key := strings.TrimSpace(text[:equals])
+		if section != "" {
+			key = section + "." + key
+		}
+		if err := set(key, strings.TrimSpace(text[equals+1:])); err != nil {
+			return fmt.Errorf("linha %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func containsString(list []string, text string) bool {
+	for _, item := range list {
+		if item == text {
+			return true
+		}
+	}
+	return false
+}
+
+// set changes the key of c to the value written on the file
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "sources":
+		sources, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			return fmt.Errorf("%w: sources sem arquivos", ErrorInvalidValue)
+		}
+		c.Sources = sources
+	case "output":
+		output, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		c.Output = output
+	case "target":
+		target, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		c.Target = target
+	case "optimization":
+		// Written either as a number or as a string
+		if unquoted, err := parseString(value); err == nil {
+			value = unquoted
+		}
+		level, err := ir.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		c.Optimization = level
+	case "locale":
+		locale, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if _, found := locales[locale]; !found {
+			return fmt.Errorf("%w: %s, as conhecidas são C, en-US, pt-BR e pt-PT", ErrorUnknownLocale, locale)
+		}
+		c.Locale = locale
+	case "keywords":
+		profile, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if KeywordProfile(profile) != DefaultKeywords && KeywordProfile(profile) != StrictKeywords {
+			return fmt.Errorf("%w: %s, os disponíveis são %s e %s", ErrorUnknownKeywordProfile, profile, DefaultKeywords, StrictKeywords)
+		}
+		c.Keywords = KeywordProfile(profile)
+	case "lang-profile":
+		language, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		c.Language = language
+	case "tab-width":
+		width, err := parsePositive(value)
+		if err != nil {
+			return err
+		}
+		c.Lint.TabWidth = width
+	case "lint.enable", "lint.disable":
+		names, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		if key == "lint.enable" {
+			return c.Lint.Enable(names...)
+		}
+		return c.Lint.Disable(names...)
+	case "lint.max-line-length":
+		length, err := parsePositive(value)
+		if err != nil {
+			return err
+		}
+		c.Lint.MaxLineLength = length
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnknownKey, key)
+	}
+	return nil
+}
+
+// stripComment returns line without the comment after a #
+// that is not on a string
+func stripComment(line string) string {
+	quoted := false
+	for index := 0; index < len(line); index++ {
+		switch {
+		case line[index] == '\\' && quoted:
+			index++
+		case line[index] == '"':
+			quoted = !quoted
+		case line[index] == '#' && !quoted:
+			return line[:index]
+		}
+	}
+	return line
+}
+
+func parseString(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return "", fmt.Errorf("%w: esperava um texto entre aspas, encontrou %s", ErrorInvalidValue, value)
+	}
+	text, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrorInvalidValue, value)
+	}
+	return text, nil
+}
+
+func parsePositive(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%w: esperava um número positivo, encontrou %s", ErrorInvalidValue, value)
+	}
+	return number, nil
+}
+
+// parseList parses a list of strings, like ["a", "b"]
+func parseList(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("%w: esperava uma lista entre colchetes, encontrou %s", ErrorInvalidValue, value)
+	}
+	items := []string{}
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		text, err := parseString(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, text)
+	}
+	return items, nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"io/ioutil"
+	"mgol-go/src/ir"
+	"mgol-go/src/lint"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	config := Default()
+	require.NoError(t, config.Read(strings.NewReader(`
+# padrões da turma
+target = "python"   # gerado para o Python
+optimization = 2
+locale = "pt-BR"
+keywords = "estrito"
+lang-profile = "mgol-en"
+tab-width = 4
+
+[lint]
+disable = ["numero-magico", "senao-ausente"]
+enable = []
+max-line-length = 80
+`)))
+
+	expected := Default()
+	expected.Target = "python"
+	expected.Optimization = ir.O2
+	expected.Locale = "pt-BR"
+	expected.Keywords = StrictKeywords
+	expected.Language = "mgol-en"
+	expected.Lint = lint.Config{Disabled: map[string]bool{"numero-magico": true, "senao-ausente": true}, MaxLineLength: 80, TabWidth: 4}
+	require.Equal(t, expected, config)
+	require.True(t, config.DecimalComma())
+	require.False(t, config.KeywordCaseWarning())
+
+	require.False(t, Default().DecimalComma())
+	require.True(t, Default().KeywordCaseWarning())
+}
+
+func TestReadErrors(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected error
+	}{
+		{`alvo = "go"`, ErrorUnknownKey},
+		{"[saida]", ErrorUnknownKey},
+		{"target", ErrorInvalidLine},
+		{"target = go", ErrorInvalidValue},
+		{`optimization = "3"`, ir.ErrorUnknownLevel},
+		{`locale = "fr-FR"`, ErrorUnknownLocale},
+		{`keywords = "ingles"`, ErrorUnknownKeywordProfile},
+		{"tab-width = 0", ErrorInvalidValue},
+		{"sources = []", ErrorInvalidValue},
+		{"[lint]\ndisable = \"numero-magico\"", ErrorInvalidValue},
+		{"[lint]\ndisable = [\"ponto-e-virgula\"]", lint.ErrorUnknownRule},
+	}
+
+	for _, tc := range testCases {
+		config := Default()
+		require.ErrorIs(t, config.Read(strings.NewReader(tc.text)), tc.expected, tc.text)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root, err := ioutil.TempDir("", "mgol-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	nested := filepath.Join(root, "lista1", "exercicio2")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	config, err := ForDirectory(nested)
+	require.NoError(t, err)
+	require.Equal(t, "", config.Path)
+
+	rc := filepath.Join(root, ".mgolrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("locale = \"pt-BR\"\n"), 0644))
+	config, err = ForDirectory(nested)
+	require.NoError(t, err)
+	require.Equal(t, rc, config.Path)
+	require.True(t, config.DecimalComma())
+
+	// mgol.toml comes first, and the nearest directory before the others
+	toml := filepath.Join(root, "lista1", "mgol.toml")
+	require.NoError(t, ioutil.WriteFile(toml, []byte("target = \"go\"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "lista1", ".mgolrc"), []byte("locale = \"pt-PT\"\n"), 0644))
+	config, err = ForDirectory(nested)
+	require.NoError(t, err)
+	require.Equal(t, toml, config.Path)
+	require.Equal(t, "go", config.Target)
+	require.False(t, config.DecimalComma())
+
+	// The sources of the manifest are relative to its directory
+	require.NoError(t, ioutil.WriteFile(toml, []byte("sources = [\"declaracoes.mgol\", \"corpo/principal.mgol\"]\noutput = \"build/media.c\"\n"), 0644))
+	config, err = ForDirectory(nested)
+	require.NoError(t, err)
+	require.Equal(t, []string{"declaracoes.mgol", "corpo/principal.mgol"}, config.Sources)
+	lista1 := filepath.Join(root, "lista1")
+	require.Equal(t, []string{filepath.Join(lista1, "declaracoes.mgol"), filepath.Join(lista1, "corpo", "principal.mgol")}, config.SourcePaths())
+	require.Equal(t, filepath.Join(lista1, "build", "media.c"), config.OutputPath())
+	require.Equal(t, "", Default().OutputPath())
+
+	require.NoError(t, ioutil.WriteFile(toml, []byte("target = go\n"), 0644))
+	_, err = ForDirectory(nested)
+	require.ErrorIs(t, err, ErrorInvalidValue)
+}
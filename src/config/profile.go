@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mgol-go/src/lexer"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadProfile returns the dialect of mgol described on the file on
+// path, written on the same subset of TOML as the configuration:
+//
+//	# ingles.perfil
+//	nome = "ingles"
+//
+//	[palavras-chave]
+//	begin = "inicio"
+//	end = "fim"
+//
+//	[automato]
+//	estados = ["26 Num inteiro"]
+//	transicoes = ["0 26 #", "26 26 dígito"]
+//
+// Each keyword is mapped to the keyword of mgol it stands for, and
+// replaces those of mgol. Each state added to the automaton is
+// written with the class of the tokens recognized on it, when final,
+// and their type, and each transition with the states it goes from
+// and to, then the symbols it reads: letra, dígito or the characters
+// themselves
+func LoadProfile(path string) (lexer.Profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return lexer.Profile{}, err
+	}
+	defer file.Close()
+	profile, err := ReadProfile(file)
+	if err != nil {
+		return lexer.Profile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = path
+	}
+	return profile, nil
+}
+
+// ReadProfile returns the dialect described on r, see LoadProfile
+func ReadProfile(r io.Reader) (lexer.Profile, error) {
+	profile := lexer.Profile{}
+	err := readEntries(r, []string{"palavras-chave", "automato"}, func(key, value string) error {
+		return setProfile(&profile, key, value)
+	})
+	return profile, err
+}
+
+// setProfile changes the key of profile to the value written on the file
+func setProfile(profile *lexer.Profile, key, value string) error {
+	switch {
+	case key == "nome":
+		name, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		profile.Name = name
+	case strings.HasPrefix(key, "palavras-chave."):
+		class, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if profile.Keywords == nil {
+			profile.Keywords = map[string]lexer.TokenClass{}
+		}
+		profile.Keywords[strings.TrimPrefix(key, "palavras-chave.")] = lexer.TokenClass(class)
+	case key == "automato.estados":
+		rows, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			state, err := parseProfileState(row)
+			if err != nil {
+				return err
+			}
+			profile.States = append(profile.States, state)
+		}
+	case key == "automato.transicoes":
+		rows, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			transition, err := parseProfileTransition(row)
+			if err != nil {
+				return err
+			}
+			profile.Transitions = append(profile.Transitions, transition)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnknownKey, key)
+	}
+	return nil
+}
+
+// parseProfileState parses a state, like "26 Num inteiro"
+func parseProfileState(row string) (lexer.ProfileState, error) {
+	fields := strings.Fields(row)
+	if len(fields) == 0 || len(fields) > 3 {
+		return lexer.ProfileState{}, fmt.Errorf("%w: esperava o estado, a classe e o tipo, encontrou %q", ErrorInvalidValue, row)
+	}
+	state, err := parseState(fields[0])
+	if err != nil {
+		return lexer.ProfileState{}, err
+	}
+	parsed := lexer.ProfileState{State: state}
+	if len(fields) > 1 {
+		parsed.Class = lexer.TokenClass(fields[1])
+	}
+	if len(fields) > 2 {
+		parsed.DataType = lexer.DataType(fields[2])
+	}
+	return parsed, nil
+}
+
+// parseProfileTransition parses a transition, like "0 1 letra _"
+func parseProfileTransition(row string) (lexer.ProfileTransition, error) {
+	fields := strings.Fields(row)
+	if len(fields) < 3 {
+		return lexer.ProfileTransition{}, fmt.Errorf("%w: esperava os estados de origem e destino e os símbolos, encontrou %q", ErrorInvalidValue, row)
+	}
+	from, err := parseState(fields[0])
+	if err != nil {
+		return lexer.ProfileTransition{}, err
+	}
+	to, err := parseState(fields[1])
+	if err != nil {
+		return lexer.ProfileTransition{}, err
+	}
+	transition := lexer.ProfileTransition{From: from, To: to, Label: strings.Join(fields[2:], " ")}
+	for _, field := range fields[2:] {
+		if symbols, found := lexer.NamedSymbols(field); found {
+			transition.Symbols = append(transition.Symbols, symbols...)
+			continue
+		}
+		for index := 0; index < len(field); index++ {
+			transition.Symbols = append(transition.Symbols, lexer.Symbol(field[index]))
+		}
+	}
+	return transition, nil
+}
+
+func parseState(field string) (lexer.State, error) {
+	state, err := strconv.Atoi(field)
+	if err != nil || state < 0 {
+		return 0, fmt.Errorf("%w: esperava um estado, encontrou %s", ErrorInvalidValue, field)
+	}
+	return lexer.State(state), nil
+}
+
+// LoadLanguage returns the dialect called profile by the lexer
+// package, or else the one described on the file profile
+func LoadLanguage(profile string) (*lexer.Language, error) {
+	if _, found := lexer.LookupProfile(profile); found || profile == "" {
+		return lexer.LoadLanguage(profile)
+	}
+	loaded, err := LoadProfile(profile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s, os disponíveis são %s, ou o arquivo de um perfil", lexer.ErrorUnknownProfile, profile, strings.Join(lexer.ProfileNames(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lexer.NewLanguage(loaded)
+}
+
+// LoadLanguage returns the dialect of the project, with the path of
+// its profile file taken relative to the configuration file
+func (c Config) LoadLanguage() (*lexer.Language, error) {
+	if _, found := lexer.LookupProfile(c.Language); found || c.Language == "" {
+		return lexer.LoadLanguage(c.Language)
+	}
+	return LoadLanguage(c.resolve(c.Language))
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"io/ioutil"
+	"mgol-go/src/lexer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProfile(t *testing.T) {
+	profile, err := ReadProfile(strings.NewReader(`
+nome = "hexadecimal"
+
+[palavras-chave]
+comeco = "inicio"   # no lugar de inicio
+
+[automato]
+estados = ["26", "27 Num inteiro"]
+transicoes = ["0 26 #", "26 27 dígito ABCDEF", "27 27 dígito ABCDEF"]
+`))
+	require.NoError(t, err)
+	hexDigits, _ := lexer.NamedSymbols("dígito")
+	hexDigits = append(hexDigits, 'A', 'B', 'C', 'D', 'E', 'F')
+	require.Equal(t, lexer.Profile{
+		Name:     "hexadecimal",
+		Keywords: map[string]lexer.TokenClass{"comeco": "inicio"},
+		States:   []lexer.ProfileState{{State: 26}, {State: 27, Class: lexer.NUM, DataType: lexer.INTEGER}},
+		Transitions: []lexer.ProfileTransition{
+			{From: 0, To: 26, Symbols: []lexer.Symbol{'#'}, Label: "#"},
+			{From: 26, To: 27, Symbols: hexDigits, Label: "dígito ABCDEF"},
+			{From: 27, To: 27, Symbols: hexDigits, Label: "dígito ABCDEF"},
+		},
+	}, profile)
+	_, err = lexer.NewLanguage(profile)
+	require.NoError(t, err)
+}
+
+func TestReadProfileErrors(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected error
+	}{
+		{`name = "x"`, ErrorUnknownKey},
+		{"[lint]", ErrorUnknownKey},
+		{"[palavras-chave]\nbegin = inicio", ErrorInvalidValue},
+		{"[automato]\nestados = [\"q26\"]", ErrorInvalidValue},
+		{"[automato]\ntransicoes = [\"0 26\"]", ErrorInvalidValue},
+		{"[automato]\ntransicoes = [\"0 -1 #\"]", ErrorInvalidValue},
+	}
+
+	for _, tc := range testCases {
+		_, err := ReadProfile(strings.NewReader(tc.text))
+		require.ErrorIs(t, err, tc.expected, tc.text)
+	}
+}
+
+func TestLoadLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mgol-profile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "curso.perfil"), []byte("[automato]\ntransicoes = [\"0 1 _\"]\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mgol.toml"), []byte(`lang-profile = "curso.perfil"`), 0644))
+
+	config, err := ForDirectory(dir)
+	require.NoError(t, err)
+	language, err := config.LoadLanguage()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "curso.perfil"), language.Name())
+
+	language, err = LoadLanguage("mgol-en")
+	require.NoError(t, err)
+	require.Equal(t, "mgol-en", language.Name())
+
+	_, err = LoadLanguage(filepath.Join(dir, "nenhum.perfil"))
+	require.ErrorIs(t, err, lexer.ErrorUnknownProfile)
+}
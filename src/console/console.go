@@ -0,0 +1,196 @@
+// Package console is how the programs run by the interp and vm
+// packages read the values of leia and write the ones of escreva,
+// the same way the C program reads and writes them
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+)
+
+// IO reads and writes the values of a program being run, so it can
+// run on a terminal, a web page or a test alike
+type IO interface {
+	ReadInteger() int32
+	ReadReal() float64
+	ReadLiteral() string
+	WriteInteger(value int32)
+	WriteReal(value float64)
+	WriteLiteral(value string)
+	// Flush writes what was left buffered once the program ends
+	Flush() error
+}
+
+// stream is the IO of a reader and a writer
+type stream struct {
+	input   *bufio.Reader
+	output  *bufio.Writer
+	options backend.Options
+}
+
+// New returns the IO reading from r and writing on w, the reals
+// with a comma if options say so. A number that can not be read is
+// 0, and a literal is read up to the end of the line, skipping the
+// empty ones. What is written is buffered until Flush
+func New(r io.Reader, w io.Writer, options backend.Options) IO {
+	return &stream{input: bufio.NewReader(r), output: bufio.NewWriter(w), options: options}
+}
+
+func (s *stream) ReadInteger() int32 {
+	var value int32
+	fmt.Fscan(s.input, &value)
+	return value
+}
+
+func (s *stream) ReadReal() float64 {
+	var value float64
+	fmt.Fscan(s.input, &value)
+	return value
+}
+
+func (s *stream) ReadLiteral() string {
+	for {
+		line, err := s.input.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" || err != nil {
+			return line
+		}
+	}
+}
+
+func (s *stream) WriteInteger(value int32) {
+	s.output.WriteString(strconv.FormatInt(int64(value), 10))
+}
+
+func (s *stream) WriteReal(value float64) {
+	s.output.WriteString(FormatReal(value, s.options))
+}
+
+func (s *stream) WriteLiteral(value string) {
+	s.output.WriteString(value)
+}
+
+func (s *stream) Flush() error {
+	return s.output.Flush()
+}
+
+// callbacks is the IO of two functions
+type callbacks struct {
+	read    func(dataType lexer.DataType) string
+	write   func(text string)
+	options backend.Options
+}
+
+// Callbacks returns the IO that calls read for each value of leia,
+// with the type of the variable, and write with each value of escreva,
+// written like the C program writes it. It lets a program be driven
+// without streams, like by a web page that asks for each value, or by
+// a test with its answers scripted. The text read is parsed like
+// New parses it, a number that can not be read being 0
+func Callbacks(read func(dataType lexer.DataType) string, write func(text string), options backend.Options) IO {
+	return &callbacks{read: read, write: write, options: options}
+}
+
+func (c *callbacks) ReadInteger() int32 {
+	var value int32
+	fmt.Sscan(c.read(lexer.INTEGER), &value)
+	return value
+}
+
+func (c *callbacks) ReadReal() float64 {
+	var value float64
+	fmt.Sscan(c.read(lexer.REAL), &value)
+	return value
+}
+
+func (c *callbacks) ReadLiteral() string {
+	return strings.TrimSpace(c.read(lexer.LITERAL))
+}
+
+func (c *callbacks) WriteInteger(value int32) {
+	c.write(strconv.FormatInt(int64(value), 10))
+}
+
+func (c *callbacks) WriteReal(value float64) {
+	c.write(FormatReal(value, c.options))
+}
+
+func (c *callbacks) WriteLiteral(value string) {
+	c.write(value)
+}
+
+// Flush does nothing, as nothing is buffered
+func (c *callbacks) Flush() error {
+	return nil
+}
+
+// FormatReal writes a real like printf does with %lf, with
+// a comma instead of the point if options say so
+func FormatReal(value float64, options backend.Options) string {
+	var text string
+	switch {
+	case math.IsInf(value, 1):
+		text = "inf"
+	case math.IsInf(value, -1):
+		text = "-inf"
+	case math.IsNaN(value) && math.Signbit(value):
+		text = "-nan"
+	case math.IsNaN(value):
+		text = "nan"
+	default:
+		text = strconv.FormatFloat(value, 'f', 6, 64)
+	}
+	if options.DecimalComma {
+		text = strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
+
+// LineWriter writes on a writer remembering whether what was written
+// ended a line, so a REPL or a debugger can show their messages on
+// lines of their own after the output of the program. It keeps the
+// first error of the writer
+type LineWriter struct {
+	w           io.Writer
+	atLineStart bool
+	err         error
+}
+
+// NewLineWriter returns a LineWriter writing on w
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{w: w, atLineStart: true}
+}
+
+func (l *LineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		l.atLineStart = p[len(p)-1] == '\n'
+	}
+	n, err := l.w.Write(p)
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+	return n, err
+}
+
+// EndLine breaks the line left open, if any
+func (l *LineWriter) EndLine() {
+	if !l.atLineStart {
+		io.WriteString(l, "\n")
+	}
+}
+
+// LineEnded tells the line was ended elsewhere, like
+// by what a user typed after a prompt
+func (l *LineWriter) LineEnded() {
+	l.atLineStart = true
+}
+
+// Err returns the first error of the writer
+func (l *LineWriter) Err() error {
+	return l.err
+}
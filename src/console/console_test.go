@@ -0,0 +1,61 @@
+package console
+
+import (
+	"bytes"
+	"math"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	var output bytes.Buffer
+	io := New(strings.NewReader("42 2.5\n\n  Ana Maria \nx"), &output, backend.Options{})
+	require.Equal(t, int32(42), io.ReadInteger())
+	require.Equal(t, 2.5, io.ReadReal())
+	require.Equal(t, "Ana Maria", io.ReadLiteral())
+	require.Equal(t, int32(0), io.ReadInteger())
+	// Like scanf, what could not be read is left for the next value
+	require.Equal(t, "x", io.ReadLiteral())
+
+	io.WriteInteger(-7)
+	io.WriteLiteral(" ")
+	io.WriteReal(0.5)
+	require.Empty(t, output.String())
+	require.NoError(t, io.Flush())
+	require.Equal(t, "-7 0.500000", output.String())
+}
+
+func TestCallbacks(t *testing.T) {
+	answers := map[lexer.DataType]string{lexer.INTEGER: " 42 ", lexer.REAL: "2,5", lexer.LITERAL: "  Ana Maria\n"}
+	var asked []lexer.DataType
+	var written []string
+	io := Callbacks(func(dataType lexer.DataType) string {
+		asked = append(asked, dataType)
+		return answers[dataType]
+	}, func(text string) {
+		written = append(written, text)
+	}, backend.Options{DecimalComma: true})
+
+	require.Equal(t, int32(42), io.ReadInteger())
+	require.Equal(t, 2.0, io.ReadReal())
+	require.Equal(t, "Ana Maria", io.ReadLiteral())
+	require.Equal(t, []lexer.DataType{lexer.INTEGER, lexer.REAL, lexer.LITERAL}, asked)
+
+	io.WriteInteger(-7)
+	io.WriteReal(0.5)
+	io.WriteLiteral("fim")
+	require.Equal(t, []string{"-7", "0,500000", "fim"}, written)
+	require.NoError(t, io.Flush())
+}
+
+func TestFormatReal(t *testing.T) {
+	require.Equal(t, "0.333333", FormatReal(1.0/3, backend.Options{}))
+	require.Equal(t, "-1234567,250000", FormatReal(-1234567.25, backend.Options{DecimalComma: true}))
+	require.Equal(t, "inf", FormatReal(math.Inf(1), backend.Options{}))
+	require.Equal(t, "-inf", FormatReal(math.Inf(-1), backend.Options{}))
+	require.Equal(t, "nan", FormatReal(math.NaN(), backend.Options{}))
+}
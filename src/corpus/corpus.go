@@ -0,0 +1,241 @@
+// Package corpus checks the compiler against a directory of programs,
+// like the official exercises of a course, each with the files next to
+// it that tell what it should give:
+//
+//	media.mgol    the program
+//	media.in      what it reads, empty if missing
+//	media.out     what it must write, run like the grade package does
+//	media.diag    the diagnostics it must have, one per line
+//
+// A line of a .diag file is the position, the severity and the code of
+// a diagnostic, like "2:9 erro S001", the message being left out so it
+// can be reworded. A program without .out and .diag must only have no
+// errors. The programs on the directories below are checked too:
+//
+//	report, err := corpus.Run("exercicios", corpus.Options{})
+//	report.WriteTAP(os.Stdout)
+package corpus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/exitcode"
+	"mgol-go/src/grade"
+	"mgol-go/src/mgol"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extensions of the files of a program
+const (
+	SourceExtension      = ".mgol"
+	InputExtension       = ".in"
+	OutputExtension      = ".out"
+	DiagnosticsExtension = ".diag"
+)
+
+// Options tells how the programs are checked and run
+type Options struct {
+	Check mgol.Options
+	Grade grade.Options
+}
+
+// Result is what checking a program gave
+type Result struct {
+	// Name is the path of the program relative to the directory
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Failures tell why a program did not pass
+	Failures []string `json:"failures,omitempty"`
+	// Status is the one of the exitcode package the program
+	// failed with, Success when it passed
+	Status int `json:"status"`
+}
+
+// Report holds the results of the programs of a directory
+type Report struct {
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+// Run checks each program on dir, in the order of their paths
+func Run(dir string, options Options) (*Report, error) {
+	var sources []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == SourceExtension {
+			sources = append(sources, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Results: []Result{}}
+	for _, source := range sources {
+		result, err := runProgram(source, options)
+		if err != nil {
+			return nil, err
+		}
+		if result.Name, err = filepath.Rel(dir, source); err != nil {
+			return nil, err
+		}
+		result.Name = filepath.ToSlash(result.Name)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// runProgram checks the program on source against its files
+func runProgram(source string, options Options) (Result, error) {
+	base := strings.TrimSuffix(source, SourceExtension)
+	text, err := ioutil.ReadFile(source)
+	if err != nil {
+		return Result{}, err
+	}
+	input, _, err := readOptional(base + InputExtension)
+	if err != nil {
+		return Result{}, err
+	}
+	expectedOutput, checksOutput, err := readOptional(base + OutputExtension)
+	if err != nil {
+		return Result{}, err
+	}
+	expectedDiagnostics, checksDiagnostics, err := readOptional(base + DiagnosticsExtension)
+	if err != nil {
+		return Result{}, err
+	}
+
+	compiled := mgol.Compile(string(text), options.Check)
+	result := Result{Passed: true}
+	fail := func(status int, format string, args ...interface{}) {
+		if result.Passed {
+			result.Status = status
+		}
+		result.Passed = false
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+	}
+
+	if checksDiagnostics {
+		expected := nonEmptyLines(expectedDiagnostics)
+		found := make([]string, len(compiled.Diagnostics))
+		for index, diagnostic := range compiled.Diagnostics {
+			found[index] = Format(diagnostic)
+		}
+		if difference, differ := firstDifference(expected, found); differ {
+			fail(diagnosticsStatus(compiled), "diagnósticos: %s", difference)
+		}
+	} else if !compiled.Accepted {
+		for _, diagnostic := range compiled.Diagnostics {
+			if diagnostic.Severity == errorhandling.Error {
+				fail(diagnosticsStatus(compiled), "%s", diagnostic)
+			}
+		}
+	}
+
+	if !checksOutput {
+		return result, nil
+	}
+	if !compiled.Accepted {
+		fail(diagnosticsStatus(compiled), "o programa tem erros e não foi executado")
+		return result, nil
+	}
+	output, err := grade.Run(compiled.Program, compiled.Info, input, options.Grade)
+	if err != nil {
+		fail(exitcode.Runtime, "a execução parou: %s", err)
+	}
+	if difference, differ := firstDifference(strings.Split(grade.Normalize(expectedOutput), "\n"), strings.Split(output, "\n")); differ {
+		fail(exitcode.Runtime, "saída: %s", difference)
+	}
+	return result, nil
+}
+
+// Format returns diagnostic as it is written on a .diag file
+func Format(diagnostic errorhandling.Diagnostic) string {
+	code := diagnostic.Code
+	if code == "" {
+		code = "-"
+	}
+	return fmt.Sprintf("%d:%d %s %s", diagnostic.Line, diagnostic.Column, diagnostic.Severity, code)
+}
+
+// diagnosticsStatus returns the status of a program whose
+// diagnostics were not the expected ones, Semantic at least
+func diagnosticsStatus(compiled mgol.Compiled) int {
+	if status := exitcode.ForDiagnostics(compiled.Diagnostics); status != exitcode.Success {
+		return status
+	}
+	return exitcode.Semantic
+}
+
+// readOptional returns the text of the file on path,
+// telling whether it exists
+func readOptional(path string) (string, bool, error) {
+	text, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	return string(text), err == nil, err
+}
+
+func nonEmptyLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(grade.Normalize(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// firstDifference describes the first line where expected and
+// found differ, telling whether there is one
+func firstDifference(expected, found []string) (string, bool) {
+	for index := 0; index < len(expected) || index < len(found); index++ {
+		switch {
+		case index >= len(found):
+			return fmt.Sprintf("linha %d: esperava %q, não há mais linhas", index+1, expected[index]), true
+		case index >= len(expected):
+			return fmt.Sprintf("linha %d: não esperava mais linhas, encontrou %q", index+1, found[index]), true
+		case expected[index] != found[index]:
+			return fmt.Sprintf("linha %d: esperava %q, encontrou %q", index+1, expected[index], found[index]), true
+		}
+	}
+	return "", false
+}
+
+// WriteTAP writes r on the Test Anything Protocol, a line for each
+// program followed by why it failed, as comments
+func (r *Report) WriteTAP(w io.Writer) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "TAP version 13\n1..%d\n", len(r.Results))
+	for index, result := range r.Results {
+		if !result.Passed {
+			text.WriteString("not ")
+		}
+		fmt.Fprintf(&text, "ok %d - %s\n", index+1, result.Name)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&text, "# %s\n", failure)
+		}
+	}
+	fmt.Fprintf(&text, "# %d de %d programas passaram\n", r.Passed, len(r.Results))
+	_, err := io.WriteString(w, text.String())
+	return err
+}
+
+// WriteJSON writes r on w as a json object
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,72 @@
+package corpus
+
+import (
+	"io/ioutil"
+	"mgol-go/src/exitcode"
+	"mgol-go/src/mgol"
+	"mgol-go/src/sem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGoldenPrograms(t *testing.T) {
+	report, err := Run(filepath.Join("..", "parser", "testdata", "run"), Options{})
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Results)
+	require.Equal(t, 0, report.Failed, "%v", report.Results)
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mgol-corpus")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"dobro.mgol":          "inicio varinicio inteiro A; varfim; leia A; A <- A * 2; escreva A; fim",
+		"dobro.in":            "21\r\n",
+		"dobro.out":           "42",
+		"errado.mgol":         "inicio varinicio inteiro A; varfim; leia A; escreva A; fim",
+		"errado.in":           "1",
+		"errado.out":          "2",
+		"lista2/erro.mgol":    "inicio varinicio varfim;\nescreva B;\nfim",
+		"lista2/erro.diag":    "2:9 erro S001\n",
+		"lista2/aviso.mgol":   "inicio varinicio real B; varfim;\nB <- 2;\nescreva B;\nfim",
+		"lista2/aviso.diag":   "2:1 aviso S004\n",
+		"lista2/rejeito.mgol": "inicio varinicio varfim;\nescreva B;\nfim",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(text), 0644))
+	}
+
+	report, err := Run(dir, Options{Check: mgol.Options{Narrowing: sem.Warn, Promotion: sem.Warn}})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Passed)
+	require.Equal(t, 3, report.Failed)
+	require.Equal(t, []Result{
+		{Name: "dobro.mgol", Passed: true},
+		{Name: "errado.mgol", Failures: []string{`saída: linha 1: esperava "2", encontrou "1"`}, Status: exitcode.Runtime},
+		{Name: "lista2/aviso.mgol", Failures: []string{`diagnósticos: linha 1: esperava "2:1 aviso S004", encontrou "2:6 aviso S005"`}, Status: exitcode.Semantic},
+		{Name: "lista2/erro.mgol", Passed: true},
+		{Name: "lista2/rejeito.mgol", Failures: []string{"erro na linha 2 coluna 9, variável não declarada: 'B'"}, Status: exitcode.Semantic},
+	}, report.Results)
+
+	var tap strings.Builder
+	require.NoError(t, report.WriteTAP(&tap))
+	require.Equal(t, ""+
+		"TAP version 13\n"+
+		"1..5\n"+
+		"ok 1 - dobro.mgol\n"+
+		"not ok 2 - errado.mgol\n"+
+		"# saída: linha 1: esperava \"2\", encontrou \"1\"\n"+
+		"not ok 3 - lista2/aviso.mgol\n"+
+		"# diagnósticos: linha 1: esperava \"2:1 aviso S004\", encontrou \"2:6 aviso S005\"\n"+
+		"ok 4 - lista2/erro.mgol\n"+
+		"not ok 5 - lista2/rejeito.mgol\n"+
+		"# erro na linha 2 coluna 9, variável não declarada: 'B'\n"+
+		"# 2 de 5 programas passaram\n", tap.String())
+}
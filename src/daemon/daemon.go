@@ -0,0 +1,441 @@
+// Package daemon serves the compiler over JSON-RPC 2.0, for the
+// plugins of the editors and the machines of a grading cluster that
+// keep a process running instead of starting one for each file. The
+// messages are framed like the ones of the Language Server Protocol,
+// on the standard input and output or on TCP connections:
+//
+//	Content-Length: 74
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "check", "params": {"source": "..."}}
+//
+// lex, parse, check and build answer what the functions of the mgolc
+// package return, build with the code of "target", and run the status
+// and the output of the program, which reads "input" and is always run
+// on the sandbox. targets lists the targets, and cancel stops the
+// request with "id". The requests are handled at once, up to
+// MaxConcurrent of them, each bounded by Timeout; check and build
+// keep what they found, by the hash of the source, for the ones that
+// send it again
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mgol-go/src/buildcache"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/exitcode"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/mgolc"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxCacheBytes is what the entries of the cache of check
+// and build may take, in bytes, when Server.MaxCacheBytes is 0
+const DefaultMaxCacheBytes = 64 << 20
+
+// Params are the ones of every method, each using its own
+type Params struct {
+	// Name is the file of the source, put on the diagnostics
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	// Target is the one build generates the code for
+	Target string `json:"target"`
+	// Conversions is named like the strictness of the flags, like avisar
+	Conversions  string `json:"conversions"`
+	Implicit     bool   `json:"implicit"`
+	DecimalComma bool   `json:"decimal_comma"`
+	// Language is the name of a profile, or the path of its file
+	Language string `json:"language"`
+	// Input is what the program run reads
+	Input string `json:"input"`
+}
+
+// Result is the answer of lex, parse, check and build
+type Result struct {
+	mgolc.Result
+	Code string `json:"code,omitempty"`
+	// CodeBase64 tells that Code is encoded on base64, as the
+	// target writes a binary file, like the bytecode
+	CodeBase64 bool `json:"code_base64,omitempty"`
+}
+
+// RunResult is the answer of run
+type RunResult struct {
+	mgolc.RunResult
+	Output string `json:"output"`
+}
+
+// cancelParams are the ones of cancel
+type cancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// Server answers the requests of any number of connections, which
+// share its limits and its cache
+type Server struct {
+	// MaxConcurrent is how many requests are handled at once,
+	// runtime.NumCPU() when 0. The others wait for their turn
+	MaxConcurrent int
+	// Timeout bounds each request, with no bound when 0
+	Timeout time.Duration
+	// MaxOutput is the size, in bytes, of the output of a
+	// program run kept, limits.DefaultMaxOutput when 0
+	MaxOutput int
+	// MaxCacheBytes bounds what the cache of check and build holds,
+	// DefaultMaxCacheBytes when 0. The entries used least recently
+	// are dropped to keep under it
+	MaxCacheBytes int64
+
+	once  sync.Once
+	slots chan struct{}
+	cache *buildcache.Cache
+}
+
+// NewServer returns a server with the default limits
+func NewServer() *Server {
+	return &Server{}
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		concurrent := s.MaxConcurrent
+		if concurrent <= 0 {
+			concurrent = runtime.NumCPU()
+		}
+		s.slots = make(chan struct{}, concurrent)
+		maxCache := s.MaxCacheBytes
+		if maxCache <= 0 {
+			maxCache = DefaultMaxCacheBytes
+		}
+		s.cache = buildcache.NewBoundedMemory(maxCache)
+	})
+}
+
+// Stats returns how much the cache of check and build holds and
+// how often the sources sent were found on it
+func (s *Server) Stats() buildcache.Stats {
+	s.init()
+	stats, _ := s.cache.Stats()
+	return stats
+}
+
+// Listen answers the connections accepted by listener, each on
+// a goroutine of its own, until it is closed
+func (s *Server) Listen(listener net.Listener) error {
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer connection.Close()
+			s.Serve(connection, connection)
+		}()
+	}
+}
+
+// Serve answers the requests read from r, writing the responses on
+// w as each request ends, until r does, waiting then for the requests
+// still running, so a client may close its side once it sent them all.
+// It only fails when r or w do
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.init()
+	c := &connection{server: s, output: w, running: make(map[string]context.CancelFunc)}
+	err := c.read(bufio.NewReader(r))
+	c.group.Wait()
+	if err == nil {
+		err = c.writeErr
+	}
+	return err
+}
+
+// connection holds the requests running of a client
+type connection struct {
+	server *Server
+	group  sync.WaitGroup
+
+	mutex  sync.Mutex
+	output io.Writer
+	// running maps the id of each request running to its cancel
+	running  map[string]context.CancelFunc
+	writeErr error
+}
+
+func (c *connection) read(r *bufio.Reader) error {
+	for {
+		content, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var request message
+		if err := json.Unmarshal(content, &request); err != nil {
+			c.reply(nil, nil, &responseError{codeParseError, err.Error()})
+			continue
+		}
+		if request.JSONRPC != "2.0" || request.Method == "" {
+			c.reply(request.ID, nil, &responseError{codeInvalidRequest, "esperava uma requisição de JSON-RPC 2.0, com o método"})
+			continue
+		}
+		if request.Method == "cancel" {
+			if err := c.cancel(request.Params); request.ID != nil {
+				c.reply(request.ID, nil, err)
+			}
+			continue
+		}
+		c.start(request)
+	}
+}
+
+// start handles request on a goroutine of its own, once there is
+// room for it among the requests of every connection
+func (c *connection) start(request message) {
+	ctx, cancel := c.server.context()
+	key := ""
+	if request.ID != nil {
+		key = string(*request.ID)
+		c.mutex.Lock()
+		c.running[key] = cancel
+		c.mutex.Unlock()
+	}
+
+	c.group.Add(1)
+	go func() {
+		defer c.group.Done()
+		defer func() {
+			cancel()
+			if key != "" {
+				c.mutex.Lock()
+				delete(c.running, key)
+				c.mutex.Unlock()
+			}
+		}()
+
+		var result interface{}
+		var requestErr *responseError
+		select {
+		case c.server.slots <- struct{}{}:
+			result, requestErr = c.server.call(ctx, request.Method, request.Params)
+			<-c.server.slots
+		case <-ctx.Done():
+			requestErr = canceled(ctx.Err())
+		}
+		if request.ID != nil {
+			c.reply(request.ID, result, requestErr)
+		}
+	}()
+}
+
+// context returns the one of a request, bounded by Timeout
+func (s *Server) context() (context.Context, context.CancelFunc) {
+	if s.Timeout > 0 {
+		return context.WithTimeout(context.Background(), s.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// cancel stops the request whose id is on params
+func (c *connection) cancel(params json.RawMessage) *responseError {
+	var decoded cancelParams
+	if err := json.Unmarshal(params, &decoded); err != nil || len(decoded.ID) == 0 {
+		return &responseError{codeInvalidParams, "cancel recebe o id da requisição"}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if cancel, found := c.running[string(decoded.ID)]; found {
+		cancel()
+	}
+	// A request that already ended has nothing left to stop
+	return nil
+}
+
+// reply writes the response to the request with id, keeping the
+// first error writing it for Serve
+func (c *connection) reply(id *json.RawMessage, result interface{}, err *responseError) {
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		response["error"] = err
+	} else {
+		response["result"] = result
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.writeErr == nil {
+		c.writeErr = writeMessage(c.output, response)
+	}
+}
+
+// canceled is the error of a request stopped by err,
+// its context being canceled or past its deadline
+func canceled(err error) *responseError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &responseError{codeRequestCancelled, "tempo limite da requisição excedido"}
+	}
+	return &responseError{codeRequestCancelled, "requisição cancelada"}
+}
+
+// call runs method with params
+func (s *Server) call(ctx context.Context, method string, raw json.RawMessage) (interface{}, *responseError) {
+	if method == "targets" {
+		return mgolc.Targets(), nil
+	}
+	var params Params
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+	}
+	options := mgolc.Options{Implicit: params.Implicit, DecimalComma: params.DecimalComma, Language: params.Language}
+	if params.Conversions != "" {
+		conversions, err := mgolc.ParseConversions(params.Conversions)
+		if err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		options.Conversions = conversions
+	}
+	source := mgolc.Source{Name: params.Name, Text: params.Source}
+
+	var result interface{}
+	var err error
+	switch method {
+	case "lex":
+		result, err = wrap(mgolc.Lex(source, options))
+	case "parse":
+		result, err = wrap(mgolc.Parse(source, options))
+	case "check":
+		result, err = s.compile(ctx, source, options)
+	case "build":
+		if params.Target == "" {
+			return nil, &responseError{codeInvalidParams, "build recebe o alvo, um de " + strings.Join(mgolc.Targets(), ", ")}
+		}
+		options.Target = params.Target
+		result, err = s.compile(ctx, source, options)
+	case "run":
+		result, err = s.run(ctx, source, params.Input, options)
+	default:
+		return nil, &responseError{codeMethodNotFound, "método desconhecido: " + method}
+	}
+	if ctx.Err() != nil {
+		return nil, canceled(ctx.Err())
+	}
+	if err != nil {
+		// Compile and the others only fail on wrong options
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	return result, nil
+}
+
+// wrap returns the answer of result
+func wrap(result mgolc.Result, err error) (Result, error) {
+	if err != nil {
+		return Result{}, err
+	}
+	answer := Result{Result: result, Code: string(result.Code)}
+	if !utf8.Valid(result.Code) {
+		answer.Code = base64.StdEncoding.EncodeToString(result.Code)
+		answer.CodeBase64 = true
+	}
+	return answer, nil
+}
+
+// compile checks source, and generates it for the target of options,
+// reading what was found from the cache when it was sent before
+func (s *Server) compile(ctx context.Context, source mgolc.Source, options mgolc.Options) (Result, error) {
+	// The profiles read from files may change with the same path
+	_, known := lexer.LookupProfile(options.Language)
+	if options.Language != "" && !known {
+		return wrap(mgolc.Compile(ctx, source, options))
+	}
+	key := buildcache.KeyOf([]string{
+		"daemon",
+		"target=" + options.Target,
+		"conversions=" + strconv.Itoa(int(options.Conversions)),
+		"implicit=" + strconv.FormatBool(options.Implicit),
+		"decimal-comma=" + strconv.FormatBool(options.DecimalComma),
+		"lang-profile=" + options.Language,
+	}, buildcache.Source{Name: source.Name, Text: source.Text})
+	if entry, found := s.cache.Get(key); found {
+		if result, err := fromEntry(source, entry); err == nil {
+			return wrap(result, nil)
+		}
+	}
+
+	result, err := mgolc.Compile(ctx, source, options)
+	if err != nil {
+		return Result{}, err
+	}
+	if entry, err := toEntry(result); err == nil {
+		s.cache.Put(key, entry)
+	}
+	return wrap(result, nil)
+}
+
+// toEntry returns result as an entry of the cache
+func toEntry(result mgolc.Result) (buildcache.Entry, error) {
+	tokens, err := json.Marshal(result.Tokens)
+	if err != nil {
+		return buildcache.Entry{}, err
+	}
+	entry := buildcache.Entry{Tokens: tokens, AST: result.AST, Output: result.Code, Diagnostics: []errorhandling.Diagnostic{}}
+	for _, diagnostic := range result.Diagnostics {
+		converted := errorhandling.NewDiagnostic(errorhandling.Severity(diagnostic.Severity), diagnostic.Position.Line, diagnostic.Position.Column, diagnostic.Message)
+		entry.Diagnostics = append(entry.Diagnostics, converted.WithCode(diagnostic.Code))
+	}
+	if !result.Accepted {
+		entry.Status = exitcode.ForDiagnostics(entry.Diagnostics)
+		if entry.Status == exitcode.Success {
+			entry.Status = exitcode.Syntax
+		}
+	}
+	return entry, nil
+}
+
+// fromEntry returns the result kept on entry for source
+func fromEntry(source mgolc.Source, entry buildcache.Entry) (mgolc.Result, error) {
+	result := mgolc.Result{AST: entry.AST, Code: entry.Output, Accepted: entry.Status == exitcode.Success, Diagnostics: []mgolc.Diagnostic{}}
+	if err := json.Unmarshal(entry.Tokens, &result.Tokens); err != nil {
+		return mgolc.Result{}, err
+	}
+	for _, diagnostic := range entry.Diagnostics {
+		result.Diagnostics = append(result.Diagnostics, mgolc.Diagnostic{
+			Severity: string(diagnostic.Severity),
+			Code:     diagnostic.Code,
+			File:     source.Name,
+			Position: mgolc.Position{Line: diagnostic.Line, Column: diagnostic.Column},
+			Message:  diagnostic.Message,
+		})
+	}
+	return result, nil
+}
+
+// run runs source reading input, keeping up to MaxOutput
+// bytes of what it writes
+func (s *Server) run(ctx context.Context, source mgolc.Source, input string, options mgolc.Options) (RunResult, error) {
+	limit := s.MaxOutput
+	if limit <= 0 {
+		limit = limits.DefaultMaxOutput
+	}
+	output := limits.NewOutputBuffer(limit)
+	result, err := mgolc.Run(ctx, source, strings.NewReader(input), output, options)
+	if err != nil {
+		return RunResult{}, err
+	}
+	if output.Exceeded() {
+		result.Status = "falha"
+		result.Error = limits.ErrorOutputLimit.Error()
+	}
+	return RunResult{result, output.String()}, nil
+}
@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mgol-go/src/limits"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const program = "inicio varinicio inteiro A; varfim; leia A; escreva A * 2; fim"
+
+// session runs server on requests and returns the responses
+// it wrote, by their id, as they are written in any order
+func session(t *testing.T, server *Server, requests ...map[string]interface{}) map[string]map[string]interface{} {
+	var input bytes.Buffer
+	for _, request := range requests {
+		request["jsonrpc"] = "2.0"
+		require.NoError(t, writeMessage(&input, request))
+	}
+	var output bytes.Buffer
+	require.NoError(t, server.Serve(&input, &output))
+
+	responses := map[string]map[string]interface{}{}
+	reader := bufio.NewReader(&output)
+	for {
+		content, err := readMessage(reader)
+		if err == io.EOF {
+			return responses
+		}
+		require.NoError(t, err)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(content, &response))
+		id, err := json.Marshal(response["id"])
+		require.NoError(t, err)
+		responses[string(id)] = response
+	}
+}
+
+func request(id int, method string, params map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"id": id, "method": method, "params": params}
+}
+
+func errorCode(response map[string]interface{}) float64 {
+	return response["error"].(map[string]interface{})["code"].(float64)
+}
+
+func TestMethods(t *testing.T) {
+	responses := session(t, NewServer(),
+		request(1, "lex", map[string]interface{}{"source": "inicio A <- 1.e;"}),
+		request(2, "parse", map[string]interface{}{"source": program}),
+		request(3, "check", map[string]interface{}{"name": "a.mgol", "source": "inicio varinicio varfim; escreva B; fim"}),
+		request(4, "build", map[string]interface{}{"source": program, "target": "python"}),
+		request(5, "run", map[string]interface{}{"source": program, "input": "21"}),
+		request(6, "targets", nil),
+	)
+	require.Len(t, responses, 6)
+
+	lexed := responses["1"]["result"].(map[string]interface{})
+	require.Len(t, lexed["tokens"], 6)
+	require.Equal(t, false, lexed["accepted"])
+
+	parsed := responses["2"]["result"].(map[string]interface{})
+	require.Equal(t, true, parsed["accepted"])
+	require.NotEmpty(t, parsed["ast"])
+
+	checked := responses["3"]["result"].(map[string]interface{})
+	require.Equal(t, false, checked["accepted"])
+	diagnostic := checked["diagnostics"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "a.mgol", diagnostic["file"])
+	require.Equal(t, "erro", diagnostic["severity"])
+
+	built := responses["4"]["result"].(map[string]interface{})
+	require.Equal(t, true, built["accepted"])
+	require.Contains(t, built["code"].(string), "print(")
+
+	run := responses["5"]["result"].(map[string]interface{})
+	require.Equal(t, "sucesso", run["status"])
+	require.Equal(t, "42", run["output"])
+
+	require.Contains(t, responses["6"]["result"].([]interface{}), "python")
+}
+
+func TestErrors(t *testing.T) {
+	responses := session(t, NewServer(),
+		request(1, "compile", nil),
+		request(2, "build", map[string]interface{}{"source": program}),
+		request(3, "build", map[string]interface{}{"source": program, "target": "cobol"}),
+		request(4, "check", map[string]interface{}{"source": program, "conversions": "talvez"}),
+		map[string]interface{}{"id": 5},
+	)
+	require.Len(t, responses, 5)
+	require.Equal(t, float64(codeMethodNotFound), errorCode(responses["1"]))
+	for _, id := range []string{"2", "3", "4"} {
+		require.Equal(t, float64(codeInvalidParams), errorCode(responses[id]))
+	}
+	require.Equal(t, float64(codeInvalidRequest), errorCode(responses["5"]))
+
+	var input bytes.Buffer
+	input.WriteString("Content-Length: 3\r\n\r\n{{}")
+	var output bytes.Buffer
+	require.NoError(t, NewServer().Serve(&input, &output))
+	content, err := readMessage(bufio.NewReader(&output))
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"code":-32700`)
+}
+
+func TestCancel(t *testing.T) {
+	// The only slot is taken, so the check waits until it is canceled
+	server := &Server{MaxConcurrent: 1}
+	server.init()
+	server.slots <- struct{}{}
+	responses := session(t, server,
+		request(1, "check", map[string]interface{}{"source": program}),
+		request(2, "cancel", map[string]interface{}{"id": 1}),
+	)
+	<-server.slots
+
+	require.Len(t, responses, 2)
+	require.Equal(t, float64(codeRequestCancelled), errorCode(responses["1"]))
+	require.Nil(t, responses["2"]["error"])
+}
+
+func TestOutputLimit(t *testing.T) {
+	server := &Server{MaxOutput: 4}
+	responses := session(t, server, request(1, "run", map[string]interface{}{"source": program, "input": "50000"}))
+	run := responses["1"]["result"].(map[string]interface{})
+	require.Equal(t, "falha", run["status"])
+	require.Equal(t, limits.ErrorOutputLimit.Error(), run["error"])
+	require.Equal(t, "1000", run["output"])
+}
+
+func TestCache(t *testing.T) {
+	server := NewServer()
+	params := map[string]interface{}{"source": program, "target": "python"}
+	first := session(t, server, request(1, "build", params))
+	second := session(t, server, request(1, "build", params))
+	require.Equal(t, first, second)
+
+	stats := server.Stats()
+	require.Equal(t, int64(1), stats.Hits)
+	require.Equal(t, int64(1), stats.Misses)
+	require.Equal(t, 1, stats.Entries)
+}
+
+func TestCacheBound(t *testing.T) {
+	server := &Server{MaxCacheBytes: 1}
+	params := map[string]interface{}{"source": program, "target": "python"}
+	session(t, server, request(1, "build", params))
+	session(t, server, request(1, "build", params))
+
+	stats := server.Stats()
+	require.Equal(t, int64(0), stats.Hits)
+	require.Equal(t, int64(2), stats.Misses)
+	require.Equal(t, 0, stats.Entries)
+}
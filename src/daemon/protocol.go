@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+var ErrorMissingLength = fmt.Errorf("mensagem sem Content-Length")
+
+// message is a request or a notification of JSON-RPC 2.0.
+// Notifications have no ID, and get no response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// responseError is the error of a request that failed
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// Codes of the errors of the responses, the ones of JSON-RPC and
+// RequestCancelled of the Language Server Protocol
+const (
+	codeParseError       = -32700
+	codeInvalidRequest   = -32600
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+	codeRequestCancelled = -32800
+)
+
+// readMessage reads a message framed like the ones of the Language
+// Server Protocol, its json after the headers, of which only
+// Content-Length is used, so the plugins of the editors reuse their
+// clients
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, ErrorMissingLength
+	}
+	content := make([]byte, length)
+	_, err = io.ReadFull(r, content)
+	return content, err
+}
+
+// writeMessage writes value as the json of a message
+func writeMessage(w io.Writer, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
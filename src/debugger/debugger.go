@@ -0,0 +1,270 @@
+// Package debugger runs a program on the interpreter of the interp
+// package stopping before its statements, by the lines of the source
+// kept on the syntax tree, so its variables can be looked at:
+//
+//	linha 3: leia A;
+//	(mgol) break 7
+//	(mgol) continue
+//	linha 7: escreva B;
+//	(mgol) print A + 1
+//
+// It stops before the first statement. step goes on to the next
+// statement, next to the next one that is not inside the se or
+// repita it stops on, and continue up to a breakpoint. The commands
+// are read from the same input as leia reads the values
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/console"
+	"mgol-go/src/interp"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorUnknownCommand = fmt.Errorf("comando desconhecido")
+	ErrorNoStatement    = fmt.Errorf("nenhum comando começa na linha")
+)
+
+// errorQuit stops the interpreter when quit is given
+var errorQuit = fmt.Errorf("execução interrompida pelo depurador")
+
+// Prompt is shown before each command
+const Prompt = "(mgol) "
+
+// help describes the commands, each with its short name
+const help = `step, s          executa até o próximo comando
+next, n          executa até o próximo comando fora do se ou repita atual
+continue, c      executa até um ponto de parada
+break, b LINHA   para antes do comando da linha LINHA
+delete, d LINHA  remove o ponto de parada da linha LINHA
+print, p EXPR    mostra o valor da expressão EXPR
+vars             mostra as variáveis, com seus valores
+quit, q          interrompe o programa
+Uma linha vazia repete o último comando
+`
+
+// mode is how the program goes on after a command
+type mode int
+
+const (
+	// stepping stops on the next statement
+	stepping mode = iota
+	// nexting stops on the next statement as deep as the one it
+	// was given on, or less
+	nexting
+	// running only stops on the breakpoints
+	running
+)
+
+// Debugger runs a program stopping on its statements
+type Debugger struct {
+	program     *ast.Program
+	info        *sem.Info
+	input       *bufio.Reader
+	output      *console.LineWriter
+	io          *numberTracker
+	environment *interp.Environment
+	// lines holds the lines where a statement starts
+	// and breakpoints the ones to stop on
+	lines       map[int]bool
+	breakpoints map[int]bool
+	mode        mode
+	// depth is the one of the statement next was given on
+	depth int
+	// previous is the last command, repeated by an empty line
+	previous string
+}
+
+// New returns a debugger for program, whose types are on info, that
+// reads its commands and the values of leia from r and writes on w,
+// the reals with options. The program must have been checked by the
+// sem package without errors
+func New(program *ast.Program, info *sem.Info, r io.Reader, w io.Writer, options backend.Options) *Debugger {
+	input := bufio.NewReader(r)
+	output := console.NewLineWriter(w)
+	programIO := &numberTracker{IO: console.New(input, output, options)}
+	d := &Debugger{
+		program:     program,
+		info:        info,
+		input:       input,
+		output:      output,
+		io:          programIO,
+		environment: interp.NewEnvironment(programIO, options),
+		lines:       make(map[int]bool),
+		breakpoints: make(map[int]bool),
+	}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if stmt, isStmt := node.(ast.Stmt); isStmt {
+			d.lines[stmt.Pos().Line] = true
+		}
+		return true
+	})
+	d.environment.Declare(d.declarations())
+	d.environment.SetHook(d.stop)
+	return d
+}
+
+// declarations returns the declarations of the program, the
+// implicit ones included
+func (d *Debugger) declarations() []*ast.VarDecl {
+	return append(append([]*ast.VarDecl{}, d.program.Declarations...), d.info.Implicit...)
+}
+
+// Break sets a breakpoint on line, which must have a statement
+func (d *Debugger) Break(line int) error {
+	if !d.lines[line] {
+		return fmt.Errorf("%w %d", ErrorNoStatement, line)
+	}
+	d.breakpoints[line] = true
+	return nil
+}
+
+// Run runs the program up to its end or to quit. The errors of the
+// commands are shown and the program goes on, so Run only fails like
+// the interpreter does, on an inteiro divided by zero
+func (d *Debugger) Run() error {
+	err := d.environment.Exec(d.program.Body, d.info)
+	d.output.EndLine()
+	if err == errorQuit {
+		return nil
+	}
+	if err == nil {
+		fmt.Fprintln(d.output, "o programa terminou")
+	}
+	return err
+}
+
+// stop is the hook of the interpreter, reading commands when
+// the program must stop before stmt
+func (d *Debugger) stop(stmt ast.Stmt, depth int) error {
+	line := stmt.Pos().Line
+	switch {
+	case d.mode == running && !d.breakpoints[line]:
+		return nil
+	case d.mode == nexting && depth > d.depth:
+		return nil
+	}
+	// What the program wrote is shown before it stops
+	d.io.Flush()
+	d.output.EndLine()
+	fmt.Fprintf(d.output, "linha %d: %s\n", line, ast.Header(stmt))
+
+	for {
+		io.WriteString(d.output, Prompt)
+		command, err := d.readCommand()
+		d.output.LineEnded()
+		switch {
+		case command == "" && err != nil:
+			// The program can not be told to go on anymore
+			return errorQuit
+		case command == "":
+			command = d.previous
+		}
+		d.previous = command
+		resume, commandErr := d.command(command, depth)
+		if resume || commandErr == errorQuit {
+			return commandErr
+		}
+		if commandErr != nil {
+			fmt.Fprintln(d.output, commandErr)
+		}
+	}
+}
+
+// readCommand reads the next command, skipping what is left of the
+// line the program read a number from
+func (d *Debugger) readCommand() (string, error) {
+	line, err := d.input.ReadString('\n')
+	if d.io.numberRead && strings.TrimSpace(line) == "" && err == nil {
+		line, err = d.input.ReadString('\n')
+	}
+	d.io.numberRead = false
+	return strings.TrimSpace(line), err
+}
+
+// command runs command, telling whether the program goes on
+func (d *Debugger) command(command string, depth int) (bool, error) {
+	name, argument := command, ""
+	if space := strings.IndexAny(command, " \t"); space >= 0 {
+		name, argument = command[:space], strings.TrimSpace(command[space:])
+	}
+	switch name {
+	case "":
+	case "step", "s":
+		d.mode = stepping
+		return true, nil
+	case "next", "n":
+		d.mode, d.depth = nexting, depth
+		return true, nil
+	case "continue", "c":
+		d.mode = running
+		return true, nil
+	case "break", "b", "delete", "d":
+		line, err := strconv.Atoi(argument)
+		if err != nil {
+			return false, fmt.Errorf("%w %s", ErrorNoStatement, argument)
+		}
+		if name == "delete" || name == "d" {
+			delete(d.breakpoints, line)
+			return false, nil
+		}
+		return false, d.Break(line)
+	case "print", "p":
+		value, err := d.eval(argument)
+		if err == nil {
+			fmt.Fprintln(d.output, value)
+		}
+		return false, err
+	case "vars":
+		for _, variable := range d.environment.Variables() {
+			fmt.Fprintln(d.output, variable)
+		}
+	case "help", "h":
+		io.WriteString(d.output, help)
+	case "quit", "q":
+		return false, errorQuit
+	default:
+		return false, fmt.Errorf("%w: %s, help lista os comandos", ErrorUnknownCommand, name)
+	}
+	return false, nil
+}
+
+// eval returns the value of the arithmetic expression text, checked
+// against the declarations of the program
+func (d *Debugger) eval(text string) (string, error) {
+	expr, err := parser.ParseExpression(text)
+	if err != nil {
+		return "", err
+	}
+	program := &ast.Program{Declarations: d.declarations(), Body: []ast.Stmt{&ast.Write{Value: expr}}}
+	info := sem.NewChecker(nil).Check(program)
+	if len(info.Errors) > 0 {
+		return "", info.Errors[0]
+	}
+	return d.environment.Eval(expr, info)
+}
+
+// numberTracker notes when the program reads a number, as its line
+// is left on the input without the line break
+type numberTracker struct {
+	console.IO
+	numberRead bool
+}
+
+func (t *numberTracker) ReadInteger() int32 {
+	t.numberRead = true
+	return t.IO.ReadInteger()
+}
+
+func (t *numberTracker) ReadReal() float64 {
+	t.numberRead = true
+	return t.IO.ReadReal()
+}
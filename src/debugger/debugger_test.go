@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const grammarPath = "../parser/grammar.json"
+
+// source adds the numbers from the one read down to 1
+const source = `inicio
+  varinicio
+    inteiro A;
+    inteiro S;
+  varfim;
+  leia A;
+  repita (A > 0)
+    S <- S + A;
+    A <- A - 1;
+  fimrepita
+  escreva S;
+fim
+`
+
+func parse(t *testing.T, source string) (*ast.Program, *sem.Info) {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	p := parser.NewRecursiveDescentParser(lexer.NewStringScanner(source, symbolTable), parser.GetRulesMap(grammarPath))
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	result := p.Parse()
+	require.Empty(t, result.Errors)
+	info := sem.NewChecker(nil).Check(result.Program)
+	require.Empty(t, info.Errors)
+	return result.Program, info
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		commands string
+		output   string
+	}{
+		{
+			"step into the loop",
+			"s\n2\ns\ns\nvars\n",
+			"linha 6: leia A;\n" +
+				"linha 7: repita (A > 0)\n" +
+				"linha 8: S <- S + A;\n" +
+				"linha 9: A <- A - 1;\n" +
+				"inteiro A = 2\ninteiro S = 2\n",
+		},
+		{
+			"next over the loop",
+			"n\n3\nn\np S * 2\nc\n",
+			"linha 6: leia A;\n" +
+				"linha 7: repita (A > 0)\n" +
+				"linha 11: escreva S;\n" +
+				"12\n" +
+				"6\n" +
+				"o programa terminou\n",
+		},
+		{
+			"breakpoint hit on each iteration",
+			"b 9\nc\n2\np A\nc\np A\nd 9\nc\n",
+			"linha 6: leia A;\n" +
+				"linha 9: A <- A - 1;\n" +
+				"2\n" +
+				"linha 9: A <- A - 1;\n" +
+				"1\n" +
+				"3\n" +
+				"o programa terminou\n",
+		},
+		{
+			"empty line repeats the command",
+			"s\n1\n\n\n",
+			"linha 6: leia A;\n" +
+				"linha 7: repita (A > 0)\n" +
+				"linha 8: S <- S + A;\n" +
+				"linha 9: A <- A - 1;\n",
+		},
+		{
+			"errors do not resume",
+			"b 3\np B\nsalta\nq\n",
+			"linha 6: leia A;\n" +
+				"nenhum comando começa na linha 3\n" +
+				"Erro semântico na linha 1, coluna 1: variável não declarada: 'B'\n" +
+				"comando desconhecido: salta, help lista os comandos\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, info := parse(t, source)
+			var output bytes.Buffer
+			require.NoError(t, New(program, info, strings.NewReader(tc.commands), &output, backend.Options{}).Run())
+			require.Equal(t, tc.output, strings.ReplaceAll(output.String(), Prompt, ""))
+		})
+	}
+}
+
+func TestBreak(t *testing.T) {
+	program, info := parse(t, source)
+	d := New(program, info, strings.NewReader(""), &bytes.Buffer{}, backend.Options{})
+	require.NoError(t, d.Break(8))
+	require.ErrorIs(t, d.Break(4), ErrorNoStatement)
+}
@@ -0,0 +1,77 @@
+package dot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type Shape string
+
+const (
+	Ellipse Shape = "ellipse"
+	Box     Shape = "box"
+	// Circle and DoubleCircle are the states of an automaton, the
+	// final ones doubled, and Point the start of the arrow to the
+	// initial state
+	Circle       Shape = "circle"
+	DoubleCircle Shape = "doublecircle"
+	Point        Shape = "point"
+)
+
+type node struct {
+	label string
+	shape Shape
+}
+
+type edge struct {
+	from  int
+	to    int
+	label string
+}
+
+// Graph is a directed graph written on the Graphviz DOT language
+type Graph struct {
+	name  string
+	nodes []node
+	edges []edge
+}
+
+func NewGraph(name string) *Graph {
+	return &Graph{name: name}
+}
+
+// AddNode adds a node and returns its id
+func (g *Graph) AddNode(label string, shape Shape) int {
+	g.nodes = append(g.nodes, node{label: label, shape: shape})
+	return len(g.nodes) - 1
+}
+
+// AddEdge adds an edge between the nodes with the ids from
+// and to. An empty label leaves the edge without one
+func (g *Graph) AddEdge(from, to int, label string) {
+	g.edges = append(g.edges, edge{from: from, to: to, label: label})
+}
+
+// Write writes the graph on the DOT language, nodes and edges
+// in the order they were added. The graphs drawn are trees, so
+// the edges leaving a node are kept in the order they were added
+func (g *Graph) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "digraph %s {\n", strconv.Quote(g.name))
+	fmt.Fprintln(writer, "\tordering=\"out\";")
+	fmt.Fprintln(writer, "\tnode [fontname=\"monospace\"];")
+	for id, n := range g.nodes {
+		fmt.Fprintf(writer, "\tn%d [label=%s, shape=%s];\n", id, strconv.Quote(n.label), n.shape)
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			fmt.Fprintf(writer, "\tn%d -> n%d;\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(writer, "\tn%d -> n%d [label=%s];\n", e.from, e.to, strconv.Quote(e.label))
+	}
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
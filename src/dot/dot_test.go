@@ -0,0 +1,28 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	graph := NewGraph("tree")
+	root := graph.AddNode("Write", Ellipse)
+	child := graph.AddNode(`Literal "ok"`, Box)
+	graph.AddEdge(root, child, "value")
+	graph.AddEdge(root, child, "")
+
+	output := &bytes.Buffer{}
+	require.NoError(t, graph.Write(output))
+	require.Equal(t, `digraph "tree" {
+	ordering="out";
+	node [fontname="monospace"];
+	n0 [label="Write", shape=ellipse];
+	n1 [label="Literal \"ok\"", shape=box];
+	n0 -> n1 [label="value"];
+	n0 -> n1;
+}
+`, output.String())
+}
@@ -0,0 +1,191 @@
+package errorhandling
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+type Severity string
+
+// Available severities of a diagnostic
+const (
+	Error   Severity = "erro"
+	Warning Severity = "aviso"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	// Code tells the kind of the problem apart, so that tools do
+	// not depend on the message. Lexical codes start with L,
+	// semantic ones with S and the ones of the linter with W
+	Code    string
+	Line    int
+	Column  int
+	Message string
+	Count   int
+}
+
+func NewDiagnostic(severity Severity, line, column int, message string) Diagnostic {
+	return Diagnostic{
+		Severity: severity,
+		Line:     line,
+		Column:   column,
+		Message:  message,
+		Count:    1,
+	}
+}
+
+// isDuplicateOf returns whether d repeats other on the
+// same line, differing at most by the column
+func (d Diagnostic) isDuplicateOf(other Diagnostic) bool {
+	return d.Severity == other.Severity && d.Code == other.Code && d.Line == other.Line && d.Message == other.Message
+}
+
+// WithCode returns d with code as its Code
+func (d Diagnostic) WithCode(code string) Diagnostic {
+	d.Code = code
+	return d
+}
+
+func (d Diagnostic) String() string {
+	text := fmt.Sprintf("%s na linha %d coluna %d, %s", d.Severity, d.Line, d.Column, d.Message)
+	if d.Count > 1 {
+		text = fmt.Sprintf("%s (%d ocorrências)", text, d.Count)
+	}
+	return text
+}
+
+// DiagnosticHandler receives the diagnostics produced by the
+// compiler phases, deciding where they should be sent to
+type DiagnosticHandler interface {
+	Handle(diagnostic Diagnostic)
+}
+
+// DiagnosticHandlerFunc allows an ordinary function
+// to be used as a DiagnosticHandler
+type DiagnosticHandlerFunc func(diagnostic Diagnostic)
+
+func (f DiagnosticHandlerFunc) Handle(diagnostic Diagnostic) {
+	f(diagnostic)
+}
+
+// LogHandler writes every diagnostic to the standard logger
+type LogHandler struct{}
+
+func (LogHandler) Handle(diagnostic Diagnostic) {
+	log.Print(diagnostic)
+}
+
+// DiagnosticBuffer holds diagnostics until they are flushed,
+// so they can be emitted in source order and with consecutive
+// duplicates collapsed into a single diagnostic
+type DiagnosticBuffer struct {
+	mutex       sync.Mutex
+	diagnostics []Diagnostic
+}
+
+func NewDiagnosticBuffer() *DiagnosticBuffer {
+	return &DiagnosticBuffer{}
+}
+
+func (b *DiagnosticBuffer) Add(diagnostic Diagnostic) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.diagnostics = append(b.diagnostics, diagnostic)
+}
+
+func (b *DiagnosticBuffer) Handle(diagnostic Diagnostic) {
+	b.Add(diagnostic)
+}
+
+// Diagnostics returns the buffered diagnostics sorted by
+// position, with consecutive duplicates collapsed and counted
+func (b *DiagnosticBuffer) Diagnostics() []Diagnostic {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return collapse(b.diagnostics)
+}
+
+// Flush logs every buffered diagnostic and empties the buffer
+func (b *DiagnosticBuffer) Flush() {
+	b.FlushTo(LogHandler{})
+}
+
+// FlushTo sends every buffered diagnostic to handler
+// and empties the buffer
+func (b *DiagnosticBuffer) FlushTo(handler DiagnosticHandler) {
+	b.mutex.Lock()
+	diagnostics := collapse(b.diagnostics)
+	b.diagnostics = nil
+	b.mutex.Unlock()
+
+	for _, diagnostic := range diagnostics {
+		handler.Handle(diagnostic)
+	}
+}
+
+func collapse(diagnostics []Diagnostic) []Diagnostic {
+	sorted := make([]Diagnostic, len(diagnostics))
+	copy(sorted, diagnostics)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Column < sorted[j].Column
+	})
+
+	result := []Diagnostic{}
+	for _, diagnostic := range sorted {
+		last := len(result) - 1
+		if last >= 0 && diagnostic.isDuplicateOf(result[last]) {
+			result[last].Count += diagnostic.Count
+			continue
+		}
+		result = append(result, diagnostic)
+	}
+	return result
+}
+
+var diagnosticBuffer *DiagnosticBuffer
+
+// EnableBuffering makes the reported diagnostics be held
+// until FlushDiagnostics is called instead of being logged
+// right away
+func EnableBuffering() {
+	if diagnosticBuffer == nil {
+		diagnosticBuffer = NewDiagnosticBuffer()
+	}
+}
+
+// DisableBuffering flushes the pending diagnostics and goes
+// back to logging them as soon as they are reported
+func DisableBuffering() {
+	FlushDiagnostics()
+	diagnosticBuffer = nil
+}
+
+// FlushDiagnostics logs the buffered diagnostics, if any
+func FlushDiagnostics() {
+	if diagnosticBuffer != nil {
+		diagnosticBuffer.Flush()
+	}
+}
+
+type defaultHandler struct{}
+
+func (defaultHandler) Handle(diagnostic Diagnostic) {
+	if diagnosticBuffer != nil {
+		diagnosticBuffer.Add(diagnostic)
+		return
+	}
+	log.Print(diagnostic)
+}
+
+// DefaultHandler returns the handler used when none is provided.
+// It logs the diagnostics, holding them first if buffering is enabled
+func DefaultHandler() DiagnosticHandler {
+	return defaultHandler{}
+}
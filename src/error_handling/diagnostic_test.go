@@ -0,0 +1,81 @@
+package errorhandling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticBuffer(t *testing.T) {
+	testCases := []struct {
+		name                string
+		reported            []Diagnostic
+		expectedDiagnostics []string
+	}{
+		{
+			name: "Diagnostics are sorted by position",
+			reported: []Diagnostic{
+				NewDiagnostic(Error, 2, 1, "palavra $ inexistente na linguagem"),
+				NewDiagnostic(Error, 1, 5, "número 1. inválido"),
+				NewDiagnostic(Warning, 1, 1, "identificador Se difere da palavra reservada se apenas por maiúsculas/minúsculas"),
+			},
+			expectedDiagnostics: []string{
+				"aviso na linha 1 coluna 1, identificador Se difere da palavra reservada se apenas por maiúsculas/minúsculas",
+				"erro na linha 1 coluna 5, número 1. inválido",
+				"erro na linha 2 coluna 1, palavra $ inexistente na linguagem",
+			},
+		},
+		{
+			name: "Consecutive duplicates are collapsed",
+			reported: []Diagnostic{
+				NewDiagnostic(Error, 1, 1, "palavra ! inexistente na linguagem"),
+				NewDiagnostic(Error, 1, 2, "palavra ! inexistente na linguagem"),
+				NewDiagnostic(Error, 1, 3, "palavra ! inexistente na linguagem"),
+			},
+			expectedDiagnostics: []string{
+				"erro na linha 1 coluna 1, palavra ! inexistente na linguagem (3 ocorrências)",
+			},
+		},
+		{
+			name: "Duplicates in different lines are kept",
+			reported: []Diagnostic{
+				NewDiagnostic(Error, 1, 1, "palavra ! inexistente na linguagem"),
+				NewDiagnostic(Error, 2, 1, "palavra ! inexistente na linguagem"),
+			},
+			expectedDiagnostics: []string{
+				"erro na linha 1 coluna 1, palavra ! inexistente na linguagem",
+				"erro na linha 2 coluna 1, palavra ! inexistente na linguagem",
+			},
+		},
+		{
+			name: "Duplicates separated by another diagnostic are kept",
+			reported: []Diagnostic{
+				NewDiagnostic(Error, 1, 1, "palavra ! inexistente na linguagem"),
+				NewDiagnostic(Error, 1, 3, "palavra ! inexistente na linguagem"),
+				NewDiagnostic(Error, 1, 2, "palavra % inexistente na linguagem"),
+			},
+			expectedDiagnostics: []string{
+				"erro na linha 1 coluna 1, palavra ! inexistente na linguagem",
+				"erro na linha 1 coluna 2, palavra % inexistente na linguagem",
+				"erro na linha 1 coluna 3, palavra ! inexistente na linguagem",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+
+			buffer := NewDiagnosticBuffer()
+			for _, diagnostic := range tc.reported {
+				buffer.Add(diagnostic)
+			}
+
+			actualDiagnostics := []string{}
+			for _, diagnostic := range buffer.Diagnostics() {
+				actualDiagnostics = append(actualDiagnostics, diagnostic.String())
+			}
+			r.Equal(tc.expectedDiagnostics, actualDiagnostics)
+		})
+	}
+}
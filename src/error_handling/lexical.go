@@ -1,7 +1,7 @@
 package errorhandling
 
 import (
-	"log"
+	"fmt"
 	"strings"
 )
 
@@ -14,6 +14,23 @@ const (
 	InvalidWord
 )
 
+// Codes of the lexical diagnostics
+const (
+	CodeInvalidLiteral = "L001"
+	CodeInvalidNumber  = "L002"
+	CodeInvalidComment = "L003"
+	CodeInvalidWord    = "L004"
+	CodeKeywordCase    = "L005"
+)
+
+// lexicalCodes holds the code of each type of lexical error
+var lexicalCodes = map[LexicalErrorType]string{
+	InvalidLiteral: CodeInvalidLiteral,
+	InvalidNumber:  CodeInvalidNumber,
+	InvalidComment: CodeInvalidComment,
+	InvalidWord:    CodeInvalidWord,
+}
+
 func isInvalidNumber(lexem string) bool {
 	containsQuotation := strings.Contains(lexem, "\"")
 	containsBrackets := strings.Contains(lexem, "{")
@@ -59,17 +76,40 @@ func getErrorType(lexem string) LexicalErrorType {
 	return InvalidWord
 }
 
-func NewLexicalError(line, column int, lexem string) {
+func NewLexicalError(line, column int, lexem string) Diagnostic {
 	errorType := getErrorType(lexem)
 
+	message := ""
 	switch errorType {
 	case InvalidLiteral:
-		log.Printf("erro na linha %d coluna %d, literal %s inválido", line, column, lexem)
+		message = fmt.Sprintf("literal %s inválido", lexem)
 	case InvalidNumber:
-		log.Printf("erro na linha %d coluna %d, número %s inválido", line, column, lexem)
+		message = fmt.Sprintf("número %s inválido", lexem)
 	case InvalidComment:
-		log.Printf("erro na linha %d coluna %d, comentário %s inválido", line, column, lexem)
+		message = fmt.Sprintf("comentário %s inválido", lexem)
 	case InvalidWord:
-		log.Printf("erro na linha %d coluna %d, palavra %s inexistente na linguagem", line, column, lexem)
+		message = fmt.Sprintf("palavra %s inexistente na linguagem", lexem)
 	}
+	return NewDiagnostic(Error, line, column, message).WithCode(lexicalCodes[errorType])
+}
+
+// NewUnterminatedLexicalError describes a lexem that reached the end
+// of the file without being closed, pointing at where it was opened
+func NewUnterminatedLexicalError(line, column, openingLine, openingColumn int, lexem string) Diagnostic {
+	errorType := getErrorType(lexem)
+
+	switch errorType {
+	case InvalidLiteral:
+		return NewDiagnostic(Error, line, column, fmt.Sprintf("literal %s inválido, aspas abertas na linha %d coluna %d", lexem, openingLine, openingColumn)).WithCode(CodeInvalidLiteral)
+	case InvalidComment:
+		return NewDiagnostic(Error, line, column, fmt.Sprintf("comentário %s inválido, chave aberta na linha %d coluna %d", lexem, openingLine, openingColumn)).WithCode(CodeInvalidComment)
+	default:
+		return NewLexicalError(line, column, lexem)
+	}
+}
+
+// NewKeywordCaseWarning warns about an identifier that only
+// differs from a reserved word by the case of its letters
+func NewKeywordCaseWarning(line, column int, lexem, keyword string) Diagnostic {
+	return NewDiagnostic(Warning, line, column, fmt.Sprintf("identificador %s difere da palavra reservada %s apenas por maiúsculas/minúsculas", lexem, keyword)).WithCode(CodeKeywordCase)
 }
@@ -11,31 +11,37 @@ func TestGetErrorType(t *testing.T) {
 		name         string
 		lexem        string
 		expectedType LexicalErrorType
+		expectedCode string
 	}{
 		{
 			name:         "Lexical error",
 			lexem:        `"this is an error`,
 			expectedType: InvalidLiteral,
+			expectedCode: CodeInvalidLiteral,
 		},
 		{
 			name:         "Default number error",
 			lexem:        "123123.",
 			expectedType: InvalidNumber,
+			expectedCode: CodeInvalidNumber,
 		},
 		{
 			name:         "Number error with letter",
 			lexem:        "1231e",
 			expectedType: InvalidNumber,
+			expectedCode: CodeInvalidNumber,
 		},
 		{
 			name:         "Invalid comment",
 			lexem:        "{asdfasdf",
 			expectedType: InvalidComment,
+			expectedCode: CodeInvalidComment,
 		},
 		{
 			name:         "Invalid word",
 			lexem:        "adaweqw$",
 			expectedType: InvalidWord,
+			expectedCode: CodeInvalidWord,
 		},
 	}
 
@@ -45,6 +51,7 @@ func TestGetErrorType(t *testing.T) {
 
 			actualValue := getErrorType(tc.lexem)
 			r.Equal(tc.expectedType, actualValue)
+			r.Equal(tc.expectedCode, NewLexicalError(1, 1, tc.lexem).Code)
 		})
 	}
 }
@@ -0,0 +1,73 @@
+// Package exitcode holds the statuses the commands of the compiler end
+// with, so a script can tell a program that is wrong from a compiler
+// that failed:
+//
+//	0  the program was compiled, or run, without errors
+//	1  lexical or syntax errors
+//	2  semantic errors, like a variable not declared
+//	3  internal error, the compiler failed on its own
+//	4  usage error, like an unknown flag or a file that does not exist
+//	5  the program was run and stopped on an error
+package exitcode
+
+import (
+	"fmt"
+	"log"
+	errorhandling "mgol-go/src/error_handling"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	Success  = 0
+	Syntax   = 1
+	Semantic = 2
+	Internal = 3
+	Usage    = 4
+	// Runtime is the status of a program stopped while running, like
+	// on an inteiro divided by zero or a limit gone over
+	Runtime = 5
+)
+
+// semanticPrefix starts the codes of the semantic diagnostics
+const semanticPrefix = "S"
+
+// ForDiagnostics returns the status of a program with diagnostics:
+// Syntax if any of the errors is lexical or syntactic, Semantic if they
+// all come from the type checker, and Success if there are only warnings
+func ForDiagnostics(diagnostics []errorhandling.Diagnostic) int {
+	status := Success
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != errorhandling.Error {
+			continue
+		}
+		if !strings.HasPrefix(diagnostic.Code, semanticPrefix) {
+			return Syntax
+		}
+		status = Semantic
+	}
+	return status
+}
+
+// Fatal logs v, like log.Print, and ends the process with status
+func Fatal(status int, v ...interface{}) {
+	log.Print(v...)
+	os.Exit(status)
+}
+
+// Fatalf logs v with format, like log.Printf, and ends the
+// process with status
+func Fatalf(status int, format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(status)
+}
+
+// Recover ends the process with Internal when the goroutine it is
+// deferred on panics, logging the panic along with its stack. Otherwise
+// Go would end it with 2, the status of the semantic errors
+func Recover() {
+	if r := recover(); r != nil {
+		Fatal(Internal, fmt.Sprintf("erro interno: %v\n%s", r, debug.Stack()))
+	}
+}
@@ -0,0 +1,34 @@
+package exitcode
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForDiagnostics(t *testing.T) {
+	lexical := errorhandling.NewLexicalError(1, 5, "@")
+	syntax := errorhandling.NewDiagnostic(errorhandling.Error, 2, 6, "esperava id")
+	semantic := errorhandling.NewDiagnostic(errorhandling.Error, 3, 9, "variável não declarada: 'B'").WithCode("S001")
+	warning := errorhandling.NewDiagnostic(errorhandling.Warning, 4, 1, "variável declarada mas nunca usada: 'C'").WithCode("S011")
+
+	testCases := []struct {
+		name        string
+		diagnostics []errorhandling.Diagnostic
+		status      int
+	}{
+		{"none", nil, Success},
+		{"warnings", []errorhandling.Diagnostic{warning}, Success},
+		{"lexical", []errorhandling.Diagnostic{lexical, warning}, Syntax},
+		{"syntax", []errorhandling.Diagnostic{syntax}, Syntax},
+		{"semantic", []errorhandling.Diagnostic{semantic, warning}, Semantic},
+		{"syntax after semantic", []errorhandling.Diagnostic{semantic, syntax}, Syntax},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.status, ForDiagnostics(tc.diagnostics))
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package explain
+
+// explanations are sorted by code. The titles of the semantic ones
+// are the messages of the errors of the sem package
+var explanations = []Explanation{
+	{
+		Code:  "L001",
+		Title: "literal inválido",
+		Description: "Um literal é um texto entre aspas duplas, na mesma linha, como \"Digite a nota\". " +
+			"Ele só pode ter letras sem acento, dígitos, espaços, tabulações e os símbolos que a linguagem conhece.",
+		Causes: []string{
+			"as aspas que fecham o literal foram esquecidas, e ele vai até o fim da linha ou do arquivo",
+			"o literal tem uma letra acentuada, um ç ou um símbolo como # ou @",
+			"o literal foi quebrado em duas linhas",
+		},
+		Example: "inicio\nvarinicio\nvarfim;\nescreva \"Digite a nota;\nfim\n",
+		Fixed:   "inicio\nvarinicio\nvarfim;\nescreva \"Digite a nota\";\nfim\n",
+	},
+	{
+		Code:  "L002",
+		Title: "número inválido",
+		Description: "Um número é inteiro, como 42, ou real, como 3.14, e pode ter um expoente, como 2.5E-3. " +
+			"Depois do ponto e do E deve vir ao menos um dígito.",
+		Causes: []string{
+			"o número termina no ponto, como 3., ou no expoente, como 1e",
+			"a parte fracionária foi separada por vírgula, como 3,14",
+			"uma letra foi colada ao número, como 2A",
+		},
+		Example: "inicio\nvarinicio\n\treal A;\nvarfim;\nA <- 3.;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\treal A;\nvarfim;\nA <- 3.0;\nescreva A;\nfim\n",
+	},
+	{
+		Code:  "L003",
+		Title: "comentário inválido",
+		Description: "Um comentário fica entre chaves, { e }, na mesma linha, e é ignorado pelo compilador. " +
+			"Como um literal, ele só pode ter letras sem acento, dígitos e os símbolos da linguagem.",
+		Causes: []string{
+			"a chave que fecha o comentário foi esquecida",
+			"o comentário foi quebrado em várias linhas, em vez de um comentário em cada uma",
+			"uma chave } foi escrita sem a { que a abre",
+			"o comentário tem uma letra acentuada ou um ç",
+		},
+		Example: "inicio\nvarinicio\nvarfim;\n{ escreve a saudacao\nescreva \"oi\";\nfim\n",
+		Fixed:   "inicio\nvarinicio\nvarfim;\n{ escreve a saudacao }\nescreva \"oi\";\nfim\n",
+	},
+	{
+		Code:  "L004",
+		Title: "palavra inexistente na linguagem",
+		Description: "O programa tem um símbolo que não faz parte de nenhum token de mgol. Os identificadores " +
+			"têm letras sem acento, dígitos e _, começando por uma letra, e os operadores são <-, +, -, *, /, " +
+			"<, >, <=, >=, = e <>.",
+		Causes: []string{
+			"foi usado um operador de outra linguagem, como % para o resto ou == para a igualdade",
+			"um identificador tem uma letra acentuada ou um ç, como MEDIA com acento",
+			"um identificador começa com _ ou com um dígito",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia B;\nA <- B % 2;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia B;\nA <- B - B / 2 * 2;\nescreva A;\nfim\n",
+	},
+	{
+		Code:  "L005",
+		Title: "identificador difere de uma palavra reservada apenas por maiúsculas/minúsculas",
+		Description: "As palavras reservadas de mgol são escritas em minúsculas, e um nome como Fim ou SE é um " +
+			"identificador diferente delas. É permitido, mas quase sempre é uma palavra reservada digitada errado, " +
+			"ou um nome que confunde quem lê o programa.",
+		Causes: []string{
+			"uma palavra reservada foi escrita com maiúsculas, como Escreva ou FIMSE",
+			"uma variável recebeu o nome de uma palavra reservada, como Fim ou Real",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro Fim;\nvarfim;\nleia Fim;\nescreva Fim;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro FINAL;\nvarfim;\nleia FINAL;\nescreva FINAL;\nfim\n",
+	},
+	{
+		Code:  "S001",
+		Title: "variável não declarada",
+		Description: "Toda variável deve ser declarada entre varinicio e varfim, com o seu tipo, antes de ser usada. " +
+			"Os nomes diferenciam maiúsculas de minúsculas. Com a opção -implicit, a primeira atribuição " +
+			"declara a variável, mas ler uma variável que nunca recebeu um valor continua sendo um erro.",
+		Causes: []string{
+			"a declaração da variável foi esquecida",
+			"o nome foi digitado errado, ou com outras maiúsculas, como Media em vez de MEDIA",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\nB <- A * 2;\nescreva B;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia A;\nB <- A * 2;\nescreva B;\nfim\n",
+	},
+	{
+		Code:        "S002",
+		Title:       "variável declarada mais de uma vez",
+		Description: "Cada nome só pode ser declarado uma vez, e tem um único tipo em todo o programa.",
+		Causes: []string{
+			"uma declaração foi copiada e o nome não foi trocado",
+			"a mesma variável foi declarada com dois tipos, para guardar valores diferentes",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\treal A;\nvarfim;\nleia A;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\treal A;\nvarfim;\nleia A;\nescreva A;\nfim\n",
+	},
+	{
+		Code:  "S003",
+		Title: "tipos diferentes para a atribuição",
+		Description: "O valor atribuído a uma variável deve ser do seu tipo. Um literal não recebe números, só o " +
+			"valor de outro literal ou o que for lido por leia, e um número não recebe um literal. Entre inteiro " +
+			"e real, veja S004 e S005.",
+		Causes: []string{
+			"a variável foi declarada literal para guardar um número",
+			"uma variável literal foi atribuída a uma numérica, esperando que o texto fosse convertido",
+		},
+		Example: "inicio\nvarinicio\n\tliteral IDADE;\nvarfim;\nIDADE <- 18;\nescreva IDADE;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro IDADE;\nvarfim;\nIDADE <- 18;\nescreva IDADE;\nfim\n",
+	},
+	{
+		Code:  "S004",
+		Title: "atribuição de um real a um inteiro perde a parte fracionária",
+		Description: "Guardar um real em uma variável inteiro descarta o que vem depois do ponto: 7.9 vira 7. " +
+			"Por padrão é um aviso; a opção -narrowing o permite, com permitir, ou o torna um erro, com proibir.",
+		Causes: []string{
+			"a variável deveria ser real, como uma média",
+			"uma divisão de reais foi guardada esperando o resultado arredondado, que não acontece",
+		},
+		Example: "inicio\nvarinicio\n\treal NOTA;\n\tinteiro MEDIA;\nvarfim;\nleia NOTA;\nMEDIA <- NOTA / 2;\nescreva MEDIA;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\treal NOTA;\n\treal MEDIA;\nvarfim;\nleia NOTA;\nMEDIA <- NOTA / 2;\nescreva MEDIA;\nfim\n",
+	},
+	{
+		Code:  "S005",
+		Title: "conversão implícita de inteiro para real",
+		Description: "Um inteiro usado em uma operação com um real, ou atribuído a uma variável real, é convertido " +
+			"para real. O valor não muda, mas a conversão pode esconder um tipo declarado errado. Por padrão é um " +
+			"aviso; a opção -promotion o permite, com permitir, ou o torna um erro, com proibir.",
+		Causes: []string{
+			"variáveis que guardam o mesmo tipo de valor foram declaradas com tipos diferentes",
+			"um número inteiro foi escrito onde se queria um real, como 2 em vez de 2.0",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\treal B;\nvarfim;\nleia A;\nB <- A;\nescreva B;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\treal A;\n\treal B;\nvarfim;\nleia A;\nB <- A;\nescreva B;\nfim\n",
+	},
+	{
+		Code:  "S006",
+		Title: "operandos com tipos incompatíveis",
+		Description: "Os dois lados de uma operação ou de uma comparação devem ser números, inteiros ou reais, " +
+			"ou os dois literais.",
+		Causes: []string{
+			"um literal foi comparado a um número, como uma resposta lida como literal",
+			"uma variável foi declarada literal para guardar um número",
+		},
+		Example: "inicio\nvarinicio\n\tliteral IDADE;\nvarfim;\nleia IDADE;\nse (IDADE >= 18) entao\n\tescreva \"maior\";\nsenao\n\tescreva \"menor\";\nfimse\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro IDADE;\nvarfim;\nleia IDADE;\nse (IDADE >= 18) entao\n\tescreva \"maior\";\nsenao\n\tescreva \"menor\";\nfimse\nfim\n",
+	},
+	{
+		Code:  "S007",
+		Title: "operador aritmético aplicado a um tipo não numérico",
+		Description: "Os operadores +, -, * e / só operam sobre números. Literais podem ser lidos, escritos, " +
+			"atribuídos e comparados, mas não somados: mgol não junta textos.",
+		Causes: []string{
+			"+ foi usado para juntar dois literais, como em outras linguagens",
+		},
+		Example: "inicio\nvarinicio\n\tliteral NOME;\n\tliteral SOBRENOME;\n\tliteral COMPLETO;\nvarfim;\nleia NOME;\nleia SOBRENOME;\nCOMPLETO <- NOME + SOBRENOME;\nescreva COMPLETO;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tliteral NOME;\n\tliteral SOBRENOME;\nvarfim;\nleia NOME;\nleia SOBRENOME;\nescreva NOME;\nescreva SOBRENOME;\nfim\n",
+	},
+	{
+		Code:  "S008",
+		Title: "condição não é uma comparação",
+		Description: "A condição de se e de repita deve ser uma comparação, como A > 0. A gramática de mgol já " +
+			"exige isso, então o erro só aparece em árvores construídas de outra forma, como por código Go " +
+			"ou por uma variante da gramática.",
+	},
+	{
+		Code:  "S009",
+		Title: "comparação fora de uma condição",
+		Description: "Uma comparação só pode ser a condição de se ou de repita: mgol não tem um tipo lógico para " +
+			"guardar ou escrever o seu resultado. A gramática já exige isso, então o erro só aparece em árvores " +
+			"construídas de outra forma, como por código Go ou por uma variante da gramática.",
+	},
+	{
+		Code:  "S010",
+		Title: "divisão por zero",
+		Description: "O divisor de uma divisão é sempre zero, como em A / 0 ou A / (2 - 2), e o programa falharia " +
+			"ao chegar nela. As divisões por variáveis são verificadas apenas na execução.",
+		Causes: []string{
+			"um número foi digitado errado no divisor",
+			"o divisor é uma conta de números que resulta em zero",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro TOTAL;\n\tinteiro MEDIA;\nvarfim;\nleia TOTAL;\nMEDIA <- TOTAL / 0;\nescreva MEDIA;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro TOTAL;\n\tinteiro QUANTIDADE;\n\tinteiro MEDIA;\nvarfim;\nleia TOTAL;\nleia QUANTIDADE;\nse (QUANTIDADE <> 0) entao\n\tMEDIA <- TOTAL / QUANTIDADE;\n\tescreva MEDIA;\nfimse\nfim\n",
+	},
+	{
+		Code:        "S011",
+		Title:       "variável declarada mas nunca usada",
+		Description: "A variável é declarada, mas o programa nunca a lê nem lhe atribui um valor. É um aviso.",
+		Causes: []string{
+			"a variável sobrou de uma versão anterior do programa",
+			"o programa usa outra variável, com um nome parecido, no lugar dela",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia A;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\nescreva A;\nfim\n",
+	},
+	{
+		Code:  "S012",
+		Title: "variável recebe valores mas nunca é lida",
+		Description: "A variável recebe valores, por leia ou por atribuições, mas eles nunca são usados em uma " +
+			"expressão ou escritos. É um aviso: o cálculo feito para ela se perde.",
+		Causes: []string{
+			"o escreva do resultado foi esquecido",
+			"o resultado foi escrito a partir de outra variável",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\tinteiro DOBRO;\nvarfim;\nleia A;\nDOBRO <- A * 2;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\n\tinteiro DOBRO;\nvarfim;\nleia A;\nDOBRO <- A * 2;\nescreva DOBRO;\nfim\n",
+	},
+	{
+		Code:  "S013",
+		Title: "variável pode ser lida antes de receber um valor",
+		Description: "Em algum caminho do programa, a variável é usada antes de um leia ou de uma atribuição, e o " +
+			"seu valor é o que estiver na memória. É um aviso, pois nem sempre esse caminho acontece.",
+		Causes: []string{
+			"o leia da variável veio depois do seu uso",
+			"a variável só recebe um valor dentro de um se, e é usada depois dele",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia A;\nse (A > 0) entao\n\tB <- A;\nfimse\nescreva B;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia A;\nB <- 0;\nse (A > 0) entao\n\tB <- A;\nfimse\nescreva B;\nfim\n",
+	},
+	{
+		Code:  "S014",
+		Title: "declaração esconde outra variável com o mesmo nome",
+		Description: "Uma variável declarada em um bloco com as suas próprias declarações tem o nome de outra, de " +
+			"um bloco externo, que deixa de ser acessível dentro dele. mgol ainda não tem blocos assim, e a " +
+			"análise guarda o aviso para eles.",
+	},
+	{
+		Code:  "W001",
+		Title: "variável com letras minúsculas",
+		Description: "Por convenção, os nomes das variáveis de mgol são escritos em maiúsculas, como MEDIA, " +
+			"o que as separa das palavras reservadas. A regra é identificador-maiusculo.",
+		Causes: []string{
+			"o nome foi escrito como em outras linguagens, como media ou notaFinal",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro media;\nvarfim;\nleia media;\nescreva media;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro MEDIA;\nvarfim;\nleia MEDIA;\nescreva MEDIA;\nfim\n",
+	},
+	{
+		Code:  "W002",
+		Title: "se sem senao",
+		Description: "Um se sem senao não diz o que acontece quando a condição não vale. Às vezes nada deve " +
+			"acontecer, mas é comum esquecer esse caso. A regra é senao-ausente.",
+		Causes: []string{
+			"a mensagem do caso contrário foi esquecida",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro NOTA;\nvarfim;\nleia NOTA;\nse (NOTA >= 6) entao\n\tescreva \"aprovado\";\nfimse\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro NOTA;\nvarfim;\nleia NOTA;\nse (NOTA >= 6) entao\n\tescreva \"aprovado\";\nsenao\n\tescreva \"reprovado\";\nfimse\nfim\n",
+	},
+	{
+		Code:  "W003",
+		Title: "número mágico",
+		Description: "Um número além de 0 e 1 escrito no meio dos comandos não diz o que é. Guardado em uma " +
+			"variável com um nome, como MEDIAMINIMA, ele se explica e muda em um lugar só. A regra é numero-magico.",
+		Causes: []string{
+			"um limite, como a nota para aprovação, foi escrito direto na condição",
+		},
+		Example: "inicio\nvarinicio\n\treal NOTA;\nvarfim;\nleia NOTA;\nse (NOTA >= 6) entao\n\tescreva \"aprovado\";\nsenao\n\tescreva \"reprovado\";\nfimse\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\treal NOTA;\n\treal MEDIAMINIMA;\nvarfim;\nleia MEDIAMINIMA;\nleia NOTA;\nse (NOTA >= MEDIAMINIMA) entao\n\tescreva \"aprovado\";\nsenao\n\tescreva \"reprovado\";\nfimse\nfim\n",
+	},
+	{
+		Code:  "W004",
+		Title: "linha longa",
+		Description: "A linha tem mais caracteres que o máximo, 100 por padrão, e não cabe em uma tela ou em uma " +
+			"folha impressa. A regra é linha-longa, e o máximo muda com -max-line-length.",
+		Causes: []string{
+			"um literal comprido foi escrito em um único escreva",
+			"vários comandos foram escritos na mesma linha",
+		},
+		Example: "inicio\nvarinicio\nvarfim;\nescreva \"Este programa le as notas de uma turma e escreve a media de cada aluno e a media geral da turma\";\nfim\n",
+		Fixed:   "inicio\nvarinicio\nvarfim;\nescreva \"Este programa le as notas de uma turma e escreve\";\nescreva \"a media de cada aluno e a media geral da turma\";\nfim\n",
+	},
+	{
+		Code:  "W005",
+		Title: "variável nunca é lida",
+		Description: "A variável é declarada, mas o seu valor nunca é usado. É o mesmo caso de S011 e S012, " +
+			"visto pelo linter, cuja regra variavel-nao-usada pode ser desligada.",
+		Causes: []string{
+			"a variável sobrou de uma versão anterior do programa",
+			"o escreva do resultado foi esquecido",
+		},
+		Example: "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia A;\nleia B;\nescreva A;\nfim\n",
+		Fixed:   "inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\nescreva A;\nfim\n",
+	},
+}
@@ -0,0 +1,101 @@
+// Package explain describes at length each diagnostic code of the
+// compiler, the lexical L, semantic S and linter W ones, with their
+// common causes and a small program that has the problem and its fix:
+//
+//	explanation, err := explain.Lookup("S001")
+//	explanation.Write(os.Stdout)
+//
+// The messages of the diagnostics stay short, for the lists of errors,
+// and point to this longer text through their code
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrorUnknownCode = fmt.Errorf("código desconhecido")
+
+// Explanation is the long description of a diagnostic code
+type Explanation struct {
+	Code string
+	// Title is the message of the diagnostic, without its details
+	Title       string
+	Description string
+	Causes      []string
+	// Example is a program with the problem, and Fixed the same
+	// program without it. They are empty for the codes that the
+	// grammar of mgol keeps from happening on programs
+	Example string
+	Fixed   string
+}
+
+// Explanations returns the explanations of every code, sorted by it
+func Explanations() []Explanation {
+	return append([]Explanation{}, explanations...)
+}
+
+// Lookup returns the explanation of code, in any case
+func Lookup(code string) (Explanation, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, explanation := range explanations {
+		if explanation.Code == code {
+			return explanation, nil
+		}
+	}
+	return Explanation{}, fmt.Errorf("%w: %s", ErrorUnknownCode, code)
+}
+
+// lineWidth is how many characters the lines of the text have at most
+const lineWidth = 80
+
+// Write writes e as text to w, the paragraphs wrapped and the programs indented
+func (e Explanation) Write(w io.Writer) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s: %s\n\n%s", e.Code, e.Title, wrap(e.Description, "", ""))
+	if len(e.Causes) > 0 {
+		text.WriteString("\nCausas comuns:\n")
+		for _, cause := range e.Causes {
+			text.WriteString(wrap(cause, "  - ", "    "))
+		}
+	}
+	if e.Example != "" {
+		fmt.Fprintf(&text, "\nExemplo com o problema:\n\n%s\nCorrigido:\n\n%s", indent(e.Example), indent(e.Fixed))
+	}
+	_, err := io.WriteString(w, text.String())
+	return err
+}
+
+// wrap breaks paragraph into lines of up to lineWidth characters,
+// the first one starting with first and the others with rest
+func wrap(paragraph, first, rest string) string {
+	var lines strings.Builder
+	line := first
+	empty := true
+	for _, word := range strings.Fields(paragraph) {
+		if !empty && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > lineWidth {
+			lines.WriteString(line + "\n")
+			line, empty = rest, true
+		}
+		if !empty {
+			line += " "
+		}
+		line += word
+		empty = false
+	}
+	lines.WriteString(line + "\n")
+	return lines.String()
+}
+
+// indent returns program with each line indented by four spaces
+func indent(program string) string {
+	lines := strings.Split(strings.TrimSuffix(program, "\n"), "\n")
+	for index, line := range lines {
+		if line != "" {
+			lines[index] = "    " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
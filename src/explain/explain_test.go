@@ -0,0 +1,112 @@
+package explain
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/lint"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// diagnostics compiles source as the mgol command does, linting it
+// when it has no errors
+func diagnostics(source string) []errorhandling.Diagnostic {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	scanner := lexer.NewStringScanner(source, symbolTable)
+	buffer := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(buffer)
+	p := parser.NewRecursiveDescentParser(scanner, parser.DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	result := p.Parse()
+	for _, syntaxError := range result.Errors {
+		buffer.Add(syntaxError.Diagnostic())
+	}
+	if result.Program == nil {
+		return buffer.Diagnostics()
+	}
+	info := sem.NewChecker(symbolTable).Check(result.Program)
+	info.Report(buffer)
+	if result.Succeeded() && len(info.Errors) == 0 {
+		for _, warning := range lint.Lint(result.Program, info, source, lint.DefaultConfig()) {
+			buffer.Add(warning)
+		}
+	}
+	return buffer.Diagnostics()
+}
+
+func TestEveryCodeIsExplained(t *testing.T) {
+	titles := map[string]string{
+		errorhandling.CodeInvalidLiteral: "",
+		errorhandling.CodeInvalidNumber:  "",
+		errorhandling.CodeInvalidComment: "",
+		errorhandling.CodeInvalidWord:    "",
+		errorhandling.CodeKeywordCase:    "",
+	}
+	for _, err := range []error{
+		sem.ErrorUndeclared, sem.ErrorRedeclared, sem.ErrorAssignType, sem.ErrorNarrowing, sem.ErrorPromotion,
+		sem.ErrorOperandTypes, sem.ErrorOperatorType, sem.ErrorConditionType, sem.ErrorComparison,
+		sem.ErrorDivisionByZero, sem.ErrorUnused, sem.ErrorNeverRead, sem.ErrorUninitialized, sem.ErrorShadowed,
+	} {
+		titles[sem.Code(err)] = err.Error()
+	}
+	for _, rule := range lint.Rules() {
+		titles[rule.Code] = ""
+	}
+
+	codes := []string{}
+	for _, explanation := range Explanations() {
+		codes = append(codes, explanation.Code)
+		title, found := titles[explanation.Code]
+		require.True(t, found, "%s explicado, mas não existe", explanation.Code)
+		if title != "" {
+			require.Equal(t, title, explanation.Title)
+		}
+	}
+	require.True(t, sort.StringsAreSorted(codes))
+	require.Len(t, codes, len(titles))
+}
+
+func TestExamples(t *testing.T) {
+	for _, explanation := range Explanations() {
+		if explanation.Example == "" {
+			continue
+		}
+		explanation := explanation
+		t.Run(explanation.Code, func(t *testing.T) {
+			found := false
+			for _, diagnostic := range diagnostics(explanation.Example) {
+				found = found || diagnostic.Code == explanation.Code
+			}
+			require.True(t, found, "o exemplo não tem %s", explanation.Code)
+
+			for _, diagnostic := range diagnostics(explanation.Fixed) {
+				require.False(t, diagnostic.Code == explanation.Code || diagnostic.Severity == errorhandling.Error, "%s %s", diagnostic.Code, diagnostic)
+			}
+			formatted, _, err := parser.Format(explanation.Fixed)
+			require.NoError(t, err)
+			require.Equal(t, formatted, explanation.Fixed)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	explanation, err := Lookup(" s010")
+	require.NoError(t, err)
+	require.Equal(t, "S010", explanation.Code)
+
+	var text strings.Builder
+	require.NoError(t, explanation.Write(&text))
+	require.True(t, strings.HasPrefix(text.String(), "S010: divisão por zero\n\n"))
+	require.Contains(t, text.String(), "\nCausas comuns:\n  - um número foi digitado errado no divisor\n")
+	require.Contains(t, text.String(), "\nCorrigido:\n\n    inicio\n    varinicio\n    \tinteiro TOTAL;\n")
+
+	_, err = Lookup("E0001")
+	require.ErrorIs(t, err, ErrorUnknownCode)
+}
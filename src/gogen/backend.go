@@ -0,0 +1,34 @@
+package gogen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(goBackend{})
+}
+
+// goBackend is the Go target on the registry of backends
+type goBackend struct {
+	options backend.Options
+}
+
+func (goBackend) Name() string {
+	return "go"
+}
+
+func (goBackend) FileExtension() string {
+	return ".go"
+}
+
+func (b goBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return GenerateWithOptions(w, program, info, b.options)
+}
+
+func (b goBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
@@ -0,0 +1,396 @@
+// Package gogen is a backend that writes a program as Go source,
+// which can be run with go run. It works on the syntax tree, with
+// the types found by the sem package, instead of on the reductions
+// like the C backend of the parser
+package gogen
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrorBadNode = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+
+// goTypes holds the Go type of the variables of each type
+var goTypes = map[lexer.DataType]string{
+	lexer.INTEGER: "int32",
+	lexer.REAL:    "float64",
+	lexer.LITERAL: "string",
+}
+
+// readers holds the function of the preamble
+// that reads a value of each type
+var readers = map[lexer.DataType]string{
+	lexer.INTEGER: "leiaInteiro",
+	lexer.REAL:    "leiaReal",
+	lexer.LITERAL: "leiaLiteral",
+}
+
+// helpers holds the source of the functions that leia
+// is translated to, which are only written when used
+var helpers = map[string]string{
+	"leiaInteiro": `func leiaInteiro() int32 {
+	var valor int32
+	fmt.Fscan(entrada, &valor)
+	return valor
+}`,
+	"leiaReal": `func leiaReal() float64 {
+	var valor float64
+	fmt.Fscan(entrada, &valor)
+	return valor
+}`,
+	// A literal is read up to the end of the line, like on the C backend
+	"leiaLiteral": `func leiaLiteral() string {
+	for {
+		linha, err := entrada.ReadString('\n')
+		if linha = strings.TrimSpace(linha); linha != "" || err != nil {
+			return linha
+		}
+	}
+}`,
+}
+
+// goOperators holds the relational operators written differently in Go
+var goOperators = map[string]string{
+	"=":  "==",
+	"<>": "!=",
+}
+
+// precedences holds how tightly each operator binds its operands
+var precedences = map[string]int{
+	"*": 2,
+	"/": 2,
+	"+": 1,
+	"-": 1,
+}
+
+// maxHeight is how deep the operations of an expression are nested
+// before a part of it is put on a temporary, since go/format gives
+// up on expressions nested about a hundred thousand levels deep
+const maxHeight = 1000
+
+// reserved holds the names a variable can not have on the generated
+// program: the keywords, the predeclared identifiers the program
+// uses and the names it declares
+var reserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"int32": true, "float64": true, "string": true, "main": true, "entrada": true,
+	"bufio": true, "fmt": true, "os": true, "strings": true,
+	"leiaInteiro": true, "leiaReal": true, "leiaLiteral": true,
+}
+
+// name returns the Go name of the variable named name
+func name(variable string) string {
+	if reserved[variable] {
+		return variable + "_"
+	}
+	return variable
+}
+
+type generator struct {
+	info    *sem.Info
+	options backend.Options
+	body    strings.Builder
+	depth   int
+	used    map[string]bool
+	imports map[string]bool
+	// constants holds the value of the operations on inteiro
+	// constants, which are folded so they wrap at 32 bits
+	constants map[ast.Expr]int32
+	// temporaries holds the name of the temporary each
+	// part of a deeply nested expression was put on
+	temporaries map[ast.Expr]string
+	err         error
+}
+
+func (g *generator) line(format string, args ...interface{}) {
+	g.body.WriteString(strings.Repeat("\t", g.depth))
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteByte('\n')
+}
+
+// Generate writes program as a Go program. info holds what the sem
+// package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	return GenerateWithOptions(w, program, info, backend.Options{})
+}
+
+// GenerateWithOptions writes program like Generate, following options
+func GenerateWithOptions(w io.Writer, program *ast.Program, info *sem.Info, options backend.Options) error {
+	g := &generator{
+		info:        info,
+		options:     options,
+		used:        make(map[string]bool),
+		imports:     map[string]bool{"fmt": true},
+		constants:   make(map[ast.Expr]int32),
+		temporaries: make(map[ast.Expr]string),
+	}
+	g.depth = 1
+	g.stmts(program.Body)
+	if g.err != nil {
+		return g.err
+	}
+
+	var source strings.Builder
+	source.WriteString("package main\n\nimport (\n")
+	if len(g.used) > 0 {
+		g.imports["bufio"], g.imports["os"] = true, true
+	}
+	for _, path := range sortedKeys(g.imports) {
+		fmt.Fprintf(&source, "\t%q\n", path)
+	}
+	source.WriteString(")\n\n")
+
+	declarations := append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...)
+	if len(declarations) > 0 {
+		source.WriteString("var (\n")
+		for _, declaration := range declarations {
+			fmt.Fprintf(&source, "\t%s %s\n", name(declaration.Name.Name), goTypes[declaration.Type])
+		}
+		source.WriteString(")\n\n")
+	}
+	if len(g.used) > 0 {
+		source.WriteString("var entrada = bufio.NewReader(os.Stdin)\n\n")
+		for _, helper := range sortedKeys(g.used) {
+			source.WriteString(helpers[helper] + "\n\n")
+		}
+	}
+	source.WriteString("func main() {\n" + g.body.String() + "}\n")
+
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (g *generator) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		g.stmt(stmt)
+	}
+}
+
+func (g *generator) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		dataType := g.info.TypeOf(node.Target)
+		reader := readers[dataType]
+		g.used[reader] = true
+		if dataType == lexer.LITERAL {
+			g.imports["strings"] = true
+		}
+		g.line("%s = %s()", name(node.Target.Name), reader)
+	case *ast.Write:
+		g.prepare(node.Value)
+		if g.info.TypeOf(node.Value) == lexer.REAL {
+			// The C backend writes reals with %lf
+			if g.options.DecimalComma {
+				g.imports["strings"] = true
+				g.line("fmt.Print(strings.Replace(fmt.Sprintf(\"%%f\", %s), \".\", \",\", 1))", g.expr(node.Value, 0))
+				return
+			}
+			g.line("fmt.Printf(\"%%f\", %s)", g.expr(node.Value, 0))
+			return
+		}
+		g.line("fmt.Print(%s)", g.expr(node.Value, 0))
+	case *ast.Assign:
+		g.prepare(node.Value)
+		value := g.expr(node.Value, 0)
+		if g.info.TypeOf(node.Target) == lexer.INTEGER && g.info.TypeOf(node.Value) == lexer.REAL {
+			value = fmt.Sprintf("int32(%s)", value)
+		}
+		g.line("%s = %s", name(node.Target.Name), value)
+	case *ast.If:
+		g.prepare(node.Condition)
+		g.line("if %s {", g.expr(node.Condition, 0))
+		g.block(node.Body)
+		if node.Else != nil {
+			g.line("} else {")
+			g.block(node.Else)
+		}
+		g.line("}")
+	case *ast.While:
+		if g.height(node.Condition) < maxHeight {
+			g.prepare(node.Condition)
+			g.line("for %s {", g.expr(node.Condition, 0))
+			g.block(node.Body)
+			g.line("}")
+			return
+		}
+		// The temporaries of the condition are computed on each pass
+		g.line("for {")
+		g.depth++
+		g.prepare(node.Condition)
+		g.line("if !(%s) {", g.expr(node.Condition, 0))
+		g.line("\tbreak")
+		g.line("}")
+		g.stmts(node.Body)
+		g.depth--
+		g.line("}")
+	default:
+		g.err = ErrorBadNode
+	}
+}
+
+func (g *generator) block(stmts []ast.Stmt) {
+	g.depth++
+	g.stmts(stmts)
+	g.depth--
+}
+
+// prepare folds the operations on inteiro constants of expr and
+// writes the parts of it nested deeper than maxHeight on temporaries,
+// returning how deep the operations left on expr are nested
+func (g *generator) prepare(expr ast.Expr) int {
+	node, isBinary := expr.(*ast.BinaryExpr)
+	if !isBinary {
+		return 0
+	}
+	height := 1 + max(g.prepare(node.Left), g.prepare(node.Right))
+	if value, folded := g.fold(node); folded {
+		g.constants[node] = value
+		return 0
+	}
+	if height < maxHeight {
+		return height
+	}
+	temporary := fmt.Sprintf("_parcial%d", len(g.temporaries)+1)
+	source, _ := g.source(node)
+	g.line("%s := %s", temporary, source)
+	g.temporaries[node] = temporary
+	return 0
+}
+
+// height returns how deep the operations of expr are nested
+func (g *generator) height(expr ast.Expr) int {
+	node, isBinary := expr.(*ast.BinaryExpr)
+	if !isBinary {
+		return 0
+	}
+	return 1 + max(g.height(node.Left), g.height(node.Right))
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// fold returns the value of node if it is an arithmetic operation
+// on inteiro constants, wrapped at 32 bits like the int of the C
+// backend. A division by 0 is left to fail when the program runs
+func (g *generator) fold(node *ast.BinaryExpr) (int32, bool) {
+	if _, arithmetic := precedences[node.Operator]; !arithmetic || g.info.TypeOf(node) != lexer.INTEGER {
+		return 0, false
+	}
+	left, leftConstant := g.integerConstant(node.Left)
+	right, rightConstant := g.integerConstant(node.Right)
+	if !leftConstant || !rightConstant || node.Operator == "/" && right == 0 {
+		return 0, false
+	}
+	switch node.Operator {
+	case "+":
+		return left + right, true
+	case "-":
+		return left - right, true
+	case "*":
+		return left * right, true
+	}
+	return left / right, true
+}
+
+// integerConstant returns the value of expr if it is an inteiro
+// constant, or an operation on them already folded
+func (g *generator) integerConstant(expr ast.Expr) (int32, bool) {
+	if value, folded := g.constants[expr]; folded {
+		return value, true
+	}
+	node, isLiteral := expr.(*ast.Literal)
+	if !isLiteral || node.Type != lexer.INTEGER {
+		return 0, false
+	}
+	value, err := lexer.ParseNumber(node.Value, node.Type)
+	return int32(int64(value)), err == nil
+}
+
+// expr returns expr written in Go as an operand of an operation whose
+// precedence is parent, converted to float64 if it was promoted. The
+// expression must have been prepared
+func (g *generator) expr(expr ast.Expr, parent int) string {
+	source, own := g.source(expr)
+	if g.err != nil {
+		return ""
+	}
+
+	if g.info.Conversions[expr] == lexer.REAL {
+		return fmt.Sprintf("float64(%s)", source)
+	}
+	if own < parent {
+		return "(" + source + ")"
+	}
+	return source
+}
+
+// source returns expr written in Go, along with the precedence
+// of its operator, which is the highest one for an operand
+func (g *generator) source(expr ast.Expr) (string, int) {
+	if temporary, found := g.temporaries[expr]; found {
+		return temporary, 3
+	}
+	if value, folded := g.constants[expr]; folded {
+		return strconv.Itoa(int(value)), 3
+	}
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		own := precedences[node.Operator]
+		operator := node.Operator
+		if goOperator, found := goOperators[operator]; found {
+			operator = goOperator
+		}
+		return fmt.Sprintf("%s %s %s", g.expr(node.Left, own), operator, g.expr(node.Right, own+1)), own
+	case *ast.Literal:
+		return literal(node), 3
+	case *ast.Ident:
+		return name(node.Name), 3
+	}
+	g.err = ErrorBadNode
+	return "", 3
+}
+
+// literal returns the Go constant of a literal or number
+func literal(node *ast.Literal) string {
+	switch node.Type {
+	case lexer.LITERAL:
+		return strconv.Quote(lexer.LiteralText(node.Value))
+	case lexer.INTEGER:
+		// An inteiro may be written with an exponent, like 25E-1
+		value, err := lexer.ParseNumber(node.Value, node.Type)
+		if err == nil {
+			return strconv.Itoa(int(int32(int64(value))))
+		}
+	}
+	return node.Value
+}
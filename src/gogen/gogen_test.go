@@ -0,0 +1,183 @@
+package gogen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// literal NOME; inteiro A; real B; inteiro var;
+	// leia NOME;
+	// leia A;
+	// repita (A <> 0)
+	//	se (NOME = "fim") entao escreva "ok\n"; senao B <- (A + 1) * 2.5; fimse
+	//	A <- A - 25E-1;
+	//	var <- B;
+	// fimrepita
+	// escreva B;
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			asttest.Declaration(lexer.LITERAL, "NOME"),
+			asttest.Declaration(lexer.INTEGER, "A"),
+			asttest.Declaration(lexer.REAL, "B"),
+			asttest.Declaration(lexer.INTEGER, "var"),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("NOME")},
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.While{Condition: asttest.Binary("<>", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)), Body: []ast.Stmt{
+				&ast.If{
+					Condition: asttest.Binary("=", asttest.Ident("NOME"), asttest.Literal(`"fim"`, lexer.LITERAL)),
+					Body:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"ok\n"`, lexer.LITERAL)}},
+					Else: []ast.Stmt{&ast.Assign{
+						Target: asttest.Ident("B"),
+						Value:  asttest.Binary("*", asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER)), asttest.Literal("2.5", lexer.REAL)),
+					}},
+				},
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("-", asttest.Ident("A"), asttest.Literal("25E-1", lexer.INTEGER))},
+				&ast.Assign{Target: asttest.Ident("var"), Value: asttest.Ident("B")},
+			}},
+			&ast.Write{Value: asttest.Ident("B")},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	require.Equal(t, `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	NOME string
+	A    int32
+	B    float64
+	var_ int32
+)
+
+var entrada = bufio.NewReader(os.Stdin)
+
+func leiaInteiro() int32 {
+	var valor int32
+	fmt.Fscan(entrada, &valor)
+	return valor
+}
+
+func leiaLiteral() string {
+	for {
+		linha, err := entrada.ReadString('\n')
+		if linha = strings.TrimSpace(linha); linha != "" || err != nil {
+			return linha
+		}
+	}
+}
+
+func main() {
+	NOME = leiaLiteral()
+	A = leiaInteiro()
+	for A != 0 {
+		if NOME == "fim" {
+			fmt.Print("ok\\n")
+		} else {
+			B = float64(A+1) * 2.5
+		}
+		A = A - 2
+		var_ = int32(B)
+	}
+	fmt.Printf("%f", B)
+}
+`, out.String())
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.Assign{Target: asttest.Ident("A"), Value: &ast.BadExpr{}}},
+	}
+	info := sem.NewChecker(nil).Check(program)
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, info), ErrorBadNode)
+}
+
+func TestGenerateIntegerConstants(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    ast.Expr
+		expected string
+	}{
+		{
+			name:     "Wraps at 32 bits",
+			value:    asttest.Binary("+", asttest.Literal("2147483647", lexer.INTEGER), asttest.Literal("1", lexer.INTEGER)),
+			expected: "A = -2147483648",
+		},
+		{
+			name: "Wraps on each operation",
+			value: asttest.Binary("-",
+				asttest.Binary("*", asttest.Literal("429981696", lexer.INTEGER), asttest.Literal("429981696", lexer.INTEGER)),
+				asttest.Literal("1", lexer.INTEGER)),
+			expected: "A = -1",
+		},
+		{
+			name:     "Only the constant part",
+			value:    asttest.Binary("-", asttest.Ident("A"), asttest.Binary("/", asttest.Literal("7", lexer.INTEGER), asttest.Literal("2", lexer.INTEGER))),
+			expected: "A = A - 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program := &ast.Program{
+				Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+				Body:         []ast.Stmt{&ast.Assign{Target: asttest.Ident("A"), Value: tt.value}},
+			}
+			info := sem.NewChecker(nil).Check(program)
+			require.Empty(t, info.Errors)
+
+			var out bytes.Buffer
+			require.NoError(t, Generate(&out, program, info))
+			require.Contains(t, out.String(), "\t"+tt.expected+"\n")
+		})
+	}
+}
+
+func TestGenerateLongExpression(t *testing.T) {
+	// repita (A + A + ... + A < 1) A <- A + A + ... + A; fimrepita
+	sum := func() ast.Expr {
+		sum := ast.Expr(asttest.Ident("A"))
+		for i := 0; i < 2500; i++ {
+			sum = asttest.Binary("+", sum, asttest.Ident("A"))
+		}
+		return sum
+	}
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body: []ast.Stmt{&ast.While{
+			Condition: asttest.Binary("<", sum(), asttest.Literal("1", lexer.INTEGER)),
+			Body:      []ast.Stmt{&ast.Assign{Target: asttest.Ident("A"), Value: sum()}},
+		}},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	_, err := parser.ParseFile(token.NewFileSet(), "programa.go", out.Bytes(), 0)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "\tfor {\n\t\t_parcial1 := ")
+	require.Contains(t, out.String(), "\t\tif !(_parcial2+A")
+	require.Contains(t, out.String(), "\t\t_parcial3 := ")
+}
@@ -0,0 +1,87 @@
+// Package grade runs programs for automated grading, where the same
+// program and input must give the same output on any machine. The
+// input and the output have their line breaks normalized to \n, as
+// the ones typed on Windows end in \r\n, the reals are always written
+// with a point, and the run is bounded like the limits package does.
+// The interpreter and the machine neither read the clock nor draw
+// random numbers, so nothing else has to be fixed for now; a feature
+// that does should have them fixed through Options:
+//
+//	output, err := grade.Run(program, info, "3\r\n", grade.Options{Timeout: time.Second})
+//	passed := grade.Equal(expected, output)
+package grade
+
+import (
+	"bytes"
+	"context"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/console"
+	"mgol-go/src/interp"
+	"mgol-go/src/ir"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"mgol-go/src/vm"
+	"strings"
+	"time"
+)
+
+// Options tells how a program is run
+type Options struct {
+	// VM runs the program compiled to bytecode, with the
+	// optimizations of ir.O2, instead of on the interpreter
+	VM      bool
+	Limits  limits.Limits
+	Timeout time.Duration
+}
+
+// lineBreaks turns the line breaks of Windows and of the old
+// Macs into the one of Unix
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Normalize returns text with its line breaks as \n
+func Normalize(text string) string {
+	return lineBreaks.Replace(text)
+}
+
+// Equal tells whether expected and output are the same once
+// their line breaks are normalized
+func Equal(expected, output string) bool {
+	return Normalize(expected) == Normalize(output)
+}
+
+// Run runs program, whose types are on info, reading input and
+// returning what it wrote, both normalized. What was written is
+// returned along with the error of a program that was stopped
+func Run(program *ast.Program, info *sem.Info, input string, options Options) (string, error) {
+	ctx := context.Background()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	var output bytes.Buffer
+	io := console.New(strings.NewReader(Normalize(input)), &output, backend.Options{})
+
+	var err error
+	if options.VM {
+		err = runBytecode(ctx, program, info, io, options.Limits)
+	} else {
+		err = interp.RunContext(ctx, program, info, io, options.Limits)
+	}
+	return Normalize(output.String()), err
+}
+
+func runBytecode(ctx context.Context, program *ast.Program, info *sem.Info, io console.IO, bounds limits.Limits) error {
+	lowered, err := ir.Lower(program, info)
+	if err != nil {
+		return err
+	}
+	optimized, _ := ir.Optimize(lowered, ir.O2)
+	compiled, err := bytecode.Compile(optimized)
+	if err != nil {
+		return err
+	}
+	return vm.RunContext(ctx, compiled, io, bounds)
+}
@@ -0,0 +1,43 @@
+package grade
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "a\nb\nc\n\n", Normalize("a\r\nb\rc\n\r\n"))
+	require.True(t, Equal("1\n2\n", "1\r\n2\r\n"))
+	require.False(t, Equal("1\n2", "1\n2\n"))
+}
+
+func TestRun(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.LITERAL, "NOME"), asttest.Declaration(lexer.REAL, "B")},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("NOME")},
+			&ast.Read{Target: asttest.Ident("B")},
+			&ast.Write{Value: asttest.Ident("NOME")},
+			&ast.Write{Value: asttest.Literal(`" "`, lexer.LITERAL)},
+			&ast.Write{Value: asttest.Ident("B")},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	for _, options := range []Options{{}, {VM: true}} {
+		output, err := Run(program, info, "Ana Maria\r\n2.5\r\n", options)
+		require.NoError(t, err)
+		require.Equal(t, "Ana Maria 2.500000", output)
+	}
+
+	output, err := Run(program, info, "Ana Maria\r\n2.5\r\n", Options{Limits: limits.Limits{Steps: 3}})
+	require.ErrorIs(t, err, limits.ErrorLimitExceeded)
+	require.Equal(t, "Ana Maria", output)
+}
@@ -0,0 +1,102 @@
+package grammar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Empty is the symbol used on BNF files for an empty alternative
+const Empty = "ε"
+
+var ErrorInvalidBNF = fmt.Errorf("gramática BNF inválida")
+
+// ReadBNF reads a grammar written one rule per line:
+//
+//	# comments start with #
+//	P' -> P
+//	A  -> ES A | CMD A
+//	   |  fim
+//	X  ::= a | ε
+//
+// Each alternative becomes a rule, numbered in the order they
+// appear, so the first rule must be the augmented start S' -> S
+func ReadBNF(r io.Reader) (*Grammar, error) {
+	rules := []Rule{}
+	left := ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if idx := strings.Index(text, "#"); idx >= 0 {
+			text = text[:idx]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		var alternatives string
+		if strings.HasPrefix(text, "|") {
+			if left == "" {
+				return nil, fmt.Errorf("%w na linha %d: alternativa sem regra", ErrorInvalidBNF, line)
+			}
+			alternatives = text[1:]
+		} else {
+			separator := "->"
+			idx := strings.Index(text, separator)
+			if other := strings.Index(text, "::="); other >= 0 && (idx < 0 || other < idx) {
+				separator = "::="
+				idx = other
+			}
+			if idx < 0 {
+				return nil, fmt.Errorf("%w na linha %d: esperado -> ou ::=", ErrorInvalidBNF, line)
+			}
+
+			symbols := strings.Fields(text[:idx])
+			if len(symbols) != 1 {
+				return nil, fmt.Errorf("%w na linha %d: o lado esquerdo deve ter um único símbolo", ErrorInvalidBNF, line)
+			}
+			left = symbols[0]
+			alternatives = text[idx+len(separator):]
+		}
+
+		for _, alternative := range strings.Split(alternatives, "|") {
+			right := []string{}
+			for _, symbol := range strings.Fields(alternative) {
+				if symbol != Empty {
+					right = append(right, symbol)
+				}
+			}
+			rules = append(rules, Rule{Number: len(rules), Left: left, Right: right})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewGrammar(rules)
+}
+
+// LoadBNFFile reads a grammar from the BNF file on path
+func LoadBNFFile(path string) (*Grammar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadBNF(file)
+}
+
+// LoadFile reads a grammar from a json file, like grammar.json,
+// or from a BNF file when path has any other extension
+func LoadFile(path string) (*Grammar, error) {
+	if filepath.Ext(path) == ".json" {
+		return LoadJSONFile(path)
+	}
+	return LoadBNFFile(path)
+}
@@ -0,0 +1,222 @@
+package grammar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidResolution = fmt.Errorf("resolução de conflito inválida")
+
+// Conflict is a cell of the action table that
+// could hold more than one action
+type Conflict struct {
+	State    int
+	Terminal string
+	// Actions holds every action proposed for the cell,
+	// the first one being the action kept on the table
+	Actions []string
+	// Items holds the items of the state that propose
+	// the actions, like E -> E . + E for a shift on +
+	Items []string
+	// Example is a shortest sequence of terminals that
+	// takes the parser to the state, followed by Terminal
+	Example []string
+}
+
+// Kind tells whether the conflict is a shift/reduce
+// or a reduce/reduce one
+func (c Conflict) Kind() string {
+	for _, action := range c.Actions {
+		if strings.HasPrefix(action, "s") {
+			return "shift/reduce"
+		}
+	}
+	return "reduce/reduce"
+}
+
+func (c Conflict) String() string {
+	message := fmt.Sprintf("conflito %s no estado %d com %s: %v", c.Kind(), c.State, c.Terminal, c.Actions)
+	for _, item := range c.Items {
+		message += "\n\t" + item
+	}
+	if len(c.Example) > 0 {
+		message += "\n\texemplo: " + strings.Join(c.Example, " ")
+	}
+	return message
+}
+
+// Resolution chooses the action of a conflicting cell
+type Resolution struct {
+	State    int
+	Terminal string
+	Action   string
+}
+
+// ParseResolution reads a resolution written as state:terminal=action,
+// like 4:+=s3, the way gentable receives it
+func ParseResolution(text string) (Resolution, error) {
+	cell := strings.SplitN(text, "=", 2)
+	position := strings.SplitN(cell[0], ":", 2)
+	if len(cell) != 2 || len(position) != 2 {
+		return Resolution{}, fmt.Errorf("%w: %s, esperava estado:terminal=ação", ErrorInvalidResolution, text)
+	}
+	state, err := strconv.Atoi(position[0])
+	if err != nil {
+		return Resolution{}, fmt.Errorf("%w: %s, o estado deve ser um número", ErrorInvalidResolution, text)
+	}
+	return Resolution{State: state, Terminal: position[1], Action: cell[1]}, nil
+}
+
+// Resolve puts the chosen action on each cell with a resolution and
+// returns the conflicts left unresolved. A resolution must name a
+// conflicting cell and one of the actions proposed for it
+func (t *Table) Resolve(resolutions []Resolution) ([]Conflict, error) {
+	chosen := make(map[int]string)
+	for _, resolution := range resolutions {
+		idx := t.conflictIndex(resolution.State, resolution.Terminal)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: não há conflito no estado %d com %s", ErrorInvalidResolution, resolution.State, resolution.Terminal)
+		}
+		if !containsAction(t.Conflicts[idx].Actions, resolution.Action) {
+			return nil, fmt.Errorf("%w: %s não é uma das ações do conflito no estado %d com %s", ErrorInvalidResolution, resolution.Action, resolution.State, resolution.Terminal)
+		}
+		chosen[idx] = resolution.Action
+	}
+
+	unresolved := []Conflict{}
+	for idx, conflict := range t.Conflicts {
+		action, found := chosen[idx]
+		if !found {
+			unresolved = append(unresolved, conflict)
+			continue
+		}
+		t.Action[conflict.State][conflict.Terminal] = action
+	}
+	return unresolved, nil
+}
+
+func (t *Table) conflictIndex(state int, terminal string) int {
+	for idx, conflict := range t.Conflicts {
+		if conflict.State == state && conflict.Terminal == terminal {
+			return idx
+		}
+	}
+	return -1
+}
+
+func containsAction(actions []string, action string) bool {
+	for _, proposed := range actions {
+		if proposed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// explainConflicts fills the items and the example of each conflict
+func (g *Grammar) explainConflicts(table *Table, sets []ItemSet) {
+	if len(table.Conflicts) == 0 {
+		return
+	}
+	paths := g.statePaths(table, sets)
+	derivations := g.shortestDerivations()
+
+	for idx := range table.Conflicts {
+		conflict := &table.Conflicts[idx]
+		set := sets[conflict.State]
+		for _, action := range conflict.Actions {
+			for _, item := range set.Items {
+				if g.itemProposes(item, conflict.Terminal, action) {
+					conflict.Items = append(conflict.Items, g.itemString(item))
+				}
+			}
+		}
+
+		conflict.Example = []string{}
+		for _, symbol := range paths[conflict.State] {
+			if derivation, found := derivations[symbol]; found {
+				conflict.Example = append(conflict.Example, derivation...)
+			} else {
+				conflict.Example = append(conflict.Example, symbol)
+			}
+		}
+		conflict.Example = append(conflict.Example, conflict.Terminal)
+	}
+}
+
+// itemProposes tells whether item leads to action on terminal:
+// a shift when the terminal follows its dot, a reduce or
+// the accept when the item is complete
+func (g *Grammar) itemProposes(item Item, terminal, action string) bool {
+	symbol, found := g.nextSymbol(item)
+	switch {
+	case strings.HasPrefix(action, "s"):
+		return found && symbol == terminal
+	case action == acceptAction:
+		return !found && item.Rule == 0
+	default:
+		return !found && "r"+strconv.Itoa(item.Rule) == action
+	}
+}
+
+// itemString writes item as a rule with a dot, like E -> E . + E
+func (g *Grammar) itemString(item Item) string {
+	rule := g.rules[item.Rule]
+	symbols := append(append(append([]string{}, rule.Right[:item.Dot]...), "."), rule.Right[item.Dot:]...)
+	return fmt.Sprintf("%s -> %s", rule.Left, strings.Join(symbols, " "))
+}
+
+// statePaths finds, for each state, a shortest sequence of symbols
+// that takes the automaton from state 0 to it
+func (g *Grammar) statePaths(table *Table, sets []ItemSet) [][]string {
+	symbols := append(append([]string{}, table.Terminals...), table.NonTerminals...)
+	paths := make([][]string, len(sets))
+	paths[0] = []string{}
+	queue := []int{0}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, symbol := range symbols {
+			target, found := sets[state].Transitions[symbol]
+			if !found || paths[target] != nil {
+				continue
+			}
+			paths[target] = append(append([]string{}, paths[state]...), symbol)
+			queue = append(queue, target)
+		}
+	}
+	return paths
+}
+
+// shortestDerivations finds, for each non terminal, a shortest
+// sequence of terminals derived from it. Non terminals that
+// derive no sequence of terminals are left out
+func (g *Grammar) shortestDerivations() map[string][]string {
+	derivations := make(map[string][]string)
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.rules {
+			derivation := []string{}
+			derivable := true
+			for _, symbol := range rule.Right {
+				if !g.isNonTerminal[symbol] {
+					derivation = append(derivation, symbol)
+					continue
+				}
+				shortest, found := derivations[symbol]
+				if !found {
+					derivable = false
+					break
+				}
+				derivation = append(derivation, shortest...)
+			}
+			current, found := derivations[rule.Left]
+			if derivable && (!found || len(derivation) < len(current)) {
+				derivations[rule.Left] = derivation
+				changed = true
+			}
+		}
+	}
+	return derivations
+}
@@ -0,0 +1,178 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SymbolSet is a set of terminals
+type SymbolSet map[string]bool
+
+// Sorted returns the symbols of the set in alphabetical order
+func (s SymbolSet) Sorted() []string {
+	symbols := make([]string, 0, len(s))
+	for symbol := range s {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// addAll adds the symbols of other to s and
+// returns whether s has changed
+func (s SymbolSet) addAll(other SymbolSet) bool {
+	changed := false
+	for symbol := range other {
+		if !s[symbol] {
+			s[symbol] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Nullable returns whether the sequence of symbols can derive the
+// empty string, which it does when every one of its symbols does.
+// A terminal is never nullable
+func (g *Grammar) Nullable(symbols ...string) bool {
+	g.computeSets()
+	return g.sequenceIsNullable(symbols)
+}
+
+// First returns the terminals that can begin a string derived
+// from the sequence of symbols. Whether the sequence can also
+// derive the empty string is told by Nullable
+func (g *Grammar) First(symbols ...string) SymbolSet {
+	g.computeSets()
+	return g.firstOfSequence(symbols)
+}
+
+// Follow returns the terminals that can appear right
+// after the non terminal on some sentential form
+func (g *Grammar) Follow(nonTerminal string) SymbolSet {
+	g.computeSets()
+	follow := SymbolSet{}
+	follow.addAll(g.follow[nonTerminal])
+	return follow
+}
+
+// NonTerminalSets holds the results of the analysis of a non terminal
+type NonTerminalSets struct {
+	NonTerminal string   `json:"non_terminal"`
+	Nullable    bool     `json:"nullable"`
+	First       []string `json:"first"`
+	Follow      []string `json:"follow"`
+}
+
+// Sets returns whether each non terminal is nullable and its FIRST
+// and FOLLOW sets, with the symbols in alphabetical order. The non
+// terminals keep the order of NonTerminals
+func (g *Grammar) Sets() []NonTerminalSets {
+	sets := make([]NonTerminalSets, 0, len(g.nonTerminals))
+	for _, nonTerminal := range g.nonTerminals {
+		sets = append(sets, NonTerminalSets{
+			NonTerminal: nonTerminal,
+			Nullable:    g.Nullable(nonTerminal),
+			First:       g.First(nonTerminal).Sorted(),
+			Follow:      g.Follow(nonTerminal).Sorted(),
+		})
+	}
+	return sets
+}
+
+// WriteSets writes the sets of every non terminal as a table,
+// a line for each one
+func (g *Grammar) WriteSets(w io.Writer) error {
+	width := 0
+	for _, nonTerminal := range g.nonTerminals {
+		if len(nonTerminal) > width {
+			width = len(nonTerminal)
+		}
+	}
+	for _, sets := range g.Sets() {
+		nullable := ""
+		if sets.Nullable {
+			nullable = " anulável"
+		}
+		_, err := fmt.Fprintf(w, "%-*s  FIRST = {%s}  FOLLOW = {%s}%s\n", width, sets.NonTerminal,
+			strings.Join(sets.First, ", "), strings.Join(sets.Follow, ", "), nullable)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Grammar) firstOfSequence(symbols []string) SymbolSet {
+	first := SymbolSet{}
+	for _, symbol := range symbols {
+		if !g.isNonTerminal[symbol] {
+			first[symbol] = true
+			return first
+		}
+		first.addAll(g.first[symbol])
+		if !g.nullable[symbol] {
+			return first
+		}
+	}
+	return first
+}
+
+func (g *Grammar) sequenceIsNullable(symbols []string) bool {
+	for _, symbol := range symbols {
+		if !g.nullable[symbol] {
+			return false
+		}
+	}
+	return true
+}
+
+// computeSets computes the nullable, FIRST and FOLLOW
+// sets of every non terminal using fixed point iteration
+func (g *Grammar) computeSets() {
+	if g.first != nil {
+		return
+	}
+
+	g.nullable = make(map[string]bool)
+	g.first = make(map[string]SymbolSet)
+	g.follow = make(map[string]SymbolSet)
+	for _, nonTerminal := range g.nonTerminals {
+		g.first[nonTerminal] = SymbolSet{}
+		g.follow[nonTerminal] = SymbolSet{}
+	}
+	g.follow[g.StartSymbol()][EndMarker] = true
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.rules {
+			if !g.nullable[rule.Left] && g.sequenceIsNullable(rule.Right) {
+				g.nullable[rule.Left] = true
+				changed = true
+			}
+			if g.first[rule.Left].addAll(g.firstOfSequence(rule.Right)) {
+				changed = true
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.rules {
+			for idx, symbol := range rule.Right {
+				if !g.isNonTerminal[symbol] {
+					continue
+				}
+				rest := rule.Right[idx+1:]
+				if g.follow[symbol].addAll(g.firstOfSequence(rest)) {
+					changed = true
+				}
+				if g.sequenceIsNullable(rest) && g.follow[symbol].addAll(g.follow[rule.Left]) {
+					changed = true
+				}
+			}
+		}
+	}
+}
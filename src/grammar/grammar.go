@@ -0,0 +1,135 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// EndMarker is the terminal that marks the end of the input
+const EndMarker = "$"
+
+var (
+	ErrorEmptyGrammar       = fmt.Errorf("a gramática não possui regras")
+	ErrorInvalidRuleNumber  = fmt.Errorf("regras devem ser numeradas a partir de 0, em ordem")
+	ErrorInvalidStartSymbol = fmt.Errorf("a regra 0 deve ter a forma S' -> S")
+)
+
+type Rule struct {
+	Number int      `json:"rule_number"`
+	Left   string   `json:"left"`
+	Right  []string `json:"right"`
+}
+
+// Grammar is an augmented context free grammar:
+// rule 0 must be S' -> S, where S is the start symbol
+type Grammar struct {
+	rules         []Rule
+	terminals     []string
+	nonTerminals  []string
+	isNonTerminal map[string]bool
+	rulesByLeft   map[string][]int
+
+	// computed on demand by computeSets
+	nullable map[string]bool
+	first    map[string]SymbolSet
+	follow   map[string]SymbolSet
+}
+
+// NewGrammar creates a grammar from its rules. Non terminals
+// are the symbols found on the left side of some rule and
+// every other symbol is a terminal. Both keep the order they
+// first appear on the rules
+func NewGrammar(rules []Rule) (*Grammar, error) {
+	if len(rules) == 0 {
+		return nil, ErrorEmptyGrammar
+	}
+	if len(rules[0].Right) != 1 {
+		return nil, ErrorInvalidStartSymbol
+	}
+
+	g := &Grammar{
+		rules:         rules,
+		isNonTerminal: make(map[string]bool),
+		rulesByLeft:   make(map[string][]int),
+	}
+
+	for idx, rule := range rules {
+		if rule.Number != idx {
+			return nil, fmt.Errorf("%w: regra %d encontrada na posição %d", ErrorInvalidRuleNumber, rule.Number, idx)
+		}
+		if !g.isNonTerminal[rule.Left] {
+			g.isNonTerminal[rule.Left] = true
+			g.nonTerminals = append(g.nonTerminals, rule.Left)
+		}
+		g.rulesByLeft[rule.Left] = append(g.rulesByLeft[rule.Left], idx)
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		for _, symbol := range rule.Right {
+			if !g.isNonTerminal[symbol] && !seen[symbol] {
+				seen[symbol] = true
+				g.terminals = append(g.terminals, symbol)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// ReadJSON reads a grammar on the format of grammar.json
+func ReadJSON(r io.Reader) (*Grammar, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []Rule{}
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, err
+	}
+	return NewGrammar(rules)
+}
+
+// LoadJSONFile reads a grammar from the json file on path
+func LoadJSONFile(path string) (*Grammar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadJSON(file)
+}
+
+func (g *Grammar) Rules() []Rule {
+	return g.rules
+}
+
+func (g *Grammar) Rule(number int) Rule {
+	return g.rules[number]
+}
+
+// Terminals returns the terminals of the
+// grammar, without the end marker
+func (g *Grammar) Terminals() []string {
+	return g.terminals
+}
+
+// NonTerminals returns the non terminals of
+// the grammar, the augmented start first
+func (g *Grammar) NonTerminals() []string {
+	return g.nonTerminals
+}
+
+func (g *Grammar) IsNonTerminal(symbol string) bool {
+	return g.isNonTerminal[symbol]
+}
+
+// StartSymbol returns the left side of rule 0
+func (g *Grammar) StartSymbol() string {
+	return g.rules[0].Left
+}
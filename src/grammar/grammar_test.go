@@ -0,0 +1,359 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	grammarPath     = "../parser/grammar.json"
+	bnfGrammarPath  = "../parser/grammar.bnf"
+	actionTablePath = "../parser/tables/action.tsv"
+	gotoTablePath   = "../parser/tables/goto.tsv"
+)
+
+// ambiguousRules is E -> E + E | id, which has a shift/reduce conflict
+var ambiguousRules = []Rule{
+	{Number: 0, Left: "E'", Right: []string{"E"}},
+	{Number: 1, Left: "E", Right: []string{"E", "+", "E"}},
+	{Number: 2, Left: "E", Right: []string{"id"}},
+}
+
+func readTable(t *testing.T, path string) [][]string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = '\t'
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestNewGrammar(t *testing.T) {
+	_, err := NewGrammar(nil)
+	require.ErrorIs(t, err, ErrorEmptyGrammar)
+
+	_, err = NewGrammar([]Rule{{Number: 0, Left: "S'", Right: []string{"a", "b"}}})
+	require.ErrorIs(t, err, ErrorInvalidStartSymbol)
+
+	_, err = NewGrammar([]Rule{{Number: 0, Left: "S'", Right: []string{"S"}}, {Number: 3, Left: "S", Right: []string{"a"}}})
+	require.ErrorIs(t, err, ErrorInvalidRuleNumber)
+
+	g, err := NewGrammar(ambiguousRules)
+	require.NoError(t, err)
+	require.Equal(t, []string{"E'", "E"}, g.NonTerminals())
+	require.Equal(t, []string{"+", "id"}, g.Terminals())
+	require.Equal(t, "E'", g.StartSymbol())
+}
+
+func TestFirstAndFollow(t *testing.T) {
+	g, err := LoadJSONFile(grammarPath)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		nonTerminal    string
+		expectedFirst  []string
+		expectedFollow []string
+	}{
+		{"P", []string{"inicio"}, []string{"$"}},
+		{"LV", []string{"inteiro", "literal", "real", "varfim"}, []string{"enquanto", "escreva", "fim", "id", "leia", "repita", "se"}},
+		{"OPRD", []string{"ab_p", "id", "num"}, []string{"fc_p", "opm", "opmul", "opr", "pt_v"}},
+		{"LD", []string{"ab_p", "id", "num"}, []string{"fc_p", "opm", "opr", "pt_v"}},
+		{"CP", []string{"enquanto", "escreva", "fimse", "id", "leia", "repita", "se", "senao"}, []string{"enquanto", "escreva", "fim", "fimenquanto", "fimrepita", "fimse", "id", "leia", "repita", "se", "senao"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.nonTerminal, func(t *testing.T) {
+			require.Equal(t, tc.expectedFirst, g.First(tc.nonTerminal).Sorted())
+			require.Equal(t, tc.expectedFollow, g.Follow(tc.nonTerminal).Sorted())
+			require.False(t, g.Nullable(tc.nonTerminal))
+		})
+	}
+
+	nullable, err := NewGrammar([]Rule{
+		{Number: 0, Left: "S'", Right: []string{"S"}},
+		{Number: 1, Left: "S", Right: []string{"A", "b"}},
+		{Number: 2, Left: "A", Right: []string{"a"}},
+		{Number: 3, Left: "A", Right: []string{}},
+	})
+	require.NoError(t, err)
+	require.True(t, nullable.Nullable("A"))
+	require.Equal(t, []string{"a", "b"}, nullable.First("S").Sorted())
+	require.Equal(t, []string{"b"}, nullable.Follow("A").Sorted())
+}
+
+func TestSLRTable(t *testing.T) {
+	g, err := LoadJSONFile(grammarPath)
+	require.NoError(t, err)
+
+	// escreva takes a number or a variable as ARG itself, instead
+	// of as an expression, so its formatting follows their type
+	table := g.SLRTable()
+	unresolved, err := table.Resolve([]Resolution{
+		{State: 34, Terminal: "pt_v", Action: "r14"},
+		{State: 35, Terminal: "pt_v", Action: "r15"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, unresolved)
+
+	// The generated tables must match the hand written ones,
+	// apart from the error codes that were filled by hand
+	action := readTable(t, actionTablePath)
+	for row, record := range table.ActionRecords() {
+		for column, cell := range record {
+			expected := action[row][column]
+			if row > 0 && strings.HasPrefix(expected, "e") {
+				expected = ""
+			}
+			require.Equal(t, expected, cell, "estado %s, %s", record[0], action[0][column])
+		}
+	}
+	require.Equal(t, readTable(t, gotoTablePath), table.GotoRecords())
+}
+
+func TestFillErrorCodes(t *testing.T) {
+	previous, err := NewGrammar([]Rule{
+		{Number: 0, Left: "E'", Right: []string{"E"}},
+		{Number: 1, Left: "E", Right: []string{"E", "+", "id"}},
+		{Number: 2, Left: "E", Right: []string{"id"}},
+	})
+	require.NoError(t, err)
+	previousTable := previous.SLRTable()
+	previousTable.FillErrorCodes(ErrorCodes{Default: "e1"})
+	previousAction := previousTable.ActionRecords()
+	require.Equal(t, []string{"0", "e1", "s2", "e1"}, previousAction[1])
+	previousAction[1][1] = "e3"
+	previousAction[1][3] = ""
+
+	g, err := NewGrammar([]Rule{
+		{Number: 0, Left: "E'", Right: []string{"E"}},
+		{Number: 1, Left: "E", Right: []string{"E", "+", "T"}},
+		{Number: 2, Left: "E", Right: []string{"T"}},
+		{Number: 3, Left: "T", Right: []string{"id"}},
+		{Number: 4, Left: "E", Right: []string{"E", "*", "T"}},
+	})
+	require.NoError(t, err)
+	table := g.SLRTable()
+	table.FillErrorCodes(ErrorCodes{
+		Previous:       previous,
+		PreviousAction: previousAction,
+		Aliases:        map[string]string{"*": "+"},
+		ByNonTerminal:  map[string]string{"T": "e5"},
+		Default:        "e2",
+	})
+
+	action := table.ActionRecords()
+	require.Equal(t, []string{"estado", "+", "id", "*", "$"}, action[0])
+	// The initial state is on both grammars and keeps its cells
+	require.Equal(t, []string{"0", "e3", "s3", "e3", ""}, action[1])
+	require.Equal(t, []string{"1", "s4", "e2", "s5", "acc"}, action[2])
+	require.Equal(t, []string{"3", "r3", "e5", "r3", "r3"}, action[4])
+}
+
+func TestSLRTableConflicts(t *testing.T) {
+	g, err := NewGrammar(ambiguousRules)
+	require.NoError(t, err)
+
+	table := g.SLRTable()
+	expected := Conflict{
+		State:    4,
+		Terminal: "+",
+		Actions:  []string{"s3", "r1"},
+		Items:    []string{"E -> E . + E", "E -> E + E ."},
+		Example:  []string{"id", "+", "id", "+"},
+	}
+	require.Equal(t, []Conflict{expected}, table.Conflicts)
+	require.Equal(t, "s3", table.Action[4]["+"])
+	require.Equal(t, "shift/reduce", expected.Kind())
+	require.Equal(t, "conflito shift/reduce no estado 4 com +: [s3 r1]\n"+
+		"\tE -> E . + E\n\tE -> E + E .\n\texemplo: id + id +", expected.String())
+
+	reduceReduce, err := NewGrammar([]Rule{
+		{Number: 0, Left: "S'", Right: []string{"S"}},
+		{Number: 1, Left: "S", Right: []string{"A"}},
+		{Number: 2, Left: "S", Right: []string{"B"}},
+		{Number: 3, Left: "A", Right: []string{"id"}},
+		{Number: 4, Left: "B", Right: []string{"id"}},
+	})
+	require.NoError(t, err)
+	conflicts := reduceReduce.SLRTable().Conflicts
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "reduce/reduce", conflicts[0].Kind())
+	require.Equal(t, []string{"A -> id .", "B -> id ."}, conflicts[0].Items)
+	require.Equal(t, []string{"id", "$"}, conflicts[0].Example)
+}
+
+func TestResolveConflicts(t *testing.T) {
+	testCases := []struct {
+		name               string
+		resolution         string
+		expectedAction     string
+		expectedUnresolved int
+		expectedError      error
+	}{
+		{
+			name:               "Keep the shift",
+			resolution:         "4:+=s3",
+			expectedAction:     "s3",
+			expectedUnresolved: 0,
+		},
+		{
+			name:               "Choose the reduce",
+			resolution:         "4:+=r1",
+			expectedAction:     "r1",
+			expectedUnresolved: 0,
+		},
+		{
+			name:          "Action not proposed",
+			resolution:    "4:+=r2",
+			expectedError: ErrorInvalidResolution,
+		},
+		{
+			name:          "Cell without conflict",
+			resolution:    "4:$=r1",
+			expectedError: ErrorInvalidResolution,
+		},
+		{
+			name:          "Malformed resolution",
+			resolution:    "4+r1",
+			expectedError: ErrorInvalidResolution,
+		},
+		{
+			name:          "State is not a number",
+			resolution:    "quatro:+=r1",
+			expectedError: ErrorInvalidResolution,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGrammar(ambiguousRules)
+			require.NoError(t, err)
+			table := g.SLRTable()
+
+			resolution, err := ParseResolution(tc.resolution)
+			var unresolved []Conflict
+			if err == nil {
+				unresolved, err = table.Resolve([]Resolution{resolution})
+			}
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, unresolved, tc.expectedUnresolved)
+			require.Equal(t, tc.expectedAction, table.Action[4]["+"])
+		})
+	}
+
+	g, err := NewGrammar(ambiguousRules)
+	require.NoError(t, err)
+	unresolved, err := g.SLRTable().Resolve(nil)
+	require.NoError(t, err)
+	require.Len(t, unresolved, 1)
+}
+
+func TestWriteGoSource(t *testing.T) {
+	g, err := NewGrammar(ambiguousRules)
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	require.NoError(t, g.SLRTable().WriteGoSource(output, "tables", "action", "goto_", "test"))
+
+	source := output.String()
+	require.True(t, strings.HasPrefix(source, "// Code generated by gentable from test. DO NOT EDIT.\n\npackage tables\n"))
+	require.Contains(t, source, `{"estado", "+", "id", "$"},`)
+	require.Contains(t, source, "var goto_ = [][]string{")
+}
+
+func TestReadBNF(t *testing.T) {
+	jsonGrammar, err := LoadJSONFile(grammarPath)
+	require.NoError(t, err)
+	bnfGrammar, err := LoadFile(bnfGrammarPath)
+	require.NoError(t, err)
+	require.Equal(t, jsonGrammar.Rules(), bnfGrammar.Rules())
+
+	testCases := []struct {
+		name          string
+		source        string
+		expectedRules []Rule
+		expectedError error
+	}{
+		{
+			name:   "Alternatives, continuations and empty rules",
+			source: "# comment\nS' -> S\nS ::= a S # trailing comment\n  | ε\n",
+			expectedRules: []Rule{
+				{Number: 0, Left: "S'", Right: []string{"S"}},
+				{Number: 1, Left: "S", Right: []string{"a", "S"}},
+				{Number: 2, Left: "S", Right: []string{}},
+			},
+		},
+		{
+			name:          "Missing separator",
+			source:        "S' -> S\nS a b",
+			expectedError: ErrorInvalidBNF,
+		},
+		{
+			name:          "Alternative without rule",
+			source:        "| a",
+			expectedError: ErrorInvalidBNF,
+		},
+		{
+			name:          "More than one symbol on the left",
+			source:        "S' -> S\nS a -> b",
+			expectedError: ErrorInvalidBNF,
+		},
+		{
+			name:          "Start is not augmented",
+			source:        "S -> a b",
+			expectedError: ErrorInvalidStartSymbol,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := ReadBNF(strings.NewReader(tc.source))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRules, g.Rules())
+		})
+	}
+}
+
+func TestSets(t *testing.T) {
+	g, err := NewGrammar([]Rule{
+		{Number: 0, Left: "S'", Right: []string{"S"}},
+		{Number: 1, Left: "S", Right: []string{"A", "b"}},
+		{Number: 2, Left: "A", Right: []string{"a"}},
+		{Number: 3, Left: "A", Right: []string{}},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []NonTerminalSets{
+		{NonTerminal: "S'", First: []string{"a", "b"}, Follow: []string{"$"}},
+		{NonTerminal: "S", First: []string{"a", "b"}, Follow: []string{"$"}},
+		{NonTerminal: "A", Nullable: true, First: []string{"a"}, Follow: []string{"b"}},
+	}, g.Sets())
+
+	// Sequences are nullable when all of their symbols are
+	require.True(t, g.Nullable())
+	require.True(t, g.Nullable("A", "A"))
+	require.False(t, g.Nullable("A", "b"))
+	require.False(t, g.Nullable("a"))
+
+	output := &bytes.Buffer{}
+	require.NoError(t, g.WriteSets(output))
+	require.Equal(t, "S'  FIRST = {a, b}  FOLLOW = {$}\n"+
+		"S   FIRST = {a, b}  FOLLOW = {$}\n"+
+		"A   FIRST = {a}  FOLLOW = {b} anulável\n", output.String())
+}
@@ -0,0 +1,124 @@
+package grammar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Item is an LR(0) item: a rule with a
+// dot marking how much of it was recognized
+type Item struct {
+	Rule int
+	Dot  int
+}
+
+// ItemSet is a state of the LR(0) automaton
+type ItemSet struct {
+	// Items holds the kernel items first, then
+	// the ones added by the closure
+	Items  []Item
+	Kernel int
+	// Transitions maps a symbol to the state reached by it
+	Transitions map[string]int
+}
+
+func (g *Grammar) nextSymbol(item Item) (string, bool) {
+	right := g.rules[item.Rule].Right
+	if item.Dot >= len(right) {
+		return "", false
+	}
+	return right[item.Dot], true
+}
+
+// closure adds to the kernel the items of every
+// non terminal that appears right after a dot
+func (g *Grammar) closure(kernel []Item) []Item {
+	items := append([]Item{}, kernel...)
+	added := make(map[Item]bool)
+	for _, item := range kernel {
+		added[item] = true
+	}
+
+	for idx := 0; idx < len(items); idx++ {
+		symbol, found := g.nextSymbol(items[idx])
+		if !found || !g.isNonTerminal[symbol] {
+			continue
+		}
+		for _, rule := range g.rulesByLeft[symbol] {
+			item := Item{Rule: rule}
+			if !added[item] {
+				added[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// itemsKey identifies an item set by its kernel
+func itemsKey(kernel []Item) string {
+	key := make([]byte, 0, len(kernel)*4)
+	for _, item := range kernel {
+		key = append(key, byte(item.Rule), byte(item.Rule>>8), byte(item.Dot), byte(item.Dot>>8))
+	}
+	return string(key)
+}
+
+// ItemSets builds the canonical collection of LR(0) item sets.
+// States are numbered in the order they are discovered,
+// state 0 being the closure of S' -> .S
+func (g *Grammar) ItemSets() []ItemSet {
+	kernels := [][]Item{{{Rule: 0, Dot: 0}}}
+	indexes := map[string]int{itemsKey(kernels[0]): 0}
+	sets := []ItemSet{}
+
+	for state := 0; state < len(kernels); state++ {
+		set := ItemSet{
+			Items:       g.closure(kernels[state]),
+			Kernel:      len(kernels[state]),
+			Transitions: make(map[string]int),
+		}
+
+		// Symbols are visited in the order they appear
+		// on the items to keep the numbering stable
+		symbols := []string{}
+		next := make(map[string][]Item)
+		for _, item := range set.Items {
+			symbol, found := g.nextSymbol(item)
+			if !found {
+				continue
+			}
+			if _, seen := next[symbol]; !seen {
+				symbols = append(symbols, symbol)
+			}
+			next[symbol] = append(next[symbol], Item{Rule: item.Rule, Dot: item.Dot + 1})
+		}
+
+		for _, symbol := range symbols {
+			key := itemsKey(next[symbol])
+			target, found := indexes[key]
+			if !found {
+				target = len(kernels)
+				indexes[key] = target
+				kernels = append(kernels, next[symbol])
+			}
+			set.Transitions[symbol] = target
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// kernelKey identifies an item set by the rules and dots of its
+// kernel items, so the same state is found on another version of
+// the grammar even if its rules were renumbered
+func (g *Grammar) kernelKey(set ItemSet) string {
+	parts := make([]string, set.Kernel)
+	for idx, item := range set.Items[:set.Kernel] {
+		rule := g.rules[item.Rule]
+		parts[idx] = fmt.Sprintf("%s -> %s . %d", rule.Left, strings.Join(rule.Right, " "), item.Dot)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,60 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io"
+)
+
+// WriteGoSource writes the action and goto tables as a Go source
+// file of the package packageName, declaring the variables
+// actionVar and gotoVar with the records of each table
+func (t *Table) WriteGoSource(w io.Writer, packageName, actionVar, gotoVar, origin string) error {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintf(buffer, "// Code generated by gentable from %s. DO NOT EDIT.\n\n", origin)
+	fmt.Fprintf(buffer, "package %s\n\n", packageName)
+	writeRecords(buffer, actionVar, t.ActionRecords())
+	buffer.WriteString("\n")
+	writeRecords(buffer, gotoVar, t.GotoRecords())
+
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+func writeRecords(buffer *bytes.Buffer, name string, records [][]string) {
+	fmt.Fprintf(buffer, "var %s = [][]string{\n", name)
+	for _, record := range records {
+		buffer.WriteString("{")
+		for idx, cell := range record {
+			if idx > 0 {
+				buffer.WriteString(", ")
+			}
+			fmt.Fprintf(buffer, "%q", cell)
+		}
+		buffer.WriteString("},\n")
+	}
+	buffer.WriteString("}\n")
+}
+
+// WriteTSV writes the action and goto tables separated by tabs,
+// the format of the tables read by the parser, with the CRLF line
+// endings of the tables on the repository
+func (t *Table) WriteTSV(actionWriter, gotoWriter io.Writer) error {
+	if err := writeTSV(actionWriter, t.ActionRecords()); err != nil {
+		return err
+	}
+	return writeTSV(gotoWriter, t.GotoRecords())
+}
+
+func writeTSV(w io.Writer, records [][]string) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+	writer.UseCRLF = true
+	return writer.WriteAll(records)
+}
@@ -0,0 +1,195 @@
+package grammar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const acceptAction = "acc"
+
+// Table holds the SLR action and goto tables of a grammar,
+// using the same cell format of the tables read by the parser:
+// sN shifts to state N, rN reduces by rule N and acc accepts
+type Table struct {
+	Terminals    []string
+	NonTerminals []string
+	Action       []map[string]string
+	Goto         []map[string]int
+	Conflicts    []Conflict
+
+	// kernelKeys and kernelLefts identify each state
+	// and tell the non terminal being recognized on it
+	kernelKeys  []string
+	kernelLefts []string
+}
+
+// SLRTable builds the SLR(1) table of the grammar. Conflicts are
+// reported on the table and solved the way yacc does: shift is
+// preferred over reduce and the smallest rule wins a reduce/reduce
+func (g *Grammar) SLRTable() *Table {
+	sets := g.ItemSets()
+	table := &Table{
+		Terminals:    append(append([]string{}, g.terminals...), EndMarker),
+		NonTerminals: g.nonTerminals,
+		Action:       make([]map[string]string, len(sets)),
+		Goto:         make([]map[string]int, len(sets)),
+	}
+
+	for state, set := range sets {
+		table.kernelKeys = append(table.kernelKeys, g.kernelKey(set))
+		table.kernelLefts = append(table.kernelLefts, g.rules[set.Items[0].Rule].Left)
+		table.Action[state] = make(map[string]string)
+		table.Goto[state] = make(map[string]int)
+
+		for _, terminal := range g.terminals {
+			if target, found := set.Transitions[terminal]; found {
+				table.setAction(state, terminal, "s"+strconv.Itoa(target))
+			}
+		}
+		for _, nonTerminal := range g.nonTerminals {
+			if target, found := set.Transitions[nonTerminal]; found {
+				table.Goto[state][nonTerminal] = target
+			}
+		}
+
+		completed := []int{}
+		for _, item := range set.Items {
+			if _, found := g.nextSymbol(item); !found {
+				completed = append(completed, item.Rule)
+			}
+		}
+		sort.Ints(completed)
+
+		for _, rule := range completed {
+			if rule == 0 {
+				table.setAction(state, EndMarker, acceptAction)
+				continue
+			}
+			follow := g.Follow(g.rules[rule].Left)
+			for _, terminal := range table.Terminals {
+				if follow[terminal] {
+					table.setAction(state, terminal, "r"+strconv.Itoa(rule))
+				}
+			}
+		}
+	}
+	g.explainConflicts(table, sets)
+	return table
+}
+
+// setAction fills a cell, recording a conflict if it is
+// already filled. Shifts are set before any reduce and
+// reduces in rule order, so the first action is kept
+func (t *Table) setAction(state int, terminal, action string) {
+	current, found := t.Action[state][terminal]
+	if !found {
+		t.Action[state][terminal] = action
+		return
+	}
+	if current == action {
+		return
+	}
+
+	for idx := range t.Conflicts {
+		conflict := &t.Conflicts[idx]
+		if conflict.State == state && conflict.Terminal == terminal {
+			conflict.Actions = append(conflict.Actions, action)
+			return
+		}
+	}
+	t.Conflicts = append(t.Conflicts, Conflict{State: state, Terminal: terminal, Actions: []string{current, action}})
+}
+
+// ErrorCodes tells how FillErrorCodes fills the error
+// cells of the action table, with codes like e2
+type ErrorCodes struct {
+	// Previous is a previous version of the grammar and
+	// PreviousAction the records of its action table
+	Previous       *Grammar
+	PreviousAction [][]string
+	// Aliases maps a terminal missing on PreviousAction to
+	// the one whose column holds the codes it should get
+	Aliases map[string]string
+	// ByNonTerminal holds the code of the states
+	// where the non terminal is being recognized
+	ByNonTerminal map[string]string
+	Default       string
+}
+
+// FillErrorCodes fills the empty cells of the action table with the
+// error codes that tell the parser which message to show. A state
+// whose kernel is also a state of the previous grammar keeps the
+// cells it had on the previous table. Any other empty cell gets the
+// code of the non terminal recognized on the state, or the default
+func (t *Table) FillErrorCodes(codes ErrorCodes) {
+	previousStates := make(map[string]int)
+	columns := make(map[string]int)
+	if codes.Previous != nil && len(codes.PreviousAction) > 0 {
+		for state, key := range codes.Previous.SLRTable().kernelKeys {
+			previousStates[key] = state
+		}
+		for idx, terminal := range codes.PreviousAction[0] {
+			columns[terminal] = idx
+		}
+	}
+
+	for state, actions := range t.Action {
+		code, found := codes.ByNonTerminal[t.kernelLefts[state]]
+		if !found {
+			code = codes.Default
+		}
+		previousState, inherited := previousStates[t.kernelKeys[state]]
+		inherited = inherited && previousState+1 < len(codes.PreviousAction)
+
+		for _, terminal := range t.Terminals {
+			if _, filled := actions[terminal]; filled {
+				continue
+			}
+			actions[terminal] = code
+			if !inherited {
+				continue
+			}
+
+			column, found := columns[terminal]
+			if !found {
+				column, found = columns[codes.Aliases[terminal]]
+			}
+			if cell := codes.PreviousAction[previousState+1][column]; found && (cell == "" || strings.HasPrefix(cell, "e")) {
+				actions[terminal] = cell
+			}
+		}
+	}
+}
+
+// ActionRecords returns the action table as rows of cells,
+// the first row being the header, as on action.tsv
+func (t *Table) ActionRecords() [][]string {
+	records := [][]string{append([]string{"estado"}, t.Terminals...)}
+	for state, actions := range t.Action {
+		row := []string{strconv.Itoa(state)}
+		for _, terminal := range t.Terminals {
+			row = append(row, actions[terminal])
+		}
+		records = append(records, row)
+	}
+	return records
+}
+
+// GotoRecords returns the goto table as rows of cells,
+// the first row being the header, as on goto.tsv
+func (t *Table) GotoRecords() [][]string {
+	records := [][]string{append([]string{"estado"}, t.NonTerminals...)}
+	for state, gotos := range t.Goto {
+		row := []string{strconv.Itoa(state)}
+		for _, nonTerminal := range t.NonTerminals {
+			cell := ""
+			if target, found := gotos[nonTerminal]; found {
+				cell = strconv.Itoa(target)
+			}
+			row = append(row, cell)
+		}
+		records = append(records, row)
+	}
+	return records
+}
@@ -0,0 +1,513 @@
+// Package interp runs a program straight from its syntax tree, once
+// checked by the sem package, so it runs where there is no compiler
+// for any of the targets. leia reads from an io.Reader and escreva
+// writes on an io.Writer, the same way the C program reads and writes:
+//
+//	err := interp.Run(program, info, os.Stdin, os.Stdout)
+//
+// The inteiros are 32 bits wide, like a C int, wrapping around on
+// overflow, and the reals are doubles
+package interp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/console"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"strconv"
+)
+
+var (
+	ErrorBadNode        = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+	ErrorDivisionByZero = fmt.Errorf("divisão de inteiro por zero")
+)
+
+// value is the value of a variable or expression of type dataType,
+// on the field of that type
+type value struct {
+	dataType lexer.DataType
+	integer  int32
+	real     float64
+	literal  string
+}
+
+// toReal returns v as a real, converting an inteiro
+func (v value) toReal() value {
+	if v.dataType == lexer.INTEGER {
+		return value{dataType: lexer.REAL, real: float64(v.integer)}
+	}
+	return v
+}
+
+// Hook is called before each statement is run, with how many se and
+// repita blocks it is inside of. An error returned by it stops the
+// program, and is returned by Exec
+type Hook func(stmt ast.Stmt, depth int) error
+
+// interpreter holds the state of a program being run
+type interpreter struct {
+	info      *sem.Info
+	io        console.IO
+	variables map[string]value
+	hook      Hook
+	depth     int
+	tracer    *tracer
+	// steps counts the statements run and the conditions of repita
+	// checked, iterations the bodies of repita run, and memory what
+	// the variables take, to stop the program when it goes over
+	// limits or ctx is done
+	ctx        context.Context
+	limits     limits.Limits
+	steps      int64
+	iterations int64
+	memory     int64
+}
+
+// Run runs program, whose types are on info, reading from r
+// and writing on w. The program must have been checked by the
+// sem package without errors. It stops on an inteiro divided by
+// zero, which ends the C program, returning ErrorDivisionByZero
+func Run(program *ast.Program, info *sem.Info, r io.Reader, w io.Writer) error {
+	return RunWithOptions(program, info, r, w, backend.Options{})
+}
+
+// RunWithOptions runs program like Run, writing like the targets do with options
+func RunWithOptions(program *ast.Program, info *sem.Info, r io.Reader, w io.Writer, options backend.Options) error {
+	return RunIO(program, info, console.New(r, w, options))
+}
+
+// RunIO runs program like Run, reading and writing with io, so an
+// embedder can script the values of leia and take the ones of
+// escreva as they are written, with console.Callbacks
+func RunIO(program *ast.Program, info *sem.Info, io console.IO) error {
+	environment := NewEnvironment(io, backend.Options{})
+	return environment.Run(program, info)
+}
+
+// RunContext runs program like RunIO, stopping it with an
+// limits.ExceededError once it goes over limits or the deadline of
+// ctx passes. A leia waiting for its value is not stopped
+func RunContext(ctx context.Context, program *ast.Program, info *sem.Info, io console.IO, bounds limits.Limits) error {
+	environment := NewEnvironment(io, backend.Options{})
+	environment.SetLimits(ctx, bounds)
+	return environment.Run(program, info)
+}
+
+// Environment keeps the variables of a program run a piece at a
+// time, like on a REPL, so each piece sees the values left by the
+// ones before it
+type Environment struct {
+	interpreter
+	options backend.Options
+	// declared holds the variables in the order they were declared
+	declared []*ast.VarDecl
+}
+
+// Variable is a declared variable with its value, written as
+// escreva writes it
+type Variable struct {
+	Name  string
+	Type  lexer.DataType
+	Value string
+}
+
+// String returns the variable written like its declaration with the
+// value, the literals quoted
+func (v Variable) String() string {
+	value := v.Value
+	if v.Type == lexer.LITERAL {
+		value = strconv.Quote(value)
+	}
+	return fmt.Sprintf("%s %s = %s", v.Type, v.Name, value)
+}
+
+// NewEnvironment returns an environment without variables whose
+// pieces read and write with io, the reals written with options
+func NewEnvironment(io console.IO, options backend.Options) *Environment {
+	return &Environment{interpreter: interpreter{io: io, variables: make(map[string]value)}, options: options}
+}
+
+// SetHook makes hook be called before each statement, like a
+// debugger does to stop on them. A nil hook removes it
+func (e *Environment) SetHook(hook Hook) {
+	e.hook = hook
+}
+
+// SetLimits makes the pieces stop once they go over bounds, counted
+// over all of them, or ctx is done
+func (e *Environment) SetLimits(ctx context.Context, bounds limits.Limits) {
+	e.ctx, e.limits = ctx, bounds
+}
+
+// Declare declares the variables of declarations with the zero
+// value of their types. The ones already declared keep their values.
+// It fails when there are more variables than the limits allow
+func (e *Environment) Declare(declarations []*ast.VarDecl) error {
+	for _, declaration := range declarations {
+		name := declaration.Name.Name
+		if _, found := e.variables[name]; found {
+			continue
+		}
+		if err := e.limits.Check(limits.Variables, int64(len(e.declared)+1)); err != nil {
+			return err
+		}
+		if err := e.store(name, value{dataType: declaration.Type}); err != nil {
+			return err
+		}
+		e.declared = append(e.declared, declaration)
+	}
+	return nil
+}
+
+// Run declares the variables of program, whose types are on info,
+// and runs its body, for a whole program run on an environment
+// set up with limits or a trace
+func (e *Environment) Run(program *ast.Program, info *sem.Info) error {
+	if err := e.Declare(append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...)); err != nil {
+		return err
+	}
+	return e.Exec(program.Body, info)
+}
+
+// Exec runs stmts, whose types are on info, on the variables
+// declared so far, flushing what they wrote
+func (e *Environment) Exec(stmts []ast.Stmt, info *sem.Info) error {
+	e.info = info
+	err := e.stmts(stmts)
+	// What was written before an error is still shown, like on the C program
+	if flushErr := e.io.Flush(); err == nil {
+		err = flushErr
+	}
+	if e.tracer != nil {
+		if flushErr := e.tracer.w.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	return err
+}
+
+// Eval returns the value of expr, whose types are on info,
+// written as escreva writes it
+func (e *Environment) Eval(expr ast.Expr, info *sem.Info) (string, error) {
+	// A debugger evaluates while the statements run with their info
+	previous := e.info
+	e.info = info
+	result, err := e.expr(expr)
+	e.info = previous
+	if err != nil {
+		return "", err
+	}
+	return e.format(result), nil
+}
+
+// Variables returns the variables declared so far, in the
+// order they were declared
+func (e *Environment) Variables() []Variable {
+	variables := make([]Variable, len(e.declared))
+	for index, declaration := range e.declared {
+		name := declaration.Name.Name
+		variables[index] = Variable{Name: name, Type: declaration.Type, Value: e.format(e.variables[name])}
+	}
+	return variables
+}
+
+func (e *Environment) format(v value) string {
+	switch v.dataType {
+	case lexer.INTEGER:
+		return strconv.FormatInt(int64(v.integer), 10)
+	case lexer.REAL:
+		return console.FormatReal(v.real, e.options)
+	}
+	return v.literal
+}
+
+func (i *interpreter) stmts(stmts []ast.Stmt) error {
+	for _, stmt := range stmts {
+		if err := i.step(); err != nil {
+			return err
+		}
+		if i.hook != nil {
+			if err := i.hook(stmt, i.depth); err != nil {
+				return err
+			}
+		}
+		if err := i.stmt(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *interpreter) stmt(stmt ast.Stmt) error {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		if err := i.store(node.Target.Name, i.read(i.info.TypeOf(node.Target))); err != nil {
+			return err
+		}
+		i.trace(stmt, "", nil)
+	case *ast.Write:
+		written, err := i.expr(node.Value)
+		if err != nil {
+			return err
+		}
+		i.write(written)
+		i.trace(stmt, "", &written)
+	case *ast.Assign:
+		assigned, err := i.expr(node.Value)
+		if err != nil {
+			return err
+		}
+		if err := i.store(node.Target.Name, convert(assigned, i.info.TypeOf(node.Target))); err != nil {
+			return err
+		}
+		i.trace(stmt, "", nil)
+	case *ast.If:
+		holds, err := i.condition(node.Condition)
+		if err != nil {
+			return err
+		}
+		i.trace(stmt, truth(holds), nil)
+		i.depth++
+		defer func() { i.depth-- }()
+		if holds {
+			return i.stmts(node.Body)
+		}
+		return i.stmts(node.Else)
+	case *ast.While:
+		i.depth++
+		defer func() { i.depth-- }()
+		for {
+			if err := i.step(); err != nil {
+				return err
+			}
+			holds, err := i.condition(node.Condition)
+			if err != nil {
+				return err
+			}
+			i.trace(stmt, truth(holds), nil)
+			if !holds {
+				return nil
+			}
+			i.iterations++
+			if err := i.limits.Check(limits.Iterations, i.iterations); err != nil {
+				return err
+			}
+			if err := i.stmts(node.Body); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrorBadNode
+	}
+	return nil
+}
+
+// trace writes the row of stmt on the trace, if there is one
+func (i *interpreter) trace(stmt ast.Stmt, condition string, written *value) {
+	if i.tracer != nil {
+		i.tracer.row(stmt, condition, written)
+	}
+}
+
+// step counts a step, failing when there are more than the limits
+// allow or the context is done
+func (i *interpreter) step() error {
+	i.steps++
+	if err := i.limits.Check(limits.Steps, i.steps); err != nil {
+		return err
+	}
+	if i.ctx != nil && i.steps%limits.CheckInterval == 1 {
+		return limits.Context(i.ctx)
+	}
+	return nil
+}
+
+// store stores v on the variable name, failing when the variables
+// take more memory, or v is a longer literal, than the limits allow
+func (i *interpreter) store(name string, v value) error {
+	previous, found := i.variables[name]
+	i.memory += limits.Size(v.dataType, v.literal)
+	if found {
+		i.memory -= limits.Size(previous.dataType, previous.literal)
+	}
+	i.variables[name] = v
+	if v.dataType == lexer.LITERAL {
+		if err := i.limits.Check(limits.Literal, int64(len(v.literal))); err != nil {
+			return err
+		}
+	}
+	return i.limits.Check(limits.Memory, i.memory)
+}
+
+// convert returns v stored on a variable of type dataType: an inteiro
+// on a real is promoted, and a real on an inteiro loses its fractional
+// part
+func convert(v value, dataType lexer.DataType) value {
+	switch {
+	case dataType == lexer.REAL:
+		return v.toReal()
+	case dataType == lexer.INTEGER && v.dataType == lexer.REAL:
+		return value{dataType: lexer.INTEGER, integer: int32(v.real)}
+	}
+	return v
+}
+
+// read reads a value of type dataType
+func (i *interpreter) read(dataType lexer.DataType) value {
+	read := value{dataType: dataType}
+	switch dataType {
+	case lexer.INTEGER:
+		read.integer = i.io.ReadInteger()
+	case lexer.REAL:
+		read.real = i.io.ReadReal()
+	case lexer.LITERAL:
+		read.literal = i.io.ReadLiteral()
+	}
+	return read
+}
+
+func (i *interpreter) write(written value) {
+	switch written.dataType {
+	case lexer.INTEGER:
+		i.io.WriteInteger(written.integer)
+	case lexer.REAL:
+		i.io.WriteReal(written.real)
+	case lexer.LITERAL:
+		i.io.WriteLiteral(written.literal)
+	}
+}
+
+// condition tells whether the comparison condition holds
+func (i *interpreter) condition(condition ast.Expr) (bool, error) {
+	node, comparison := condition.(*ast.BinaryExpr)
+	if !comparison {
+		return false, ErrorBadNode
+	}
+	left, err := i.expr(node.Left)
+	if err != nil {
+		return false, err
+	}
+	right, err := i.expr(node.Right)
+	if err != nil {
+		return false, err
+	}
+
+	var less, equal bool
+	switch left.dataType {
+	case lexer.INTEGER:
+		less, equal = left.integer < right.integer, left.integer == right.integer
+	case lexer.REAL:
+		less, equal = left.real < right.real, left.real == right.real
+	case lexer.LITERAL:
+		less, equal = left.literal < right.literal, left.literal == right.literal
+	}
+	// A nan is neither less, greater nor equal to anything
+	greater := !less && !equal && !math.IsNaN(left.real) && !math.IsNaN(right.real)
+	switch node.Operator {
+	case "<":
+		return less, nil
+	case ">":
+		return greater, nil
+	case "<=":
+		return less || equal, nil
+	case ">=":
+		return greater || equal, nil
+	case "=":
+		return equal, nil
+	case "<>":
+		return !equal, nil
+	}
+	return false, ErrorBadNode
+}
+
+// expr returns the value of expr, converted to a real if it was promoted
+func (i *interpreter) expr(expr ast.Expr) (value, error) {
+	result, err := i.value(expr)
+	if err == nil && i.info.Conversions[expr] == lexer.REAL {
+		result = result.toReal()
+	}
+	return result, err
+}
+
+func (i *interpreter) value(expr ast.Expr) (value, error) {
+	switch node := expr.(type) {
+	case *ast.Ident:
+		return i.variables[node.Name], nil
+	case *ast.Literal:
+		return literal(node)
+	case *ast.BinaryExpr:
+		left, err := i.expr(node.Left)
+		if err != nil {
+			return value{}, err
+		}
+		right, err := i.expr(node.Right)
+		if err != nil {
+			return value{}, err
+		}
+		if left.dataType == lexer.REAL {
+			return arithmeticReal(node.Operator, left.real, right.real)
+		}
+		if node.Operator == "/" && right.integer == 0 {
+			return value{}, fmt.Errorf("%w na linha %d, coluna %d", ErrorDivisionByZero, node.Pos().Line, node.Pos().Column)
+		}
+		return arithmeticInteger(node.Operator, left.integer, right.integer)
+	}
+	return value{}, ErrorBadNode
+}
+
+// arithmeticInteger operates on two inteiros, the
+// division dropping the fractional part, like in C
+func arithmeticInteger(operator string, left, right int32) (value, error) {
+	result := value{dataType: lexer.INTEGER}
+	switch operator {
+	case "+":
+		result.integer = left + right
+	case "-":
+		result.integer = left - right
+	case "*":
+		result.integer = left * right
+	case "/":
+		result.integer = left / right
+	default:
+		return value{}, ErrorBadNode
+	}
+	return result, nil
+}
+
+func arithmeticReal(operator string, left, right float64) (value, error) {
+	result := value{dataType: lexer.REAL}
+	switch operator {
+	case "+":
+		result.real = left + right
+	case "-":
+		result.real = left - right
+	case "*":
+		result.real = left * right
+	case "/":
+		result.real = left / right
+	default:
+		return value{}, ErrorBadNode
+	}
+	return result, nil
+}
+
+// literal returns the value of a number or literal constant
+func literal(node *ast.Literal) (value, error) {
+	if node.Type == lexer.LITERAL {
+		return value{dataType: lexer.LITERAL, literal: lexer.LiteralText(node.Value)}, nil
+	}
+	number, err := lexer.ParseNumber(node.Value, node.Type)
+	if err != nil {
+		return value{}, err
+	}
+	if node.Type == lexer.INTEGER {
+		return value{dataType: lexer.INTEGER, integer: int32(number)}, nil
+	}
+	return value{dataType: lexer.REAL, real: number}, nil
+}
@@ -0,0 +1,275 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/backend"
+	"mgol-go/src/console"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// declarations declares A as inteiro, B as real and NOME as literal
+func declarations() []*ast.VarDecl {
+	return []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A"), asttest.Declaration(lexer.REAL, "B"), asttest.Declaration(lexer.LITERAL, "NOME")}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name   string
+		body   []ast.Stmt
+		input  string
+		output string
+	}{
+		{
+			"read and write each type",
+			[]ast.Stmt{
+				&ast.Read{Target: asttest.Ident("NOME")},
+				&ast.Read{Target: asttest.Ident("A")},
+				&ast.Read{Target: asttest.Ident("B")},
+				&ast.Write{Value: asttest.Ident("NOME")},
+				&ast.Write{Value: asttest.Literal(`" "`, lexer.LITERAL)},
+				&ast.Write{Value: asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER))},
+				&ast.Write{Value: asttest.Literal(`"\t"`, lexer.LITERAL)},
+				&ast.Write{Value: asttest.Ident("B")},
+			},
+			"\nAna Maria\n41 2.5\n",
+			"Ana Maria 42\\t2.500000",
+		},
+		{
+			"values that can not be read are zero",
+			[]ast.Stmt{
+				&ast.Read{Target: asttest.Ident("A")},
+				&ast.Read{Target: asttest.Ident("NOME")},
+				&ast.Write{Value: asttest.Ident("A")},
+				&ast.Write{Value: asttest.Ident("NOME")},
+			},
+			"",
+			"0",
+		},
+		{
+			"inteiro division truncated and promoted operand",
+			[]ast.Stmt{
+				&ast.Write{Value: asttest.Binary("/", asttest.Literal("-7", lexer.INTEGER), asttest.Literal("2", lexer.INTEGER))},
+				&ast.Write{Value: asttest.Literal(`" "`, lexer.LITERAL)},
+				&ast.Write{Value: asttest.Binary("/", asttest.Literal("7", lexer.INTEGER), asttest.Literal("2.0", lexer.REAL))},
+			},
+			"",
+			"-3 3.500000",
+		},
+		{
+			"real assigned to an inteiro",
+			[]ast.Stmt{
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("*", asttest.Literal("2.75", lexer.REAL), asttest.Literal("-1.0", lexer.REAL))},
+				&ast.Write{Value: asttest.Ident("A")},
+			},
+			"",
+			"-2",
+		},
+		{
+			"inteiro wrapping around",
+			[]ast.Stmt{
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("+", asttest.Literal("2147483647", lexer.INTEGER), asttest.Literal("1", lexer.INTEGER))},
+				&ast.Write{Value: asttest.Ident("A")},
+			},
+			"",
+			"-2147483648",
+		},
+		{
+			"loop and conditional",
+			[]ast.Stmt{
+				&ast.Read{Target: asttest.Ident("A")},
+				&ast.While{
+					Condition: asttest.Binary(">", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)),
+					Body: []ast.Stmt{
+						&ast.If{
+							Condition: asttest.Binary("=", asttest.Ident("A"), asttest.Literal("2", lexer.INTEGER)),
+							Body:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"dois "`, lexer.LITERAL)}},
+							Else:      []ast.Stmt{&ast.Write{Value: asttest.Ident("A")}, &ast.Write{Value: asttest.Literal(`" "`, lexer.LITERAL)}},
+						},
+						&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("-", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER))},
+					},
+				},
+			},
+			"3",
+			"3 dois 1 ",
+		},
+		{
+			"literals compared like strcmp",
+			[]ast.Stmt{
+				&ast.Read{Target: asttest.Ident("NOME")},
+				&ast.If{
+					Condition: asttest.Binary("<", asttest.Ident("NOME"), asttest.Literal(`"b"`, lexer.LITERAL)),
+					Body:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"antes"`, lexer.LITERAL)}},
+					Else:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"depois"`, lexer.LITERAL)}},
+				},
+			},
+			"B\n",
+			"antes",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program := &ast.Program{Declarations: declarations(), Body: tc.body}
+			info := sem.NewChecker(nil).Check(program)
+			require.Empty(t, info.Errors)
+
+			var output bytes.Buffer
+			require.NoError(t, Run(program, info, strings.NewReader(tc.input), &output))
+			require.Equal(t, tc.output, output.String())
+		})
+	}
+}
+
+func TestRunDecimalComma(t *testing.T) {
+	program := &ast.Program{Declarations: declarations(), Body: []ast.Stmt{
+		&ast.Read{Target: asttest.Ident("B")},
+		&ast.Write{Value: asttest.Ident("B")},
+	}}
+	info := sem.NewChecker(nil).Check(program)
+
+	var output bytes.Buffer
+	require.NoError(t, RunWithOptions(program, info, strings.NewReader("3.14"), &output, backend.Options{DecimalComma: true}))
+	require.Equal(t, "3,140000", output.String())
+}
+
+func TestRunDivisionByZero(t *testing.T) {
+	program := &ast.Program{Declarations: declarations(), Body: []ast.Stmt{
+		&ast.Read{Target: asttest.Ident("A")},
+		&ast.Write{Value: asttest.Literal(`"antes"`, lexer.LITERAL)},
+		&ast.Write{Value: asttest.Binary("/", asttest.Literal("1", lexer.INTEGER), asttest.Ident("A"))},
+	}}
+	info := sem.NewChecker(nil).Check(program)
+
+	var output bytes.Buffer
+	err := Run(program, info, strings.NewReader("0"), &output)
+	require.ErrorIs(t, err, ErrorDivisionByZero)
+	require.Equal(t, "antes", output.String())
+}
+
+func TestRunBadNode(t *testing.T) {
+	program := &ast.Program{Body: []ast.Stmt{&ast.BadStmt{}}}
+	err := Run(program, sem.NewChecker(nil).Check(program), strings.NewReader(""), &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrorBadNode)
+}
+
+func TestEnvironment(t *testing.T) {
+	var output bytes.Buffer
+	environment := NewEnvironment(console.New(strings.NewReader("41"), &output, backend.Options{}), backend.Options{})
+	first := &ast.Program{Declarations: declarations()[:1], Body: []ast.Stmt{&ast.Read{Target: asttest.Ident("A")}}}
+	require.NoError(t, environment.Declare(first.Declarations))
+	require.NoError(t, environment.Exec(first.Body, sem.NewChecker(nil).Check(first)))
+
+	sum := asttest.Binary("+", asttest.Ident("A"), asttest.Ident("B"))
+	second := &ast.Program{Declarations: declarations(), Body: []ast.Stmt{&ast.Write{Value: sum}}}
+	require.NoError(t, environment.Declare(second.Declarations))
+	value, err := environment.Eval(sum, sem.NewChecker(nil).Check(second))
+	require.NoError(t, err)
+	require.Equal(t, "41.000000", value)
+	require.Equal(t, []Variable{
+		{Name: "A", Type: lexer.INTEGER, Value: "41"},
+		{Name: "B", Type: lexer.REAL, Value: "0.000000"},
+		{Name: "NOME", Type: lexer.LITERAL, Value: ""},
+	}, environment.Variables())
+}
+
+func TestRunIO(t *testing.T) {
+	program := &ast.Program{Declarations: declarations(), Body: []ast.Stmt{
+		&ast.Read{Target: asttest.Ident("NOME")},
+		&ast.Read{Target: asttest.Ident("A")},
+		&ast.Write{Value: asttest.Ident("NOME")},
+		&ast.Write{Value: asttest.Binary("*", asttest.Ident("A"), asttest.Literal("2", lexer.INTEGER))},
+	}}
+	info := sem.NewChecker(nil).Check(program)
+
+	answers := []string{"Ana", "21"}
+	var written []string
+	io := console.Callbacks(func(lexer.DataType) string {
+		answer := answers[0]
+		answers = answers[1:]
+		return answer
+	}, func(text string) {
+		written = append(written, text)
+	}, backend.Options{})
+	require.NoError(t, RunIO(program, info, io))
+	require.Equal(t, []string{"Ana", "42"}, written)
+}
+
+func TestRunContext(t *testing.T) {
+	endless := &ast.While{Condition: asttest.Binary("=", asttest.Ident("A"), asttest.Ident("A")), Body: []ast.Stmt{
+		&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER))},
+	}}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		bounds limits.Limits
+		body   []ast.Stmt
+		input  string
+		err    error
+	}{
+		{"steps", context.Background(), limits.Limits{Steps: 100}, []ast.Stmt{endless}, "", &limits.ExceededError{Resource: limits.Steps, Limit: 100}},
+		{"time", expired, limits.Limits{}, []ast.Stmt{endless}, "", &limits.ExceededError{Resource: limits.Time}},
+		{"variables", context.Background(), limits.Limits{Variables: 2}, nil, "", &limits.ExceededError{Resource: limits.Variables, Limit: 2}},
+		{"memory", context.Background(), limits.Limits{Memory: 20}, []ast.Stmt{&ast.Read{Target: asttest.Ident("NOME")}}, "Ana Maria\n", &limits.ExceededError{Resource: limits.Memory, Limit: 20}},
+		{"literal", context.Background(), limits.Limits{Literal: 8}, []ast.Stmt{&ast.Read{Target: asttest.Ident("NOME")}}, "Ana Maria\n", &limits.ExceededError{Resource: limits.Literal, Limit: 8}},
+		{"iterations", context.Background(), limits.Limits{Iterations: 10}, []ast.Stmt{endless}, "", &limits.ExceededError{Resource: limits.Iterations, Limit: 10}},
+		{"within the limits", context.Background(), limits.Limits{Steps: 1, Variables: 3, Memory: 21, Literal: 9}, []ast.Stmt{&ast.Read{Target: asttest.Ident("NOME")}}, "Ana Maria\n", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program := &ast.Program{Declarations: declarations(), Body: tc.body}
+			info := sem.NewChecker(nil).Check(program)
+			io := console.New(strings.NewReader(tc.input), &bytes.Buffer{}, backend.Options{})
+			err := RunContext(tc.ctx, program, info, io, tc.bounds)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, limits.ErrorLimitExceeded)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	at := func(line int) ast.Span {
+		return ast.Span{Start: lexer.Position{Line: line}}
+	}
+	program := &ast.Program{Declarations: declarations(), Body: []ast.Stmt{
+		&ast.Read{Span: at(1), Target: asttest.Ident("NOME")},
+		&ast.Assign{Span: at(2), Target: asttest.Ident("A"), Value: asttest.Literal("2", lexer.INTEGER)},
+		&ast.While{Span: at(3), Condition: asttest.Binary(">", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)), Body: []ast.Stmt{
+			&ast.Assign{Span: at(4), Target: asttest.Ident("A"), Value: asttest.Binary("-", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER))},
+		}},
+		&ast.Write{Span: at(6), Value: asttest.Ident("NOME")},
+	}}
+	info := sem.NewChecker(nil).Check(program)
+	var output, trace bytes.Buffer
+	environment := NewEnvironment(console.New(strings.NewReader("Ana\n"), &output, backend.Options{}), backend.Options{})
+	environment.SetTrace(&trace)
+	require.NoError(t, environment.Run(program, info))
+	require.Equal(t, "Ana", output.String())
+	require.Equal(t, "linha  comando         condição    A  B         NOME   saída\n"+
+		"1      leia NOME;                  0  0.000000  \"Ana\"  \n"+
+		"2      A <- 2;                     2  0.000000  \"Ana\"  \n"+
+		"3      repita (A > 0)  verdadeiro  2  0.000000  \"Ana\"  \n"+
+		"4      A <- A - 1;                 1  0.000000  \"Ana\"  \n"+
+		"3      repita (A > 0)  verdadeiro  1  0.000000  \"Ana\"  \n"+
+		"4      A <- A - 1;                 0  0.000000  \"Ana\"  \n"+
+		"3      repita (A > 0)  falso       0  0.000000  \"Ana\"  \n"+
+		"6      escreva NOME;               0  0.000000  \"Ana\"  \"Ana\"\n",
+		trace.String())
+}
@@ -0,0 +1,74 @@
+package interp
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// tracer writes the dry run table of a program, the one students
+// fill by hand: a row for each statement run and each condition
+// checked, with the values of the variables after it and what
+// escreva wrote
+type tracer struct {
+	w           *tabwriter.Writer
+	environment *Environment
+	// columns holds the variables of the table, the ones
+	// declared when its first row was written
+	columns []string
+}
+
+// SetTrace makes each statement run and each condition checked be
+// written on w as a row of a table, with the variables declared so far
+// as columns. The table is aligned, so it is only written once the
+// pieces end
+func (e *Environment) SetTrace(w io.Writer) {
+	e.tracer = &tracer{w: tabwriter.NewWriter(w, 0, 8, 2, ' ', 0), environment: e}
+}
+
+// row writes the row of stmt, with condition telling how a
+// condition turned out and written what escreva wrote, if any
+func (t *tracer) row(stmt ast.Stmt, condition string, written *value) {
+	values := make(map[string]string)
+	for _, variable := range t.environment.Variables() {
+		values[variable.Name] = cell(variable.Type, variable.Value)
+	}
+	if t.columns == nil {
+		t.columns = []string{}
+		for _, variable := range t.environment.Variables() {
+			t.columns = append(t.columns, variable.Name)
+		}
+		fmt.Fprintf(t.w, "linha\tcomando\tcondição\t%s\tsaída\n", strings.Join(t.columns, "\t"))
+	}
+
+	cells := []string{strconv.Itoa(stmt.Pos().Line), ast.Header(stmt), condition}
+	for _, name := range t.columns {
+		cells = append(cells, values[name])
+	}
+	output := ""
+	if written != nil {
+		output = cell(written.dataType, t.environment.format(*written))
+	}
+	fmt.Fprintln(t.w, strings.Join(append(cells, output), "\t"))
+}
+
+// cell returns how text, a value of type dataType, is shown on
+// the table: the literals are quoted, so the spaces are seen
+func cell(dataType lexer.DataType, text string) string {
+	if dataType == lexer.LITERAL {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+// truth returns how a condition that holds, or not, is shown on the table
+func truth(holds bool) string {
+	if holds {
+		return "verdadeiro"
+	}
+	return "falso"
+}
@@ -0,0 +1,193 @@
+package ir
+
+import "fmt"
+
+// Statistics counts what the optimizations removed from a program
+type Statistics struct {
+	// Unused is the number of instructions removed
+	// for writing a value that is never read
+	Unused int
+	// Blocks is the number of blocks removed for never running,
+	// and Unreachable the number of instructions on them
+	Blocks      int
+	Unreachable int
+}
+
+func (s Statistics) String() string {
+	return fmt.Sprintf("%d instruções sem uso removidas, %d blocos inalcançáveis removidos, com %d instruções",
+		s.Unused, s.Blocks, s.Unreachable)
+}
+
+// Add adds up what other removed
+func (s *Statistics) Add(other Statistics) {
+	s.Unused += other.Unused
+	s.Blocks += other.Blocks
+	s.Unreachable += other.Unreachable
+}
+
+// block is a sequence of instructions that always run together,
+// the ones on instructions[start:end]
+type block struct {
+	start, end int
+	successors []int
+}
+
+// splitBlocks splits instructions on the labels and after the jumps
+func splitBlocks(instructions []Instruction) []block {
+	var blocks []block
+	start := 0
+	for index, instruction := range instructions {
+		if instruction.Op == Mark && index > start {
+			blocks = append(blocks, block{start: start, end: index})
+			start = index
+		}
+		if instruction.Op == Jump || instruction.Op == JumpUnless {
+			blocks = append(blocks, block{start: start, end: index + 1})
+			start = index + 1
+		}
+	}
+	if start < len(instructions) {
+		blocks = append(blocks, block{start: start, end: len(instructions)})
+	}
+
+	marks := make(map[Label]int)
+	for index, block := range blocks {
+		if instructions[block.start].Op == Mark {
+			marks[instructions[block.start].Label] = index
+		}
+	}
+	for index := range blocks {
+		last := instructions[blocks[index].end-1]
+		if last.Op == Jump || last.Op == JumpUnless {
+			if target, found := marks[last.Label]; found {
+				blocks[index].successors = append(blocks[index].successors, target)
+			}
+		}
+		if last.Op != Jump && index+1 < len(blocks) {
+			blocks[index].successors = append(blocks[index].successors, index+1)
+		}
+	}
+	return blocks
+}
+
+// EliminateDeadCode returns program without the blocks no jump or
+// instruction before leads to and without the instructions writing
+// values that are never read. leia is kept, as it still reads the input
+func EliminateDeadCode(program *Program) (*Program, Statistics) {
+	eliminated := *program
+	eliminated.Instructions = append([]Instruction{}, program.Instructions...)
+	var statistics Statistics
+	for {
+		instructions, blocks, unreachable := removeUnreachable(eliminated.Instructions)
+		statistics.Blocks += blocks
+		statistics.Unreachable += unreachable
+		instructions, unused := removeUnused(instructions)
+		statistics.Unused += unused
+		eliminated.Instructions = instructions
+		// A removed jump or read may leave more to remove
+		if blocks == 0 && unused == 0 {
+			return &eliminated, statistics
+		}
+	}
+}
+
+// removeUnreachable removes the blocks not reached from the first one
+func removeUnreachable(instructions []Instruction) ([]Instruction, int, int) {
+	blocks := splitBlocks(instructions)
+	reached := make([]bool, len(blocks))
+	pending := []int{}
+	if len(blocks) > 0 {
+		reached[0], pending = true, append(pending, 0)
+	}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, successor := range blocks[current].successors {
+			if !reached[successor] {
+				reached[successor] = true
+				pending = append(pending, successor)
+			}
+		}
+	}
+
+	var kept []Instruction
+	removedBlocks, removed := 0, 0
+	for index, block := range blocks {
+		if reached[index] {
+			kept = append(kept, instructions[block.start:block.end]...)
+			continue
+		}
+		removedBlocks++
+		removed += block.end - block.start
+	}
+	return kept, removedBlocks, removed
+}
+
+// writes tells whether instruction only writes a value on Dest
+func writes(instruction Instruction) bool {
+	return instruction.Op == Copy || instruction.Op == Binary || instruction.Op == Convert
+}
+
+// removeUnused removes the instructions writing values that are not
+// read before being written again or the program ending. What is read
+// at the start of each block is found by going back over the blocks
+// after it until nothing changes, as the loops go back to the blocks
+func removeUnused(instructions []Instruction) ([]Instruction, int) {
+	blocks := splitBlocks(instructions)
+	liveIn := make([]map[string]bool, len(blocks))
+	for index := range liveIn {
+		liveIn[index] = make(map[string]bool)
+	}
+	liveOut := func(index int) map[string]bool {
+		live := make(map[string]bool)
+		for _, successor := range blocks[index].successors {
+			for name := range liveIn[successor] {
+				live[name] = true
+			}
+		}
+		return live
+	}
+	// live goes back over the instructions of a block, calling
+	// dead on the ones writing values that are not read
+	live := func(index int, dead func(int)) map[string]bool {
+		read := liveOut(index)
+		for position := blocks[index].end - 1; position >= blocks[index].start; position-- {
+			instruction := instructions[position]
+			if writes(instruction) && !read[instruction.Dest.String()] {
+				dead(position)
+				continue
+			}
+			if writes(instruction) || instruction.Op == Read {
+				delete(read, instruction.Dest.String())
+			}
+			for _, operand := range []Operand{instruction.Left, instruction.Right} {
+				if operand.Kind == Variable || operand.Kind == Temporary {
+					read[operand.String()] = true
+				}
+			}
+		}
+		return read
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for index := len(blocks) - 1; index >= 0; index-- {
+			read := live(index, func(int) {})
+			if len(read) != len(liveIn[index]) {
+				liveIn[index], changed = read, true
+			}
+		}
+	}
+
+	dead := make(map[int]bool)
+	for index := range blocks {
+		live(index, func(position int) { dead[position] = true })
+	}
+	kept := make([]Instruction, 0, len(instructions)-len(dead))
+	for position, instruction := range instructions {
+		if !dead[position] {
+			kept = append(kept, instruction)
+		}
+	}
+	return kept, len(dead)
+}
@@ -0,0 +1,124 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEliminateDeadCode(t *testing.T) {
+	testCases := []struct {
+		name       string
+		before     string
+		after      string
+		statistics Statistics
+	}{
+		{
+			"values never read",
+			`inteiro A
+inteiro B
+	leia A
+	%t1 = A + 1
+	B = %t1
+	%t2 = A * 2
+	A = %t2
+	escreva A
+	A = 5
+`,
+			`inteiro A
+inteiro B
+	leia A
+	%t2 = A * 2
+	A = %t2
+	escreva A
+`,
+			Statistics{Unused: 3},
+		},
+		{
+			"read kept",
+			`inteiro A
+	leia A
+`,
+			`inteiro A
+	leia A
+`,
+			Statistics{},
+		},
+		{
+			"read on the next iteration",
+			`inteiro A
+inteiro B
+	A = 3
+L1:
+	ifFalse A > 0 goto L2
+	B = A
+	%t1 = A - 1
+	A = %t1
+	goto L1
+L2:
+	escreva A
+`,
+			`inteiro A
+inteiro B
+	A = 3
+L1:
+	ifFalse A > 0 goto L2
+	%t1 = A - 1
+	A = %t1
+	goto L1
+L2:
+	escreva A
+`,
+			Statistics{Unused: 1},
+		},
+		{
+			"unreachable blocks",
+			`inteiro A
+	leia A
+	goto L2
+L1:
+	escreva "nunca"
+	goto L2
+L2:
+	escreva A
+	goto L3
+	escreva "nunca"
+L3:
+`,
+			`inteiro A
+	leia A
+	goto L2
+L2:
+	escreva A
+	goto L3
+L3:
+`,
+			Statistics{Blocks: 2, Unreachable: 4},
+		},
+		{
+			"value read only on unreachable blocks",
+			`inteiro A
+	A = 1
+	goto L1
+	escreva A
+L1:
+`,
+			`inteiro A
+	goto L1
+L1:
+`,
+			Statistics{Unused: 1, Blocks: 1, Unreachable: 1},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			before, err := Parse(testCase.before)
+			require.NoError(t, err)
+
+			after, statistics := EliminateDeadCode(before)
+			require.Equal(t, testCase.after, after.String())
+			require.Equal(t, testCase.statistics, statistics)
+			require.Equal(t, testCase.before, before.String())
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package ir is the three-address code the backends lower the syntax
+// tree to, shared by them and the optimizations. Each instruction
+// takes at most two operands, variables, temporaries or constants,
+// and the se and repita blocks are turned into labels and jumps:
+//
+//	inteiro A
+//	L1:
+//		ifFalse A > 0 goto L2
+//		%t1 = A - 1
+//		A = %t1
+//		goto L1
+//	L2:
+//
+// The conversions between inteiro and real, implicit on the tree,
+// have their own instructions, so every operation takes operands
+// of the same type.
+//
+// That listing, of Program.String, is meant to stay as it is, for the
+// tests and the lessons that read it, and Parse reads it back. It has
+// the declarations first, one on each line with the type and the name,
+// and then the instructions, the labels ending with a colon. The names
+// of the temporaries start with %t, so a variable can be named t1
+package ir
+
+import (
+	"fmt"
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+// OperandKind tells what an operand is
+type OperandKind int
+
+const (
+	// NoOperand is the kind of the operands an instruction does not take
+	NoOperand OperandKind = iota
+	Variable
+	Temporary
+	Constant
+)
+
+// Operand is what an instruction reads or writes
+type Operand struct {
+	Kind OperandKind
+	// Name is the name of a variable, or the constant as written
+	// on the source, quotes included. A number is real when it
+	// has a point, like on the source
+	Name string
+	// Temporary is the number of a temporary, from 1
+	Temporary int
+	Type      lexer.DataType
+}
+
+func (o Operand) String() string {
+	switch o.Kind {
+	case Temporary:
+		return fmt.Sprintf("%%t%d", o.Temporary)
+	case Variable, Constant:
+		return o.Name
+	}
+	return ""
+}
+
+// Label is the target of the jumps, numbered from 1
+type Label int
+
+func (l Label) String() string {
+	return fmt.Sprintf("L%d", l)
+}
+
+// Op is the operation of an instruction
+type Op int
+
+const (
+	// Copy stores Left on Dest: Dest = Left
+	Copy Op = iota
+	// Binary stores the arithmetic operation on Left and Right
+	// on Dest: Dest = Left Operator Right
+	Binary
+	// Convert stores Left converted to the type of Dest:
+	// Dest = (real) Left. A real has its fractional part dropped
+	Convert
+	// Read reads a value into Dest: leia Dest
+	Read
+	// Write writes Left: escreva Left
+	Write
+	// Mark is where Label is: L1:
+	Mark
+	// Jump goes on to Label: goto L1
+	Jump
+	// JumpUnless goes on to Label unless the comparison of Left and
+	// Right with the relational Operator holds: ifFalse Left < Right goto L1
+	JumpUnless
+)
+
+// Instruction is a three-address instruction
+type Instruction struct {
+	Op       Op
+	Dest     Operand
+	Left     Operand
+	Right    Operand
+	Operator string
+	Label    Label
+	// Pos is where the operation of the instruction is on the
+	// source, for its runtime errors, or the zero one when unknown
+	Pos lexer.Position
+}
+
+// typeNames holds how the type of a conversion is written
+var typeNames = map[lexer.DataType]string{
+	lexer.INTEGER: "inteiro",
+	lexer.REAL:    "real",
+	lexer.LITERAL: "literal",
+}
+
+func (i Instruction) String() string {
+	switch i.Op {
+	case Copy:
+		return fmt.Sprintf("%s = %s", i.Dest, i.Left)
+	case Binary:
+		return fmt.Sprintf("%s = %s %s %s", i.Dest, i.Left, i.Operator, i.Right)
+	case Convert:
+		return fmt.Sprintf("%s = (%s) %s", i.Dest, typeNames[i.Dest.Type], i.Left)
+	case Read:
+		return fmt.Sprintf("leia %s", i.Dest)
+	case Write:
+		return fmt.Sprintf("escreva %s", i.Left)
+	case Mark:
+		return fmt.Sprintf("%s:", i.Label)
+	case Jump:
+		return fmt.Sprintf("goto %s", i.Label)
+	case JumpUnless:
+		return fmt.Sprintf("ifFalse %s %s %s goto %s", i.Left, i.Operator, i.Right, i.Label)
+	}
+	return ""
+}
+
+// Declaration is a variable of the program
+type Declaration struct {
+	Name string
+	Type lexer.DataType
+}
+
+// Program is a program lowered to three-address code
+type Program struct {
+	// Declarations holds the variables, in the order they were
+	// declared, followed by the ones declared implicitly
+	Declarations []Declaration
+	Instructions []Instruction
+	// Temporaries holds the type of each temporary,
+	// the one of tN on Temporaries[N-1]
+	Temporaries []lexer.DataType
+	// Labels is the number of labels
+	Labels int
+}
+
+// InstructionCount returns how many instructions p has, without the labels
+func (p *Program) InstructionCount() int {
+	instructions := 0
+	for _, instruction := range p.Instructions {
+		if instruction.Op != Mark {
+			instructions++
+		}
+	}
+	return instructions
+}
+
+// String writes the declarations and then an instruction on each line
+func (p *Program) String() string {
+	var listing strings.Builder
+	for _, declaration := range p.Declarations {
+		fmt.Fprintf(&listing, "%s %s\n", typeNames[declaration.Type], declaration.Name)
+	}
+	for _, instruction := range p.Instructions {
+		if instruction.Op != Mark {
+			listing.WriteByte('\t')
+		}
+		listing.WriteString(instruction.String() + "\n")
+	}
+	return listing.String()
+}
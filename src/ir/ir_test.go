@@ -0,0 +1,138 @@
+package ir
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLower(t *testing.T) {
+	// inteiro A; real B; literal C;
+	// leia A;
+	// repita (A > 0)
+	//	se (C = "sim") entao B <- (A + 1) * 2.5; senao escreva "não"; fimse
+	//	A <- B;
+	// fimrepita
+	// se (B >= 1) entao escreva B; fimse
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			asttest.Declaration(lexer.INTEGER, "A"),
+			asttest.Declaration(lexer.REAL, "B"),
+			asttest.Declaration(lexer.LITERAL, "C"),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.While{Condition: asttest.Binary(">", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)), Body: []ast.Stmt{
+				&ast.If{
+					Condition: asttest.Binary("=", asttest.Ident("C"), asttest.Literal(`"sim"`, lexer.LITERAL)),
+					Body: []ast.Stmt{&ast.Assign{
+						Target: asttest.Ident("B"),
+						Value:  asttest.Binary("*", asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER)), asttest.Literal("2.5", lexer.REAL)),
+					}},
+					Else: []ast.Stmt{&ast.Write{Value: asttest.Literal(`"não"`, lexer.LITERAL)}},
+				},
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Ident("B")},
+			}},
+			&ast.If{
+				Condition: asttest.Binary(">=", asttest.Ident("B"), asttest.Literal("1", lexer.INTEGER)),
+				Body:      []ast.Stmt{&ast.Write{Value: asttest.Ident("B")}},
+			},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	lowered, err := Lower(program, info)
+	require.NoError(t, err)
+	require.Equal(t, `inteiro A
+real B
+literal C
+	leia A
+L1:
+	ifFalse A > 0 goto L2
+	ifFalse C = "sim" goto L3
+	%t1 = A + 1
+	%t2 = (real) %t1
+	%t3 = %t2 * 2.5
+	B = %t3
+	goto L4
+L3:
+	escreva "não"
+L4:
+	%t4 = (inteiro) B
+	A = %t4
+	goto L1
+L2:
+	%t5 = (real) 1
+	ifFalse B >= %t5 goto L5
+	escreva B
+L5:
+`, lowered.String())
+	require.Equal(t, []lexer.DataType{lexer.INTEGER, lexer.REAL, lexer.REAL, lexer.INTEGER, lexer.REAL}, lowered.Temporaries)
+	require.Equal(t, 5, lowered.Labels)
+}
+
+func TestLowerBadNode(t *testing.T) {
+	program := &ast.Program{Body: []ast.Stmt{&ast.BadStmt{}}}
+
+	_, err := Lower(program, sem.NewChecker(nil).Check(program))
+	require.ErrorIs(t, err, ErrorBadNode)
+}
+
+func TestParse(t *testing.T) {
+	listing := `inteiro A
+real t1
+literal C
+; the temporaries get the type of their value
+	leia A
+L1:
+	ifFalse A > 0 goto L2
+	%t1 = A - 1
+	%t2 = (real) %t1
+	t1 = %t2
+	C = "fim de linha"
+	escreva "dois  espaços e \"aspas\""
+	goto L1
+L2:
+`
+	program, err := Parse(listing)
+	require.NoError(t, err)
+	require.Equal(t, []lexer.DataType{lexer.INTEGER, lexer.REAL}, program.Temporaries)
+	require.Equal(t, 2, program.Labels)
+	require.Equal(t, Operand{Kind: Variable, Name: "t1", Type: lexer.REAL}, program.Instructions[5].Dest)
+	require.Equal(t, Operand{Kind: Constant, Name: `"dois  espaços e \"aspas\""`, Type: lexer.LITERAL}, program.Instructions[7].Left)
+
+	// What is parsed is written back the same way, but the comment
+	reparsed, err := Parse(program.String())
+	require.NoError(t, err)
+	require.Equal(t, program, reparsed)
+	require.Equal(t, strings.Replace(listing, "; the temporaries get the type of their value\n", "", 1), program.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		listing string
+		message string
+	}{
+		{"undeclared variable", "leia A", "linha 1: leia A: leia espera uma variável"},
+		{"temporary read before written", "inteiro A\nA = %t1", "linha 2: A = %t1: %t1 é lido antes de ter um valor"},
+		{"declaration after instructions", "inteiro A\nleia A\ninteiro B", "linha 3: inteiro B: declaração após as instruções"},
+		{"unknown instruction", "inteiro A\nA <- 1", "linha 2: A <- 1: instrução desconhecida"},
+		{"unterminated literal", "escreva \"oi", "linha 1: escreva \"oi: literal sem fim"},
+		{"invalid label", "goto fim", "linha 1: goto fim: rótulo inválido: fim"},
+		{"temporary with two types", "%t1 = 1\n%t1 = 2.0", "linha 2: %t1 = 2.0: %t1 já tem outro tipo"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Parse(testCase.listing)
+			require.ErrorIs(t, err, ErrorInvalidIR)
+			require.Equal(t, ErrorInvalidIR.Error()+": "+testCase.message, err.Error())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package ir
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+)
+
+var ErrorBadNode = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+
+type lowering struct {
+	info    *sem.Info
+	program *Program
+	types   map[string]lexer.DataType
+	err     error
+}
+
+// Lower lowers program to three-address code. info holds what the sem
+// package found on it, which must have been without errors
+func Lower(program *ast.Program, info *sem.Info) (*Program, error) {
+	l := &lowering{info: info, program: &Program{}, types: make(map[string]lexer.DataType)}
+	for _, declaration := range append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...) {
+		l.program.Declarations = append(l.program.Declarations, Declaration{declaration.Name.Name, declaration.Type})
+		l.types[declaration.Name.Name] = declaration.Type
+	}
+	l.stmts(program.Body)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.program, nil
+}
+
+func (l *lowering) emit(instruction Instruction) {
+	l.program.Instructions = append(l.program.Instructions, instruction)
+}
+
+func (l *lowering) temporary(dataType lexer.DataType) Operand {
+	l.program.Temporaries = append(l.program.Temporaries, dataType)
+	return Operand{Kind: Temporary, Temporary: len(l.program.Temporaries), Type: dataType}
+}
+
+func (l *lowering) label() Label {
+	l.program.Labels++
+	return Label(l.program.Labels)
+}
+
+func (l *lowering) variable(ident *ast.Ident) Operand {
+	return Operand{Kind: Variable, Name: ident.Name, Type: l.types[ident.Name]}
+}
+
+// convert returns operand with the type dataType,
+// converting it on a new temporary if needed
+func (l *lowering) convert(operand Operand, dataType lexer.DataType) Operand {
+	if operand.Type == dataType || operand.Type == lexer.LITERAL || dataType == lexer.LITERAL {
+		return operand
+	}
+	converted := l.temporary(dataType)
+	l.emit(Instruction{Op: Convert, Dest: converted, Left: operand})
+	return converted
+}
+
+func (l *lowering) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		l.stmt(stmt)
+	}
+}
+
+func (l *lowering) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		l.emit(Instruction{Op: Read, Dest: l.variable(node.Target)})
+	case *ast.Write:
+		l.emit(Instruction{Op: Write, Left: l.expr(node.Value)})
+	case *ast.Assign:
+		target := l.variable(node.Target)
+		l.emit(Instruction{Op: Copy, Dest: target, Left: l.convert(l.expr(node.Value), target.Type)})
+	case *ast.If:
+		otherwise := l.label()
+		l.condition(node.Condition, otherwise)
+		l.stmts(node.Body)
+		if node.Else == nil {
+			l.emit(Instruction{Op: Mark, Label: otherwise})
+			return
+		}
+		end := l.label()
+		l.emit(Instruction{Op: Jump, Label: end})
+		l.emit(Instruction{Op: Mark, Label: otherwise})
+		l.stmts(node.Else)
+		l.emit(Instruction{Op: Mark, Label: end})
+	case *ast.While:
+		start, end := l.label(), l.label()
+		l.emit(Instruction{Op: Mark, Label: start})
+		l.condition(node.Condition, end)
+		l.stmts(node.Body)
+		l.emit(Instruction{Op: Jump, Label: start})
+		l.emit(Instruction{Op: Mark, Label: end})
+	default:
+		l.err = ErrorBadNode
+	}
+}
+
+// condition emits the jump to otherwise when condition does not hold
+func (l *lowering) condition(condition ast.Expr, otherwise Label) {
+	comparison, isBinary := condition.(*ast.BinaryExpr)
+	if !isBinary {
+		l.err = ErrorBadNode
+		return
+	}
+	left, right := l.operands(comparison)
+	l.emit(Instruction{Op: JumpUnless, Left: left, Right: right, Operator: comparison.Operator, Label: otherwise})
+}
+
+// operands lowers the operands of node, with the same type
+func (l *lowering) operands(node *ast.BinaryExpr) (Operand, Operand) {
+	left, right := l.expr(node.Left), l.expr(node.Right)
+	// The sem package promotes one of the operands, unless
+	// the tree was folded after it, so it is done here too
+	if left.Type == lexer.REAL || right.Type == lexer.REAL {
+		return l.convert(left, lexer.REAL), l.convert(right, lexer.REAL)
+	}
+	return left, right
+}
+
+// expr emits the instructions computing expr, returning their result
+func (l *lowering) expr(expr ast.Expr) Operand {
+	var result Operand
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		left, right := l.operands(node)
+		result = l.temporary(left.Type)
+		l.emit(Instruction{Op: Binary, Dest: result, Left: left, Right: right, Operator: node.Operator, Pos: node.Pos()})
+	case *ast.Literal:
+		result = Operand{Kind: Constant, Name: node.Value, Type: node.Type}
+	case *ast.Ident:
+		result = l.variable(node)
+	default:
+		l.err = ErrorBadNode
+		return Operand{}
+	}
+
+	if conversion, found := l.info.Conversions[expr]; found {
+		return l.convert(result, conversion)
+	}
+	return result
+}
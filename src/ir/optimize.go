@@ -0,0 +1,56 @@
+package ir
+
+import "fmt"
+
+var ErrorUnknownLevel = fmt.Errorf("nível de otimização desconhecido")
+
+// Level tells which optimizations Optimize runs, trading
+// how long they take and how much the code still looks like
+// the source for how small and fast it gets
+type Level int
+
+const (
+	// O0 leaves the code as it was lowered
+	O0 Level = iota
+	// O1 folds and propagates the constants
+	O1
+	// O2 also removes the dead code and cleans up with Peephole
+	O2
+)
+
+var levelNames = map[Level]string{
+	O0: "0",
+	O1: "1",
+	O2: "2",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns the level named name, as written by String
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return O0, fmt.Errorf("%w: %s", ErrorUnknownLevel, name)
+}
+
+// Optimize runs the optimizations of level on program: its constants
+// are propagated, what was left without use is removed and the rest is
+// cleaned up by Peephole, which may leave more blocks unreachable
+func Optimize(program *Program, level Level) (*Program, Statistics) {
+	var statistics Statistics
+	if level >= O1 {
+		program = PropagateConstants(program)
+	}
+	if level >= O2 {
+		eliminated, removed := EliminateDeadCode(program)
+		statistics.Add(removed)
+		program, removed = EliminateDeadCode(Peephole(eliminated))
+		statistics.Add(removed)
+	}
+	return program, statistics
+}
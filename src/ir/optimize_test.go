@@ -0,0 +1,63 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	// At O2 the comparison of the constant leaves the block of senao
+	// unreachable, the temporaries are left without use and the
+	// jump of the end of the block goes to the next label
+	listing := `inteiro A
+	A = 2
+	ifFalse A > 1 goto L1
+	%t1 = A * 10
+	escreva %t1
+	goto L2
+L1:
+	%t2 = A - 1
+	escreva %t2
+L2:
+`
+	testCases := []struct {
+		level      Level
+		after      string
+		statistics Statistics
+	}{
+		{O0, listing, Statistics{}},
+		{O1, `inteiro A
+	A = 2
+	escreva 20
+	goto L2
+L1:
+	%t2 = A - 1
+	escreva %t2
+L2:
+`, Statistics{}},
+		{O2, `inteiro A
+	escreva 20
+`, Statistics{Unused: 1, Blocks: 1, Unreachable: 3}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.level.String(), func(t *testing.T) {
+			before, err := Parse(listing)
+			require.NoError(t, err)
+
+			after, statistics := Optimize(before, testCase.level)
+			require.Equal(t, testCase.after, after.String())
+			require.Equal(t, testCase.statistics, statistics)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{O0, O1, O2} {
+		parsed, err := ParseLevel(level.String())
+		require.NoError(t, err)
+		require.Equal(t, level, parsed)
+	}
+	_, err := ParseLevel("3")
+	require.ErrorIs(t, err, ErrorUnknownLevel)
+}
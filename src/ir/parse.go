@@ -0,0 +1,249 @@
+package ir
+
+import (
+	"fmt"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidIR = fmt.Errorf("código de três endereços inválido")
+
+// dataTypes holds the type of each type name
+var dataTypes = map[string]lexer.DataType{
+	"inteiro": lexer.INTEGER,
+	"real":    lexer.REAL,
+	"literal": lexer.LITERAL,
+}
+
+var relational = map[string]bool{"<": true, ">": true, "<=": true, ">=": true, "=": true, "<>": true}
+
+var arithmetic = map[string]bool{"+": true, "-": true, "*": true, "/": true}
+
+// parser reads the listing of Program.String back
+type parser struct {
+	program *Program
+	types   map[string]lexer.DataType
+}
+
+// Parse reads a program written like Program.String writes it, so that
+// the tests of the optimizations can be written as three-address code.
+// The lines starting with ; are comments. The type of a temporary comes
+// from the instruction writing it, and the one of a number from how it
+// is written, a real having a point like on the source
+func Parse(listing string) (*Program, error) {
+	p := &parser{program: &Program{}, types: make(map[string]lexer.DataType)}
+	for number, line := range strings.Split(listing, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		fields, err := split(line)
+		if err == nil {
+			err = p.line(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %s: %v", ErrorInvalidIR, number+1, line, err)
+		}
+	}
+	return p.program, nil
+}
+
+// split splits line on its spaces, but the ones of the literals
+func split(line string) ([]string, error) {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			fields = append(fields, line[:end])
+			line = line[end:]
+			continue
+		}
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, fmt.Errorf("literal sem fim")
+		}
+		fields = append(fields, line[:end+1])
+		line = line[end+1:]
+	}
+	return fields, nil
+}
+
+func (p *parser) line(fields []string) error {
+	emit := func(instruction Instruction) error {
+		p.program.Instructions = append(p.program.Instructions, instruction)
+		return nil
+	}
+
+	if dataType, found := dataTypes[fields[0]]; found && len(fields) == 2 {
+		if len(p.program.Instructions) > 0 {
+			return fmt.Errorf("declaração após as instruções")
+		}
+		p.program.Declarations = append(p.program.Declarations, Declaration{fields[1], dataType})
+		p.types[fields[1]] = dataType
+		return nil
+	}
+	if len(fields) == 1 && strings.HasSuffix(fields[0], ":") {
+		label, err := p.label(strings.TrimSuffix(fields[0], ":"))
+		if err != nil {
+			return err
+		}
+		return emit(Instruction{Op: Mark, Label: label})
+	}
+
+	switch {
+	case fields[0] == "goto" && len(fields) == 2:
+		label, err := p.label(fields[1])
+		if err != nil {
+			return err
+		}
+		return emit(Instruction{Op: Jump, Label: label})
+	case fields[0] == "ifFalse" && len(fields) == 6 && relational[fields[2]] && fields[4] == "goto":
+		left, err := p.operand(fields[1])
+		if err != nil {
+			return err
+		}
+		right, err := p.operand(fields[3])
+		if err != nil {
+			return err
+		}
+		label, err := p.label(fields[5])
+		if err != nil {
+			return err
+		}
+		return emit(Instruction{Op: JumpUnless, Left: left, Right: right, Operator: fields[2], Label: label})
+	case fields[0] == "leia" && len(fields) == 2:
+		dest, err := p.operand(fields[1])
+		if err != nil || dest.Kind != Variable {
+			return fmt.Errorf("leia espera uma variável")
+		}
+		return emit(Instruction{Op: Read, Dest: dest})
+	case fields[0] == "escreva" && len(fields) == 2:
+		left, err := p.operand(fields[1])
+		if err != nil {
+			return err
+		}
+		return emit(Instruction{Op: Write, Left: left})
+	case len(fields) < 3 || fields[1] != "=":
+		return fmt.Errorf("instrução desconhecida")
+	}
+
+	instruction := Instruction{Op: Copy}
+	switch {
+	case len(fields) == 3:
+	case len(fields) == 4 && strings.HasPrefix(fields[2], "(") && strings.HasSuffix(fields[2], ")"):
+		instruction.Op = Convert
+	case len(fields) == 5 && arithmetic[fields[3]]:
+		instruction.Op = Binary
+		instruction.Operator = fields[3]
+	default:
+		return fmt.Errorf("instrução desconhecida")
+	}
+
+	var err error
+	switch instruction.Op {
+	case Copy:
+		instruction.Left, err = p.operand(fields[2])
+	case Convert:
+		instruction.Left, err = p.operand(fields[3])
+	case Binary:
+		instruction.Left, err = p.operand(fields[2])
+		if err == nil {
+			instruction.Right, err = p.operand(fields[4])
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	dataType := instruction.Left.Type
+	if instruction.Op == Convert {
+		var found bool
+		if dataType, found = dataTypes[strings.Trim(fields[2], "()")]; !found {
+			return fmt.Errorf("tipo desconhecido: %s", fields[2])
+		}
+	}
+	instruction.Dest, err = p.dest(fields[0], dataType)
+	if err != nil {
+		return err
+	}
+	return emit(instruction)
+}
+
+// dest returns the operand written by an instruction, giving a
+// temporary written for the first time the type of its value
+func (p *parser) dest(field string, dataType lexer.DataType) (Operand, error) {
+	if !strings.HasPrefix(field, "%t") {
+		return p.operand(field)
+	}
+	number, err := p.temporary(field)
+	if err != nil {
+		return Operand{}, err
+	}
+	for len(p.program.Temporaries) < number {
+		p.program.Temporaries = append(p.program.Temporaries, lexer.NULL)
+	}
+	if previous := p.program.Temporaries[number-1]; previous != lexer.NULL && previous != dataType {
+		return Operand{}, fmt.Errorf("%s já tem outro tipo", field)
+	}
+	p.program.Temporaries[number-1] = dataType
+	return Operand{Kind: Temporary, Temporary: number, Type: dataType}, nil
+}
+
+func (p *parser) temporary(field string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimPrefix(field, "%t"))
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("temporário inválido: %s", field)
+	}
+	return number, nil
+}
+
+func (p *parser) operand(field string) (Operand, error) {
+	switch {
+	case strings.HasPrefix(field, "%t"):
+		number, err := p.temporary(field)
+		if err != nil {
+			return Operand{}, err
+		}
+		if number > len(p.program.Temporaries) || p.program.Temporaries[number-1] == lexer.NULL {
+			return Operand{}, fmt.Errorf("%s é lido antes de ter um valor", field)
+		}
+		return Operand{Kind: Temporary, Temporary: number, Type: p.program.Temporaries[number-1]}, nil
+	case strings.HasPrefix(field, "\""):
+		return Operand{Kind: Constant, Name: field, Type: lexer.LITERAL}, nil
+	case field[0] >= '0' && field[0] <= '9':
+		dataType := lexer.INTEGER
+		if strings.Contains(field, ".") {
+			dataType = lexer.REAL
+		}
+		if _, err := lexer.ParseNumber(field, dataType); err != nil {
+			return Operand{}, err
+		}
+		return Operand{Kind: Constant, Name: field, Type: dataType}, nil
+	}
+	dataType, found := p.types[field]
+	if !found {
+		return Operand{}, fmt.Errorf("variável não declarada: %s", field)
+	}
+	return Operand{Kind: Variable, Name: field, Type: dataType}, nil
+}
+
+func (p *parser) label(field string) (Label, error) {
+	number, err := strconv.Atoi(strings.TrimPrefix(field, "L"))
+	if !strings.HasPrefix(field, "L") || err != nil || number < 1 {
+		return 0, fmt.Errorf("rótulo inválido: %s", field)
+	}
+	if number > p.program.Labels {
+		p.program.Labels = number
+	}
+	return Label(number), nil
+}
@@ -0,0 +1,111 @@
+package ir
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrorDuplicatePass = fmt.Errorf("já há uma passagem com esse nome")
+	ErrorUnknownPass   = fmt.Errorf("passagem de otimização desconhecida")
+)
+
+// Pass is an optimization run by name with RunPasses. Like the
+// backends, one defined out of this tree registers itself on the
+// init function of its package, so it runs on the pipeline once
+// imported, or loaded as a plugin, without changing the compiler
+type Pass interface {
+	// Name is how the pass is selected, like "peephole"
+	Name() string
+	// Run returns program optimized, along with what was removed.
+	// program is not changed, as it may be used by other targets
+	Run(program *Program) (*Program, Statistics)
+}
+
+// passFunc is a Pass made of a function
+type passFunc struct {
+	name string
+	run  func(program *Program) (*Program, Statistics)
+}
+
+func (p passFunc) Name() string {
+	return p.name
+}
+
+func (p passFunc) Run(program *Program) (*Program, Statistics) {
+	return p.run(program)
+}
+
+// NewPass returns a pass named name that runs run
+func NewPass(name string, run func(program *Program) (*Program, Statistics)) Pass {
+	return passFunc{name, run}
+}
+
+var (
+	passMutex sync.RWMutex
+	passes    = make(map[string]Pass)
+)
+
+// The optimizations of the levels can be run on their own as well
+func init() {
+	RegisterPass(NewPass("propagate", func(program *Program) (*Program, Statistics) {
+		return PropagateConstants(program), Statistics{}
+	}))
+	RegisterPass(NewPass("dead-code", EliminateDeadCode))
+	RegisterPass(NewPass("peephole", func(program *Program) (*Program, Statistics) {
+		return Peephole(program), Statistics{}
+	}))
+}
+
+// RegisterPass adds pass to the registry. It panics when the
+// name was already registered, like backend.Register
+func RegisterPass(pass Pass) {
+	passMutex.Lock()
+	defer passMutex.Unlock()
+	if _, found := passes[pass.Name()]; found {
+		panic(fmt.Errorf("%w: %s", ErrorDuplicatePass, pass.Name()))
+	}
+	passes[pass.Name()] = pass
+}
+
+// LookupPass returns the pass registered with name
+func LookupPass(name string) (Pass, bool) {
+	passMutex.RLock()
+	defer passMutex.RUnlock()
+	pass, found := passes[name]
+	return pass, found
+}
+
+// PassNames returns the names of the registered passes, sorted
+func PassNames() []string {
+	passMutex.RLock()
+	defer passMutex.RUnlock()
+	names := make([]string, 0, len(passes))
+	for name := range passes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunPasses runs the passes named names on program, in order, adding
+// up what they removed. No pass runs if any of them is unknown
+func RunPasses(program *Program, names []string) (*Program, Statistics, error) {
+	selected := make([]Pass, 0, len(names))
+	for _, name := range names {
+		pass, found := LookupPass(name)
+		if !found {
+			return nil, Statistics{}, fmt.Errorf("%w: %s, as disponíveis são %s", ErrorUnknownPass, name, strings.Join(PassNames(), ", "))
+		}
+		selected = append(selected, pass)
+	}
+	var statistics Statistics
+	for _, pass := range selected {
+		var removed Statistics
+		program, removed = pass.Run(program)
+		statistics.Add(removed)
+	}
+	return program, statistics, nil
+}
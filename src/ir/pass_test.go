@@ -0,0 +1,45 @@
+package ir
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPasses(t *testing.T) {
+	// A pass of a fork, dropping the writes
+	RegisterPass(NewPass("test-silence", func(program *Program) (*Program, Statistics) {
+		silenced := *program
+		silenced.Instructions = nil
+		for _, instruction := range program.Instructions {
+			if instruction.Op != Write {
+				silenced.Instructions = append(silenced.Instructions, instruction)
+			}
+		}
+		return &silenced, Statistics{Unused: len(program.Instructions) - len(silenced.Instructions)}
+	}))
+
+	before, err := Parse(`inteiro A
+	A = 2
+	%t1 = A * 10
+	escreva %t1
+`)
+	require.NoError(t, err)
+
+	after, statistics, err := RunPasses(before, []string{"propagate", "test-silence", "dead-code"})
+	require.NoError(t, err)
+	require.Equal(t, "inteiro A\n", after.String())
+	require.Equal(t, Statistics{Unused: 2}, statistics)
+
+	_, _, err = RunPasses(before, []string{"propagate", "unroll"})
+	require.ErrorIs(t, err, ErrorUnknownPass)
+	require.Equal(t, []string{"dead-code", "peephole", "propagate", "test-silence"}, PassNames())
+
+	defer func() {
+		err, isError := recover().(error)
+		require.True(t, isError)
+		require.True(t, errors.Is(err, ErrorDuplicatePass))
+	}()
+	RegisterPass(NewPass("peephole", nil))
+}
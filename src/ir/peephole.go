@@ -0,0 +1,137 @@
+package ir
+
+import "mgol-go/src/lexer"
+
+// Peephole returns program cleaned up by looking at few instructions
+// at a time: a temporary copied to a variable right after being
+// written is written on the variable instead, the jumps to jumps go
+// straight to where those go, and the jumps to the instruction after
+// them, the comparisons that always hold and the labels no jump goes
+// to are removed. A comparison that never holds becomes a goto
+func Peephole(program *Program) *Program {
+	cleaned := *program
+	cleaned.Instructions = append([]Instruction{}, program.Instructions...)
+	for changed := true; changed; {
+		cleaned.Instructions, changed = peephole(cleaned.Instructions)
+	}
+	return &cleaned
+}
+
+// peephole goes once over instructions, telling whether it changed them
+func peephole(instructions []Instruction) ([]Instruction, bool) {
+	reads := make(map[int]int)
+	marks := make(map[Label]int)
+	jumps := make(map[Label]bool)
+	for index, instruction := range instructions {
+		for _, operand := range []Operand{instruction.Left, instruction.Right} {
+			if operand.Kind == Temporary {
+				reads[operand.Temporary]++
+			}
+		}
+		switch instruction.Op {
+		case Mark:
+			marks[instruction.Label] = index
+		case Jump, JumpUnless:
+			jumps[instruction.Label] = true
+		}
+	}
+
+	kept := make([]Instruction, 0, len(instructions))
+	changed := false
+	for index := 0; index < len(instructions); index++ {
+		instruction := instructions[index]
+		switch instruction.Op {
+		case Copy, Binary, Convert:
+			if index+1 < len(instructions) && copiedOnly(instruction, instructions[index+1], reads) {
+				instruction.Dest = instructions[index+1].Dest
+				index++
+				changed = true
+			}
+		case Jump, JumpUnless:
+			if target := destination(instructions, marks, instruction.Label); target != instruction.Label {
+				instruction.Label, changed = target, true
+			}
+			if holds, trivial := alwaysHolds(instruction); instruction.Op == JumpUnless && trivial {
+				changed = true
+				if holds {
+					continue
+				}
+				instruction = Instruction{Op: Jump, Label: instruction.Label}
+			}
+			if fallsThrough(instructions[index+1:], instruction.Label) {
+				changed = true
+				continue
+			}
+		case Mark:
+			if !jumps[instruction.Label] {
+				changed = true
+				continue
+			}
+		}
+		kept = append(kept, instruction)
+	}
+	return kept, changed
+}
+
+// copiedOnly tells whether next only copies the temporary written
+// by instruction, which is read nowhere else, like %t1 = A; B = %t1
+func copiedOnly(instruction, next Instruction, reads map[int]int) bool {
+	return instruction.Dest.Kind == Temporary && next.Op == Copy && next.Left == instruction.Dest &&
+		next.Dest.Type == instruction.Dest.Type && reads[instruction.Dest.Temporary] == 1
+}
+
+// destination returns where a jump to label ends up, following
+// the jumps right after it. A loop of jumps ends where it started
+func destination(instructions []Instruction, marks map[Label]int, label Label) Label {
+	followed := make(map[Label]bool)
+	for !followed[label] {
+		followed[label] = true
+		index, found := marks[label]
+		if !found {
+			break
+		}
+		for index < len(instructions) && instructions[index].Op == Mark {
+			index++
+		}
+		if index == len(instructions) || instructions[index].Op != Jump {
+			break
+		}
+		label = instructions[index].Label
+	}
+	return label
+}
+
+// alwaysHolds tells whether the comparison of a conditional jump
+// is known before the program runs, and if so whether it holds: the
+// ones of constants and the ones of an operand with itself. A real
+// may not equal itself, if it was read as nan, so those are not known
+func alwaysHolds(instruction Instruction) (holds bool, known bool) {
+	if holds, isConstant := compare(instruction); isConstant {
+		return holds, true
+	}
+	left := instruction.Left
+	if left != instruction.Right || left.Type == lexer.REAL || left.Kind == Constant {
+		return false, false
+	}
+	switch instruction.Operator {
+	case "=", "<=", ">=":
+		return true, true
+	case "<", ">", "<>":
+		return false, true
+	}
+	return false, false
+}
+
+// fallsThrough tells whether the labels right after a
+// jump, on next, include the one it jumps to
+func fallsThrough(next []Instruction, label Label) bool {
+	for _, instruction := range next {
+		if instruction.Op != Mark {
+			return false
+		}
+		if instruction.Label == label {
+			return true
+		}
+	}
+	return false
+}
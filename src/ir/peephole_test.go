@@ -0,0 +1,121 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeephole(t *testing.T) {
+	testCases := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{
+			"temporaries copied once",
+			`inteiro A
+inteiro B
+real C
+	leia A
+	%t1 = A
+	B = %t1
+	%t2 = A + 1
+	A = %t2
+	%t3 = (real) A
+	C = %t3
+	%t4 = A * 2
+	B = %t4
+	escreva %t4
+`,
+			`inteiro A
+inteiro B
+real C
+	leia A
+	B = A
+	A = A + 1
+	C = (real) A
+	%t4 = A * 2
+	B = %t4
+	escreva %t4
+`,
+		},
+		{
+			"jumps to jumps",
+			`inteiro A
+	leia A
+L1:
+	ifFalse A > 0 goto L2
+	A = 0
+	goto L1
+L2:
+L3:
+	goto L1
+`,
+			`inteiro A
+	leia A
+L1:
+	ifFalse A > 0 goto L1
+	A = 0
+	goto L1
+	goto L1
+`,
+		},
+		{
+			"jumps to the next instruction",
+			`inteiro A
+	leia A
+	ifFalse A > 0 goto L1
+L1:
+	goto L3
+L2:
+L3:
+	escreva A
+`,
+			`inteiro A
+	leia A
+	escreva A
+`,
+		},
+		{
+			"trivial conditions",
+			`inteiro A
+real B
+	leia A
+	leia B
+	ifFalse A = A goto L1
+	escreva "sempre"
+	ifFalse A < A goto L1
+	escreva "nunca"
+	ifFalse B = B goto L1
+	ifFalse 1 > 2 goto L1
+	escreva "nunca"
+L1:
+	escreva A
+`,
+			`inteiro A
+real B
+	leia A
+	leia B
+	escreva "sempre"
+	goto L1
+	escreva "nunca"
+	ifFalse B = B goto L1
+	goto L1
+	escreva "nunca"
+L1:
+	escreva A
+`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			before, err := Parse(testCase.before)
+			require.NoError(t, err)
+
+			after := Peephole(before)
+			require.Equal(t, testCase.after, after.String())
+			require.Equal(t, testCase.before, before.String())
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ir
+
+import (
+	"math"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+)
+
+// PropagateConstants returns program with the constants written on the
+// temporaries and variables put in place of them where they are read,
+// up to the next label, and the instructions simplified with them: the
+// operations on constants are folded, the ones by 0 or 1 turned into
+// copies and the comparisons of constants into a jump or nothing. The
+// temporaries no longer read lose the instruction writing them
+func PropagateConstants(program *Program) *Program {
+	propagated := *program
+	propagated.Instructions = nil
+	known := make(map[string]Operand)
+	for _, instruction := range program.Instructions {
+		if instruction.Op == Mark {
+			// Other jumps may get here, with other values
+			known = make(map[string]Operand)
+		}
+		if constant, found := known[instruction.Left.String()]; found && instruction.Left.Kind != Constant {
+			instruction.Left = constant
+		}
+		if constant, found := known[instruction.Right.String()]; found && instruction.Right.Kind != Constant {
+			instruction.Right = constant
+		}
+
+		switch instruction.Op {
+		case Binary:
+			instruction = simplify(instruction)
+		case Convert:
+			if value, isNumber := numberValue(instruction.Left); isNumber {
+				instruction = Instruction{Op: Copy, Dest: instruction.Dest, Left: constant(value, instruction.Dest.Type)}
+			}
+		case JumpUnless:
+			holds, isConstant := compare(instruction)
+			if isConstant && holds {
+				continue
+			}
+			if isConstant {
+				instruction = Instruction{Op: Jump, Label: instruction.Label}
+			}
+		}
+
+		if instruction.Op == Copy || instruction.Op == Binary || instruction.Op == Convert || instruction.Op == Read {
+			delete(known, instruction.Dest.String())
+			if instruction.Op == Copy && instruction.Left.Kind == Constant {
+				known[instruction.Dest.String()] = instruction.Left
+			}
+		}
+		propagated.Instructions = append(propagated.Instructions, instruction)
+	}
+	propagated.Instructions = dropUnread(propagated.Instructions)
+	return &propagated
+}
+
+// simplify folds the arithmetic operation instruction when it can
+func simplify(instruction Instruction) Instruction {
+	left, leftNumber := numberValue(instruction.Left)
+	right, rightNumber := numberValue(instruction.Right)
+	copyOf := func(operand Operand) Instruction {
+		return Instruction{Op: Copy, Dest: instruction.Dest, Left: operand}
+	}
+
+	if leftNumber && rightNumber && isInteger(instruction.Left, left) && isInteger(instruction.Right, right) {
+		if instruction.Operator == "/" && right == 0 {
+			return instruction
+		}
+		return copyOf(constant(float64(foldInteger(instruction.Operator, int32(left), int32(right))), lexer.INTEGER))
+	}
+	if leftNumber && rightNumber {
+		var value float64
+		switch instruction.Operator {
+		case "+":
+			value = left + right
+		case "-":
+			value = left - right
+		case "*":
+			value = left * right
+		case "/":
+			if right == 0 {
+				// Left as it is, to fail when run like it would
+				return instruction
+			}
+			value = left / right
+		}
+		if instruction.Dest.Type == lexer.INTEGER {
+			value = math.Trunc(value)
+		}
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return instruction
+		}
+		return copyOf(constant(value, instruction.Dest.Type))
+	}
+
+	switch {
+	case rightNumber && right == 0 && (instruction.Operator == "+" || instruction.Operator == "-"):
+		return copyOf(instruction.Left)
+	case leftNumber && left == 0 && instruction.Operator == "+":
+		return copyOf(instruction.Right)
+	case rightNumber && right == 1 && (instruction.Operator == "*" || instruction.Operator == "/"):
+		return copyOf(instruction.Left)
+	case leftNumber && left == 1 && instruction.Operator == "*":
+		return copyOf(instruction.Right)
+	case instruction.Dest.Type == lexer.INTEGER && instruction.Operator == "*" &&
+		(leftNumber && left == 0 || rightNumber && right == 0):
+		// A real times 0 may not be 0, if it is infinite
+		return copyOf(constant(0, lexer.INTEGER))
+	}
+	return instruction
+}
+
+// foldInteger returns left operator right as an inteiro, which
+// wraps at 32 bits like the int of the C backend
+func foldInteger(operator string, left, right int32) int32 {
+	switch operator {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	}
+	return left / right
+}
+
+// isInteger tells whether operand, whose value is value, is an inteiro
+func isInteger(operand Operand, value float64) bool {
+	return operand.Type == lexer.INTEGER && value == float64(int32(value))
+}
+
+// compare returns whether the comparison of the jump holds,
+// if its operands are numeric constants
+func compare(instruction Instruction) (holds bool, isConstant bool) {
+	left, leftNumber := numberValue(instruction.Left)
+	right, rightNumber := numberValue(instruction.Right)
+	if !leftNumber || !rightNumber {
+		return false, false
+	}
+	switch instruction.Operator {
+	case "<":
+		return left < right, true
+	case ">":
+		return left > right, true
+	case "<=":
+		return left <= right, true
+	case ">=":
+		return left >= right, true
+	case "=":
+		return left == right, true
+	case "<>":
+		return left != right, true
+	}
+	return false, false
+}
+
+// numberValue returns the value of operand, if it is a numeric constant
+func numberValue(operand Operand) (float64, bool) {
+	if operand.Kind != Constant || operand.Type == lexer.LITERAL {
+		return 0, false
+	}
+	value, err := lexer.ParseNumber(operand.Name, operand.Type)
+	return value, err == nil
+}
+
+// constant returns the constant of type dataType with value. A real
+// always has a point, so the listing is read back with the same types
+func constant(value float64, dataType lexer.DataType) Operand {
+	if dataType == lexer.INTEGER {
+		value = math.Trunc(value)
+	}
+	name := strconv.FormatFloat(value, 'f', -1, 64)
+	if dataType == lexer.REAL && !strings.Contains(name, ".") {
+		name += ".0"
+	}
+	return Operand{Kind: Constant, Name: name, Type: dataType}
+}
+
+// dropUnread removes the copies of constants on temporaries never read
+func dropUnread(instructions []Instruction) []Instruction {
+	read := make(map[int]bool)
+	for _, instruction := range instructions {
+		for _, operand := range []Operand{instruction.Left, instruction.Right} {
+			if operand.Kind == Temporary {
+				read[operand.Temporary] = true
+			}
+		}
+	}
+	kept := instructions[:0]
+	for _, instruction := range instructions {
+		if instruction.Op == Copy && instruction.Dest.Kind == Temporary &&
+			instruction.Left.Kind == Constant && !read[instruction.Dest.Temporary] {
+			continue
+		}
+		kept = append(kept, instruction)
+	}
+	return kept
+}
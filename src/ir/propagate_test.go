@@ -0,0 +1,172 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagateConstants(t *testing.T) {
+	testCases := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{
+			"folded through temporaries",
+			`inteiro A
+	%t1 = 2 + 3
+	%t2 = %t1 * 4
+	A = %t2
+	escreva A
+`,
+			`inteiro A
+	A = 20
+	escreva 20
+`,
+		},
+		{
+			"integer division truncated",
+			`inteiro A
+	%t1 = 7 / 2
+	A = %t1
+`,
+			`inteiro A
+	A = 3
+`,
+		},
+		{
+			"integers wrapped at 32 bits",
+			`inteiro A
+	%t1 = 2147483647 + 1
+	%t2 = 429981696 * 429981696
+	%t3 = %t1 - %t2
+	A = %t3
+`,
+			`inteiro A
+	A = -2147483648
+`,
+		},
+		{
+			"conversions",
+			`real B
+inteiro A
+	%t1 = (real) 2
+	%t2 = %t1 / 4.0
+	B = %t2
+	%t3 = (inteiro) 2.75
+	A = %t3
+`,
+			`real B
+inteiro A
+	B = 0.5
+	A = 2
+`,
+		},
+		{
+			"identities",
+			`inteiro A
+real B
+	leia A
+	leia B
+	%t1 = A + 0
+	%t2 = 1 * %t1
+	%t3 = %t2 * 0
+	%t4 = B * 0.0
+	%t5 = %t4 / 1.0
+	B = %t5
+	A = %t3
+`,
+			`inteiro A
+real B
+	leia A
+	leia B
+	%t1 = A
+	%t2 = %t1
+	%t4 = B * 0.0
+	%t5 = %t4
+	B = %t5
+	A = 0
+`,
+		},
+		{
+			"forgotten at labels and reads",
+			`inteiro A
+	A = 1
+	escreva A
+	leia A
+	escreva A
+	A = 2
+L1:
+	escreva A
+	A = 3
+	goto L1
+`,
+			`inteiro A
+	A = 1
+	escreva 1
+	leia A
+	escreva A
+	A = 2
+L1:
+	escreva A
+	A = 3
+	goto L1
+`,
+		},
+		{
+			"constant comparisons",
+			`inteiro A
+	A = 1
+	ifFalse A > 0 goto L1
+	escreva "sim"
+	ifFalse A = 2 goto L1
+	escreva "não"
+L1:
+`,
+			`inteiro A
+	A = 1
+	escreva "sim"
+	goto L1
+	escreva "não"
+L1:
+`,
+		},
+		{
+			"division by zero kept",
+			`inteiro A
+	%t1 = 1 / 0
+	A = %t1
+`,
+			`inteiro A
+	%t1 = 1 / 0
+	A = %t1
+`,
+		},
+		{
+			"literals",
+			`literal C
+	C = "oi"
+	escreva C
+	ifFalse C = "oi" goto L1
+L1:
+`,
+			`literal C
+	C = "oi"
+	escreva "oi"
+	ifFalse "oi" = "oi" goto L1
+L1:
+`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			before, err := Parse(testCase.before)
+			require.NoError(t, err)
+
+			after := PropagateConstants(before)
+			require.Equal(t, testCase.after, after.String())
+			require.Equal(t, testCase.before, before.String())
+		})
+	}
+}
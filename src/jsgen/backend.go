@@ -0,0 +1,34 @@
+package jsgen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(jsBackend{})
+}
+
+// jsBackend is the JavaScript target on the registry of backends
+type jsBackend struct {
+	options backend.Options
+}
+
+func (jsBackend) Name() string {
+	return "js"
+}
+
+func (jsBackend) FileExtension() string {
+	return ".js"
+}
+
+func (b jsBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return GenerateWithOptions(w, program, info, b.options)
+}
+
+func (b jsBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
@@ -0,0 +1,313 @@
+// Package jsgen is a backend that writes a program as JavaScript, to
+// run on a browser without anything installed: leia is read with
+// prompt and escreva is written with console.log
+package jsgen
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrorBadNode = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+
+// readers holds the helper leia is translated to for each type
+var readers = map[lexer.DataType]string{
+	lexer.INTEGER: "leiaInteiro",
+	lexer.REAL:    "leiaReal",
+	lexer.LITERAL: "leiaLiteral",
+}
+
+// helpers holds the source of the functions leia is translated to,
+// which are only written when used. A value is read on each prompt,
+// with what can not be read as a number taken as 0, like scanf leaves it
+var helpers = map[string]string{
+	"leia": `function leia() {
+  const valor = prompt();
+  return valor === null ? "" : valor.trim();
+}`,
+	"leiaInteiro": `function leiaInteiro() {
+  return parseInt(leia(), 10) || 0;
+}`,
+	"leiaReal": `function leiaReal() {
+  return parseFloat(leia()) || 0;
+}`,
+	"leiaLiteral": `function leiaLiteral() {
+  return leia();
+}`,
+}
+
+// writer is the function escreva is translated to. console.log ends
+// each call with a new line, so the text is only written as the lines
+// end, to be written like the C backend writes it
+const writer = `let saida = "";
+
+function escreva(texto) {
+  const linhas = (saida + texto).split("\n");
+  saida = linhas.pop();
+  for (const linha of linhas) {
+    console.log(linha);
+  }
+}`
+
+// initial holds the value the variables of each type start with
+var initial = map[lexer.DataType]string{
+	lexer.INTEGER: "0",
+	lexer.REAL:    "0",
+	lexer.LITERAL: `""`,
+}
+
+// jsOperators holds the relational operators written differently in JavaScript
+var jsOperators = map[string]string{
+	"=":  "===",
+	"<>": "!==",
+}
+
+// precedences holds how tightly each operator binds its operands
+var precedences = map[string]int{
+	"*": 2,
+	"/": 2,
+	"+": 1,
+	"-": 1,
+}
+
+// reserved holds the names a variable can not have on the generated
+// program: the reserved words and the globals the program uses or declares
+var reserved = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"implements": true, "import": true, "in": true, "instanceof": true, "interface": true,
+	"let": true, "new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "static": true, "super": true,
+	"switch": true, "this": true, "throw": true, "true": true, "try": true,
+	"typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"yield": true, "arguments": true, "eval": true, "undefined": true, "NaN": true,
+	"Infinity": true, "Math": true, "String": true, "console": true, "prompt": true,
+	"parseInt": true, "parseFloat": true, "saida": true, "escreva": true, "leia": true,
+	"leiaInteiro": true, "leiaReal": true, "leiaLiteral": true,
+}
+
+// name returns the JavaScript name of the variable named name
+func name(variable string) string {
+	if reserved[variable] {
+		return variable + "_"
+	}
+	return variable
+}
+
+type generator struct {
+	info    *sem.Info
+	options backend.Options
+	body    strings.Builder
+	depth   int
+	used    map[string]bool
+	err     error
+}
+
+func (g *generator) line(format string, args ...interface{}) {
+	g.body.WriteString(strings.Repeat("  ", g.depth))
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteByte('\n')
+}
+
+// Generate writes program as a JavaScript script. info holds what
+// the sem package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	return GenerateWithOptions(w, program, info, backend.Options{})
+}
+
+// GenerateWithOptions writes program like Generate, following options
+func GenerateWithOptions(w io.Writer, program *ast.Program, info *sem.Info, options backend.Options) error {
+	g := &generator{info: info, options: options, used: make(map[string]bool)}
+	g.stmts(program.Body)
+	if g.err != nil {
+		return g.err
+	}
+
+	var source strings.Builder
+	source.WriteString("\"use strict\";\n\n")
+	for _, helper := range sortedKeys(g.used) {
+		source.WriteString(helpers[helper] + "\n\n")
+	}
+	source.WriteString(writer + "\n\n")
+
+	declarations := append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...)
+	for _, declaration := range declarations {
+		fmt.Fprintf(&source, "let %s = %s;\n", name(declaration.Name.Name), initial[declaration.Type])
+	}
+	if len(declarations) > 0 {
+		source.WriteByte('\n')
+	}
+	source.WriteString(g.body.String())
+	source.WriteString("if (saida !== \"\") {\n  console.log(saida);\n}\n")
+
+	_, err := io.WriteString(w, source.String())
+	return err
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (g *generator) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		g.stmt(stmt)
+	}
+}
+
+func (g *generator) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		reader := readers[g.info.TypeOf(node.Target)]
+		g.used[reader], g.used["leia"] = true, true
+		g.line("%s = %s();", name(node.Target.Name), reader)
+	case *ast.Write:
+		value := g.expr(node.Value, 0)
+		if g.info.TypeOf(node.Value) == lexer.REAL {
+			// The C backend writes reals with %lf
+			if _, variable := node.Value.(*ast.Ident); !variable {
+				value = "(" + value + ")"
+			}
+			value += ".toFixed(6)"
+			if g.options.DecimalComma {
+				value += `.replace(".", ",")`
+			}
+		}
+		g.line("escreva(%s);", value)
+	case *ast.Assign:
+		value := g.expr(node.Value, 0)
+		if g.info.TypeOf(node.Target) == lexer.INTEGER && g.info.TypeOf(node.Value) == lexer.REAL {
+			value = fmt.Sprintf("Math.trunc(%s)", value)
+		}
+		g.line("%s = %s;", name(node.Target.Name), value)
+	case *ast.If:
+		g.line("if (%s) {", g.expr(node.Condition, 0))
+		g.block(node.Body)
+		if node.Else != nil {
+			g.line("} else {")
+			g.block(node.Else)
+		}
+		g.line("}")
+	case *ast.While:
+		g.line("while (%s) {", g.expr(node.Condition, 0))
+		g.block(node.Body)
+		g.line("}")
+	default:
+		g.err = ErrorBadNode
+	}
+}
+
+func (g *generator) block(stmts []ast.Stmt) {
+	g.depth++
+	g.stmts(stmts)
+	g.depth--
+}
+
+// expr returns expr written in JavaScript as an operand
+// of an operation whose precedence is parent
+func (g *generator) expr(expr ast.Expr, parent int) string {
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		// Numbers are all doubles, so an inteiro is wrapped
+		// at 32 bits like a C int
+		if g.wraps(node) && node.Operator == "*" {
+			return fmt.Sprintf("Math.imul(%s, %s)", g.operand(node, node.Left, 0), g.operand(node, node.Right, 0))
+		}
+		if g.wraps(node) {
+			return fmt.Sprintf("(%s | 0)", g.binary(node, 0))
+		}
+		return g.binary(node, parent)
+	case *ast.Literal:
+		return literal(node)
+	case *ast.Ident:
+		return name(node.Name)
+	}
+	g.err = ErrorBadNode
+	return ""
+}
+
+// binary returns node written in JavaScript like expr,
+// without wrapping its own result
+func (g *generator) binary(node *ast.BinaryExpr, parent int) string {
+	own := precedences[node.Operator]
+	operator := node.Operator
+	if jsOperator, found := jsOperators[operator]; found {
+		operator = jsOperator
+	}
+	source := fmt.Sprintf("%s %s %s", g.operand(node, node.Left, own), operator, g.operand(node, node.Right, own+1))
+	// Numbers are all doubles, so the division of
+	// inteiros has its fractional part dropped
+	if node.Operator == "/" && g.info.TypeOf(node) == lexer.INTEGER {
+		return fmt.Sprintf("Math.trunc(%s)", source)
+	}
+	if own < parent {
+		return "(" + source + ")"
+	}
+	return source
+}
+
+// operand returns the operand of node written with the precedence
+// parent. A sum of inteiros is exact on a double, so it is left for
+// node to wrap when node is wrapped too
+func (g *generator) operand(node *ast.BinaryExpr, operand ast.Expr, parent int) string {
+	if child, isBinary := operand.(*ast.BinaryExpr); isBinary && g.wraps(node) && g.wraps(child) && child.Operator != "*" {
+		return g.binary(child, parent)
+	}
+	return g.expr(operand, parent)
+}
+
+// wraps tells whether node is a +, - or * on inteiros, whose result
+// may not fit in 32 bits. The quotient of two inteiros always fits
+func (g *generator) wraps(node *ast.BinaryExpr) bool {
+	return node.Operator != "/" && precedences[node.Operator] > 0 && g.info.TypeOf(node) == lexer.INTEGER
+}
+
+// literal returns the JavaScript constant of a literal or number
+func literal(node *ast.Literal) string {
+	switch node.Type {
+	case lexer.LITERAL:
+		return quote(lexer.LiteralText(node.Value))
+	case lexer.INTEGER, lexer.REAL:
+		value, err := lexer.ParseNumber(node.Value, node.Type)
+		if err == nil {
+			return strconv.FormatFloat(value, 'g', -1, 64)
+		}
+	}
+	return node.Value
+}
+
+// quote returns text as a JavaScript string literal
+func quote(text string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range text {
+		switch {
+		case r == '"' || r == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(r)
+		case r == '\n':
+			quoted.WriteString(`\n`)
+		case r == '\t':
+			quoted.WriteString(`\t`)
+		case r < ' ' || r == 0x7f || r == 0x2028 || r == 0x2029:
+			fmt.Fprintf(&quoted, `\u%04x`, r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
@@ -0,0 +1,96 @@
+package jsgen
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// inteiro A; real B; literal new;
+	// leia A;
+	// leia new;
+	// repita (A > 0)
+	//	se (new <> "fim") entao escreva "sim\n"; senao B <- (A + 1) / 2; fimse
+	//	A <- A / 2 - 25E-1;
+	// fimrepita
+	// escreva B * 2;
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			asttest.Declaration(lexer.INTEGER, "A"),
+			asttest.Declaration(lexer.REAL, "B"),
+			asttest.Declaration(lexer.LITERAL, "new"),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.Read{Target: asttest.Ident("new")},
+			&ast.While{Condition: asttest.Binary(">", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)), Body: []ast.Stmt{
+				&ast.If{
+					Condition: asttest.Binary("<>", asttest.Ident("new"), asttest.Literal(`"fim"`, lexer.LITERAL)),
+					Body:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"sim\n"`, lexer.LITERAL)}},
+					Else: []ast.Stmt{&ast.Assign{
+						Target: asttest.Ident("B"),
+						Value:  asttest.Binary("/", asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER)), asttest.Literal("2", lexer.INTEGER)),
+					}},
+				},
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("-", asttest.Binary("/", asttest.Ident("A"), asttest.Literal("2", lexer.INTEGER)), asttest.Literal("25E-1", lexer.INTEGER))},
+			}},
+			&ast.Write{Value: asttest.Binary("*", asttest.Ident("B"), asttest.Literal("2", lexer.INTEGER))},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	require.Equal(t, `"use strict";
+
+function leia() {
+  const valor = prompt();
+  return valor === null ? "" : valor.trim();
+}
+
+function leiaInteiro() {
+  return parseInt(leia(), 10) || 0;
+}
+
+function leiaLiteral() {
+  return leia();
+}
+
+`+writer+`
+
+let A = 0;
+let B = 0;
+let new_ = "";
+
+A = leiaInteiro();
+new_ = leiaLiteral();
+while (A > 0) {
+  if (new_ !== "fim") {
+    escreva("sim\\n");
+  } else {
+    B = Math.trunc((A + 1 | 0) / 2);
+  }
+  A = (Math.trunc(A / 2) - 2 | 0);
+}
+escreva((B * 2).toFixed(6));
+if (saida !== "") {
+  console.log(saida);
+}
+`, out.String())
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.Write{Value: &ast.BadExpr{}}},
+	}
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, sem.NewChecker(nil).Check(program)), ErrorBadNode)
+}
@@ -0,0 +1,218 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+)
+
+func letterGenerator() []Symbol {
+	result := []Symbol{}
+
+	for i := 'a'; i <= 'z'; i++ {
+		result = append(result, Symbol(i))
+	}
+
+	for i := 'A'; i <= 'Z'; i++ {
+		result = append(result, Symbol(i))
+	}
+
+	return result
+}
+
+func numGenerator() []Symbol {
+	result := []Symbol{}
+
+	for i := '0'; i <= '9'; i++ {
+		result = append(result, Symbol(i))
+	}
+
+	return result
+}
+
+var (
+	letters = letterGenerator()
+	numbers = numGenerator()
+)
+
+func flatten(symbols [][]Symbol) []Symbol {
+	result := []Symbol{}
+
+	for _, arr := range symbols {
+		result = append(result, arr...)
+	}
+
+	return result
+}
+
+// symbolSet is what a transition of the automaton reads, with
+// how it is shown on its drawing
+type symbolSet struct {
+	label   string
+	symbols []Symbol
+}
+
+// symbolsOf returns the set of the characters of text,
+// shown apart from each other
+func symbolsOf(text string) symbolSet {
+	return symbolSet{strings.Join(strings.Split(text, ""), " "), []Symbol(text)}
+}
+
+var (
+	letterSet     = symbolSet{"letra", letters}
+	digitSet      = symbolSet{"dígito", numbers}
+	identifierSet = symbolSet{"letra, dígito, _", flatten([][]Symbol{letters, numbers, {'_'}})}
+	// The comments and literals take any symbol of the
+	// alphabet on their line, up to the one closing them
+	commentSet = symbolSet{"outro, exceto }", flatten([][]Symbol{
+		letters,
+		numbers,
+		{'\t', ' ', '_', '+', '-', '*', '/', '>', '<', '=', '{', '(', ')', ';', '"', '.', ':', ',', '!', '?', '[', ']', '\\'},
+	})}
+	literalSet = symbolSet{`outro, exceto "`, flatten([][]Symbol{
+		letters,
+		numbers,
+		{'\t', ' ', '_', '+', '-', '*', '/', '>', '<', '=', '{', '}', '(', ')', ';', '.', ':', ',', '!', '?', '[', ']', '\\'},
+	})}
+)
+
+// automatonState is a row of the table of the states of the
+// automaton: the class of the token recognized when the scanner
+// stops on it, none for the states that are not final, and the
+// type of the numbers recognized there
+type automatonState struct {
+	state    State
+	class    TokenClass
+	dataType DataType
+}
+
+// automatonTransition is a row of the table of the transitions
+type automatonTransition struct {
+	from, to State
+	reading  symbolSet
+}
+
+// The automaton of the scanner, as data. The tables below are the
+// states and the transitions of the automaton presented on the
+// course, from which the scanner, the patterns of the tokens and its
+// drawing are all built, so changing the language is changing them
+var (
+	automatonStates = []automatonState{
+		{0, "", ""},
+		{1, IDENTIFIER, ""},
+		{2, NUM, INTEGER},
+		{3, "", ""},
+		{4, NUM, REAL},
+		{5, "", ""},
+		{6, "", ""},
+		{7, NUM, INTEGER},
+		{8, REL_OP, ""},
+		{9, REL_OP, ""},
+		{10, REL_OP, ""},
+		{11, REL_OP, ""},
+		{12, REL_OP, ""},
+		{13, ATTR, ""},
+		{14, ARIT_OP, ""},
+		{15, OPEN_PAR, ""},
+		{16, CLOSE_PAR, ""},
+		{17, SEMICOLON, ""},
+		{19, "", ""},
+		{20, COMMENT, ""},
+		{21, "", ""},
+		{22, LITERAL_CONST, ""},
+		{23, "", ""},
+		{24, "", ""},
+		{25, NUM, REAL},
+	}
+	automatonTransitions = []automatonTransition{
+		{0, 1, letterSet},
+		{0, 2, digitSet},
+		{0, 8, symbolsOf("<")},
+		{0, 10, symbolsOf(">")},
+		{0, 12, symbolsOf("=")},
+		{0, 14, symbolsOf("+-*/")},
+		{0, 15, symbolsOf("(")},
+		{0, 16, symbolsOf(")")},
+		{0, 17, symbolsOf(";")},
+		{0, 19, symbolsOf("{")},
+		{0, 21, symbolsOf(`"`)},
+		{1, 1, identifierSet},
+		{2, 2, digitSet},
+		{2, 3, symbolsOf(".")},
+		{2, 5, symbolsOf("eE")},
+		{3, 4, digitSet},
+		{4, 4, digitSet},
+		{4, 23, symbolsOf("eE")},
+		{5, 6, symbolsOf("+-")},
+		{5, 7, digitSet},
+		{6, 7, digitSet},
+		{7, 7, digitSet},
+		{8, 9, symbolsOf(">=")},
+		{8, 13, symbolsOf("-")},
+		{10, 11, symbolsOf("=")},
+		{19, 19, commentSet},
+		{19, 20, symbolsOf("}")},
+		{21, 21, literalSet},
+		{21, 22, symbolsOf(`"`)},
+		{23, 24, symbolsOf("+-")},
+		{23, 25, digitSet},
+		{24, 25, digitSet},
+		{25, 25, digitSet},
+	}
+)
+
+var (
+	alphabet = flatten([][]Symbol{
+		letters,
+		numbers,
+		{
+			'\n', '\t', ' ',
+			'_', '+', '-', '*', '/',
+			'>', '<', '=', '{', '}',
+			'(', ')', ';', '"', '.',
+			'E', 'e', ':', ',', '!',
+			'?', '[', ']', '\\',
+		},
+	})
+	states, finalStates, stateToTokenClassMap, numericTypes = automatonStateTables(automatonStates)
+	transitionMap                                           = automatonTransitionMap(automatonTransitions)
+)
+
+// automatonStateTables returns the states of the rows, the final
+// ones and the class and type of what is recognized on them
+func automatonStateTables(rows []automatonState) ([]State, []State, map[State]TokenClass, map[State]DataType) {
+	all, final := []State{}, []State{}
+	classes, types := map[State]TokenClass{}, map[State]DataType{}
+	for _, row := range rows {
+		all = append(all, row.state)
+		if row.class != "" {
+			final = append(final, row.state)
+			classes[row.state] = row.class
+		}
+		if row.dataType != "" {
+			types[row.state] = row.dataType
+		}
+	}
+	return all, final, classes, types
+}
+
+// automatonTransitionMap returns the transitions leaving each state,
+// in the order of the rows
+func automatonTransitionMap(rows []automatonTransition) map[State][]Transition {
+	transitions := map[State][]Transition{}
+	for _, row := range rows {
+		transitions[row.from] = append(transitions[row.from], Transition{from: row.from, to: row.to, reading: row.reading.symbols, label: row.reading.label})
+	}
+	return transitions
+}
+
+// ScannerDft returns a new automaton of the scanner of mgol,
+// on its initial state
+func ScannerDft() *Dft {
+	return DefaultLanguage().ScannerDft()
+}
+
+// WriteAutomatonDOT draws the automaton of the scanner of mgol on the
+// Graphviz DOT language, each final state with the class of its tokens
+func WriteAutomatonDOT(w io.Writer) error {
+	return DefaultLanguage().WriteAutomatonDOT(w)
+}
@@ -2,6 +2,11 @@ package lexer
 
 import (
 	"fmt"
+	"io"
+	"mgol-go/src/dot"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -9,15 +14,23 @@ var (
 	ErrorInvalidFinalStateSet   = fmt.Errorf("provided an invalid final state set")
 	ErrorNotFinalState          = fmt.Errorf("provided state does not represent a final state")
 	ErrorTransitionDoesNotExist = fmt.Errorf("transition does not exist")
+	ErrorInvalidState           = fmt.Errorf("provided a negative state")
+	ErrorNondeterministic       = fmt.Errorf("a state reads the same symbol on more than one transition")
 )
 
 type State int
 type Symbol byte
 
+// noTransition marks the symbols a state has no transition on
+const noTransition State = -1
+
 type Transition struct {
 	from    State
 	to      State
 	reading []Symbol
+	// label is how the symbols are shown on the drawing of
+	// the automaton, listed by their ranges when empty
+	label string
 }
 
 type Dft struct {
@@ -27,6 +40,10 @@ type Dft struct {
 	finalStates   []State
 	transitionMap map[State][]Transition
 	currentState  State
+	// next holds the state reached from each state on each
+	// symbol, on the row of the state, or noTransition
+	next  [][256]State
+	final []bool
 }
 
 func NewDft(alphabet []Symbol, states []State, initialState State, finalStates []State, transitionMap map[State][]Transition) (*Dft, error) {
@@ -38,6 +55,42 @@ func NewDft(alphabet []Symbol, states []State, initialState State, finalStates [
 		return &Dft{}, ErrorInvalidFinalStateSet
 	}
 
+	rows := 0
+	for _, state := range states {
+		if state < 0 {
+			return &Dft{}, fmt.Errorf("%w: %d", ErrorInvalidState, state)
+		}
+		if int(state) >= rows {
+			rows = int(state) + 1
+		}
+	}
+	next := make([][256]State, rows)
+	for index := range next {
+		for symbol := range next[index] {
+			next[index][symbol] = noTransition
+		}
+	}
+	for from, transitions := range transitionMap {
+		for _, transition := range transitions {
+			if !ContainsState(states, from) || !ContainsState(states, transition.to) {
+				return &Dft{}, fmt.Errorf("%w: %d -> %d", ErrorInvalidState, from, transition.to)
+			}
+			for _, symbol := range transition.reading {
+				if next[from][symbol] != noTransition && next[from][symbol] != transition.to {
+					return &Dft{}, fmt.Errorf("%w: %d em %q", ErrorNondeterministic, from, rune(symbol))
+				}
+				next[from][symbol] = transition.to
+			}
+		}
+	}
+	final := make([]bool, rows)
+	for _, state := range finalStates {
+		if !ContainsState(states, state) {
+			return &Dft{}, ErrorInvalidFinalStateSet
+		}
+		final[state] = true
+	}
+
 	return &Dft{
 		alphabet:      alphabet,
 		states:        states,
@@ -45,47 +98,22 @@ func NewDft(alphabet []Symbol, states []State, initialState State, finalStates [
 		finalStates:   finalStates,
 		transitionMap: transitionMap,
 		currentState:  initialState,
+		next:          next,
+		final:         final,
 	}, nil
 }
 
-// Checks the existence of a certain symbol
-// inside a reading slice in a Transition
-func (d *Dft) transitionExists(char Symbol) bool {
-	possibleTransitions := d.transitionMap[d.currentState]
-	for _, transition := range possibleTransitions {
-		for _, symbol := range transition.reading {
-			if char == symbol {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func (d *Dft) getNextTransition(char Symbol) Transition {
-	possibleTransitions := d.transitionMap[d.currentState]
-	for _, transition := range possibleTransitions {
-		for _, symbol := range transition.reading {
-			if char == symbol {
-				return transition
-			}
-		}
-	}
-	return Transition{}
-}
-
 // Next updates and returns the next state when consuming
 // char in the current state. If there is no transitions
 // possible to be made, Next returns the
 // inital state and ErrorTransitionDoesNotExist
 func (d *Dft) Next(char Symbol) (State, error) {
-	if !d.transitionExists(char) {
+	next := d.next[d.currentState][char]
+	if next == noTransition {
 		return d.initialState, ErrorTransitionDoesNotExist
 	}
 
-	nextTransition := d.getNextTransition(char)
-	d.currentState = nextTransition.to
-
+	d.currentState = next
 	return d.currentState, nil
 }
 
@@ -96,9 +124,87 @@ func (d *Dft) Reset() {
 
 // IsFinalState returns whether we stopped on a final state or not
 func (d *Dft) IsFinalState() bool {
-	return ContainsState(d.finalStates, d.currentState)
+	return d.final[d.currentState]
 }
 
 func (d *Dft) GetCurrentState() State {
 	return d.currentState
 }
+
+// WriteDOT draws the automaton on the Graphviz DOT language, each
+// state named qN, with its line of stateLabels below the name, and
+// the final ones doubled. The transitions between the same states
+// are drawn as one edge, with the symbols of each of them
+func (d *Dft) WriteDOT(w io.Writer, stateLabels map[State]string) error {
+	graph := dot.NewGraph("afd")
+	start := graph.AddNode("", dot.Point)
+	nodes := make(map[State]int, len(d.states))
+	for _, state := range d.states {
+		label := "q" + strconv.Itoa(int(state))
+		if extra := stateLabels[state]; extra != "" {
+			label += "\n" + extra
+		}
+		shape := dot.Circle
+		if d.final[state] {
+			shape = dot.DoubleCircle
+		}
+		nodes[state] = graph.AddNode(label, shape)
+	}
+	graph.AddEdge(start, nodes[d.initialState], "")
+
+	for _, from := range d.states {
+		var targets []State
+		labels := make(map[State][]string)
+		for _, transition := range d.transitionMap[from] {
+			if _, found := labels[transition.to]; !found {
+				targets = append(targets, transition.to)
+			}
+			label := transition.label
+			if label == "" {
+				label = describeSymbols(transition.reading)
+			}
+			labels[transition.to] = append(labels[transition.to], label)
+		}
+		for _, to := range targets {
+			graph.AddEdge(nodes[from], nodes[to], strings.Join(labels[to], ", "))
+		}
+	}
+	return graph.Write(w)
+}
+
+// describeSymbols lists symbols by their ranges, like 0-9 +
+func describeSymbols(symbols []Symbol) string {
+	sorted := append([]Symbol(nil), symbols...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var ranges []string
+	for start := 0; start < len(sorted); {
+		end := start
+		for end+1 < len(sorted) && sorted[end+1] <= sorted[end]+1 {
+			end++
+		}
+		if end-start >= 2 {
+			ranges = append(ranges, describeSymbol(sorted[start])+"-"+describeSymbol(sorted[end]))
+		} else {
+			for index := start; index <= end; index++ {
+				if index == start || sorted[index] != sorted[index-1] {
+					ranges = append(ranges, describeSymbol(sorted[index]))
+				}
+			}
+		}
+		start = end + 1
+	}
+	return strings.Join(ranges, " ")
+}
+
+// describeSymbol writes the blanks by their escapes
+func describeSymbol(symbol Symbol) string {
+	switch symbol {
+	case ' ':
+		return `' '`
+	case '\t':
+		return `\t`
+	case '\n':
+		return `\n`
+	}
+	return string(rune(symbol))
+}
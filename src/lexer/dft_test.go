@@ -1 +1,96 @@
 package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newNumberDft returns an automaton of the integers, like 42
+func newNumberDft(t *testing.T) *Dft {
+	dft, err := NewDft(numbers, []State{0, 1}, 0, []State{1}, map[State][]Transition{
+		0: {{from: 0, to: 1, reading: numbers}},
+		1: {{from: 1, to: 1, reading: numbers, label: "dígito"}},
+	})
+	require.NoError(t, err)
+	return dft
+}
+
+func TestDftNext(t *testing.T) {
+	dft := newNumberDft(t)
+	require.False(t, dft.IsFinalState())
+	for _, char := range "42" {
+		state, err := dft.Next(Symbol(char))
+		require.NoError(t, err)
+		require.Equal(t, State(1), state)
+	}
+	require.True(t, dft.IsFinalState())
+
+	state, err := dft.Next('x')
+	require.ErrorIs(t, err, ErrorTransitionDoesNotExist)
+	require.Equal(t, State(0), state)
+	require.Equal(t, State(1), dft.GetCurrentState())
+	dft.Reset()
+	require.Equal(t, State(0), dft.GetCurrentState())
+}
+
+func TestNewDftErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		states      []State
+		finalStates []State
+		transitions map[State][]Transition
+		expected    error
+	}{
+		{"initial state", []State{1}, nil, nil, ErrorInvalidInitialState},
+		{"final state", []State{0}, []State{1}, nil, ErrorInvalidFinalStateSet},
+		{"negative state", []State{0, -1}, nil, nil, ErrorInvalidState},
+		{"unknown target", []State{0}, nil, map[State][]Transition{0: {{from: 0, to: 3, reading: []Symbol{'a'}}}}, ErrorInvalidState},
+		{
+			"nondeterministic",
+			[]State{0, 1, 2},
+			nil,
+			map[State][]Transition{0: {{from: 0, to: 1, reading: []Symbol{'a', 'b'}}, {from: 0, to: 2, reading: []Symbol{'b'}}}},
+			ErrorNondeterministic,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewDft(alphabet, tc.states, 0, tc.finalStates, tc.transitions)
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestDftWriteDOT(t *testing.T) {
+	var text strings.Builder
+	require.NoError(t, newNumberDft(t).WriteDOT(&text, map[State]string{1: "num"}))
+	require.Equal(t, `digraph "afd" {
+	ordering="out";
+	node [fontname="monospace"];
+	n0 [label="", shape=point];
+	n1 [label="q0", shape=circle];
+	n2 [label="q1\nnum", shape=doublecircle];
+	n0 -> n1;
+	n1 -> n2 [label="0-9"];
+	n2 -> n2 [label="dígito"];
+}
+`, text.String())
+}
+
+func TestDescribeSymbols(t *testing.T) {
+	require.Equal(t, "\\t ' ' + - a-c", describeSymbols([]Symbol("c-ab+ \ta")))
+}
+
+func TestWriteAutomatonDOT(t *testing.T) {
+	var text strings.Builder
+	require.NoError(t, WriteAutomatonDOT(&text))
+	dot := text.String()
+	require.Contains(t, dot, `[label="q1\nid", shape=doublecircle]`)
+	require.Contains(t, dot, `[label="q3", shape=circle]`)
+	require.Contains(t, dot, `[label="letra, dígito, _"]`)
+	require.Contains(t, dot, `[label="e E"]`)
+	require.Equal(t, len(automatonStates)+1, strings.Count(dot, "shape="))
+}
@@ -0,0 +1,136 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// syntaxRule tells how the editors highlight the tokens of a class
+type syntaxRule struct {
+	class TokenClass
+	// scope is the name given to the tokens by TextMate grammars,
+	// which the themes of VS Code color
+	scope string
+	// group is the highlight group of Vim the tokens are linked to
+	group string
+}
+
+// syntaxRules are in the order TextMate tries them on each position,
+// so a comment is not read as the tokens inside it and <- is not
+// read as <. Vim prefers the last match instead, and reads them
+// backwards. The reserved words come between the literals and the
+// identifiers
+var syntaxRules = []syntaxRule{
+	{COMMENT, "comment.block.mgol", "Comment"},
+	{LITERAL_CONST, "string.quoted.double.mgol", "String"},
+	{IDENTIFIER, "variable.other.mgol", "Identifier"},
+	{NUM, "constant.numeric.mgol", "Number"},
+	{ATTR, "keyword.operator.assignment.mgol", "Operator"},
+	{REL_OP, "keyword.operator.comparison.mgol", "Operator"},
+	{ARIT_OP, "keyword.operator.arithmetic.mgol", "Operator"},
+	{OPEN_PAR, "punctuation.section.parens.begin.mgol", "Delimiter"},
+	{CLOSE_PAR, "punctuation.section.parens.end.mgol", "Delimiter"},
+	{SEMICOLON, "punctuation.terminator.statement.mgol", "Delimiter"},
+}
+
+// Scopes and groups of the reserved words, those naming types apart
+const (
+	keywordScope = "keyword.control.mgol"
+	typeScope    = "storage.type.mgol"
+	keywordGroup = "Keyword"
+	typeGroup    = "Type"
+)
+
+// editorKeywords returns the reserved words of mgol, sorted, split
+// into the ones naming types and the others
+func editorKeywords() (keywords, types []string) {
+	for keyword := range DefaultKeywords() {
+		switch DataType(keyword) {
+		case INTEGER, REAL, LITERAL:
+			types = append(types, keyword)
+		default:
+			keywords = append(keywords, keyword)
+		}
+	}
+	sort.Strings(keywords)
+	sort.Strings(types)
+	return keywords, types
+}
+
+type textMateGrammar struct {
+	Name      string          `json:"name"`
+	ScopeName string          `json:"scopeName"`
+	FileTypes []string        `json:"fileTypes"`
+	Patterns  []textMateMatch `json:"patterns"`
+}
+
+type textMateMatch struct {
+	Name  string `json:"name"`
+	Match string `json:"match"`
+}
+
+// WriteTextMateGrammar writes to w the TextMate grammar of mgol, which
+// VS Code and most editors use to highlight the programs. It is built
+// from the automaton of the scanner and its reserved words
+func WriteTextMateGrammar(w io.Writer) error {
+	patterns := tokenPatterns(goDialect)
+	keywords, types := editorKeywords()
+	grammar := textMateGrammar{Name: "mgol", ScopeName: "source.mgol", FileTypes: []string{"mgol"}}
+	for _, rule := range syntaxRules {
+		if rule.class == IDENTIFIER {
+			grammar.Patterns = append(grammar.Patterns,
+				textMateMatch{keywordScope, `\b(?:` + strings.Join(keywords, "|") + `)\b`},
+				textMateMatch{typeScope, `\b(?:` + strings.Join(types, "|") + `)\b`},
+			)
+		}
+		grammar.Patterns = append(grammar.Patterns, textMateMatch{rule.scope, patterns[rule.class]})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(grammar)
+}
+
+// WriteVimSyntax writes to w the syntax file of mgol for Vim, built
+// like the grammar of WriteTextMateGrammar
+func WriteVimSyntax(w io.Writer) error {
+	patterns := tokenPatterns(vimDialect)
+	keywords, types := editorKeywords()
+
+	var syntax strings.Builder
+	syntax.WriteString("\" Vim syntax file\n\" Language: mgol\n\" Generated from the scanner with: go run ./src/cmd/gensyntax -format vim\n\n")
+	syntax.WriteString("if exists(\"b:current_syntax\")\n  finish\nendif\n\n")
+	groups := []string{}
+	linked := map[string]bool{}
+	for index := len(syntaxRules) - 1; index >= 0; index-- {
+		rule := syntaxRules[index]
+		pattern := `\v` + patterns[rule.class]
+		// The pattern is delimited by a symbol it does not have
+		delimiter := "/"
+		for _, candidate := range []string{"/", "#", "!", "%", "&"} {
+			if !strings.Contains(pattern, candidate) {
+				delimiter = candidate
+				break
+			}
+		}
+		fmt.Fprintf(&syntax, "syntax match mgol%s %s%s%s\n", rule.group, delimiter, pattern, delimiter)
+		if !linked[rule.group] {
+			linked[rule.group] = true
+			groups = append(groups, rule.group)
+		}
+	}
+	fmt.Fprintf(&syntax, "syntax keyword mgol%s %s\n", keywordGroup, strings.Join(keywords, " "))
+	fmt.Fprintf(&syntax, "syntax keyword mgol%s %s\n\n", typeGroup, strings.Join(types, " "))
+
+	for _, group := range append([]string{keywordGroup, typeGroup}, groups...) {
+		fmt.Fprintf(&syntax, "highlight default link mgol%s %s\n", group, group)
+	}
+	syntax.WriteString("\nlet b:current_syntax = \"mgol\"\n")
+
+	_, err := io.WriteString(w, syntax.String())
+	return err
+}
@@ -0,0 +1,70 @@
+package lexer
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenPatterns(t *testing.T) {
+	lexemes := map[TokenClass][]string{
+		IDENTIFIER:    {"A", "media_2", "inicio"},
+		NUM:           {"7", "3.14", "1e5", "2.5E-3", "10e+2"},
+		LITERAL_CONST: {`""`, `"Digite A: {}"`},
+		COMMENT:       {"{}", "{ leia A; }"},
+		REL_OP:        {"<", "<=", "<>", ">", ">=", "="},
+		ARIT_OP:       {"+", "-", "*", "/"},
+		ATTR:          {"<-"},
+		OPEN_PAR:      {"("},
+		CLOSE_PAR:     {")"},
+		SEMICOLON:     {";"},
+	}
+	// The scanner reads these as errors or as more than one token
+	invalid := []string{"3.", "1e", "_A", "{ \n }", `"a`, "<<", "=="}
+
+	patterns := TokenPatterns()
+	require.Len(t, patterns, len(lexemes))
+	for class, pattern := range patterns {
+		matcher := regexp.MustCompile(`^(?:` + pattern + `)$`)
+		for otherClass, examples := range lexemes {
+			for _, lexeme := range examples {
+				require.Equal(t, class == otherClass, matcher.MatchString(lexeme), "%s on the pattern %s of %s", lexeme, pattern, class)
+			}
+		}
+		for _, lexeme := range invalid {
+			require.False(t, matcher.MatchString(lexeme), "%s on the pattern %s of %s", lexeme, pattern, class)
+		}
+	}
+}
+
+// TestEditorSyntax checks the files on editors/ were
+// generated from the current scanner. -update rewrites them
+func TestEditorSyntax(t *testing.T) {
+	testCases := []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{"vscode/syntaxes/mgol.tmLanguage.json", WriteTextMateGrammar},
+		{"vim/syntax/mgol.vim", WriteVimSyntax},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			var generated strings.Builder
+			require.NoError(t, tc.write(&generated))
+
+			path := filepath.Join("..", "..", "editors", filepath.FromSlash(tc.path))
+			if *update {
+				require.NoError(t, ioutil.WriteFile(path, []byte(generated.String()), 0644))
+			}
+			expected, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), generated.String(), "rode go test ./src/lexer -run TestEditorSyntax -update")
+		})
+	}
+}
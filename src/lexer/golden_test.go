@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files with the current output:
+//
+//	go test ./src/lexer -run TestGolden -update
+var update = flag.Bool("update", false, "reescreve os arquivos .golden de testdata")
+
+// tokensDump lists the tokens read from file, one per line
+func tokensDump(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+	scanner := NewScanner(file, symbolTable)
+
+	dump := &strings.Builder{}
+	for {
+		token, line, column := scanner.Scan()
+		fmt.Fprintf(dump, "%d:%d\t%s\t%q\t%s\n", line, column, token.GetClass(), token.GetLexem(), token.GetType())
+		if token == EOF_TOKEN {
+			return dump.String()
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "*.mgol"))
+	require.NoError(t, err)
+	require.NotEmpty(t, sources)
+
+	for _, source := range sources {
+		source := source
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			dump := tokensDump(t, source)
+
+			golden := strings.TrimSuffix(source, ".mgol") + ".golden"
+			if *update {
+				require.NoError(t, ioutil.WriteFile(golden, []byte(dump), 0644))
+			}
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err, "rode com -update para criar %s", golden)
+			require.Equal(t, string(expected), dump)
+		})
+	}
+}
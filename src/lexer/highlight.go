@@ -0,0 +1,147 @@
+package lexer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	errorhandling "mgol-go/src/error_handling"
+	"strings"
+)
+
+var ErrorUnknownHighlightFormat = fmt.Errorf("formato de destaque desconhecido")
+
+type HighlightFormat string
+
+// Available formats of the highlighted source
+const (
+	HTMLHighlight HighlightFormat = "html"
+	ANSIHighlight HighlightFormat = "ansi"
+)
+
+// Categories of the tokens, each with its own color. They are the
+// classes of the spans on html, prefixed with mgol-
+const (
+	keywordCategory     = "palavra-chave"
+	identifierCategory  = "identificador"
+	numberCategory      = "numero"
+	literalCategory     = "literal"
+	commentCategory     = "comentario"
+	operatorCategory    = "operador"
+	punctuationCategory = "pontuacao"
+	errorCategory       = "erro"
+)
+
+// ansiColors are the escape sequences that color each category
+// on a terminal. Identifiers and punctuation are left as they are
+var ansiColors = map[string]string{
+	keywordCategory:  "\x1b[1;34m",
+	numberCategory:   "\x1b[35m",
+	literalCategory:  "\x1b[32m",
+	commentCategory:  "\x1b[90m",
+	operatorCategory: "\x1b[33m",
+	errorCategory:    "\x1b[4;31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// HighlightCSS is a style sheet for the html written by Highlight,
+// for the pages that have none of their own
+const HighlightCSS = `pre.mgol { background: #f8f8f8; padding: 0.5em; tab-size: 4; }
+.mgol-palavra-chave { color: #00509d; font-weight: bold; }
+.mgol-numero { color: #8e24aa; }
+.mgol-literal { color: #2e7d32; }
+.mgol-comentario { color: #757575; font-style: italic; }
+.mgol-operador { color: #b26a00; }
+.mgol-erro { color: #c62828; text-decoration: underline wavy; }
+`
+
+// highlightCategory returns the category of token
+func highlightCategory(token Token) string {
+	switch token.class {
+	case IDENTIFIER:
+		return identifierCategory
+	case NUM:
+		return numberCategory
+	case LITERAL_CONST:
+		return literalCategory
+	case COMMENT:
+		return commentCategory
+	case REL_OP, ARIT_OP, ATTR:
+		return operatorCategory
+	case OPEN_PAR, CLOSE_PAR, SEMICOLON:
+		return punctuationCategory
+	case ERROR:
+		return errorCategory
+	}
+	// The reserved words are the only tokens with a class of their own
+	return keywordCategory
+}
+
+// Highlight writes source to w with its tokens colored by their class,
+// as an html <pre> whose spans have a class for each category, or as
+// text with the colors of a terminal. The whitespace and the lexical
+// errors are kept, so any program can be shown as it was written
+func Highlight(w io.Writer, source string, format HighlightFormat) error {
+	var style func(category, text string) string
+	switch format {
+	case HTMLHighlight:
+		style = func(category, text string) string {
+			if category == "" {
+				return html.EscapeString(text)
+			}
+			return fmt.Sprintf(`<span class="mgol-%s">%s</span>`, category, html.EscapeString(text))
+		}
+	case ANSIHighlight:
+		style = func(category, text string) string {
+			if color, found := ansiColors[category]; found {
+				return color + text + ansiReset
+			}
+			return text
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnknownHighlightFormat, format)
+	}
+
+	symbolTable := NewSymbolTable()
+	symbolTable.SetReservedWords(DefaultReservedWords())
+	scanner := NewStringScanner(source, symbolTable)
+	// The errors are only colored, as the program is not being compiled
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(errorhandling.Diagnostic) {}))
+	scanner.SetKeywordCaseWarning(false)
+
+	var highlighted strings.Builder
+	// written is the offset of the first byte of source not written yet
+	written := 0
+	for {
+		token, _, _ := scanner.Scan()
+		if token == EOF_TOKEN {
+			break
+		}
+		start, end := scanner.TokenOffsets()
+		if int(start) < written {
+			start = int64(written)
+		}
+		if int(end) > len(source) {
+			end = int64(len(source))
+		}
+		// The offsets of an error may start on the whitespace before it
+		for start < end && strings.IndexByte(" \t\r\n", source[start]) >= 0 {
+			start++
+		}
+		if end <= start {
+			continue
+		}
+		// What is between the tokens is whitespace
+		highlighted.WriteString(style("", source[written:start]))
+		highlighted.WriteString(style(highlightCategory(token), source[start:end]))
+		written = int(end)
+	}
+	highlighted.WriteString(style("", source[written:]))
+
+	if format == HTMLHighlight {
+		_, err := fmt.Fprintf(w, "<pre class=\"mgol\"><code>%s</code></pre>\n", highlighted.String())
+		return err
+	}
+	_, err := io.WriteString(w, highlighted.String())
+	return err
+}
@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		format        HighlightFormat
+		expected      string
+		expectedError error
+	}{
+		{
+			name:   "html",
+			source: "{ dobro }\nA <- 2 * A;\tescreva \"<fim>\";",
+			format: HTMLHighlight,
+			expected: `<pre class="mgol"><code><span class="mgol-comentario">{ dobro }</span>` + "\n" +
+				`<span class="mgol-identificador">A</span> <span class="mgol-operador">&lt;-</span> <span class="mgol-numero">2</span> ` +
+				`<span class="mgol-operador">*</span> <span class="mgol-identificador">A</span><span class="mgol-pontuacao">;</span>` + "\t" +
+				`<span class="mgol-palavra-chave">escreva</span> <span class="mgol-literal">&#34;&lt;fim&gt;&#34;</span><span class="mgol-pontuacao">;</span></code></pre>` + "\n",
+		},
+		{
+			name:     "ansi",
+			source:   "se (A > 1) entao\n",
+			format:   ANSIHighlight,
+			expected: "\x1b[1;34mse\x1b[0m (A \x1b[33m>\x1b[0m \x1b[35m1\x1b[0m) \x1b[1;34mentao\x1b[0m\n",
+		},
+		{
+			name:     "lexical errors",
+			source:   "B @ C;\nD <- {aberto",
+			format:   ANSIHighlight,
+			expected: "B \x1b[4;31m@\x1b[0m C;\nD \x1b[33m<-\x1b[0m \x1b[4;31m{aberto\x1b[0m",
+		},
+		{
+			name:          "unknown format",
+			source:        "fim",
+			format:        "latex",
+			expectedError: ErrorUnknownHighlightFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var highlighted strings.Builder
+			err := Highlight(&highlighted, tc.source, tc.format)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, highlighted.String())
+		})
+	}
+}
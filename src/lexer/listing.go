@@ -0,0 +1,127 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	errorhandling "mgol-go/src/error_handling"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteSourceListing writes to w the listing of the compilation of
+// source, as compilers used to print it: each line numbered and
+// followed by its diagnostics, with a mark under their column, then
+// how many tokens of each class source has and the symbol table
+func WriteSourceListing(w io.Writer, source string, diagnostics []errorhandling.Diagnostic, table *SymbolTable) error {
+	lines := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	// The ones without a line, found on the end of the file, go last
+	line := func(diagnostic errorhandling.Diagnostic) int {
+		if diagnostic.Line < 1 {
+			return math.MaxInt32
+		}
+		return diagnostic.Line
+	}
+	sorted := append([]errorhandling.Diagnostic{}, diagnostics...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if line(sorted[i]) != line(sorted[j]) {
+			return line(sorted[i]) < line(sorted[j])
+		}
+		return sorted[i].Column < sorted[j].Column
+	})
+
+	width := len(strconv.Itoa(len(lines)))
+	if width < 4 {
+		width = 4
+	}
+	var listing strings.Builder
+	next := 0
+	for index, text := range lines {
+		text = strings.TrimSuffix(text, "\r")
+		fmt.Fprintf(&listing, "%*d  %s\n", width, index+1, text)
+		for ; next < len(sorted) && line(sorted[next]) <= index+1; next++ {
+			writeListedDiagnostic(&listing, width, text, sorted[next])
+		}
+	}
+	for ; next < len(sorted); next++ {
+		writeListedDiagnostic(&listing, width, "", sorted[next])
+	}
+
+	errors, warnings := 0, 0
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == errorhandling.Error {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Fprintf(&listing, "\n%d erro(s), %d aviso(s)\n\nTokens:\n", errors, warnings)
+	if _, err := io.WriteString(w, listing.String()); err != nil {
+		return err
+	}
+	if err := writeTokenCounts(w, source); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\nTabela de símbolos:\n"); err != nil {
+		return err
+	}
+	return table.WriteListing(w)
+}
+
+// writeListedDiagnostic writes diagnostic under line, with a ^ on its
+// column. The tabs before it are kept so that the mark lines up
+func writeListedDiagnostic(listing *strings.Builder, width int, line string, diagnostic errorhandling.Diagnostic) {
+	mark := []byte{}
+	for index := 0; index < diagnostic.Column-1 && index < len(line); index++ {
+		if line[index] == '\t' {
+			mark = append(mark, '\t')
+		} else {
+			mark = append(mark, ' ')
+		}
+	}
+	code := ""
+	if diagnostic.Code != "" {
+		code = " " + diagnostic.Code
+	}
+	fmt.Fprintf(listing, "%*s  %s^ %s%s: %s", width, "", mark, diagnostic.Severity, code, diagnostic.Message)
+	if diagnostic.Count > 1 {
+		fmt.Fprintf(listing, " (%d ocorrências)", diagnostic.Count)
+	}
+	listing.WriteString("\n")
+}
+
+// writeTokenCounts writes to w how many tokens of each class source
+// has, scanned again on a table of its own so that table is kept as
+// the compiler left it
+func writeTokenCounts(w io.Writer, source string) error {
+	table := NewSymbolTable()
+	table.SetReservedWords(DefaultReservedWords())
+	scanner := NewStringScanner(source, table)
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(errorhandling.Diagnostic) {}))
+
+	counts := map[TokenClass]int{}
+	total := 0
+	for {
+		token, _, _ := scanner.Scan()
+		if token == EOF_TOKEN {
+			break
+		}
+		counts[token.class]++
+		total++
+	}
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Classe\tQuantidade")
+	for _, class := range classes {
+		fmt.Fprintf(writer, "%s\t%d\n", class, counts[TokenClass(class)])
+	}
+	fmt.Fprintf(writer, "Total\t%d\n", total)
+	return writer.Flush()
+}
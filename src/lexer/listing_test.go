@@ -0,0 +1,55 @@
+package lexer
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSourceListing(t *testing.T) {
+	source := "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n\tA<-B;\nfim\n"
+	table := NewSymbolTable()
+	table.RegisterKeywords(map[string]TokenClass{"inteiro": "inteiro"})
+	table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+	table.AddUse("A", Position{Line: 3, Column: 10})
+	table.Declare("A", INTEGER)
+	diagnostics := []errorhandling.Diagnostic{
+		errorhandling.NewDiagnostic(errorhandling.Error, 5, 5, "variável não declarada: B").WithCode("S001"),
+		errorhandling.NewDiagnostic(errorhandling.Warning, 3, 10, "variável nunca lida: A"),
+		errorhandling.NewDiagnostic(errorhandling.Error, 0, 0, "fim inesperado do arquivo"),
+	}
+
+	var listing strings.Builder
+	require.NoError(t, WriteSourceListing(&listing, source, diagnostics, table))
+	require.Equal(t, ""+
+		"   1  inicio\n"+
+		"   2  varinicio\n"+
+		"   3  \tinteiro A;\n"+
+		"      \t        ^ aviso: variável nunca lida: A\n"+
+		"   4  varfim;\n"+
+		"   5  \tA<-B;\n"+
+		"      \t   ^ erro S001: variável não declarada: B\n"+
+		"   6  fim\n"+
+		"      ^ erro: fim inesperado do arquivo\n"+
+		"\n"+
+		"2 erro(s), 1 aviso(s)\n"+
+		"\n"+
+		"Tokens:\n"+
+		"Classe     Quantidade\n"+
+		"PT_V       3\n"+
+		"RCB        1\n"+
+		"fim        1\n"+
+		"id         3\n"+
+		"inicio     1\n"+
+		"inteiro    1\n"+
+		"varfim     1\n"+
+		"varinicio  1\n"+
+		"Total      12\n"+
+		"\n"+
+		"Tabela de símbolos:\n"+
+		"Lexema   Classe   Tipo     Declaração\n"+
+		"A        id       inteiro  3\n"+
+		"inteiro  inteiro  inteiro  -\n", listing.String())
+}
@@ -0,0 +1,10 @@
+package lexer
+
+import "strings"
+
+// LiteralText returns the text of a literal read by the scanner, without
+// its quotes. mgol has no escapes, so a backslash is part of the text
+// like any other character, and every target writes it as it is
+func LiteralText(lexeme string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(lexeme, `"`), `"`)
+}
@@ -0,0 +1,31 @@
+package lexer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var ErrorInvalidNumber = fmt.Errorf("número inválido")
+
+// ParseNumber returns the value of a number read by the scanner with
+// the type dataType. Both types take an exponent, so an inteiro like
+// 25E-1 has its fractional part dropped, as it would on a C int
+func ParseNumber(lexeme string, dataType DataType) (float64, error) {
+	value, err := strconv.ParseFloat(lexeme, 64)
+	if err != nil || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%w: %s", ErrorInvalidNumber, lexeme)
+	}
+	if dataType == INTEGER {
+		value = math.Trunc(value)
+	}
+	return value, nil
+}
+
+// NumericValue returns the value of a num token
+func (t Token) NumericValue() (float64, error) {
+	if t.class != NUM {
+		return 0, fmt.Errorf("%w: %s", ErrorInvalidNumber, t.lexeme)
+	}
+	return ParseNumber(t.lexeme, t.dataType)
+}
@@ -0,0 +1,50 @@
+package lexer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		lexeme   string
+		dataType DataType
+		expected float64
+	}{
+		{"42", INTEGER, 42},
+		{"3.25", REAL, 3.25},
+		{"2E3", INTEGER, 2000},
+		{"2e+3", INTEGER, 2000},
+		{"25E-1", INTEGER, 2},
+		{"1.5E-2", REAL, 0.015},
+	}
+
+	for _, tt := range tests {
+		value, err := ParseNumber(tt.lexeme, tt.dataType)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, value, tt.lexeme)
+	}
+
+	for _, lexeme := range []string{"", "abc", "1E999"} {
+		_, err := ParseNumber(lexeme, REAL)
+		require.True(t, errors.Is(err, ErrorInvalidNumber), lexeme)
+	}
+}
+
+func TestNumericValue(t *testing.T) {
+	scanner := NewStringScanner("12E1 0.5 A", NewSymbolTable())
+
+	expected := []float64{120, 0.5}
+	for _, value := range expected {
+		token, _, _ := scanner.Scan()
+		numericValue, err := token.NumericValue()
+		require.NoError(t, err)
+		require.Equal(t, value, numericValue)
+	}
+
+	identifier, _, _ := scanner.Scan()
+	_, err := identifier.NumericValue()
+	require.True(t, errors.Is(err, ErrorInvalidNumber))
+}
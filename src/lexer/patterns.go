@@ -0,0 +1,248 @@
+package lexer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dialect is the syntax of the regular expressions of a tool
+type dialect struct {
+	// group opens a group that captures nothing
+	group string
+	// quote returns a symbol as it is written outside a character class
+	quote func(symbol Symbol) string
+	// classSpecial holds the symbols escaped inside a character class
+	classSpecial string
+}
+
+// goDialect is the syntax of the regexp package, which TextMate
+// grammars share for what the patterns use
+var goDialect = dialect{
+	group:        "(?:",
+	quote:        func(symbol Symbol) string { return regexp.QuoteMeta(string(rune(symbol))) },
+	classSpecial: `\]^-[`,
+}
+
+// vimDialect is the very magic syntax of Vim, started by \v, where
+// every symbol other than letters, digits and _ is special
+var vimDialect = dialect{
+	group: "%(",
+	quote: func(symbol Symbol) string {
+		if symbol == '_' || ContainsSymbol(letters, symbol) || ContainsSymbol(numbers, symbol) {
+			return string(rune(symbol))
+		}
+		return `\` + string(rune(symbol))
+	},
+	classSpecial: `\]^-`,
+}
+
+// pattern is a regular expression being built, with how it binds,
+// so it is only grouped when put inside a tighter operator
+type pattern struct {
+	text string
+	kind patternKind
+}
+
+type patternKind int
+
+const (
+	// emptyPattern matches only the empty string
+	emptyPattern patternKind = iota
+	// atomPattern is a character, a class or a group
+	atomPattern
+	// repeatedPattern ends with * or ?, which can not be repeated again
+	repeatedPattern
+	sequencePattern
+	alternationPattern
+)
+
+// repeat returns p followed by operator, grouped if needed
+func (d dialect) repeat(p pattern, operator string) pattern {
+	if p.kind == atomPattern {
+		return pattern{p.text + operator, repeatedPattern}
+	}
+	return pattern{d.group + p.text + ")" + operator, repeatedPattern}
+}
+
+func (d dialect) concat(patterns ...pattern) pattern {
+	result := pattern{kind: emptyPattern}
+	for _, p := range patterns {
+		switch {
+		case p.kind == emptyPattern:
+			continue
+		case result.kind == emptyPattern:
+			result = p
+		default:
+			result = pattern{d.operand(result) + d.operand(p), sequencePattern}
+		}
+	}
+	return result
+}
+
+// operand returns p as a part of a sequence
+func (d dialect) operand(p pattern) string {
+	if p.kind == alternationPattern {
+		return d.group + p.text + ")"
+	}
+	return p.text
+}
+
+func (d dialect) alternate(a, b pattern) pattern {
+	switch {
+	case a.kind == emptyPattern && b.kind == emptyPattern:
+		return a
+	case a.kind == emptyPattern:
+		return d.repeat(b, "?")
+	case b.kind == emptyPattern:
+		return d.repeat(a, "?")
+	}
+	return pattern{a.text + "|" + b.text, alternationPattern}
+}
+
+func (d dialect) star(p pattern) pattern {
+	if p.kind == emptyPattern {
+		return p
+	}
+	return d.repeat(p, "*")
+}
+
+// symbols returns the pattern matching one of symbols, with
+// the runs of three or more characters written as ranges
+func (d dialect) symbols(symbols []Symbol) pattern {
+	unique := map[Symbol]bool{}
+	for _, symbol := range symbols {
+		unique[symbol] = true
+	}
+	sorted := make([]Symbol, 0, len(unique))
+	for symbol := range unique {
+		sorted = append(sorted, symbol)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	if len(sorted) == 1 {
+		return pattern{d.escape(sorted[0], false), atomPattern}
+	}
+	var class strings.Builder
+	class.WriteString("[")
+	for start := 0; start < len(sorted); {
+		end := start
+		for end+1 < len(sorted) && sorted[end+1] == sorted[end]+1 {
+			end++
+		}
+		if end-start >= 2 {
+			fmt.Fprintf(&class, "%s-%s", d.escape(sorted[start], true), d.escape(sorted[end], true))
+		} else {
+			for _, symbol := range sorted[start : end+1] {
+				class.WriteString(d.escape(symbol, true))
+			}
+		}
+		start = end + 1
+	}
+	class.WriteString("]")
+	return pattern{class.String(), atomPattern}
+}
+
+// escape returns symbol as it is written on a pattern,
+// inside a character class or outside of one
+func (d dialect) escape(symbol Symbol, inClass bool) string {
+	switch symbol {
+	case '\t':
+		return `\t`
+	case '\n':
+		return `\n`
+	}
+	if !inClass {
+		return d.quote(symbol)
+	}
+	if strings.IndexByte(d.classSpecial, byte(symbol)) >= 0 {
+		return `\` + string(rune(symbol))
+	}
+	return string(rune(symbol))
+}
+
+// TokenPatterns returns, for each class of token read by the
+// automaton of the scanner, a regular expression matching exactly
+// its lexemes, like [*+\-/] for OPM. The reserved words match the
+// one of the identifiers. They are built from the automaton itself,
+// so the tools that highlight mgol follow the language as it changes
+func TokenPatterns() map[TokenClass]string {
+	return tokenPatterns(goDialect)
+}
+
+func tokenPatterns(d dialect) map[TokenClass]string {
+	patterns := map[TokenClass]string{}
+	for _, class := range stateToTokenClassMap {
+		if _, found := patterns[class]; !found {
+			patterns[class] = d.classPattern(class).text
+		}
+	}
+	return patterns
+}
+
+// classPattern turns the automaton into the pattern of the lexemes of
+// class, removing its states one by one and labeling the edges around
+// each with the patterns of the paths that went through it
+func (d dialect) classPattern(class TokenClass) pattern {
+	// The paths go from start, before the state 0, to end,
+	// after the final states of class
+	const start, end State = -1, -2
+	edges := map[State]map[State]pattern{}
+	addEdge := func(from, to State, p pattern) {
+		if edges[from] == nil {
+			edges[from] = map[State]pattern{}
+		}
+		if existing, found := edges[from][to]; found {
+			p = d.alternate(existing, p)
+		}
+		edges[from][to] = p
+	}
+	// order holds every state, so the edges are visited in the
+	// same order each time and so are the alternatives of the pattern
+	order := append(append([]State{start}, states...), end)
+
+	addEdge(start, 0, pattern{kind: emptyPattern})
+	for _, state := range states {
+		readings := map[State][]Symbol{}
+		for _, transition := range transitionMap[state] {
+			readings[transition.to] = append(readings[transition.to], transition.reading...)
+		}
+		for _, to := range order {
+			if reading, found := readings[to]; found {
+				addEdge(state, to, d.symbols(reading))
+			}
+		}
+		if stateToTokenClassMap[state] == class && ContainsState(finalStates, state) {
+			addEdge(state, end, pattern{kind: emptyPattern})
+		}
+	}
+
+	// Removing the states from the last one keeps the prefixes the
+	// lexemes share, like the digits before the dot of a number, once
+	for index := len(states) - 1; index >= 0; index-- {
+		removed := states[index]
+		loop, hasLoop := edges[removed][removed]
+		delete(edges[removed], removed)
+		for _, from := range order {
+			in, found := edges[from][removed]
+			if !found || from == removed {
+				continue
+			}
+			delete(edges[from], removed)
+			for _, to := range order {
+				next, found := edges[removed][to]
+				if !found {
+					continue
+				}
+				if hasLoop {
+					addEdge(from, to, d.concat(in, d.star(loop), next))
+				} else {
+					addEdge(from, to, d.concat(in, next))
+				}
+			}
+		}
+		delete(edges, removed)
+	}
+	return edges[start][end]
+}
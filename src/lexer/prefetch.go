@@ -0,0 +1,117 @@
+package lexer
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"sync"
+)
+
+// prefetched is a token read ahead, along with the
+// diagnostics the scanner reported while reading it
+type prefetched struct {
+	token       ScannedToken
+	diagnostics []errorhandling.Diagnostic
+}
+
+// PrefetchSource reads the tokens of a scanner on a goroutine of its
+// own, ahead of its reader, so a file is scanned while the one before
+// it is parsed. At most capacity tokens are read ahead: past them the
+// scanner waits for the reader, so a large file is never held whole.
+//
+// The identifiers are only inserted on the symbol table of the
+// scanner, and its diagnostics only given to its handler, when the
+// reader gets their token. The uses are then recorded and the
+// diagnostics reported in the same order as with the scanner alone
+type PrefetchSource struct {
+	scanner     *Scanner
+	symbolTable *SymbolTable
+	handler     errorhandling.DiagnosticHandler
+	tokens      chan prefetched
+	done        chan struct{}
+	start, stop sync.Once
+	// last is the EOF token, once read
+	last     ScannedToken
+	finished bool
+}
+
+// NewPrefetchSource returns a source reading ahead the tokens of
+// scanner, which must not be used by anything else from then on
+func NewPrefetchSource(scanner *Scanner, capacity int) *PrefetchSource {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &PrefetchSource{
+		scanner:     scanner,
+		symbolTable: scanner.symbolTable,
+		handler:     scanner.diagnosticHandler,
+		tokens:      make(chan prefetched, capacity),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start makes the source begin to read ahead, when it hasn't yet.
+// NextToken starts it as well
+func (p *PrefetchSource) Start() {
+	p.start.Do(func() {
+		p.scanner.symbolTable = p.symbolTable.fork()
+		go p.prefetch()
+	})
+}
+
+func (p *PrefetchSource) prefetch() {
+	defer close(p.tokens)
+	var diagnostics []errorhandling.Diagnostic
+	p.scanner.diagnosticHandler = errorhandling.DiagnosticHandlerFunc(func(diagnostic errorhandling.Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	for {
+		token := p.scanner.NextToken()
+		select {
+		case p.tokens <- prefetched{token, diagnostics}:
+		case <-p.done:
+			return
+		}
+		if token.Token == EOF_TOKEN {
+			return
+		}
+		diagnostics = nil
+	}
+}
+
+// NextToken returns the next token of the scanner, waiting
+// for it to be read when the reader is ahead of the scanner
+func (p *PrefetchSource) NextToken() ScannedToken {
+	p.Start()
+	if p.finished {
+		return p.last
+	}
+	read, open := <-p.tokens
+	if !open {
+		// Closed before the end of the file
+		p.finished = true
+		p.last = ScannedToken{Token: EOF_TOKEN, Source: p.scanner}
+		return p.last
+	}
+	for _, diagnostic := range read.diagnostics {
+		p.handler.Handle(diagnostic)
+	}
+	token := read.token
+	if token.Token.class == IDENTIFIER {
+		token.Token = p.symbolTable.Insert(token.Token.lexeme, token.Token)
+		if token.Token.class == IDENTIFIER {
+			p.symbolTable.AddUse(token.Token.lexeme, token.Start)
+		}
+	}
+	if token.Token == EOF_TOKEN {
+		p.finished = true
+		p.last = token
+	}
+	return token
+}
+
+// Close stops the reading ahead, for a reader that gives up before
+// the end of the file, so the goroutine of the scanner ends
+func (p *PrefetchSource) Close() {
+	p.stop.Do(func() {
+		close(p.done)
+	})
+}
@@ -0,0 +1,75 @@
+package lexer
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchSource(t *testing.T) {
+	source := "A <- {um} 10 $ ;\nESCREVA B;\nA <- A + B;"
+	read := func(prefetch bool, capacity int) ([]ScannedToken, []errorhandling.Diagnostic, SymbolEntry) {
+		symbolTable := NewSymbolTable()
+		symbolTable.RegisterKeywords(DefaultKeywords())
+		scanner := NewStringScanner(source, symbolTable)
+		diagnostics := errorhandling.NewDiagnosticBuffer()
+		scanner.SetDiagnosticHandler(diagnostics)
+		var tokens TokenSource = scanner
+		if prefetch {
+			tokens = NewPrefetchSource(scanner, capacity)
+		}
+
+		var read []ScannedToken
+		for token := tokens.NextToken(); token.Token != EOF_TOKEN; token = tokens.NextToken() {
+			token.Source = nil
+			read = append(read, token)
+		}
+		// Past the end every token is EOF
+		require.Equal(t, EOF_TOKEN, tokens.NextToken().Token)
+		entry, err := symbolTable.GetEntry("A")
+		require.NoError(t, err)
+		return read, diagnostics.Diagnostics(), entry
+	}
+
+	expectedTokens, expectedDiagnostics, expectedEntry := read(false, 0)
+	require.Len(t, expectedDiagnostics, 2)
+	require.Len(t, expectedEntry.Uses, 3)
+	for _, capacity := range []int{1, 4, 100} {
+		tokens, diagnostics, entry := read(true, capacity)
+		require.Equal(t, expectedTokens, tokens)
+		require.Equal(t, expectedDiagnostics, diagnostics)
+		require.Equal(t, expectedEntry, entry)
+	}
+}
+
+func TestPrefetchSourceOrder(t *testing.T) {
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+	first := NewPrefetchSource(NewStringScanner("A", symbolTable), 1)
+	second := NewPrefetchSource(NewStringScanner("A", symbolTable), 1)
+
+	// The second file is read ahead, but its use of A is
+	// only recorded once its token is read
+	second.Start()
+	first.NextToken()
+	entry, err := symbolTable.GetEntry("A")
+	require.NoError(t, err)
+	require.Len(t, entry.Uses, 1)
+	second.NextToken()
+	entry, err = symbolTable.GetEntry("A")
+	require.NoError(t, err)
+	require.Len(t, entry.Uses, 2)
+}
+
+func TestPrefetchSourceClose(t *testing.T) {
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+	source := NewPrefetchSource(NewStringScanner("A <- B + 1;", symbolTable), 1)
+	require.Equal(t, "A", source.NextToken().Token.GetLexem())
+	source.Close()
+	// The tokens read ahead before closing may still come
+	for token := source.NextToken(); token.Token != EOF_TOKEN; token = source.NextToken() {
+	}
+	require.Equal(t, EOF_TOKEN, source.NextToken().Token)
+}
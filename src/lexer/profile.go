@@ -0,0 +1,247 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrorUnknownTokenClass = fmt.Errorf("classe de token desconhecida")
+	ErrorDuplicateState    = fmt.Errorf("estado já existe no autômato")
+	ErrorUnknownProfile    = fmt.Errorf("perfil de linguagem desconhecido")
+)
+
+// Profile describes a dialect of mgol, like the one of a course
+// offering that writes the keywords in english. The grammar is the
+// same on every dialect, so a profile can only change how its
+// tokens are written: the set of the reserved words, and states and
+// transitions added to the automaton of the scanner
+type Profile struct {
+	Name string
+	// Keywords are the reserved words mapped to the keyword of mgol
+	// they stand for, like begin to inicio. The ones of mgol are
+	// kept when it is empty
+	Keywords map[string]TokenClass
+	// States and Transitions are added to those of the automaton of
+	// mgol, whose states are numbered up to 25
+	States      []ProfileState
+	Transitions []ProfileTransition
+}
+
+// ProfileState is a state added to the automaton. Class is the one
+// of the tokens recognized when the scanner stops on it, empty for a
+// state that is not final, and DataType the type of the numbers
+type ProfileState struct {
+	State    State
+	Class    TokenClass
+	DataType DataType
+}
+
+// ProfileTransition is a transition added to the automaton, shown
+// with Label on its drawing, or with its symbols when it is empty
+type ProfileTransition struct {
+	From, To State
+	Symbols  []Symbol
+	Label    string
+}
+
+// profiles are the dialects known by name, besides the ones read
+// from a file
+var profiles = map[string]Profile{
+	"mgol": {Name: "mgol"},
+	"mgol-en": {Name: "mgol-en", Keywords: map[string]TokenClass{
+		"begin":     "inicio",
+		"varbegin":  "varinicio",
+		"varend":    "varfim",
+		"write":     "escreva",
+		"read":      "leia",
+		"if":        "se",
+		"then":      "entao",
+		"else":      "senao",
+		"endif":     "fimse",
+		"repeat":    "repita",
+		"endrepeat": "fimrepita",
+		"while":     "enquanto",
+		"endwhile":  "fimenquanto",
+		"end":       "fim",
+		"integer":   "inteiro",
+		"string":    "literal",
+		"real":      "real",
+	}},
+	// Identifiers may start with an underscore, like _total
+	"mgol-sublinhado": {Name: "mgol-sublinhado", Transitions: []ProfileTransition{
+		{From: 0, To: 1, Symbols: []Symbol{'_'}},
+	}},
+}
+
+// namedSymbols are the sets of symbols a transition of a profile
+// file may read by name, besides the symbols themselves
+var namedSymbols = map[string][]Symbol{
+	"letra":  letters,
+	"dígito": numbers,
+	"digito": numbers,
+}
+
+// NamedSymbols returns the symbols of the set called name, like letra
+func NamedSymbols(name string) ([]Symbol, bool) {
+	symbols, found := namedSymbols[name]
+	return symbols, found
+}
+
+// LookupProfile returns the dialect called name
+func LookupProfile(name string) (Profile, bool) {
+	profile, found := profiles[name]
+	return profile, found
+}
+
+// ProfileNames returns the names of the dialects known, sorted
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Language is a dialect ready to be scanned, built from its Profile.
+// It never changes, so it can be shared by every scanner
+type Language struct {
+	name          string
+	reservedWords *ReservedWords
+	dft           *Dft
+	alphabet      []Symbol
+	classes       map[State]TokenClass
+	numericTypes  map[State]DataType
+}
+
+// NewLanguage builds the dialect of profile, checking that its
+// keywords stand for the ones of mgol, and its automaton still
+// recognizes only the tokens of mgol, deterministically
+func NewLanguage(profile Profile) (*Language, error) {
+	keywords := profile.Keywords
+	if len(keywords) == 0 {
+		keywords = DefaultKeywords()
+	}
+	mgolKeywords := DefaultKeywords()
+	for keyword, class := range keywords {
+		if _, found := mgolKeywords[string(class)]; !found {
+			return nil, fmt.Errorf("%w: %s, de %s", ErrorUnknownTokenClass, class, keyword)
+		}
+	}
+
+	stateRows := append([]automatonState(nil), automatonStates...)
+	known := map[State]bool{}
+	for _, row := range automatonStates {
+		known[row.state] = true
+	}
+	for _, state := range profile.States {
+		if known[state.State] {
+			return nil, fmt.Errorf("%w: %d", ErrorDuplicateState, state.State)
+		}
+		if _, found := tokenClasses[state.Class]; !found && state.Class != "" {
+			return nil, fmt.Errorf("%w: %s, do estado %d", ErrorUnknownTokenClass, state.Class, state.State)
+		}
+		known[state.State] = true
+		stateRows = append(stateRows, automatonState{state.State, state.Class, state.DataType})
+	}
+
+	transitionRows := append([]automatonTransition(nil), automatonTransitions...)
+	languageAlphabet := append([]Symbol(nil), alphabet...)
+	for _, transition := range profile.Transitions {
+		transitionRows = append(transitionRows, automatonTransition{transition.From, transition.To, symbolSet{transition.Label, transition.Symbols}})
+		for _, symbol := range transition.Symbols {
+			if !ContainsSymbol(languageAlphabet, symbol) {
+				languageAlphabet = append(languageAlphabet, symbol)
+			}
+		}
+	}
+
+	all, final, classes, types := automatonStateTables(stateRows)
+	dft, err := NewDft(languageAlphabet, all, 0, final, automatonTransitionMap(transitionRows))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", profile.Name, err)
+	}
+	return &Language{
+		name:          profile.Name,
+		reservedWords: NewReservedWords(keywords),
+		dft:           dft,
+		alphabet:      languageAlphabet,
+		classes:       classes,
+		numericTypes:  types,
+	}, nil
+}
+
+// tokenClasses are the classes of the tokens recognized by
+// the automaton of mgol, the only ones its grammar knows
+var tokenClasses = automatonTokenClasses()
+
+func automatonTokenClasses() map[TokenClass]bool {
+	classes := map[TokenClass]bool{}
+	for _, row := range automatonStates {
+		if row.class != "" {
+			classes[row.class] = true
+		}
+	}
+	return classes
+}
+
+// defaultLanguage is built once, as every scanner of mgol shares it
+var defaultLanguage = newDefaultLanguage()
+
+func newDefaultLanguage() *Language {
+	language, err := NewLanguage(profiles["mgol"])
+	if err != nil {
+		// The tables of the automaton are wrong
+		panic(err)
+	}
+	return language
+}
+
+// DefaultLanguage returns mgol itself
+func DefaultLanguage() *Language {
+	return defaultLanguage
+}
+
+// LoadLanguage returns the dialect called name, built
+func LoadLanguage(name string) (*Language, error) {
+	if name == "" || name == defaultLanguage.name {
+		return defaultLanguage, nil
+	}
+	profile, found := LookupProfile(name)
+	if !found {
+		return nil, fmt.Errorf("%w: %s, os disponíveis são %s", ErrorUnknownProfile, name, strings.Join(ProfileNames(), ", "))
+	}
+	return NewLanguage(profile)
+}
+
+// Name returns the name of the profile of the dialect
+func (l *Language) Name() string {
+	return l.name
+}
+
+// ReservedWords returns the reserved words of the dialect, to be set
+// on the symbol table of its scanners
+func (l *Language) ReservedWords() *ReservedWords {
+	return l.reservedWords
+}
+
+// ScannerDft returns a new automaton of the dialect,
+// on its initial state
+func (l *Language) ScannerDft() *Dft {
+	dft := *l.dft
+	dft.Reset()
+	return &dft
+}
+
+// WriteAutomatonDOT draws the automaton of the dialect on the Graphviz
+// DOT language, each final state with the class of its tokens
+func (l *Language) WriteAutomatonDOT(w io.Writer) error {
+	labels := make(map[State]string, len(l.classes))
+	for state, class := range l.classes {
+		labels[state] = string(class)
+	}
+	return l.ScannerDft().WriteDOT(w, labels)
+}
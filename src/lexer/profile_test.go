@@ -0,0 +1,95 @@
+package lexer
+
+import (
+	"fmt"
+	errorhandling "mgol-go/src/error_handling"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scanClasses returns the classes of the tokens of text on language
+func scanClasses(language *Language, text string) []TokenClass {
+	symbolTable := NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	scanner := NewStringScanner(text, symbolTable)
+	scanner.SetLanguage(language)
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(errorhandling.Diagnostic) {}))
+	var classes []TokenClass
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		classes = append(classes, token.class)
+	}
+	return classes
+}
+
+func TestLoadLanguage(t *testing.T) {
+	testCases := []struct {
+		profile  string
+		text     string
+		expected []TokenClass
+	}{
+		{"mgol", "inicio _total", []TokenClass{"inicio", ERROR, IDENTIFIER}},
+		{"mgol-en", "begin integer inicio;", []TokenClass{"inicio", "inteiro", IDENTIFIER, SEMICOLON}},
+		{"mgol-sublinhado", "inicio _total", []TokenClass{"inicio", IDENTIFIER}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.profile, func(t *testing.T) {
+			language, err := LoadLanguage(tc.profile)
+			require.NoError(t, err)
+			require.Equal(t, tc.profile, language.Name())
+			require.Equal(t, tc.expected, scanClasses(language, tc.text))
+		})
+	}
+
+	_, err := LoadLanguage("klingon")
+	require.ErrorIs(t, err, ErrorUnknownProfile)
+}
+
+func TestNewLanguageErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		profile  Profile
+		expected error
+	}{
+		{"keyword", Profile{Keywords: map[string]TokenClass{"enquanto": "while"}}, ErrorUnknownTokenClass},
+		{"existing state", Profile{States: []ProfileState{{State: 1}}}, ErrorDuplicateState},
+		{"state class", Profile{States: []ProfileState{{State: 26, Class: "Hex"}}}, ErrorUnknownTokenClass},
+		{"unknown state", Profile{Transitions: []ProfileTransition{{From: 0, To: 30, Symbols: []Symbol{'#'}}}}, ErrorInvalidState},
+		{"nondeterministic", Profile{Transitions: []ProfileTransition{{From: 0, To: 2, Symbols: []Symbol{'a'}}}}, ErrorNondeterministic},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewLanguage(tc.profile)
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestLanguageStates(t *testing.T) {
+	// Numbers written on hexadecimal, like #1F, taken as inteiro
+	hexDigits := []Symbol("0123456789ABCDEF")
+	language, err := NewLanguage(Profile{
+		Name:   "hexadecimal",
+		States: []ProfileState{{State: 26}, {State: 27, Class: NUM, DataType: INTEGER}},
+		Transitions: []ProfileTransition{
+			{From: 0, To: 26, Symbols: []Symbol{'#'}},
+			{From: 26, To: 27, Symbols: hexDigits, Label: "hexa"},
+			{From: 27, To: 27, Symbols: hexDigits, Label: "hexa"},
+		},
+	})
+	require.NoError(t, err)
+
+	symbolTable := NewSymbolTable()
+	scanner := NewStringScanner("#1F", symbolTable)
+	scanner.SetLanguage(language)
+	token, _, _ := scanner.Scan()
+	require.Equal(t, NewToken(NUM, "#1F", INTEGER), token)
+
+	var text strings.Builder
+	require.NoError(t, language.WriteAutomatonDOT(&text))
+	require.Contains(t, text.String(), fmt.Sprintf("[label=%q, shape=doublecircle]", "q27\nNum"))
+	require.Contains(t, text.String(), `[label="hexa"]`)
+}
@@ -0,0 +1,93 @@
+package lexer
+
+import "sort"
+
+// ReservedWords is an immutable set of reserved words. As it
+// never changes after being created, the same instance can be
+// shared by every compilation and every symbol table
+type ReservedWords struct {
+	tokens map[string]Token
+	folded map[string]string
+}
+
+// NewReservedWords creates the set of the given reserved words,
+// each one with its token class. As the reserved words of mgol,
+// the data type of each keyword token is the keyword itself
+func NewReservedWords(keywords map[string]TokenClass) *ReservedWords {
+	reservedWords := &ReservedWords{
+		tokens: make(map[string]Token, len(keywords)),
+		folded: make(map[string]string, len(keywords)),
+	}
+	for keyword, class := range keywords {
+		reservedWords.tokens[keyword] = NewToken(class, keyword, DataType(keyword))
+		reservedWords.folded[foldCase(keyword)] = keyword
+	}
+	return reservedWords
+}
+
+var defaultReservedWords = NewReservedWords(DefaultKeywords())
+
+// DefaultReservedWords returns the shared set of the reserved words of mgol
+func DefaultReservedWords() *ReservedWords {
+	return defaultReservedWords
+}
+
+// With returns a new set holding the reserved words of r
+// and the given keywords. r itself is left untouched
+func (r *ReservedWords) With(keywords map[string]TokenClass) *ReservedWords {
+	merged := r.Keywords()
+	for keyword, class := range keywords {
+		merged[keyword] = class
+	}
+	return NewReservedWords(merged)
+}
+
+// Keywords returns a copy of the reserved words mapped to their classes
+func (r *ReservedWords) Keywords() map[string]TokenClass {
+	keywords := map[string]TokenClass{}
+	if r == nil {
+		return keywords
+	}
+	for keyword, token := range r.tokens {
+		keywords[keyword] = token.class
+	}
+	return keywords
+}
+
+// Tokens returns the tokens of the reserved words ordered by their lexeme
+func (r *ReservedWords) Tokens() []Token {
+	tokens := []Token{}
+	if r == nil {
+		return tokens
+	}
+	for _, token := range r.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].lexeme < tokens[j].lexeme
+	})
+	return tokens
+}
+
+// Lookup returns the token of the reserved word spelled as lexem
+func (r *ReservedWords) Lookup(lexem string) (Token, bool) {
+	if r == nil {
+		return Token{}, false
+	}
+	token, found := r.tokens[lexem]
+	return token, found
+}
+
+// LookupIgnoringCase returns the token of the reserved
+// word that matches lexem when case is ignored
+func (r *ReservedWords) LookupIgnoringCase(lexem string) (Token, bool) {
+	if r == nil {
+		return Token{}, false
+	}
+	var buf [maxFoldedLength]byte
+	keyword, found := r.folded[string(appendFolded(buf[:0], lexem))]
+	if !found {
+		return Token{}, false
+	}
+	return r.tokens[keyword], true
+}
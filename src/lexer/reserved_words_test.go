@@ -0,0 +1,87 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedWordsLookup(t *testing.T) {
+	testCases := []struct {
+		name          string
+		lexem         string
+		ignoringCase  bool
+		expectedFound bool
+		expectedToken Token
+	}{
+		{
+			name:          "Exact reserved word",
+			lexem:         "varinicio",
+			expectedFound: true,
+			expectedToken: NewToken("varinicio", "varinicio", "varinicio"),
+		},
+		{
+			name:          "Reserved word with different case",
+			lexem:         "VarInicio",
+			expectedFound: false,
+			expectedToken: Token{},
+		},
+		{
+			name:          "Reserved word with different case ignoring case",
+			lexem:         "VarInicio",
+			ignoringCase:  true,
+			expectedFound: true,
+			expectedToken: NewToken("varinicio", "varinicio", "varinicio"),
+		},
+		{
+			name:          "Identifier",
+			lexem:         "media",
+			ignoringCase:  true,
+			expectedFound: false,
+			expectedToken: Token{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup := DefaultReservedWords().Lookup
+			if tc.ignoringCase {
+				lookup = DefaultReservedWords().LookupIgnoringCase
+			}
+
+			token, found := lookup(tc.lexem)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expectedToken, token)
+		})
+	}
+}
+
+func TestSharedReservedWords(t *testing.T) {
+	first := NewSymbolTable()
+	first.SetReservedWords(DefaultReservedWords())
+	second := NewSymbolTable()
+	second.SetReservedWords(DefaultReservedWords())
+
+	first.RegisterKeywords(map[string]TokenClass{"laco": "repita"})
+	first.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+	first.Cleanup()
+
+	token, err := first.GetToken("laco")
+	require.NoError(t, err)
+	require.Equal(t, NewToken("repita", "laco", "laco"), token)
+
+	_, err = first.GetToken("se")
+	require.NoError(t, err)
+
+	_, err = first.GetToken("A")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+
+	_, err = second.GetToken("laco")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+
+	_, found := DefaultReservedWords().Lookup("laco")
+	require.False(t, found)
+
+	require.ErrorIs(t, second.Update("se", NewToken(IDENTIFIER, "se", INTEGER)), ErrorReservedWord)
+	require.ErrorIs(t, second.Declare("se", INTEGER), ErrorReservedWord)
+}
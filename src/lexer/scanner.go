@@ -3,405 +3,122 @@ package lexer
 import (
 	"errors"
 	"io"
-	"log"
+	"io/ioutil"
+	"math"
 	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/metrics"
 	"os"
 	"strings"
 )
 
-func letterGenerator() []Symbol {
-	result := []Symbol{}
-
-	for i := 'a'; i <= 'z'; i++ {
-		result = append(result, Symbol(i))
-	}
-
-	for i := 'A'; i <= 'Z'; i++ {
-		result = append(result, Symbol(i))
-	}
-
-	return result
-}
-
-func numGenerator() []Symbol {
-	result := []Symbol{}
-
-	for i := '0'; i <= '9'; i++ {
-		result = append(result, Symbol(i))
-	}
-
-	return result
+// source is what a Scanner reads: a file or, for
+// NewStringScanner, a string
+type source interface {
+	io.Reader
+	io.Seeker
+	io.ReaderAt
 }
 
-var (
-	letters = letterGenerator()
-	numbers = numGenerator()
-)
-
-func flatten(symbols [][]Symbol) []Symbol {
-	result := []Symbol{}
-
-	for _, arr := range symbols {
-		result = append(result, arr...)
-	}
-
-	return result
-}
-
-var (
-	alphabet = flatten([][]Symbol{
-		letters,
-		numbers,
-		{
-			'\n', '\t', ' ',
-			'_', '+', '-', '*', '/',
-			'>', '<', '=', '{', '}',
-			'(', ')', ';', '"', '.',
-			'E', 'e', ':', ',', '!',
-			'?', '[', ']', '\\',
-		},
-	})
-	states        = []State{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25}
-	finalStates   = []State{1, 2, 4, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 20, 22, 25}
-	transitionMap = map[State][]Transition{
-		0: {
-			{
-				from: 0,
-				to:   1,
-				reading: flatten([][]Symbol{
-					letters,
-				}),
-			},
-			{
-				from: 0,
-				to:   2,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-			{
-				from: 0,
-				to:   8,
-				reading: flatten([][]Symbol{
-					{'<'},
-				}),
-			},
-			{
-				from: 0,
-				to:   10,
-				reading: flatten([][]Symbol{
-					{'>'},
-				}),
-			},
-			{
-				from: 0,
-				to:   12,
-				reading: flatten([][]Symbol{
-					{'='},
-				}),
-			},
-			{
-				from: 0,
-				to:   14,
-				reading: flatten([][]Symbol{
-					{'+', '-', '*', '/'},
-				}),
-			},
-			{
-				from: 0,
-				to:   15,
-				reading: flatten([][]Symbol{
-					{'('},
-				}),
-			},
-			{
-				from: 0,
-				to:   16,
-				reading: flatten([][]Symbol{
-					{')'},
-				}),
-			},
-			{
-				from: 0,
-				to:   17,
-				reading: flatten([][]Symbol{
-					{';'},
-				}),
-			},
-			{
-				from: 0,
-				to:   19,
-				reading: flatten([][]Symbol{
-					{'{'},
-				}),
-			},
-			{
-				from: 0,
-				to:   21,
-				reading: flatten([][]Symbol{
-					{'"'},
-				}),
-			},
-		},
-
-		1: {
-			{
-				from: 1,
-				to:   1,
-				reading: flatten([][]Symbol{
-					letters,
-					numbers,
-					{'_'},
-				}),
-			},
-		},
-
-		2: {
-			{
-				from: 2,
-				to:   2,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-			{
-				from: 2,
-				to:   3,
-				reading: flatten([][]Symbol{
-					{'.'},
-				}),
-			},
-			{
-				from: 2,
-				to:   5,
-				reading: flatten([][]Symbol{
-					{'e', 'E'},
-				}),
-			},
-		},
-
-		3: {
-			{
-				from: 3,
-				to:   4,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		4: {
-			{
-				from: 4,
-				to:   4,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-			{
-				from: 4,
-				to:   23,
-				reading: flatten([][]Symbol{
-					{'e', 'E'},
-				}),
-			},
-		},
-
-		5: {
-			{
-				from: 5,
-				to:   6,
-				reading: flatten([][]Symbol{
-					{'+', '-'},
-				}),
-			},
-			{
-				from: 5,
-				to:   7,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		6: {
-			{
-				from: 6,
-				to:   7,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		7: {
-			{
-				from: 7,
-				to:   7,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		8: {
-			{
-				from: 8,
-				to:   9,
-				reading: flatten([][]Symbol{
-					{'>', '='},
-				}),
-			},
-			{
-				from: 8,
-				to:   13,
-				reading: flatten([][]Symbol{
-					{'-'},
-				}),
-			},
-		},
-
-		10: {
-			{
-				from: 10,
-				to:   11,
-				reading: flatten([][]Symbol{
-					{'='},
-				}),
-			},
-		},
-
-		19: {
-			{
-				from: 19,
-				to:   19,
-				reading: flatten([][]Symbol{
-					letters,
-					numbers,
-					{'\t', ' ', '_', '+', '-', '*', '/', '>', '<', '=', '{', '(', ')', ';', '"', '.', ':', ',', '!', '?', '[', ']', '\\'},
-				}),
-			},
-			{
-				from: 19,
-				to:   20,
-				reading: flatten([][]Symbol{
-					{'}'},
-				}),
-			},
-		},
-
-		21: {
-			{
-				from: 21,
-				to:   21,
-				reading: flatten([][]Symbol{
-					letters,
-					numbers,
-					{'\t', ' ', '_', '+', '-', '*', '/', '>', '<', '=', '{', '}', '(', ')', ';', '.', ':', ',', '!', '?', '[', ']', '\\'},
-				}),
-			},
-			{
-				from: 21,
-				to:   22,
-				reading: flatten([][]Symbol{
-					{'"'},
-				}),
-			},
-		},
-
-		23: {
-			{
-				from: 23,
-				to:   24,
-				reading: flatten([][]Symbol{
-					{'+', '-'},
-				}),
-			},
-			{
-				from: 23,
-				to:   25,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		24: {
-			{
-				from: 24,
-				to:   25,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-
-		25: {
-			{
-				from: 25,
-				to:   25,
-				reading: flatten([][]Symbol{
-					numbers,
-				}),
-			},
-		},
-	}
-	stateToTokenClassMap = map[State]TokenClass{
-		1:  IDENTIFIER,
-		2:  NUM,
-		4:  NUM,
-		7:  NUM,
-		8:  REL_OP,
-		9:  REL_OP,
-		10: REL_OP,
-		11: REL_OP,
-		12: REL_OP,
-		13: ATTR,
-		14: ARIT_OP,
-		15: OPEN_PAR,
-		16: CLOSE_PAR,
-		17: SEMICOLON,
-		20: COMMENT,
-		22: LITERAL_CONST,
-		25: NUM,
-	}
-	numericTypes = map[State]DataType{
-		2:  INTEGER,
-		4:  REAL,
-		7:  INTEGER,
-		25: REAL,
-	}
-)
-
 type Scanner struct {
-	file                 *os.File
+	file                 source
 	lexemBuffer          []byte
 	currentLineFile      int
 	currentColumnFile    int
+	lexemStartLine       int
+	lexemStartColumn     int
+	offset               int64
+	lexemStartOffset     int64
 	dft                  Dft
+	alphabet             []Symbol
 	stateToTokenClassMap map[State]TokenClass
+	numericTypes         map[State]DataType
 	symbolsToIgnore      []Symbol
 	symbolTable          *SymbolTable
+	warnKeywordCase      bool
+	diagnosticHandler    errorhandling.DiagnosticHandler
+	// name is the file name put on the positions
+	name string
+	// stats, when set, gets what each Scan took
+	stats *ScanStats
+	// tracer, when set, gets each step of the automaton
+	tracer TraceHandler
+}
+
+// ScanStats is what the scanners given it with SetStats spent,
+// apart from the parser that asked them for the tokens
+type ScanStats struct {
+	Tokens int
+	metrics.Sample
 }
 
 func NewScanner(file *os.File, symbolTable *SymbolTable) *Scanner {
-	dft, err := NewDft(alphabet, states, 0, finalStates, transitionMap)
-	if err != nil {
-		log.Fatal("Failed to create DFT:", err)
-	}
+	return newScanner(file, symbolTable)
+}
 
+// NewStringScanner creates a Scanner that reads
+// text instead of the contents of a file
+func NewStringScanner(text string, symbolTable *SymbolTable) *Scanner {
+	return newScanner(strings.NewReader(text), symbolTable)
+}
+
+func newScanner(file source, symbolTable *SymbolTable) *Scanner {
 	return &Scanner{
 		file:                 file,
 		lexemBuffer:          []byte{},
 		currentLineFile:      1,
 		currentColumnFile:    0,
-		dft:                  *dft,
+		dft:                  *ScannerDft(),
+		alphabet:             alphabet,
 		stateToTokenClassMap: stateToTokenClassMap,
+		numericTypes:         numericTypes,
 		symbolsToIgnore:      []Symbol{'\n', ' ', '\t'},
 		symbolTable:          symbolTable,
+		warnKeywordCase:      true,
+		diagnosticHandler:    errorhandling.DefaultHandler(),
 	}
 }
 
+// SetLanguage makes the scanner read the tokens of the dialect
+// language instead of those of mgol. Its reserved words are set on
+// the symbol table apart, as the scanners of a program share it
+func (s *Scanner) SetLanguage(language *Language) {
+	s.dft = *language.ScannerDft()
+	s.alphabet = language.alphabet
+	s.stateToTokenClassMap = language.classes
+	s.numericTypes = language.numericTypes
+}
+
+// SetStats makes each Scan add what it took to stats, which can be
+// shared by the scanners of a program. Measuring every token slows
+// the scanner, so it is only meant for -stats
+func (s *Scanner) SetStats(stats *ScanStats) {
+	s.stats = stats
+}
+
+// SetDiagnosticHandler changes where the errors and
+// warnings found while scanning are sent to
+func (s *Scanner) SetDiagnosticHandler(handler errorhandling.DiagnosticHandler) {
+	s.diagnosticHandler = handler
+}
+
+// SetName makes the positions of the tokens read carry name as
+// the file they come from, for programs split among several files
+func (s *Scanner) SetName(name string) {
+	s.name = name
+}
+
+// Name returns the file name set with SetName
+func (s *Scanner) Name() string {
+	return s.name
+}
+
+// SetKeywordCaseWarning enables or disables the warning emitted
+// when an identifier differs from a reserved word only by case.
+// Strict mode disables it to keep the output silent
+func (s *Scanner) SetKeywordCaseWarning(enabled bool) {
+	s.warnKeywordCase = enabled
+}
+
 func (s *Scanner) getTokenClass(state State) TokenClass {
 	return s.stateToTokenClassMap[state]
 }
@@ -411,7 +128,7 @@ func (s *Scanner) getTokenClass(state State) TokenClass {
 func (s *Scanner) updateDataType(token *Token) {
 	switch token.class {
 	case NUM:
-		token.dataType = numericTypes[s.dft.currentState]
+		token.dataType = s.numericTypes[s.dft.currentState]
 	case LITERAL_CONST:
 		token.dataType = LITERAL
 	default:
@@ -423,6 +140,59 @@ func (s *Scanner) clearLexemBuffer() {
 	s.lexemBuffer = []byte{}
 }
 
+// markLexemStart records the position of the
+// first character of the lexem being read
+func (s *Scanner) markLexemStart() {
+	s.lexemStartLine = s.currentLineFile
+	s.lexemStartColumn = s.currentColumnFile
+	s.lexemStartOffset = s.offset - 1
+}
+
+// insertIdentifier stores an identifier token on the symbol table
+// and records where it was used, warning the user when it looks
+// like a misspelled reserved word
+func (s *Scanner) insertIdentifier(token Token) Token {
+	inserted := s.symbolTable.Insert(token.lexeme, token)
+	if inserted.class != IDENTIFIER {
+		return inserted
+	}
+
+	if s.warnKeywordCase {
+		if keyword, found := s.symbolTable.keywordIgnoringCase(token.lexeme); found && keyword != token.lexeme {
+			s.diagnosticHandler.Handle(errorhandling.NewKeywordCaseWarning(s.lexemStartLine, s.lexemStartColumn, token.lexeme, keyword))
+		}
+	}
+	s.symbolTable.AddUse(token.lexeme, s.TokenStart())
+	return inserted
+}
+
+// TokenStart returns the position of the first
+// character of the last token returned by Scan
+func (s *Scanner) TokenStart() Position {
+	return Position{File: s.name, Line: s.lexemStartLine, Column: s.lexemStartColumn}
+}
+
+// TokenOffsets returns the byte offsets of the first character of
+// the last token returned by Scan and of the character after it
+func (s *Scanner) TokenOffsets() (int64, int64) {
+	return s.lexemStartOffset, s.offset
+}
+
+// ReadSource returns the text of the file between the byte offsets
+// start and end, without moving the head of the scanner. A negative
+// end reads until the end of the file
+func (s *Scanner) ReadSource(start, end int64) (string, error) {
+	if end < 0 {
+		end = math.MaxInt64
+	}
+	if end <= start {
+		return "", nil
+	}
+
+	text, err := ioutil.ReadAll(io.NewSectionReader(s.file, start, end-start))
+	return string(text), err
+}
+
 func (s *Scanner) GetSymbolTable() *SymbolTable {
 	return s.symbolTable
 }
@@ -440,6 +210,7 @@ func (s *Scanner) reset() {
 func (s *Scanner) resetAndRewind() {
 	s.reset()
 	s.file.Seek(-1, os.SEEK_CUR)
+	s.offset--
 }
 
 // Scan reads the Scanner file until finds a Token or an error.
@@ -447,6 +218,33 @@ func (s *Scanner) resetAndRewind() {
 // just returns an error Token and shows to the user the error
 // message related
 func (s *Scanner) Scan() (Token, int, int) {
+	if s.stats == nil {
+		return s.scan()
+	}
+	stop := metrics.Start()
+	token, line, column := s.scan()
+	s.stats.Sample = s.stats.Add(stop())
+	if token != EOF_TOKEN {
+		s.stats.Tokens++
+	}
+	return token, line, column
+}
+
+// NextToken scans the next token, with where it is on the file
+func (s *Scanner) NextToken() ScannedToken {
+	token, line, column := s.Scan()
+	start, end := s.TokenOffsets()
+	return ScannedToken{
+		Token:       token,
+		Start:       s.TokenStart(),
+		End:         Position{File: s.name, Line: line, Column: column},
+		StartOffset: start,
+		EndOffset:   end,
+		Source:      s,
+	}
+}
+
+func (s *Scanner) scan() (Token, int, int) {
 	readBuffer := make([]byte, 1)
 
 	for {
@@ -455,26 +253,43 @@ func (s *Scanner) Scan() (Token, int, int) {
 		currSymbol := Symbol(currChar)
 
 		s.currentColumnFile += n
+		s.offset += int64(n)
+		line, column, from := s.currentLineFile, s.currentColumnFile, s.dft.currentState
 
 		if err == io.EOF && len(s.lexemBuffer) == 0 {
-			return EOF_TOKEN, 0, 0
+			// EOF is right after the last character of the file
+			s.lexemStartLine, s.lexemStartColumn, s.lexemStartOffset = line, column+1, s.offset
+			return EOF_TOKEN, line, column + 1
 		}
 
 		if err == io.EOF && len(s.lexemBuffer) != 0 {
 			if ContainsByte(s.lexemBuffer, '{') && !ContainsByte(s.lexemBuffer, '}') {
-				errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer))
+				if s.tracer != nil {
+					s.trace(line, column, 0, true, from, TraceReject, "")
+				}
+				s.diagnosticHandler.Handle(errorhandling.NewUnterminatedLexicalError(s.currentLineFile, s.currentColumnFile, s.lexemStartLine, s.lexemStartColumn, string(s.lexemBuffer)))
 				s.reset()
 				return ERROR_TOKEN, 0, 0
 			}
 
 			numberOfQuotation := strings.Count(string(s.lexemBuffer), "\"")
 			if numberOfQuotation == 1 {
-				errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer))
+				if s.tracer != nil {
+					s.trace(line, column, 0, true, from, TraceReject, "")
+				}
+				s.diagnosticHandler.Handle(errorhandling.NewUnterminatedLexicalError(s.currentLineFile, s.currentColumnFile, s.lexemStartLine, s.lexemStartColumn, string(s.lexemBuffer)))
 				s.reset()
 				return ERROR_TOKEN, 0, 0
 			}
 
 			tokenClass := s.getTokenClass(s.dft.GetCurrentState())
+			if s.tracer != nil {
+				action := TraceAccept
+				if tokenClass == "" {
+					action = TraceReject
+				}
+				s.trace(line, column, 0, true, from, action, tokenClass)
+			}
 			if tokenClass == COMMENT {
 				s.reset()
 				return COMMENT_TOKEN, 0, 0
@@ -486,13 +301,16 @@ func (s *Scanner) Scan() (Token, int, int) {
 			s.reset()
 
 			if token.class == IDENTIFIER {
-				return s.symbolTable.Insert(token.lexeme, token), s.currentLineFile, s.currentColumnFile
+				return s.insertIdentifier(token), s.currentLineFile, s.currentColumnFile
 			}
 			return token, s.currentLineFile, s.currentColumnFile
 		}
 
-		if !ContainsSymbol(alphabet, currSymbol) || !ContainsByte(s.lexemBuffer, '{') && currChar == '}' {
-			errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer)+string(currChar))
+		if !ContainsSymbol(s.alphabet, currSymbol) || !ContainsByte(s.lexemBuffer, '{') && currChar == '}' {
+			if s.tracer != nil {
+				s.trace(line, column, currSymbol, false, from, TraceReject, "")
+			}
+			s.diagnosticHandler.Handle(errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer)+string(currChar)))
 			s.reset()
 			return ERROR_TOKEN, 0, 0
 		}
@@ -507,6 +325,9 @@ func (s *Scanner) Scan() (Token, int, int) {
 
 		if errors.Is(err, ErrorTransitionDoesNotExist) && s.dft.IsFinalState() {
 			tokenClass := s.getTokenClass(s.dft.GetCurrentState())
+			if s.tracer != nil {
+				s.trace(line, column, currSymbol, false, from, TraceAccept, tokenClass)
+			}
 			if tokenClass == COMMENT {
 				s.resetAndRewind()
 				return COMMENT_TOKEN, 0, 0
@@ -520,35 +341,49 @@ func (s *Scanner) Scan() (Token, int, int) {
 			s.currentColumnFile -= n
 			if currChar == '\n' {
 				s.currentLineFile -= 1
+				s.currentColumnFile = previousColumnLine - n
 			}
 
 			if token.class == IDENTIFIER {
-				return s.symbolTable.Insert(token.lexeme, token), s.currentLineFile, previousColumnLine - 1
+				return s.insertIdentifier(token), s.currentLineFile, previousColumnLine - 1
 			}
 			return token, s.currentLineFile, previousColumnLine - 1
 		}
 
 		if errors.Is(err, ErrorTransitionDoesNotExist) && !s.dft.IsFinalState() {
 			if currChar == ' ' || currChar == '\n' || currChar == '\t' {
+				if s.tracer != nil {
+					s.trace(line, column, currSymbol, false, from, TraceSkip, "")
+				}
 				continue
 			}
+			if s.tracer != nil {
+				s.trace(line, column, currSymbol, false, from, TraceReject, "")
+			}
 
 			if len(string(s.lexemBuffer)) == 0 {
-				errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(currChar))
+				s.diagnosticHandler.Handle(errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(currChar)))
 			} else {
-				errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer))
+				s.diagnosticHandler.Handle(errorhandling.NewLexicalError(s.currentLineFile, s.currentColumnFile, string(s.lexemBuffer)))
 			}
 
 			s.clearLexemBuffer()
 			if s.dft.currentState != s.dft.initialState {
 				s.file.Seek(-1, os.SEEK_CUR)
+				s.offset--
 			}
 			s.dft.Reset()
 
 			return ERROR_TOKEN, 0, 0
 		}
 
+		if s.tracer != nil {
+			s.trace(line, column, currSymbol, false, from, TraceAdvance, "")
+		}
 		if !ContainsSymbol(s.symbolsToIgnore, currSymbol) {
+			if len(s.lexemBuffer) == 0 {
+				s.markLexemStart()
+			}
 			s.lexemBuffer = append(s.lexemBuffer, currChar)
 		} else if ContainsByte(s.lexemBuffer, '"') || ContainsByte(s.lexemBuffer, '{') {
 			s.lexemBuffer = append(s.lexemBuffer, currChar)
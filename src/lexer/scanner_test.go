@@ -2,10 +2,14 @@ package lexer
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	errorhandling "mgol-go/src/error_handling"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -168,7 +172,7 @@ func TestScanNumToken(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			scanner := NewScanner(file, NewSymbolTable())
 			tokens := []Token{}
 			for {
 				token, _, _ := scanner.Scan()
@@ -222,7 +226,7 @@ func TestScanIdToken(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			scanner := NewScanner(file, NewSymbolTable())
 			token, _, _ := scanner.Scan()
 
 			require.Equal(t, tc.expectedToken, token)
@@ -292,7 +296,7 @@ func TestScanCommentToken(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			scanner := NewScanner(file, NewSymbolTable())
 
 			for _, expectedToken := range tc.expectedToken {
 				token, _, _ := scanner.Scan()
@@ -326,7 +330,7 @@ func TestScanLiteralConstantToken(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			scanner := NewScanner(file, NewSymbolTable())
 			token, _, _ := scanner.Scan()
 
 			require.Equal(t, tc.expectedToken, token)
@@ -495,11 +499,6 @@ func TestScanGeneralCases(t *testing.T) {
 		},
 	}
 
-	symbolTable := GetSymbolTableInstance()
-
-	FillSymbolTable(symbolTable)
-	defer symbolTable.Cleanup()
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			file, err := ioutil.TempFile("", "scan-test")
@@ -511,7 +510,10 @@ func TestScanGeneralCases(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			symbolTable := NewSymbolTable()
+			symbolTable.RegisterKeywords(DefaultKeywords())
+
+			scanner := NewScanner(file, symbolTable)
 
 			for _, expectedToken := range tc.expectedToken {
 				token, _, _ := scanner.Scan()
@@ -595,16 +597,50 @@ func TestStdoutErrorLog(t *testing.T) {
 			name:         "Malformated literal",
 			preparedText: `"this is a malformated literal`,
 			expectedOutput: []string{
-				"erro na linha 1 coluna 30, literal \"this is a malformated literal inválido",
+				"erro na linha 1 coluna 30, literal \"this is a malformated literal inválido, aspas abertas na linha 1 coluna 1",
 				"",
 			},
 		},
+		{
+			name:         "Malformated literal after other tokens",
+			preparedText: "A <- \"unterminated",
+			expectedOutput: []string{
+				"",
+				"",
+				"erro na linha 1 coluna 18, literal \"unterminated inválido, aspas abertas na linha 1 coluna 6",
+			},
+		},
 		{
 			name:         "Malformated comment",
 			preparedText: "{this is malformated commment",
 			expectedOutput: []string{
-				"erro na linha 1 coluna 29, comentário {this is malformated commment inválido",
+				"erro na linha 1 coluna 29, comentário {this is malformated commment inválido, chave aberta na linha 1 coluna 1",
+				"",
+			},
+		},
+		{
+			name:         "Malformated comment after other tokens",
+			preparedText: "A;\n  {unterminated",
+			expectedOutput: []string{
+				"",
+				"",
+				"erro na linha 2 coluna 15, comentário {unterminated inválido, chave aberta na linha 2 coluna 3",
+			},
+		},
+		{
+			name:         "Identifier differing from keyword only by case",
+			preparedText: "Se",
+			expectedOutput: []string{
+				"aviso na linha 1 coluna 1, identificador Se difere da palavra reservada se apenas por maiúsculas/minúsculas",
+			},
+		},
+		{
+			name:         "Identifier differing from keyword only by case after other tokens",
+			preparedText: "A;\nFIM ",
+			expectedOutput: []string{
+				"",
 				"",
+				"aviso na linha 2 coluna 1, identificador FIM difere da palavra reservada fim apenas por maiúsculas/minúsculas",
 			},
 		},
 		{
@@ -626,11 +662,6 @@ func TestStdoutErrorLog(t *testing.T) {
 		},
 	}
 
-	symbolTable := GetSymbolTableInstance()
-
-	FillSymbolTable(symbolTable)
-	defer symbolTable.Cleanup()
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			file, err := ioutil.TempFile("", "scan-test")
@@ -642,7 +673,10 @@ func TestStdoutErrorLog(t *testing.T) {
 
 			file.Seek(0, io.SeekStart)
 
-			scanner := NewScanner(file, GetSymbolTableInstance())
+			symbolTable := NewSymbolTable()
+			symbolTable.RegisterKeywords(DefaultKeywords())
+
+			scanner := NewScanner(file, symbolTable)
 
 			for _, expectedOutput := range tc.expectedOutput {
 				output := captureOutput(func() { scanner.Scan() })
@@ -655,3 +689,279 @@ func TestStdoutErrorLog(t *testing.T) {
 		})
 	}
 }
+
+func TestKeywordCaseWarningDisabled(t *testing.T) {
+	symbolTable := NewSymbolTable()
+
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("Se FIM")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	scanner := NewScanner(file, symbolTable)
+	scanner.SetKeywordCaseWarning(false)
+
+	output := captureOutput(func() {
+		for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		}
+	})
+	require.Equal(t, "", output)
+}
+
+func TestBufferedErrorLog(t *testing.T) {
+	symbolTable := NewSymbolTable()
+
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("A !!!;\n%")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	scanner := NewScanner(file, symbolTable)
+
+	errorhandling.EnableBuffering()
+	defer errorhandling.DisableBuffering()
+
+	output := captureOutput(func() {
+		for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		}
+		errorhandling.FlushDiagnostics()
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	require.Equal(t, 2, len(lines))
+	require.Equal(t, "erro na linha 1 coluna 3, palavra ! inexistente na linguagem (3 ocorrências)", lines[0][20:])
+	require.Equal(t, "erro na linha 2 coluna 1, palavra % inexistente na linguagem", lines[1][20:])
+}
+
+func TestDiagnosticHandler(t *testing.T) {
+	symbolTable := NewSymbolTable()
+
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("Se %\n\"open")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	diagnostics := []errorhandling.Diagnostic{}
+	scanner := NewScanner(file, symbolTable)
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(diagnostic errorhandling.Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	}))
+
+	output := captureOutput(func() {
+		for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		}
+	})
+
+	require.Equal(t, "", output)
+	require.Equal(t, []errorhandling.Diagnostic{
+		errorhandling.NewKeywordCaseWarning(1, 1, "Se", "se"),
+		errorhandling.NewLexicalError(1, 4, "%"),
+		errorhandling.NewUnterminatedLexicalError(2, 5, 2, 1, "\"open"),
+	}, diagnostics)
+}
+
+// Run with -race to make sure scanners sharing a
+// symbol table don't race on it
+func TestConcurrentScanners(t *testing.T) {
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	const scanners = 8
+	var wg sync.WaitGroup
+	for i := 0; i < scanners; i++ {
+		file, err := ioutil.TempFile("", "scan-test")
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = file.WriteString(fmt.Sprintf("varinicio inteiro A; inteiro B%d; varfim; A <- B%d;", i, i))
+		require.NoError(t, err)
+
+		file.Seek(0, io.SeekStart)
+
+		wg.Add(1)
+		go func(scanner *Scanner) {
+			defer wg.Done()
+			for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+				symbolTable.GetToken(token.GetLexem())
+			}
+		}(NewScanner(file, symbolTable))
+	}
+	wg.Wait()
+
+	for i := 0; i < scanners; i++ {
+		_, err := symbolTable.GetToken(fmt.Sprintf("B%d", i))
+		require.NoError(t, err)
+	}
+}
+
+func TestScannerRecordsUses(t *testing.T) {
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("A <- B;\n  A <- A;")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	scanner := NewScanner(file, symbolTable)
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+	}
+
+	entry, err := symbolTable.GetEntry("A")
+	require.NoError(t, err)
+	require.Equal(t, []Position{{Line: 1, Column: 1}, {Line: 2, Column: 3}, {Line: 2, Column: 8}}, entry.Uses)
+
+	entry, err = symbolTable.GetEntry("B")
+	require.NoError(t, err)
+	require.Equal(t, []Position{{Line: 1, Column: 6}}, entry.Uses)
+}
+
+func TestScannerTokenOffsets(t *testing.T) {
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	source := "A <- {um}  10;\nB"
+	_, err = file.WriteString(source)
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	scanner := NewScanner(file, symbolTable)
+	texts := []string{}
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		if token == COMMENT_TOKEN {
+			continue
+		}
+		start, end := scanner.TokenOffsets()
+		text, err := scanner.ReadSource(start, end)
+		require.NoError(t, err)
+		texts = append(texts, text)
+	}
+	require.Equal(t, []string{"A", "<-", "10", ";", "B"}, texts)
+
+	text, err := scanner.ReadSource(5, -1)
+	require.NoError(t, err)
+	require.Equal(t, source[5:], text)
+}
+
+func TestScanEOFPosition(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected Position
+	}{
+		{source: "", expected: Position{Line: 1, Column: 1}},
+		{source: "leia A;", expected: Position{Line: 1, Column: 8}},
+		{source: "leia A;\n", expected: Position{Line: 2, Column: 1}},
+		{source: "leia A;\n{ sem fim }", expected: Position{Line: 2, Column: 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			symbolTable := NewSymbolTable()
+			symbolTable.RegisterKeywords(DefaultKeywords())
+			scanner := NewStringScanner(tt.source, symbolTable)
+
+			token := scanner.NextToken()
+			for token.Token != EOF_TOKEN {
+				token = scanner.NextToken()
+			}
+			require.Equal(t, tt.expected, token.Start)
+			require.Equal(t, tt.expected, token.End)
+			require.Equal(t, token.StartOffset, token.EndOffset)
+			// Past the end every token is EOF, at the same position
+			require.Equal(t, token.End, scanner.NextToken().End)
+		})
+	}
+}
+
+func TestScanCaseInsensitive(t *testing.T) {
+	file, err := ioutil.TempFile("", "scan-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("Media <- media; SE")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	symbolTable := NewSymbolTable()
+	symbolTable.SetCaseInsensitive(true)
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	scanner := NewScanner(file, symbolTable)
+
+	output := captureOutput(func() {
+		for _, expectedToken := range []Token{
+			NewToken(IDENTIFIER, "Media", NULL),
+			ATTR_TOKEN,
+			NewToken(IDENTIFIER, "Media", NULL),
+			SEMICOLON_TOKEN,
+			NewToken("se", "se", "se"),
+			EOF_TOKEN,
+		} {
+			token, _, _ := scanner.Scan()
+			require.Equal(t, expectedToken, token)
+		}
+	})
+	require.Equal(t, "", output)
+}
+
+func benchmarkScan(b *testing.B, source string) {
+	file, err := ioutil.TempFile("", "scan-bench")
+	require.NoError(b, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	_, err = file.WriteString(source)
+	require.NoError(b, err)
+
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		file.Seek(0, io.SeekStart)
+		scanner := NewScanner(file, symbolTable)
+		for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		}
+	}
+}
+
+func BenchmarkScanKeywordHeavy(b *testing.B) {
+	benchmarkScan(b, strings.Repeat("se entao fimse repita fimrepita leia escreva inteiro real literal\n", 50))
+}
+
+func BenchmarkScanIdentifierHeavy(b *testing.B) {
+	source := strings.Builder{}
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&source, "Soma%d <- Parcela%d + Valor;\n", i, i)
+	}
+	benchmarkScan(b, source.String())
+}
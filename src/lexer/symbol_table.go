@@ -2,6 +2,8 @@ package lexer
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/pterm/pterm"
 )
@@ -10,62 +12,468 @@ import (
 var (
 	ErrorAlreadyOnTable = fmt.Errorf("the specified symbol is already on the symbol table")
 	ErrorSymbolNotFound = fmt.Errorf("the specified symbol doesn't exists on the symbol table")
+	ErrorReservedWord   = fmt.Errorf("the specified symbol is a reserved word")
 )
 
+// Position locates a lexem on the source file. File is
+// only set when the program is split among several files
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Before tells whether p comes before other on the source
+func (p Position) Before(other Position) bool {
+	return p.Line < other.Line || (p.Line == other.Line && p.Column < other.Column)
+}
+
+// SymbolEntry is what the symbol table keeps for each
+// symbol. Besides the data of its token, it records
+// where the symbol was declared and where it was used
+type SymbolEntry struct {
+	Lexeme      string
+	Class       TokenClass
+	Type        DataType
+	Declaration *Position
+	Uses        []Position
+	// Lookups counts how many times the symbol was
+	// searched with Insert or GetToken after inserted.
+	// It tells no block nor write apart, so sem finds
+	// the unused variables on the syntax tree instead
+	Lookups int
+}
+
+func newSymbolEntry(token Token) *SymbolEntry {
+	return &SymbolEntry{
+		Lexeme: token.lexeme,
+		Class:  token.class,
+		Type:   token.dataType,
+	}
+}
+
+// Token returns the token represented by the entry
+func (e SymbolEntry) Token() Token {
+	return NewToken(e.Class, e.Lexeme, e.Type)
+}
+
+// IsDeclared returns whether a declaration was found for the symbol
+func (e SymbolEntry) IsDeclared() bool {
+	return e.Declaration != nil
+}
+
+func (e SymbolEntry) clone() SymbolEntry {
+	clone := e
+	if e.Declaration != nil {
+		declaration := *e.Declaration
+		clone.Declaration = &declaration
+	}
+	clone.Uses = append([]Position(nil), e.Uses...)
+	return clone
+}
+
+// SymbolTable is safe for concurrent use, so scanners
+// running in parallel can share the same instance. The reserved
+// words are kept apart from the identifiers, on a set that may
+// be shared with other tables
 type SymbolTable struct {
-	table map[string]Token
+	mutex           sync.RWMutex
+	table           map[string]*SymbolEntry
+	reservedWords   *ReservedWords
+	caseInsensitive bool
+	observers       []SymbolTableObserver
+}
+
+// initialTableCapacity is enough room for the identifiers of
+// a typical program, so the table doesn't need to grow while
+// scanning
+const initialTableCapacity = 128
+
+// NewSymbolTable returns a new empty symbol table. Every
+// compilation should own its table so they don't share
+// identifiers with each other
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		table: make(map[string]*SymbolEntry, initialTableCapacity),
+	}
+}
+
+// SetReservedWords makes the table recognize reservedWords,
+// replacing the reserved words it knew before
+func (s *SymbolTable) SetReservedWords(reservedWords *ReservedWords) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reservedWords = reservedWords
+}
+
+// fork returns an empty table that knows the same reserved words
+// and folds the case the same way, for a scanner that reads ahead
+// of the one the identifiers are inserted on
+func (s *SymbolTable) fork() *SymbolTable {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	forked := NewSymbolTable()
+	forked.reservedWords = s.reservedWords
+	forked.caseInsensitive = s.caseInsensitive
+	return forked
+}
+
+// reservedWord returns the token of the reserved word matching id
+func (s *SymbolTable) reservedWord(id string) (Token, bool) {
+	if s.caseInsensitive {
+		return s.reservedWords.LookupIgnoringCase(id)
+	}
+	return s.reservedWords.Lookup(id)
+}
+
+// SetCaseInsensitive makes lookups ignore the case of the
+// letters, so "Media" and "media" resolve to the same symbol.
+// The entry keeps the spelling that was inserted first, which
+// becomes its key again if the option is disabled later
+func (s *SymbolTable) SetCaseInsensitive(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.caseInsensitive = enabled
+
+	table := make(map[string]*SymbolEntry, len(s.table))
+	for id, entry := range s.table {
+		if enabled {
+			id = foldCase(id)
+		} else if strings.EqualFold(id, entry.Lexeme) {
+			id = entry.Lexeme
+		}
+		if _, found := table[id]; !found {
+			table[id] = entry
+		}
+	}
+	s.table = table
+}
+
+// maxFoldedLength is the size of the buffer used to fold
+// the case of identifiers without allocating
+const maxFoldedLength = 64
+
+// appendFolded appends the lower case version of id to buf.
+// The alphabet of mgol is ASCII, so only ASCII letters are folded
+func appendFolded(buf []byte, id string) []byte {
+	for i := 0; i < len(id); i++ {
+		char := id[i]
+		if 'A' <= char && char <= 'Z' {
+			char += 'a' - 'A'
+		}
+		buf = append(buf, char)
+	}
+	return buf
+}
+
+func foldCase(id string) string {
+	return string(appendFolded(nil, id))
+}
+
+// key returns the key used to store id on the table
+func (s *SymbolTable) key(id string) string {
+	if s.caseInsensitive {
+		return foldCase(id)
+	}
+	return id
+}
+
+// lookup returns the entry of id. The folded key is only
+// used to index the map, so the lookup doesn't allocate
+func (s *SymbolTable) lookup(id string) (*SymbolEntry, bool) {
+	if !s.caseInsensitive {
+		entry, found := s.table[id]
+		return entry, found
+	}
+
+	var buf [maxFoldedLength]byte
+	entry, found := s.table[string(appendFolded(buf[:0], id))]
+	return entry, found
 }
 
 var symbolTableInstance *SymbolTable
 
+// GetSymbolTableInstance returns a symbol table shared by
+// the whole process.
+//
+// Deprecated: use NewSymbolTable instead
 func GetSymbolTableInstance() *SymbolTable {
 	if symbolTableInstance == nil {
-		symbolTableInstance = &SymbolTable{
-			table: make(map[string]Token),
-		}
-		return symbolTableInstance
+		symbolTableInstance = NewSymbolTable()
 	}
 	return symbolTableInstance
 }
 
 func (s *SymbolTable) Insert(id string, token Token) Token {
-	tok, found := s.table[id]
+	s.mutex.Lock()
+	result, inserted := s.insert(id, token)
+	s.mutex.Unlock()
+
+	if inserted {
+		s.notifyInsert(id, result)
+	} else {
+		s.notifyLookupHit(id, result)
+	}
+	return result
+}
+
+// insert stores token on id if it is not on the table yet,
+// returning the stored token and whether it was inserted
+func (s *SymbolTable) insert(id string, token Token) (Token, bool) {
+	if reserved, found := s.reservedWord(id); found {
+		return reserved, false
+	}
+
+	entry, found := s.lookup(id)
 	if found {
-		return tok
+		entry.Lookups++
+		return entry.Token(), false
 	}
 
-	s.table[id] = token
+	entry = newSymbolEntry(token)
+	s.table[s.key(id)] = entry
 
-	return s.table[id]
+	return entry.Token(), true
+}
+
+// RegisterKeywords adds the given reserved words to the ones
+// known by the table, each one with its token class
+func (s *SymbolTable) RegisterKeywords(keywords map[string]TokenClass) {
+	inserted := map[string]Token{}
+
+	s.mutex.Lock()
+	reservedWords := s.reservedWords.With(keywords)
+	for keyword := range keywords {
+		if _, found := s.reservedWords.Lookup(keyword); !found {
+			inserted[keyword], _ = reservedWords.Lookup(keyword)
+		}
+	}
+	s.reservedWords = reservedWords
+	s.mutex.Unlock()
+
+	for keyword, token := range inserted {
+		s.notifyInsert(keyword, token)
+	}
+}
+
+// keywordIgnoringCase returns the reserved word
+// that matches lexem when case is ignored
+func (s *SymbolTable) keywordIgnoringCase(lexem string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	token, found := s.reservedWords.LookupIgnoringCase(lexem)
+	return token.lexeme, found
 }
 
 func (s *SymbolTable) GetToken(lexem string) (Token, error) {
-	token, found := s.table[lexem]
-	if !found {
-		return Token{}, ErrorSymbolNotFound
+	s.mutex.Lock()
+	if reserved, found := s.reservedWord(lexem); found {
+		s.mutex.Unlock()
+
+		s.notifyLookupHit(lexem, reserved)
+		return reserved, nil
+	}
+
+	entry, found := s.lookup(lexem)
+	if found {
+		entry.Lookups++
+		token := entry.Token()
+		s.mutex.Unlock()
+
+		s.notifyLookupHit(lexem, token)
+		return token, nil
 	}
-	return token, nil
+	s.mutex.Unlock()
+
+	s.notifyLookupMiss(lexem)
+	return Token{}, ErrorSymbolNotFound
 }
 
+// GetEntry returns a copy of everything the table knows about
+// lexem. It is not counted as one of the lookups of the symbol
+func (s *SymbolTable) GetEntry(lexem string) (SymbolEntry, error) {
+	s.mutex.RLock()
+	if reserved, found := s.reservedWord(lexem); found {
+		s.mutex.RUnlock()
+
+		s.notifyLookupHit(lexem, reserved)
+		return *newSymbolEntry(reserved), nil
+	}
+
+	entry, found := s.lookup(lexem)
+	if found {
+		clone := entry.clone()
+		s.mutex.RUnlock()
+
+		s.notifyLookupHit(lexem, clone.Token())
+		return clone, nil
+	}
+	s.mutex.RUnlock()
+
+	s.notifyLookupMiss(lexem)
+	return SymbolEntry{}, ErrorSymbolNotFound
+}
+
+// Update replaces the token data of id, keeping
+// its declaration and the positions it was used
 func (s *SymbolTable) Update(id string, newToken Token) error {
-	_, found := s.table[id]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.reservedWord(id); found {
+		return ErrorReservedWord
+	}
+
+	entry, found := s.lookup(id)
+	if !found {
+		return ErrorSymbolNotFound
+	}
+	entry.Lexeme = newToken.lexeme
+	entry.Class = newToken.class
+	entry.Type = newToken.dataType
+	return nil
+}
+
+// AddUse records that id appears on position
+func (s *SymbolTable) AddUse(id string, position Position) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.reservedWord(id); found {
+		return ErrorReservedWord
+	}
+
+	entry, found := s.lookup(id)
+	if !found {
+		return ErrorSymbolNotFound
+	}
+	entry.Uses = append(entry.Uses, position)
+	return nil
+}
+
+// SetType records dataType as the type of the identifier id
+func (s *SymbolTable) SetType(id string, dataType DataType) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.reservedWord(id); found {
+		return ErrorReservedWord
+	}
+
+	entry, found := s.lookup(id)
+	if !found {
+		return ErrorSymbolNotFound
+	}
+	entry.Type = dataType
+	return nil
+}
+
+// GetType returns the type recorded for id, which is
+// NULL while the identifier wasn't declared
+func (s *SymbolTable) GetType(id string) (DataType, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if reserved, found := s.reservedWord(id); found {
+		return reserved.dataType, nil
+	}
+
+	entry, found := s.lookup(id)
+	if !found {
+		return NULL, ErrorSymbolNotFound
+	}
+	return entry.Type, nil
+}
+
+// Declare sets the type of id and marks its most recent
+// use as the place where it was declared
+func (s *SymbolTable) Declare(id string, dataType DataType) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.reservedWord(id); found {
+		return ErrorReservedWord
+	}
+
+	entry, found := s.lookup(id)
 	if !found {
 		return ErrorSymbolNotFound
 	}
-	s.table[id] = newToken
+	entry.Type = dataType
+
+	if len(entry.Uses) > 0 {
+		declaration := entry.Uses[len(entry.Uses)-1]
+		entry.Declaration = &declaration
+		entry.Uses = entry.Uses[:len(entry.Uses)-1]
+	}
 	return nil
 }
 
+// SymbolTableSnapshot is a copy of the state of a
+// symbol table taken by Snapshot
+type SymbolTableSnapshot struct {
+	table           map[string]SymbolEntry
+	reservedWords   *ReservedWords
+	caseInsensitive bool
+}
+
+// Snapshot copies the current state of the table, so the
+// changes made after it can be rolled back with Restore
+func (s *SymbolTable) Snapshot() SymbolTableSnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshot := SymbolTableSnapshot{
+		table:           make(map[string]SymbolEntry, len(s.table)),
+		reservedWords:   s.reservedWords,
+		caseInsensitive: s.caseInsensitive,
+	}
+	for key, entry := range s.table {
+		snapshot.table[key] = entry.clone()
+	}
+	return snapshot
+}
+
+// Restore puts the table back on the state it had when
+// snapshot was taken. The observers are kept
+func (s *SymbolTable) Restore(snapshot SymbolTableSnapshot) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.table = make(map[string]*SymbolEntry, len(snapshot.table))
+	for key, entry := range snapshot.table {
+		clone := entry.clone()
+		s.table[key] = &clone
+	}
+	s.reservedWords = snapshot.reservedWords
+	s.caseInsensitive = snapshot.caseInsensitive
+}
+
+// Cleanup removes every identifier from the table.
+// The reserved words are kept
 func (s *SymbolTable) Cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for k := range s.table {
 		delete(s.table, k)
 	}
 }
 
 func (s *SymbolTable) Print() {
+	s.mutex.RLock()
 	data := pterm.TableData{{"Chave", "Valor"}}
+	for _, token := range s.reservedWords.Tokens() {
+		data = append(data, []string{token.lexeme, token.String()})
+	}
 	for k, v := range s.table {
-		data = append(data, []string{k, v.String()})
+		data = append(data, []string{k, v.Token().String()})
 	}
+	s.mutex.RUnlock()
+
 	pterm.DefaultTable.WithHasHeader().WithData(data).Render()
 }
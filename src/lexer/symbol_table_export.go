@@ -0,0 +1,177 @@
+package lexer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var ErrorUnknownDumpFormat = fmt.Errorf("unknown dump format")
+
+type DumpFormat string
+
+// Available formats to dump the symbol table and the tokens
+const (
+	JSONFormat  DumpFormat = "json"
+	CSVFormat   DumpFormat = "csv"
+	TableFormat DumpFormat = "tabela"
+)
+
+type dumpedEntry struct {
+	Key         string     `json:"key"`
+	Lexeme      string     `json:"lexeme"`
+	Class       TokenClass `json:"class"`
+	Type        DataType   `json:"type"`
+	Declaration *Position  `json:"declaration"`
+	Uses        []Position `json:"uses"`
+	Lookups     int        `json:"lookups"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// sortedEntries returns a copy of every entry of the table
+// ordered by its key, including the reserved words if asked
+func (s *SymbolTable) sortedEntries(includeReservedWords bool) []dumpedEntry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := make([]dumpedEntry, 0, len(s.table))
+	for key, entry := range s.table {
+		clone := entry.clone()
+		if clone.Uses == nil {
+			clone.Uses = []Position{}
+		}
+		entries = append(entries, dumpedEntry{
+			Key:         key,
+			Lexeme:      clone.Lexeme,
+			Class:       clone.Class,
+			Type:        clone.Type,
+			Declaration: clone.Declaration,
+			Uses:        clone.Uses,
+			Lookups:     clone.Lookups,
+		})
+	}
+
+	if includeReservedWords {
+		for _, token := range s.reservedWords.Tokens() {
+			entries = append(entries, dumpedEntry{
+				Key:    token.lexeme,
+				Lexeme: token.lexeme,
+				Class:  token.class,
+				Type:   token.dataType,
+				Uses:   []Position{},
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Dump writes every entry of the table with its metadata to w
+// using the given format, reserved words included. The entries
+// are ordered by their key
+func (s *SymbolTable) Dump(w io.Writer, format DumpFormat) error {
+	entries := s.sortedEntries(true)
+
+	switch format {
+	case JSONFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case CSVFormat:
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"Chave", "Lexema", "Classe", "Tipo", "Declaração", "Usos", "Consultas"})
+		for _, entry := range entries {
+			declaration := ""
+			if entry.Declaration != nil {
+				declaration = entry.Declaration.String()
+			}
+
+			uses := []string{}
+			for _, use := range entry.Uses {
+				uses = append(uses, use.String())
+			}
+
+			writer.Write([]string{
+				entry.Key,
+				entry.Lexeme,
+				string(entry.Class),
+				string(entry.Type),
+				declaration,
+				strings.Join(uses, " "),
+				strconv.Itoa(entry.Lookups),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	case TableFormat:
+		return s.WriteListing(w)
+	}
+
+	return ErrorUnknownDumpFormat
+}
+
+// WriteCrossReference writes to w the cross-reference listing of
+// the identifiers on the table: the line where each one was
+// declared followed by the lines where it was used
+func (s *SymbolTable) WriteCrossReference(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Identificador\tDeclaração\tUsos")
+
+	identifiers := []dumpedEntry{}
+	for _, entry := range s.sortedEntries(false) {
+		if entry.Class == IDENTIFIER {
+			identifiers = append(identifiers, entry)
+		}
+	}
+	sort.SliceStable(identifiers, func(i, j int) bool {
+		return identifiers[i].Lexeme < identifiers[j].Lexeme
+	})
+
+	for _, entry := range identifiers {
+		declaration := "-"
+		if entry.Declaration != nil {
+			declaration = strconv.Itoa(entry.Declaration.Line)
+		}
+
+		lines := []string{}
+		for index, use := range entry.Uses {
+			if index > 0 && entry.Uses[index-1].Line == use.Line {
+				continue
+			}
+			lines = append(lines, strconv.Itoa(use.Line))
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", entry.Lexeme, declaration, strings.Join(lines, ", "))
+	}
+
+	return writer.Flush()
+}
+
+// WriteListing writes to w a listing of every entry of the
+// table, reserved words included, with aligned columns for
+// the lexeme, class, type and declaration line of each one
+func (s *SymbolTable) WriteListing(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Lexema\tClasse\tTipo\tDeclaração")
+
+	for _, entry := range s.sortedEntries(true) {
+		declaration := "-"
+		if entry.Declaration != nil {
+			declaration = strconv.Itoa(entry.Declaration.Line)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", entry.Lexeme, entry.Class, entry.Type, declaration)
+	}
+
+	return writer.Flush()
+}
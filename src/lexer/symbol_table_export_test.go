@@ -0,0 +1,153 @@
+package lexer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	prepareTable := func() *SymbolTable {
+		table := NewSymbolTable()
+		table.Insert("se", NewToken("se", "se", "se"))
+		table.Insert("B", NewToken(IDENTIFIER, "B", NULL))
+		table.AddUse("B", Position{Line: 3, Column: 1})
+		table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+		table.AddUse("A", Position{Line: 1, Column: 9})
+		table.Declare("A", INTEGER)
+		table.AddUse("A", Position{Line: 2, Column: 1})
+		table.AddUse("A", Position{Line: 2, Column: 6})
+		return table
+	}
+
+	testCases := []struct {
+		name           string
+		format         DumpFormat
+		expectedError  error
+		expectedOutput string
+	}{
+		{
+			name:   "Dump as JSON",
+			format: JSONFormat,
+			expectedOutput: `[
+  {
+    "key": "A",
+    "lexeme": "A",
+    "class": "id",
+    "type": "inteiro",
+    "declaration": {
+      "line": 1,
+      "column": 9
+    },
+    "uses": [
+      {
+        "line": 2,
+        "column": 1
+      },
+      {
+        "line": 2,
+        "column": 6
+      }
+    ],
+    "lookups": 0
+  },
+  {
+    "key": "B",
+    "lexeme": "B",
+    "class": "id",
+    "type": "NULO",
+    "declaration": null,
+    "uses": [
+      {
+        "line": 3,
+        "column": 1
+      }
+    ],
+    "lookups": 0
+  },
+  {
+    "key": "se",
+    "lexeme": "se",
+    "class": "se",
+    "type": "se",
+    "declaration": null,
+    "uses": [],
+    "lookups": 0
+  }
+]
+`,
+		},
+		{
+			name:   "Dump as CSV",
+			format: CSVFormat,
+			expectedOutput: "Chave,Lexema,Classe,Tipo,Declaração,Usos,Consultas\n" +
+				"A,A,id,inteiro,1:9,2:1 2:6,0\n" +
+				"B,B,id,NULO,,3:1,0\n" +
+				"se,se,se,se,,,0\n",
+		},
+		{
+			name:           "Unknown format",
+			format:         "xml",
+			expectedError:  ErrorUnknownDumpFormat,
+			expectedOutput: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := prepareTable().Dump(&buf, tc.format)
+			require.Equal(t, tc.expectedError, err)
+			require.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
+
+func TestWriteCrossReference(t *testing.T) {
+	file, err := ioutil.TempFile("", "xref-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("varinicio\ninteiro Total;\nvarfim;\nTotal <- Total + B;\nB <- 1;\n\nTotal <- B;")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	table := NewSymbolTable()
+	table.RegisterKeywords(DefaultKeywords())
+
+	scanner := NewScanner(file, table)
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		if token.GetLexem() == "Total" && token.GetType() == NULL {
+			table.Declare("Total", INTEGER)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, table.WriteCrossReference(&buf))
+	require.Equal(t, ""+
+		"Identificador  Declaração  Usos\n"+
+		"B              -           4, 5, 7\n"+
+		"Total          2           4, 7\n", buf.String())
+}
+
+func TestWriteListing(t *testing.T) {
+	table := NewSymbolTable()
+	table.RegisterKeywords(map[string]TokenClass{"inteiro": "inteiro", "se": "se"})
+	table.Insert("Contador", NewToken(IDENTIFIER, "Contador", NULL))
+	table.AddUse("Contador", Position{Line: 12, Column: 9})
+	table.Declare("Contador", INTEGER)
+	table.Insert("X", NewToken(IDENTIFIER, "X", NULL))
+
+	var buf bytes.Buffer
+	require.NoError(t, table.WriteListing(&buf))
+	require.Equal(t, ""+
+		"Lexema    Classe   Tipo     Declaração\n"+
+		"Contador  id       inteiro  12\n"+
+		"X         id       NULO     -\n"+
+		"inteiro   inteiro  inteiro  -\n"+
+		"se        se       se       -\n", buf.String())
+}
@@ -0,0 +1,55 @@
+package lexer
+
+// SymbolTableObserver holds callbacks fired while the symbol
+// table is used, allowing tools to follow how it evolves.
+// Any of them may be nil. The callbacks run after the table
+// is unlocked, so they are free to query it
+type SymbolTableObserver struct {
+	// OnInsert is fired when a new symbol is stored
+	OnInsert func(id string, token Token)
+	// OnLookupHit is fired when a searched symbol is found,
+	// including inserts of symbols already on the table
+	OnLookupHit func(id string, token Token)
+	// OnLookupMiss is fired when a searched symbol is not found
+	OnLookupMiss func(id string)
+}
+
+// AddObserver registers observer to be notified
+// about the inserts and lookups on the table
+func (s *SymbolTable) AddObserver(observer SymbolTableObserver) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.observers = append(s.observers, observer)
+}
+
+func (s *SymbolTable) getObservers() []SymbolTableObserver {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.observers
+}
+
+func (s *SymbolTable) notifyInsert(id string, token Token) {
+	for _, observer := range s.getObservers() {
+		if observer.OnInsert != nil {
+			observer.OnInsert(id, token)
+		}
+	}
+}
+
+func (s *SymbolTable) notifyLookupHit(id string, token Token) {
+	for _, observer := range s.getObservers() {
+		if observer.OnLookupHit != nil {
+			observer.OnLookupHit(id, token)
+		}
+	}
+}
+
+func (s *SymbolTable) notifyLookupMiss(id string) {
+	for _, observer := range s.getObservers() {
+		if observer.OnLookupMiss != nil {
+			observer.OnLookupMiss(id)
+		}
+	}
+}
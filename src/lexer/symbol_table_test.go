@@ -1,6 +1,9 @@
 package lexer
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,15 +46,13 @@ func TestInsert(t *testing.T) {
 		},
 	}
 
-	symbolTable := GetSymbolTableInstance()
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			symbolTable := NewSymbolTable()
 			for index, key := range tc.keys {
 				token := symbolTable.Insert(key, tc.values[index])
 				require.Equal(t, tc.expectedResult[index], token)
 			}
-			symbolTable.Cleanup()
 		})
 	}
 }
@@ -112,7 +113,7 @@ func TestGetToken(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			table := GetSymbolTableInstance()
+			table := NewSymbolTable()
 			tc.prepareFunction(table)
 			token, err := table.GetToken(tc.key)
 			if tc.expectedError != nil {
@@ -121,7 +122,6 @@ func TestGetToken(t *testing.T) {
 				require.NoError(t, err)
 			}
 			require.Equal(t, tc.expectedToken, token)
-			table.Cleanup()
 		})
 	}
 }
@@ -189,7 +189,7 @@ func TestUpdate(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			table := GetSymbolTableInstance()
+			table := NewSymbolTable()
 
 			tc.prepareFunction(table)
 
@@ -203,8 +203,364 @@ func TestUpdate(t *testing.T) {
 				require.Equal(t, tc.expectedToken, accquiredToken)
 				require.NoError(t, err)
 			}
+		})
+	}
+}
+
+func TestSymbolTablesAreIndependent(t *testing.T) {
+	first := NewSymbolTable()
+	second := NewSymbolTable()
+
+	first.Insert("A", NewToken(IDENTIFIER, "A", INTEGER))
+
+	_, err := second.GetToken("A")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+}
+
+func TestGetSymbolTableInstance(t *testing.T) {
+	require.True(t, GetSymbolTableInstance() == GetSymbolTableInstance())
+}
+
+func TestSymbolEntry(t *testing.T) {
+	testCases := []struct {
+		name            string
+		prepareFunction func(table *SymbolTable)
+		key             string
+		expectedError   error
+		expectedEntry   SymbolEntry
+	}{
+		{
+			name: "Entry without uses",
+			prepareFunction: func(table *SymbolTable) {
+				table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+			},
+			key: "A",
+			expectedEntry: SymbolEntry{
+				Lexeme: "A",
+				Class:  IDENTIFIER,
+				Type:   NULL,
+			},
+		},
+		{
+			name: "Declared entry with uses",
+			prepareFunction: func(table *SymbolTable) {
+				table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+				table.AddUse("A", Position{Line: 2, Column: 9})
+				table.Declare("A", INTEGER)
+				table.AddUse("A", Position{Line: 5, Column: 1})
+				table.AddUse("A", Position{Line: 6, Column: 7})
+			},
+			key: "A",
+			expectedEntry: SymbolEntry{
+				Lexeme:      "A",
+				Class:       IDENTIFIER,
+				Type:        INTEGER,
+				Declaration: &Position{Line: 2, Column: 9},
+				Uses:        []Position{{Line: 5, Column: 1}, {Line: 6, Column: 7}},
+			},
+		},
+		{
+			name: "Update keeps the metadata",
+			prepareFunction: func(table *SymbolTable) {
+				table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+				table.AddUse("A", Position{Line: 1, Column: 1})
+				table.Update("A", NewToken(IDENTIFIER, "A", REAL))
+			},
+			key: "A",
+			expectedEntry: SymbolEntry{
+				Lexeme: "A",
+				Class:  IDENTIFIER,
+				Type:   REAL,
+				Uses:   []Position{{Line: 1, Column: 1}},
+			},
+		},
+		{
+			name:            "Non-existing entry",
+			prepareFunction: func(table *SymbolTable) {},
+			key:             "A",
+			expectedError:   ErrorSymbolNotFound,
+			expectedEntry:   SymbolEntry{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := NewSymbolTable()
+			tc.prepareFunction(table)
+
+			entry, err := table.GetEntry(tc.key)
+			require.Equal(t, tc.expectedError, err)
+			require.Equal(t, tc.expectedEntry, entry)
+		})
+	}
+}
+
+func TestCaseInsensitiveLookup(t *testing.T) {
+	table := NewSymbolTable()
+	table.Insert("Media", NewToken(IDENTIFIER, "Media", NULL))
+	table.SetCaseInsensitive(true)
+
+	token := table.Insert("media", NewToken(IDENTIFIER, "media", NULL))
+	require.Equal(t, NewToken(IDENTIFIER, "Media", NULL), token)
+
+	token, err := table.GetToken("MEDIA")
+	require.NoError(t, err)
+	require.Equal(t, NewToken(IDENTIFIER, "Media", NULL), token)
+
+	require.NoError(t, table.Declare("mEdIa", REAL))
+	token, err = table.GetToken("Media")
+	require.NoError(t, err)
+	require.Equal(t, NewToken(IDENTIFIER, "Media", REAL), token)
+
+	table.SetCaseInsensitive(false)
+	_, err = table.GetToken("media")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+}
+
+func TestRegisterKeywords(t *testing.T) {
+	testCases := []struct {
+		name          string
+		keywords      map[string]TokenClass
+		key           string
+		expectedError error
+		expectedToken Token
+	}{
+		{
+			name:          "Default keyword",
+			keywords:      DefaultKeywords(),
+			key:           "fimrepita",
+			expectedToken: NewToken("fimrepita", "fimrepita", "fimrepita"),
+		},
+		{
+			name:          "Keyword of a language variant",
+			keywords:      map[string]TokenClass{"enquanto": "repita"},
+			key:           "enquanto",
+			expectedToken: NewToken("repita", "enquanto", "enquanto"),
+		},
+		{
+			name:          "Default keyword missing on a language variant",
+			keywords:      map[string]TokenClass{"enquanto": "repita"},
+			key:           "repita",
+			expectedError: ErrorSymbolNotFound,
+			expectedToken: Token{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := NewSymbolTable()
+			table.RegisterKeywords(tc.keywords)
+
+			token, err := table.GetToken(tc.key)
+			require.Equal(t, tc.expectedError, err)
+			require.Equal(t, tc.expectedToken, token)
+		})
+	}
+}
+
+func TestDefaultKeywordsAreIndependent(t *testing.T) {
+	keywords := DefaultKeywords()
+	delete(keywords, "se")
+
+	require.Equal(t, TokenClass("se"), DefaultKeywords()["se"])
+}
+
+func TestSymbolTableObserver(t *testing.T) {
+	events := []string{}
+	table := NewSymbolTable()
+	table.AddObserver(SymbolTableObserver{
+		OnInsert: func(id string, token Token) {
+			events = append(events, "insert "+id)
+		},
+		OnLookupHit: func(id string, token Token) {
+			events = append(events, "hit "+id)
+		},
+		OnLookupMiss: func(id string) {
+			events = append(events, "miss "+id)
+		},
+	})
+	table.AddObserver(SymbolTableObserver{
+		OnLookupMiss: func(id string) {
+			_, found := table.keywordIgnoringCase(id)
+			require.False(t, found)
+		},
+	})
+
+	table.RegisterKeywords(map[string]TokenClass{"se": "se"})
+	table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+	table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+	table.GetToken("se")
+	table.GetEntry("B")
+
+	require.Equal(t, []string{"insert se", "insert A", "hit A", "hit se", "miss B"}, events)
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	table := NewSymbolTable()
+	table.RegisterKeywords(DefaultKeywords())
+	table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+	table.AddUse("A", Position{Line: 1, Column: 9})
+	table.Declare("A", INTEGER)
+
+	snapshot := table.Snapshot()
+
+	table.Insert("B", NewToken(IDENTIFIER, "B", NULL))
+	table.AddUse("A", Position{Line: 3, Column: 1})
+	table.Update("A", NewToken(IDENTIFIER, "A", REAL))
+
+	table.Restore(snapshot)
+
+	_, err := table.GetToken("B")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+
+	entry, err := table.GetEntry("A")
+	require.NoError(t, err)
+	require.Equal(t, SymbolEntry{
+		Lexeme:      "A",
+		Class:       IDENTIFIER,
+		Type:        INTEGER,
+		Declaration: &Position{Line: 1, Column: 9},
+	}, entry)
+
+	token, err := table.GetToken("se")
+	require.NoError(t, err)
+	require.Equal(t, NewToken("se", "se", "se"), token)
+
+	table.Insert("C", NewToken(IDENTIFIER, "C", NULL))
+	table.Restore(snapshot)
+	_, err = table.GetToken("C")
+	require.ErrorIs(t, err, ErrorSymbolNotFound)
+}
+
+func benchmarkLookups(b *testing.B, caseInsensitive bool, lexems []string) {
+	table := NewSymbolTable()
+	table.SetCaseInsensitive(caseInsensitive)
+	table.RegisterKeywords(DefaultKeywords())
+	for _, lexem := range lexems {
+		table.Insert(lexem, NewToken(IDENTIFIER, lexem, NULL))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lexem := lexems[i%len(lexems)]
+		table.Insert(lexem, NewToken(IDENTIFIER, lexem, NULL))
+		table.GetToken(lexem)
+		table.keywordIgnoringCase(lexem)
+	}
+}
+
+func BenchmarkKeywordLookups(b *testing.B) {
+	benchmarkLookups(b, false, defaultKeywords)
+}
+
+func BenchmarkIdentifierLookups(b *testing.B) {
+	lexems := []string{}
+	for i := 0; i < 1000; i++ {
+		lexems = append(lexems, fmt.Sprintf("Identificador_%d", i))
+	}
+	benchmarkLookups(b, false, lexems)
+}
+
+func BenchmarkCaseInsensitiveIdentifierLookups(b *testing.B) {
+	lexems := []string{}
+	for i := 0; i < 1000; i++ {
+		lexems = append(lexems, fmt.Sprintf("Identificador_%d", i))
+	}
+	benchmarkLookups(b, true, lexems)
+}
+
+func TestLookups(t *testing.T) {
+	file, err := ioutil.TempFile("", "lookups-test")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString("varinicio\ninteiro A;\ninteiro B;\ninteiro C;\nvarfim;\nA <- C;")
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	table := NewSymbolTable()
+	table.SetReservedWords(DefaultReservedWords())
+
+	scanner := NewScanner(file, table)
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		if token.GetClass() == string(IDENTIFIER) && token.GetType() == NULL {
+			table.Declare(token.GetLexem(), INTEGER)
+		}
+	}
+
+	for lexeme, lookups := range map[string]int{"A": 1, "B": 0, "C": 1} {
+		entry, err := table.GetEntry(lexeme)
+		require.NoError(t, err)
+		require.Equal(t, lookups, entry.Lookups, lexeme)
+	}
+
+	table.GetToken("B")
+	entry, err := table.GetEntry("B")
+	require.NoError(t, err)
+	require.Equal(t, 1, entry.Lookups)
+	require.Equal(t, &Position{Line: 3, Column: 9}, entry.Declaration)
+}
+
+func TestSetAndGetType(t *testing.T) {
+	testCases := []struct {
+		name             string
+		prepareFunction  func(table *SymbolTable) error
+		key              string
+		expectedError    error
+		expectedType     DataType
+		expectedGetError error
+	}{
+		{
+			name: "Type of an undeclared identifier",
+			prepareFunction: func(table *SymbolTable) error {
+				table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+				return nil
+			},
+			key:          "A",
+			expectedType: NULL,
+		},
+		{
+			name: "Annotated identifier",
+			prepareFunction: func(table *SymbolTable) error {
+				table.Insert("A", NewToken(IDENTIFIER, "A", NULL))
+				return table.SetType("A", REAL)
+			},
+			key:          "A",
+			expectedType: REAL,
+		},
+		{
+			name: "Annotating a non-existing identifier",
+			prepareFunction: func(table *SymbolTable) error {
+				return table.SetType("A", REAL)
+			},
+			key:              "A",
+			expectedError:    ErrorSymbolNotFound,
+			expectedType:     NULL,
+			expectedGetError: ErrorSymbolNotFound,
+		},
+		{
+			name: "Annotating a reserved word",
+			prepareFunction: func(table *SymbolTable) error {
+				return table.SetType("inteiro", REAL)
+			},
+			key:           "inteiro",
+			expectedError: ErrorReservedWord,
+			expectedType:  INTEGER,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := NewSymbolTable()
+			table.SetReservedWords(DefaultReservedWords())
+
+			require.Equal(t, tc.expectedError, tc.prepareFunction(table))
 
-			table.Cleanup()
+			dataType, err := table.GetType(tc.key)
+			require.Equal(t, tc.expectedGetError, err)
+			require.Equal(t, tc.expectedType, dataType)
 		})
 	}
 }
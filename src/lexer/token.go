@@ -78,22 +78,36 @@ var (
 	}
 )
 
-//Language Reserved Tokens
-var LanguageReservedTokens = []Token{
-	NewToken("inicio", "inicio", "inicio"),
-	NewToken("varinicio", "varinicio", "varinicio"),
-	NewToken("varfim", "varfim", "varfim"),
-	NewToken("escreva", "escreva", "escreva"),
-	NewToken("leia", "leia", "leia"),
-	NewToken("se", "se", "se"),
-	NewToken("entao", "entao", "entao"),
-	NewToken("fimse", "fimse", "fimse"),
-	NewToken("repita", "repita", "repita"),
-	NewToken("fimrepita", "fimrepita", "fimrepita"),
-	NewToken("fim", "fim", "fim"),
-	NewToken("inteiro", "inteiro", "inteiro"),
-	NewToken("literal", "literal", "literal"),
-	NewToken("real", "real", "real"),
+//Language Reserved Words
+var defaultKeywords = []string{
+	"inicio",
+	"varinicio",
+	"varfim",
+	"escreva",
+	"leia",
+	"se",
+	"entao",
+	"senao",
+	"fimse",
+	"repita",
+	"fimrepita",
+	"enquanto",
+	"fimenquanto",
+	"fim",
+	"inteiro",
+	"literal",
+	"real",
+}
+
+// DefaultKeywords returns the reserved words of mgol mapped to
+// their token classes. Language variants can change the returned
+// map before registering it on a symbol table
+func DefaultKeywords() map[string]TokenClass {
+	keywords := make(map[string]TokenClass, len(defaultKeywords))
+	for _, keyword := range defaultKeywords {
+		keywords[keyword] = TokenClass(keyword)
+	}
+	return keywords
 }
 
 func NewToken(class TokenClass, lexeme string, dataType DataType) Token {
@@ -0,0 +1,61 @@
+package lexer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// tokenColumns are the columns of the tokens on a table or CSV
+var tokenColumns = []string{"Linha", "Coluna", "Classe", "Lexema", "Tipo"}
+
+type dumpedToken struct {
+	Line   int        `json:"line"`
+	Column int        `json:"column"`
+	Class  TokenClass `json:"class"`
+	Lexeme string     `json:"lexeme"`
+	Type   DataType   `json:"type"`
+}
+
+// DumpTokens writes tokens to w using the given format, in the
+// order they were read, each with the position where it starts
+func DumpTokens(w io.Writer, tokens []ScannedToken, format DumpFormat) error {
+	dumped := make([]dumpedToken, len(tokens))
+	for index, token := range tokens {
+		dumped[index] = dumpedToken{
+			Line:   token.Start.Line,
+			Column: token.Start.Column,
+			Class:  token.Token.class,
+			Lexeme: token.Token.lexeme,
+			Type:   token.Token.dataType,
+		}
+	}
+
+	switch format {
+	case JSONFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dumped)
+	case CSVFormat:
+		writer := csv.NewWriter(w)
+		writer.Write(tokenColumns)
+		for _, token := range dumped {
+			writer.Write([]string{strconv.Itoa(token.Line), strconv.Itoa(token.Column), string(token.Class), token.Lexeme, string(token.Type)})
+		}
+		writer.Flush()
+		return writer.Error()
+	case TableFormat:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(tokenColumns, "\t"))
+		for _, token := range dumped {
+			fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t%s\n", token.Line, token.Column, token.Class, token.Lexeme, token.Type)
+		}
+		return writer.Flush()
+	}
+
+	return ErrorUnknownDumpFormat
+}
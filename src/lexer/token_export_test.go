@@ -0,0 +1,82 @@
+package lexer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpTokens(t *testing.T) {
+	tokens := []ScannedToken{
+		{Token: NewToken("leia", "leia", "leia"), Start: Position{Line: 1, Column: 1}},
+		{Token: NewToken(IDENTIFIER, "Total", INTEGER), Start: Position{Line: 1, Column: 6}},
+		{Token: NewToken(SEMICOLON, ";", NULL), Start: Position{Line: 1, Column: 11}},
+	}
+
+	testCases := []struct {
+		name           string
+		format         DumpFormat
+		expectedError  error
+		expectedOutput string
+	}{
+		{
+			name:   "Dump as a table",
+			format: TableFormat,
+			expectedOutput: "Linha  Coluna  Classe  Lexema  Tipo\n" +
+				"1      1       leia    leia    leia\n" +
+				"1      6       id      Total   inteiro\n" +
+				"1      11      PT_V    ;       NULO\n",
+		},
+		{
+			name:   "Dump as JSON",
+			format: JSONFormat,
+			expectedOutput: `[
+  {
+    "line": 1,
+    "column": 1,
+    "class": "leia",
+    "lexeme": "leia",
+    "type": "leia"
+  },
+  {
+    "line": 1,
+    "column": 6,
+    "class": "id",
+    "lexeme": "Total",
+    "type": "inteiro"
+  },
+  {
+    "line": 1,
+    "column": 11,
+    "class": "PT_V",
+    "lexeme": ";",
+    "type": "NULO"
+  }
+]
+`,
+		},
+		{
+			name:   "Dump as CSV",
+			format: CSVFormat,
+			expectedOutput: "Linha,Coluna,Classe,Lexema,Tipo\n" +
+				"1,1,leia,leia,leia\n" +
+				"1,6,id,Total,inteiro\n" +
+				"1,11,PT_V,;,NULO\n",
+		},
+		{
+			name:          "Unknown format",
+			format:        "xml",
+			expectedError: ErrorUnknownDumpFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := DumpTokens(&buf, tokens, tc.format)
+			require.Equal(t, tc.expectedError, err)
+			require.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
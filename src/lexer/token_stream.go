@@ -0,0 +1,150 @@
+package lexer
+
+// ScannedToken is a token read from the source
+// along with where it is on the source
+type ScannedToken struct {
+	Token Token
+	// Start is the position of the first character of the token
+	// and End the one of its last character. End is zero on EOF
+	Start Position
+	End   Position
+	// StartOffset and EndOffset are the byte offsets of
+	// the first character and of the one after the token
+	StartOffset int64
+	EndOffset   int64
+	// Source is the scanner the token was read from
+	Source *Scanner
+}
+
+// TokenSource gives the tokens of a file one at a time, comments
+// and errors included, and EOF once past its end. A Scanner is one,
+// and a PrefetchSource reads the tokens of a scanner ahead
+type TokenSource interface {
+	NextToken() ScannedToken
+}
+
+// TokenStream reads the tokens of a TokenSource with any lookahead,
+// skipping comment and error tokens, which the scanner already
+// reported. Tokens read ahead are buffered until consumed, and
+// the ones after a mark are kept until it is released, so the
+// reader can rewind to it. The tokens of several sources, one
+// for each file of a program, are read one source after another
+type TokenStream struct {
+	sources []TokenSource
+	// buffer holds the tokens read from the scanner and not
+	// discarded yet, first being the one at index base
+	buffer []ScannedToken
+	base   int
+	// position is the index of the next token to be consumed
+	position int
+	// marks counts the marks on each index not released yet
+	marks map[int]int
+}
+
+func NewTokenStream(scanners ...*Scanner) *TokenStream {
+	stream := NewSourceStream()
+	for _, scanner := range scanners {
+		stream.AddScanner(scanner)
+	}
+	return stream
+}
+
+// NewSourceStream returns a stream of the tokens of sources
+func NewSourceStream(sources ...TokenSource) *TokenStream {
+	return &TokenStream{
+		sources: sources,
+		marks:   make(map[int]int),
+	}
+}
+
+// AddScanner makes the stream read the tokens of
+// scanner after the ones of the sources it has
+func (s *TokenStream) AddScanner(scanner *Scanner) {
+	s.AddSource(scanner)
+}
+
+// AddSource makes the stream read the tokens of
+// source after the ones of the sources it has
+func (s *TokenStream) AddSource(source TokenSource) {
+	s.sources = append(s.sources, source)
+}
+
+// Peek returns the token k positions after the next one to be
+// consumed, without consuming it. Peek(0) is the next token. Past
+// the end of the source every token is EOF
+func (s *TokenStream) Peek(k int) ScannedToken {
+	index := s.position + k - s.base
+	for len(s.buffer) <= index {
+		if last := len(s.buffer) - 1; last >= 0 && s.buffer[last].Token == EOF_TOKEN {
+			return s.buffer[last]
+		}
+		s.buffer = append(s.buffer, s.scan())
+	}
+	return s.buffer[index]
+}
+
+// Next consumes the next token and returns it
+func (s *TokenStream) Next() ScannedToken {
+	token := s.Peek(0)
+	if token.Token != EOF_TOKEN {
+		s.position++
+		s.discard()
+	}
+	return token
+}
+
+// Mark returns the position of the next token to
+// be consumed, to which the stream can be rewound
+// with Rewind until the mark is released
+func (s *TokenStream) Mark() int {
+	s.marks[s.position]++
+	return s.position
+}
+
+// Rewind makes the token on mark be the next one to be consumed
+func (s *TokenStream) Rewind(mark int) {
+	if s.marks[mark] == 0 {
+		panic("rewind to a released mark")
+	}
+	s.position = mark
+}
+
+// Release tells the stream it will not be rewound to
+// mark anymore, so the tokens before the next one to
+// be consumed may be discarded
+func (s *TokenStream) Release(mark int) {
+	if s.marks[mark]--; s.marks[mark] <= 0 {
+		delete(s.marks, mark)
+	}
+	s.discard()
+}
+
+// discard drops the tokens consumed that no mark needs
+func (s *TokenStream) discard() {
+	oldest := s.position
+	for mark := range s.marks {
+		if mark < oldest {
+			oldest = mark
+		}
+	}
+	if oldest > s.base {
+		s.buffer = s.buffer[oldest-s.base:]
+		s.base = oldest
+	}
+}
+
+// scan reads the next token that is not skipped, going on
+// to the next source when one reaches the end of its file
+func (s *TokenStream) scan() ScannedToken {
+	if len(s.sources) == 0 {
+		return ScannedToken{Token: EOF_TOKEN}
+	}
+	token := s.sources[0].NextToken()
+	for token.Token == COMMENT_TOKEN || token.Token == ERROR_TOKEN || (token.Token == EOF_TOKEN && len(s.sources) > 1) {
+		if token.Token == EOF_TOKEN {
+			s.sources = s.sources[1:]
+		}
+		token = s.sources[0].NextToken()
+	}
+	return token
+}
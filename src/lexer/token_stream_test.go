@@ -0,0 +1,109 @@
+package lexer
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenStream(t *testing.T, source string) *TokenStream {
+	file, err := ioutil.TempFile("", "token-stream-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+
+	_, err = file.WriteString(source)
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+	return NewTokenStream(NewScanner(file, symbolTable))
+}
+
+func TestTokenStreamPeek(t *testing.T) {
+	stream := newTestTokenStream(t, "A <- {um} 10 $ ;")
+
+	require.Equal(t, "A", stream.Peek(0).Token.GetLexem())
+	require.Equal(t, "10", stream.Peek(2).Token.GetLexem())
+	require.Equal(t, ";", stream.Peek(3).Token.GetLexem())
+	require.Equal(t, EOF_TOKEN, stream.Peek(4).Token)
+	require.Equal(t, EOF_TOKEN, stream.Peek(10).Token)
+
+	// Peeking does not consume, nor changes where the tokens are
+	first := stream.Next()
+	require.Equal(t, "A", first.Token.GetLexem())
+	require.Equal(t, Position{Line: 1, Column: 1}, first.Start)
+	require.Equal(t, Position{Line: 1, Column: 1}, first.End)
+	require.Equal(t, int64(0), first.StartOffset)
+	require.Equal(t, int64(1), first.EndOffset)
+
+	attribution := stream.Peek(0)
+	require.Equal(t, Position{Line: 1, Column: 3}, attribution.Start)
+	require.Equal(t, Position{Line: 1, Column: 4}, attribution.End)
+
+	lexems := []string{}
+	for token := stream.Next(); token.Token != EOF_TOKEN; token = stream.Next() {
+		lexems = append(lexems, token.Token.GetLexem())
+	}
+	require.Equal(t, []string{"<-", "10", ";"}, lexems)
+	require.Equal(t, EOF_TOKEN, stream.Next().Token)
+}
+
+func TestTokenStreamRewind(t *testing.T) {
+	stream := newTestTokenStream(t, "A <- B + 1;")
+
+	stream.Next()
+	mark := stream.Mark()
+	require.Equal(t, "<-", stream.Next().Token.GetLexem())
+	require.Equal(t, "B", stream.Next().Token.GetLexem())
+
+	inner := stream.Mark()
+	require.Equal(t, "+", stream.Next().Token.GetLexem())
+	stream.Rewind(inner)
+	require.Equal(t, "+", stream.Next().Token.GetLexem())
+	stream.Release(inner)
+
+	stream.Rewind(mark)
+	require.Equal(t, "<-", stream.Next().Token.GetLexem())
+	stream.Release(mark)
+	require.Panics(t, func() { stream.Rewind(mark) })
+
+	// Without marks the tokens consumed are discarded
+	require.Equal(t, "B", stream.Next().Token.GetLexem())
+	require.Equal(t, "+", stream.Next().Token.GetLexem())
+	require.Len(t, stream.buffer, 0)
+	require.Equal(t, "1", stream.Peek(0).Token.GetLexem())
+	require.Len(t, stream.buffer, 1)
+}
+
+func TestTokenStreamSeveralScanners(t *testing.T) {
+	symbolTable := NewSymbolTable()
+	symbolTable.RegisterKeywords(DefaultKeywords())
+	first := NewStringScanner("A <-", symbolTable)
+	first.SetName("um.mgol")
+	second := NewStringScanner("{vazio}", symbolTable)
+	second.SetName("dois.mgol")
+	third := NewStringScanner("\n 1;", symbolTable)
+	third.SetName("tres.mgol")
+
+	stream := NewTokenStream(first, second)
+	stream.AddScanner(third)
+	tokens := []ScannedToken{}
+	for token := stream.Next(); token.Token != EOF_TOKEN; token = stream.Next() {
+		tokens = append(tokens, token)
+	}
+
+	require.Len(t, tokens, 4)
+	require.Equal(t, Position{File: "um.mgol", Line: 1, Column: 3}, tokens[1].Start)
+	require.Equal(t, Position{File: "tres.mgol", Line: 2, Column: 2}, tokens[2].Start)
+	require.Equal(t, Position{File: "tres.mgol", Line: 2, Column: 3}, tokens[3].End)
+	require.Equal(t, third, tokens[3].Source)
+
+	// The symbol table records the file of each use
+	entry, err := symbolTable.GetEntry("A")
+	require.NoError(t, err)
+	require.Equal(t, []Position{{File: "um.mgol", Line: 1, Column: 1}}, entry.Uses)
+}
@@ -0,0 +1,111 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+)
+
+// TraceAction is what the scanner did on a character
+type TraceAction string
+
+// Available trace actions
+const (
+	// TraceAdvance is a transition taken, the character
+	// becoming part of the lexeme
+	TraceAdvance TraceAction = "avança"
+	// TraceSkip is a blank read between the tokens
+	TraceSkip TraceAction = "ignora"
+	// TraceAccept is a character with no transition read on a final
+	// state: the token of the state is recognized, and the character
+	// read again for the next one
+	TraceAccept TraceAction = "aceita"
+	// TraceReject is a character with no transition read on a
+	// state that is not final, or one out of the alphabet
+	TraceReject TraceAction = "erro"
+)
+
+// TraceStep is a step of the automaton of the scanner
+type TraceStep struct {
+	Line   int
+	Column int
+	// Symbol is the character read, unless EOF, when the end of
+	// the source was reached
+	Symbol Symbol
+	EOF    bool
+	From   State
+	// Input is the class of the symbols of the transition taken,
+	// like dígito, or the symbol itself when there is none
+	Input  string
+	To     State
+	Action TraceAction
+	// Token is the class of the token recognized on TraceAccept
+	Token TokenClass
+}
+
+// TraceHandler receives each step the scanner takes
+type TraceHandler interface {
+	Trace(step TraceStep)
+}
+
+// TraceHandlerFunc allows an ordinary function
+// to be used as a TraceHandler
+type TraceHandlerFunc func(step TraceStep)
+
+func (f TraceHandlerFunc) Trace(step TraceStep) {
+	f(step)
+}
+
+// SetTrace makes the scanner send each step of its automaton to
+// handler, for seeing why a text splits into the tokens it does
+func (s *Scanner) SetTrace(handler TraceHandler) {
+	s.tracer = handler
+}
+
+// NewTraceWriter returns the TraceHandler that writes each step on w,
+// one per line, like
+//
+//	1:2    .       q2   .                 -> q3   avança
+func NewTraceWriter(w io.Writer) TraceHandler {
+	return TraceHandlerFunc(func(step TraceStep) {
+		symbol := "EOF"
+		if !step.EOF {
+			symbol = describeSymbol(step.Symbol)
+		}
+		line := fmt.Sprintf("%d:%d", step.Line, step.Column)
+		fmt.Fprintf(w, "%-6s %-7s q%-3d %-17s -> q%-3d %s", line, symbol, step.From, step.Input, step.To, step.Action)
+		if step.Token != "" {
+			fmt.Fprintf(w, " %s", step.Token)
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+// trace sends the step of the automaton from the state from, on
+// symbol at line and column, to the tracer. The automaton moves on
+// TraceAdvance, stays on TraceSkip and goes back to its initial state
+// on the others
+func (s *Scanner) trace(line, column int, symbol Symbol, eof bool, from State, action TraceAction, token TokenClass) {
+	step := TraceStep{Line: line, Column: column, Symbol: symbol, EOF: eof, From: from, To: s.dft.initialState, Action: action, Token: token}
+	if eof {
+		step.Input = "EOF"
+	} else {
+		step.Input = describeSymbol(symbol)
+	}
+	if action == TraceSkip {
+		step.To = from
+	}
+	if action == TraceAdvance {
+		for _, transition := range s.dft.transitionMap[from] {
+			if ContainsSymbol(transition.reading, symbol) {
+				step.To = transition.to
+				if transition.label != "" {
+					step.Input = transition.label
+				} else {
+					step.Input = describeSymbols(transition.reading)
+				}
+				break
+			}
+		}
+	}
+	s.tracer.Trace(step)
+}
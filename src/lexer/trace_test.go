@@ -0,0 +1,55 @@
+package lexer
+
+import (
+	errorhandling "mgol-go/src/error_handling"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScannerTrace(t *testing.T) {
+	scanner := NewStringScanner("1.e+0", NewSymbolTable())
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(errorhandling.Diagnostic) {}))
+	var steps []TraceStep
+	scanner.SetTrace(TraceHandlerFunc(func(step TraceStep) {
+		steps = append(steps, step)
+	}))
+	var classes []TokenClass
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+		classes = append(classes, token.class)
+	}
+	require.Equal(t, []TokenClass{ERROR, IDENTIFIER, ARIT_OP, NUM}, classes)
+	require.Equal(t, []TraceStep{
+		{Line: 1, Column: 1, Symbol: '1', From: 0, Input: "dígito", To: 2, Action: TraceAdvance},
+		{Line: 1, Column: 2, Symbol: '.', From: 2, Input: ".", To: 3, Action: TraceAdvance},
+		{Line: 1, Column: 3, Symbol: 'e', From: 3, Input: "e", To: 0, Action: TraceReject},
+		{Line: 1, Column: 4, Symbol: 'e', From: 0, Input: "letra", To: 1, Action: TraceAdvance},
+		{Line: 1, Column: 5, Symbol: '+', From: 1, Input: "+", To: 0, Action: TraceAccept, Token: IDENTIFIER},
+		{Line: 1, Column: 5, Symbol: '+', From: 0, Input: "+ - * /", To: 14, Action: TraceAdvance},
+		{Line: 1, Column: 6, Symbol: '0', From: 14, Input: "0", To: 0, Action: TraceAccept, Token: ARIT_OP},
+		{Line: 1, Column: 6, Symbol: '0', From: 0, Input: "dígito", To: 2, Action: TraceAdvance},
+		{Line: 1, Column: 6, EOF: true, From: 2, Input: "EOF", To: 0, Action: TraceAccept, Token: NUM},
+	}, steps)
+}
+
+func TestTraceWriter(t *testing.T) {
+	scanner := NewStringScanner("A <- 1 ;", NewSymbolTable())
+	var text strings.Builder
+	scanner.SetTrace(NewTraceWriter(&text))
+	for token, _, _ := scanner.Scan(); token != EOF_TOKEN; token, _, _ = scanner.Scan() {
+	}
+	require.Equal(t, ""+
+		"1:1    A       q0   letra             -> q1   avança\n"+
+		"1:2    ' '     q1   ' '               -> q0   aceita id\n"+
+		"1:2    ' '     q0   ' '               -> q0   ignora\n"+
+		"1:3    <       q0   <                 -> q8   avança\n"+
+		"1:4    -       q8   -                 -> q13  avança\n"+
+		"1:5    ' '     q13  ' '               -> q0   aceita RCB\n"+
+		"1:5    ' '     q0   ' '               -> q0   ignora\n"+
+		"1:6    1       q0   dígito            -> q2   avança\n"+
+		"1:7    ' '     q2   ' '               -> q0   aceita Num\n"+
+		"1:7    ' '     q0   ' '               -> q0   ignora\n"+
+		"1:8    ;       q0   ;                 -> q17  avança\n"+
+		"1:8    EOF     q17  EOF               -> q0   aceita PT_V\n", text.String())
+}
@@ -0,0 +1,153 @@
+// Package limits bounds what a program run by the interp and vm
+// packages may use, so code sent by anyone, like the one students
+// send to a grader, can be run on a server. A program that goes over
+// a limit is stopped with an ExceededError telling which one:
+//
+//	err := interp.RunContext(ctx, program, info, io, limits.Limits{Steps: 1000000})
+//	var exceeded *limits.ExceededError
+//	if errors.As(err, &exceeded) && exceeded.Resource == limits.Steps {
+//		// the program did not end, it may have an endless repita
+//	}
+package limits
+
+import (
+	"context"
+	"fmt"
+	"mgol-go/src/lexer"
+	"time"
+)
+
+var ErrorLimitExceeded = fmt.Errorf("limite de execução excedido")
+
+// Resource is what a limit bounds
+type Resource string
+
+const (
+	// Steps are the statements run and the conditions of repita
+	// checked, on the interpreter, or the instructions run, on the
+	// virtual machine
+	Steps Resource = "passos"
+	// Time is the one given by the deadline of the context
+	Time Resource = "tempo"
+	// Variables are the ones declared
+	Variables Resource = "variáveis"
+	// Memory is what the values of the variables take, as told by Size
+	Memory Resource = "bytes de memória"
+	// Literal is the length of the text a literal variable holds
+	Literal Resource = "bytes em um literal"
+	// Iterations are the times the body of a repita was run,
+	// those of all of them together
+	Iterations Resource = "iterações de repita"
+)
+
+// Limits holds the limit on each resource, 0 meaning no limit
+type Limits struct {
+	Steps      int64
+	Variables  int64
+	Memory     int64
+	Literal    int64
+	Iterations int64
+}
+
+// Sandbox are the limits of the sandbox profile, for a server
+// running code sent by anyone, like a public playground. They
+// let any exercise of a course run, but not much more
+var Sandbox = Limits{
+	Steps:      10000000,
+	Variables:  256,
+	Memory:     1 << 20,
+	Literal:    4096,
+	Iterations: 1000000,
+}
+
+// SandboxTimeout is how long a program runs on the sandbox profile
+const SandboxTimeout = 2 * time.Second
+
+// Tighten returns the smaller of each limit of l and bounds,
+// no limit being larger than any
+func (l Limits) Tighten(bounds Limits) Limits {
+	smaller := func(a, b int64) int64 {
+		if a == 0 || (b > 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	return Limits{
+		Steps:      smaller(l.Steps, bounds.Steps),
+		Variables:  smaller(l.Variables, bounds.Variables),
+		Memory:     smaller(l.Memory, bounds.Memory),
+		Literal:    smaller(l.Literal, bounds.Literal),
+		Iterations: smaller(l.Iterations, bounds.Iterations),
+	}
+}
+
+// ExceededError tells a program was stopped for going over Limit
+type ExceededError struct {
+	Resource Resource
+	// Limit is the limit gone over, 0 for Time
+	Limit int64
+}
+
+func (e *ExceededError) Error() string {
+	if e.Resource == Time {
+		return fmt.Sprintf("%v: tempo esgotado", ErrorLimitExceeded)
+	}
+	return fmt.Sprintf("%v: mais de %d %s", ErrorLimitExceeded, e.Limit, e.Resource)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrorLimitExceeded
+}
+
+// Check returns an ExceededError when used goes over the limit
+// on resource, and nil when it does not or there is no limit
+func (l Limits) Check(resource Resource, used int64) error {
+	var limit int64
+	switch resource {
+	case Steps:
+		limit = l.Steps
+	case Variables:
+		limit = l.Variables
+	case Memory:
+		limit = l.Memory
+	case Literal:
+		limit = l.Literal
+	case Iterations:
+		limit = l.Iterations
+	}
+	if limit > 0 && used > limit {
+		return &ExceededError{Resource: resource, Limit: limit}
+	}
+	return nil
+}
+
+// CheckInterval is how many steps are run between each look at
+// whether the context is done, as doing it on every one is slow
+const CheckInterval = 1024
+
+// Context returns nil while ctx is not done, an ExceededError for
+// Time once its deadline passed, and the error of ctx when it was
+// canceled
+func Context(ctx context.Context) error {
+	switch err := ctx.Err(); err {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return &ExceededError{Resource: Time}
+	default:
+		return err
+	}
+}
+
+// Size returns how many bytes a variable of type dataType takes: 4
+// for an inteiro and 8 for a real, like in C, and the length of its
+// text for a literal holding literal
+func Size(dataType lexer.DataType, literal string) int64 {
+	switch dataType {
+	case lexer.INTEGER:
+		return 4
+	case lexer.REAL:
+		return 8
+	}
+	return int64(len(literal))
+}
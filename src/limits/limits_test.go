@@ -0,0 +1,57 @@
+package limits
+
+import (
+	"context"
+	"mgol-go/src/lexer"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	bounds := Limits{Steps: 10, Memory: 16}
+	require.NoError(t, bounds.Check(Steps, 10))
+	require.NoError(t, bounds.Check(Variables, 1000))
+	err := bounds.Check(Memory, 17)
+	require.ErrorIs(t, err, ErrorLimitExceeded)
+	require.Equal(t, &ExceededError{Resource: Memory, Limit: 16}, err)
+	require.Equal(t, "limite de execução excedido: mais de 16 bytes de memória", err.Error())
+}
+
+func TestTighten(t *testing.T) {
+	bounds := Limits{Steps: 10, Memory: 16, Literal: 8}.Tighten(Limits{Steps: 100, Variables: 2, Literal: 4})
+	require.Equal(t, Limits{Steps: 10, Variables: 2, Memory: 16, Literal: 4}, bounds)
+}
+
+func TestContext(t *testing.T) {
+	require.NoError(t, Context(context.Background()))
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	require.Equal(t, &ExceededError{Resource: Time}, Context(expired))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, Context(canceled), context.Canceled)
+}
+
+func TestSize(t *testing.T) {
+	require.Equal(t, int64(4), Size(lexer.INTEGER, ""))
+	require.Equal(t, int64(8), Size(lexer.REAL, ""))
+	require.Equal(t, int64(9), Size(lexer.LITERAL, "Ana Maria"))
+}
+
+func TestOutputBuffer(t *testing.T) {
+	output := NewOutputBuffer(4)
+	written, err := output.Write([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, 3, written)
+	require.False(t, output.Exceeded())
+
+	written, err = output.Write([]byte("def"))
+	require.ErrorIs(t, err, ErrorOutputLimit)
+	require.Equal(t, 1, written)
+	require.True(t, output.Exceeded())
+	require.Equal(t, "abcd", output.String())
+}
@@ -0,0 +1,42 @@
+package limits
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrorOutputLimit is returned by the writes on an OutputBuffer
+// after it holds all it can
+var ErrorOutputLimit = errors.New("limite de saída excedido")
+
+// DefaultMaxOutput is the size, in bytes, of the output of a
+// program run kept by a server when it is not told another
+const DefaultMaxOutput = 64 << 10
+
+// OutputBuffer keeps up to the limit it was created with of what
+// is written on it, failing the writes after it, so a program
+// writing in a loop does not take all the memory of a server
+type OutputBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+// NewOutputBuffer returns an empty buffer keeping up to limit bytes
+func NewOutputBuffer(limit int) *OutputBuffer {
+	return &OutputBuffer{limit: limit}
+}
+
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		b.exceeded = true
+		written, _ := b.Buffer.Write(p[:b.limit-b.Len()])
+		return written, ErrorOutputLimit
+	}
+	return b.Buffer.Write(p)
+}
+
+// Exceeded tells whether something was written past the limit
+func (b *OutputBuffer) Exceeded() bool {
+	return b.exceeded
+}
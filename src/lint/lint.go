@@ -0,0 +1,246 @@
+// Package lint warns about programs that are valid but written in a
+// way an instructor would point out, like a number without a name or
+// a variable that is never read. Each rule can be turned off:
+//
+//	config := lint.DefaultConfig()
+//	config.Disable("numero-magico")
+//	for _, diagnostic := range lint.Lint(program, info, source, config) {
+//		fmt.Println(diagnostic)
+//	}
+//
+// The program must have been checked by the sem package without errors
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrorUnknownRule = fmt.Errorf("regra desconhecida")
+
+// DefaultMaxLineLength is how long a line may be by default
+const DefaultMaxLineLength = 100
+
+// Rule is a check of the linter
+type Rule struct {
+	Name        string
+	Code        string
+	Description string
+	check       func(l *linter)
+}
+
+var rules = []Rule{
+	{"identificador-maiusculo", "W001", "variáveis com letras minúsculas no nome", (*linter).uppercase},
+	{"senao-ausente", "W002", "se sem senao", (*linter).missingElse},
+	{"numero-magico", "W003", "números além de 0 e 1 escritos nos comandos, em vez de guardados em uma variável", (*linter).magicNumbers},
+	{"linha-longa", "W004", "linhas mais longas que o máximo", (*linter).longLines},
+	{"variavel-nao-usada", "W005", "variáveis declaradas que nunca são lidas", (*linter).unused},
+}
+
+// Rules returns the rules of the linter, in the order they run
+func Rules() []Rule {
+	return append([]Rule{}, rules...)
+}
+
+func findRule(name string) (Rule, error) {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return rule, nil
+		}
+	}
+	names := make([]string, len(rules))
+	for index, rule := range rules {
+		names[index] = rule.Name
+	}
+	return Rule{}, fmt.Errorf("%w: %s, as disponíveis são %s", ErrorUnknownRule, name, strings.Join(names, ", "))
+}
+
+// Config tells which rules run, and how
+type Config struct {
+	// Disabled holds the names of the rules turned off
+	Disabled map[string]bool
+	// MaxLineLength is how many characters a line may have
+	MaxLineLength int
+	// TabWidth is how many columns a tab takes on the length
+	// of a line, going to the next multiple of it. Zero counts
+	// a tab as one character
+	TabWidth int
+}
+
+// DefaultConfig returns the configuration with every rule
+func DefaultConfig() Config {
+	return Config{Disabled: make(map[string]bool), MaxLineLength: DefaultMaxLineLength}
+}
+
+// Enable turns the rules named names on
+func (c *Config) Enable(names ...string) error {
+	for _, name := range names {
+		if _, err := findRule(name); err != nil {
+			return err
+		}
+		delete(c.Disabled, name)
+	}
+	return nil
+}
+
+// Disable turns the rules named names off
+func (c *Config) Disable(names ...string) error {
+	for _, name := range names {
+		if _, err := findRule(name); err != nil {
+			return err
+		}
+		c.Disabled[name] = true
+	}
+	return nil
+}
+
+// configFile is the json a configuration is read from:
+//
+//	{"disable": ["numero-magico"], "max-line-length": 80, "tab-width": 4}
+type configFile struct {
+	Enable        []string `json:"enable"`
+	Disable       []string `json:"disable"`
+	MaxLineLength int      `json:"max-line-length"`
+	TabWidth      int      `json:"tab-width"`
+}
+
+// Read changes c by the json read from r
+func (c *Config) Read(r io.Reader) error {
+	var file configFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return err
+	}
+	if err := c.Disable(file.Disable...); err != nil {
+		return err
+	}
+	if err := c.Enable(file.Enable...); err != nil {
+		return err
+	}
+	if file.MaxLineLength > 0 {
+		c.MaxLineLength = file.MaxLineLength
+	}
+	if file.TabWidth > 0 {
+		c.TabWidth = file.TabWidth
+	}
+	return nil
+}
+
+// Load changes c by the json on the file on path
+func (c *Config) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := c.Read(file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Lint runs the rules of config on program, whose types are on info
+// and whose text is source, returning their warnings sorted by position
+func Lint(program *ast.Program, info *sem.Info, source string, config Config) []errorhandling.Diagnostic {
+	l := &linter{program: program, info: info, source: source, config: config, diagnostics: errorhandling.NewDiagnosticBuffer()}
+	for _, rule := range rules {
+		if !config.Disabled[rule.Name] {
+			l.rule = rule
+			rule.check(l)
+		}
+	}
+	return l.diagnostics.Diagnostics()
+}
+
+// linter holds what the rules look at
+type linter struct {
+	program     *ast.Program
+	info        *sem.Info
+	source      string
+	config      Config
+	diagnostics *errorhandling.DiagnosticBuffer
+	// rule is the one running
+	rule Rule
+}
+
+// warnf warns about what is on position, with the code of the rule
+func (l *linter) warnf(position lexer.Position, format string, args ...interface{}) {
+	diagnostic := errorhandling.NewDiagnostic(errorhandling.Warning, position.Line, position.Column, fmt.Sprintf(format, args...))
+	l.diagnostics.Add(diagnostic.WithCode(l.rule.Code))
+}
+
+func (l *linter) uppercase() {
+	for _, declaration := range l.program.Declarations {
+		name := declaration.Name.Name
+		if upper := strings.ToUpper(name); upper != name {
+			l.warnf(declaration.Name.Start, "variável '%s' com letras minúsculas, escreva '%s'", name, upper)
+		}
+	}
+}
+
+func (l *linter) missingElse() {
+	ast.Inspect(l.program, func(node ast.Node) bool {
+		if stmt, isIf := node.(*ast.If); isIf && stmt.Else == nil {
+			l.warnf(stmt.Start, "se sem senao, diga o que acontece quando a condição não vale")
+		}
+		return true
+	})
+}
+
+func (l *linter) magicNumbers() {
+	ast.Inspect(l.program, func(node ast.Node) bool {
+		literal, isLiteral := node.(*ast.Literal)
+		if !isLiteral || literal.Type == lexer.LITERAL {
+			return true
+		}
+		if value, err := strconv.ParseFloat(literal.Value, 64); err == nil && value != 0 && value != 1 {
+			l.warnf(literal.Start, "número mágico %s, guarde-o em uma variável com um nome que diga o que ele é", literal.Value)
+		}
+		return true
+	})
+}
+
+func (l *linter) longLines() {
+	for index, line := range strings.Split(l.source, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if length := lineLength(line, l.config.TabWidth); length > l.config.MaxLineLength {
+			position := lexer.Position{Line: index + 1, Column: l.config.MaxLineLength + 1}
+			l.warnf(position, "linha com %d caracteres, mais que %d", length, l.config.MaxLineLength)
+		}
+	}
+}
+
+// lineLength returns how many columns line takes, its tabs
+// going to the next multiple of tabWidth, if it is not zero
+func lineLength(line string, tabWidth int) int {
+	if tabWidth == 0 {
+		return utf8.RuneCountInString(line)
+	}
+	length := 0
+	for _, char := range line {
+		if char == '\t' {
+			length += tabWidth - length%tabWidth
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+func (l *linter) unused() {
+	for _, declaration := range l.program.Declarations {
+		if l.info.Usage[declaration].Reads == 0 {
+			l.warnf(declaration.Name.Start, "variável '%s' nunca é lida", declaration.Name.Name)
+		}
+	}
+}
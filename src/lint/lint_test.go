@@ -0,0 +1,104 @@
+package lint
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// check parses and checks source, which must be valid
+func check(t *testing.T, source string) (*ast.Program, *sem.Info) {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	p := parser.NewRecursiveDescentParser(lexer.NewStringScanner(source, symbolTable), parser.DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	result := p.Parse()
+	require.True(t, result.Succeeded())
+	info := sem.NewChecker(symbolTable).Check(result.Program)
+	require.Empty(t, info.Errors)
+	return result.Program, info
+}
+
+func TestLint(t *testing.T) {
+	source := "inicio\nvarinicio\n\tinteiro contador;\n\tinteiro B;\n\tinteiro C;\nvarfim;\n" +
+		"leia contador;\nB <- 1;\nse (contador > 10) entao\n\tB <- contador * 0;\nfimse\nescreva B;\nfim\n"
+	program, info := check(t, source)
+
+	testCases := []struct {
+		name      string
+		configure func(config *Config)
+		expected  []string
+	}{
+		{
+			"default",
+			func(config *Config) {},
+			[]string{
+				"W001 aviso na linha 3 coluna 10, variável 'contador' com letras minúsculas, escreva 'CONTADOR'",
+				"W005 aviso na linha 5 coluna 10, variável 'C' nunca é lida",
+				"W002 aviso na linha 9 coluna 1, se sem senao, diga o que acontece quando a condição não vale",
+				"W003 aviso na linha 9 coluna 16, número mágico 10, guarde-o em uma variável com um nome que diga o que ele é",
+			},
+		},
+		{
+			"disabled",
+			func(config *Config) {
+				require.NoError(t, config.Disable("identificador-maiusculo", "senao-ausente", "numero-magico"))
+			},
+			[]string{"W005 aviso na linha 5 coluna 10, variável 'C' nunca é lida"},
+		},
+		{
+			"long lines",
+			func(config *Config) {
+				require.NoError(t, config.Disable("identificador-maiusculo", "senao-ausente", "numero-magico", "variavel-nao-usada"))
+				config.MaxLineLength = 20
+			},
+			[]string{"W004 aviso na linha 9 coluna 21, linha com 24 caracteres, mais que 20"},
+		},
+		{
+			"tab width",
+			func(config *Config) {
+				require.NoError(t, config.Disable("identificador-maiusculo", "senao-ausente", "numero-magico", "variavel-nao-usada"))
+				config.MaxLineLength = 20
+				config.TabWidth = 8
+			},
+			[]string{
+				"W004 aviso na linha 3 coluna 21, linha com 25 caracteres, mais que 20",
+				"W004 aviso na linha 9 coluna 21, linha com 24 caracteres, mais que 20",
+				"W004 aviso na linha 10 coluna 21, linha com 26 caracteres, mais que 20",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.configure(&config)
+			diagnostics := Lint(program, info, source, config)
+			found := make([]string, len(diagnostics))
+			for index, diagnostic := range diagnostics {
+				found[index] = diagnostic.Code + " " + diagnostic.String()
+			}
+			require.Equal(t, tc.expected, found)
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	config := DefaultConfig()
+	require.NoError(t, config.Read(strings.NewReader(`{"disable": ["numero-magico", "linha-longa"], "max-line-length": 80, "tab-width": 4}`)))
+	require.Equal(t, Config{Disabled: map[string]bool{"numero-magico": true, "linha-longa": true}, MaxLineLength: 80, TabWidth: 4}, config)
+
+	require.NoError(t, config.Read(strings.NewReader(`{"enable": ["linha-longa"]}`)))
+	require.Equal(t, map[string]bool{"numero-magico": true}, config.Disabled)
+	require.Equal(t, 80, config.MaxLineLength)
+
+	require.ErrorIs(t, config.Disable("ponto-e-virgula"), ErrorUnknownRule)
+	require.ErrorIs(t, config.Read(strings.NewReader(`{"enable": ["ponto-e-virgula"]}`)), ErrorUnknownRule)
+	require.Error(t, config.Read(strings.NewReader(`{"desativar": []}`)))
+}
@@ -0,0 +1,39 @@
+package llvmgen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(llvmBackend{})
+}
+
+// llvmBackend is the LLVM IR target on the registry of backends
+type llvmBackend struct {
+	options backend.Options
+}
+
+func (llvmBackend) Name() string {
+	return "llvm"
+}
+
+func (llvmBackend) FileExtension() string {
+	return ".ll"
+}
+
+func (b llvmBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return GenerateWithOptions(w, program, info, b.options)
+}
+
+func (b llvmBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
+
+func (b llvmBackend) GenerateIR(program *ir.Program, w io.Writer) error {
+	return GenerateIRWithOptions(w, program, b.options)
+}
@@ -0,0 +1,422 @@
+// Package llvmgen is a backend that writes a program as textual LLVM
+// IR, which can be optimized with opt and compiled with llc or clang:
+//
+//	llc -relocation-model=pic programa.ll -o programa.s && gcc programa.s -o programa
+//
+// It is generated from the three-address code of the ir package, its
+// temporaries becoming registers. The variables are globals, loaded and
+// stored on each use, which mem2reg turns into registers too. Reading
+// and writing go through the C library, so the program behaves like the
+// one of the C backend
+package llvmgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"strconv"
+	"strings"
+)
+
+// literalSize is the size of the buffer of a literal
+// variable, which the C backend declares as char[256]
+const literalSize = 256
+
+// irTypes holds the LLVM type of the values of each type
+var irTypes = map[lexer.DataType]string{
+	lexer.INTEGER: "i32",
+	lexer.REAL:    "double",
+	lexer.LITERAL: "i8*",
+}
+
+// scanFormats and printFormats hold the global with the
+// format scanf reads and printf writes each type with
+var scanFormats = map[lexer.DataType]string{
+	lexer.INTEGER: "@.scan.inteiro",
+	lexer.REAL:    "@.scan.real",
+	lexer.LITERAL: "@.scan.literal",
+}
+
+var printFormats = map[lexer.DataType]string{
+	lexer.INTEGER: "@.print.inteiro",
+	lexer.REAL:    "@.print.real",
+	lexer.LITERAL: "@.print.literal",
+}
+
+// preamble declares the functions of the C library and the formats
+// they take. A literal is read up to the end of the line, spaces included
+const preamble = `@.scan.inteiro = private unnamed_addr constant [3 x i8] c"%d\00"
+@.scan.real = private unnamed_addr constant [4 x i8] c"%lf\00"
+@.scan.literal = private unnamed_addr constant [10 x i8] c" %255[^\0A]\00"
+@.print.inteiro = private unnamed_addr constant [3 x i8] c"%d\00"
+@.print.real = private unnamed_addr constant [3 x i8] c"%f\00"
+@.print.literal = private unnamed_addr constant [3 x i8] c"%s\00"
+
+declare i32 @scanf(i8*, ...)
+declare i32 @printf(i8*, ...)
+declare i32 @strcmp(i8*, i8*)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+`
+
+// decimalComma writes a real with a comma as the decimal separator:
+// printf writes it on a buffer, whose point becomes a comma
+const decimalComma = `
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare i8* @strchr(i8*, i32)
+
+define private void @escreva_real(double %valor) {
+entry:
+  %texto = alloca [320 x i8]
+  %inicio = getelementptr inbounds [320 x i8], [320 x i8]* %texto, i64 0, i64 0
+  call i32 (i8*, i64, i8*, ...) @snprintf(i8* %inicio, i64 320, i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.print.real, i64 0, i64 0), double %valor)
+  %ponto = call i8* @strchr(i8* %inicio, i32 46)
+  %achou = icmp ne i8* %ponto, null
+  br i1 %achou, label %troca, label %escreve
+troca:
+  store i8 44, i8* %ponto
+  br label %escreve
+escreve:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.print.literal, i64 0, i64 0), i8* %inicio)
+  ret void
+}
+`
+
+// formatSizes holds the size of the arrays of the formats
+var formatSizes = map[string]int{
+	"@.scan.inteiro":  3,
+	"@.scan.real":     4,
+	"@.scan.literal":  10,
+	"@.print.inteiro": 3,
+	"@.print.real":    3,
+	"@.print.literal": 3,
+}
+
+// integerOperations and realOperations hold the instruction of each
+// operator. The relational ones are comparisons, whose predicate
+// follows the instruction
+var integerOperations = map[string]string{
+	"+":  "add",
+	"-":  "sub",
+	"*":  "mul",
+	"/":  "sdiv",
+	"<":  "icmp slt",
+	">":  "icmp sgt",
+	"<=": "icmp sle",
+	">=": "icmp sge",
+	"=":  "icmp eq",
+	"<>": "icmp ne",
+}
+
+var realOperations = map[string]string{
+	"+":  "fadd",
+	"-":  "fsub",
+	"*":  "fmul",
+	"/":  "fdiv",
+	"<":  "fcmp olt",
+	">":  "fcmp ogt",
+	"<=": "fcmp ole",
+	">=": "fcmp oge",
+	"=":  "fcmp oeq",
+	"<>": "fcmp une",
+}
+
+// reserved holds the names of the globals a variable can not take
+var reserved = map[string]bool{
+	"main": true, "scanf": true, "printf": true, "strcmp": true,
+	"snprintf": true, "strchr": true, "escreva_real": true,
+}
+
+// global returns the global of the variable named name
+func global(name string) string {
+	if reserved[name] {
+		return "@" + name + "_"
+	}
+	return "@" + name
+}
+
+type generator struct {
+	options   backend.Options
+	types     map[string]lexer.DataType
+	strings   []string
+	constants map[string]string
+	// values holds the operands the temporaries were copied from
+	values map[int]string
+	body   strings.Builder
+	// registers and blocks count the ones added to the three-address code:
+	// the registers loading the variables and the blocks starting after jumps
+	registers int
+	blocks    int
+	// terminated tells whether the last instruction ended its block
+	terminated bool
+	err        error
+}
+
+func (g *generator) emit(format string, args ...interface{}) {
+	g.body.WriteString("  ")
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteByte('\n')
+}
+
+func (g *generator) label(name string) {
+	fmt.Fprintf(&g.body, "%s:\n", name)
+	g.terminated = false
+}
+
+// block starts a new block, for the instructions after a jump
+func (g *generator) block() string {
+	g.blocks++
+	name := fmt.Sprintf("B%d", g.blocks)
+	g.label(name)
+	return name
+}
+
+// register returns a new register, apart from the temporaries
+func (g *generator) register() string {
+	g.registers++
+	return fmt.Sprintf("%%r%d", g.registers)
+}
+
+// Generate writes program as a LLVM module. info holds what the sem
+// package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	return GenerateWithOptions(w, program, info, backend.Options{})
+}
+
+// GenerateWithOptions writes program like Generate, following options
+func GenerateWithOptions(w io.Writer, program *ast.Program, info *sem.Info, options backend.Options) error {
+	lowered, err := ir.Lower(program, info)
+	if err != nil {
+		return err
+	}
+	return GenerateIRWithOptions(w, lowered, options)
+}
+
+// GenerateIR writes a program already lowered to
+// three-address code, like after its optimizations
+func GenerateIR(w io.Writer, lowered *ir.Program) error {
+	return GenerateIRWithOptions(w, lowered, backend.Options{})
+}
+
+// GenerateIRWithOptions writes lowered like GenerateIR, following options
+func GenerateIRWithOptions(w io.Writer, lowered *ir.Program, options backend.Options) error {
+	g := &generator{options: options, types: make(map[string]lexer.DataType), constants: make(map[string]string), values: make(map[int]string)}
+	for _, declaration := range lowered.Declarations {
+		g.types[declaration.Name] = declaration.Type
+	}
+	for _, instruction := range lowered.Instructions {
+		g.instruction(instruction)
+	}
+	if g.err != nil {
+		return g.err
+	}
+	if g.terminated {
+		g.block()
+	}
+
+	var module strings.Builder
+	for _, declaration := range lowered.Declarations {
+		name := global(declaration.Name)
+		switch declaration.Type {
+		case lexer.INTEGER:
+			fmt.Fprintf(&module, "%s = global i32 0\n", name)
+		case lexer.REAL:
+			fmt.Fprintf(&module, "%s = global double 0.0\n", name)
+		case lexer.LITERAL:
+			fmt.Fprintf(&module, "%s = global [%d x i8] zeroinitializer\n", name, literalSize)
+		}
+	}
+	if len(lowered.Declarations) > 0 {
+		module.WriteByte('\n')
+	}
+	for index, text := range g.strings {
+		fmt.Fprintf(&module, "@.str.%d = private unnamed_addr constant [%d x i8] c\"%s\\00\"\n", index, len(text)+1, escape(text))
+	}
+	module.WriteString(preamble)
+	if g.options.DecimalComma {
+		module.WriteString(decimalComma)
+	}
+	module.WriteString("\ndefine i32 @main() {\nentry:\n")
+	module.WriteString(g.body.String())
+	module.WriteString("  ret i32 0\n}\n")
+
+	_, err := io.WriteString(w, module.String())
+	return err
+}
+
+// escape writes text as the contents of a LLVM string constant
+func escape(text string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(text) {
+		if b < ' ' || b > '~' || b == '"' || b == '\\' {
+			fmt.Fprintf(&escaped, "\\%02X", b)
+			continue
+		}
+		escaped.WriteByte(b)
+	}
+	return escaped.String()
+}
+
+// pointer returns the address of the first byte of the array
+// named array with size bytes, as a constant expression
+func pointer(array string, size int) string {
+	return fmt.Sprintf("getelementptr inbounds ([%d x i8], [%d x i8]* %s, i64 0, i64 0)", size, size, array)
+}
+
+// address returns the operand pointing to the variable named name,
+// its first byte for a literal
+func (g *generator) address(name string) string {
+	dataType := g.types[name]
+	if dataType == lexer.LITERAL {
+		return pointer(global(name), literalSize)
+	}
+	return fmt.Sprintf("%s* %s", irTypes[dataType], global(name))
+}
+
+func (g *generator) instruction(instruction ir.Instruction) {
+	if instruction.Op == ir.Mark {
+		if !g.terminated {
+			g.emit("br label %%%s", instruction.Label)
+		}
+		g.label(instruction.Label.String())
+		return
+	}
+	if g.terminated {
+		// Nothing jumps to the instructions after a jump,
+		// but LLVM still needs them on a block of their own
+		g.block()
+	}
+
+	dest, dataType := instruction.Dest, instruction.Dest.Type
+	switch instruction.Op {
+	case ir.Read:
+		format := scanFormats[dataType]
+		target := g.address(dest.Name)
+		if dataType == lexer.LITERAL {
+			target = "i8* " + target
+		}
+		g.emit("call i32 (i8*, ...) @scanf(i8* %s, %s)", pointer(format, formatSizes[format]), target)
+	case ir.Write:
+		written := instruction.Left.Type
+		if written == lexer.REAL && g.options.DecimalComma {
+			g.emit("call void @escreva_real(double %s)", g.operand(instruction.Left))
+			return
+		}
+		format := printFormats[written]
+		g.emit("call i32 (i8*, ...) @printf(i8* %s, %s %s)", pointer(format, formatSizes[format]), irTypes[written], g.operand(instruction.Left))
+	case ir.Copy:
+		if dest.Kind == ir.Temporary {
+			// LLVM has no copies, so the temporary stands for the operand
+			g.values[dest.Temporary] = g.operand(instruction.Left)
+			return
+		}
+		if dataType == lexer.LITERAL {
+			g.emit("call void @llvm.memcpy.p0i8.p0i8.i64(i8* %s, i8* %s, i64 %d, i1 false)", g.address(dest.Name), g.operand(instruction.Left), literalSize)
+			return
+		}
+		g.emit("store %s %s, %s", irTypes[dataType], g.operand(instruction.Left), g.address(dest.Name))
+	case ir.Binary:
+		operations := integerOperations
+		if dataType == lexer.REAL {
+			operations = realOperations
+		}
+		left, right := g.operand(instruction.Left), g.operand(instruction.Right)
+		g.define(dest, fmt.Sprintf("%s %s %s, %s", operations[instruction.Operator], irTypes[dataType], left, right))
+	case ir.Convert:
+		if dataType == lexer.REAL {
+			g.define(dest, fmt.Sprintf("sitofp i32 %s to double", g.operand(instruction.Left)))
+			return
+		}
+		// Like on a C int, the fractional part is dropped
+		g.define(dest, fmt.Sprintf("fptosi double %s to i32", g.operand(instruction.Left)))
+	case ir.Jump:
+		g.emit("br label %%%s", instruction.Label)
+		g.terminated = true
+	case ir.JumpUnless:
+		condition := g.comparison(instruction)
+		next := fmt.Sprintf("B%d", g.blocks+1)
+		g.emit("br i1 %s, label %%%s, label %%%s", condition, next, instruction.Label)
+		g.block()
+	}
+}
+
+// define emits value, naming its result after dest if it is
+// a temporary, or else storing it on the variable dest
+func (g *generator) define(dest ir.Operand, value string) {
+	if dest.Kind == ir.Temporary {
+		g.emit("%%t%d = %s", dest.Temporary, value)
+		return
+	}
+	register := g.register()
+	g.emit("%s = %s", register, value)
+	g.emit("store %s %s, %s", irTypes[dest.Type], register, g.address(dest.Name))
+}
+
+// comparison emits the comparison of a conditional
+// jump, returning the register with its result
+func (g *generator) comparison(instruction ir.Instruction) string {
+	left, right := g.operand(instruction.Left), g.operand(instruction.Right)
+	register := g.register()
+	switch instruction.Left.Type {
+	case lexer.LITERAL:
+		// Literals are compared by the sign of strcmp, like on the C backend
+		g.emit("%s = call i32 @strcmp(i8* %s, i8* %s)", register, left, right)
+		compared := register
+		register = g.register()
+		g.emit("%s = %s i32 %s, 0", register, integerOperations[instruction.Operator], compared)
+	case lexer.REAL:
+		g.emit("%s = %s double %s, %s", register, realOperations[instruction.Operator], left, right)
+	default:
+		g.emit("%s = %s i32 %s, %s", register, integerOperations[instruction.Operator], left, right)
+	}
+	return register
+}
+
+// operand returns the LLVM operand of operand, loading the variables
+func (g *generator) operand(operand ir.Operand) string {
+	switch operand.Kind {
+	case ir.Temporary:
+		if value, found := g.values[operand.Temporary]; found {
+			return value
+		}
+		return fmt.Sprintf("%%t%d", operand.Temporary)
+	case ir.Constant:
+		return g.constant(operand)
+	}
+	if operand.Type == lexer.LITERAL {
+		return g.address(operand.Name)
+	}
+	register := g.register()
+	g.emit("%s = load %s, %s", register, irTypes[operand.Type], g.address(operand.Name))
+	return register
+}
+
+// constant returns the operand of a number or a literal constant.
+// The literals are added to the globals of the module once each
+func (g *generator) constant(operand ir.Operand) string {
+	if operand.Type == lexer.LITERAL {
+		text := lexer.LiteralText(operand.Name)
+		name, found := g.constants[text]
+		if !found {
+			name = fmt.Sprintf("@.str.%d", len(g.strings))
+			g.constants[text] = name
+			g.strings = append(g.strings, text)
+		}
+		return pointer(name, len(text)+1)
+	}
+
+	number, err := lexer.ParseNumber(operand.Name, operand.Type)
+	if err != nil {
+		g.err = err
+		return "undef"
+	}
+	if operand.Type == lexer.INTEGER {
+		return strconv.FormatInt(int64(number), 10)
+	}
+	// A double constant is only accepted in decimal when it is exact
+	return fmt.Sprintf("0x%016X", math.Float64bits(number))
+}
@@ -0,0 +1,111 @@
+package llvmgen
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// inteiro A; real main;
+	// leia A;
+	// se (A > 1) entao main <- A * 2.5; fimse
+	// escreva "ok";
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A"), asttest.Declaration(lexer.REAL, "main")},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.If{
+				Condition: &ast.BinaryExpr{Operator: ">", Left: asttest.Ident("A"), Right: asttest.Literal("1", lexer.INTEGER)},
+				Body: []ast.Stmt{&ast.Assign{
+					Target: asttest.Ident("main"),
+					Value:  &ast.BinaryExpr{Operator: "*", Left: asttest.Ident("A"), Right: asttest.Literal("2.5", lexer.REAL)},
+				}},
+			},
+			&ast.Write{Value: asttest.Literal(`"ok"`, lexer.LITERAL)},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	require.Equal(t, `@A = global i32 0
+@main_ = global double 0.0
+
+@.str.0 = private unnamed_addr constant [3 x i8] c"ok\00"
+`+preamble+`
+define i32 @main() {
+entry:
+  call i32 (i8*, ...) @scanf(i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.scan.inteiro, i64 0, i64 0), i32* @A)
+  %r1 = load i32, i32* @A
+  %r2 = icmp sgt i32 %r1, 1
+  br i1 %r2, label %B1, label %L1
+B1:
+  %r3 = load i32, i32* @A
+  %t1 = sitofp i32 %r3 to double
+  %t2 = fmul double %t1, 0x4004000000000000
+  store double %t2, double* @main_
+  br label %L1
+L1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.print.literal, i64 0, i64 0), i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.str.0, i64 0, i64 0))
+  ret i32 0
+}
+`, out.String())
+}
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `a \22b\22\0A\5C`, escape("a \"b\"\n\\"))
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.BadStmt{}},
+	}
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, sem.NewChecker(nil).Check(program)), ir.ErrorBadNode)
+}
+
+func TestGenerateIR(t *testing.T) {
+	// What the optimizations leave: operations written straight
+	// on the variables and temporaries copied from other operands
+	program, err := ir.Parse(`inteiro A
+real B
+	leia A
+	A = A + 1
+	B = (real) A
+	%t1 = A
+	%t2 = %t1 * 2
+	escreva %t2
+`)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, GenerateIR(&out, program))
+	require.Equal(t, `@A = global i32 0
+@B = global double 0.0
+
+`+preamble+`
+define i32 @main() {
+entry:
+  call i32 (i8*, ...) @scanf(i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.scan.inteiro, i64 0, i64 0), i32* @A)
+  %r1 = load i32, i32* @A
+  %r2 = add i32 %r1, 1
+  store i32 %r2, i32* @A
+  %r3 = load i32, i32* @A
+  %r4 = sitofp i32 %r3 to double
+  store double %r4, double* @B
+  %r5 = load i32, i32* @A
+  %t2 = mul i32 %r5, 2
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([3 x i8], [3 x i8]* @.print.inteiro, i64 0, i64 0), i32 %t2)
+  ret i32 0
+}
+`, out.String())
+}
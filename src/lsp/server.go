@@ -0,0 +1,375 @@
+// Package lsp is a server of the Language Server Protocol for mgol,
+// so editors like VS Code show the errors of a program as it is
+// written, the type of a variable under the mouse, go to its
+// declaration and format the program:
+//
+//	lsp.NewServer(os.Stdin, os.Stdout).Run()
+//
+// Each document is checked again, whole, when it changes. The columns
+// are counted in bytes, the same as the characters of the protocol
+// while the program has only ASCII, as the scanner requires outside
+// the literals
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"mgol-go/src/version"
+	"strings"
+)
+
+var ErrorExitWithoutShutdown = fmt.Errorf("exit recebido antes de shutdown")
+
+// source is put on the diagnostics, telling where they come from
+const source = "mgol"
+
+// Server answers the requests of an editor
+type Server struct {
+	input     *bufio.Reader
+	output    io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// document is a program open on the editor, and what was found on it
+type document struct {
+	text        string
+	program     *ast.Program
+	info        *sem.Info
+	diagnostics []errorhandling.Diagnostic
+	// spans holds the span of the diagnostics found with one,
+	// the syntactic and semantic ones, by where they are
+	spans map[diagnosticKey]ast.Span
+}
+
+// diagnosticKey tells a diagnostic apart from the others of a document
+type diagnosticKey struct {
+	line, column  int
+	code, message string
+}
+
+func keyOf(diagnostic errorhandling.Diagnostic) diagnosticKey {
+	return diagnosticKey{diagnostic.Line, diagnostic.Column, diagnostic.Code, diagnostic.Message}
+}
+
+// NewServer returns a server that reads the messages of the
+// editor from r and writes its own on w
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{input: bufio.NewReader(r), output: w, documents: make(map[string]*document)}
+}
+
+// Run answers the messages until the editor tells the server to exit
+// or closes the input. Exiting before shutdown is an error, as the
+// protocol tells the server to end with a failure then
+func (s *Server) Run() error {
+	for {
+		content, err := readMessage(s.input)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var request message
+		if err := json.Unmarshal(content, &request); err != nil {
+			if err := s.reply(nil, nil, &responseError{codeParseError, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if request.Method == "exit" {
+			if !s.shutdown {
+				return ErrorExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.handle(request); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers request, failing only when the answer
+// can not be written
+func (s *Server) handle(request message) error {
+	result, known, requestErr := s.call(request.Method, request.Params)
+	if request.ID == nil {
+		// Notifications have no answer, not even when they fail
+		return nil
+	}
+	if !known {
+		requestErr = &responseError{codeMethodNotFound, "método desconhecido: " + request.Method}
+	}
+	return s.reply(request.ID, result, requestErr)
+}
+
+// call runs the method with params, telling whether it is known.
+// Notifications the server has no use for, like initialized, are
+// unknown too, and ignored by handle
+func (s *Server) call(method string, params json.RawMessage) (interface{}, bool, *responseError) {
+	decode := func(value interface{}) *responseError {
+		if err := json.Unmarshal(params, value); err != nil {
+			return &responseError{codeInvalidParams, err.Error()}
+		}
+		return nil
+	}
+
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// The documents are sent whole on each change
+				"textDocumentSync":           1,
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": source, "version": version.Get().Version},
+		}, true, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, true, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		return nil, true, s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		if changes := params.ContentChanges; len(changes) > 0 {
+			return nil, true, s.update(params.TextDocument.URI, changes[len(changes)-1].Text)
+		}
+		return nil, true, nil
+	case "textDocument/didClose":
+		var params textDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, true, s.publish(params.TextDocument.URI, nil)
+	case "textDocument/hover":
+		var params positionParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		return s.hover(params), true, nil
+	case "textDocument/definition":
+		var params positionParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		return s.definition(params), true, nil
+	case "textDocument/formatting":
+		var params formattingParams
+		if err := decode(&params); err != nil {
+			return nil, true, err
+		}
+		edits, err := s.format(params.TextDocument.URI, params.Options)
+		return edits, true, err
+	}
+	return nil, false, nil
+}
+
+// reply writes the response to the request with id
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *responseError) error {
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		response["error"] = err
+	} else {
+		response["result"] = result
+	}
+	return writeMessage(s.output, response)
+}
+
+// update checks the document on uri, whose text is now text,
+// and sends its diagnostics
+func (s *Server) update(uri, text string) *responseError {
+	d := analyze(text)
+	s.documents[uri] = d
+	return s.publish(uri, d)
+}
+
+// publish sends the diagnostics of d, the document on uri, or
+// none when it is nil
+func (s *Server) publish(uri string, d *document) *responseError {
+	params := publishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}}
+	if d == nil {
+		d = &document{}
+	}
+	for _, diagnostic := range d.diagnostics {
+		severity := severityWarning
+		if diagnostic.Severity == errorhandling.Error {
+			severity = severityError
+		}
+		// The lexical ones only tell their first character
+		position := protocolPosition(diagnostic.Line, diagnostic.Column-1)
+		diagnosticRange := Range{Start: position, End: Position{Line: position.Line, Character: position.Character + 1}}
+		if span, found := d.spans[keyOf(diagnostic)]; found && span.Start.Line > 0 {
+			diagnosticRange = spanRange(span)
+		}
+		params.Diagnostics = append(params.Diagnostics, Diagnostic{
+			Range:    diagnosticRange,
+			Severity: severity,
+			Code:     diagnostic.Code,
+			Source:   source,
+			Message:  diagnostic.Message,
+		})
+	}
+	notification := map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": params}
+	if err := writeMessage(s.output, notification); err != nil {
+		return &responseError{codeRequestFailed, err.Error()}
+	}
+	return nil
+}
+
+// analyze parses and checks text, keeping the partial tree
+// of a program with syntax errors, so the editor still shows
+// what is known about the rest of it
+func analyze(text string) *document {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	scanner := lexer.NewStringScanner(text, symbolTable)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+
+	p := parser.NewRecursiveDescentParser(scanner, parser.DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	result := p.Parse()
+	d := &document{text: text, program: result.Program, spans: make(map[diagnosticKey]ast.Span)}
+	add := func(diagnostic errorhandling.Diagnostic, span ast.Span) {
+		d.spans[keyOf(diagnostic)] = span
+		diagnostics.Add(diagnostic)
+	}
+	for _, syntaxError := range result.Errors {
+		add(syntaxError.Diagnostic(), syntaxError.Span)
+	}
+	for _, ioError := range result.IOErrors {
+		add(ioError.Diagnostic(), ioError.Span)
+	}
+	if result.Program != nil {
+		d.info = sem.NewChecker(symbolTable).Check(result.Program)
+		for _, warning := range d.info.Warnings {
+			add(warning.Diagnostic(), warning.Span)
+		}
+		for _, semanticError := range d.info.Errors {
+			add(semanticError.Diagnostic(), semanticError.Span)
+		}
+	}
+	d.diagnostics = diagnostics.Diagnostics()
+	return d
+}
+
+// declarationAt returns the identifier on position of the document on
+// uri, with the declaration of its variable, or nil if there is none
+func (s *Server) declarationAt(uri string, position Position) (*ast.Ident, *ast.VarDecl) {
+	d := s.documents[uri]
+	if d == nil || d.program == nil || d.info == nil {
+		return nil, nil
+	}
+	line, column := position.Line+1, position.Character+1
+	var ident *ast.Ident
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if found, isIdent := node.(*ast.Ident); isIdent && found.Start.Line == line && found.Start.Column <= column && column <= found.Span.End.Column {
+			ident = found
+		}
+		return ident == nil
+	})
+	if ident == nil {
+		return nil, nil
+	}
+	if declaration, found := d.info.Uses[ident]; found {
+		return ident, declaration
+	}
+	for _, declaration := range d.program.Declarations {
+		if declaration.Name == ident {
+			return ident, declaration
+		}
+	}
+	return ident, nil
+}
+
+// spanRange returns the range of the protocol covering span
+func spanRange(span ast.Span) Range {
+	return Range{
+		Start: protocolPosition(span.Start.Line, span.Start.Column-1),
+		// The span ends on its last character, and the range after it
+		End: protocolPosition(span.End.Line, span.End.Column),
+	}
+}
+
+// protocolPosition returns the position of the protocol on line,
+// counted from 1, and character, counted from 0. A position before
+// the start of the source, which the protocol rejects, is put on it
+func protocolPosition(line, character int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	if character < 0 {
+		character = 0
+	}
+	return Position{Line: line - 1, Character: character}
+}
+
+func (s *Server) hover(params positionParams) interface{} {
+	ident, declaration := s.declarationAt(params.TextDocument.URI, params.Position)
+	if declaration == nil {
+		return nil
+	}
+	return hover{
+		Contents: markupContent{Kind: "plaintext", Value: fmt.Sprintf("%s %s", declaration.Type, declaration.Name.Name)},
+		Range:    spanRange(ident.Span),
+	}
+}
+
+func (s *Server) definition(params positionParams) interface{} {
+	_, declaration := s.declarationAt(params.TextDocument.URI, params.Position)
+	if declaration == nil {
+		return nil
+	}
+	return Location{URI: params.TextDocument.URI, Range: spanRange(declaration.Name.Span)}
+}
+
+// format returns the edit that formats the document on uri,
+// indented as options tell
+func (s *Server) format(uri string, options formattingOptions) ([]TextEdit, *responseError) {
+	d := s.documents[uri]
+	if d == nil {
+		return nil, &responseError{codeRequestFailed, "documento não aberto: " + uri}
+	}
+	formatted, _, err := parser.Format(d.text)
+	if err != nil {
+		return nil, &responseError{codeRequestFailed, err.Error()}
+	}
+	if options.InsertSpaces && options.TabSize > 0 {
+		formatted = indentWithSpaces(formatted, options.TabSize)
+	}
+	if formatted == d.text {
+		return []TextEdit{}, nil
+	}
+	lines := strings.Split(d.text, "\n")
+	end := Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}
+	return []TextEdit{{Range: Range{End: end}, NewText: formatted}}, nil
+}
+
+// indentWithSpaces returns formatted, which is indented with tabs,
+// with each tab of the indentation turned into tabSize spaces
+func indentWithSpaces(formatted string, tabSize int) string {
+	lines := strings.Split(formatted, "\n")
+	for index, line := range lines {
+		text := strings.TrimLeft(line, "\t")
+		lines[index] = strings.Repeat(" ", tabSize*(len(line)-len(text))) + text
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const uri = "file:///programa.mgol"
+
+// session runs a server on requests and returns the messages it wrote
+func session(t *testing.T, requests ...map[string]interface{}) []map[string]interface{} {
+	var input bytes.Buffer
+	for _, request := range requests {
+		request["jsonrpc"] = "2.0"
+		require.NoError(t, writeMessage(&input, request))
+	}
+	var output bytes.Buffer
+	require.NoError(t, NewServer(&input, &output).Run())
+
+	var messages []map[string]interface{}
+	reader := bufio.NewReader(&output)
+	for {
+		content, err := readMessage(reader)
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(t, err)
+		var message map[string]interface{}
+		require.NoError(t, json.Unmarshal(content, &message))
+		messages = append(messages, message)
+	}
+}
+
+func open(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"method": "textDocument/didOpen",
+		"params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri, "text": text}},
+	}
+}
+
+func request(id int, method string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		},
+	}
+}
+
+// toJSON returns the json of value decoded back, to compare with the messages
+func toJSON(t *testing.T, value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	return decoded
+}
+
+func TestDiagnostics(t *testing.T) {
+	messages := session(t,
+		open("inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\nB <- A;\nfim\n"),
+		map[string]interface{}{
+			"method": "textDocument/didChange",
+			"params": map[string]interface{}{
+				"textDocument":   map[string]interface{}{"uri": uri},
+				"contentChanges": []interface{}{map[string]interface{}{"text": "inicio\nvarinicio\nvarfim;\nfim\n"}},
+			},
+		},
+	)
+	require.Len(t, messages, 2)
+	require.Equal(t, "textDocument/publishDiagnostics", messages[0]["method"])
+	diagnostics := messages[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	require.Len(t, diagnostics, 1)
+	diagnostic := diagnostics[0].(map[string]interface{})
+	require.Equal(t, toJSON(t, Range{Start: Position{5, 0}, End: Position{5, 1}}), diagnostic["range"])
+	require.Equal(t, float64(severityError), diagnostic["severity"])
+	require.Equal(t, "S001", diagnostic["code"])
+	require.Empty(t, messages[1]["params"].(map[string]interface{})["diagnostics"])
+}
+
+func TestRequests(t *testing.T) {
+	source := "inicio\nvarinicio\n\tinteiro A;\n\treal B;\nvarfim;\nleia A;\nB<-A;\nfim\n"
+	messages := session(t,
+		open(source),
+		request(1, "textDocument/hover", 6, 3),
+		request(2, "textDocument/definition", 6, 3),
+		request(3, "textDocument/hover", 6, 1),
+		request(4, "textDocument/formatting", 0, 0),
+		request(5, "textDocument/rename", 6, 3),
+		map[string]interface{}{"id": 6, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+	require.Len(t, messages, 7)
+
+	testCases := []struct {
+		name     string
+		field    string
+		expected interface{}
+	}{
+		{"hover", "result", hover{markupContent{"plaintext", "inteiro A"}, Range{Position{6, 3}, Position{6, 4}}}},
+		{"definition", "result", Location{uri, Range{Position{2, 9}, Position{2, 10}}}},
+		{"hover outside an identifier", "result", nil},
+		{"formatting", "result", []TextEdit{{Range{End: Position{8, 0}}, strings.Replace(source, "B<-A", "B <- A", 1)}}},
+		{"unknown method", "error", responseError{codeMethodNotFound, "método desconhecido: textDocument/rename"}},
+		{"shutdown", "result", nil},
+	}
+	for index, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message := messages[index+1]
+			require.Equal(t, float64(index+1), message["id"])
+			require.Equal(t, toJSON(t, tc.expected), message[tc.field])
+		})
+	}
+}
+
+func TestSyntaxErrorRange(t *testing.T) {
+	messages := session(t, open("inicio\nvarinicio\n\tinteiro A;\nvarfim;\nA <- A +;\nfim\n"))
+	require.Len(t, messages, 1)
+	// The range of the error covers the assignment, up to the token discarded
+	diagnostic := errorDiagnostic(t, messages[0])
+	require.Equal(t, toJSON(t, Range{Start: Position{4, 0}, End: Position{4, 9}}), diagnostic["range"])
+}
+
+func TestMissingFimRange(t *testing.T) {
+	messages := session(t, open("inicio\nvarinicio\n\tinteiro A;\nvarfim;\nleia A;\n"))
+	require.Len(t, messages, 1)
+	diagnostic := errorDiagnostic(t, messages[0])
+	require.Contains(t, diagnostic["message"], "programa sem fim")
+	// The error is at the end of the source, after the last line
+	require.Equal(t, toJSON(t, Range{Start: Position{5, 0}, End: Position{5, 1}}), diagnostic["range"])
+}
+
+// errorDiagnostic returns the diagnostic of severity error
+// published by notification
+func errorDiagnostic(t *testing.T, notification map[string]interface{}) map[string]interface{} {
+	diagnostics := notification["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	for _, diagnostic := range diagnostics {
+		if diagnostic.(map[string]interface{})["severity"] == float64(severityError) {
+			return diagnostic.(map[string]interface{})
+		}
+	}
+	t.Fatal("nenhum diagnóstico de erro")
+	return nil
+}
+
+func TestFormattingOptions(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nfim\n"
+	formatting := func(id int, options map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"id":     id,
+			"method": "textDocument/formatting",
+			"params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}, "options": options},
+		}
+	}
+	messages := session(t,
+		open(source),
+		formatting(1, map[string]interface{}{"tabSize": 2, "insertSpaces": true}),
+		formatting(2, map[string]interface{}{"tabSize": 2, "insertSpaces": false}),
+	)
+	require.Len(t, messages, 3)
+	edit := func(text string) interface{} {
+		return toJSON(t, []TextEdit{{Range{End: Position{5, 0}}, text}})
+	}
+	require.Equal(t, edit(strings.Replace(source, "inteiro", "  inteiro", 1)), messages[1]["result"])
+	require.Equal(t, edit(strings.Replace(source, "inteiro", "\tinteiro", 1)), messages[2]["result"])
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	var input bytes.Buffer
+	require.NoError(t, writeMessage(&input, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"}))
+	require.ErrorIs(t, NewServer(&input, ioutil.Discard).Run(), ErrorExitWithoutShutdown)
+}
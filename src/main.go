@@ -1,11 +1,45 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	_ "mgol-go/src/asmgen"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/cfg"
+	"mgol-go/src/config"
+	"mgol-go/src/console"
+	"mgol-go/src/debugger"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/exitcode"
+	_ "mgol-go/src/gogen"
+	"mgol-go/src/grade"
+	"mgol-go/src/grammar"
+	"mgol-go/src/interp"
+	"mgol-go/src/ir"
+	_ "mgol-go/src/jsgen"
 	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	_ "mgol-go/src/llvmgen"
+	"mgol-go/src/metrics"
 	"mgol-go/src/parser"
+	_ "mgol-go/src/pygen"
+	"mgol-go/src/repl"
+	"mgol-go/src/sem"
 	"mgol-go/src/stack"
+	"mgol-go/src/version"
+	"mgol-go/src/vm"
+	_ "mgol-go/src/wasmgen"
 	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+	"time"
 )
 
 var separator = "=================="
@@ -17,24 +51,621 @@ const (
 	gotoTablePath   = "./src/parser/tables/goto.tsv"
 )
 
+// Parsers the -backend flag can choose
+const (
+	backendSLR     = "slr"
+	backendDescent = "descendente"
+)
+
 func main() {
-	filePath := os.Args[1]
+	defer exitcode.Recover()
+	// The configuration file of the project gives the
+	// defaults of the flags, which override it
+	project, err := config.ForDirectory(".")
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	defaultTargets := ""
+	if project.Target != config.Default().Target {
+		defaultTargets = project.Target
+	}
+	// The flags given wrong end the compiler with exitcode.Usage,
+	// instead of the 2 of the flag package
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	output := flag.String("o", "programa.c", "arquivo onde o programa em C é escrito, - para a saída padrão. Os alvos de -target têm o mesmo nome, com as suas extensões")
+	grammarFile := flag.String("grammar", "", "arquivo BNF ou json com uma gramática alternativa, apenas verifica a sintaxe")
+	trace := flag.Bool("trace", false, "mostra cada passo da análise sintática")
+	astJSON := flag.String("ast-json", "", "arquivo onde a árvore sintática é escrita em json")
+	astDOT := flag.String("ast-dot", "", "arquivo onde a árvore sintática é escrita em DOT, do Graphviz")
+	cfgDOT := flag.String("cfg-dot", "", "arquivo onde o grafo de fluxo de controle é escrito em DOT, do Graphviz")
+	targets := flag.String("target", defaultTargets, "alvos para os quais o programa também é gerado, separados por vírgula: "+strings.Join(backend.Names(), ", "))
+	emitIR := flag.String("emit-ir", "", "arquivo onde o código de três endereços é escrito, - para a saída padrão")
+	level := flag.String("O", project.Optimization.String(), "nível de otimização do código de três endereços, em -emit-ir e nos alvos gerados a partir dele: 0, nenhuma, 1, propagação de constantes, ou 2, também remoção do código sem uso e peephole")
+	passes := flag.String("passes", "", "passagens de otimização executadas após as de -O, na ordem dada, separadas por vírgula: "+strings.Join(ir.PassNames(), ", "))
+	plugins := flag.String("plugin", "", "plugins do Go, compilados com -buildmode=plugin e separados por vírgula, que registram alvos e passagens de otimização")
+	for _, fixed := range []ir.Level{ir.O0, ir.O1, ir.O2} {
+		flag.Var(levelShorthand{level, fixed.String()}, "O"+fixed.String(), "o mesmo que -O "+fixed.String())
+	}
+	optimize := flag.Bool("optimize", false, "o mesmo que -O 2")
+	optimizeStats := flag.Bool("optimize-stats", false, "mostra quantas instruções e blocos a otimização removeu")
+	parseTreeDOT := flag.String("parse-tree-dot", "", "arquivo onde a árvore de derivação é escrita em DOT, do Graphviz")
+	dfaDOT := flag.String("dfa-dot", "", "arquivo onde o autômato do analisador léxico é escrito em DOT, do Graphviz. Sem programas, apenas o escreve")
+	backend := flag.String("backend", backendSLR, "analisador sintático usado: slr, guiado pelas tabelas, ou descendente, recursivo")
+	maxErrors := flag.Int("max-errors", 0, "número de erros de sintaxe após o qual a análise é interrompida, 0 para não haver limite")
+	maxNesting := flag.Int("max-nesting", parser.DefaultMaxNesting, "profundidade máxima de parênteses e blocos aninhados, 0 para não haver limite")
+	narrowing := flag.String("narrowing", sem.Warn.String(), "atribuição de um real a uma variável inteiro: permitir, avisar ou proibir")
+	promotion := flag.String("promotion", sem.Warn.String(), "uso de um inteiro como real, em operações ou atribuições: permitir, avisar ou proibir")
+	implicit := flag.Bool("implicit", false, "declara as variáveis na primeira atribuição, com o tipo do valor atribuído")
+	fold := flag.Bool("fold", false, "substitui as expressões constantes da árvore sintática pelo seu valor")
+	sourceComments := flag.Bool("source-comments", false, "escreve cada comando do programa como comentário antes do seu código em C, com a sua linha")
+	decimalComma := flag.Bool("decimal-comma", project.DecimalComma(), "escreve os reais com vírgula, como 3,140000, em vez de ponto, em programa.c e nos alvos")
+	sourceMap := flag.String("source-map", "", "arquivo onde é escrita em json a linha do programa de onde vem cada trecho do código em C")
+	run := flag.Bool("run", false, "executa o programa com o interpretador, lendo a entrada padrão, em vez de gerar programa.c")
+	useVM := flag.Bool("vm", false, "com -run, executa o programa compilado para bytecode na máquina virtual, mais rápida em laços longos, após as otimizações de -O")
+	deterministic := flag.Bool("deterministic", false, "com -run, executa como na correção automática: lê toda a entrada padrão, normaliza as quebras de linha da entrada e da saída para \\n e escreve os reais com ponto")
+	runTrace := flag.String("run-trace", "", "com -run, arquivo onde é escrito o teste de mesa: cada comando executado e cada condição verificada, com os valores das variáveis após ele, - para a saída padrão")
+	maxSteps := flag.Int64("max-steps", 0, "com -run, número máximo de comandos executados, ou de instruções com -vm, 0 para não haver limite")
+	timeout := flag.Duration("timeout", 0, "com -run, tempo máximo de execução, como 2s, 0 para não haver limite")
+	maxVariables := flag.Int64("max-variables", 0, "com -run, número máximo de variáveis declaradas, 0 para não haver limite")
+	maxMemory := flag.Int64("max-memory", 0, "com -run, número máximo de bytes ocupados pelas variáveis, 4 por inteiro, 8 por real e o tamanho do texto de cada literal, 0 para não haver limite")
+	maxLiteral := flag.Int64("max-literal", 0, "com -run, número máximo de bytes do texto guardado em um literal, 0 para não haver limite")
+	maxIterations := flag.Int64("max-iterations", 0, "com -run, número máximo de iterações de todos os repita juntos, 0 para não haver limite")
+	sandbox := flag.Bool("sandbox", false, "com -run, executa no perfil restrito, para servidores que recebem programas de qualquer um: limita os recursos, mantendo os limites menores dados, e recusa as opções que leem ou escrevem outros arquivos")
+	debug := flag.Bool("debug", false, "executa o programa com o depurador, que para antes do primeiro comando e lê os seus comandos da entrada padrão, help os lista")
+	startREPL := flag.Bool("repl", false, "lê declarações, comandos e expressões linha a linha, executando cada um com o interpretador. :ajuda lista os comandos da sessão")
+	arena := flag.Bool("arena", false, "aloca os nós da árvore sintática em blocos, mais rápido para programas grandes")
+	showStats := flag.Bool("stats", false, "mostra na saída de erros o tempo e as alocações de cada fase, e quantos tokens, nós da árvore sintática e instruções do código de três endereços o programa tem")
+	listing := flag.String("listing", "", "arquivo onde é escrita a listagem da compilação: as linhas numeradas do programa com os seus erros e avisos, a contagem dos tokens e a tabela de símbolos, - para a saída padrão. Apenas com um programa")
+	showVersion := flag.Bool("version", false, "mostra a versão do compilador, o commit de onde foi gerado e a revisão da linguagem e da gramática")
+	switch err := flag.CommandLine.Parse(os.Args[1:]); {
+	case err == flag.ErrHelp:
+		os.Exit(exitcode.Success)
+	case err != nil:
+		os.Exit(exitcode.Usage)
+	}
+	if *showVersion {
+		if err := version.Get().Write(os.Stdout); err != nil {
+			exitcode.Fatal(exitcode.Internal, err)
+		}
+		return
+	}
 
-	file, err := os.Open(filePath)
+	if *sandbox {
+		checkSandbox(*run)
+	}
+	loadPlugins(*plugins)
+	narrowingStrictness, err := sem.ParseStrictness(*narrowing)
 	if err != nil {
-		log.Fatal(err)
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	promotionStrictness, err := sem.ParseStrictness(*promotion)
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	optimization, err := ir.ParseLevel(*level)
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	if *optimize {
+		optimization = ir.O2
+	}
+	passNames := splitList(*passes)
+	if *runTrace != "" && (*useVM || *deterministic) {
+		exitcode.Fatal(exitcode.Usage, "-run-trace só pode ser usado com o interpretador, sem -vm e -deterministic")
+	}
+	bounds := limits.Limits{Steps: *maxSteps, Variables: *maxVariables, Memory: *maxMemory, Literal: *maxLiteral, Iterations: *maxIterations}
+	if *sandbox {
+		bounds = bounds.Tighten(limits.Sandbox)
+		if *timeout == 0 || *timeout > limits.SandboxTimeout {
+			*timeout = limits.SandboxTimeout
+		}
+	}
+	optimized := optimization > ir.O0 || len(passNames) > 0
+
+	if *startREPL {
+		runREPL(*decimalComma)
+		return
+	}
+	// The automaton is the same for every program
+	if *dfaDOT != "" {
+		writeFile(*dfaDOT, lexer.WriteAutomatonDOT)
+		if flag.NArg() == 0 {
+			return
+		}
 	}
-	defer file.Close()
 
-	symbolTable := lexer.GetSymbolTableInstance()
+	errorhandling.EnableBuffering()
 
-	lexer.FillSymbolTable(symbolTable)
-	defer symbolTable.Cleanup()
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+
+	// The lexical errors are skipped by the parsers, so they are
+	// counted for the status the compiler ends with
+	lexicalErrors := 0
+	// listed keeps every diagnostic for -listing
+	listed := []errorhandling.Diagnostic{}
+	handler := errorhandling.DefaultHandler()
+	lexicalHandler := errorhandling.DiagnosticHandlerFunc(func(diagnostic errorhandling.Diagnostic) {
+		if diagnostic.Severity == errorhandling.Error {
+			lexicalErrors++
+		}
+		listed = append(listed, diagnostic)
+		handler.Handle(diagnostic)
+	})
+	if *listing != "" && flag.NArg() > 1 {
+		exitcode.Fatal(exitcode.Usage, "-listing só pode ser usado com um programa")
+	}
+	var listingSource string
+	// The scanners add what they took to scanStats, which
+	// is taken from the time of the parser that calls them
+	scanStats := &lexer.ScanStats{}
+	stats := &compilationStats{enabled: *showStats}
 
-	scanner := lexer.NewScanner(file, symbolTable)
+	// A program may be split among several files, read in the
+	// order given. Their names are only shown when there are many.
+	// - is the standard input, read whole as the scanner goes back
+	// on the text, which a pipe does not allow
+	scanners := []*lexer.Scanner{}
+	for _, filePath := range flag.Args() {
+		var scanner *lexer.Scanner
+		if filePath == "-" {
+			source, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				exitcode.Fatal(exitcode.Internal, err)
+			}
+			scanner = lexer.NewStringScanner(string(source), symbolTable)
+			listingSource = string(source)
+		} else {
+			file, err := os.Open(filePath)
+			if err != nil {
+				exitcode.Fatal(exitcode.Usage, err)
+			}
+			if *listing != "" {
+				source, err := ioutil.ReadFile(filePath)
+				if err != nil {
+					exitcode.Fatal(exitcode.Usage, err)
+				}
+				listingSource = string(source)
+			}
+			defer file.Close()
+			scanner = lexer.NewScanner(file, symbolTable)
+		}
+		if flag.NArg() > 1 {
+			scanner.SetName(filePath)
+		}
+		scanner.SetDiagnosticHandler(lexicalHandler)
+		scanner.SetKeywordCaseWarning(project.KeywordCaseWarning())
+		if *showStats {
+			scanner.SetStats(scanStats)
+		}
+		scanners = append(scanners, scanner)
+	}
+	if len(scanners) == 0 {
+		exitcode.Fatal(exitcode.Usage, "nenhum arquivo informado")
+	}
+	scanner := scanners[0]
 	stack := stack.NewStack(stackCapacity)
 	rules := parser.GetRulesMap(grammarPath)
-	parser := parser.NewParser(scanner, stack, rules, actionTablePath, gotoTablePath)
+	var analyzer parser.Analyzer
+	switch *backend {
+	case backendSLR:
+		slrParser := parser.NewParser(scanner, stack, rules, actionTablePath, gotoTablePath)
+		if *grammarFile != "" {
+			g, err := grammar.LoadFile(*grammarFile)
+			if err != nil {
+				exitcode.Fatal(exitcode.Usage, err)
+			}
+			for _, conflict := range slrParser.UseGrammar(g) {
+				log.Print(conflict)
+			}
+		}
+		if *trace {
+			slrParser.SetTrace(os.Stdout)
+		}
+		analyzer = slrParser
+	case backendDescent:
+		if *grammarFile != "" || *trace {
+			exitcode.Fatal(exitcode.Usage, "-grammar e -trace só podem ser usados com -backend slr")
+		}
+		analyzer = parser.NewRecursiveDescentParser(scanner, rules)
+	default:
+		exitcode.Fatalf(exitcode.Usage, "analisador sintático desconhecido: %s", *backend)
+	}
+
+	for _, source := range scanners[1:] {
+		if err := analyzer.AddSource(source); err != nil {
+			exitcode.Fatal(exitcode.Internal, err)
+		}
+	}
+
+	analyzer.SetErrorLimit(*maxErrors)
+	analyzer.SetMaxNesting(*maxNesting)
+	analyzer.SetImplicitDeclarations(*implicit)
+	analyzer.SetSourceComments(*sourceComments)
+	analyzer.SetDecimalComma(*decimalComma)
+	analyzer.SetQuietReductions(*run || *debug)
+	if *arena {
+		analyzer.UseArena(ast.NewArena())
+	}
+
+	var result *parser.ParseResult
+	parsing := metrics.Measure(func() { result = analyzer.Parse() })
+	stats.AddPhase("léxica", scanStats.Sample)
+	stats.AddPhase("sintática", parsing.Sub(scanStats.Sample))
+	stats.AddCount("tokens", scanStats.Tokens)
+	if result.Program != nil {
+		stats.AddCount("nós da árvore sintática", ast.CountNodes(result.Program))
+	}
+	analyzed := result.Accepted && len(result.Errors) == 0 && len(result.IOErrors) == 0 && *grammarFile == ""
+
+	var info *sem.Info
+	if analyzed && result.Program != nil {
+		checker := sem.NewChecker(symbolTable)
+		checker.SetNarrowing(narrowingStrictness)
+		checker.SetPromotion(promotionStrictness)
+		checker.SetImplicitDeclarations(*implicit)
+		stats.AddPhase("semântica", metrics.Measure(func() {
+			info = checker.Check(result.Program)
+			if *fold && len(info.Errors) == 0 {
+				sem.Fold(result.Program, info)
+			}
+		}))
+	}
+
+	if result.Program != nil {
+		if *astJSON != "" {
+			writeFile(*astJSON, func(w io.Writer) error { return ast.EncodeJSON(w, result.Program) })
+		}
+		if *astDOT != "" {
+			writeFile(*astDOT, func(w io.Writer) error { return ast.EncodeDOT(w, result.Program) })
+		}
+		if *cfgDOT != "" {
+			writeFile(*cfgDOT, func(w io.Writer) error { return cfg.EncodeDOT(w, cfg.New(result.Program)) })
+		}
+	}
+	if *parseTreeDOT != "" && result.ParseTree != nil {
+		writeFile(*parseTreeDOT, result.ParseTree.EncodeDOT)
+	}
+
+	// The type checker reports the undeclared variables and checks the
+	// assignments. The operands with different types are reported by the
+	// semantic actions as well, so they were already shown when those failed
+	semanticErrors := 0
+	if info != nil {
+		operandTypes := sem.Code(sem.ErrorOperandTypes)
+		info.Report(errorhandling.DiagnosticHandlerFunc(func(diagnostic errorhandling.Diagnostic) {
+			listed = append(listed, diagnostic)
+			if result.SemanticErrorFound && diagnostic.Code == operandTypes {
+				return
+			}
+			if diagnostic.Severity == errorhandling.Error {
+				semanticErrors++
+			}
+			handler.Handle(diagnostic)
+		}))
+		errorhandling.FlushDiagnostics()
+	}
+	if *listing != "" {
+		for _, syntaxError := range result.Errors {
+			listed = append(listed, syntaxError.Diagnostic())
+		}
+		writeFile(*listing, func(w io.Writer) error {
+			return lexer.WriteSourceListing(w, listingSource, listed, symbolTable)
+		})
+	}
+	if !analyzed {
+		stats.show()
+		os.Exit(exitStatus(result, lexicalErrors, semanticErrors))
+	}
+	// The program is still run and generated with lexical errors,
+	// ending the compiler with exitcode.Syntax afterwards
+	status := exitStatus(result, lexicalErrors, semanticErrors)
+	if *run || *debug {
+		// Running the program is not a phase of the compiler
+		stats.show()
+	}
+	if result.Succeeded() && semanticErrors == 0 && *debug && info != nil {
+		debugProgram(result.Program, info, *decimalComma)
+		os.Exit(status)
+	}
+	if result.Succeeded() && semanticErrors == 0 && *run && info != nil {
+		if *deterministic {
+			gradeProgram(result.Program, info, grade.Options{VM: *useVM, Limits: bounds, Timeout: *timeout})
+			os.Exit(status)
+		}
+		if *useVM {
+			runBytecode(lower(result.Program, info, optimization, passNames, *optimizeStats), *decimalComma, bounds, *timeout)
+			os.Exit(status)
+		}
+		runProgram(result.Program, info, *decimalComma, bounds, *timeout, *runTrace)
+		os.Exit(status)
+	}
+	if result.Succeeded() && semanticErrors == 0 {
+		stopGeneration := metrics.Start()
+		writeFile(*output, analyzer.WriteCode)
+		if *sourceMap != "" {
+			writeFile(*sourceMap, analyzer.EncodeSourceMap)
+		}
+		var lowered *ir.Program
+		lowering := metrics.Sample{}
+		if info != nil && (*emitIR != "" || optimized && *targets != "" || *showStats) {
+			lowering = metrics.Measure(func() {
+				lowered = lower(result.Program, info, optimization, passNames, *optimizeStats)
+			})
+			stats.AddPhase("código de três endereços", lowering)
+			stats.AddCount("instruções do código de três endereços", lowered.InstructionCount())
+		}
+		if *emitIR != "" && lowered != nil {
+			writeFile(*emitIR, func(w io.Writer) error {
+				_, err := io.WriteString(w, lowered.String())
+				return err
+			})
+		}
+		if *targets != "" && info != nil {
+			if !optimized {
+				lowered = nil
+			}
+			generateTargets(strings.Split(*targets, ","), outputName(*output), *decimalComma, result.Program, info, lowered)
+		}
+		stats.AddPhase("geração de código", stopGeneration().Sub(lowering))
+	}
+	stats.show()
+	os.Exit(status)
+}
+
+// compilationStats is the report of -stats, shown once on
+// whichever path the compiler ends
+type compilationStats struct {
+	metrics.Report
+	enabled bool
+	shown   bool
+}
+
+// show writes the report on the standard error, if -stats was given
+func (s *compilationStats) show() {
+	if !s.enabled || s.shown {
+		return
+	}
+	s.shown = true
+	if err := s.Write(os.Stderr); err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
+}
+
+// exitStatus returns the status the compiler ends with for a program
+// parsed into result, with lexicalErrors and semanticErrors
+func exitStatus(result *parser.ParseResult, lexicalErrors, semanticErrors int) int {
+	switch {
+	case lexicalErrors > 0 || !result.Accepted || len(result.Errors) > 0 || len(result.IOErrors) > 0:
+		return exitcode.Syntax
+	case result.SemanticErrorFound || semanticErrors > 0:
+		return exitcode.Semantic
+	}
+	return exitcode.Success
+}
+
+// levelShorthand is a boolean flag, like -O2, setting
+// the level of -O to its own
+type levelShorthand struct {
+	level *string
+	fixed string
+}
+
+func (f levelShorthand) String() string {
+	return ""
+}
 
-	parser.Parse()
+func (f levelShorthand) Set(value string) error {
+	if value != "true" {
+		return fmt.Errorf("-O%s não recebe valor", f.fixed)
+	}
+	*f.level = f.fixed
+	return nil
+}
+
+func (f levelShorthand) IsBoolFlag() bool {
+	return true
+}
+
+// sandboxFlags are the flags that can be given with -sandbox, as
+// they neither read nor write files other than the program
+var sandboxFlags = map[string]bool{
+	"sandbox": true, "run": true, "vm": true, "deterministic": true, "decimal-comma": true,
+	"backend": true, "max-errors": true, "max-nesting": true, "narrowing": true, "promotion": true,
+	"implicit": true, "fold": true, "O": true, "O0": true, "O1": true, "O2": true, "optimize": true, "passes": true,
+	"max-steps": true, "timeout": true, "max-variables": true, "max-memory": true,
+	"max-literal": true, "max-iterations": true,
+}
+
+// checkSandbox ends the compiler when -sandbox is given without -run,
+// or with a flag that is not one of sandboxFlags
+func checkSandbox(run bool) {
+	if !run {
+		exitcode.Fatal(exitcode.Usage, "-sandbox só pode ser usado com -run")
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if !sandboxFlags[f.Name] {
+			exitcode.Fatalf(exitcode.Usage, "-%s não pode ser usado com -sandbox", f.Name)
+		}
+	})
+}
+
+// runProgram runs program with the interpreter on the standard input
+// and output, writing the reals with a comma if decimalComma, and
+// stopping it once it goes over bounds or runs for longer than timeout.
+// The dry run table is written on tracePath, if not empty
+func runProgram(program *ast.Program, info *sem.Info, decimalComma bool, bounds limits.Limits, timeout time.Duration, tracePath string) {
+	ctx, cancel := runContext(timeout)
+	defer cancel()
+	options := backend.Options{DecimalComma: decimalComma}
+	environment := interp.NewEnvironment(console.New(os.Stdin, os.Stdout, options), options)
+	environment.SetLimits(ctx, bounds)
+	if tracePath == "" {
+		if err := environment.Run(program, info); err != nil {
+			exitcode.Fatal(exitcode.Runtime, err)
+		}
+		return
+	}
+	writeFile(tracePath, func(w io.Writer) error {
+		environment.SetTrace(w)
+		return environment.Run(program, info)
+	})
+}
+
+// gradeProgram runs program like the automated grading does, on the
+// whole standard input, writing its normalized output
+func gradeProgram(program *ast.Program, info *sem.Info, options grade.Options) {
+	input, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
+	output, err := grade.Run(program, info, string(input), options)
+	io.WriteString(os.Stdout, output)
+	if err != nil {
+		exitcode.Fatal(exitcode.Runtime, err)
+	}
+}
+
+// runContext returns the context of a program run for up to
+// timeout, with no deadline when it is 0
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// debugProgram runs program with the debugger on the standard
+// input and output, like runProgram
+func debugProgram(program *ast.Program, info *sem.Info, decimalComma bool) {
+	if err := debugger.New(program, info, os.Stdin, os.Stdout, backend.Options{DecimalComma: decimalComma}).Run(); err != nil {
+		exitcode.Fatal(exitcode.Runtime, err)
+	}
+}
+
+// runREPL starts a session on the standard input and output, showing
+// the prompts only when the input is a terminal
+func runREPL(decimalComma bool) {
+	session := repl.New(parser.GetRulesMap(grammarPath), os.Stdin, os.Stdout, backend.Options{DecimalComma: decimalComma})
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		session.SetPrompts(false)
+	}
+	if err := session.Run(); err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
+}
+
+// runBytecode compiles lowered to bytecode and runs it on the virtual
+// machine, on the standard input and output, like runProgram
+func runBytecode(lowered *ir.Program, decimalComma bool, bounds limits.Limits, timeout time.Duration) {
+	program, err := bytecode.Compile(lowered)
+	if err == nil {
+		ctx, cancel := runContext(timeout)
+		defer cancel()
+		programIO := console.New(os.Stdin, os.Stdout, backend.Options{DecimalComma: decimalComma})
+		err = vm.RunContext(ctx, program, programIO, bounds)
+	}
+	if err != nil {
+		exitcode.Fatal(exitcode.Runtime, err)
+	}
+}
+
+// lower lowers program to three-address code, optimizing it on level,
+// running the passes named passNames and then showing what was removed
+// if showStatistics
+func lower(program *ast.Program, info *sem.Info, level ir.Level, passNames []string, showStatistics bool) *ir.Program {
+	lowered, err := ir.Lower(program, info)
+	if err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
+	lowered, statistics := ir.Optimize(lowered, level)
+	lowered, removed, err := ir.RunPasses(lowered, passNames)
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+	statistics.Add(removed)
+	if showStatistics {
+		log.Print(statistics)
+	}
+	return lowered
+}
+
+// loadPlugins opens the Go plugins on paths, separated by commas, whose
+// init functions register their backends and passes, so a fork can try
+// new ones on the compiler as it is built
+func loadPlugins(paths string) {
+	for _, path := range splitList(paths) {
+		if _, err := plugin.Open(path); err != nil {
+			exitcode.Fatal(exitcode.Internal, err)
+		}
+	}
+}
+
+// splitList returns the items of list, separated by commas, without spaces
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// outputName returns the name of the files of the targets,
+// the one of the C program without its extension
+func outputName(output string) string {
+	if output == "-" {
+		return "programa"
+	}
+	return strings.TrimSuffix(output, filepath.Ext(output))
+}
+
+// generateTargets writes the program as name with the file extension
+// of each target, along with the files its output runs with, if any. The
+// targets that follow options write the reals with a comma if decimalComma,
+// and the ones generated from the three-address code get lowered, if not nil
+func generateTargets(names []string, name string, decimalComma bool, program *ast.Program, info *sem.Info, lowered *ir.Program) {
+	for _, targetName := range names {
+		target, found := backend.Lookup(strings.TrimSpace(targetName))
+		if !found {
+			exitcode.Fatalf(exitcode.Usage, "alvo desconhecido: %s, os disponíveis são %s", targetName, strings.Join(backend.Names(), ", "))
+		}
+		if configurable, found := target.(backend.Configurable); found {
+			target = configurable.WithOptions(backend.Options{DecimalComma: decimalComma})
+		}
+		generate := func(w io.Writer) error { return target.Generate(program, info, w) }
+		if fromIR, found := target.(backend.FromIR); found && lowered != nil {
+			generate = func(w io.Writer) error { return fromIR.GenerateIR(lowered, w) }
+		}
+		writeFile(name+target.FileExtension(), generate)
+		if withRuntime, found := target.(backend.WithRuntime); found {
+			for path, contents := range withRuntime.Runtime() {
+				writeFile(filepath.Join(filepath.Dir(name), path), func(w io.Writer) error {
+					_, err := io.WriteString(w, contents)
+					return err
+				})
+			}
+		}
+	}
+}
+
+// writeFile creates the file on path and writes on it with encode,
+// or writes on the standard output if path is -
+func writeFile(path string, encode func(io.Writer) error) {
+	if path == "-" {
+		if err := encode(os.Stdout); err != nil {
+			exitcode.Fatal(exitcode.Internal, err)
+		}
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
+	defer file.Close()
+
+	if err := encode(file); err != nil {
+		exitcode.Fatal(exitcode.Internal, err)
+	}
 }
@@ -0,0 +1,117 @@
+// Package metrics measures the time and the memory the phases of the
+// compiler take, and writes them along with the sizes of what each
+// one built, for -stats:
+//
+//	report := &metrics.Report{}
+//	report.AddPhase("semântica", metrics.Measure(func() { info = checker.Check(program) }))
+//	report.AddCount("nós da árvore sintática", nodes)
+//	report.Write(os.Stderr)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+)
+
+// Sample is what some code took to run
+type Sample struct {
+	Duration time.Duration
+	// Allocations is how many objects it allocated on the heap
+	// and Bytes their size
+	Allocations uint64
+	Bytes       uint64
+}
+
+// Add returns the sum of s and other
+func (s Sample) Add(other Sample) Sample {
+	return Sample{s.Duration + other.Duration, s.Allocations + other.Allocations, s.Bytes + other.Bytes}
+}
+
+// Sub returns s without other, which it must include
+func (s Sample) Sub(other Sample) Sample {
+	return Sample{s.Duration - other.Duration, s.Allocations - other.Allocations, s.Bytes - other.Bytes}
+}
+
+// Start begins a measure, which the function it returns ends,
+// giving what ran between the two calls. Reading the memory
+// statistics stops the program for a moment, so the code
+// measured should not be too small
+func Start() func() Sample {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	return func() Sample {
+		duration := time.Since(start)
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		return Sample{duration, after.Mallocs - before.Mallocs, after.TotalAlloc - before.TotalAlloc}
+	}
+}
+
+// Measure returns what run took
+func Measure(run func()) Sample {
+	stop := Start()
+	run()
+	return stop()
+}
+
+// Report holds the phases of a compilation and counts of what
+// they built, written in the order they were added
+type Report struct {
+	phases []phase
+	counts []count
+}
+
+type phase struct {
+	name string
+	Sample
+}
+
+type count struct {
+	name  string
+	value int
+}
+
+// AddPhase adds the phase name, which took sample
+func (r *Report) AddPhase(name string, sample Sample) {
+	r.phases = append(r.phases, phase{name, sample})
+}
+
+// AddCount adds how many of name there are
+func (r *Report) AddCount(name string, value int) {
+	r.counts = append(r.counts, count{name, value})
+}
+
+// Empty tells whether nothing was added to r
+func (r *Report) Empty() bool {
+	return len(r.phases) == 0 && len(r.counts) == 0
+}
+
+// Write writes to w a table of the phases, with their total,
+// followed by the counts
+func (r *Report) Write(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Fase\tTempo\tAlocações\tBytes")
+	total := Sample{}
+	for _, phase := range r.phases {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%d\n", phase.name, phase.Duration, phase.Allocations, phase.Bytes)
+		total = total.Add(phase.Sample)
+	}
+	fmt.Fprintf(writer, "Total\t%s\t%d\t%d\n", total.Duration, total.Allocations, total.Bytes)
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if len(r.counts) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	writer = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, count := range r.counts {
+		fmt.Fprintf(writer, "%s\t%d\n", count.name, count.value)
+	}
+	return writer.Flush()
+}
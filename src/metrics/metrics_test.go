@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasure(t *testing.T) {
+	var kept [][]byte
+	sample := Measure(func() {
+		for i := 0; i < 100; i++ {
+			kept = append(kept, make([]byte, 1024))
+		}
+	})
+	require.Len(t, kept, 100)
+	require.True(t, sample.Allocations >= 100, "%d alocações", sample.Allocations)
+	require.True(t, sample.Bytes >= 100*1024, "%d bytes", sample.Bytes)
+	require.True(t, sample.Duration > 0)
+}
+
+func TestReport(t *testing.T) {
+	whole := Sample{3 * time.Millisecond, 30, 3000}
+	scanning := Sample{time.Millisecond, 10, 1000}
+
+	report := &Report{}
+	require.True(t, report.Empty())
+	report.AddPhase("léxica", scanning)
+	report.AddPhase("sintática", whole.Sub(scanning))
+	report.AddCount("tokens", 42)
+	require.False(t, report.Empty())
+
+	var text strings.Builder
+	require.NoError(t, report.Write(&text))
+	require.Equal(t, ""+
+		"Fase       Tempo  Alocações  Bytes\n"+
+		"léxica     1ms    10         1000\n"+
+		"sintática  2ms    20         2000\n"+
+		"Total      3ms    30         3000\n"+
+		"\n"+
+		"tokens  42\n", text.String())
+}
@@ -0,0 +1,181 @@
+// Package mgol checks and runs a program from its source in a single
+// call, for Go programs that embed the language, like a grader or a
+// web service:
+//
+//	result := mgol.Run(ctx, source, os.Stdin, os.Stdout, mgol.Options{})
+//	for _, diagnostic := range result.Diagnostics {
+//		fmt.Println(diagnostic)
+//	}
+//	if result.Status == mgol.Failed {
+//		fmt.Println(result.Err)
+//	}
+//
+// The program is parsed with the rules of grammar.json kept on the
+// code, so no file is needed, and run on the interpreter. It only
+// reads stdin and writes stdout: neither the files nor the
+// environment of the process can be reached by it. Code sent by
+// anyone, like on a public playground, should still be run with
+// Sandbox, which bounds what it may use
+package mgol
+
+import (
+	"context"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/console"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/interp"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+)
+
+// Options tells how a program is checked and run. Its zero value
+// accepts the implicit conversions silently, and runs without limits
+type Options struct {
+	Narrowing sem.Strictness
+	Promotion sem.Strictness
+	// Implicit declares the variables on their first assignment
+	Implicit     bool
+	DecimalComma bool
+	// NoKeywordCaseWarning leaves out the warnings about identifiers
+	// that differ from a reserved word only by case, like ESCREVA
+	NoKeywordCaseWarning bool
+	// Language is the dialect the program is written on, mgol when nil
+	Language *lexer.Language
+	Limits   limits.Limits
+	// Sandbox runs the program with the limits of limits.Sandbox,
+	// those of Limits kept where smaller, for up to
+	// limits.SandboxTimeout
+	Sandbox bool
+}
+
+// Status tells how far a program went
+type Status int
+
+const (
+	// Succeeded is the one of a program run up to its end
+	Succeeded Status = iota
+	// Rejected is the one of a program with lexical, syntax or
+	// semantic errors, which is not run
+	Rejected
+	// Failed is the one of a program stopped by an error while
+	// running, like an inteiro divided by zero or a limit gone over
+	Failed
+)
+
+var statusNames = map[Status]string{
+	Succeeded: "sucesso",
+	Rejected:  "rejeitado",
+	Failed:    "falha",
+}
+
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// Result is what running a program gave
+type Result struct {
+	Status Status
+	// Diagnostics holds the errors and warnings found
+	// on the source, sorted by position
+	Diagnostics []errorhandling.Diagnostic
+	// Variables holds the variables of the program with the values
+	// they had when it stopped, nil when it was Rejected
+	Variables []interp.Variable
+	// Err is the error that stopped a program that Failed
+	Err error
+}
+
+// Compiled is a program checked by Compile
+type Compiled struct {
+	// Program and Info are nil when the program could not be parsed
+	Program *ast.Program
+	Info    *sem.Info
+	// Diagnostics holds the errors and warnings found
+	// on the source, sorted by position
+	Diagnostics []errorhandling.Diagnostic
+	// Accepted tells whether the program has no errors,
+	// so it can be run or generated for a target
+	Accepted bool
+}
+
+// Compile parses and checks the program src with options
+func Compile(src string, options Options) Compiled {
+	language := options.Language
+	if language == nil {
+		language = lexer.DefaultLanguage()
+	}
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	scanner := lexer.NewStringScanner(src, symbolTable)
+	scanner.SetLanguage(language)
+	scanner.SetKeywordCaseWarning(!options.NoKeywordCaseWarning)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+
+	p := parser.NewRecursiveDescentParser(scanner, parser.DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	p.SetImplicitDeclarations(options.Implicit)
+	parsed := p.Parse()
+	for _, syntaxError := range parsed.Errors {
+		diagnostics.Add(syntaxError.Diagnostic())
+	}
+	for _, ioError := range parsed.IOErrors {
+		diagnostics.Add(ioError.Diagnostic())
+	}
+
+	compiled := Compiled{Program: parsed.Program}
+	if parsed.Program != nil {
+		checker := sem.NewChecker(symbolTable)
+		checker.SetNarrowing(options.Narrowing)
+		checker.SetPromotion(options.Promotion)
+		checker.SetImplicitDeclarations(options.Implicit)
+		compiled.Info = checker.Check(parsed.Program)
+		compiled.Info.Report(diagnostics)
+	}
+	compiled.Diagnostics = diagnostics.Diagnostics()
+	compiled.Accepted = compiled.Info != nil && parsed.Accepted
+	for _, diagnostic := range compiled.Diagnostics {
+		if diagnostic.Severity == errorhandling.Error {
+			compiled.Accepted = false
+		}
+	}
+	return compiled
+}
+
+// Run checks the program src and runs it reading stdin and writing
+// on stdout, until it ends, fails or ctx is done
+func Run(ctx context.Context, src string, stdin io.Reader, stdout io.Writer, options Options) Result {
+	return Compile(src, options).Run(ctx, stdin, stdout, options)
+}
+
+// Run runs c like the function Run, with the limits and the
+// output of options, being Rejected if c was not Accepted
+func (c Compiled) Run(ctx context.Context, stdin io.Reader, stdout io.Writer, options Options) Result {
+	result := Result{Status: Rejected, Diagnostics: c.Diagnostics}
+	if !c.Accepted {
+		return result
+	}
+
+	bounds := options.Limits
+	if options.Sandbox {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.SandboxTimeout)
+		defer cancel()
+		bounds = bounds.Tighten(limits.Sandbox)
+	}
+	backendOptions := backend.Options{DecimalComma: options.DecimalComma}
+	environment := interp.NewEnvironment(console.New(stdin, stdout, backendOptions), backendOptions)
+	environment.SetLimits(ctx, bounds)
+	result.Err = environment.Run(c.Program, c.Info)
+	result.Variables = environment.Variables()
+	result.Status = Succeeded
+	if result.Err != nil {
+		result.Status = Failed
+	}
+	return result
+}
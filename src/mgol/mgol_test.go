@@ -0,0 +1,145 @@
+package mgol
+
+import (
+	"bytes"
+	"context"
+	"mgol-go/src/interp"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name        string
+		source      string
+		input       string
+		options     Options
+		status      Status
+		output      string
+		diagnostics []string
+		variables   []interp.Variable
+	}{
+		{
+			"succeeded",
+			"inicio varinicio inteiro A; literal N; varfim; leia A; leia N; A <- A * 2; escreva A; escreva N; fim",
+			"21\nfim\n",
+			Options{},
+			Succeeded,
+			"42fim",
+			nil,
+			[]interp.Variable{{Name: "A", Type: lexer.INTEGER, Value: "42"}, {Name: "N", Type: lexer.LITERAL, Value: "fim"}},
+		},
+		{
+			"warning",
+			"inicio varinicio real B; varfim; B <- 2; escreva B; fim",
+			"",
+			Options{Promotion: sem.Warn, DecimalComma: true},
+			Succeeded,
+			"2,000000",
+			[]string{"aviso na linha 1 coluna 39"},
+			[]interp.Variable{{Name: "B", Type: lexer.REAL, Value: "2,000000"}},
+		},
+		{
+			"syntax error",
+			"inicio varinicio inteiro A; varfim;\nA <- ;\nfim",
+			"",
+			Options{},
+			Rejected,
+			"",
+			[]string{"aviso na linha 1 coluna 18", "erro na linha 2 coluna 6"},
+			nil,
+		},
+		{
+			"semantic error",
+			"inicio varinicio varfim;\nescreva B;\nfim",
+			"",
+			Options{},
+			Rejected,
+			"",
+			[]string{"erro na linha 2 coluna 9"},
+			nil,
+		},
+		{
+			"failed",
+			"inicio varinicio inteiro A; varfim; escreva \"antes \"; A <- 1 / A; fim",
+			"",
+			Options{},
+			Failed,
+			"antes ",
+			[]string{"aviso na linha 1 coluna 64"},
+			[]interp.Variable{{Name: "A", Type: lexer.INTEGER, Value: "0"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			result := Run(context.Background(), tc.source, strings.NewReader(tc.input), &output, tc.options)
+			require.Equal(t, tc.status, result.Status)
+			require.Equal(t, tc.output, output.String())
+			require.Equal(t, tc.variables, result.Variables)
+			require.Len(t, result.Diagnostics, len(tc.diagnostics))
+			for index, diagnostic := range result.Diagnostics {
+				require.Contains(t, diagnostic.String(), tc.diagnostics[index])
+			}
+			if tc.status == Failed {
+				require.Error(t, result.Err)
+			} else {
+				require.NoError(t, result.Err)
+			}
+		})
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	source := "inicio varinicio inteiro A; varfim; repita (A = A) A <- A + 1; fimrepita fim"
+	result := Run(context.Background(), source, strings.NewReader(""), &bytes.Buffer{}, Options{Limits: limits.Limits{Steps: 100}})
+	require.Equal(t, Failed, result.Status)
+	require.ErrorIs(t, result.Err, limits.ErrorLimitExceeded)
+}
+
+func TestRunSandbox(t *testing.T) {
+	source := "inicio varinicio literal N; varfim; leia N; escreva N; fim"
+	input := strings.Repeat("a", int(limits.Sandbox.Literal)+1)
+	result := Run(context.Background(), source, strings.NewReader(input), &bytes.Buffer{}, Options{Sandbox: true})
+	require.Equal(t, Failed, result.Status)
+	require.Equal(t, &limits.ExceededError{Resource: limits.Literal, Limit: limits.Sandbox.Literal}, result.Err)
+
+	result = Run(context.Background(), source, strings.NewReader("Ana"), &bytes.Buffer{}, Options{Sandbox: true, Limits: limits.Limits{Literal: 2}})
+	require.Equal(t, &limits.ExceededError{Resource: limits.Literal, Limit: 2}, result.Err)
+}
+
+func TestRunLanguage(t *testing.T) {
+	language, err := lexer.LoadLanguage("mgol-en")
+	require.NoError(t, err)
+	source := "begin varbegin integer A; varend; read A; A <- A * 2; write A; end"
+	var output bytes.Buffer
+	result := Run(context.Background(), source, strings.NewReader("21"), &output, Options{Language: language})
+	require.Equal(t, Succeeded, result.Status)
+	require.Equal(t, "42", output.String())
+
+	result = Run(context.Background(), source, strings.NewReader("21"), &output, Options{})
+	require.Equal(t, Rejected, result.Status)
+}
+
+func TestCompile(t *testing.T) {
+	compiled := Compile("inicio varinicio inteiro A; varfim; A <- 2; escreva A; fim", Options{})
+	require.True(t, compiled.Accepted)
+	require.Empty(t, compiled.Diagnostics)
+	require.Len(t, compiled.Program.Body, 2)
+
+	var output bytes.Buffer
+	result := compiled.Run(context.Background(), strings.NewReader(""), &output, Options{})
+	require.Equal(t, Succeeded, result.Status)
+	require.Equal(t, "2", output.String())
+
+	compiled = Compile("inicio varinicio varfim;\nescreva B;\nfim", Options{})
+	require.False(t, compiled.Accepted)
+	require.Len(t, compiled.Diagnostics, 1)
+	require.Equal(t, Rejected, compiled.Run(context.Background(), strings.NewReader(""), &output, Options{}).Status)
+}
@@ -0,0 +1,353 @@
+// Package mgolc is the compiler of mgol for Go programs that embed
+// it, like an editor plugin or an online judge. It is the one package
+// they should depend on: its types are its own, and stay the same
+// while the lexer, parser and generators below it change.
+//
+//	result, err := mgolc.Compile(ctx, mgolc.Source{Name: "media.mgol", Text: text}, mgolc.Options{Target: "python"})
+//	if err != nil {
+//		return err // the options were wrong, like an unknown target
+//	}
+//	for _, diagnostic := range result.Diagnostics {
+//		fmt.Println(diagnostic)
+//	}
+//	if result.Accepted {
+//		os.Stdout.Write(result.Code)
+//	}
+//
+// A program with errors is not an error of Compile: it is told by
+// the diagnostics, and the result is not Accepted. Lex only reads its
+// tokens, for highlighting it, Parse builds its syntax tree without
+// checking its types, and Run runs it on the sandbox
+package mgolc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/config"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/mgol"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"strings"
+
+	// The targets, registered on the backend package, so
+	// the embedders get them all with this package
+	_ "mgol-go/src/asmgen"
+	_ "mgol-go/src/bytecode"
+	_ "mgol-go/src/gogen"
+	_ "mgol-go/src/jsgen"
+	_ "mgol-go/src/llvmgen"
+	_ "mgol-go/src/pygen"
+	_ "mgol-go/src/wasmgen"
+)
+
+var ErrorUnknownTarget = fmt.Errorf("alvo desconhecido")
+
+// Source is a program to be compiled
+type Source struct {
+	// Name is the one of the file of the program, if any,
+	// put on its diagnostics
+	Name string
+	Text string
+}
+
+// Conversions tells how the implicit conversions between inteiro
+// and real are treated. Its zero value warns about them, like mgol
+// check and the playground do
+type Conversions int
+
+const (
+	// WarnConversions accepts them with a warning
+	WarnConversions Conversions = iota
+	// AllowConversions accepts them silently
+	AllowConversions
+	// RejectConversions takes them as errors
+	RejectConversions
+)
+
+// conversionsOf maps the strictness of the sem package to Conversions
+var conversionsOf = map[sem.Strictness]Conversions{
+	sem.Permissive: AllowConversions,
+	sem.Warn:       WarnConversions,
+	sem.Strict:     RejectConversions,
+}
+
+// ParseConversions returns the Conversions named like the
+// strictness of the flags of mgol: permitir, avisar or proibir
+func ParseConversions(name string) (Conversions, error) {
+	strictness, err := sem.ParseStrictness(name)
+	if err != nil {
+		return WarnConversions, err
+	}
+	return conversionsOf[strictness], nil
+}
+
+// Options tells how a program is compiled. Its zero value checks the
+// program on mgol, warning about the implicit conversions, without
+// generating it
+type Options struct {
+	// Target is the name of the one the code is generated for, one
+	// of Targets, none when empty
+	Target      string
+	Conversions Conversions
+	// Implicit declares the variables on their first assignment
+	Implicit bool
+	// DecimalComma makes the generated code write the reals
+	// with a comma, like 3,140000
+	DecimalComma bool
+	// Language is the dialect the program is written on, the name
+	// of a profile or the path of its file, mgol when empty
+	Language string
+}
+
+// Position is the line and column of a character, from 1
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Token is a token of the program, with the class of the
+// terminals of the grammar, like id, num or se
+type Token struct {
+	Class  string   `json:"class"`
+	Lexeme string   `json:"lexeme"`
+	Type   string   `json:"type"`
+	Start  Position `json:"start"`
+	End    Position `json:"end"`
+}
+
+// Diagnostic is an error or warning found on the program
+type Diagnostic struct {
+	// Severity is either "erro" or "aviso"
+	Severity string   `json:"severity"`
+	Code     string   `json:"code"`
+	File     string   `json:"file,omitempty"`
+	Position Position `json:"position"`
+	Message  string   `json:"message"`
+}
+
+// IsError tells whether the diagnostic keeps the program from being accepted
+func (d Diagnostic) IsError() bool {
+	return d.Severity == string(errorhandling.Error)
+}
+
+func (d Diagnostic) String() string {
+	text := fmt.Sprintf("%s na linha %d coluna %d, %s", d.Severity, d.Position.Line, d.Position.Column, d.Message)
+	if d.File != "" {
+		return d.File + ": " + text
+	}
+	return text
+}
+
+// Result is what compiling a program gave
+type Result struct {
+	// Tokens are all of the program, the comments and the
+	// ones with lexical errors included
+	Tokens []Token `json:"tokens"`
+	// AST is the syntax tree on json, with a node for each construct
+	// that could not be parsed, null when there is none
+	AST         json.RawMessage `json:"ast"`
+	Diagnostics []Diagnostic    `json:"diagnostics"`
+	// Accepted tells whether the program has no errors
+	Accepted bool `json:"accepted"`
+	// Code is the program generated for the target, when Accepted
+	Code []byte `json:"code,omitempty"`
+}
+
+// RunResult is what running a program gave
+type RunResult struct {
+	// Status is sucesso for a program run up to its end, rejeitado
+	// for one with errors, which is not run, and falha for one
+	// stopped while running
+	Status      string       `json:"status"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// Error is the one that stopped a program that failed
+	Error string `json:"error,omitempty"`
+}
+
+// Targets returns the names of the targets, sorted
+func Targets() []string {
+	return backend.Names()
+}
+
+// Compile compiles source with options. It only fails when options
+// are wrong, or ctx is done before the program is compiled
+func Compile(ctx context.Context, source Source, options Options) (Result, error) {
+	var target backend.Backend
+	if options.Target != "" {
+		var found bool
+		if target, found = backend.Lookup(options.Target); !found {
+			return Result{}, fmt.Errorf("%w: %s, os disponíveis são %s", ErrorUnknownTarget, options.Target, strings.Join(Targets(), ", "))
+		}
+		if configurable, found := target.(backend.Configurable); found {
+			target = configurable.WithOptions(backend.Options{DecimalComma: options.DecimalComma})
+		}
+	}
+	language, err := config.LoadLanguage(options.Language)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	compiled := mgol.Compile(source.Text, checkOptions(options, language))
+	tokens, _ := scan(source, language)
+	result := Result{
+		Tokens:      tokens,
+		AST:         json.RawMessage("null"),
+		Diagnostics: convertDiagnostics(source, compiled.Diagnostics),
+		Accepted:    compiled.Accepted,
+	}
+	if compiled.Program != nil {
+		var encoded bytes.Buffer
+		if err := ast.EncodeJSON(&encoded, compiled.Program); err != nil {
+			return Result{}, err
+		}
+		result.AST = encoded.Bytes()
+	}
+
+	if target != nil && compiled.Accepted {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		var code bytes.Buffer
+		if err := target.Generate(compiled.Program, compiled.Info, &code); err != nil {
+			return Result{}, err
+		}
+		result.Code = code.Bytes()
+	}
+	return result, nil
+}
+
+// Lex returns the tokens of source, with the lexical diagnostics.
+// The result is Accepted when none of them is an error
+func Lex(source Source, options Options) (Result, error) {
+	language, err := config.LoadLanguage(options.Language)
+	if err != nil {
+		return Result{}, err
+	}
+	tokens, diagnostics := scan(source, language)
+	result := Result{Tokens: tokens, AST: json.RawMessage("null"), Diagnostics: diagnostics, Accepted: true}
+	for _, diagnostic := range diagnostics {
+		result.Accepted = result.Accepted && !diagnostic.IsError()
+	}
+	return result, nil
+}
+
+// Parse returns the tokens and the syntax tree of source, with
+// the lexical and syntax diagnostics, without checking its types
+func Parse(source Source, options Options) (Result, error) {
+	language, err := config.LoadLanguage(options.Language)
+	if err != nil {
+		return Result{}, err
+	}
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	scanner := lexer.NewStringScanner(source.Text, symbolTable)
+	scanner.SetLanguage(language)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+
+	p := parser.NewRecursiveDescentParser(scanner, parser.DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	p.SetImplicitDeclarations(options.Implicit)
+	parsed := p.Parse()
+	for _, syntaxError := range parsed.Errors {
+		diagnostics.Add(syntaxError.Diagnostic())
+	}
+	for _, ioError := range parsed.IOErrors {
+		diagnostics.Add(ioError.Diagnostic())
+	}
+
+	tokens, _ := scan(source, language)
+	result := Result{
+		Tokens:      tokens,
+		AST:         json.RawMessage("null"),
+		Diagnostics: convertDiagnostics(source, diagnostics.Diagnostics()),
+		Accepted:    parsed.Accepted,
+	}
+	for _, diagnostic := range result.Diagnostics {
+		result.Accepted = result.Accepted && !diagnostic.IsError()
+	}
+	if parsed.Program != nil {
+		var encoded bytes.Buffer
+		if err := ast.EncodeJSON(&encoded, parsed.Program); err != nil {
+			return Result{}, err
+		}
+		result.AST = encoded.Bytes()
+	}
+	return result, nil
+}
+
+// Run checks source and runs it on the interpreter, reading stdin and
+// writing stdout. It is always run on the sandbox of the limits
+// package, as anyone may have written it, like on a playground on
+// the browser. It only fails when options are wrong
+func Run(ctx context.Context, source Source, stdin io.Reader, stdout io.Writer, options Options) (RunResult, error) {
+	language, err := config.LoadLanguage(options.Language)
+	if err != nil {
+		return RunResult{}, err
+	}
+	checked := checkOptions(options, language)
+	checked.DecimalComma = options.DecimalComma
+	checked.Sandbox = true
+	ran := mgol.Run(ctx, source.Text, stdin, stdout, checked)
+	result := RunResult{Status: ran.Status.String(), Diagnostics: convertDiagnostics(source, ran.Diagnostics)}
+	if ran.Err != nil {
+		result.Error = ran.Err.Error()
+	}
+	return result, nil
+}
+
+// checkOptions returns the options of the mgol package for options
+func checkOptions(options Options, language *lexer.Language) mgol.Options {
+	strictness := map[Conversions]sem.Strictness{AllowConversions: sem.Permissive, WarnConversions: sem.Warn, RejectConversions: sem.Strict}[options.Conversions]
+	return mgol.Options{
+		Narrowing: strictness,
+		Promotion: strictness,
+		Implicit:  options.Implicit,
+		Language:  language,
+	}
+}
+
+func convertDiagnostics(source Source, diagnostics []errorhandling.Diagnostic) []Diagnostic {
+	converted := make([]Diagnostic, len(diagnostics))
+	for index, diagnostic := range diagnostics {
+		converted[index] = Diagnostic{string(diagnostic.Severity), diagnostic.Code, source.Name, Position{diagnostic.Line, diagnostic.Column}, diagnostic.Message}
+	}
+	return converted
+}
+
+// scan returns the tokens of source, the comments and the
+// errors included, along with its lexical diagnostics
+func scan(source Source, language *lexer.Language) ([]Token, []Diagnostic) {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	scanner := lexer.NewStringScanner(source.Text, symbolTable)
+	scanner.SetLanguage(language)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+	tokens := []Token{}
+	for {
+		token, line, column := scanner.Scan()
+		if token == lexer.EOF_TOKEN {
+			return tokens, convertDiagnostics(source, diagnostics.Diagnostics())
+		}
+		start := scanner.TokenStart()
+		tokens = append(tokens, Token{
+			Class:  token.GetClass(),
+			Lexeme: token.GetLexem(),
+			Type:   string(token.GetType()),
+			Start:  Position{start.Line, start.Column},
+			End:    Position{line, column},
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package mgolc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	result, err := Compile(context.Background(), Source{Text: "inicio varinicio real B; varfim; B <- 2; escreva B; fim"}, Options{Target: "python"})
+	require.NoError(t, err)
+	require.True(t, result.Accepted)
+	require.Len(t, result.Tokens, 15)
+	require.Equal(t, Token{Class: "inicio", Lexeme: "inicio", Type: "inicio", Start: Position{1, 1}, End: Position{1, 6}}, result.Tokens[0])
+	require.Equal(t, []Diagnostic{{Severity: "aviso", Code: "S005", Position: Position{1, 39}, Message: result.Diagnostics[0].Message}}, result.Diagnostics)
+	require.False(t, result.Diagnostics[0].IsError())
+	require.Contains(t, string(result.Code), "print(")
+
+	var tree map[string]interface{}
+	require.NoError(t, json.Unmarshal(result.AST, &tree))
+	require.NotEmpty(t, tree)
+}
+
+func TestCompileRejected(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		options  Options
+		expected string
+	}{
+		{"semantic error", "inicio varinicio varfim;\nescreva B;\nfim", Options{Target: "python"}, "erros.mgol: erro na linha 2 coluna 9"},
+		{"strict conversions", "inicio varinicio real B; varfim; B <- 2; escreva B; fim", Options{Conversions: RejectConversions}, "erros.mgol: erro na linha 1 coluna 39"},
+		{"dialect", "inicio varinicio varfim; fim", Options{Language: "mgol-en"}, "erros.mgol: erro na linha"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Compile(context.Background(), Source{Name: "erros.mgol", Text: tc.text}, tc.options)
+			require.NoError(t, err)
+			require.False(t, result.Accepted)
+			require.Empty(t, result.Code)
+			require.NotEmpty(t, result.Diagnostics)
+			require.True(t, result.Diagnostics[0].IsError())
+			require.Contains(t, result.Diagnostics[0].String(), tc.expected)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	source := Source{Text: "inicio varinicio varfim; fim"}
+	_, err := Compile(context.Background(), source, Options{Target: "cobol"})
+	require.ErrorIs(t, err, ErrorUnknownTarget)
+
+	_, err = Compile(context.Background(), source, Options{Language: "klingon"})
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Compile(ctx, source, Options{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLex(t *testing.T) {
+	result, err := Lex(Source{Text: "begin A <- 1.e;"}, Options{Language: "mgol-en"})
+	require.NoError(t, err)
+	require.False(t, result.Accepted)
+	require.Equal(t, []string{"inicio", "id", "rcb", "erro", "id", "pt_v"}, classes(result.Tokens))
+	require.Len(t, result.Diagnostics, 1)
+	require.Equal(t, Position{1, 14}, result.Diagnostics[0].Position)
+}
+
+func TestParse(t *testing.T) {
+	// Parsing leaves the undeclared variable to the checker
+	result, err := Parse(Source{Text: "inicio varinicio varfim; escreva B; fim"}, Options{})
+	require.NoError(t, err)
+	require.True(t, result.Accepted)
+	require.Empty(t, result.Diagnostics)
+	require.Contains(t, string(result.AST), `"B"`)
+
+	result, err = Parse(Source{Text: "inicio varinicio varfim; escreva ; fim"}, Options{})
+	require.NoError(t, err)
+	require.False(t, result.Accepted)
+	require.Len(t, result.Diagnostics, 1)
+}
+
+func TestParseConversions(t *testing.T) {
+	conversions, err := ParseConversions("proibir")
+	require.NoError(t, err)
+	require.Equal(t, RejectConversions, conversions)
+	_, err = ParseConversions("talvez")
+	require.Error(t, err)
+}
+
+func classes(tokens []Token) []string {
+	names := make([]string, len(tokens))
+	for index, token := range tokens {
+		names[index] = token.Class
+	}
+	return names
+}
+
+func TestRun(t *testing.T) {
+	var output bytes.Buffer
+	source := Source{Text: "inicio varinicio inteiro A; varfim; leia A; escreva A * 2; fim"}
+	result, err := Run(context.Background(), source, strings.NewReader("21"), &output, Options{})
+	require.NoError(t, err)
+	require.Equal(t, RunResult{Status: "sucesso", Diagnostics: []Diagnostic{}}, result)
+	require.Equal(t, "42", output.String())
+
+	source = Source{Text: "inicio varinicio inteiro A; varfim; A <- 1; repita (A > 0) A <- A + 1; fimrepita fim"}
+	result, err = Run(context.Background(), source, strings.NewReader(""), &output, Options{})
+	require.NoError(t, err)
+	require.Equal(t, "falha", result.Status)
+	require.NotEmpty(t, result.Error)
+}
@@ -37,6 +37,13 @@ func NewActionReader(path string) *ActionReader {
 		panic(err)
 	}
 
+	return NewActionReaderFromRecords(records)
+}
+
+// NewActionReaderFromRecords creates an ActionReader from rows
+// of cells, the first being the header, like the tables
+// written as Go source by cmd/gentable
+func NewActionReaderFromRecords(records [][]string) *ActionReader {
 	ac := &ActionReader{}
 	ac.indexes = make(map[string]int)
 	for idx, record := range records[0] {
@@ -46,17 +53,41 @@ func NewActionReader(path string) *ActionReader {
 	return ac
 }
 
-func (a *ActionReader) GetAction(state lexer.State, token lexer.Token) (Action, int) {
-	var class string
+// multiplicativeOperators are the arithmetic operators that bind
+// tighter than + and -. The scanner reads every arithmetic operator
+// as opm, so the parser tells them apart to honor the precedence
+var multiplicativeOperators = map[string]bool{
+	"*": true,
+	"/": true,
+}
+
+// tokenSymbol returns the terminal of the grammar
+// that matches the token read by the scanner
+func tokenSymbol(token lexer.Token) string {
 	if token == lexer.EOF_TOKEN {
-		class = "$"
-	} else {
-		class = token.GetClass()
+		return "$"
+	}
+	class := token.GetClass()
+	if class == "opm" && multiplicativeOperators[token.GetLexem()] {
+		return "opmul"
+	}
+	return class
+}
+
+func (a *ActionReader) GetAction(state lexer.State, token lexer.Token) (Action, int) {
+	class := tokenSymbol(token)
+	column, found := a.indexes[class]
+	if !found && class == "opmul" {
+		// Grammars without precedence use opm for every operator
+		column, found = a.indexes["opm"]
+	}
+	if !found {
+		return ERROR, 0
 	}
 
 	//We need to sum to sum one to access line n because we want to
 	//eliminate the header itself
-	value := []byte(a.records[state+1][a.indexes[class]])
+	value := []byte(a.records[state+1][column])
 
 	if len(value) == 0 {
 		return ERROR, 0
@@ -89,3 +120,18 @@ func (a *ActionReader) GetAction(state lexer.State, token lexer.Token) (Action,
 
 	return -1, -1
 }
+
+// ExpectedTokens returns the token classes that have an action
+// other than an error on state, in the order of the table header
+func (a *ActionReader) ExpectedTokens(state lexer.State) []string {
+	expected := []string{}
+	//We need to sum to sum one to access line n because we want to
+	//eliminate the header itself
+	for idx, value := range a.records[state+1] {
+		if idx == 0 || len(value) == 0 || value[0] == 'e' {
+			continue
+		}
+		expected = append(expected, a.records[0][idx])
+	}
+	return expected
+}
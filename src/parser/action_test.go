@@ -35,7 +35,7 @@ func TestGetAction(t *testing.T) {
 		},
 		{
 			name:            "Get reduce",
-			state:           23,
+			state:           25,
 			tokenClass:      lexer.IDENTIFIER,
 			expectedAction:  REDUCE,
 			expectedOperand: 8,
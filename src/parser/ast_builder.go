@@ -0,0 +1,349 @@
+package parser
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+)
+
+// astItem is an element of the builder stack: a shifted
+// token or the value built by a previous reduction
+type astItem struct {
+	span  ast.Span
+	value interface{}
+}
+
+// astBuilder mirrors the parser stack, building
+// the tree bottom-up as the rules are reduced
+type astBuilder struct {
+	items []astItem
+	// failed is set when the builder stack can not
+	// be kept matched to the parser stack
+	failed bool
+	// ioValidators check the leia and escreva statements
+	// built, and ioErrors holds the errors they returned
+	ioValidators []IOValidator
+	ioErrors     []IOError
+	// arena allocates the nodes, each one is
+	// allocated on its own when it is nil
+	arena *ast.Arena
+}
+
+func newASTBuilder() *astBuilder {
+	return &astBuilder{ioValidators: []IOValidator{ValidateIOArgument}}
+}
+
+var typeTokens = map[string]lexer.DataType{
+	"inteiro": lexer.INTEGER,
+	"real":    lexer.REAL,
+	"literal": lexer.LITERAL,
+}
+
+var astRules = map[int]func(arena *ast.Arena, span ast.Span, items []astItem) interface{}{
+	// P -> inicio V A
+	1: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return &ast.Program{
+			Span:         span,
+			Declarations: items[1].value.([]*ast.VarDecl),
+			Body:         items[2].value.([]ast.Stmt),
+		}
+	},
+	// V -> varinicio LV
+	2: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return items[1].value
+	},
+	// LV -> D LV
+	3: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return append([]*ast.VarDecl{items[0].value.(*ast.VarDecl)}, items[1].value.([]*ast.VarDecl)...)
+	},
+	// LV -> varfim pt_v
+	4: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return []*ast.VarDecl{}
+	},
+	// D -> TIPO L pt_v
+	5: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return arena.NewVarDecl(ast.VarDecl{
+			Span: span,
+			Type: items[0].value.(lexer.DataType),
+			Name: items[1].value.(*ast.Ident),
+		})
+	},
+	// L -> id
+	6: identFromToken,
+	// TIPO -> inteiro | real | literal
+	7: dataTypeFromToken,
+	8: dataTypeFromToken,
+	9: dataTypeFromToken,
+	// A -> ES A
+	10: prependStmt,
+	// ES -> leia id pt_v
+	11: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return arena.NewRead(ast.Read{Span: span, Target: identFromToken(arena, items[1].span, items[1:2]).(*ast.Ident)})
+	},
+	// ES -> escreva ARG pt_v
+	12: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return arena.NewWrite(ast.Write{Span: span, Value: items[1].value.(ast.Expr)})
+	},
+	// ARG -> lit
+	13: literalFromToken,
+	// ARG -> num
+	14: literalFromToken,
+	// ARG -> id
+	15: identFromToken,
+	// A -> CMD A
+	16: prependStmt,
+	// CMD -> id rcb LD pt_v
+	17: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return arena.NewAssign(ast.Assign{
+			Span:   span,
+			Target: identFromToken(arena, items[0].span, items[0:1]).(*ast.Ident),
+			Value:  items[2].value.(ast.Expr),
+		})
+	},
+	// LD -> LD opm TERMO
+	18: binaryExprFromItems,
+	// LD -> TERMO
+	19: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return items[0].value
+	},
+	// OPRD -> id
+	20: identFromToken,
+	// OPRD -> num
+	21: literalFromToken,
+	// A -> COND A
+	22: prependStmt,
+	// COND -> CAB CP
+	23: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		blocks := items[1].value.(conditionalBlocks)
+		return arena.NewIf(ast.If{Span: span, Condition: items[0].value.(ast.Expr), Body: blocks.body, Else: blocks.otherwise})
+	},
+	// CAB -> se ab_p EXP_R fc_p entao
+	24: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return items[2].value
+	},
+	// EXP_R -> LD opr LD
+	25: binaryExprFromItems,
+	// CP -> ES CP | CMD CP | COND CP
+	26: prependToBody,
+	27: prependToBody,
+	28: prependToBody,
+	// CP -> fimse
+	29: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return conditionalBlocks{body: []ast.Stmt{}}
+	},
+	// A -> R A
+	30: prependStmt,
+	// R -> CABR CPR
+	31: whileFromItems,
+	// CABR -> repita ab_p EXP_R fc_p
+	32: loopCondition,
+	// CPR -> ES CPR | CMD CPR | COND CPR
+	33: prependStmt,
+	34: prependStmt,
+	35: prependStmt,
+	// CPR -> fimrepita
+	36: emptyStmtList,
+	// A -> fim
+	37: emptyStmtList,
+	// TERMO -> TERMO opmul OPRD
+	38: binaryExprFromItems,
+	// TERMO -> OPRD
+	39: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return items[0].value
+	},
+	// OPRD -> ab_p LD fc_p
+	40: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return widenSpan(items[1].value.(ast.Expr), span)
+	},
+	// CP -> senao CPE
+	41: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return conditionalBlocks{body: []ast.Stmt{}, otherwise: items[1].value.([]ast.Stmt)}
+	},
+	// CPE -> ES CPE | CMD CPE | COND CPE
+	42: prependStmt,
+	43: prependStmt,
+	44: prependStmt,
+	// CPE -> fimse
+	45: emptyStmtList,
+	// CP -> R CP
+	46: prependToBody,
+	// CPR -> R CPR | CPE -> R CPE
+	47: prependStmt,
+	48: prependStmt,
+	// ARG -> LD
+	49: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		return items[0].value
+	},
+	// R -> CABW CPW
+	50: func(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+		loop := whileFromItems(arena, span, items).(*ast.While)
+		loop.Enquanto = true
+		return loop
+	},
+	// CABW -> enquanto ab_p EXP_R fc_p
+	51: loopCondition,
+	// CPW -> ES CPW | CMD CPW | COND CPW | R CPW
+	52: prependStmt,
+	53: prependStmt,
+	54: prependStmt,
+	55: prependStmt,
+	// CPW -> fimenquanto
+	56: emptyStmtList,
+}
+
+// conditionalBlocks is the value of CP: the statements run when the
+// condition holds and, if there is a senao, the ones run otherwise
+type conditionalBlocks struct {
+	body      []ast.Stmt
+	otherwise []ast.Stmt
+}
+
+func identFromToken(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return arena.NewIdent(ast.Ident{Span: span, Name: items[0].value.(lexer.Token).GetLexem()})
+}
+
+func literalFromToken(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	token := items[0].value.(lexer.Token)
+	return arena.NewLiteral(ast.Literal{Span: span, Value: token.GetLexem(), Type: token.GetType()})
+}
+
+func dataTypeFromToken(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return typeTokens[items[0].value.(lexer.Token).GetClass()]
+}
+
+func binaryExprFromItems(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return arena.NewBinaryExpr(ast.BinaryExpr{
+		Span:     span,
+		Operator: items[1].value.(lexer.Token).GetLexem(),
+		Left:     items[0].value.(ast.Expr),
+		Right:    items[2].value.(ast.Expr),
+	})
+}
+
+// whileFromItems builds a loop, repita or enquanto, from its
+// condition and body
+func whileFromItems(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return arena.NewWhile(ast.While{Span: span, Condition: items[0].value.(ast.Expr), Body: items[1].value.([]ast.Stmt)})
+}
+
+// loopCondition is the value of the header of a loop
+func loopCondition(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return items[2].value
+}
+
+func prependStmt(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return append([]ast.Stmt{items[0].value.(ast.Stmt)}, items[1].value.([]ast.Stmt)...)
+}
+
+func prependToBody(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	blocks := items[1].value.(conditionalBlocks)
+	blocks.body = append([]ast.Stmt{items[0].value.(ast.Stmt)}, blocks.body...)
+	return blocks
+}
+
+// widenSpan makes the span of expr cover the parentheses around it
+func widenSpan(expr ast.Expr, span ast.Span) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		e.Span = span
+	case *ast.Literal:
+		e.Span = span
+	case *ast.Ident:
+		e.Span = span
+	}
+	return expr
+}
+
+func emptyStmtList(arena *ast.Arena, span ast.Span, items []astItem) interface{} {
+	return []ast.Stmt{}
+}
+
+// shift pushes a token read from start to end
+func (b *astBuilder) shift(token lexer.Token, start, end lexer.Position) {
+	if b.failed {
+		return
+	}
+	b.items = append(b.items, astItem{span: ast.Span{Start: start, End: end}, value: token})
+}
+
+// reduce replaces the items of the right side
+// of the rule by the value it builds
+func (b *astBuilder) reduce(rule Rule) {
+	if b.failed {
+		return
+	}
+
+	build, found := astRules[rule.Number]
+	size := len(rule.Right)
+	if !found || size == 0 || size > len(b.items) {
+		b.fail()
+		return
+	}
+	// The recovery from a syntax error may leave on the stack
+	// items of the wrong kind, in which case the tree is dropped
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			b.fail()
+		}
+	}()
+
+	items := b.items[len(b.items)-size:]
+	span := ast.Span{Start: items[0].span.Start, End: items[size-1].span.End}
+	value := build(b.arena, span, items)
+	b.validateIO(value)
+	b.items = append(b.items[:len(b.items)-size], astItem{span: span, value: value})
+}
+
+// replace discards the items pushed after the first height ones,
+// the part of a construct parsed before a syntax error, pushing
+// items in their place
+func (b *astBuilder) replace(height int, items ...astItem) {
+	if b.failed || height > len(b.items) {
+		return
+	}
+	b.items = append(b.items[:height], items...)
+}
+
+// reduceMissing pushes the value of rule, whose symbols are missing
+// from the source, like the fimse of a conditional left open at
+// the end of the file. The value is built without any item
+func (b *astBuilder) reduceMissing(rule Rule, at lexer.Position) {
+	if b.failed {
+		return
+	}
+	build, found := astRules[rule.Number]
+	if !found {
+		b.fail()
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			b.fail()
+		}
+	}()
+
+	span := ast.Span{Start: at, End: at}
+	b.items = append(b.items, astItem{span: span, value: build(b.arena, span, nil)})
+}
+
+// height returns how many items the builder stack has
+func (b *astBuilder) height() int {
+	return len(b.items)
+}
+
+// fail discards the tree, for when the builder stack can no longer
+// be matched to the parser one, like on a grammar other than the
+// one the rules of the tree were written for
+func (b *astBuilder) fail() {
+	b.failed = true
+	b.items = nil
+}
+
+// program returns the built tree, partial when the source has
+// syntax errors, or nil if it could not be built
+func (b *astBuilder) program() *ast.Program {
+	if b.failed || len(b.items) != 1 {
+		return nil
+	}
+	program, _ := b.items[0].value.(*ast.Program)
+	return program
+}
@@ -0,0 +1,874 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+)
+
+// Analyzer is what the parsers of mgol have in common
+type Analyzer interface {
+	// Parse analyzes the tokens of the scanner
+	Parse() *ParseResult
+	// GenerateCode writes the code produced by the semantic actions
+	// on programa.c. It should only be called after a successful parse
+	GenerateCode()
+	// WriteCode writes that code on w instead, which may be
+	// a file, the standard output or a buffer in memory
+	WriteCode(w io.Writer) error
+	// AddIOValidator makes the parser run validator
+	// on each leia and escreva statement it parses
+	AddIOValidator(validator IOValidator)
+	// AddSource makes the parser read the tokens of scanner
+	// after the ones of the scanners it has
+	AddSource(scanner *lexer.Scanner) error
+	// SetErrorLimit makes the parser stop after
+	// reporting limit syntax errors, 0 meaning no limit
+	SetErrorLimit(limit int)
+	// SetMaxNesting makes the parser stop on parentheses or
+	// blocks nested deeper than limit, 0 meaning no limit
+	SetMaxNesting(limit int)
+	// UseArena makes the parser allocate the nodes of the
+	// syntax tree on arena, nil meaning each one on its own
+	UseArena(arena *ast.Arena)
+	// SetConcreteSyntax makes the leaves of the parse tree keep
+	// the source of their tokens, comments and whitespace included
+	SetConcreteSyntax(enabled bool)
+	// SetImplicitDeclarations makes the first assignment to
+	// an undeclared variable declare it with the type of
+	// the value, instead of being an error
+	SetImplicitDeclarations(enabled bool)
+	// SetSourceComments makes the generated code have each
+	// statement of the source as a comment before its code
+	SetSourceComments(enabled bool)
+	// SetDecimalComma makes the generated code write
+	// the reals with a comma as the decimal separator
+	SetDecimalComma(enabled bool)
+	// EncodeSourceMap writes as json where the code of each statement
+	// starts on the generated code, after a successful parse
+	EncodeSourceMap(w io.Writer) error
+	// SetTranslation makes the parser run the semantic
+	// rules of scheme on each reduction
+	SetTranslation(scheme *TranslationScheme)
+	// SetEventHandler makes the parser stream the parts of the
+	// program to handler instead of building the trees
+	SetEventHandler(handler EventHandler)
+	// SetQuietReductions keeps the reductions off the standard
+	// output, for when it is the one of the program being run
+	SetQuietReductions(enabled bool)
+}
+
+var (
+	_ Analyzer = (*Parser)(nil)
+	_ Analyzer = (*RecursiveDescentParser)(nil)
+)
+
+// blockRules holds the rules of a list of statements, like A or CP:
+// the ones that prepend each kind of statement to the list, the one
+// that ends it with end and, for CP, the one that goes on with senao
+type blockRules struct {
+	read, command, conditional, loop int
+	end                              string
+	endRule                          int
+	elseRule                         int
+}
+
+var (
+	programBlock     = blockRules{10, 16, 22, 30, "fim", 37, 0}
+	conditionalBlock = blockRules{26, 27, 28, 46, "fimse", 29, 41}
+	loopBlock        = blockRules{33, 34, 35, 47, "fimrepita", 36, 0}
+	whileBlock       = blockRules{52, 53, 54, 55, "fimenquanto", 56, 0}
+	elseBlock        = blockRules{42, 43, 44, 48, "fimse", 45, 0}
+)
+
+// statementStarts are the tokens a statement starts with
+var statementStarts = map[string]bool{
+	"leia":     true,
+	"escreva":  true,
+	"id":       true,
+	"se":       true,
+	"repita":   true,
+	"enquanto": true,
+}
+
+// RecursiveDescentParser parses the grammar of grammar.json with a
+// function for each non terminal. It reduces the rules in the same
+// order of the SLR parser, so both give the same semantic actions,
+// syntax tree and parse tree, but its error messages tell what was
+// being parsed when the error was found
+type RecursiveDescentParser struct {
+	tokens      *lexer.TokenStream
+	rules       *RulesMap
+	semantic    *Semantic
+	builder     *astBuilder
+	treeBuilder *parseTreeBuilder
+	events      eventEmitter
+	translator  translator
+	runSemantic bool
+	result      *ParseResult
+
+	// token is the lookahead, the token being parsed
+	token lexer.ScannedToken
+	// statementStart is where the construct being parsed starts
+	// and previousEnd where the last token consumed ends
+	statementStart lexer.Position
+	previousEnd    lexer.Position
+	// openEnds holds the tokens that end the blocks being parsed
+	openEnds []string
+	// quiet keeps the reductions and the errors off the output,
+	// and quietReductions only the reductions
+	quiet           bool
+	quietReductions bool
+	// errorLimit is how many syntax errors the parser reports
+	// before giving up, and skippedTokens how many tokens it
+	// discarded to recover from them
+	errorLimit    int
+	skippedTokens int
+	// nesting is how many parentheses and blocks are open
+	// and maxNesting how many of them can be open at once
+	nesting    int
+	maxNesting int
+	// source keeps the source text on the parse tree
+	source sourceTracker
+}
+
+func NewRecursiveDescentParser(scanner *lexer.Scanner, rules *RulesMap) *RecursiveDescentParser {
+	return newRecursiveDescentParser(lexer.NewTokenStream(scanner), scanner.GetSymbolTable(), rules)
+}
+
+// NewSourceParser returns a parser of the tokens of sources, read one
+// after another as a single program. The identifiers they read must
+// be inserted on symbolTable, like a PrefetchSource of a scanner on
+// it does
+func NewSourceParser(symbolTable *lexer.SymbolTable, rules *RulesMap, sources ...lexer.TokenSource) *RecursiveDescentParser {
+	return newRecursiveDescentParser(lexer.NewSourceStream(sources...), symbolTable, rules)
+}
+
+func newRecursiveDescentParser(tokens *lexer.TokenStream, symbolTable *lexer.SymbolTable, rules *RulesMap) *RecursiveDescentParser {
+	return &RecursiveDescentParser{
+		tokens:      tokens,
+		rules:       rules,
+		semantic:    NewSemantic(symbolTable),
+		builder:     newASTBuilder(),
+		treeBuilder: &parseTreeBuilder{},
+		runSemantic: true,
+		maxNesting:  DefaultMaxNesting,
+	}
+}
+
+// AddIOValidator makes the parser run validator
+// on each leia and escreva statement it parses
+func (p *RecursiveDescentParser) AddIOValidator(validator IOValidator) {
+	p.builder.ioValidators = append(p.builder.ioValidators, validator)
+}
+
+// AddSource makes the parser read the tokens of scanner after the
+// ones of the scanners it has, for programs split among several
+// files. The scanners must share the symbol table
+func (p *RecursiveDescentParser) AddSource(scanner *lexer.Scanner) error {
+	if scanner.GetSymbolTable() != p.semantic.symbolTable {
+		return ErrorSeparateSymbolTable
+	}
+	p.tokens.AddScanner(scanner)
+	return nil
+}
+
+// SetErrorLimit makes the parser stop after reporting limit
+// syntax errors. A limit of 0, the default, means no limit
+func (p *RecursiveDescentParser) SetErrorLimit(limit int) {
+	p.errorLimit = limit
+}
+
+// SetMaxNesting makes the parser stop with an error on parentheses
+// or blocks nested deeper than limit, so that a malicious source
+// can not make it exhaust the stack. A limit of 0 means no limit
+func (p *RecursiveDescentParser) SetMaxNesting(limit int) {
+	p.maxNesting = limit
+}
+
+// UseArena makes the parser allocate the nodes of the syntax tree
+// on arena, which is faster for large programs. By default, or with
+// a nil arena, each node is allocated on its own
+func (p *RecursiveDescentParser) UseArena(arena *ast.Arena) {
+	p.builder.arena = arena
+}
+
+// SetTranslation makes the parser run the semantic rules of scheme
+// on each reduction, along with its own semantic actions. The
+// attribute of the start symbol is on ParseResult.Translation
+func (p *RecursiveDescentParser) SetTranslation(scheme *TranslationScheme) {
+	p.translator.scheme = scheme
+}
+
+// SetImplicitDeclarations makes the first assignment to an undeclared
+// variable declare it with the type of the value. The semantic
+// actions then write its declaration before the code
+func (p *RecursiveDescentParser) SetImplicitDeclarations(enabled bool) {
+	p.semantic.SetImplicitDeclarations(enabled)
+}
+
+// SetDecimalComma makes the generated code write
+// the reals with a comma as the decimal separator
+func (p *RecursiveDescentParser) SetDecimalComma(enabled bool) {
+	p.semantic.SetDecimalComma(enabled)
+}
+
+// SetQuietReductions keeps the reductions off the standard output
+func (p *RecursiveDescentParser) SetQuietReductions(enabled bool) {
+	p.quietReductions = enabled
+}
+
+// SetQuiet keeps the reductions and the syntax errors off the
+// output, for drivers that show the errors of the result themselves
+func (p *RecursiveDescentParser) SetQuiet(enabled bool) {
+	p.quiet = enabled
+}
+
+// SetSemanticActions turns the semantic actions on or off. Without
+// them there is no C program and the type errors are left to the
+// sem package, but the syntax tree is built all the same
+func (p *RecursiveDescentParser) SetSemanticActions(enabled bool) {
+	p.runSemantic = enabled
+}
+
+// SetSourceComments makes the generated code have each
+// statement of the source as a comment before its code
+func (p *RecursiveDescentParser) SetSourceComments(enabled bool) {
+	p.semantic.SetSourceComments(enabled)
+}
+
+// EncodeSourceMap writes as json where the code of each
+// statement starts on the generated code
+func (p *RecursiveDescentParser) EncodeSourceMap(w io.Writer) error {
+	return p.semantic.EncodeSourceMap(w)
+}
+
+// SetEventHandler makes the parser stream the parts of the program
+// to handler as it finds them. The trees are not built then, so
+// Program and ParseTree are nil and the I/O validators do not run
+func (p *RecursiveDescentParser) SetEventHandler(handler EventHandler) {
+	p.events.handler = handler
+	p.builder.fail()
+	p.treeBuilder.fail()
+}
+
+func (p *RecursiveDescentParser) Parse() *ParseResult {
+	p.result = &ParseResult{}
+	p.result.Accepted = p.parseSource()
+	p.result.finishRecovery(!p.result.Accepted, p.skippedTokens)
+
+	errorhandling.FlushDiagnostics()
+	p.result.SemanticErrorFound = p.semantic.ErrorFound()
+	p.result.Program = p.builder.program()
+	p.result.ParseTree = p.treeBuilder.root()
+	if p.result.ParseTree != nil {
+		p.result.ParseTree.Trailing = p.source.trailing()
+	}
+	if len(p.result.Errors) == 0 {
+		p.result.Translation = p.translator.result()
+	}
+	p.result.IOErrors = p.builder.ioErrors
+	for _, ioError := range p.result.IOErrors {
+		if !p.quiet {
+			log.Print(ioError)
+		}
+	}
+	return p.result
+}
+
+// parseSource parses the whole source, returning false when the
+// parser gave up on reaching the error limit or the nesting one
+func (p *RecursiveDescentParser) parseSource() (finished bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered != ErrorLimitReached && recovered != ErrorNestingTooDeep {
+				panic(recovered)
+			}
+			if recovered == ErrorLimitReached && !p.quiet {
+				log.Print(ErrorLimitReached)
+			}
+			finished = false
+		}
+	}()
+
+	p.next()
+	p.program()
+	if p.token.Token != lexer.EOF_TOKEN {
+		p.fail("conteúdo após o fim do programa", "$")
+		for p.token.Token != lexer.EOF_TOKEN {
+			p.skip()
+		}
+	}
+	return true
+}
+
+// GenerateCode writes the code produced by the semantic
+// actions. It should only be called after a successful parse
+func (p *RecursiveDescentParser) GenerateCode() {
+	p.semantic.GenerateCode()
+}
+
+// WriteCode writes the code produced by the semantic actions on w
+func (p *RecursiveDescentParser) WriteCode(w io.Writer) error {
+	return p.semantic.WriteCode(w)
+}
+
+// next reads the token after the current one
+func (p *RecursiveDescentParser) next() {
+	p.token = p.tokens.Next()
+}
+
+func (p *RecursiveDescentParser) symbol() string {
+	return tokenSymbol(p.token.Token)
+}
+
+// shift consumes the current token
+func (p *RecursiveDescentParser) shift() {
+	if p.runSemantic {
+		p.semantic.Shift(p.token.Token, p.token.Start)
+	}
+	p.builder.shift(p.token.Token, p.token.Start, p.token.End)
+	p.events.shift(p.token.Token, p.token.Start, p.token.End)
+	leading, text := p.source.token(p.token)
+	p.treeBuilder.shift(p.token.Token, leading, text)
+	p.translator.shift(p.token.Token, p.token.Start, p.token.End)
+	p.previousEnd = p.token.End
+	p.next()
+}
+
+// skip discards the current token after an error
+func (p *RecursiveDescentParser) skip() {
+	errors := p.result.Errors
+	errors[len(errors)-1].Span.End = p.token.End
+	p.skippedTokens++
+	p.previousEnd = p.token.End
+	p.next()
+}
+
+// reduce records that the rule numbered number was recognized
+func (p *RecursiveDescentParser) reduce(number int) {
+	rule := p.recordReduction(number)
+	p.builder.reduce(rule)
+	p.treeBuilder.reduce(rule)
+	p.events.reduce(rule)
+	p.translator.reduce(rule)
+}
+
+// reduceMalformed is reduce for a construct with a syntax error,
+// which starts after the first height items of the syntax tree
+// builder. Its items are replaced by the ones given
+func (p *RecursiveDescentParser) reduceMalformed(number, height int, items ...astItem) {
+	rule := p.recordReduction(number)
+	p.builder.replace(height, items...)
+	p.treeBuilder.reduce(rule)
+	p.events.reduce(rule)
+	p.translator.reduce(rule)
+}
+
+// reduceMissing is reduce for a rule whose symbols are missing
+func (p *RecursiveDescentParser) reduceMissing(number int) {
+	rule := p.recordReduction(number)
+	p.builder.reduceMissing(rule, p.previousEnd)
+	p.treeBuilder.reduce(rule)
+	p.events.reduce(rule)
+	p.translator.reduce(rule)
+}
+
+// recordReduction prints the rule numbered number, adds
+// it to the result and runs its semantic action
+func (p *RecursiveDescentParser) recordReduction(number int) Rule {
+	rule := p.rules.GetRule(number)
+	if !p.quiet && !p.quietReductions {
+		fmt.Printf("%s -> %s\n", rule.Left, rule.Right)
+	}
+	p.result.Reductions = append(p.result.Reductions, rule)
+	errorhandling.FlushDiagnostics()
+	if p.runSemantic {
+		p.semantic.ExecuteRule(rule, p.token.End.Line, p.token.End.Column)
+	}
+	return rule
+}
+
+// placeholder pushes an item for a token missing from the
+// source, so that the syntax tree can still be built
+func (p *RecursiveDescentParser) placeholder() {
+	at := ast.Span{Start: p.token.Start, End: p.token.Start}
+	p.builder.replace(p.builder.height(), astItem{span: at})
+}
+
+// fail reports a syntax error on the current token. The semantic
+// actions and the parse tree can not go on after it, and the
+// syntax tree goes on with nodes marking the malformed constructs
+func (p *RecursiveDescentParser) fail(message string, expected ...string) {
+	errorhandling.FlushDiagnostics()
+	start := p.token.Start
+	if p.statementStart.Before(start) {
+		start = p.statementStart
+	}
+	syntaxError := SyntaxError{
+		Line:     p.token.End.Line,
+		Column:   p.token.End.Column,
+		Span:     ast.Span{Start: start, End: p.token.End},
+		Token:    p.token.Token,
+		Message:  message,
+		Expected: expected,
+	}
+	if !p.quiet {
+		log.Print(syntaxError)
+	}
+	p.result.Errors = append(p.result.Errors, syntaxError)
+	p.events.fail(syntaxError)
+	if p.errorLimit > 0 && len(p.result.Errors) >= p.errorLimit {
+		// Unwinds every function being run, up to parseSource
+		panic(ErrorLimitReached)
+	}
+	p.treeBuilder.fail()
+	p.translator.fail()
+	p.runSemantic = false
+}
+
+// enter opens a level of nesting, stopping the parser when it
+// goes above the limit. Each call must be paired with one to leave
+func (p *RecursiveDescentParser) enter() {
+	p.nesting++
+	if p.maxNesting > 0 && p.nesting > p.maxNesting {
+		p.fail(nestingMessage(p.maxNesting))
+		// Unwinds every function being run, up to parseSource
+		panic(ErrorNestingTooDeep)
+	}
+}
+
+// leave closes the level of nesting opened by the last call to enter
+func (p *RecursiveDescentParser) leave() {
+	p.nesting--
+}
+
+// expect consumes the current token if it is of class,
+// reporting message otherwise
+func (p *RecursiveDescentParser) expect(class, message string) bool {
+	if p.symbol() != class {
+		p.fail(message, class)
+		return false
+	}
+	p.shift()
+	return true
+}
+
+// P -> inicio V A
+func (p *RecursiveDescentParser) program() {
+	p.statementStart = p.token.Start
+	if p.symbol() == "inicio" {
+		p.shift()
+	} else {
+		p.fail("programa sem inicio", "inicio")
+		p.placeholder()
+	}
+	p.declarations()
+	p.block(programBlock, "programa sem fim")
+	p.reduce(1)
+}
+
+// V -> varinicio LV
+// LV -> D LV | varfim pt_v
+func (p *RecursiveDescentParser) declarations() {
+	p.statementStart = p.token.Start
+	if p.symbol() == "varinicio" {
+		p.shift()
+	} else {
+		p.fail("declaração de variáveis sem varinicio", "varinicio")
+		p.placeholder()
+	}
+
+	declared := 0
+	ended := false
+	height := p.builder.height()
+	for {
+		p.statementStart = p.token.Start
+		height = p.builder.height()
+		switch symbol := p.symbol(); {
+		case symbol == "inteiro", symbol == "real", symbol == "literal":
+			if p.declaration() {
+				declared++
+			}
+			continue
+		case symbol == "varfim":
+			p.shift()
+			ended = p.expect("pt_v", "falta ';' após varfim")
+		case statementStarts[symbol], symbol == "fim", p.token.Token == lexer.EOF_TOKEN:
+			p.fail("declaração de variáveis sem varfim", "varfim")
+		default:
+			p.fail("declaração de variáveis mal formada", "inteiro", "real", "literal", "varfim")
+			p.skip()
+			p.synchronize()
+			continue
+		}
+		break
+	}
+
+	if ended {
+		p.reduce(4)
+	} else {
+		end := ast.Span{Start: p.previousEnd, End: p.previousEnd}
+		p.reduceMalformed(4, height, astItem{span: end, value: []*ast.VarDecl{}})
+	}
+	for ; declared > 0; declared-- {
+		p.reduce(3)
+	}
+	p.reduce(2)
+}
+
+// D -> TIPO L pt_v
+// A malformed declaration is left out of the syntax tree
+func (p *RecursiveDescentParser) declaration() bool {
+	height := p.builder.height()
+	typeRules := map[string]int{"inteiro": 7, "real": 8, "literal": 9}
+	p.reduceAfterShift(typeRules[p.symbol()])
+	if !p.expect("id", "declaração sem o nome da variável") {
+		p.synchronize()
+		p.builder.replace(height)
+		return false
+	}
+	p.reduce(6)
+	if !p.expect("pt_v", "falta ';' ao fim da declaração") {
+		p.synchronize()
+		p.builder.replace(height)
+		return false
+	}
+	p.reduce(5)
+	return true
+}
+
+// reduceAfterShift consumes the current token,
+// the only symbol of the rule numbered number
+func (p *RecursiveDescentParser) reduceAfterShift(number int) {
+	p.shift()
+	p.reduce(number)
+}
+
+// block parses the statements of a list like A or CP until the token
+// that ends it, reducing the rules of the list from its end
+func (p *RecursiveDescentParser) block(rules blockRules, missingEnd string) {
+	opened := len(p.openEnds)
+	p.openEnds = append(p.openEnds, rules.end)
+	if rules.elseRule != 0 {
+		p.openEnds = append(p.openEnds, "senao")
+	}
+	defer func() { p.openEnds = p.openEnds[:opened] }()
+
+	prepended := []int{}
+	for {
+		p.statementStart = p.token.Start
+		symbol := p.symbol()
+		if symbol == rules.end {
+			p.shift()
+			p.reduce(rules.endRule)
+			break
+		}
+		if symbol == "senao" && rules.elseRule != 0 {
+			p.shift()
+			p.block(elseBlock, missingEnd)
+			p.reduce(rules.elseRule)
+			break
+		}
+		if statementStarts[symbol] {
+			prepended = append(prepended, p.statement(rules))
+			continue
+		}
+		if p.token.Token == lexer.EOF_TOKEN || p.closesOpenBlock() {
+			// The end of an outer block or of the program: the
+			// parser goes on as if this block was ended
+			p.fail(missingEnd, rules.expected()...)
+			p.reduceMissing(rules.endRule)
+			break
+		}
+		p.fail("comando inválido", rules.expected()...)
+		p.skip()
+		p.synchronize()
+	}
+
+	for idx := len(prepended) - 1; idx >= 0; idx-- {
+		p.reduce(prepended[idx])
+	}
+}
+
+// closesOpenBlock tells whether the current
+// token ends one of the blocks being parsed
+func (p *RecursiveDescentParser) closesOpenBlock() bool {
+	for _, end := range p.openEnds {
+		if p.symbol() == end {
+			return true
+		}
+	}
+	return false
+}
+
+// expected returns the tokens a list of statements may go on with
+func (b blockRules) expected() []string {
+	expected := []string{"leia", "escreva", "id", "se", "repita", "enquanto", b.end}
+	if b.elseRule != 0 {
+		expected = append(expected, "senao")
+	}
+	return expected
+}
+
+// statement parses a statement of a list, returning the rule
+// that prepends it to the list. A malformed statement is
+// replaced by a BadStmt on the syntax tree
+func (p *RecursiveDescentParser) statement(rules blockRules) int {
+	height := p.builder.height()
+	start := p.token.Start
+	var parsed bool
+	var rule int
+	switch p.symbol() {
+	case "leia", "escreva":
+		parsed, rule = p.inputOutput(), rules.read
+	case "id":
+		parsed, rule = p.assignment(), rules.command
+	case "se":
+		parsed, rule = p.conditional(), rules.conditional
+	case "repita", "enquanto":
+		parsed, rule = p.loop(), rules.loop
+	}
+	if !parsed {
+		p.synchronize()
+		span := ast.Span{Start: start, End: p.previousEnd}
+		p.builder.replace(height, astItem{span: span, value: &ast.BadStmt{Span: span}})
+	}
+	return rule
+}
+
+// synchronize discards tokens after an error until the end of the
+// malformed statement, a ";", or the start of another statement.
+// An id only starts an assignment when "<-" comes after it,
+// otherwise it is an operand of the malformed statement
+func (p *RecursiveDescentParser) synchronize() {
+	for p.token.Token != lexer.EOF_TOKEN {
+		switch symbol := p.symbol(); {
+		case symbol == "pt_v":
+			p.skip()
+			return
+		case symbol == "id":
+			if tokenSymbol(p.tokens.Peek(0).Token) == "rcb" {
+				return
+			}
+		case statementStarts[symbol], p.closesOpenBlock():
+			return
+		case symbol == "varfim", symbol == "inteiro", symbol == "real", symbol == "literal":
+			return
+		}
+		p.skip()
+	}
+}
+
+// synchronizeHeader discards tokens after an error on the header
+// of a block until class, which ends the header, so that the
+// statements of the block are still parsed
+func (p *RecursiveDescentParser) synchronizeHeader(class string) {
+	for p.token.Token != lexer.EOF_TOKEN {
+		switch symbol := p.symbol(); {
+		case symbol == class:
+			p.skip()
+			return
+		case symbol != "id" && statementStarts[symbol], p.closesOpenBlock():
+			return
+		}
+		p.skip()
+	}
+}
+
+// ES -> leia id pt_v | escreva ARG pt_v
+func (p *RecursiveDescentParser) inputOutput() bool {
+	if p.symbol() == "leia" {
+		p.shift()
+		if !p.expect("id", "leia precisa de uma variável") || !p.expect("pt_v", "falta ';' ao fim do leia") {
+			return false
+		}
+		p.reduce(11)
+		return true
+	}
+
+	p.shift()
+	if !p.argument() || !p.expect("pt_v", "falta ';' ao fim do escreva") {
+		return false
+	}
+	p.reduce(12)
+	return true
+}
+
+// ARG -> lit | num | id | LD
+// A number or a variable alone is an ARG of its own, it is only
+// an operand when an operator follows it, like the SLR table does
+func (p *RecursiveDescentParser) argument() bool {
+	argumentRules := map[string]int{"num": 14, "id": 15}
+	operandRules := map[string]int{"num": 21, "id": 20}
+	switch symbol := p.symbol(); symbol {
+	case "lit":
+		p.reduceAfterShift(13)
+		return true
+	case "num", "id":
+		p.shift()
+		if p.symbol() == "pt_v" {
+			p.reduce(argumentRules[symbol])
+			return true
+		}
+		p.reduce(operandRules[symbol])
+		if !p.restOfTerm() || !p.restOfExpression() {
+			return false
+		}
+	case "ab_p":
+		if !p.expression() {
+			return false
+		}
+	default:
+		p.fail("escreva precisa de um literal ou de uma expressão", "lit", "num", "id", "ab_p")
+		return false
+	}
+	p.reduce(49)
+	return true
+}
+
+// CMD -> id rcb LD pt_v
+func (p *RecursiveDescentParser) assignment() bool {
+	name := p.token.Token.GetLexem()
+	p.shift()
+	if !p.expect("rcb", fmt.Sprintf("atribuição a '%s' sem '<-'", name)) || !p.expression() {
+		return false
+	}
+	if !p.expect("pt_v", fmt.Sprintf("falta ';' ao fim da atribuição a '%s'", name)) {
+		return false
+	}
+	p.reduce(17)
+	return true
+}
+
+// COND -> CAB CP
+// CAB -> se ab_p EXP_R fc_p entao
+func (p *RecursiveDescentParser) conditional() bool {
+	height := p.builder.height()
+	start := p.token.Start
+	p.shift()
+	conditionStart := p.token.Start
+	if !p.condition("se") || !p.expect("entao", "falta entao após a condição do se") {
+		p.synchronizeHeader("entao")
+		p.reduceMalformed(24, height, p.badHeader(start, conditionStart))
+	} else {
+		p.reduce(24)
+	}
+	p.enter()
+	p.block(conditionalBlock, "estrutura condicional sem fimse")
+	p.leave()
+	p.reduce(23)
+	return true
+}
+
+// R -> CABR CPR | CABW CPW
+// CABR -> repita ab_p EXP_R fc_p
+// CABW -> enquanto ab_p EXP_R fc_p
+func (p *RecursiveDescentParser) loop() bool {
+	header, body, rule, missing := 32, loopBlock, 31, "estrutura de repetição sem fimrepita"
+	if p.symbol() == "enquanto" {
+		header, body, rule, missing = 51, whileBlock, 50, "estrutura de repetição sem fimenquanto"
+	}
+	keyword := p.symbol()
+	height := p.builder.height()
+	start := p.token.Start
+	p.shift()
+	conditionStart := p.token.Start
+	if !p.condition(keyword) {
+		p.synchronizeHeader("fc_p")
+		p.reduceMalformed(header, height, p.badHeader(start, conditionStart))
+	} else {
+		p.reduce(header)
+	}
+	p.enter()
+	p.block(body, missing)
+	p.leave()
+	p.reduce(rule)
+	return true
+}
+
+// badHeader returns the item of a malformed header of a block,
+// which starts on start. Its value is the condition of the block:
+// a BadExpr from conditionStart to the end of the header
+func (p *RecursiveDescentParser) badHeader(start, conditionStart lexer.Position) astItem {
+	condition := &ast.BadExpr{Span: ast.Span{Start: conditionStart, End: p.previousEnd}}
+	return astItem{span: ast.Span{Start: start, End: p.previousEnd}, value: condition}
+}
+
+// condition parses ab_p EXP_R fc_p, the condition of statement
+// EXP_R -> LD opr LD
+func (p *RecursiveDescentParser) condition(statement string) bool {
+	if p.symbol() == "ab_p" {
+		p.enter()
+		defer p.leave()
+	}
+	if !p.expect("ab_p", fmt.Sprintf("a condição do %s deve estar entre parênteses", statement)) || !p.expression() {
+		return false
+	}
+	if !p.expect("opr", fmt.Sprintf("a condição do %s precisa de um operador relacional", statement)) || !p.expression() {
+		return false
+	}
+	p.reduce(25)
+	return p.expect("fc_p", fmt.Sprintf("falta ')' ao fim da condição do %s", statement))
+}
+
+// LD -> LD opm TERMO | TERMO
+func (p *RecursiveDescentParser) expression() bool {
+	return p.term() && p.restOfExpression()
+}
+
+// restOfExpression parses what follows the first TERMO of an LD
+func (p *RecursiveDescentParser) restOfExpression() bool {
+	p.reduce(19)
+	for p.symbol() == "opm" {
+		p.shift()
+		if !p.term() {
+			return false
+		}
+		p.reduce(18)
+	}
+	return true
+}
+
+// TERMO -> TERMO opmul OPRD | OPRD
+func (p *RecursiveDescentParser) term() bool {
+	return p.operand() && p.restOfTerm()
+}
+
+// restOfTerm parses what follows the first OPRD of a TERMO
+func (p *RecursiveDescentParser) restOfTerm() bool {
+	p.reduce(39)
+	for p.symbol() == "opmul" {
+		p.shift()
+		if !p.operand() {
+			return false
+		}
+		p.reduce(38)
+	}
+	return true
+}
+
+// OPRD -> id | num | ab_p LD fc_p
+func (p *RecursiveDescentParser) operand() bool {
+	switch p.symbol() {
+	case "id":
+		p.reduceAfterShift(20)
+	case "num":
+		p.reduceAfterShift(21)
+	case "ab_p":
+		p.enter()
+		defer p.leave()
+		p.shift()
+		if !p.expression() || !p.expect("fc_p", "parênteses desbalanceados na expressão") {
+			return false
+		}
+		p.reduce(40)
+	default:
+		p.fail("esperava um operando na expressão", "id", "num", "ab_p")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,277 @@
+package parser
+
+import (
+	"io/ioutil"
+	"mgol-go/src/grammar"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDescentParser(t *testing.T, source string) *RecursiveDescentParser {
+	return NewRecursiveDescentParser(newTestScanner(t, source), GetRulesMap(grammarPath))
+}
+
+func TestStatementStartsMatchGrammar(t *testing.T) {
+	g, err := grammar.LoadJSONFile(grammarPath)
+	require.NoError(t, err)
+
+	starts := map[string]bool{}
+	for _, statement := range []string{"ES", "CMD", "COND", "R"} {
+		for symbol := range g.First(statement) {
+			starts[symbol] = true
+		}
+	}
+	require.Equal(t, starts, statementStarts)
+}
+
+func TestDefaultRulesMatchGrammar(t *testing.T) {
+	require.Equal(t, GetRulesMap(grammarPath), DefaultRules())
+}
+
+func TestRecursiveDescentMatchesSLR(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "*.mgol"))
+	require.NoError(t, err)
+
+	for _, source := range sources {
+		source := source
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			content, err := ioutil.ReadFile(source)
+			require.NoError(t, err)
+
+			slrParser := newTestParser(t, string(content))
+			slrResult := slrParser.Parse()
+			descentParser := newTestDescentParser(t, string(content))
+			descentResult := descentParser.Parse()
+
+			require.Equal(t, slrResult.Succeeded(), descentResult.Succeeded())
+			if !slrResult.Succeeded() {
+				require.NotEmpty(t, descentResult.Errors)
+				return
+			}
+			require.Equal(t, slrResult.Reductions, descentResult.Reductions)
+			require.Equal(t, slrResult.Program, descentResult.Program)
+			require.Equal(t, slrResult.ParseTree, descentResult.ParseTree)
+			require.Equal(t, slrParser.semantic.codeBuffer.Code(), descentParser.semantic.codeBuffer.Code())
+		})
+	}
+}
+
+func TestRecursiveDescentErrors(t *testing.T) {
+	testCases := []struct {
+		name             string
+		source           string
+		expectedMessages []string
+	}{
+		{
+			name:             "Assignment without operator",
+			source:           "inicio varinicio inteiro A; varfim; A 1; fim",
+			expectedMessages: []string{"atribuição a 'A' sem '<-'"},
+		},
+		{
+			name:             "Missing semicolon after assignment",
+			source:           "inicio varinicio inteiro A; varfim; A <- 1 escreva A; fim",
+			expectedMessages: []string{"falta ';' ao fim da atribuição a 'A'"},
+		},
+		{
+			name:             "Missing operand",
+			source:           "inicio varinicio inteiro A; varfim; A <- A + ; fim",
+			expectedMessages: []string{"esperava um operando na expressão"},
+		},
+		{
+			name:             "Unbalanced parentheses",
+			source:           "inicio varinicio inteiro A; varfim; A <- (A + 1; fim",
+			expectedMessages: []string{"parênteses desbalanceados na expressão"},
+		},
+		{
+			name:             "Condition without relational operator",
+			source:           "inicio varinicio inteiro A; varfim; se (A) entao escreva A; fimse fim",
+			expectedMessages: []string{"a condição do se precisa de um operador relacional"},
+		},
+		{
+			name:             "Missing entao",
+			source:           "inicio varinicio inteiro A; varfim; se (A > 1) escreva A; fimse fim",
+			expectedMessages: []string{"falta entao após a condição do se"},
+		},
+		{
+			name:             "Loop without fimrepita",
+			source:           "inicio varinicio inteiro A; varfim; repita (A > 1) A <- A - 1; fim",
+			expectedMessages: []string{"estrutura de repetição sem fimrepita"},
+		},
+		{
+			name:             "Missing varfim",
+			source:           "inicio varinicio inteiro A; leia A; fim",
+			expectedMessages: []string{"declaração de variáveis sem varfim"},
+		},
+		{
+			name:             "Content after fim",
+			source:           "inicio varinicio varfim; fim leia A;",
+			expectedMessages: []string{"conteúdo após o fim do programa"},
+		},
+		{
+			name:   "Errors on separate statements",
+			source: "inicio varinicio inteiro A; varfim; leia ; escreva ; A <- 1; fim",
+			expectedMessages: []string{
+				"leia precisa de uma variável",
+				"escreva precisa de um literal ou de uma expressão",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newTestDescentParser(t, tc.source).Parse()
+			require.True(t, result.Accepted)
+			require.NotNil(t, result.Program)
+
+			messages := []string{}
+			for _, syntaxError := range result.Errors {
+				messages = append(messages, syntaxError.Message)
+			}
+			require.Equal(t, tc.expectedMessages, messages)
+		})
+	}
+}
+
+func TestParseRecoveryStats(t *testing.T) {
+	testCases := []struct {
+		name             string
+		source           string
+		errorLimit       int
+		expectedAccepted bool
+		expectedErrors   int
+		expectedStats    RecoveryStats
+		// expectedSkipped holds the tokens each parser
+		// skips, since they recover in different ways
+		expectedSkipped map[string]int
+	}{
+		{
+			name:             "Valid program",
+			source:           "inicio varinicio inteiro A; varfim; leia A; fim",
+			expectedAccepted: true,
+			expectedStats:    RecoveryStats{},
+		},
+		{
+			name:             "Recovered errors",
+			source:           "inicio varinicio inteiro A; varfim; A <- A A; leia ; escreva A; fim",
+			expectedAccepted: true,
+			expectedErrors:   2,
+			expectedStats:    RecoveryStats{Recovered: 2, Partial: true},
+			expectedSkipped:  map[string]int{"slr": 2, "descendente": 3},
+		},
+		{
+			name:             "Tokens after the end",
+			source:           "inicio varinicio varfim; fim leia A;",
+			expectedAccepted: true,
+			expectedErrors:   1,
+			expectedStats:    RecoveryStats{Recovered: 1, Partial: true},
+			expectedSkipped:  map[string]int{"slr": 3, "descendente": 3},
+		},
+		{
+			name:             "Errors below the limit",
+			source:           "inicio varinicio inteiro A; varfim; A <- A A; leia ; escreva A; fim",
+			errorLimit:       3,
+			expectedAccepted: true,
+			expectedErrors:   2,
+			expectedStats:    RecoveryStats{Recovered: 2, Partial: true},
+			expectedSkipped:  map[string]int{"slr": 2, "descendente": 3},
+		},
+		{
+			name:             "Error limit reached",
+			source:           "inicio varinicio inteiro A; varfim; A <- A A; leia ; escreva A; fim",
+			errorLimit:       2,
+			expectedAccepted: false,
+			expectedErrors:   2,
+			expectedStats:    RecoveryStats{Recovered: 1, Aborted: true, Partial: true},
+			expectedSkipped:  map[string]int{"slr": 1, "descendente": 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		analyzers := map[string]Analyzer{
+			"slr":         newTestParser(t, tc.source),
+			"descendente": newTestDescentParser(t, tc.source),
+		}
+		for name, analyzer := range analyzers {
+			t.Run(tc.name+" "+name, func(t *testing.T) {
+				analyzer.SetErrorLimit(tc.errorLimit)
+				result := analyzer.Parse()
+				require.Equal(t, tc.expectedAccepted, result.Accepted)
+				require.Len(t, result.Errors, tc.expectedErrors)
+
+				expectedStats := tc.expectedStats
+				expectedStats.SkippedTokens = tc.expectedSkipped[name]
+				require.Equal(t, expectedStats, result.Recovery)
+			})
+		}
+	}
+}
+
+func TestParseNestingLimit(t *testing.T) {
+	nested := func(open, middle, close string, depth int) string {
+		return strings.Repeat(open, depth) + middle + strings.Repeat(close, depth)
+	}
+
+	testCases := []struct {
+		name            string
+		source          string
+		maxNesting      int
+		expectedMessage string
+		expectedLine    int
+	}{
+		{
+			name:       "Parentheses within the limit",
+			source:     "inicio varinicio inteiro A; varfim; A <- " + nested("(", "A", ")", 5) + "; fim",
+			maxNesting: 5,
+		},
+		{
+			name:            "Parentheses above the limit",
+			source:          "inicio varinicio inteiro A; varfim; A <- " + nested("(", "A", ")", 6) + "; fim",
+			maxNesting:      5,
+			expectedMessage: "aninhamento acima do limite de 5 níveis",
+			expectedLine:    1,
+		},
+		{
+			name:       "Blocks within the limit",
+			source:     "inicio varinicio inteiro A; varfim;\n" + nested("repita (A > 1)\n", "A <- A - 1;\n", "fimrepita\n", 2) + "fim",
+			maxNesting: 3,
+		},
+		{
+			name: "Blocks above the limit",
+			source: "inicio varinicio inteiro A; varfim;\n" +
+				nested("se (A > 1) entao\n", "escreva A;\n", "fimse\n", 3) + "fim",
+			maxNesting:      2,
+			expectedMessage: "aninhamento acima do limite de 2 níveis",
+			expectedLine:    4,
+		},
+		{
+			name:       "No limit",
+			source:     "inicio varinicio inteiro A; varfim; A <- " + nested("(", "A", ")", 500) + "; fim",
+			maxNesting: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		analyzers := map[string]Analyzer{
+			"slr":         newTestParser(t, tc.source),
+			"descendente": newTestDescentParser(t, tc.source),
+		}
+		for name, analyzer := range analyzers {
+			t.Run(tc.name+" "+name, func(t *testing.T) {
+				analyzer.SetMaxNesting(tc.maxNesting)
+				result := analyzer.Parse()
+				if tc.expectedMessage == "" {
+					require.True(t, result.Succeeded())
+					return
+				}
+				require.False(t, result.Accepted)
+				require.True(t, result.Recovery.Aborted)
+				require.Len(t, result.Errors, 1)
+				require.Equal(t, tc.expectedMessage, result.Errors[0].Message)
+				require.Equal(t, tc.expectedLine, result.Errors[0].Line)
+			})
+		}
+	}
+}
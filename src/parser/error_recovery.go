@@ -11,33 +11,195 @@ const (
 	recoveryFail   RecoveryStatus = false
 )
 
-func panicMode(parser *Parser, firstToken lexer.Token) RecoveryStatus {
-
-	stack_copy := parser.stack.Clone()
-	actionReader := NewActionReader(parser.actionTablePath)
-	token := firstToken
-	parser.stack.Pop()
-
-	for {
-		for parser.stack.GetLength() > 0 {
-			topStack, err := parser.stack.Get()
-			if err != nil {
-				panic(err)
+// syncTokens are the token classes the parser looks for to
+// resume the analysis after a syntax error. They end a
+// command, a declaration or a block
+var syncTokens = map[string]bool{
+	"pt_v":        true,
+	"varfim":      true,
+	"senao":       true,
+	"fimse":       true,
+	"fimrepita":   true,
+	"fimenquanto": true,
+	"fim":         true,
+}
+
+// recoveryPoint is the token the parser resumed from after an error
+type recoveryPoint struct {
+	token  lexer.Token
+	line   int
+	column int
+}
+
+// sectionMarkers are the tokens that open or close the program,
+// its declarations and its blocks, followed by the tokens that
+// must come right after them
+var sectionMarkers = map[string][]string{
+	"inicio":      {"inicio"},
+	"varinicio":   {"varinicio"},
+	"varfim":      {"varfim", "pt_v"},
+	"fimse":       {"fimse"},
+	"fimrepita":   {"fimrepita"},
+	"fimenquanto": {"fimenquanto"},
+	"fim":         {"fim"},
+}
+
+// missingMarkerMessages holds the message of the
+// error raised when a section marker is missing
+var missingMarkerMessages = map[string]string{
+	"inicio":      "programa sem inicio",
+	"varinicio":   "declaração de variáveis sem varinicio",
+	"varfim":      "declaração de variáveis sem varfim",
+	"fimse":       "estrutura condicional sem fimse",
+	"fimrepita":   "estrutura de repetição sem fimrepita",
+	"fimenquanto": "estrutura de repetição sem fimenquanto",
+	"fim":         "programa sem fim",
+}
+
+// uniqueMarkers are the markers that may appear only once on a program
+var uniqueMarkers = map[string]bool{
+	"inicio":    true,
+	"varinicio": true,
+	"varfim":    true,
+}
+
+// markerToken returns the token the scanner reads for class
+func markerToken(class string) lexer.Token {
+	if class == "pt_v" {
+		return lexer.NewToken(lexer.SEMICOLON, ";", lexer.NULL)
+	}
+	return lexer.NewToken(lexer.TokenClass(class), class, lexer.DataType(class))
+}
+
+// maxMissingMarkers bounds how many markers missing in a
+// row, like the ends of nested blocks, are looked for
+const maxMissingMarkers = 16
+
+// missingMarker returns the section marker that, read before token
+// on the parser stack made of states, would let the parser go on
+// with it, possibly after other missing markers. The parser resumes
+// as if the tokens of the marker were read
+func (p *Parser) missingMarker(states []int, token lexer.Token, depth int) (string, bool) {
+	if depth >= maxMissingMarkers {
+		return "", false
+	}
+	for _, class := range p.actionReader.ExpectedTokens(lexer.State(states[len(states)-1])) {
+		marker, found := sectionMarkers[class]
+		if !found {
+			continue
+		}
+
+		after, shifted := states, true
+		for _, markerClass := range marker {
+			if after, shifted = p.simulateShift(after, markerClass); !shifted {
+				break
 			}
+		}
+		if !shifted {
+			continue
+		}
+		if _, shifted = p.simulateShift(after, tokenSymbol(token)); shifted {
+			return class, true
+		}
+		if _, found = p.missingMarker(after, token, depth+1); found {
+			return class, true
+		}
+	}
+	return "", false
+}
+
+// skipMarker discards a marker that the program already has, along
+// with the tokens that come with it, returning the token after it
+// and where the last token discarded ends
+func (p *Parser) skipMarker(token lexer.Token, line, column int) (lexer.Token, int, int, lexer.Position) {
+	end := p.position(line, column)
+	for _, class := range sectionMarkers[token.GetClass()] {
+		if token == lexer.EOF_TOKEN || token.GetClass() != class {
+			break
+		}
+		end = p.position(line, column)
+		p.skippedTokens++
+		token, line, column = p.nextToken()
+	}
+	return token, line, column, end
+}
+
+// skipToEnd discards the tokens found after the end of the program,
+// returning where the last token discarded ends
+func (p *Parser) skipToEnd(token lexer.Token, line, column int) (lexer.Token, int, int, lexer.Position) {
+	end := p.position(line, column)
+	for token != lexer.EOF_TOKEN {
+		end = p.position(line, column)
+		p.skippedTokens++
+		token, line, column = p.nextToken()
+	}
+	return token, line, column, end
+}
+
+// afterProgramEnd tells whether the parser read the whole
+// program and only expects the end of the file
+func afterProgramEnd(expected []string) bool {
+	return len(expected) == 1 && expected[0] == "$"
+}
 
-			state := lexer.State(topStack.(int))
-			action, _ := actionReader.GetAction(state, token)
+// endsConstruct tells whether a construct, like a
+// command or the header of a block, ends on token
+func endsConstruct(token lexer.Token) bool {
+	switch token.GetClass() {
+	case "inicio", "varinicio", "entao":
+		return true
+	}
+	return isSyncToken(token)
+}
+
+func isSyncToken(token lexer.Token) bool {
+	return token != lexer.EOF_TOKEN && syncTokens[token.GetClass()]
+}
 
-			if action != ERROR {
-				return recoverySucess
+// panicMode discards tokens until a synchronization token is found
+// and pops states until one of them can continue with it. When
+// no state can continue with a ";" the token after it, which
+// starts a new command, is tried as well. If the parser already
+// failed to go on from token, skipToken makes it be discarded.
+// It returns the token the parser must continue with, its position
+// and where the last token discarded, or token itself, ends
+func panicMode(parser *Parser, token lexer.Token, line, column int, skipToken bool) (RecoveryStatus, lexer.Token, int, int, lexer.Position) {
+	end := parser.position(line, column)
+	afterSemicolon := false
+	for token != lexer.EOF_TOKEN {
+		if !skipToken && (isSyncToken(token) || afterSemicolon) {
+			if recoverStack(parser, token) {
+				return recoverySucess, token, line, column, end
 			}
-			parser.stack.Pop()
 		}
 
-		parser.stack = stack_copy
-		token, _, _ = parser.scanner.Scan()
-		if token == lexer.EOF_TOKEN {
-			return recoveryFail
+		afterSemicolon = token.GetClass() == "pt_v"
+		skipToken = false
+		end = parser.position(line, column)
+		parser.skippedTokens++
+		token, line, column = parser.nextToken()
+	}
+	return recoveryFail, token, line, column, end
+}
+
+// recoverStack pops states until the one on top has an action for
+// token, replacing the parser stack. The stack is kept untouched
+// when no state on it can continue with token
+func recoverStack(parser *Parser, token lexer.Token) bool {
+	stackCopy := parser.stack.Clone()
+	for stackCopy.GetLength() > 0 {
+		topStack, err := stackCopy.Get()
+		if err != nil {
+			panic(err)
+		}
+
+		state := lexer.State(topStack.(int))
+		action, _ := parser.actionReader.GetAction(state, token)
+		if action != ERROR {
+			parser.stack = stackCopy
+			return true
 		}
+		stackCopy.Pop()
 	}
+	return false
 }
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+)
+
+// EventKind tells which part of the program an Event is about
+type EventKind int
+
+const (
+	// EnterProgram is sent on inicio and ExitProgram once
+	// the whole program, up to fim, is parsed
+	EnterProgram EventKind = iota
+	ExitProgram
+	// BeginDecls is sent on varinicio and EndDecls on varfim;
+	BeginDecls
+	EndDecls
+	// Declaration is sent for each variable declared
+	Declaration
+	// Statement is sent for each leia, escreva and assignment
+	Statement
+	// EnterBlock is sent after the header of a se or repita,
+	// ElseBlock on senao and ExitBlock at the end of the block
+	EnterBlock
+	ElseBlock
+	ExitBlock
+	// Error is sent for each syntax error
+	Error
+)
+
+var eventKindNames = map[EventKind]string{
+	EnterProgram: "EnterProgram",
+	ExitProgram:  "ExitProgram",
+	BeginDecls:   "BeginDecls",
+	EndDecls:     "EndDecls",
+	Declaration:  "Declaration",
+	Statement:    "Statement",
+	EnterBlock:   "EnterBlock",
+	ElseBlock:    "ElseBlock",
+	ExitBlock:    "ExitBlock",
+	Error:        "Error",
+}
+
+func (k EventKind) String() string {
+	return eventKindNames[k]
+}
+
+// Event is a part of the program found by the parser
+type Event struct {
+	Kind EventKind
+	// Span covers the part of the program: the header of the block
+	// on EnterBlock and the whole block on ExitBlock
+	Span ast.Span
+	// Keyword is leia, escreva or <- on a Statement
+	// and se or repita on the events of a block
+	Keyword string
+	// Name is the variable declared, read or assigned,
+	// or the argument of escreva
+	Name string
+	// Type is the type of the variable on a Declaration
+	Type lexer.DataType
+	// SyntaxError is the error found on an Error
+	SyntaxError *SyntaxError
+}
+
+// EventHandler receives the events of a parse as they are found
+type EventHandler func(event Event)
+
+// eventItem is an element of the emitter stack: the
+// span of a symbol and the first token derived from it
+type eventItem struct {
+	span  ast.Span
+	token lexer.Token
+}
+
+// eventEmitter mirrors the parser stack like astBuilder, but
+// keeps only what the events need instead of building nodes
+type eventEmitter struct {
+	handler EventHandler
+	items   []eventItem
+	// failed is set once a syntax error desynchronizes
+	// the emitter stack from the parser stack
+	failed bool
+}
+
+// statementKeywords holds the keyword of the statements
+// reduced by each rule and which of their symbols is the name
+var statementKeywords = map[int]struct {
+	keyword string
+	name    int
+}{
+	// ES -> leia id pt_v
+	11: {"leia", 1},
+	// ES -> escreva ARG pt_v
+	12: {"escreva", 1},
+	// CMD -> id rcb LD pt_v
+	17: {"<-", 0},
+}
+
+func (e *eventEmitter) emit(event Event) {
+	if e.handler != nil {
+		e.handler(event)
+	}
+}
+
+// shift pushes a token read from start to end
+func (e *eventEmitter) shift(token lexer.Token, start, end lexer.Position) {
+	if e.handler == nil || e.failed {
+		return
+	}
+	span := ast.Span{Start: start, End: end}
+	e.items = append(e.items, eventItem{span: span, token: token})
+
+	switch token.GetClass() {
+	case "inicio":
+		e.emit(Event{Kind: EnterProgram, Span: span})
+	case "varinicio":
+		e.emit(Event{Kind: BeginDecls, Span: span})
+	case "senao":
+		e.emit(Event{Kind: ElseBlock, Span: span, Keyword: "se"})
+	}
+}
+
+// reduce replaces the items of the right side of the
+// rule by one for its left side, sending its event
+func (e *eventEmitter) reduce(rule Rule) {
+	if e.handler == nil || e.failed {
+		return
+	}
+	size := len(rule.Right)
+	if size == 0 || size > len(e.items) {
+		e.failed = true
+		e.items = nil
+		return
+	}
+
+	items := e.items[len(e.items)-size:]
+	span := ast.Span{Start: items[0].span.Start, End: items[size-1].span.End}
+	first := items[0].token
+
+	switch rule.Number {
+	case 1:
+		e.emit(Event{Kind: ExitProgram, Span: span})
+	case 4:
+		e.emit(Event{Kind: EndDecls, Span: span})
+	case 5:
+		e.emit(Event{
+			Kind: Declaration,
+			Span: span,
+			Name: items[1].token.GetLexem(),
+			Type: typeTokens[items[0].token.GetClass()],
+		})
+	case 11, 12, 17:
+		statement := statementKeywords[rule.Number]
+		e.emit(Event{Kind: Statement, Span: span, Keyword: statement.keyword, Name: items[statement.name].token.GetLexem()})
+	case 24, 32:
+		e.emit(Event{Kind: EnterBlock, Span: span, Keyword: first.GetClass()})
+	case 23, 31:
+		e.emit(Event{Kind: ExitBlock, Span: span, Keyword: first.GetClass()})
+	}
+
+	e.items = append(e.items[:len(e.items)-size], eventItem{span: span, token: first})
+}
+
+// fail sends the syntax error. Since the emitter stack no longer
+// matches the parser one, only errors are sent after it
+func (e *eventEmitter) fail(syntaxError SyntaxError) {
+	e.emit(Event{Kind: Error, Span: syntaxError.Span, SyntaxError: &syntaxError})
+	e.failed = true
+	e.items = nil
+}
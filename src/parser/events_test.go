@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// eventString describes event without its span
+func eventString(event Event) string {
+	switch event.Kind {
+	case Declaration:
+		return fmt.Sprintf("%v %s %s", event.Kind, event.Type, event.Name)
+	case Statement:
+		return fmt.Sprintf("%v %s %s", event.Kind, event.Keyword, event.Name)
+	case EnterBlock, ElseBlock, ExitBlock:
+		return fmt.Sprintf("%v %s", event.Kind, event.Keyword)
+	case Error:
+		return fmt.Sprintf("%v %s", event.Kind, event.SyntaxError.Message)
+	}
+	return event.Kind.String()
+}
+
+func TestEvents(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         string
+		expectedEvents []string
+	}{
+		{
+			name: "Every construct",
+			source: "inicio varinicio inteiro A; literal B; varfim;\n" +
+				"leia A; escreva B;\n" +
+				"se (A > 1) entao A <- A - 1; senao repita (A < 1) A <- A + 1; fimrepita fimse\n" +
+				"fim",
+			expectedEvents: []string{
+				"EnterProgram",
+				"BeginDecls",
+				"Declaration inteiro A",
+				"Declaration literal B",
+				"EndDecls",
+				"Statement leia A",
+				"Statement escreva B",
+				"EnterBlock se",
+				"Statement <- A",
+				"ElseBlock se",
+				"EnterBlock repita",
+				"Statement <- A",
+				"ExitBlock repita",
+				"ExitBlock se",
+				"ExitProgram",
+			},
+		},
+		{
+			name:   "Only errors after a syntax error",
+			source: "inicio varinicio inteiro A; varfim; leia A; leia ; escreva ; leia A; fim",
+			expectedEvents: []string{
+				"EnterProgram",
+				"BeginDecls",
+				"Declaration inteiro A",
+				"EndDecls",
+				"Statement leia A",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		analyzers := map[string]Analyzer{
+			"slr":         newTestParser(t, tc.source),
+			"descendente": newTestDescentParser(t, tc.source),
+		}
+		for name, analyzer := range analyzers {
+			t.Run(tc.name+" "+name, func(t *testing.T) {
+				events := []string{}
+				errors := 0
+				analyzer.SetEventHandler(func(event Event) {
+					if event.Kind == Error {
+						errors++
+						return
+					}
+					events = append(events, eventString(event))
+				})
+				result := analyzer.Parse()
+				require.Equal(t, tc.expectedEvents, events)
+				require.Equal(t, len(result.Errors), errors)
+				require.Nil(t, result.Program)
+				require.Nil(t, result.ParseTree)
+			})
+		}
+	}
+}
+
+func TestEventSpans(t *testing.T) {
+	source := "inicio varinicio varfim;\nrepita (1 < 2)\n\tleia A;\nfimrepita\nfim"
+	for name, analyzer := range map[string]Analyzer{
+		"slr":         newTestParser(t, source),
+		"descendente": newTestDescentParser(t, source),
+	} {
+		t.Run(name, func(t *testing.T) {
+			events := []Event{}
+			analyzer.SetEventHandler(func(event Event) {
+				events = append(events, event)
+			})
+			analyzer.Parse()
+
+			require.Equal(t, EnterBlock, events[3].Kind)
+			require.Equal(t, lexer.Position{Line: 2, Column: 1}, events[3].Span.Start)
+			require.Equal(t, lexer.Position{Line: 2, Column: 14}, events[3].Span.End)
+			require.Equal(t, ExitBlock, events[5].Kind)
+			require.Equal(t, lexer.Position{Line: 2, Column: 1}, events[5].Span.Start)
+			require.Equal(t, lexer.Position{Line: 4, Column: 9}, events[5].Span.End)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"fmt"
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+// tokenDescriptions holds how the token classes that are not
+// written as a single fixed word are shown on error messages
+var tokenDescriptions = map[string]string{
+	"id":    "identificador",
+	"num":   "número",
+	"lit":   "literal",
+	"opm":   "operador aditivo",
+	"opmul": "operador multiplicativo",
+	"opr":   "operador relacional",
+	"pt_v":  "';'",
+	"rcb":   "'<-'",
+	"ab_p":  "'('",
+	"fc_p":  "')'",
+	"$":     "fim de arquivo",
+}
+
+// maxSimulatedReductions bounds the reductions simulated by
+// expectedTokens, guarding against cycles on the tables
+const maxSimulatedReductions = 1000
+
+// expectedTokens returns the token classes that the parser could
+// shift from its current stack. The action table of an SLR parser
+// reduces on every token of FOLLOW, so each candidate is checked by
+// simulating the reductions it causes on a copy of the stack
+func (p *Parser) expectedTokens() []string {
+	elements := p.stack.Elements()
+	top := lexer.State(elements[len(elements)-1].(int))
+
+	expected := []string{}
+	for _, class := range p.actionReader.ExpectedTokens(top) {
+		if p.canShift(elements, class) {
+			expected = append(expected, class)
+		}
+	}
+	return expected
+}
+
+func (p *Parser) canShift(elements []interface{}, class string) bool {
+	_, shifted := p.simulateShift(stackStates(elements), class)
+	return shifted
+}
+
+// stackStates returns the states of the elements of the parser stack
+func stackStates(elements []interface{}) []int {
+	states := make([]int, len(elements))
+	for idx, element := range elements {
+		states[idx] = element.(int)
+	}
+	return states
+}
+
+// simulateShift runs the reductions a token of class causes on a
+// copy of states, returning the states once the token is shifted
+func (p *Parser) simulateShift(states []int, class string) ([]int, bool) {
+	states = append([]int{}, states...)
+	token := lexer.NewToken(lexer.TokenClass(class), "", lexer.NULL)
+	if class == "$" {
+		token = lexer.EOF_TOKEN
+	}
+
+	for step := 0; step < maxSimulatedReductions; step++ {
+		action, opr := p.actionReader.GetAction(lexer.State(states[len(states)-1]), token)
+		switch action {
+		case SHIFT:
+			return append(states, opr), true
+		case ACCEPT:
+			return states, true
+		case REDUCE:
+			rule := p.rules.GetRule(opr)
+			if len(rule.Right) >= len(states) {
+				return nil, false
+			}
+			states = states[:len(states)-len(rule.Right)]
+			next := p.gotoReader.GetGoto(lexer.State(states[len(states)-1]), rule.Left)
+			if next < 0 {
+				return nil, false
+			}
+			states = append(states, next)
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// describeClass returns how a token class of the
+// parse tables is shown to the user
+func describeClass(class string) string {
+	if description, found := tokenDescriptions[class]; found {
+		return description
+	}
+	return fmt.Sprintf("'%s'", class)
+}
+
+// describeExpected lists the classes as "a, b ou c"
+func describeExpected(classes []string) string {
+	descriptions := make([]string, len(classes))
+	for idx, class := range classes {
+		descriptions[idx] = describeClass(class)
+	}
+	if len(descriptions) == 1 {
+		return descriptions[0]
+	}
+	last := len(descriptions) - 1
+	return strings.Join(descriptions[:last], ", ") + " ou " + descriptions[last]
+}
+
+// describeToken shows the token found on the source. Tokens
+// whose class has many lexemes show the lexeme as well
+func describeToken(token lexer.Token) string {
+	switch class := tokenSymbol(token); class {
+	case "id", "num", "lit", "opm", "opmul", "opr":
+		return fmt.Sprintf("%s '%s'", describeClass(class), token.GetLexem())
+	default:
+		return describeClass(class)
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+)
+
+var ErrorInvalidExpression = fmt.Errorf("expressão inválida")
+
+// expressionRules are the rules of grammar.json that derive an
+// arithmetic expression, so ParseExpression does not need the file
+var expressionRules = RulesMap{
+	18: {Number: 18, Left: "LD", Right: []string{"LD", "opm", "TERMO"}},
+	19: {Number: 19, Left: "LD", Right: []string{"TERMO"}},
+	20: {Number: 20, Left: "OPRD", Right: []string{"id"}},
+	21: {Number: 21, Left: "OPRD", Right: []string{"num"}},
+	38: {Number: 38, Left: "TERMO", Right: []string{"TERMO", "opmul", "OPRD"}},
+	39: {Number: 39, Left: "TERMO", Right: []string{"OPRD"}},
+	40: {Number: 40, Left: "OPRD", Right: []string{"ab_p", "LD", "fc_p"}},
+}
+
+// ParseExpression parses src as a standalone arithmetic expression,
+// like A + 2 * (B - 1), without the skeleton of a program. The
+// identifiers are not checked against any declaration, and
+// nothing is written to the output
+func ParseExpression(src string) (ast.Expr, error) {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	scanner := lexer.NewStringScanner(src, symbolTable)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+
+	p := NewRecursiveDescentParser(scanner, &expressionRules)
+	p.quiet = true
+	p.runSemantic = false
+	p.result = &ParseResult{}
+	p.next()
+	if p.expression() && p.token.Token != lexer.EOF_TOKEN {
+		p.fail("conteúdo após o fim da expressão", "$")
+	}
+
+	for _, diagnostic := range diagnostics.Diagnostics() {
+		if diagnostic.Severity == errorhandling.Error {
+			return nil, fmt.Errorf("%w: %s", ErrorInvalidExpression, diagnostic)
+		}
+	}
+	if len(p.result.Errors) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidExpression, p.result.Errors[0])
+	}
+	return p.builder.items[0].value.(ast.Expr), nil
+}
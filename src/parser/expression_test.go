@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionRulesMatchGrammar(t *testing.T) {
+	rules := GetRulesMap(grammarPath)
+	for number, rule := range expressionRules {
+		require.Equal(t, rules.GetRule(number), rule)
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedExpr  string
+		expectedError error
+	}{
+		{
+			name:         "Single operand",
+			source:       "A",
+			expectedExpr: "A",
+		},
+		{
+			name:         "Precedence and associativity",
+			source:       "A + 2 * (B - 1) - 3",
+			expectedExpr: "((A + (2 * (B - 1))) - 3)",
+		},
+		{
+			name:         "Undeclared identifiers",
+			source:       "naoDeclarada / 2.5",
+			expectedExpr: "(naoDeclarada / 2.5)",
+		},
+		{
+			name:          "Empty source",
+			source:        "",
+			expectedError: ErrorInvalidExpression,
+		},
+		{
+			name:          "Missing operand",
+			source:        "A +",
+			expectedError: ErrorInvalidExpression,
+		},
+		{
+			name:          "Unbalanced parentheses",
+			source:        "(A + 1",
+			expectedError: ErrorInvalidExpression,
+		},
+		{
+			name:          "Content after the expression",
+			source:        "A + 1 B",
+			expectedError: ErrorInvalidExpression,
+		},
+		{
+			name:          "Lexical error",
+			source:        "A + $",
+			expectedError: ErrorInvalidExpression,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := ParseExpression(tc.source)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				require.Nil(t, expr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedExpr, exprString(expr))
+		})
+	}
+}
+
+func TestParseExpressionSpans(t *testing.T) {
+	expr, err := ParseExpression("A * (B + 1)")
+	require.NoError(t, err)
+
+	binary := expr.(*ast.BinaryExpr)
+	require.Equal(t, ast.Span{Start: lexer.Position{Line: 1, Column: 1}, End: lexer.Position{Line: 1, Column: 11}}, binary.Span)
+	// The parentheses are part of the operand
+	require.Equal(t, ast.Span{Start: lexer.Position{Line: 1, Column: 5}, End: lexer.Position{Line: 1, Column: 11}}, binary.Right.GetSpan())
+}
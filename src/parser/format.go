@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+var ErrorNotFormatted = fmt.Errorf("o programa tem erros e não foi formatado")
+
+// Format parses the program src and returns it in canonical form,
+// along with the diagnostics found. It fails with ErrorNotFormatted
+// when any of them is an error
+func Format(src string) (string, []errorhandling.Diagnostic, error) {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	scanner := lexer.NewStringScanner(src, symbolTable)
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	scanner.SetDiagnosticHandler(diagnostics)
+
+	p := NewRecursiveDescentParser(scanner, DefaultRules())
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	p.SetConcreteSyntax(true)
+	result := p.Parse()
+	for _, syntaxError := range result.Errors {
+		diagnostics.Add(syntaxError.Diagnostic())
+	}
+	for _, ioError := range result.IOErrors {
+		diagnostics.Add(ioError.Diagnostic())
+	}
+	found := diagnostics.Diagnostics()
+	for _, diagnostic := range found {
+		if diagnostic.Severity == errorhandling.Error {
+			return "", found, ErrorNotFormatted
+		}
+	}
+	if !result.Accepted || result.ParseTree == nil {
+		return "", found, ErrorNotFormatted
+	}
+
+	output := &bytes.Buffer{}
+	err := FormatTree(output, result.ParseTree)
+	return output.String(), found, err
+}
+
+// FormatTree writes the program of tree, built on concrete syntax
+// mode, on w in canonical form: one statement per line, the blocks
+// indented with tabs, one space around the operators and none inside
+// the parentheses. Unlike ast.Fprint, the parentheses and comments
+// of the source are kept. A comment on a line of its own stays so,
+// and one after a statement follows it on the same line. The blank
+// lines between statements are kept too, at most one in a row
+func FormatTree(w io.Writer, tree *ParseTreeNode) error {
+	f := &formatter{}
+	f.node(tree, "")
+	f.trivia(tree.Trailing)
+	f.endLine()
+	_, err := w.Write(f.output.Bytes())
+	return err
+}
+
+// Symbols whose tokens end the line they are on
+var lineEnds = map[string]bool{
+	"inicio": true, "pt_v": true, "fimse": true, "fimrepita": true, "fimenquanto": true, "fim": true,
+}
+
+// Symbols whose tokens start a block, ending their line,
+// and the ones that end it
+var (
+	blockStarts = map[string]bool{"varinicio": true, "entao": true, "senao": true}
+	blockEnds   = map[string]bool{"varfim": true, "senao": true, "fimse": true, "fimrepita": true, "fimenquanto": true}
+)
+
+// formatter writes the leaves of a parse tree in canonical form
+type formatter struct {
+	output bytes.Buffer
+	depth  int
+	// lineStarted tells whether the line being written has text,
+	// previous is the symbol of the last token written on it
+	lineStarted bool
+	previous    string
+	// blockStarted tells whether the last line started a block
+	blockStarted bool
+}
+
+// node writes the leaves of node, whose parent has the symbol parent
+func (f *formatter) node(node *ParseTreeNode, parent string) {
+	if node.Token == nil {
+		for _, child := range node.Children {
+			f.node(child, node.Symbol)
+		}
+		return
+	}
+
+	breaks := f.trivia(node.Leading)
+	if blockEnds[node.Symbol] {
+		f.depth--
+	} else if !f.lineStarted {
+		f.blankLine(breaks)
+	}
+	f.write(node.Text, node.Symbol)
+	// The header of a loop ends on its parenthesis
+	if blockStarts[node.Symbol] || node.Symbol == "fc_p" && (parent == "CABR" || parent == "CABW") {
+		f.depth++
+		f.blockStarted = true
+		f.endLine()
+	}
+	if lineEnds[node.Symbol] {
+		f.endLine()
+	}
+}
+
+// write writes text, the source of a token with symbol, on the
+// line, separated from the previous one by a space if needed
+func (f *formatter) write(text, symbol string) {
+	if !f.lineStarted {
+		f.output.WriteString(strings.Repeat("\t", f.depth))
+		f.lineStarted = true
+	} else if f.previous != "ab_p" && symbol != "fc_p" && symbol != "pt_v" {
+		f.output.WriteByte(' ')
+	}
+	f.output.WriteString(text)
+	f.previous = symbol
+	f.blockStarted = false
+}
+
+func (f *formatter) endLine() {
+	if f.lineStarted {
+		f.output.WriteByte('\n')
+		f.lineStarted = false
+	}
+}
+
+// blankLine keeps a blank line of the source, on the breaks line
+// breaks before a line, unless it is the first of the program or
+// of a block
+func (f *formatter) blankLine(breaks int) {
+	if breaks > 1 && f.output.Len() > 0 && !f.blockStarted {
+		f.output.WriteByte('\n')
+	}
+}
+
+// trivia writes the comments of trivia, the text between two tokens,
+// returning how many line breaks follow the last one
+func (f *formatter) trivia(trivia string) int {
+	for {
+		start := strings.IndexByte(trivia, '{')
+		if start < 0 {
+			return strings.Count(trivia, "\n")
+		}
+		end := start + strings.IndexByte(trivia[start:], '}') + 1
+		f.comment(trivia[start:end], strings.Count(trivia[:start], "\n"))
+		trivia = trivia[end:]
+	}
+}
+
+// comment writes comment, which comes after breaks line breaks
+func (f *formatter) comment(comment string, breaks int) {
+	switch {
+	case f.lineStarted:
+		// Inside a statement it stays between its tokens
+		f.write(comment, "")
+	case breaks == 0 && f.output.Len() > 0:
+		// After a statement it goes back to its line
+		f.output.Truncate(f.output.Len() - 1)
+		f.output.WriteString(" " + comment + "\n")
+	default:
+		f.blankLine(breaks)
+		f.write(comment, "")
+		f.endLine()
+	}
+}
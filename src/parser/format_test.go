@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			"layout",
+			"inicio varinicio inteiro A;real B; varfim; leia A; B<-(A+1)*2 ;se(A>1)entao escreva \"maior\"; senao repita ( A<B ) A<-A+1; fimrepita fimse escreva B; fim",
+			"inicio\nvarinicio\n\tinteiro A;\n\treal B;\nvarfim;\nleia A;\nB <- (A + 1) * 2;\nse (A > 1) entao\n\tescreva \"maior\";\nsenao\n\trepita (A < B)\n\t\tA <- A + 1;\n\tfimrepita\nfimse\nescreva B;\nfim\n",
+		},
+		{
+			"comments",
+			"{ programa }\ninicio\n  varinicio\n\tinteiro A; {contador}\n  varfim;\n  A<-  {um} 1;\n  repita (A < 3)\n  A <- A + 1;\n  {no laco}\n  fimrepita\nfim {fim}\n{ depois }\n",
+			"{ programa }\ninicio\nvarinicio\n\tinteiro A; {contador}\nvarfim;\nA <- {um} 1;\nrepita (A < 3)\n\tA <- A + 1;\n\t{no laco}\nfimrepita\nfim {fim}\n{ depois }\n",
+		},
+		{
+			"blank lines",
+			"inicio\nvarinicio\n\n\tinteiro A;\nvarfim;\n\n\n\nleia A;\n\n{ escreve }\nescreva A;\n\nfim",
+			"inicio\nvarinicio\n\tinteiro A;\nvarfim;\n\nleia A;\n\n{ escreve }\nescreva A;\n\nfim\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, diagnostics, err := Format(tc.source)
+			require.NoError(t, err)
+			require.Empty(t, diagnostics)
+			require.Equal(t, tc.expected, formatted)
+
+			again, _, err := Format(formatted)
+			require.NoError(t, err)
+			require.Equal(t, formatted, again)
+		})
+	}
+}
+
+func TestFormatCanonical(t *testing.T) {
+	// The programs on testdata are already formatted
+	for _, name := range []string{"declarations", "expressions", "conditionals", "loops"} {
+		t.Run(name, func(t *testing.T) {
+			source, err := ioutil.ReadFile(filepath.Join("testdata", name+".mgol"))
+			require.NoError(t, err)
+			formatted, _, err := Format(string(source))
+			require.NoError(t, err)
+			require.Equal(t, string(source), formatted)
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	formatted, diagnostics, err := Format("inicio varinicio varfim;\nA <- ;\nfim")
+	require.ErrorIs(t, err, ErrorNotFormatted)
+	require.Empty(t, formatted)
+	require.NotEmpty(t, diagnostics)
+
+	_, diagnostics, err = Format("inicio varinicio varfim; leia @; fim")
+	require.ErrorIs(t, err, ErrorNotFormatted)
+	require.NotEmpty(t, diagnostics)
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files with the current output:
+//
+//	go test ./src/parser -run TestGolden -update
+var update = flag.Bool("update", false, "reescreve os arquivos .golden e .out de testdata")
+
+// goldenDump shows what the parser found on a source: whether it
+// was accepted, its errors, the rules reduced and the syntax tree
+func goldenDump(t *testing.T, result *ParseResult) string {
+	dump := &strings.Builder{}
+	fmt.Fprintf(dump, "aceito: %v\n", result.Accepted)
+	fmt.Fprintf(dump, "erro semântico: %v\n", result.SemanticErrorFound)
+
+	fmt.Fprintln(dump, "\nerros:")
+	for _, syntaxError := range result.Errors {
+		fmt.Fprintf(dump, "\t%s-%s %s\n", syntaxError.Span.Start, syntaxError.Span.End, syntaxError)
+	}
+	for _, ioError := range result.IOErrors {
+		fmt.Fprintf(dump, "\t%s\n", ioError)
+	}
+
+	fmt.Fprintln(dump, "\nderivação:")
+	for _, rule := range result.Reductions {
+		fmt.Fprintf(dump, "\t%s -> %s\n", rule.Left, strings.Join(rule.Right, " "))
+	}
+
+	fmt.Fprintln(dump, "\nárvore sintática:")
+	tree := &bytes.Buffer{}
+	var program ast.Node
+	if result.Program != nil {
+		program = result.Program
+	}
+	require.NoError(t, ast.EncodeJSON(tree, program))
+	dump.Write(tree.Bytes())
+	return dump.String()
+}
+
+func TestGolden(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "*.mgol"))
+	require.NoError(t, err)
+	require.NotEmpty(t, sources)
+
+	for _, source := range sources {
+		source := source
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			content, err := ioutil.ReadFile(source)
+			require.NoError(t, err)
+			dump := goldenDump(t, newTestParser(t, string(content)).Parse())
+
+			golden := strings.TrimSuffix(source, ".mgol") + ".golden"
+			if *update {
+				require.NoError(t, ioutil.WriteFile(golden, []byte(dump), 0644))
+			}
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err, "rode com -update para criar %s", golden)
+			require.Equal(t, string(expected), dump)
+		})
+	}
+}
+
+// TestGoldenRuns generates each program of testdata/run, runs it with
+// the input on the .in file next to it and compares what it writes with
+// the .out file. runOnBackends builds and runs the C program with the
+// system compiler and the other targets whose tools are installed
+func TestGoldenRuns(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "run", "*.mgol"))
+	require.NoError(t, err)
+	require.NotEmpty(t, sources)
+
+	for _, source := range sources {
+		source := source
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			content, err := ioutil.ReadFile(source)
+			require.NoError(t, err)
+			name := strings.TrimSuffix(source, ".mgol")
+			input, err := ioutil.ReadFile(name + ".in")
+			require.NoError(t, err)
+			output := runOnBackends(t, string(content), string(input), backend.Options{})
+
+			if *update {
+				require.NoError(t, ioutil.WriteFile(name+".out", []byte(output), 0644))
+			}
+			expected, err := ioutil.ReadFile(name + ".out")
+			require.NoError(t, err, "rode com -update para criar %s.out", name)
+			require.Equal(t, string(expected), output)
+		})
+	}
+}
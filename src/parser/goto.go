@@ -27,6 +27,13 @@ func NewGotoReader(path string) *GotoReader {
 		panic(err)
 	}
 
+	return NewGotoReaderFromRecords(records)
+}
+
+// NewGotoReaderFromRecords creates a GotoReader from rows
+// of cells, the first being the header, like the tables
+// written as Go source by cmd/gentable
+func NewGotoReaderFromRecords(records [][]string) *GotoReader {
 	got := &GotoReader{}
 	got.indexes = make(map[string]int)
 	for idx, record := range records[0] {
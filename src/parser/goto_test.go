@@ -26,9 +26,9 @@ func TestGetGoto(t *testing.T) {
 		},
 		{
 			name:          "Getting Valid State 2",
-			inicialState:  21,
+			inicialState:  23,
 			nonTerminal:   "L",
-			expectedState: 49,
+			expectedState: 65,
 		},
 		{
 			name:          "Getting Non Existent State",
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+)
+
+var (
+	ErrorReadTarget    = fmt.Errorf("leia espera um identificador")
+	ErrorWriteArgument = fmt.Errorf("escreva espera um literal ou uma expressão")
+)
+
+// IOValidator checks the argument of a leia or escreva statement
+// once its node is built. The statement is kept on the tree and
+// the error returned is reported on ParseResult.IOErrors
+type IOValidator func(stmt ast.Stmt) error
+
+// IOError is an error returned by an IOValidator
+type IOError struct {
+	Span ast.Span
+	Err  error
+}
+
+func (e IOError) Error() string {
+	return fmt.Sprintf("Erro: %v na linha %d, coluna %d", e.Err, e.Span.Start.Line, e.Span.Start.Column)
+}
+
+func (e IOError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostic returns the error as a diagnostic, for the quiet
+// parsers, which leave the reporting to their callers
+func (e IOError) Diagnostic() errorhandling.Diagnostic {
+	return errorhandling.NewDiagnostic(errorhandling.Error, e.Span.Start.Line, e.Span.Start.Column, e.Err.Error())
+}
+
+// ValidateIOArgument checks that leia reads into an identifier and
+// escreva writes a literal or an expression, which may be a single
+// identifier or number. Parsers run it on every I/O statement
+func ValidateIOArgument(stmt ast.Stmt) error {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		if node.Target == nil || node.Target.Name == "" {
+			return ErrorReadTarget
+		}
+	case *ast.Write:
+		switch value := node.Value.(type) {
+		case *ast.Ident:
+			if value != nil {
+				return nil
+			}
+		case *ast.Literal:
+			if value != nil {
+				return nil
+			}
+		case *ast.BinaryExpr:
+			if value != nil {
+				return nil
+			}
+		}
+		return ErrorWriteArgument
+	}
+	return nil
+}
+
+// AddIOValidator makes the parser run validator
+// on each leia and escreva statement it parses
+func (p *Parser) AddIOValidator(validator IOValidator) {
+	p.builder.ioValidators = append(p.builder.ioValidators, validator)
+}
+
+// validateIO runs the I/O validators on value
+// when it is a leia or escreva statement
+func (b *astBuilder) validateIO(value interface{}) {
+	var stmt ast.Stmt
+	var span ast.Span
+	switch node := value.(type) {
+	case *ast.Read:
+		stmt, span = node, node.Span
+	case *ast.Write:
+		stmt, span = node, node.Span
+	default:
+		return
+	}
+
+	for _, validator := range b.ioValidators {
+		if err := validator(stmt); err != nil {
+			b.ioErrors = append(b.ioErrors, IOError{Span: span, Err: err})
+		}
+	}
+}
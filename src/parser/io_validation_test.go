@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIOArgument(t *testing.T) {
+	testCases := []struct {
+		name          string
+		stmt          ast.Stmt
+		expectedError error
+	}{
+		{
+			name: "leia an identifier",
+			stmt: &ast.Read{Target: &ast.Ident{Name: "A"}},
+		},
+		{
+			name:          "leia without target",
+			stmt:          &ast.Read{},
+			expectedError: ErrorReadTarget,
+		},
+		{
+			name: "escreva an identifier",
+			stmt: &ast.Write{Value: &ast.Ident{Name: "A"}},
+		},
+		{
+			name: "escreva a number",
+			stmt: &ast.Write{Value: &ast.Literal{Value: "1", Type: lexer.INTEGER}},
+		},
+		{
+			name: "escreva an expression",
+			stmt: &ast.Write{Value: &ast.BinaryExpr{
+				Operator: "+",
+				Left:     &ast.Ident{Name: "A"},
+				Right:    &ast.Literal{Value: "1", Type: lexer.INTEGER},
+			}},
+		},
+		{
+			name:          "escreva a malformed expression",
+			stmt:          &ast.Write{Value: &ast.BadExpr{}},
+			expectedError: ErrorWriteArgument,
+		},
+		{
+			name:          "escreva without argument",
+			stmt:          &ast.Write{},
+			expectedError: ErrorWriteArgument,
+		},
+		{
+			name: "Other statements",
+			stmt: &ast.Assign{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedError, ValidateIOArgument(tc.stmt))
+		})
+	}
+}
+
+func TestParseIOValidators(t *testing.T) {
+	errorNumberWritten := fmt.Errorf("número escrito")
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\nescreva 10;\nescreva A;\nfim"
+
+	parser := newTestParser(t, source)
+	visited := []string{}
+	parser.AddIOValidator(func(stmt ast.Stmt) error {
+		visited = append(visited, nodeKind(stmt))
+		if write, ok := stmt.(*ast.Write); ok {
+			if literal, ok := write.Value.(*ast.Literal); ok && literal.Type != lexer.LITERAL {
+				return errorNumberWritten
+			}
+		}
+		return nil
+	})
+	result := parser.Parse()
+
+	require.Equal(t, []string{"Read", "Write", "Write"}, visited)
+	require.Equal(t, []IOError{
+		{
+			Span: ast.Span{Start: lexer.Position{Line: 6, Column: 1}, End: lexer.Position{Line: 6, Column: 11}},
+			Err:  errorNumberWritten,
+		},
+	}, result.IOErrors)
+	require.True(t, errors.Is(result.IOErrors[0], errorNumberWritten))
+	require.Equal(t, "Erro: número escrito na linha 6, coluna 1", result.IOErrors[0].Error())
+	require.True(t, result.Accepted)
+	require.False(t, result.Succeeded())
+	// The statement is kept on the tree
+	require.Len(t, result.Program.Body, 3)
+
+	result = newTestParser(t, source).Parse()
+	require.Empty(t, result.IOErrors)
+	require.True(t, result.Succeeded())
+}
+
+func nodeKind(node ast.Node) string {
+	return fmt.Sprintf("%T", node)[len("*ast."):]
+}
@@ -0,0 +1,301 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+)
+
+// parseState is what a run of Parse keeps besides the stack
+// of the parser: the lookahead, the recovery bookkeeping and
+// where the symbols on the stack are on the source
+type parseState struct {
+	*Parser
+	result *ParseResult
+	// token is the lookahead, which ends on line and column
+	token  lexer.Token
+	line   int
+	column int
+	// recoveredAt is the token the last error recovery resumed from
+	recoveredAt recoveryPoint
+	// pending holds the tokens to parse before reading the next
+	// one: the rest of a missing marker and the token found instead
+	pending []recoveryPoint
+	// shiftedMarkers holds the unique markers already read
+	shiftedMarkers map[string]bool
+	// spans holds where the symbol of each state
+	// on the stack is on the source
+	spans []ast.Span
+	// nesting holds how many parentheses and blocks are
+	// open up to the symbol of each state on the stack
+	nesting []int
+	// constructStart is where the construct being parsed
+	// starts, unless atConstructStart is set
+	constructStart   lexer.Position
+	atConstructStart bool
+}
+
+// newParseState reads the first token and pushes the initial state
+func (p *Parser) newParseState() *parseState {
+	s := &parseState{
+		Parser:           p,
+		result:           &ParseResult{},
+		recoveredAt:      recoveryPoint{line: -1},
+		shiftedMarkers:   make(map[string]bool),
+		spans:            []ast.Span{{}},
+		nesting:          []int{0},
+		atConstructStart: true,
+	}
+	s.token, s.line, s.column = p.nextToken()
+	p.stack.Push(0)
+	return s
+}
+
+// step runs the action of the top of the stack on the
+// lookahead, returning whether the parser goes on
+func (s *parseState) step() bool {
+	topStack, err := s.stack.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	action, opr := s.actionReader.GetAction(lexer.State(topStack.(int)), s.token)
+	switch action {
+	case SHIFT:
+		return s.shift(opr)
+	case REDUCE:
+		return s.reduce(opr)
+	case ACCEPT:
+		if s.tracer != nil {
+			s.tracer.accept(s.stack.Elements(), s.token)
+		}
+		s.result.Accepted = true
+		return false
+	case ERROR:
+		return s.recover(opr)
+	}
+	return true
+}
+
+// shift pushes state with the lookahead and reads the next token
+func (s *parseState) shift(state int) bool {
+	token := s.token
+	if s.tracer != nil {
+		s.tracer.shift(s.stack.Elements(), token, state)
+	}
+	s.stack.Push(state)
+	if uniqueMarkers[tokenSymbol(token)] {
+		s.shiftedMarkers[tokenSymbol(token)] = true
+	}
+	tokenSpan := ast.Span{Start: s.current.Start, End: s.position(s.line, s.column)}
+	if s.runSemantic {
+		s.semantic.Shift(token, tokenSpan.Start)
+	}
+	if !s.pushSymbol(tokenSpan, tokenSymbol(token)) {
+		return false
+	}
+	if s.atConstructStart {
+		s.constructStart = tokenSpan.Start
+	}
+	s.atConstructStart = endsConstruct(token)
+	s.builder.shift(token, tokenSpan.Start, tokenSpan.End)
+	s.events.shift(token, tokenSpan.Start, tokenSpan.End)
+	leading, text := s.source.token(s.current)
+	s.treeBuilder.shift(token, leading, text)
+	s.translator.shift(token, tokenSpan.Start, tokenSpan.End)
+	if len(s.pending) > 0 {
+		s.token, s.line, s.column = s.pending[0].token, s.pending[0].line, s.pending[0].column
+		s.pending = s.pending[1:]
+		return true
+	}
+	s.token, s.line, s.column = s.nextToken()
+	return true
+}
+
+// reduce reduces the rule numbered number
+func (s *parseState) reduce(number int) bool {
+	rule := s.rules.GetRule(number)
+	if !s.quietReductions {
+		fmt.Printf("%s -> %s\n", rule.Left, rule.Right)
+	}
+	if s.tracer != nil {
+		s.tracer.reduce(s.stack.Elements(), s.token, rule)
+	}
+	s.result.Reductions = append(s.result.Reductions, rule)
+	for range rule.Right {
+		s.stack.Pop()
+	}
+	s.spans = reduceSpans(s.spans, len(rule.Right))
+	s.nesting = pushNesting(s.nesting, len(s.nesting)-len(rule.Right), rule.Left)
+	if s.nestingTooDeep(s.nesting) {
+		s.result.Errors = append(s.result.Errors, s.nestingError(s.token, s.line, s.column))
+		return false
+	}
+	top, err := s.stack.Get()
+	if err != nil {
+		panic(err)
+	}
+	s.stack.Push(s.gotoReader.GetGoto(lexer.State(top.(int)), rule.Left))
+	errorhandling.FlushDiagnostics()
+	if s.runSemantic {
+		s.semantic.ExecuteRule(rule, s.line, s.column)
+	}
+	s.builder.reduce(rule)
+	s.treeBuilder.reduce(rule)
+	s.events.reduce(rule)
+	s.translator.reduce(rule)
+	return true
+}
+
+// pushSymbol records where the symbol just pushed onto the stack
+// is on the source, returning false when it is nested too deep
+func (s *parseState) pushSymbol(span ast.Span, symbol string) bool {
+	s.spans = append(s.spans, span)
+	s.nesting = pushNesting(s.nesting, len(s.nesting), symbol)
+	if s.nestingTooDeep(s.nesting) {
+		s.result.Errors = append(s.result.Errors, s.nestingError(s.token, s.line, s.column))
+		return false
+	}
+	return true
+}
+
+// recover reports the syntax error on the lookahead and goes on
+// from it, returning whether the parser goes on. opr is the
+// error number of the action table
+func (s *parseState) recover(opr int) bool {
+	errorhandling.FlushDiagnostics()
+	token, line, column := s.token, s.line, s.column
+	syntaxError := SyntaxError{
+		Line:     line,
+		Column:   column,
+		Span:     ast.Span{Start: s.current.Start, End: s.position(line, column)},
+		Token:    token,
+		Message:  getErrorMessage(opr),
+		Expected: s.expectedTokens(),
+	}
+	marker, markerMissing := s.missingMarker(stackStates(s.stack.Elements()), token, 0)
+	markerRepeated := !markerMissing && s.shiftedMarkers[tokenSymbol(token)]
+	programEnded := !markerMissing && afterProgramEnd(syntaxError.Expected)
+	switch {
+	case markerMissing:
+		syntaxError.Message = missingMarkerMessages[marker]
+	case markerRepeated:
+		syntaxError.Message = fmt.Sprintf("'%s' duplicado", token.GetClass())
+	case programEnded:
+		syntaxError.Message = "conteúdo após o fim do programa"
+	}
+	log.Print(syntaxError)
+	if s.tracer != nil {
+		s.tracer.error(s.stack.Elements(), token, syntaxError.Message)
+	}
+	errorSpan := s.addError(syntaxError)
+	if s.errorLimit > 0 && len(s.result.Errors) >= s.errorLimit {
+		log.Print(ErrorLimitReached)
+		return false
+	}
+	s.treeBuilder.fail()
+	s.translator.fail()
+	// The semantic stack no longer matches the parser
+	// one, so the semantic actions can not go on
+	s.runSemantic = false
+
+	switch {
+	case markerMissing:
+		s.pending = s.pending[:0]
+		for _, class := range sectionMarkers[marker][1:] {
+			s.pending = append(s.pending, recoveryPoint{markerToken(class), line, column})
+		}
+		s.pending = append(s.pending, recoveryPoint{token, line, column})
+		s.token = markerToken(marker)
+		return true
+	case markerRepeated:
+		s.token, s.line, s.column, errorSpan.End = s.skipMarker(token, line, column)
+		return true
+	case programEnded:
+		s.token, s.line, s.column, errorSpan.End = s.skipToEnd(token, line, column)
+		return true
+	}
+
+	// An error on the token the last recovery resumed
+	// from means the parser could not go on from it
+	skipToken := s.recoveredAt == (recoveryPoint{token, line, column})
+	var recoveryStatus RecoveryStatus
+	recoveryStatus, s.token, s.line, s.column, errorSpan.End = panicMode(s.Parser, token, line, column, skipToken)
+	s.recoveredAt = recoveryPoint{s.token, s.line, s.column}
+	popped := s.popSymbols(errorSpan)
+	s.builder.replace(s.stack.GetLength() - 1)
+	s.atConstructStart = true
+	if recoveryStatus == recoveryFail {
+		return false
+	}
+	// The statement popped is kept on the syntax tree as a
+	// BadStmt when the parser can go on as if it was there
+	if next, found := s.badStatementState(s.token); popped && found {
+		s.stack.Push(next)
+		s.spans = append(s.spans, *errorSpan)
+		s.nesting = pushNesting(s.nesting, len(s.nesting), badStatementSymbol)
+		s.builder.replace(s.builder.height(), astItem{span: *errorSpan, value: &ast.BadStmt{Span: *errorSpan}})
+		if s.tracer != nil {
+			s.tracer.push(s.stack.Elements(), badStatementSymbol)
+		}
+	}
+	return true
+}
+
+// addError adds syntaxError to the result, starting its span at
+// the start of the construct it is on, and returns the span, which
+// the recovery extends up to the last token it discards
+func (s *parseState) addError(syntaxError SyntaxError) *ast.Span {
+	if !s.atConstructStart {
+		syntaxError.Span.Start = s.constructStart
+	}
+	s.result.Errors = append(s.result.Errors, syntaxError)
+	s.events.fail(syntaxError)
+	return &s.result.Errors[len(s.result.Errors)-1].Span
+}
+
+// popSymbols drops the spans and nesting levels of the symbols
+// the recovery popped off the stack, returning whether there was
+// any. The error spans them as well, since they belong to the
+// malformed construct
+func (s *parseState) popSymbols(errorSpan *ast.Span) bool {
+	length := s.stack.GetLength()
+	popped := false
+	if length < len(s.spans) {
+		if s.spans[length].Start.Before(errorSpan.Start) {
+			errorSpan.Start = s.spans[length].Start
+		}
+		s.spans = s.spans[:length]
+		popped = true
+	}
+	if length < len(s.nesting) {
+		s.nesting = s.nesting[:length]
+	}
+	return popped
+}
+
+// finish completes the result once the parser stops
+func (s *parseState) finish() *ParseResult {
+	result := s.result
+	result.finishRecovery(!result.Accepted, s.skippedTokens)
+	errorhandling.FlushDiagnostics()
+	if s.tracer != nil {
+		s.tracer.flush()
+	}
+	result.SemanticErrorFound = s.semantic.ErrorFound()
+	result.Program = s.builder.program()
+	result.IOErrors = s.builder.ioErrors
+	for _, ioError := range result.IOErrors {
+		log.Print(ioError)
+	}
+	if len(result.Errors) == 0 {
+		result.Translation = s.translator.result()
+	}
+	result.ParseTree = s.treeBuilder.root()
+	if result.ParseTree != nil {
+		result.ParseTree.Trailing = s.source.trailing()
+	}
+	return result
+}
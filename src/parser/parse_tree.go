@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/dot"
+	"mgol-go/src/lexer"
+)
+
+// ParseTreeNode is a node of the parse tree: a grammar symbol
+// and the symbols it derives, in the order of the rule reduced.
+// Leaves are terminals and hold the token read
+type ParseTreeNode struct {
+	Symbol   string
+	Token    *lexer.Token
+	Children []*ParseTreeNode
+	// Leading and Text are only filled on concrete syntax mode:
+	// the whitespace and comments before the token and the
+	// token as written on the source
+	Leading string
+	Text    string
+	// Trailing holds, on the root, what follows the last token
+	Trailing string
+}
+
+// parseTreeBuilder mirrors the parser stack, building
+// the parse tree bottom-up as the rules are reduced
+type parseTreeBuilder struct {
+	nodes  []*ParseTreeNode
+	failed bool
+}
+
+func (b *parseTreeBuilder) shift(token lexer.Token, leading, text string) {
+	if b.failed {
+		return
+	}
+	b.nodes = append(b.nodes, &ParseTreeNode{Symbol: tokenSymbol(token), Token: &token, Leading: leading, Text: text})
+}
+
+func (b *parseTreeBuilder) reduce(rule Rule) {
+	if b.failed {
+		return
+	}
+	size := len(rule.Right)
+	if size > len(b.nodes) {
+		b.fail()
+		return
+	}
+
+	children := make([]*ParseTreeNode, size)
+	copy(children, b.nodes[len(b.nodes)-size:])
+	b.nodes = append(b.nodes[:len(b.nodes)-size], &ParseTreeNode{Symbol: rule.Left, Children: children})
+}
+
+func (b *parseTreeBuilder) fail() {
+	b.failed = true
+	b.nodes = nil
+}
+
+// root returns the built tree, or nil if it could not be built
+func (b *parseTreeBuilder) root() *ParseTreeNode {
+	if b.failed || len(b.nodes) != 1 {
+		return nil
+	}
+	return b.nodes[0]
+}
+
+func (n *ParseTreeNode) addToGraph(graph *dot.Graph) int {
+	if n.Token != nil {
+		label := n.Symbol
+		if lexem := n.Token.GetLexem(); lexem != "" && lexem != n.Symbol {
+			label = fmt.Sprintf("%s\n%s", n.Symbol, lexem)
+		}
+		return graph.AddNode(label, dot.Box)
+	}
+
+	id := graph.AddNode(n.Symbol, dot.Ellipse)
+	for _, child := range n.Children {
+		graph.AddEdge(id, child.addToGraph(graph), "")
+	}
+	return id
+}
+
+// EncodeDOT writes the tree as a Graphviz graph. Non terminals
+// are ellipses and terminals are boxes labeled with their lexeme
+func (n *ParseTreeNode) EncodeDOT(w io.Writer) error {
+	graph := dot.NewGraph("parse_tree")
+	n.addToGraph(graph)
+	return graph.Write(w)
+}
+
+func (n *ParseTreeNode) writeSource(w io.Writer) error {
+	if n.Token != nil {
+		_, err := io.WriteString(w, n.Leading+n.Text)
+		return err
+	}
+	for _, child := range n.Children {
+		if err := child.writeSource(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSource writes the leading trivia and the text of each token,
+// followed by the trailing trivia. Trees built on concrete syntax
+// mode reproduce the source exactly where they were not modified
+func (n *ParseTreeNode) WriteSource(w io.Writer) error {
+	if err := n.writeSource(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, n.Trailing)
+	return err
+}
+
+// SetConcreteSyntax enables the concrete syntax mode, where the
+// leaves of the parse tree keep the comments and whitespace before
+// each token and the token as written on the source
+func (p *Parser) SetConcreteSyntax(enabled bool) {
+	p.source.enabled = enabled
+}
+
+// SetConcreteSyntax enables the concrete syntax mode, like
+// the one of the SLR parser
+func (p *RecursiveDescentParser) SetConcreteSyntax(enabled bool) {
+	p.source.enabled = enabled
+}
+
+// sourceTracker reads the source of the tokens shifted
+// by a parser, on concrete syntax mode
+type sourceTracker struct {
+	enabled bool
+	// previousSource is the scanner the last token shifted
+	// was read from and previousTokenEnd where it ends
+	previousSource   *lexer.Scanner
+	previousTokenEnd int64
+}
+
+// token returns the trivia before token, which is being
+// shifted, and its text, on concrete syntax mode
+func (t *sourceTracker) token(token lexer.ScannedToken) (string, string) {
+	if !t.enabled {
+		return "", ""
+	}
+
+	leading := ""
+	if t.previousSource != nil && t.previousSource != token.Source {
+		// The token starts another file of the program, so
+		// the rest of the previous file comes before it
+		leading = readSource(t.previousSource, t.previousTokenEnd, -1)
+		t.previousTokenEnd = 0
+	}
+	t.previousSource = token.Source
+
+	start, end := token.StartOffset, token.EndOffset
+	leading += readSource(token.Source, t.previousTokenEnd, start)
+	text := readSource(token.Source, start, end)
+	t.previousTokenEnd = end
+	return leading, text
+}
+
+// trailing returns what follows the last
+// token shifted, on concrete syntax mode
+func (t *sourceTracker) trailing() string {
+	if !t.enabled || t.previousSource == nil {
+		return ""
+	}
+	return readSource(t.previousSource, t.previousTokenEnd, -1)
+}
+
+func readSource(scanner *lexer.Scanner, start, end int64) string {
+	text, err := scanner.ReadSource(start, end)
+	if err != nil {
+		panic(err)
+	}
+	return text
+}
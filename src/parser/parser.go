@@ -2,18 +2,40 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/grammar"
 	"mgol-go/src/lexer"
 	"mgol-go/src/stack"
 )
 
 var (
-	tokensToIgnore = []lexer.Token{
-		lexer.ERROR_TOKEN,
-		lexer.COMMENT_TOKEN,
-	}
+	ErrorSeparateSymbolTable = fmt.Errorf("os arquivos de um programa devem compartilhar a tabela de símbolos")
+	ErrorLimitReached        = fmt.Errorf("muitos erros de sintaxe, a análise foi interrompida")
+	ErrorNestingTooDeep      = fmt.Errorf("aninhamento acima do limite")
 )
 
+// DefaultMaxNesting is how deep parentheses and blocks can be
+// nested unless SetMaxNesting is called
+const DefaultMaxNesting = 100
+
+// nestingSymbols are the symbols that open a level of nesting: a
+// parenthesis, until it is closed, and the header of a conditional
+// or of a loop, until the block after it is reduced
+var nestingSymbols = map[string]bool{
+	"ab_p": true,
+	"CAB":  true,
+	"CABR": true,
+	"CABW": true,
+}
+
+// nestingMessage is the message of the error on nesting above limit
+func nestingMessage(limit int) string {
+	return fmt.Sprintf("%v de %d níveis", ErrorNestingTooDeep, limit)
+}
+
 var errorsMessage = map[int]string{
 	1: "token inesperado",
 	2: "declaração de variáveis mal formada",
@@ -26,98 +48,366 @@ var errorsMessage = map[int]string{
 	9: "parênteses desbalanceados",
 }
 
-var parserErrorFlag = false
+// SyntaxError describes a syntax error found while parsing
+type SyntaxError struct {
+	Line   int
+	Column int
+	// Span goes from the start of the malformed construct
+	// to the last token discarded to recover from it
+	Span    ast.Span
+	Token   lexer.Token
+	Message string
+	// Expected holds the token classes that
+	// would have been accepted instead of Token
+	Expected []string
+}
+
+func (e SyntaxError) String() string {
+	message := fmt.Sprintf("Erro: %v na linha %v, coluna %v", e.Message, e.Line, e.Column)
+	if file := e.Span.Start.File; file != "" {
+		message = fmt.Sprintf("Erro: %v em %s, na linha %v, coluna %v", e.Message, file, e.Line, e.Column)
+	}
+	if len(e.Expected) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s, esperava %s, encontrou %s", message, describeExpected(e.Expected), describeToken(e.Token))
+}
+
+// Diagnostic returns the error as a diagnostic, which is
+// reported and shown like the lexical and semantic ones
+func (e SyntaxError) Diagnostic() errorhandling.Diagnostic {
+	message := e.Message
+	if len(e.Expected) > 0 {
+		message = fmt.Sprintf("%s, esperava %s, encontrou %s", message, describeExpected(e.Expected), describeToken(e.Token))
+	}
+	return errorhandling.NewDiagnostic(errorhandling.Error, e.Line, e.Column, message)
+}
+
+// ParseResult is what the parser found while parsing a source
+type ParseResult struct {
+	// Accepted tells whether the parser reached the accept action
+	Accepted bool
+	// Reductions holds the rules reduced, in the order they were made
+	Reductions []Rule
+	// Errors holds the syntax errors found, in source order
+	Errors []SyntaxError
+	// SemanticErrorFound tells whether the semantic
+	// actions found an error while parsing
+	SemanticErrorFound bool
+	// Program is the syntax tree of the source. After a syntax
+	// error it is partial: the malformed statements are BadStmt
+	// nodes, or are left out when the parser could not tell where
+	// they were. It is nil when the parser gave up before the end
+	// of the source or streamed events
+	Program *ast.Program
+	// ParseTree is the derivation of the source. It is nil when
+	// the parser found a syntax error or streamed events
+	ParseTree *ParseTreeNode
+	// IOErrors holds the errors returned by the I/O
+	// validators, in source order
+	IOErrors []IOError
+	// Recovery tells how the parser went through the syntax errors
+	Recovery RecoveryStats
+	// Translation is the attribute of the start symbol computed by
+	// the translation scheme, nil without one or after a syntax error
+	Translation interface{}
+}
+
+// RecoveryStats tells how the parser went through the syntax
+// errors, so a driver can decide whether the next phases are
+// worth running
+type RecoveryStats struct {
+	// Recovered counts the syntax errors the parser went on from
+	Recovered int
+	// SkippedTokens counts the tokens discarded to recover
+	SkippedTokens int
+	// Aborted tells whether the parser stopped before the end of
+	// the source, on reaching the error limit or failing to recover
+	Aborted bool
+	// Partial tells whether the trees lack some part of the source.
+	// Program then has error nodes and ParseTree, which is not built
+	// after a syntax error, is nil
+	Partial bool
+}
+
+// finishRecovery fills the recovery stats of the result
+func (r *ParseResult) finishRecovery(aborted bool, skippedTokens int) {
+	r.Recovery = RecoveryStats{
+		Recovered:     len(r.Errors),
+		SkippedTokens: skippedTokens,
+		Aborted:       aborted,
+		Partial:       len(r.Errors) > 0,
+	}
+	if aborted && len(r.Errors) > 0 {
+		r.Recovery.Recovered--
+	}
+}
+
+// Succeeded returns whether the source was accepted
+// without any syntax, semantic or I/O validation error
+func (r *ParseResult) Succeeded() bool {
+	return r.Accepted && len(r.Errors) == 0 && !r.SemanticErrorFound && len(r.IOErrors) == 0
+}
 
 type Parser struct {
 	scanner         *lexer.Scanner
+	tokens          *lexer.TokenStream
 	stack           *stack.Stack
 	rules           *RulesMap
 	semantic        *Semantic
+	builder         *astBuilder
+	treeBuilder     *parseTreeBuilder
+	events          eventEmitter
+	translator      translator
 	actionTablePath string
 	gotoTablePath   string
+	actionReader    *ActionReader
+	gotoReader      *GotoReader
+	// runSemantic is false when the grammar is not the
+	// one the semantic actions were written for
+	runSemantic bool
+	tracer      *tracer
+	// current is the last token read from tokens
+	current lexer.ScannedToken
+	// errorLimit is how many syntax errors the parser reports
+	// before giving up, and skippedTokens how many tokens it
+	// discarded to recover from them
+	errorLimit    int
+	skippedTokens int
+	// maxNesting is how deep parentheses and blocks can be nested
+	maxNesting int
+	// source keeps the source text on the parse tree
+	source sourceTracker
+	// quietReductions keeps the reductions off the standard output
+	quietReductions bool
 }
 
 func NewParser(scanner *lexer.Scanner, stack *stack.Stack, rules *RulesMap, actionTablePath, gotoTablePath string) *Parser {
 	return &Parser{
 		scanner:         scanner,
+		tokens:          lexer.NewTokenStream(scanner),
 		stack:           stack,
 		rules:           rules,
 		actionTablePath: actionTablePath,
 		gotoTablePath:   gotoTablePath,
 		semantic:        NewSemantic(scanner.GetSymbolTable()),
+		builder:         newASTBuilder(),
+		treeBuilder:     &parseTreeBuilder{},
+		runSemantic:     true,
+		maxNesting:      DefaultMaxNesting,
+	}
+}
+
+// UseGrammar makes the parser use the rules of g and the SLR tables
+// built from it instead of the ones read from files. The semantic
+// actions and the syntax tree are tied to the rule numbers of
+// grammar.json, so they are disabled: the parser only checks
+// whether the source can be derived from g
+func (p *Parser) UseGrammar(g *grammar.Grammar) []grammar.Conflict {
+	table := g.SLRTable()
+	p.rules = NewRulesMap(g)
+	p.actionReader = NewActionReaderFromRecords(table.ActionRecords())
+	p.gotoReader = NewGotoReaderFromRecords(table.GotoRecords())
+	p.runSemantic = false
+	p.builder.fail()
+	// Only the errors are sent, the other events need the rules
+	p.events.failed = true
+	p.translator.fail()
+	return table.Conflicts
+}
+
+// reduceSpans replaces the spans of the last size symbols
+// of the stack by the span of the symbol reduced from them
+func reduceSpans(spans []ast.Span, size int) []ast.Span {
+	last := len(spans) - 1
+	if size == 0 {
+		return append(spans, ast.Span{Start: spans[last].End, End: spans[last].End})
 	}
+	span := ast.Span{Start: spans[len(spans)-size].Start, End: spans[last].End}
+	return append(spans[:len(spans)-size], span)
+}
+
+// SetErrorLimit makes the parser stop after reporting limit
+// syntax errors. A limit of 0, the default, means no limit
+func (p *Parser) SetErrorLimit(limit int) {
+	p.errorLimit = limit
+}
+
+// SetMaxNesting makes the parser stop with an error on parentheses
+// or blocks nested deeper than limit, so that a malicious source
+// can not make the compiler exhaust the stack. A limit of 0 means
+// no limit
+func (p *Parser) SetMaxNesting(limit int) {
+	p.maxNesting = limit
+}
+
+// UseArena makes the parser allocate the nodes of the syntax tree
+// on arena, which is faster for large programs. By default, or with
+// a nil arena, each node is allocated on its own
+func (p *Parser) UseArena(arena *ast.Arena) {
+	p.builder.arena = arena
 }
 
-// isInTokensToIgnore return whether a token
-// t is in the list of tokens to ignore or not
-func isInTokensToIgnore(t lexer.Token) bool {
-	for _, token := range tokensToIgnore {
-		if t == token {
-			return true
-		}
-	}
-	return false
-}
-
-func (p *Parser) Parse() {
-	token, line, column := p.scanner.Scan()
-	for isInTokensToIgnore(token) {
-		token, line, column = p.scanner.Scan()
-	}
-	p.stack.Push(0)
-
-	actionReader := NewActionReader(p.actionTablePath)
-	gotoReader := NewGotoReader(p.gotoTablePath)
-	for {
-		topStack, err := p.stack.Get()
-		if err != nil {
-			panic(err)
-		}
-
-		state := lexer.State(topStack.(int))
-		action, opr := actionReader.GetAction(state, token)
-		switch action {
-		case SHIFT:
-			p.stack.Push(opr)
-			p.semantic.semanticStack.Push(token)
-			token, line, column = p.scanner.Scan()
-			for isInTokensToIgnore(token) {
-				token, line, column = p.scanner.Scan()
-			}
-		case REDUCE:
-			rule := p.rules.GetRule(opr)
-			fmt.Printf("%s -> %s\n", rule.Left, rule.Right)
-			for range rule.Right {
-				p.stack.Pop()
-			}
-			currentTopElement, err := p.stack.Get()
-			state = lexer.State(currentTopElement.(int))
-			if err != nil {
-				panic(err)
-			}
-			gotoOpr := gotoReader.GetGoto(state, rule.Left)
-			p.stack.Push(gotoOpr)
-			p.semantic.ExecuteRule(rule, line, column)
-		case ACCEPT:
-			goto end_for
-		case ERROR:
-			errorMessage := getErrorMessage(opr)
-			log.Printf("Erro: %v na linha %v, coluna %v", errorMessage, line, column)
-			parserErrorFlag = true
-			recoveryStatus := panicMode(p, token)
-
-			if recoveryStatus == recoveryFail {
-				goto end_for
-			}
-		}
-	}
-end_for:
-	if semanticErrorFlag == false && parserErrorFlag == false {
-		p.semantic.GenerateCode()
-	}
-	// p.semantic.symbolTable.Print()
+// SetTranslation makes the parser run the semantic rules of scheme
+// on each reduction, along with its own semantic actions. The
+// attribute of the start symbol is on ParseResult.Translation
+func (p *Parser) SetTranslation(scheme *TranslationScheme) {
+	p.translator.scheme = scheme
 }
 
+// SetImplicitDeclarations makes the first assignment to an undeclared
+// variable declare it with the type of the value. The semantic
+// actions then write its declaration before the code
+func (p *Parser) SetImplicitDeclarations(enabled bool) {
+	p.semantic.SetImplicitDeclarations(enabled)
+}
+
+// SetDecimalComma makes the generated code write
+// the reals with a comma as the decimal separator
+func (p *Parser) SetDecimalComma(enabled bool) {
+	p.semantic.SetDecimalComma(enabled)
+}
+
+// SetQuietReductions keeps the reductions off the standard output
+func (p *Parser) SetQuietReductions(enabled bool) {
+	p.quietReductions = enabled
+}
+
+// SetSourceComments makes the generated code have each
+// statement of the source as a comment before its code
+func (p *Parser) SetSourceComments(enabled bool) {
+	p.semantic.SetSourceComments(enabled)
+}
+
+// EncodeSourceMap writes as json where the code of each
+// statement starts on the generated code
+func (p *Parser) EncodeSourceMap(w io.Writer) error {
+	return p.semantic.EncodeSourceMap(w)
+}
+
+// SetEventHandler makes the parser stream the parts of the program
+// to handler as it finds them. The trees are not built then, so
+// Program and ParseTree are nil and the I/O validators do not run
+func (p *Parser) SetEventHandler(handler EventHandler) {
+	p.events.handler = handler
+	p.builder.fail()
+	p.treeBuilder.fail()
+}
+
+// pushNesting returns nesting with the nesting level of the
+// symbol pushed onto the stack after the ones of nesting[:size]
+func pushNesting(nesting []int, size int, symbol string) []int {
+	nesting = nesting[:size]
+	level := nesting[size-1]
+	if nestingSymbols[symbol] {
+		level++
+	}
+	return append(nesting, level)
+}
+
+// nestingTooDeep tells whether the symbol on top of
+// the stack is nested deeper than the limit
+func (p *Parser) nestingTooDeep(nesting []int) bool {
+	return p.maxNesting > 0 && nesting[len(nesting)-1] > p.maxNesting
+}
+
+// position returns the position of line and column on the
+// file of the last token read
+func (p *Parser) position(line, column int) lexer.Position {
+	return lexer.Position{File: p.current.Start.File, Line: line, Column: column}
+}
+
+// AddSource makes the parser read the tokens of scanner after the
+// ones of the scanners it has, for programs split among several
+// files. The scanners must share the symbol table
+func (p *Parser) AddSource(scanner *lexer.Scanner) error {
+	if scanner.GetSymbolTable() != p.scanner.GetSymbolTable() {
+		return ErrorSeparateSymbolTable
+	}
+	p.tokens.AddScanner(scanner)
+	return nil
+}
+
+// nextToken consumes the next token of the source,
+// returning it and where it ends
+func (p *Parser) nextToken() (lexer.Token, int, int) {
+	p.current = p.tokens.Next()
+	return p.current.Token, p.current.End.Line, p.current.End.Column
+}
+
+// Parse runs the SLR driver over the tokens of the scanner,
+// executing the semantic actions of each reduction
+func (p *Parser) Parse() *ParseResult {
+	if p.actionReader == nil {
+		p.actionReader = NewActionReader(p.actionTablePath)
+	}
+	if p.gotoReader == nil {
+		p.gotoReader = NewGotoReader(p.gotoTablePath)
+	}
+	s := p.newParseState()
+	for s.step() {
+	}
+	return s.finish()
+}
+
+// badStatementSymbol is the symbol a BadStmt takes the place of
+const badStatementSymbol = "ES"
+
+// badStatementState returns the state reached by going on from
+// the top of the stack with a statement, if the parser can go on
+// from that state with token. The syntax tree must be being built
+func (p *Parser) badStatementState(token lexer.Token) (int, bool) {
+	if p.builder.failed {
+		return 0, false
+	}
+	top, err := p.stack.Get()
+	if err != nil {
+		panic(err)
+	}
+	next := p.gotoReader.GetGoto(lexer.State(top.(int)), badStatementSymbol)
+	if next < 0 {
+		return 0, false
+	}
+	action, _ := p.actionReader.GetAction(lexer.State(next), token)
+	return next, action != ERROR
+}
+
+// nestingError reports that the parser stopped on token,
+// which is nested deeper than the limit
+func (p *Parser) nestingError(token lexer.Token, line, column int) SyntaxError {
+	errorhandling.FlushDiagnostics()
+	syntaxError := SyntaxError{
+		Line:    line,
+		Column:  column,
+		Span:    ast.Span{Start: p.current.Start, End: p.position(line, column)},
+		Token:   token,
+		Message: nestingMessage(p.maxNesting),
+	}
+	log.Print(syntaxError)
+	if p.tracer != nil {
+		p.tracer.error(p.stack.Elements(), token, syntaxError.Message)
+	}
+	p.events.fail(syntaxError)
+	return syntaxError
+}
+
+// GenerateCode writes the code produced by the semantic
+// actions. It should only be called after a successful parse
+func (p *Parser) GenerateCode() {
+	p.semantic.GenerateCode()
+}
+
+// WriteCode writes the code produced by the semantic actions on w
+func (p *Parser) WriteCode(w io.Writer) error {
+	return p.semantic.WriteCode(w)
+}
+
+// getErrorMessage returns the message of the error id found on
+// the action table. Tables without error codes, like the ones
+// built by UseGrammar, fall back to the unexpected token message
 func getErrorMessage(id int) string {
-	return errorsMessage[id]
+	if message, found := errorsMessage[id]; found {
+		return message
+	}
+	return errorsMessage[1]
 }
@@ -1 +1,1029 @@
 package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mgol-go/src/ast"
+	"mgol-go/src/grammar"
+	"mgol-go/src/lexer"
+	"mgol-go/src/stack"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	grammarPath = "./grammar.json"
+)
+
+func newTestParser(t *testing.T, source string) *Parser {
+	return NewParser(newTestScanner(t, source), stack.NewStack(1000), GetRulesMap(grammarPath), actionTablePath, gotoTablePath)
+}
+
+func newTestScanner(t *testing.T, source string) *lexer.Scanner {
+	file, err := ioutil.TempFile("", "parse-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+
+	_, err = file.WriteString(source)
+	require.NoError(t, err)
+
+	file.Seek(0, io.SeekStart)
+
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+
+	return lexer.NewScanner(file, symbolTable)
+}
+
+func TestParse(t *testing.T) {
+	span := func(startLine, startColumn, endLine, endColumn int) ast.Span {
+		return ast.Span{
+			Start: lexer.Position{Line: startLine, Column: startColumn},
+			End:   lexer.Position{Line: endLine, Column: endColumn},
+		}
+	}
+
+	testCases := []struct {
+		name                 string
+		source               string
+		expectedAccepted     bool
+		expectedReductions   int
+		expectedErrors       []SyntaxError
+		expectedSemanticFlag bool
+	}{
+		{
+			name:               "Smallest program",
+			source:             "inicio varinicio varfim; fim",
+			expectedAccepted:   true,
+			expectedReductions: 4,
+			expectedErrors:     nil,
+		},
+		{
+			name:               "Program with declarations and commands",
+			source:             "inicio\nvarinicio\ninteiro A;\nvarfim;\nA <- 1;\nescreva A;\nfim",
+			expectedAccepted:   true,
+			expectedReductions: 16,
+			expectedErrors:     nil,
+		},
+		{
+			name:             "Missing semicolon",
+			source:           "inicio\nvarinicio\ninteiro A\nvarfim;\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     4,
+					Column:   6,
+					Span:     span(3, 1, 4, 6),
+					Token:    lexer.NewToken("varfim", "varfim", "varfim"),
+					Message:  "declaração de variáveis mal formada",
+					Expected: []string{"pt_v"},
+				},
+			},
+		},
+		{
+			name:             "Errors after the first one are still reported",
+			source:           "inicio\nvarinicio\ninteiro A;\nvarfim;\nA <- A A;\nleia ;\nescreva A;\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     5,
+					Column:   8,
+					Span:     span(5, 1, 5, 8),
+					Token:    lexer.NewToken(lexer.IDENTIFIER, "A", lexer.INTEGER),
+					Message:  "expressão inválida",
+					Expected: []string{"pt_v", "opm", "opmul"},
+				},
+				{
+					Line:     6,
+					Column:   6,
+					Span:     span(6, 1, 6, 6),
+					Token:    lexer.NewToken(lexer.SEMICOLON, ";", lexer.NULL),
+					Message:  "operação de entrada e saída inválida",
+					Expected: []string{"id"},
+				},
+			},
+		},
+		{
+			name:             "Error inside a conditional",
+			source:           "inicio\nvarinicio\ninteiro A;\nvarfim;\nse (A > ) entao\nA <- 1;\nfimse\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     5,
+					Column:   9,
+					Span:     span(5, 1, 7, 5),
+					Token:    lexer.NewToken(lexer.CLOSE_PAR, ")", lexer.NULL),
+					Message:  "expressão inválida",
+					Expected: []string{"id", "num", "ab_p"},
+				},
+			},
+		},
+		{
+			name:             "Loop without fimrepita",
+			source:           "inicio\nvarinicio\ninteiro A;\nvarfim;\nrepita (A > 0)\nA <- A - 1;\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     7,
+					Column:   3,
+					Span:     span(7, 1, 7, 3),
+					Token:    lexer.NewToken("fim", "fim", "fim"),
+					Message:  "estrutura de repetição sem fimrepita",
+					Expected: []string{"id", "leia", "escreva", "se", "repita", "fimrepita", "enquanto"},
+				},
+			},
+		},
+		{
+			name:             "Loop without fimenquanto",
+			source:           "inicio\nvarinicio\ninteiro A;\nvarfim;\nenquanto (A > 0)\nA <- A - 1;\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     7,
+					Column:   3,
+					Span:     span(7, 1, 7, 3),
+					Token:    lexer.NewToken("fim", "fim", "fim"),
+					Message:  "estrutura de repetição sem fimenquanto",
+					Expected: []string{"id", "leia", "escreva", "se", "repita", "enquanto", "fimenquanto"},
+				},
+			},
+		},
+		{
+			name:             "Nested blocks left open",
+			source:           "inicio\nvarinicio\ninteiro A;\nvarfim;\nse (A > 0) entao\nrepita (A > 0)\nse (A > 1) entao\nleia A;\nfimse\nfim",
+			expectedAccepted: true,
+			expectedErrors: []SyntaxError{
+				{
+					Line:     10,
+					Column:   3,
+					Span:     span(10, 1, 10, 3),
+					Token:    lexer.NewToken("fim", "fim", "fim"),
+					Message:  "estrutura de repetição sem fimrepita",
+					Expected: []string{"id", "leia", "escreva", "se", "repita", "fimrepita", "enquanto"},
+				},
+				{
+					Line:     10,
+					Column:   3,
+					Span:     span(10, 1, 10, 3),
+					Token:    lexer.NewToken("fim", "fim", "fim"),
+					Message:  "estrutura condicional sem fimse",
+					Expected: []string{"id", "leia", "escreva", "se", "fimse", "repita", "senao", "enquanto"},
+				},
+			},
+		},
+		{
+			name:                 "Undeclared variable",
+			source:               "inicio varinicio varfim; B <- 1; fim",
+			expectedAccepted:     true,
+			expectedErrors:       nil,
+			expectedSemanticFlag: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newTestParser(t, tc.source).Parse()
+
+			require.Equal(t, tc.expectedAccepted, result.Accepted)
+			require.Equal(t, tc.expectedErrors, result.Errors)
+			require.Equal(t, tc.expectedSemanticFlag, result.SemanticErrorFound)
+			if tc.expectedReductions > 0 {
+				require.Equal(t, tc.expectedReductions, len(result.Reductions))
+				require.Equal(t, "P", result.Reductions[len(result.Reductions)-1].Left)
+			}
+		})
+	}
+}
+
+func TestParseProgramStructure(t *testing.T) {
+	testCases := []struct {
+		name            string
+		source          string
+		expectedLine    int
+		expectedColumn  int
+		expectedMessage string
+	}{
+		{
+			name:            "Missing inicio",
+			source:          "varinicio\ninteiro A;\nvarfim;\nleia A;\nfim",
+			expectedLine:    1,
+			expectedColumn:  9,
+			expectedMessage: "programa sem inicio",
+		},
+		{
+			name:            "Missing varinicio",
+			source:          "inicio\ninteiro A;\nvarfim;\nleia A;\nfim",
+			expectedLine:    2,
+			expectedColumn:  7,
+			expectedMessage: "declaração de variáveis sem varinicio",
+		},
+		{
+			name:            "Missing varfim",
+			source:          "inicio\nvarinicio\ninteiro A;\nleia A;\nfim",
+			expectedLine:    4,
+			expectedColumn:  4,
+			expectedMessage: "declaração de variáveis sem varfim",
+		},
+		{
+			name:            "Missing fim",
+			source:          "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\n",
+			expectedLine:    6,
+			expectedColumn:  1,
+			expectedMessage: "programa sem fim",
+		},
+		{
+			name:            "Duplicated inicio",
+			source:          "inicio\ninicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\nfim",
+			expectedLine:    2,
+			expectedColumn:  6,
+			expectedMessage: "'inicio' duplicado",
+		},
+		{
+			name:            "Duplicated varinicio",
+			source:          "inicio\nvarinicio\ninteiro A;\nvarfim;\nvarinicio\nleia A;\nfim",
+			expectedLine:    5,
+			expectedColumn:  9,
+			expectedMessage: "'varinicio' duplicado",
+		},
+		{
+			name:            "Duplicated varfim",
+			source:          "inicio\nvarinicio\ninteiro A;\nvarfim;\nvarfim;\nleia A;\nfim",
+			expectedLine:    5,
+			expectedColumn:  6,
+			expectedMessage: "'varfim' duplicado",
+		},
+		{
+			name:            "Tokens after fim",
+			source:          "inicio\nvarinicio\ninteiro A;\nvarfim;\nfim\nleia A;\nfim",
+			expectedLine:    6,
+			expectedColumn:  4,
+			expectedMessage: "conteúdo após o fim do programa",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newTestParser(t, tc.source).Parse()
+
+			// The parser goes on as if the program was well formed
+			require.True(t, result.Accepted)
+			require.Len(t, result.Errors, 1)
+			require.Equal(t, tc.expectedLine, result.Errors[0].Line)
+			require.Equal(t, tc.expectedColumn, result.Errors[0].Column)
+			require.Equal(t, tc.expectedMessage, result.Errors[0].Message)
+		})
+	}
+}
+
+func TestSyntaxErrorSpan(t *testing.T) {
+	testCases := []struct {
+		name         string
+		source       string
+		expectedSpan string
+	}{
+		{
+			name:         "Duplicated marker and the semicolon after it",
+			source:       "inicio\nvarinicio\ninteiro A;\nvarfim;\nvarfim;\nleia A;\nfim",
+			expectedSpan: "5:1-5:7",
+		},
+		{
+			name:         "Every token after fim",
+			source:       "inicio\nvarinicio\ninteiro A;\nvarfim;\nfim\nleia A;\nescreva A;\nfim",
+			expectedSpan: "6:1-8:3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newTestParser(t, tc.source).Parse()
+			require.Len(t, result.Errors, 1)
+			span := result.Errors[0].Span
+			require.Equal(t, tc.expectedSpan, fmt.Sprintf("%s-%s", span.Start, span.End))
+		})
+	}
+}
+
+func TestParseBuildsTree(t *testing.T) {
+	pos := func(line, column int) lexer.Position {
+		return lexer.Position{Line: line, Column: column}
+	}
+	span := func(startLine, startColumn, endLine, endColumn int) ast.Span {
+		return ast.Span{Start: pos(startLine, startColumn), End: pos(endLine, endColumn)}
+	}
+
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\nse (A > 1) entao\nA <- A + 2;\nfimse\nescreva \"ok\";\nfim"
+	result := newTestParser(t, source).Parse()
+	require.True(t, result.Succeeded())
+
+	expected := &ast.Program{
+		Span: span(1, 1, 10, 3),
+		Declarations: []*ast.VarDecl{
+			{Span: span(3, 1, 3, 10), Type: lexer.INTEGER, Name: &ast.Ident{Span: span(3, 9, 3, 9), Name: "A"}},
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Span: span(5, 1, 5, 7), Target: &ast.Ident{Span: span(5, 6, 5, 6), Name: "A"}},
+			&ast.If{
+				Span: span(6, 1, 8, 5),
+				Condition: &ast.BinaryExpr{
+					Span:     span(6, 5, 6, 9),
+					Operator: ">",
+					Left:     &ast.Ident{Span: span(6, 5, 6, 5), Name: "A"},
+					Right:    &ast.Literal{Span: span(6, 9, 6, 9), Value: "1", Type: lexer.INTEGER},
+				},
+				Body: []ast.Stmt{
+					&ast.Assign{
+						Span:   span(7, 1, 7, 11),
+						Target: &ast.Ident{Span: span(7, 1, 7, 1), Name: "A"},
+						Value: &ast.BinaryExpr{
+							Span:     span(7, 6, 7, 10),
+							Operator: "+",
+							Left:     &ast.Ident{Span: span(7, 6, 7, 6), Name: "A"},
+							Right:    &ast.Literal{Span: span(7, 10, 7, 10), Value: "2", Type: lexer.INTEGER},
+						},
+					},
+				},
+			},
+			&ast.Write{Span: span(9, 1, 9, 13), Value: &ast.Literal{Span: span(9, 9, 9, 12), Value: "\"ok\"", Type: lexer.LITERAL}},
+		},
+	}
+	require.Equal(t, expected, result.Program)
+
+	// The malformed declaration is left out of the partial tree
+	result = newTestParser(t, "inicio\nvarinicio\ninteiro A\nvarfim;\nfim").Parse()
+	require.NotNil(t, result.Program)
+	require.Empty(t, result.Program.Declarations)
+}
+
+func TestParsePartialTree(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         string
+		expectedSource string
+	}{
+		{
+			name:   "Malformed statement",
+			source: "inicio varinicio inteiro A; varfim; leia A; leia ; escreva A; fim",
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"leia A;\n{ erro de sintaxe }\nescreva A;\nfim\n",
+		},
+		{
+			name:   "Malformed expression",
+			source: "inicio varinicio inteiro A; varfim; A <- (A + 1; escreva A; fim",
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"{ erro de sintaxe }\nescreva A;\nfim\n",
+		},
+		{
+			name:   "Malformed statement in a block",
+			source: "inicio varinicio inteiro A; varfim; se (A > 1) entao leia ; senao escreva A; fimse fim",
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"se (A > 1) entao\n\t{ erro de sintaxe }\nsenao\n\tescreva A;\nfimse\nfim\n",
+		},
+		{
+			name:   "Missing fimrepita",
+			source: "inicio varinicio inteiro A; varfim; repita (A > 1) leia A; fim",
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"repita (A > 1)\n\tleia A;\nfimrepita\nfim\n",
+		},
+		{
+			name:   "Missing inicio",
+			source: "varinicio inteiro A; varfim; leia A; fim",
+			expectedSource: "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+				"leia A;\nfim\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		analyzers := map[string]Analyzer{
+			"slr":         newTestParser(t, tc.source),
+			"descendente": newTestDescentParser(t, tc.source),
+		}
+		for name, analyzer := range analyzers {
+			t.Run(tc.name+" "+name, func(t *testing.T) {
+				result := analyzer.Parse()
+				require.NotEmpty(t, result.Errors)
+				require.True(t, result.Recovery.Partial)
+				require.Nil(t, result.ParseTree)
+
+				output := &bytes.Buffer{}
+				require.NoError(t, ast.Fprint(output, result.Program))
+				require.Equal(t, tc.expectedSource, output.String())
+			})
+		}
+	}
+
+	// The BadStmt covers the malformed statement
+	result := newTestParser(t, "inicio varinicio varfim;\nleia ;\nfim").Parse()
+	bad := result.Program.Body[0].(*ast.BadStmt)
+	require.Equal(t, ast.Span{Start: lexer.Position{Line: 2, Column: 1}, End: lexer.Position{Line: 2, Column: 6}}, bad.Span)
+}
+
+func TestParseWithGrammar(t *testing.T) {
+	// The same grammar without the declarations block
+	g, err := grammar.ReadBNF(strings.NewReader(`
+		P' -> P
+		P  -> inicio A
+		A  -> leia id pt_v A | fim
+	`))
+	require.NoError(t, err)
+
+	parser := newTestParser(t, "inicio leia A; fim")
+	require.Empty(t, parser.UseGrammar(g))
+	result := parser.Parse()
+	require.True(t, result.Succeeded())
+	require.Equal(t, 3, len(result.Reductions))
+	require.Nil(t, result.Program)
+
+	parser = newTestParser(t, "inicio varinicio varfim; fim")
+	parser.UseGrammar(g)
+	result = parser.Parse()
+	require.False(t, result.Succeeded())
+	require.Equal(t, "token inesperado", result.Errors[0].Message)
+}
+
+func TestParseTrace(t *testing.T) {
+	output := &bytes.Buffer{}
+	parser := newTestParser(t, "inicio varinicio varfim; fim")
+	parser.SetTrace(output)
+	parser.Parse()
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	require.Equal(t, 11, len(lines))
+	require.Equal(t, []string{"Passo", "Pilha", "Entrada", "Ação"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"1", "0", "inicio", "empilha", "2"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"5", "0", "inicio", "2", "varinicio", "4", "varfim", "22", "pt_v", "64", "fim", "reduz", "LV", "->", "varfim", "pt_v"}, strings.Fields(lines[5]))
+	require.Equal(t, []string{"10", "0", "P", "1", "$", "aceita"}, strings.Fields(lines[10]))
+}
+
+// exprString shows an expression with every binary
+// operation enclosed in parentheses
+func exprString(expr ast.Expr) string {
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		return fmt.Sprintf("(%s %s %s)", exprString(node.Left), node.Operator, exprString(node.Right))
+	case *ast.Ident:
+		return node.Name
+	case *ast.Literal:
+		return node.Value
+	default:
+		return "?"
+	}
+}
+
+func TestParseExpressions(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		expectedExpr string
+		expectedCode string
+	}{
+		{
+			name:         "Multiplication binds tighter than addition",
+			body:         "A <- 1 + 2 * A;",
+			expectedExpr: "(1 + (2 * A))",
+			expectedCode: "T0 = 2 * A;\nT0 = 1 + T0;\nA = T0;\n",
+		},
+		{
+			name:         "Operators of the same precedence are left associative",
+			body:         "A <- A - 1 - 2;",
+			expectedExpr: "((A - 1) - 2)",
+			expectedCode: "T0 = A - 1;\nT0 = T0 - 2;\nA = T0;\n",
+		},
+		{
+			name:         "Parentheses",
+			body:         "A <- (1 + 2) / (A);",
+			expectedExpr: "((1 + 2) / A)",
+			expectedCode: "T0 = 1 + 2;\nT0 = T0 / A;\nA = T0;\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestParser(t, "inicio\nvarinicio\ninteiro A;\nvarfim;\n"+tc.body+"\nfim")
+			result := p.Parse()
+			require.True(t, result.Succeeded())
+			require.Equal(t, tc.expectedExpr, exprString(result.Program.Body[0].(*ast.Assign).Value))
+			require.Equal(t, tc.expectedCode, p.semantic.codeBuffer.Code()[len("int A;\n"):])
+		})
+	}
+}
+
+func TestParseWriteExpressions(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		expectedExpr string
+		expectedCode string
+	}{
+		{
+			name:         "Variable",
+			body:         "escreva A;",
+			expectedExpr: "A",
+			expectedCode: "escreva_inteiro(A);\n",
+		},
+		{
+			name:         "Integer expression",
+			body:         "escreva A * 2 + 1;",
+			expectedExpr: "((A * 2) + 1)",
+			expectedCode: "T0 = A * 2;\nT0 = T0 + 1;\nescreva_inteiro(T0);\n",
+		},
+		{
+			name:         "Real expression",
+			body:         "escreva B / 2.0;",
+			expectedExpr: "(B / 2.0)",
+			expectedCode: "T0 = B / 2.0;\nescreva_real(T0);\n",
+		},
+		{
+			name:         "Parenthesized variable",
+			body:         "escreva (A);",
+			expectedExpr: "A",
+			expectedCode: "escreva_inteiro(A);\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source := "inicio\nvarinicio\ninteiro A;\nreal B;\nvarfim;\n" + tc.body + "\nfim"
+			slrParser := newTestParser(t, source)
+			descentParser := newTestDescentParser(t, source)
+			for _, result := range []*ParseResult{slrParser.Parse(), descentParser.Parse()} {
+				require.True(t, result.Succeeded())
+				require.Empty(t, result.IOErrors)
+				require.Equal(t, tc.expectedExpr, exprString(result.Program.Body[0].(*ast.Write).Value))
+			}
+			require.Equal(t, tc.expectedCode, slrParser.semantic.codeBuffer.Code()[len("int A;\ndouble B;\n"):])
+			require.Equal(t, slrParser.semantic.codeBuffer.Code(), descentParser.semantic.codeBuffer.Code())
+		})
+	}
+}
+
+func TestParseImplicitDeclarations(t *testing.T) {
+	// The token after each assignment is read before it is reduced,
+	// so A and B are read ahead before they have a type
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\n" +
+		"B <- 1.5;\nB <- B * 2;\nC <- A + 1;\nC <- C - 1;\nescreva B;\nfim"
+	expectedDeclarations := "/*----Variaveis declaradas no primeiro uso----*/\n" +
+		"double B;\nint C;\n" +
+		"/*------------------------------*/\n"
+
+	parsers := map[string]Analyzer{"slr": newTestParser(t, source), "descendente": newTestDescentParser(t, source)}
+	for name, analyzer := range parsers {
+		t.Run(name, func(t *testing.T) {
+			analyzer.SetImplicitDeclarations(true)
+			result := analyzer.Parse()
+			require.True(t, result.Succeeded())
+			require.False(t, result.SemanticErrorFound)
+
+			var semantic *Semantic
+			switch p := analyzer.(type) {
+			case *Parser:
+				semantic = p.semantic
+			case *RecursiveDescentParser:
+				semantic = p.semantic
+			}
+			require.Equal(t, expectedDeclarations, semantic.codeBuffer.PrintDeclarations())
+			require.Equal(t, "int A;\nB = 1.5;\nT0 = B * 2;\nB = T0;\nT1 = A + 1;\nC = T1;\nT1 = C - 1;\nC = T1;\nescreva_real(B);\n", semantic.codeBuffer.Code())
+		})
+	}
+
+	// Without them, the first assignment to B is an error
+	p := newTestParser(t, source)
+	require.True(t, p.Parse().SemanticErrorFound)
+	require.Empty(t, p.semantic.codeBuffer.PrintDeclarations())
+}
+
+func TestParseConditionals(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\n" +
+		"se (A > 1) entao\nescreva \"a\";\nsenao\n" +
+		"se (A < 0) entao escreva \"b\"; senao escreva \"c\"; fimse\n" +
+		"fimse\n" +
+		"se (A <> 0) entao leia A; fimse\nfim"
+	p := newTestParser(t, source)
+	result := p.Parse()
+	require.True(t, result.Succeeded())
+
+	require.Len(t, result.Program.Body, 2)
+	outer := result.Program.Body[0].(*ast.If)
+	require.Len(t, outer.Body, 1)
+	require.Len(t, outer.Else, 1)
+	inner := outer.Else[0].(*ast.If)
+	require.Equal(t, "(A < 0)", exprString(inner.Condition))
+	require.Len(t, inner.Body, 1)
+	require.Len(t, inner.Else, 1)
+	require.Nil(t, result.Program.Body[1].(*ast.If).Else)
+
+	expected := "int A;\n" +
+		"T0 = A > 1;\nif (T0) {\nescreva_literal(\"a\");\n} else {\n" +
+		"T0 = A < 0;\nif (T0) {\nescreva_literal(\"b\");\n} else {\nescreva_literal(\"c\");\n}\n" +
+		"}\n" +
+		"T0 = A < 0 || A > 0;\nif (T0) {\nleia_inteiro(&A);\n}\n"
+	require.Equal(t, expected, p.semantic.codeBuffer.Code())
+}
+
+func TestParseRepitaConditionCode(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\n" +
+		"repita (A + 1 < 10 * 2)\nA <- A + 1;\nfimrepita\nfim"
+	p := newTestParser(t, source)
+	require.True(t, p.Parse().Succeeded())
+
+	// The loop evaluates its whole condition again before closing
+	expected := "int A;\n" +
+		"T0 = A + 1;\nT1 = 10 * 2;\nT2 = T0 < T1;\nwhile (T2) {\n" +
+		"T1 = A + 1;\nA = T1;\n" +
+		"T0 = A + 1;\nT1 = 10 * 2;\nT2 = T0 < T1;\n}\n"
+	require.Equal(t, expected, p.semantic.codeBuffer.Code())
+}
+
+func TestTemporalsAreReused(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nreal B;\nvarfim;\n" +
+		"A <- ((A + 1) * (A + 2)) + ((A + 3) * (A + 4));\nB <- B * 2.0 + B;\nA <- A + 1;\nfim"
+	p := newTestParser(t, source)
+	require.True(t, p.Parse().Succeeded())
+
+	// Only the operands whose value is still to be read keep
+	// their temporals, and each type has its own temporals
+	expected := "int A;\ndouble B;\n" +
+		"T0 = A + 1;\nT1 = A + 2;\nT1 = T0 * T1;\nT0 = A + 3;\nT2 = A + 4;\nT2 = T0 * T2;\nT2 = T1 + T2;\nA = T2;\n" +
+		"T3 = B * 2.0;\nT3 = T3 + B;\nB = T3;\n" +
+		"T2 = A + 1;\nA = T2;\n"
+	require.Equal(t, expected, p.semantic.codeBuffer.Code())
+	require.Equal(t, []TemporalType{TemporalInt, TemporalInt, TemporalInt, TemporalFloat}, p.semantic.codeBuffer.temporals)
+}
+
+func TestParseNestedLoops(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\n" +
+		"repita (A > 0)\nrepita (A > 5)\nA <- A - 1;\nfimrepita\nA <- A - 1;\nfimrepita\nfim"
+	p := newTestParser(t, source)
+	result := p.Parse()
+	require.True(t, result.Succeeded())
+
+	outer := result.Program.Body[0].(*ast.While)
+	require.Len(t, outer.Body, 2)
+	require.Equal(t, "(A > 5)", exprString(outer.Body[0].(*ast.While).Condition))
+
+	// Each loop evaluates its own condition again before closing, so the
+	// inner one can reuse the temporal of the outer condition meanwhile
+	expected := "int A;\n" +
+		"T0 = A > 0;\nwhile (T0) {\n" +
+		"T0 = A > 5;\nwhile (T0) {\n" +
+		"T1 = A - 1;\nA = T1;\n" +
+		"T0 = A > 5;\n}\n" +
+		"T1 = A - 1;\nA = T1;\n" +
+		"T0 = A > 0;\n}\n"
+	require.Equal(t, expected, p.semantic.codeBuffer.Code())
+}
+
+func TestSourceComments(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\n" +
+		"repita (A > 0)\n  se (A = 2) entao escreva \"*/\"; senao A <- (A + 1) * 2; fimse\n" +
+		"  A <- A - 3;\nfimrepita\nfim"
+	analyzers := map[string]Analyzer{
+		"slr":         newTestParser(t, source),
+		"descendente": newTestDescentParser(t, source),
+	}
+	for name, analyzer := range analyzers {
+		t.Run(name, func(t *testing.T) {
+			analyzer.SetSourceComments(true)
+			require.True(t, analyzer.Parse().Succeeded())
+
+			var semantic *Semantic
+			switch p := analyzer.(type) {
+			case *Parser:
+				semantic = p.semantic
+			case *RecursiveDescentParser:
+				semantic = p.semantic
+			}
+			code := semantic.Source()
+			temporals := "/*------------------------------*/\n"
+			require.Equal(t, "int A;\n"+
+				"/* linha 5: leia A */\nleia_inteiro(&A);\n"+
+				"/* linha 6: repita (A > 0) */\nT0 = A > 0;\nwhile (T0) {\n"+
+				"/* linha 7: se (A = 2) entao */\nT0 = A == 2;\nif (T0) {\n"+
+				"/* linha 7: escreva \"* /\" */\nescreva_literal(\"*/\");\n} else {\n"+
+				"/* linha 7: A <- (A + 1) * 2 */\nT1 = A + 1;\nT1 = T1 * 2;\nA = T1;\n}\n"+
+				"/* linha 8: A <- A - 3 */\nT1 = A - 3;\nA = T1;\nT0 = A > 0;\n}\n"+
+				"return 0;\n}\n", code[strings.LastIndex(code, temporals)+len(temporals):])
+
+			// Each line on the map is the first of the code of its
+			// statement, the one after the comment
+			lines := strings.Split(code, "\n")
+			sourceMap := semantic.SourceMap()
+			require.Len(t, sourceMap, 6)
+			for _, sourceLine := range sourceMap {
+				comment := fmt.Sprintf("/* linha %d: %s */", sourceLine.Source.Line, strings.ReplaceAll(sourceLine.Text, "*/", "* /"))
+				require.Equal(t, comment, lines[sourceLine.Generated-2])
+			}
+			require.Equal(t, lexer.Position{Line: 7, Column: 3}, sourceMap[2].Source)
+		})
+	}
+}
+
+func TestWriteCode(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\nescreva A;\nfim"
+	p := newTestParser(t, source)
+	require.True(t, p.Parse().Succeeded())
+
+	var code bytes.Buffer
+	require.NoError(t, p.WriteCode(&code))
+	require.Equal(t, p.semantic.Source(), code.String())
+	require.Contains(t, code.String(), "int A;\nleia_inteiro(&A);\nescreva_inteiro(A);\nreturn 0;\n}\n")
+}
+
+func TestSyntaxErrorString(t *testing.T) {
+	testCases := []struct {
+		name           string
+		syntaxError    SyntaxError
+		expectedString string
+	}{
+		{
+			name:           "Without expected tokens",
+			syntaxError:    SyntaxError{Line: 1, Column: 2, Token: lexer.EOF_TOKEN, Message: "token inesperado"},
+			expectedString: "Erro: token inesperado na linha 1, coluna 2",
+		},
+		{
+			name: "Identifier found",
+			syntaxError: SyntaxError{
+				Line:     3,
+				Column:   10,
+				Token:    lexer.NewToken(lexer.IDENTIFIER, "x", lexer.NULL),
+				Message:  "estrutura condicional mal formada",
+				Expected: []string{"pt_v", "entao"},
+			},
+			expectedString: "Erro: estrutura condicional mal formada na linha 3, coluna 10, esperava ';' ou 'entao', encontrou identificador 'x'",
+		},
+		{
+			name: "End of file found",
+			syntaxError: SyntaxError{
+				Line:     5,
+				Column:   1,
+				Token:    lexer.EOF_TOKEN,
+				Message:  "token inesperado",
+				Expected: []string{"id", "leia", "fim"},
+			},
+			expectedString: "Erro: token inesperado na linha 5, coluna 1, esperava identificador, 'leia' ou 'fim', encontrou fim de arquivo",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedString, tc.syntaxError.String())
+		})
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	leaf := func(class lexer.TokenClass, lexem string) *ParseTreeNode {
+		token := lexer.NewToken(class, lexem, lexer.DataType(lexem))
+		return &ParseTreeNode{Symbol: string(class), Token: &token}
+	}
+	node := func(symbol string, children ...*ParseTreeNode) *ParseTreeNode {
+		return &ParseTreeNode{Symbol: symbol, Children: children}
+	}
+
+	result := newTestParser(t, "inicio varinicio varfim; fim").Parse()
+	semicolon := lexer.NewToken(lexer.SEMICOLON, ";", lexer.NULL)
+	require.Equal(t, node("P",
+		leaf("inicio", "inicio"),
+		node("V", leaf("varinicio", "varinicio"), node("LV", leaf("varfim", "varfim"), &ParseTreeNode{Symbol: "pt_v", Token: &semicolon})),
+		node("A", leaf("fim", "fim")),
+	), result.ParseTree)
+
+	output := &bytes.Buffer{}
+	require.NoError(t, result.ParseTree.EncodeDOT(output))
+	require.Contains(t, output.String(), "n6 [label=\"pt_v\\n;\", shape=box];\n")
+	require.Contains(t, output.String(), "n4 -> n6;\n")
+
+	result = newTestParser(t, "inicio varinicio varfim fim").Parse()
+	require.Nil(t, result.ParseTree)
+}
+
+func TestParseConcreteSyntax(t *testing.T) {
+	source := "{ programa }\ninicio\n  varinicio\n\tinteiro A; {contador}\n  varfim;\n  A<-A  +1;\nfim\n{ fim do arquivo }\n"
+
+	parser := newTestParser(t, source)
+	parser.SetConcreteSyntax(true)
+	result := parser.Parse()
+	require.NotNil(t, result.ParseTree)
+
+	output := &bytes.Buffer{}
+	require.NoError(t, result.ParseTree.WriteSource(output))
+	require.Equal(t, source, output.String())
+
+	leaves := []*ParseTreeNode{}
+	var collect func(node *ParseTreeNode)
+	collect = func(node *ParseTreeNode) {
+		if node.Token != nil {
+			leaves = append(leaves, node)
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(result.ParseTree)
+
+	require.Equal(t, "{ programa }\n", leaves[0].Leading)
+	require.Equal(t, "inicio", leaves[0].Text)
+	require.Equal(t, " {contador}\n  ", leaves[5].Leading)
+	require.Equal(t, "varfim", leaves[5].Text)
+	require.Equal(t, "<-", leaves[8].Text)
+	require.Equal(t, "\n{ fim do arquivo }\n", result.ParseTree.Trailing)
+
+	// Without the mode the tree keeps only the tokens
+	result = newTestParser(t, source).Parse()
+	require.Equal(t, "", result.ParseTree.Children[0].Leading)
+	require.Equal(t, "", result.ParseTree.Children[0].Text)
+}
+
+func TestFprintRoundTrip(t *testing.T) {
+	// The accepted programs on testdata are written in canonical form
+	for _, name := range []string{"declarations", "expressions", "conditionals", "loops"} {
+		t.Run(name, func(t *testing.T) {
+			source, err := ioutil.ReadFile(filepath.Join("testdata", name+".mgol"))
+			require.NoError(t, err)
+			result := newTestParser(t, string(source)).Parse()
+			require.True(t, result.Succeeded())
+
+			output := &bytes.Buffer{}
+			require.NoError(t, ast.Fprint(output, result.Program))
+			require.Equal(t, string(source), output.String())
+		})
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	position := func(line, column int) lexer.Position {
+		return lexer.Position{Line: line, Column: column}
+	}
+
+	source := "inicio\nvarinicio\n\tinteiro A;\nvarfim;\n" +
+		"se (A > 1) entao\n\tA <- (A + 1) * 2;\nsenao\n\tleia A;\nfimse\nfim"
+	result := newTestParser(t, source).Parse()
+	require.True(t, result.Succeeded())
+
+	program := result.Program
+	require.Equal(t, position(1, 1), program.Pos())
+	require.Equal(t, position(10, 3), program.End())
+	declaration := program.Declarations[0]
+	require.Equal(t, position(3, 2), declaration.Pos())
+	require.Equal(t, position(3, 11), declaration.End())
+
+	// The keywords and the terminators belong to the statements
+	conditional := program.Body[0].(*ast.If)
+	require.Equal(t, position(5, 1), conditional.Pos())
+	require.Equal(t, position(9, 5), conditional.End())
+	assign := conditional.Body[0].(*ast.Assign)
+	require.Equal(t, position(6, 2), assign.Pos())
+	require.Equal(t, position(6, 18), assign.End())
+	read := conditional.Else[0].(*ast.Read)
+	require.Equal(t, position(8, 2), read.Pos())
+	require.Equal(t, position(8, 8), read.End())
+
+	multiplication := assign.Value.(*ast.BinaryExpr)
+	require.Equal(t, position(6, 7), multiplication.Pos())
+	require.Equal(t, position(6, 13), multiplication.Left.End())
+
+	// Every node lies within its parent
+	parents := []ast.Node{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+		if len(parents) > 0 {
+			parent := parents[len(parents)-1]
+			require.False(t, node.Pos().Before(parent.Pos()), "%T starts before %T", node, parent)
+			require.False(t, parent.End().Before(node.End()), "%T ends after %T", node, parent)
+		}
+		parents = append(parents, node)
+		return true
+	})
+}
+
+func TestParseSeveralFiles(t *testing.T) {
+	newSources := func(files ...string) []*lexer.Scanner {
+		symbolTable := lexer.NewSymbolTable()
+		symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+		scanners := []*lexer.Scanner{}
+		for idx, text := range files {
+			scanner := lexer.NewStringScanner(text, symbolTable)
+			scanner.SetName(fmt.Sprintf("arquivo%d.mgol", idx+1))
+			scanners = append(scanners, scanner)
+		}
+		return scanners
+	}
+	newAnalyzers := func(files ...string) map[string]Analyzer {
+		slrSources := newSources(files...)
+		descentSources := newSources(files...)
+		analyzers := map[string]Analyzer{
+			"slr":         NewParser(slrSources[0], stack.NewStack(1000), GetRulesMap(grammarPath), actionTablePath, gotoTablePath),
+			"descendente": NewRecursiveDescentParser(descentSources[0], GetRulesMap(grammarPath)),
+		}
+		for idx := 1; idx < len(files); idx++ {
+			require.NoError(t, analyzers["slr"].AddSource(slrSources[idx]))
+			require.NoError(t, analyzers["descendente"].AddSource(descentSources[idx]))
+		}
+		return analyzers
+	}
+
+	for name, analyzer := range newAnalyzers("inicio\nvarinicio\n\tinteiro A;\nvarfim;\n", "leia A;\n", "escreva A;\nfim\n") {
+		t.Run(name, func(t *testing.T) {
+			result := analyzer.Parse()
+			require.True(t, result.Succeeded())
+
+			program := result.Program
+			require.Equal(t, lexer.Position{File: "arquivo1.mgol", Line: 1, Column: 1}, program.Pos())
+			require.Equal(t, lexer.Position{File: "arquivo3.mgol", Line: 2, Column: 3}, program.End())
+			require.Equal(t, lexer.Position{File: "arquivo1.mgol", Line: 3, Column: 10}, program.Declarations[0].Name.Pos())
+			require.Equal(t, lexer.Position{File: "arquivo2.mgol", Line: 1, Column: 1}, program.Body[0].Pos())
+			require.Equal(t, lexer.Position{File: "arquivo3.mgol", Line: 1, Column: 10}, program.Body[1].End())
+		})
+	}
+
+	for name, analyzer := range newAnalyzers("inicio\nvarinicio\n\tinteiro A;\nvarfim;\n", "leia ;\nfim\n") {
+		t.Run(name+" with an error", func(t *testing.T) {
+			result := analyzer.Parse()
+			require.Len(t, result.Errors, 1)
+			require.Equal(t, "arquivo2.mgol", result.Errors[0].Span.Start.File)
+			require.True(t, strings.HasPrefix(result.Errors[0].String(), "Erro: "+result.Errors[0].Message+" em arquivo2.mgol, na linha 1, coluna 6"))
+		})
+	}
+
+	// The source is kept across the files on concrete syntax mode
+	files := []string{"inicio {a}\nvarinicio varfim;\n{b}", "  fim {c}\n"}
+	sources := newSources(files...)
+	p := NewParser(sources[0], stack.NewStack(1000), GetRulesMap(grammarPath), actionTablePath, gotoTablePath)
+	require.NoError(t, p.AddSource(sources[1]))
+	p.SetConcreteSyntax(true)
+	result := p.Parse()
+	require.True(t, result.Succeeded())
+	output := &bytes.Buffer{}
+	require.NoError(t, result.ParseTree.WriteSource(output))
+	require.Equal(t, strings.Join(files, ""), output.String())
+
+	// The files must share the symbol table
+	other := newSources("fim")
+	require.ErrorIs(t, newTestParser(t, "inicio").AddSource(other[0]), ErrorSeparateSymbolTable)
+}
+
+func TestParseWithArena(t *testing.T) {
+	source := largeProgram(5)
+	for name, newAnalyzer := range map[string]func() Analyzer{
+		"slr":         func() Analyzer { return newTestParser(t, source) },
+		"descendente": func() Analyzer { return newTestDescentParser(t, source) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			expected := newAnalyzer().Parse()
+			require.True(t, expected.Succeeded())
+
+			analyzer := newAnalyzer()
+			analyzer.UseArena(ast.NewArena())
+			result := analyzer.Parse()
+			require.True(t, result.Succeeded())
+			require.Equal(t, expected.Program, result.Program)
+		})
+	}
+}
+
+// largeProgram returns a program with statements
+// statements of every kind
+func largeProgram(statements int) string {
+	source := &strings.Builder{}
+	source.WriteString("inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\n")
+	for i := 0; i < statements; i++ {
+		fmt.Fprintf(source, "leia A;\nB <- (A + %d) * B - A / 2;\n", i)
+		fmt.Fprintf(source, "se (A > %d) entao\n\tescreva B;\nsenao\n\tA <- A + 1;\nfimse\n", i)
+		source.WriteString("repita (A < 10)\n\tA <- A + 1;\nfimrepita\n")
+	}
+	source.WriteString("fim\n")
+	return source.String()
+}
+
+func benchmarkParse(b *testing.B, newArena func() *ast.Arena) {
+	source := largeProgram(200)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		symbolTable := lexer.NewSymbolTable()
+		symbolTable.RegisterKeywords(lexer.DefaultKeywords())
+		p := NewRecursiveDescentParser(lexer.NewStringScanner(source, symbolTable), GetRulesMap(grammarPath))
+		// Only the building of the trees is measured
+		p.quiet = true
+		p.runSemantic = false
+		p.UseArena(newArena())
+		if result := p.Parse(); result.Program == nil {
+			b.Fatal("the program was not parsed")
+		}
+	}
+}
+
+func BenchmarkParseDefaultAllocator(b *testing.B) {
+	benchmarkParse(b, func() *ast.Arena { return nil })
+}
+
+func BenchmarkParseArena(b *testing.B) {
+	benchmarkParse(b, ast.NewArena)
+}
@@ -3,13 +3,10 @@ package parser
 import (
 	"encoding/json"
 	"io/ioutil"
+	"mgol-go/src/grammar"
 )
 
-type Rule struct {
-	Number int      `json:"rule_number"`
-	Left   string   `json:"left"`
-	Right  []string `json:"right"`
-}
+type Rule = grammar.Rule
 
 type RulesMap map[int]Rule
 
@@ -47,6 +44,78 @@ func GetRulesMap(path string) *RulesMap {
 	}
 }
 
+// NewRulesMap creates a RulesMap with the rules of g
+func NewRulesMap(g *grammar.Grammar) *RulesMap {
+	return createMapFromSlice(g.Rules())
+}
+
+// defaultRules are the rules of grammar.json, so a program can
+// be parsed by DefaultRules without the file
+var defaultRules = RulesMap{
+	0:  {Number: 0, Left: "P'", Right: []string{"P"}},
+	1:  {Number: 1, Left: "P", Right: []string{"inicio", "V", "A"}},
+	2:  {Number: 2, Left: "V", Right: []string{"varinicio", "LV"}},
+	3:  {Number: 3, Left: "LV", Right: []string{"D", "LV"}},
+	4:  {Number: 4, Left: "LV", Right: []string{"varfim", "pt_v"}},
+	5:  {Number: 5, Left: "D", Right: []string{"TIPO", "L", "pt_v"}},
+	6:  {Number: 6, Left: "L", Right: []string{"id"}},
+	7:  {Number: 7, Left: "TIPO", Right: []string{"inteiro"}},
+	8:  {Number: 8, Left: "TIPO", Right: []string{"real"}},
+	9:  {Number: 9, Left: "TIPO", Right: []string{"literal"}},
+	10: {Number: 10, Left: "A", Right: []string{"ES", "A"}},
+	11: {Number: 11, Left: "ES", Right: []string{"leia", "id", "pt_v"}},
+	12: {Number: 12, Left: "ES", Right: []string{"escreva", "ARG", "pt_v"}},
+	13: {Number: 13, Left: "ARG", Right: []string{"lit"}},
+	14: {Number: 14, Left: "ARG", Right: []string{"num"}},
+	15: {Number: 15, Left: "ARG", Right: []string{"id"}},
+	16: {Number: 16, Left: "A", Right: []string{"CMD", "A"}},
+	17: {Number: 17, Left: "CMD", Right: []string{"id", "rcb", "LD", "pt_v"}},
+	18: {Number: 18, Left: "LD", Right: []string{"LD", "opm", "TERMO"}},
+	19: {Number: 19, Left: "LD", Right: []string{"TERMO"}},
+	20: {Number: 20, Left: "OPRD", Right: []string{"id"}},
+	21: {Number: 21, Left: "OPRD", Right: []string{"num"}},
+	22: {Number: 22, Left: "A", Right: []string{"COND", "A"}},
+	23: {Number: 23, Left: "COND", Right: []string{"CAB", "CP"}},
+	24: {Number: 24, Left: "CAB", Right: []string{"se", "ab_p", "EXP_R", "fc_p", "entao"}},
+	25: {Number: 25, Left: "EXP_R", Right: []string{"LD", "opr", "LD"}},
+	26: {Number: 26, Left: "CP", Right: []string{"ES", "CP"}},
+	27: {Number: 27, Left: "CP", Right: []string{"CMD", "CP"}},
+	28: {Number: 28, Left: "CP", Right: []string{"COND", "CP"}},
+	29: {Number: 29, Left: "CP", Right: []string{"fimse"}},
+	30: {Number: 30, Left: "A", Right: []string{"R", "A"}},
+	31: {Number: 31, Left: "R", Right: []string{"CABR", "CPR"}},
+	32: {Number: 32, Left: "CABR", Right: []string{"repita", "ab_p", "EXP_R", "fc_p"}},
+	33: {Number: 33, Left: "CPR", Right: []string{"ES", "CPR"}},
+	34: {Number: 34, Left: "CPR", Right: []string{"CMD", "CPR"}},
+	35: {Number: 35, Left: "CPR", Right: []string{"COND", "CPR"}},
+	36: {Number: 36, Left: "CPR", Right: []string{"fimrepita"}},
+	37: {Number: 37, Left: "A", Right: []string{"fim"}},
+	38: {Number: 38, Left: "TERMO", Right: []string{"TERMO", "opmul", "OPRD"}},
+	39: {Number: 39, Left: "TERMO", Right: []string{"OPRD"}},
+	40: {Number: 40, Left: "OPRD", Right: []string{"ab_p", "LD", "fc_p"}},
+	41: {Number: 41, Left: "CP", Right: []string{"senao", "CPE"}},
+	42: {Number: 42, Left: "CPE", Right: []string{"ES", "CPE"}},
+	43: {Number: 43, Left: "CPE", Right: []string{"CMD", "CPE"}},
+	44: {Number: 44, Left: "CPE", Right: []string{"COND", "CPE"}},
+	45: {Number: 45, Left: "CPE", Right: []string{"fimse"}},
+	46: {Number: 46, Left: "CP", Right: []string{"R", "CP"}},
+	47: {Number: 47, Left: "CPR", Right: []string{"R", "CPR"}},
+	48: {Number: 48, Left: "CPE", Right: []string{"R", "CPE"}},
+	49: {Number: 49, Left: "ARG", Right: []string{"LD"}},
+	50: {Number: 50, Left: "R", Right: []string{"CABW", "CPW"}},
+	51: {Number: 51, Left: "CABW", Right: []string{"enquanto", "ab_p", "EXP_R", "fc_p"}},
+	52: {Number: 52, Left: "CPW", Right: []string{"ES", "CPW"}},
+	53: {Number: 53, Left: "CPW", Right: []string{"CMD", "CPW"}},
+	54: {Number: 54, Left: "CPW", Right: []string{"COND", "CPW"}},
+	55: {Number: 55, Left: "CPW", Right: []string{"R", "CPW"}},
+	56: {Number: 56, Left: "CPW", Right: []string{"fimenquanto"}},
+}
+
+// DefaultRules returns the rules of grammar.json, kept on the code
+func DefaultRules() *RulesMap {
+	return &defaultRules
+}
+
 func (r *RulesMap) GetRule(ruleNumber int) Rule {
 	return (*r)[ruleNumber]
 }
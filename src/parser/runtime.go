@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+// runtimePreamble starts every generated program. It declares the
+// literal type and the functions leia and escreva are translated to,
+// one for each type, and includes strcmp for the comparisons of
+// literals, so the output compiles on its own:
+//
+//	gcc programa.c -o programa
+const runtimePreamble = `#include <stdio.h>
+#include <stdbool.h>
+#include <string.h>
+
+typedef char literal[256];
+
+/* leia: a value that can not be read leaves the variable zeroed */
+static void leia_inteiro(int *valor) {
+	if (scanf("%d", valor) != 1) {
+		*valor = 0;
+	}
+}
+
+static void leia_real(double *valor) {
+	if (scanf("%lf", valor) != 1) {
+		*valor = 0;
+	}
+}
+
+/* a literal is read up to the end of the line, spaces included */
+static void leia_literal(literal valor) {
+	if (scanf(" %255[^\n]", valor) != 1) {
+		valor[0] = '\0';
+	}
+}
+
+static void escreva_inteiro(int valor) {
+	printf("%d", valor);
+}
+
+static void escreva_real(double valor) {
+	printf("%lf", valor);
+}
+
+static void escreva_literal(const char *valor) {
+	printf("%s", valor);
+}
+`
+
+// escrevaReal is escreva_real of the preamble, and escrevaRealComma the
+// one replacing it when the reals are written with a comma as the decimal
+// separator, like 3,140000. The latter has printf write on a buffer first,
+// large enough for the 309 digits of the largest double
+const (
+	escrevaReal = `static void escreva_real(double valor) {
+	printf("%lf", valor);
+}`
+	escrevaRealComma = `static void escreva_real(double valor) {
+	char texto[320];
+	char *ponto;
+	snprintf(texto, sizeof texto, "%lf", valor);
+	if ((ponto = strchr(texto, '.')) != NULL) {
+		*ponto = ',';
+	}
+	printf("%s", texto);
+}`
+)
+
+// preamble returns runtimePreamble, writing the
+// reals with a comma if decimalComma
+func preamble(decimalComma bool) string {
+	if decimalComma {
+		return strings.Replace(runtimePreamble, escrevaReal, escrevaRealComma, 1)
+	}
+	return runtimePreamble
+}
+
+// cString returns the literal lexeme, quotes included, as a C string
+// with the same text, its backslashes escaped
+func cString(lexeme string) string {
+	return strings.ReplaceAll(lexeme, `\`, `\\`)
+}
+
+// readHelpers and writeHelpers name the functions of the
+// preamble that read and write a value of each type
+var (
+	readHelpers = map[lexer.DataType]string{
+		lexer.INTEGER: "leia_inteiro",
+		lexer.REAL:    "leia_real",
+		lexer.LITERAL: "leia_literal",
+	}
+	writeHelpers = map[lexer.DataType]string{
+		lexer.INTEGER: "escreva_inteiro",
+		lexer.REAL:    "escreva_real",
+		lexer.LITERAL: "escreva_literal",
+	}
+)
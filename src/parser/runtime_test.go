@@ -0,0 +1,367 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mgol-go/src/asmgen"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/gogen"
+	"mgol-go/src/interp"
+	"mgol-go/src/ir"
+	"mgol-go/src/jsgen"
+	"mgol-go/src/llvmgen"
+	"mgol-go/src/pygen"
+	"mgol-go/src/sem"
+	"mgol-go/src/vm"
+	"mgol-go/src/wasmgen"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeneratedSourceCompiles builds the generated program with
+// the C compiler, when there is one, and runs it
+func TestGeneratedSourceCompiles(t *testing.T) {
+	compiler, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc não encontrado")
+	}
+
+	source := "inicio\nvarinicio\nliteral NOME;\nliteral OUTRO;\ninteiro A;\nreal B;\nvarfim;\n" +
+		"leia NOME;\nleia OUTRO;\nleia A;\nleia B;\n" +
+		"se (NOME = OUTRO) entao escreva \"oi \"; fimse\n" +
+		"se (A = 2) entao escreva A * 10; fimse\n" +
+		"escreva \" \";\nescreva B / 2.0;\nfim"
+	p := newTestParser(t, source)
+	require.True(t, p.Parse().Succeeded())
+
+	dir, err := ioutil.TempDir("", "programa")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cFile, binary := filepath.Join(dir, "programa.c"), filepath.Join(dir, "programa")
+	require.NoError(t, ioutil.WriteFile(cFile, []byte(p.semantic.Source()), 0644))
+	output, err := exec.Command(compiler, "-Wall", "-Werror", cFile, "-o", binary).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	run := exec.Command(binary)
+	run.Stdin = strings.NewReader("Ana Maria\nAna Maria\n2\n5.0\n")
+	output, err = run.Output()
+	require.NoError(t, err)
+	require.Equal(t, "oi 20 2.500000", string(output))
+}
+
+// TestBackendsAgree runs the same program generated as C, as Go, as
+// x86-64 assembly on such a machine and, when python3, lli and node are
+// found, as Python, LLVM IR, optimized or not, JavaScript and
+// WebAssembly, which must all write the same output, and so must the
+// interpreter and the virtual machine
+func TestBackendsAgree(t *testing.T) {
+	source := "inicio\nvarinicio\nliteral NOME;\ninteiro A;\ninteiro N;\nreal B;\nvarfim;\n" +
+		"leia NOME;\nleia N;\nB <- 0.5;\nA <- 0;\n" +
+		"repita (A < N)\nB <- B * 2 + A;\nA <- A + 1;\nfimrepita\n" +
+		"escreva NOME;\nescreva \" \";\nescreva A * 3 / 2;\nescreva \" \";\nescreva B;\n" +
+		"se (B >= 10) entao escreva \" grande\"; senao escreva \" pequeno\"; fimse\nfim"
+	require.Equal(t, "Ana Maria 6 19.000000 grande", runOnBackends(t, source, "Ana Maria\n4\n", backend.Options{}))
+}
+
+// TestRealsAndBackslashes checks that C computes the reals as doubles,
+// like the other backends, and writes a backslash of a literal as is
+func TestRealsAndBackslashes(t *testing.T) {
+	source := "inicio\nvarinicio\nreal B;\nvarfim;\n" +
+		"leia B;\nescreva B * 3;\nescreva \" C:\\novo\\tabela \";\nescreva B - 79.54;\nfim"
+	require.Equal(t, `186.270000 C:\novo\tabela -17.450000`, runOnBackends(t, source, "62.09\n", backend.Options{}))
+}
+
+// TestIntegerOverflow checks that every backend wraps an inteiro
+// at 32 bits, like the int of the C backend
+func TestIntegerOverflow(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\ninteiro B;\nvarfim;\n" +
+		"leia A;\nescreva A * A;\nB <- 2147483647;\nB <- B + 1;\nescreva \" \";\nescreva B;\n" +
+		"B <- B - 1;\nescreva \" \";\nescreva B;\nescreva \" \";\nescreva A * 5 + 7;\n" +
+		"escreva \" \";\nescreva 2147483647 + 1;\nescreva \" \";\nescreva 429981696 * 429981696;\nfim"
+	require.Equal(t, "0 -2147483648 2147483647 -2145058809 -2147483648 0", runOnBackends(t, source, "429981696\n", backend.Options{}))
+}
+
+// TestDecimalComma writes reals with a comma on every backend, only
+// on them: the literals with a point and the reals read are kept
+func TestDecimalComma(t *testing.T) {
+	source := "inicio\nvarinicio\nreal B;\ninteiro A;\nvarfim;\n" +
+		"leia B;\nA <- 2;\nescreva B;\nescreva \" 1.5 \";\nescreva B * A;\nescreva \" \";\nescreva A;\n" +
+		"B <- 0.0 - 1234567.25;\nescreva \" \";\nescreva B;\nfim"
+	require.Equal(t, "3.140000 1.5 6.280000 2 -1234567.250000", runOnBackends(t, source, "3.14\n", backend.Options{}))
+	require.Equal(t, "3,140000 1.5 6,280000 2 -1234567,250000", runOnBackends(t, source, "3.14\n", backend.Options{DecimalComma: true}))
+}
+
+// TestControlFlowRuns runs programs nesting se, senao and repita
+// on every backend, which write the blocks as structured code
+// or, from the three-address code, as labels and jumps
+func TestControlFlowRuns(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		input  string
+		output string
+	}{
+		{
+			"senao chain",
+			"leia A;\n" +
+				"se (A < 0) entao escreva \"negativo\"; senao\n" +
+				"  se (A = 0) entao escreva \"zero\"; senao\n" +
+				"    se (A <= 9) entao escreva \"unidade\"; senao escreva \"dezenas\"; fimse\n" +
+				"  fimse\n" +
+				"fimse\n",
+			"7\n",
+			"unidade",
+		},
+		{
+			"nested loops",
+			"leia N;\nA <- 1;\n" +
+				"repita (A <= N)\n" +
+				"  B <- 1;\n" +
+				"  repita (B <= A)\n    escreva A * B;\n    escreva \" \";\n    B <- B + 1;\n  fimrepita\n" +
+				"  escreva \"- \";\n" +
+				"  A <- A + 1;\n" +
+				"fimrepita\n",
+			"3\n",
+			"1 - 2 4 - 3 6 9 - ",
+		},
+		{
+			"loop never entered",
+			"A <- 5;\nrepita (A < 5)\n  escreva \"nunca\";\n  A <- A + 1;\nfimrepita\n" +
+				"se (A <> 5) entao escreva \"nunca\"; fimse\nescreva A;\n",
+			"",
+			"5",
+		},
+		{
+			"se inside repita",
+			"leia N;\nA <- 0;\nB <- 0;\n" +
+				"repita (A < N)\n" +
+				"  A <- A + 1;\n" +
+				"  se (A / 2 * 2 = A) entao B <- B + A; senao escreva A; fimse\n" +
+				"fimrepita\n" +
+				"escreva \" \";\nescreva B;\n",
+			"6\n",
+			"135 12",
+		},
+		{
+			"enquanto nesting repita",
+			"leia N;\nA <- 0;\n" +
+				"enquanto (A < N)\n" +
+				"  A <- A + 1;\n" +
+				"  B <- 0;\n" +
+				"  repita (B < A)\n    B <- B + 1;\n    enquanto (B = 2)\n      escreva \"*\";\n      B <- B + 1;\n    fimenquanto\n  fimrepita\n" +
+				"  escreva B;\n" +
+				"fimenquanto\n",
+			"3\n",
+			"1*3*3",
+		},
+		{
+			"literal conditions",
+			"leia D;\nleia C;\nA <- 0;\n" +
+				"repita (C <> D)\n" +
+				"  A <- A + 1;\n" +
+				"  leia C;\n" +
+				"fimrepita\n" +
+				"se (C = D) entao escreva A; fimse\n",
+			"fim\num\ndois\ntrês\nfim\n",
+			"3",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			source := "inicio\nvarinicio\ninteiro A;\ninteiro B;\ninteiro N;\nliteral C;\nliteral D;\nvarfim;\n" + testCase.source + "fim"
+			require.Equal(t, testCase.output, runOnBackends(t, source, testCase.input, backend.Options{}))
+		})
+	}
+}
+
+// runOnBackends runs source, generated with options, with input on
+// each backend found, checking they all agree, and returns the output
+// of the C one
+func runOnBackends(t *testing.T, source, input string, options backend.Options) string {
+	compiler, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc não encontrado")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go não encontrado")
+	}
+
+	p := newTestParser(t, source)
+	p.SetDecimalComma(options.DecimalComma)
+	result := p.Parse()
+	require.True(t, result.Succeeded())
+	info := sem.NewChecker(nil).Check(result.Program)
+	require.Empty(t, info.Errors)
+
+	dir, err := ioutil.TempDir("", "programa")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cFile, binary, goFile := filepath.Join(dir, "programa.c"), filepath.Join(dir, "programa"), filepath.Join(dir, "programa.go")
+	require.NoError(t, ioutil.WriteFile(cFile, []byte(p.semantic.Source()), 0644))
+	output, err := exec.Command(compiler, cFile, "-o", binary).CombinedOutput()
+	require.NoError(t, err, string(output))
+	var goSource bytes.Buffer
+	require.NoError(t, gogen.GenerateWithOptions(&goSource, result.Program, info, options))
+	require.NoError(t, ioutil.WriteFile(goFile, goSource.Bytes(), 0644))
+
+	run := exec.Command(binary)
+	run.Stdin = strings.NewReader(input)
+	cOutput, err := run.Output()
+	require.NoError(t, err)
+	var interpreted bytes.Buffer
+	require.NoError(t, interp.RunWithOptions(result.Program, info, strings.NewReader(input), &interpreted, options))
+	require.Equal(t, string(cOutput), interpreted.String())
+	lowered, err := ir.Lower(result.Program, info)
+	require.NoError(t, err)
+	optimized, _ := ir.Optimize(lowered, ir.O2)
+	for _, code := range []*ir.Program{lowered, optimized} {
+		program, err := bytecode.Compile(code)
+		require.NoError(t, err)
+		var machineOutput bytes.Buffer
+		require.NoError(t, vm.RunWithOptions(program, strings.NewReader(input), &machineOutput, options))
+		require.Equal(t, string(cOutput), machineOutput.String())
+	}
+	run = exec.Command(goTool, "run", goFile)
+	run.Stdin = strings.NewReader(input)
+	goOutput, err := run.Output()
+	require.NoError(t, err)
+	require.Equal(t, string(cOutput), string(goOutput))
+
+	if runtime.GOARCH == "amd64" && runtime.GOOS == "linux" {
+		asmFile, asmBinary := filepath.Join(dir, "programa.s"), filepath.Join(dir, "programa-asm")
+		var assembly bytes.Buffer
+		require.NoError(t, asmgen.GenerateWithOptions(&assembly, result.Program, info, options))
+		require.NoError(t, ioutil.WriteFile(asmFile, assembly.Bytes(), 0644))
+		output, err := exec.Command(compiler, asmFile, "-o", asmBinary).CombinedOutput()
+		require.NoError(t, err, string(output))
+		run = exec.Command(asmBinary)
+		run.Stdin = strings.NewReader(input)
+		asmOutput, err := run.Output()
+		require.NoError(t, err)
+		require.Equal(t, string(cOutput), string(asmOutput))
+	}
+
+	if python, err := exec.LookPath("python3"); err == nil {
+		pyFile := filepath.Join(dir, "programa.py")
+		var pySource bytes.Buffer
+		require.NoError(t, pygen.GenerateWithOptions(&pySource, result.Program, info, options))
+		require.NoError(t, ioutil.WriteFile(pyFile, pySource.Bytes(), 0644))
+		run = exec.Command(python, pyFile)
+		run.Stdin = strings.NewReader(input)
+		pyOutput, err := run.Output()
+		require.NoError(t, err)
+		require.Equal(t, string(cOutput), string(pyOutput))
+	}
+
+	if interpreter, err := exec.LookPath("lli"); err == nil {
+		irFile := filepath.Join(dir, "programa.ll")
+		var llvmSource bytes.Buffer
+		require.NoError(t, llvmgen.GenerateWithOptions(&llvmSource, result.Program, info, options))
+		require.NoError(t, ioutil.WriteFile(irFile, llvmSource.Bytes(), 0644))
+		run = exec.Command(interpreter, irFile)
+		run.Stdin = strings.NewReader(input)
+		irOutput, err := run.Output()
+		require.NoError(t, err)
+		require.Equal(t, string(cOutput), string(irOutput))
+
+		// The optimized three-address code must still do the same
+		llvmSource.Reset()
+		require.NoError(t, llvmgen.GenerateIRWithOptions(&llvmSource, optimized, options))
+		require.NoError(t, ioutil.WriteFile(irFile, llvmSource.Bytes(), 0644))
+		run = exec.Command(interpreter, irFile)
+		run.Stdin = strings.NewReader(input)
+		irOutput, err = run.Output()
+		require.NoError(t, err)
+		require.Equal(t, string(cOutput), string(irOutput))
+	}
+
+	if node, err := exec.LookPath("node"); err == nil {
+		// node has no prompt, each call reads a line of the input instead
+		jsFile, promptFile := filepath.Join(dir, "programa.js"), filepath.Join(dir, "prompt.js")
+		var js bytes.Buffer
+		require.NoError(t, jsgen.GenerateWithOptions(&js, result.Program, info, options))
+		require.NoError(t, ioutil.WriteFile(jsFile, js.Bytes(), 0644))
+		require.NoError(t, ioutil.WriteFile(promptFile, []byte("const lines = require(\"fs\").readFileSync(0, \"utf8\").split(\"\\n\");\n"+
+			"globalThis.prompt = () => lines.length > 0 ? lines.shift() : null;\n"), 0644))
+		run = exec.Command(node, "-r", promptFile, jsFile)
+		run.Stdin = strings.NewReader(input)
+		jsOutput, err := run.Output()
+		require.NoError(t, err)
+		// console.log ends the last line
+		require.Equal(t, string(cOutput)+"\n", string(jsOutput))
+
+		var module bytes.Buffer
+		require.NoError(t, wasmgen.Generate(&module, result.Program, info))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "programa.wasm"), module.Bytes(), 0644))
+		glue := wasmgen.Glue
+		if options.DecimalComma {
+			glue = wasmgen.GlueDecimalComma
+		}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "glue.js"), []byte(glue), 0644))
+		run = exec.Command(node, "-r", promptFile, "-e", "require(\"./glue.js\").executaMgol(require(\"fs\").readFileSync(\"programa.wasm\"))")
+		run.Dir = dir
+		run.Stdin = strings.NewReader(input)
+		wasmOutput, err := run.Output()
+		require.NoError(t, err)
+		require.Equal(t, string(jsOutput), string(wasmOutput))
+	}
+	return string(cOutput)
+}
+
+// TestGenerationIsDeterministic generates the same program several
+// times, from a new parse each time, on every target, which must
+// write the same bytes: the temporaries are numbered in the order
+// they are needed and nothing is written in the order of a map
+func TestGenerationIsDeterministic(t *testing.T) {
+	source := "inicio\nvarinicio\nliteral NOME;\nliteral Z;\ninteiro A;\nreal B;\nvarfim;\n" +
+		"leia NOME;\nleia Z;\nleia A;\nX <- A * 2;\nY <- 1.5;\n" +
+		"repita (A < 10)\nB <- (B + X) * (Y - A) + (A / 2);\nA <- A + 1;\nfimrepita\n" +
+		"se (NOME <> Z) entao escreva \"nao\"; senao escreva \"sim\"; fimse\n" +
+		"escreva \"oi\";\nescreva B * 2.0;\nescreva Y;\nfim"
+	generate := func() map[string][]byte {
+		p := newTestParser(t, source)
+		p.SetImplicitDeclarations(true)
+		p.SetSourceComments(true)
+		result := p.Parse()
+		require.True(t, result.Succeeded())
+		checker := sem.NewChecker(nil)
+		checker.SetImplicitDeclarations(true)
+		info := checker.Check(result.Program)
+		require.Empty(t, info.Errors)
+
+		outputs := make(map[string][]byte)
+		var code, sourceMap bytes.Buffer
+		require.NoError(t, p.WriteCode(&code))
+		require.NoError(t, p.EncodeSourceMap(&sourceMap))
+		outputs["c"], outputs["source map"] = code.Bytes(), sourceMap.Bytes()
+		for _, name := range backend.Names() {
+			target, _ := backend.Lookup(name)
+			var generated bytes.Buffer
+			require.NoError(t, target.Generate(result.Program, info, &generated))
+			outputs[name] = generated.Bytes()
+		}
+		lowered, err := ir.Lower(result.Program, info)
+		require.NoError(t, err)
+		optimized, _ := ir.Optimize(lowered, ir.O2)
+		outputs["ir"], outputs["optimized ir"] = []byte(lowered.String()), []byte(optimized.String())
+		return outputs
+	}
+
+	first := generate()
+	for _, name := range []string{"c", "go", "llvm", "js", "wasm", "ir"} {
+		require.NotEmpty(t, first[name], name)
+	}
+	for run := 0; run < 10; run++ {
+		for name, output := range generate() {
+			require.True(t, bytes.Equal(first[name], output), "%s mudou entre duas gerações", name)
+		}
+	}
+}
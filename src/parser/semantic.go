@@ -1,11 +1,14 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"mgol-go/src/lexer"
 	"mgol-go/src/stack"
+	"strings"
 )
 
 type TemporalType int
@@ -18,13 +21,14 @@ const (
 
 const maxCapacityStack = 10000
 
-var semanticErrorFlag = false
-
-var repitaEndCode = ""
-
 type CodeBuffer struct {
 	temporals []TemporalType
-	code      string
+	// declarations holds the variables declared on their first
+	// assignment, which are written along with the temporals
+	declarations strings.Builder
+	// code is only appended to, as the long programs would
+	// copy it over and over
+	code strings.Builder
 }
 
 func NewCodeBuffer() *CodeBuffer {
@@ -41,7 +45,7 @@ func (c *CodeBuffer) PrintTemporals() string {
 		case TemporalInt:
 			chunk = fmt.Sprintf("int T%d;\n", idx)
 		case TemporalFloat:
-			chunk = fmt.Sprintf("float T%d;\n", idx)
+			chunk = fmt.Sprintf("double T%d;\n", idx)
 		}
 		temporalCode += chunk
 	}
@@ -49,6 +53,27 @@ func (c *CodeBuffer) PrintTemporals() string {
 	return temporalCode
 }
 
+// PrintDeclarations returns the declarations of the variables
+// declared on their first assignment, if there are any
+func (c *CodeBuffer) PrintDeclarations() string {
+	if c.declarations.Len() == 0 {
+		return ""
+	}
+	return "/*----Variaveis declaradas no primeiro uso----*/\n" + c.declarations.String() + "/*------------------------------*/\n"
+}
+
+// Code returns the code added so far
+func (c *CodeBuffer) Code() string {
+	return c.code.String()
+}
+
+// cTypes holds the C type of the variables of each type
+var cTypes = map[lexer.DataType]string{
+	lexer.INTEGER: "int",
+	lexer.REAL:    "double",
+	lexer.LITERAL: "literal",
+}
+
 var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 	// D -> TIPO L pt_v
 	6: func(s *Semantic, rule Rule, line int, column int) {
@@ -64,7 +89,7 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 		typeTokenConverted := typeToken.(lexer.Token)
 
 		identifierTokenConverted.SetType(typeTokenConverted.GetType())
-		s.symbolTable.Update(identifierTokenConverted.GetLexem(), identifierTokenConverted)
+		s.symbolTable.Declare(identifierTokenConverted.GetLexem(), typeTokenConverted.GetType())
 
 		s.AddToCodeBuffer(identifierTokenConverted.GetLexem())
 	},
@@ -80,7 +105,7 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 	9: func(s *Semantic, rule Rule, line int, column int) {
 		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), "", lexer.REAL)
 		s.semanticStack.Push(newToken)
-		s.AddToCodeBuffer("float ")
+		s.AddToCodeBuffer("double ")
 	},
 
 	// TIPO -> literal
@@ -94,19 +119,16 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 	12: func(s *Semantic, rule Rule, line int, column int) {
 		s.semanticStack.Pop() // Remove our pt_v
 		idToken, _ := s.semanticStack.Pop()
-		idTokenConverted := idToken.(lexer.Token)
+		idTokenConverted := s.withCurrentType(idToken.(lexer.Token))
 		if idTokenConverted.GetType() == lexer.NULL {
-			log.Printf("Erro: variável '%s' não declarada na linha %d, coluna %d\n", idTokenConverted.GetLexem(), line-1, column)
-			semanticErrorFlag = true
+			s.undeclared()
 			return
 		}
 		switch idTokenConverted.GetType() {
-		case lexer.INTEGER:
-			s.AddToCodeBuffer(fmt.Sprintf("scanf(\"%%d\", &%s);\n", idTokenConverted.GetLexem()))
+		case lexer.INTEGER, lexer.REAL:
+			s.AddToCodeBuffer(fmt.Sprintf("%s(&%s);\n", readHelpers[idTokenConverted.GetType()], idTokenConverted.GetLexem()))
 		case lexer.LITERAL:
-			s.AddToCodeBuffer(fmt.Sprintf("scanf(\"%%s\", %s);\n", idTokenConverted.GetLexem()))
-		case lexer.REAL:
-			s.AddToCodeBuffer(fmt.Sprintf("scanf(\"%%lf\", &%s);\n", idTokenConverted.GetLexem()))
+			s.AddToCodeBuffer(fmt.Sprintf("%s(%s);\n", readHelpers[lexer.LITERAL], idTokenConverted.GetLexem()))
 		}
 	},
 
@@ -115,13 +137,9 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 		s.semanticStack.Pop() // Remove our pt_v
 		argToken, _ := s.semanticStack.Pop()
 		argTokenConverted := argToken.(lexer.Token)
-		switch argTokenConverted.GetType() {
-		case lexer.INTEGER:
-			s.AddToCodeBuffer(fmt.Sprintf("printf(\"%%d\", %s);\n", argTokenConverted.GetLexem()))
-		case lexer.LITERAL:
-			s.AddToCodeBuffer(fmt.Sprintf("printf(\"%%s\", %s);\n", argTokenConverted.GetLexem()))
-		case lexer.REAL:
-			s.AddToCodeBuffer(fmt.Sprintf("printf(\"%%lf\", %s);\n", argTokenConverted.GetLexem()))
+		s.release(argTokenConverted)
+		if helper, found := writeHelpers[argTokenConverted.GetType()]; found {
+			s.AddToCodeBuffer(fmt.Sprintf("%s(%s);\n", helper, argTokenConverted.GetLexem()))
 		}
 	},
 
@@ -129,7 +147,7 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 	14: func(s *Semantic, rule Rule, line int, column int) {
 		literalToken, _ := s.semanticStack.Pop()
 		literalTokenConverted := literalToken.(lexer.Token)
-		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), literalTokenConverted.GetLexem(), literalTokenConverted.GetType())
+		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), cString(literalTokenConverted.GetLexem()), literalTokenConverted.GetType())
 		s.semanticStack.Push(newToken)
 	},
 
@@ -144,10 +162,9 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 	// ARG -> id
 	16: func(s *Semantic, rule Rule, line int, column int) {
 		idToken, _ := s.semanticStack.Pop()
-		idTokenConverted := idToken.(lexer.Token)
+		idTokenConverted := s.withCurrentType(idToken.(lexer.Token))
 		if idTokenConverted.GetType() == lexer.NULL {
-			log.Printf("Erro: variável '%s' não declarada na linha %d, coluna %d\n", idTokenConverted.GetLexem(), line-1, column)
-			semanticErrorFlag = true
+			s.undeclared()
 			return
 		}
 
@@ -164,72 +181,34 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 		s.semanticStack.Pop() // remove our rcb
 
 		rawId, _ := s.semanticStack.Pop()
-		id := rawId.(lexer.Token)
+		id := s.withCurrentType(rawId.(lexer.Token))
+		s.release(LD)
 
 		if id.GetType() == lexer.NULL {
-			log.Printf("Erro: variável '%s' não declarada na linha %d, coluna %d\n", id.GetLexem(), line-1, column)
-			semanticErrorFlag = true
-			return
-		}
-
-		if id.GetType() != LD.GetType() && LD.GetType() != lexer.NULL {
-			log.Printf("Erro: Tipos diferentes para a atribuição na linha %d, coluna %d. '%s' é do tipo '%s', enquanto que '%s' é do tipo '%s'\n", line-1, column, id.GetLexem(), id.GetType(), LD.GetLexem(), LD.GetType())
-			semanticErrorFlag = true
-			return
+			if !s.implicitDeclarations || LD.GetType() == lexer.NULL {
+				s.undeclared()
+				return
+			}
+			s.declareImplicitly(id.GetLexem(), LD.GetType())
 		}
 
+		// The types of both sides are checked by the sem package,
+		// which knows whether a real may be stored on an inteiro
 		s.AddToCodeBuffer(fmt.Sprintf("%s = %s;\n", id.GetLexem(), LD.GetLexem()))
 	},
 
-	// LD -> OPRD opm OPRD
-	19: func(s *Semantic, rule Rule, line int, column int) {
-		rawOprd2, _ := s.semanticStack.Pop()
-		oprd2 := rawOprd2.(lexer.Token)
+	// LD -> LD opm TERMO
+	19: arithmeticOperation,
 
-		rawOpm, _ := s.semanticStack.Pop()
-		opm := rawOpm.(lexer.Token)
-
-		rawOprd1, _ := s.semanticStack.Pop()
-		oprd1 := rawOprd1.(lexer.Token)
-
-		if oprd1.GetType() != oprd2.GetType() && oprd1.GetType() != lexer.LITERAL && oprd2.GetType() != lexer.LITERAL {
-			log.Printf("Erro: Operandos com tipos incompatíveis na linha %d, coluna %d. '%s' é do tipo '%s', enquanto que '%s' é do tipo '%s'\n", line, column, oprd1.GetLexem(), oprd1.GetType(), oprd2.GetLexem(), oprd2.GetType())
-			semanticErrorFlag = true
-			return
-		}
-
-		temporal := ""
-		operationType := lexer.NULL
-
-		switch oprd1.GetType() {
-		case lexer.INTEGER:
-			temporal = s.NewTemporal(TemporalInt)
-			operationType = lexer.INTEGER
-		case lexer.REAL:
-			temporal = s.NewTemporal(TemporalFloat)
-			operationType = lexer.REAL
-		}
-
-		s.AddToCodeBuffer(fmt.Sprintf("%s = %s %s %s;\n", temporal, oprd1.GetLexem(), opm.GetLexem(), oprd2.GetLexem()))
-		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), temporal, operationType)
-		s.semanticStack.Push(newToken)
-	},
-
-	// LD -> OPRD
-	20: func(s *Semantic, rule Rule, line int, column int) {
-		oprdToken, _ := s.semanticStack.Pop()
-		oprdTokenConverted := oprdToken.(lexer.Token)
-		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), oprdTokenConverted.GetLexem(), oprdTokenConverted.GetType())
-		s.semanticStack.Push(newToken)
-	},
+	// LD -> TERMO
+	20: passThrough,
 
 	// OPRD -> id
 	21: func(s *Semantic, rule Rule, line int, column int) {
 		idToken, _ := s.semanticStack.Pop()
-		idTokenConverted := idToken.(lexer.Token)
+		idTokenConverted := s.withCurrentType(idToken.(lexer.Token))
 		if idTokenConverted.GetType() == lexer.NULL {
-			log.Printf("Erro: variável '%s' não declarada na linha %d, coluna %d\n", idTokenConverted.GetLexem(), line-1, column)
-			semanticErrorFlag = true
+			s.undeclared()
 			return
 		}
 		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), idTokenConverted.GetLexem(), idTokenConverted.GetType())
@@ -256,10 +235,11 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 		s.semanticStack.Pop() // remove "fc_p" from stack
 		rawExp_r, _ := s.semanticStack.Pop()
 		exp_r := rawExp_r.(lexer.Token)
+		s.release(exp_r)
 		s.AddToCodeBuffer(fmt.Sprintf("if (%s) {\n", exp_r.GetLexem()))
 	},
 
-	// EXP_R -> OPRD opr OPRD
+	// EXP_R -> LD opr LD
 	26: func(s *Semantic, rule Rule, line int, column int) {
 		rawOprd2, _ := s.semanticStack.Pop()
 		oprd2 := rawOprd2.(lexer.Token)
@@ -279,46 +259,154 @@ var rulesMap = map[int]func(s *Semantic, rule Rule, line int, column int){
 		s.semanticStack.Push(seOrRepita)
 		s.semanticStack.Push(abp)
 
-		if oprd1.GetType() != oprd2.GetType() {
+		if oprd1.GetType() != oprd2.GetType() && !mixedNumbers(oprd1, oprd2) {
 			log.Printf("Erro: Operandos com tipos incompatíveis na linha %d, coluna %d. '%s' é do tipo '%s', enquanto que '%s' é do tipo '%s'\n", line, column, oprd1.GetLexem(), oprd1.GetType(), oprd2.GetLexem(), oprd2.GetType())
-			semanticErrorFlag = true
+			s.errorFound = true
 			return
 		}
 
+		s.release(oprd1, oprd2)
 		temporalId := s.NewTemporal(TemporalBool)
 
 		exp_rToken := lexer.NewToken(lexer.TokenClass(rule.Left), temporalId, lexer.NULL)
 		s.semanticStack.Push(exp_rToken)
-		updateString := ""
-		if opr.GetLexem() == "<>" {
-			updateString = fmt.Sprintf("%s = %s < %s || %s > %s;\n", temporalId, oprd1.GetLexem(), oprd2.GetLexem(), oprd1.GetLexem(), oprd2.GetLexem())
-			s.AddToCodeBuffer(updateString)
-		} else {
-			s.AddToCodeBuffer(fmt.Sprintf("%s = %s %s %s;\n", temporalId, oprd1.GetLexem(), opr.GetLexem(), oprd2.GetLexem()))
+		left, right := oprd1.GetLexem(), oprd2.GetLexem()
+		if oprd1.GetType() == lexer.LITERAL {
+			// C would compare where the literals are, not their text
+			left, right = fmt.Sprintf("strcmp(%s, %s)", left, right), "0"
 		}
-
-		if seOrRepita.GetType() == "repita" {
-			if opr.GetLexem() == "<>" {
-				repitaEndCode = updateString
-			} else {
-				repitaEndCode = fmt.Sprintf("%s = %s %s %s;\n", temporalId, oprd1.GetLexem(), opr.GetLexem(), oprd2.GetLexem())
-			}
+		switch opr.GetLexem() {
+		case "<>":
+			s.AddToCodeBuffer(fmt.Sprintf("%s = %s < %s || %s > %s;\n", temporalId, left, right, left, right))
+		case "=":
+			s.AddToCodeBuffer(fmt.Sprintf("%s = %s == %s;\n", temporalId, left, right))
+		default:
+			s.AddToCodeBuffer(fmt.Sprintf("%s = %s %s %s;\n", temporalId, left, opr.GetLexem(), right))
 		}
 
+		if class := seOrRepita.GetClass(); class == "repita" || class == "enquanto" {
+			// The whole condition, operands included,
+			// is evaluated again at the end of the loop
+			start := s.loopStarts[len(s.loopStarts)-1]
+			s.loopEndCodes = append(s.loopEndCodes, s.codeBuffer.Code()[start:])
+		}
 	},
 
 	// R -> CABR CPR
-	32: func(s *Semantic, rule Rule, line int, column int) {
-		s.AddToCodeBuffer(repitaEndCode + "}\n")
-	},
+	32: endLoop,
 
 	// CABR -> repita ab_p EXP_R fc_p
-	33: func(s *Semantic, rule Rule, line int, column int) {
+	33: loopHeader,
+
+	// TERMO -> TERMO opmul OPRD
+	39: arithmeticOperation,
+
+	// TERMO -> OPRD
+	40: passThrough,
+
+	// OPRD -> ab_p LD fc_p
+	41: func(s *Semantic, rule Rule, line int, column int) {
 		s.semanticStack.Pop() // remove "fc_p" from stack
-		rawExp_r, _ := s.semanticStack.Pop()
-		exp_r := rawExp_r.(lexer.Token)
-		s.AddToCodeBuffer(fmt.Sprintf("while (%s) {\n", exp_r.GetLexem()))
+		rawLD, _ := s.semanticStack.Pop()
+		LD := rawLD.(lexer.Token)
+		s.semanticStack.Pop() // remove "ab_p" from stack
+
+		newToken := lexer.NewToken(lexer.TokenClass(rule.Left), LD.GetLexem(), LD.GetType())
+		s.semanticStack.Push(newToken)
 	},
+
+	// ARG -> LD
+	50: passThrough,
+
+	// R -> CABW CPW
+	51: endLoop,
+
+	// CABW -> enquanto ab_p EXP_R fc_p
+	52: loopHeader,
+}
+
+// endLoop closes a loop, evaluating its condition
+// again at the end of its body
+func endLoop(s *Semantic, rule Rule, line int, column int) {
+	s.loopStarts = s.loopStarts[:len(s.loopStarts)-1]
+	if len(s.loopEndCodes) < len(s.loopStarts)+1 {
+		// The condition had a semantic error
+		s.AddToCodeBuffer("}\n")
+		return
+	}
+	last := len(s.loopEndCodes) - 1
+	s.AddToCodeBuffer(s.loopEndCodes[last] + "}\n")
+	s.loopEndCodes = s.loopEndCodes[:last]
+}
+
+// loopHeader opens a loop on the temporal of its condition
+func loopHeader(s *Semantic, rule Rule, line int, column int) {
+	s.semanticStack.Pop() // remove "fc_p" from stack
+	rawExp_r, _ := s.semanticStack.Pop()
+	exp_r := rawExp_r.(lexer.Token)
+	// The code evaluating the condition again at the end of the
+	// loop writes the temporal before it is read, so it is free
+	// meanwhile, like the temporals of the operands
+	s.release(exp_r)
+	s.AddToCodeBuffer(fmt.Sprintf("while (%s) {\n", exp_r.GetLexem()))
+}
+
+// arithmeticOperation stores the result of a
+// binary arithmetic operation on a new temporal
+func arithmeticOperation(s *Semantic, rule Rule, line int, column int) {
+
+	rawOprd2, _ := s.semanticStack.Pop()
+	oprd2 := rawOprd2.(lexer.Token)
+
+	rawOpm, _ := s.semanticStack.Pop()
+	opm := rawOpm.(lexer.Token)
+
+	rawOprd1, _ := s.semanticStack.Pop()
+	oprd1 := rawOprd1.(lexer.Token)
+
+	if oprd1.GetType() != oprd2.GetType() && oprd1.GetType() != lexer.LITERAL && oprd2.GetType() != lexer.LITERAL && !mixedNumbers(oprd1, oprd2) {
+		log.Printf("Erro: Operandos com tipos incompatíveis na linha %d, coluna %d. '%s' é do tipo '%s', enquanto que '%s' é do tipo '%s'\n", line, column, oprd1.GetLexem(), oprd1.GetType(), oprd2.GetLexem(), oprd2.GetType())
+		s.errorFound = true
+		return
+	}
+
+	// The result can be stored on the temporal of an operand
+	s.release(oprd1, oprd2)
+	temporal := ""
+	operationType := lexer.NULL
+
+	switch {
+	case mixedNumbers(oprd1, oprd2):
+		// C promotes the inteiro operand, as the sem package expects
+		temporal = s.NewTemporal(TemporalFloat)
+		operationType = lexer.REAL
+	case oprd1.GetType() == lexer.INTEGER:
+		temporal = s.NewTemporal(TemporalInt)
+		operationType = lexer.INTEGER
+	case oprd1.GetType() == lexer.REAL:
+		temporal = s.NewTemporal(TemporalFloat)
+		operationType = lexer.REAL
+	}
+
+	s.AddToCodeBuffer(fmt.Sprintf("%s = %s %s %s;\n", temporal, oprd1.GetLexem(), opm.GetLexem(), oprd2.GetLexem()))
+	newToken := lexer.NewToken(lexer.TokenClass(rule.Left), temporal, operationType)
+	s.semanticStack.Push(newToken)
+}
+
+// mixedNumbers returns whether one of the operands is
+// inteiro and the other real, which are promoted to real
+func mixedNumbers(oprd1, oprd2 lexer.Token) bool {
+	first, second := oprd1.GetType(), oprd2.GetType()
+	return (first == lexer.INTEGER && second == lexer.REAL) || (first == lexer.REAL && second == lexer.INTEGER)
+}
+
+// passThrough hands the value of the only
+// symbol of the rule over to its left side
+func passThrough(s *Semantic, rule Rule, line int, column int) {
+	token, _ := s.semanticStack.Pop()
+	tokenConverted := token.(lexer.Token)
+	newToken := lexer.NewToken(lexer.TokenClass(rule.Left), tokenConverted.GetLexem(), tokenConverted.GetType())
+	s.semanticStack.Push(newToken)
 }
 
 type Semantic struct {
@@ -326,6 +414,69 @@ type Semantic struct {
 	codeBuffer    *CodeBuffer
 	ruleMap       map[int]func(s *Semantic, rule Rule, line int, column int)
 	symbolTable   *lexer.SymbolTable
+	errorFound    bool
+	// implicitDeclarations makes the first assignment to an
+	// undeclared variable declare it with the type of the value
+	implicitDeclarations bool
+	// loopStarts holds where the code of each open repita
+	// begins and loopEndCodes the code that evaluates its
+	// condition again, innermost loop last
+	loopStarts   []int
+	loopEndCodes []string
+	// live holds the type of the temporals whose value is still to be
+	// read, and free the ones already read, which a new temporal of
+	// their type reuses instead of being declared
+	live map[string]TemporalType
+	free map[TemporalType][]string
+	// sourceComments makes each statement of the source
+	// be written as a comment before its code
+	sourceComments bool
+	// decimalComma makes escreva write the reals with a comma
+	decimalComma bool
+	// shifted holds the tokens shifted since the last statement ended,
+	// and statementStart where the code of the next one starts
+	shifted        []shiftedToken
+	statementStart int
+	statements     []statement
+}
+
+type shiftedToken struct {
+	token    lexer.Token
+	position lexer.Position
+}
+
+// statement is a statement of the source, whose code
+// starts at offset on the code of the semantic actions
+type statement struct {
+	offset   int
+	position lexer.Position
+	text     string
+}
+
+// statementRules holds the rules ending a statement, with the
+// class of a token it has and how many tokens before it starts
+var statementRules = map[int]struct {
+	class  string
+	before int
+}{
+	12: {"leia", 0},
+	13: {"escreva", 0},
+	18: {"rcb", 1},
+	25: {"se", 0},
+	33: {"repita", 0},
+	52: {"enquanto", 0},
+}
+
+// boundaryRules holds the rules ending a declaration or a block,
+// whose code belongs to none of the statements around them
+var boundaryRules = map[int]bool{6: true, 24: true, 32: true, 51: true}
+
+// SourceLine tells the line of the source the code on a line of the
+// generated C comes from, up to the line of the next SourceLine
+type SourceLine struct {
+	Generated int            `json:"generated"`
+	Source    lexer.Position `json:"source"`
+	Text      string         `json:"text"`
 }
 
 func NewSemantic(symbolTable *lexer.SymbolTable) *Semantic {
@@ -334,40 +485,219 @@ func NewSemantic(symbolTable *lexer.SymbolTable) *Semantic {
 		codeBuffer:    NewCodeBuffer(),
 		ruleMap:       rulesMap,
 		symbolTable:   symbolTable,
+		live:          make(map[string]TemporalType),
+		free:          make(map[TemporalType][]string),
 	}
 }
 
+// Shift pushes a token read by the parser, found
+// at position on the source, onto the semantic stack
+func (s *Semantic) Shift(token lexer.Token, position lexer.Position) {
+	switch token.GetClass() {
+	case "repita", "enquanto":
+		s.loopStarts = append(s.loopStarts, s.codeBuffer.code.Len())
+	case "senao":
+		s.AddToCodeBuffer("} else {\n")
+		s.endStatement()
+	}
+	s.shifted = append(s.shifted, shiftedToken{token, position})
+	s.semanticStack.Push(token)
+}
+
 func (s *Semantic) ExecuteRule(rule Rule, line int, column int) {
-	_, found := s.ruleMap[rule.Number+1]
-	if !found {
+	number := rule.Number + 1
+	if action, found := s.ruleMap[number]; found {
+		action(s, rule, line, column)
+	}
+	statementRule, isStatement := statementRules[number]
+	if isStatement {
+		s.recordStatement(statementRule.class, statementRule.before)
+	}
+	if isStatement || boundaryRules[number] {
+		s.endStatement()
+	}
+}
+
+// recordStatement records the statement just reduced, which starts
+// before tokens before the last shifted token of class
+func (s *Semantic) recordStatement(class string, before int) {
+	start := len(s.shifted) - 1
+	for start >= 0 && s.shifted[start].token.GetClass() != class {
+		start--
+	}
+	start -= before
+	if start < 0 {
 		return
 	}
-	s.ruleMap[rule.Number+1](s, rule, line, column)
+	s.statements = append(s.statements, statement{
+		offset:   s.statementStart,
+		position: s.shifted[start].position,
+		text:     statementText(s.shifted[start:]),
+	})
+}
+
+// endStatement marks where the code of the next statement starts
+func (s *Semantic) endStatement() {
+	s.statementStart = s.codeBuffer.code.Len()
+	s.shifted = s.shifted[:0]
+}
+
+// statementText writes tokens like on the source, without the semicolon
+func statementText(tokens []shiftedToken) string {
+	var text strings.Builder
+	for index, shifted := range tokens {
+		class := shifted.token.GetClass()
+		if class == "pt_v" {
+			break
+		}
+		if index > 0 && class != "fc_p" && tokens[index-1].token.GetClass() != "ab_p" {
+			text.WriteByte(' ')
+		}
+		text.WriteString(shifted.token.GetLexem())
+	}
+	return text.String()
+}
+
+// SetSourceComments makes each statement of the source be written as
+// a comment before its code, like /* linha 12: A <- B + C */
+func (s *Semantic) SetSourceComments(enabled bool) {
+	s.sourceComments = enabled
+}
+
+// SetDecimalComma makes escreva write the reals with a comma
+// as the decimal separator, like 3,140000, instead of a point
+func (s *Semantic) SetDecimalComma(enabled bool) {
+	s.decimalComma = enabled
+}
+
+// SetImplicitDeclarations makes the first assignment to an
+// undeclared variable declare it, instead of being an error
+func (s *Semantic) SetImplicitDeclarations(enabled bool) {
+	s.implicitDeclarations = enabled
+}
+
+// withCurrentType returns token with the type its variable has now.
+// The parser reads a token ahead, so one read before the assignment
+// that declared its variable has no type yet
+func (s *Semantic) withCurrentType(token lexer.Token) lexer.Token {
+	if token.GetType() != lexer.NULL || !s.implicitDeclarations {
+		return token
+	}
+	if dataType, err := s.symbolTable.GetType(token.GetLexem()); err == nil {
+		token.SetType(dataType)
+	}
+	return token
+}
+
+// declareImplicitly declares name, assigned a value of dataType
+// before being declared, on the symbol table and on the code
+func (s *Semantic) declareImplicitly(name string, dataType lexer.DataType) {
+	s.symbolTable.SetType(name, dataType)
+	fmt.Fprintf(&s.codeBuffer.declarations, "%s %s;\n", cTypes[dataType], name)
+}
+
+// undeclared stops the code generation on the use of an undeclared
+// variable. It is reported by the sem package, which knows its
+// exact position and can suggest a similar name
+func (s *Semantic) undeclared() {
+	s.errorFound = true
+}
+
+// ErrorFound returns whether a semantic error was found
+func (s *Semantic) ErrorFound() bool {
+	return s.errorFound
 }
 
 func (s *Semantic) AddToCodeBuffer(code string) {
-	s.codeBuffer.code += code
+	s.codeBuffer.code.WriteString(code)
 }
 
-// NewTemporal adds a new temporal variable of TemporalType
+// NewTemporal returns a temporal variable of TemporalType, reusing
+// one whose value was already read or else declaring a new one
 func (s *Semantic) NewTemporal(temporalType TemporalType) string {
-	temporalId := len(s.codeBuffer.temporals)
-	s.codeBuffer.temporals = append(s.codeBuffer.temporals, temporalType)
-	return fmt.Sprintf("T%d", temporalId)
+	temporal := ""
+	if free := s.free[temporalType]; len(free) > 0 {
+		temporal = free[len(free)-1]
+		s.free[temporalType] = free[:len(free)-1]
+	} else {
+		temporal = fmt.Sprintf("T%d", len(s.codeBuffer.temporals))
+		s.codeBuffer.temporals = append(s.codeBuffer.temporals, temporalType)
+	}
+	s.live[temporal] = temporalType
+	return temporal
 }
 
-func (s *Semantic) GenerateCode() {
-	currentCode := `
-#include<stdio.h>
-#include<stdbool.h>
-typedef char literal[256];
-void main() {
-`
-	currentCode = fmt.Sprintf("%s%s", currentCode, s.codeBuffer.PrintTemporals())
+// release frees the temporals among operands, which are
+// read once, by the instruction being added
+func (s *Semantic) release(operands ...lexer.Token) {
+	for _, operand := range operands {
+		temporalType, found := s.live[operand.GetLexem()]
+		if !found {
+			continue
+		}
+		delete(s.live, operand.GetLexem())
+		s.free[temporalType] = append(s.free[temporalType], operand.GetLexem())
+	}
+}
+
+// Source returns the whole C program: the runtime preamble
+// and a main function with the code of the semantic actions
+func (s *Semantic) Source() string {
+	source, _ := s.sourceWithMap()
+	return source
+}
+
+// SourceMap returns where the code of each statement starts on the
+// C program of Source, the lines being counted from 1
+func (s *Semantic) SourceMap() []SourceLine {
+	_, sourceMap := s.sourceWithMap()
+	return sourceMap
+}
+
+// EncodeSourceMap writes SourceMap as json
+func (s *Semantic) EncodeSourceMap(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(s.SourceMap())
+}
+
+func (s *Semantic) sourceWithMap() (string, []SourceLine) {
+	var currentCode strings.Builder
+	lines := 0
+	write := func(code string) {
+		currentCode.WriteString(code)
+		lines += strings.Count(code, "\n")
+	}
+	write(preamble(s.decimalComma) + "\nint main(void) {\n")
+	write(s.codeBuffer.PrintDeclarations())
+	write(s.codeBuffer.PrintTemporals())
+
+	sourceMap := []SourceLine{}
+	code := s.codeBuffer.Code()
+	written := 0
+	for _, statement := range s.statements {
+		write(code[written:statement.offset])
+		written = statement.offset
+		if s.sourceComments {
+			// A literal with */ would end the comment
+			write(fmt.Sprintf("/* linha %d: %s */\n", statement.position.Line, strings.ReplaceAll(statement.text, "*/", "* /")))
+		}
+		sourceMap = append(sourceMap, SourceLine{Generated: lines + 1, Source: statement.position, Text: statement.text})
+	}
+	write(code[written:])
+	write("return 0;\n}\n")
 
-	currentCode = fmt.Sprintf("%s%s", currentCode, s.codeBuffer.code)
+	return currentCode.String(), sourceMap
+}
 
-	currentCode = fmt.Sprintf("%s%s", currentCode, "\n}")
+// GenerateCode writes the C program on programa.c
+func (s *Semantic) GenerateCode() {
+	ioutil.WriteFile("programa.c", []byte(s.Source()), 0755)
+}
 
-	ioutil.WriteFile("programa.c", []byte(currentCode), 0755)
+// WriteCode writes the C program on w
+func (s *Semantic) WriteCode(w io.Writer) error {
+	_, err := io.WriteString(w, s.Source())
+	return err
 }
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/lexer"
+	"strings"
+	"text/tabwriter"
+)
+
+// tracer writes each step taken by the parser: the
+// stack, the lookahead token and the action taken
+type tracer struct {
+	writer  *tabwriter.Writer
+	step    int
+	symbols []string
+}
+
+func newTracer(w io.Writer) *tracer {
+	t := &tracer{writer: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+	fmt.Fprintln(t.writer, "Passo\tPilha\tEntrada\tAção")
+	return t
+}
+
+// SetTrace makes the parser write the derivation step by step
+// to w, showing the stack, the lookahead token and the action
+// taken on each step. A nil writer disables the trace
+func (p *Parser) SetTrace(w io.Writer) {
+	if w == nil {
+		p.tracer = nil
+		return
+	}
+	p.tracer = newTracer(w)
+}
+
+// stackString interleaves the states of the parser
+// stack with the grammar symbols between them
+func (t *tracer) stackString(states []interface{}) string {
+	// Error recovery pops states without telling the tracer
+	if len(t.symbols) > len(states)-1 && len(states) > 0 {
+		t.symbols = t.symbols[:len(states)-1]
+	}
+
+	parts := make([]string, 0, len(states)+len(t.symbols))
+	for idx, state := range states {
+		if idx > 0 && idx-1 < len(t.symbols) {
+			parts = append(parts, t.symbols[idx-1])
+		}
+		parts = append(parts, fmt.Sprint(state))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (t *tracer) trace(states []interface{}, token lexer.Token, action string) {
+	t.step++
+	fmt.Fprintf(t.writer, "%d\t%s\t%s\t%s\n", t.step, t.stackString(states), tokenSymbol(token), action)
+}
+
+func (t *tracer) shift(states []interface{}, token lexer.Token, state int) {
+	t.trace(states, token, fmt.Sprintf("empilha %d", state))
+	t.symbols = append(t.symbols, tokenSymbol(token))
+}
+
+func (t *tracer) reduce(states []interface{}, token lexer.Token, rule Rule) {
+	t.trace(states, token, fmt.Sprintf("reduz %s -> %s", rule.Left, strings.Join(rule.Right, " ")))
+	t.symbols = append(t.symbols[:len(t.symbols)-len(rule.Right)], rule.Left)
+}
+
+// push records that the error recovery pushed the
+// state on top of states, reached with symbol
+func (t *tracer) push(states []interface{}, symbol string) {
+	if len(t.symbols) > len(states)-2 {
+		t.symbols = t.symbols[:len(states)-2]
+	}
+	t.symbols = append(t.symbols, symbol)
+}
+
+func (t *tracer) accept(states []interface{}, token lexer.Token) {
+	t.trace(states, token, "aceita")
+}
+
+func (t *tracer) error(states []interface{}, token lexer.Token, message string) {
+	t.trace(states, token, "erro: "+message)
+}
+
+func (t *tracer) flush() {
+	t.writer.Flush()
+}
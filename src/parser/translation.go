@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+var ErrorUnknownProduction = fmt.Errorf("produção inexistente na gramática")
+
+// Reduction is what a semantic rule gets: the rule reduced and
+// the attributes of the symbols of its right side
+type Reduction struct {
+	Rule Rule
+	// Values holds the attribute of each symbol of the right side.
+	// A terminal has its lexer.Token and a non terminal what the
+	// semantic rule of the production that reduced it returned
+	Values []interface{}
+	// Span covers the source reduced
+	Span ast.Span
+}
+
+// Token returns the token of the terminal at index on the right side
+func (r *Reduction) Token(index int) lexer.Token {
+	return r.Values[index].(lexer.Token)
+}
+
+// SemanticRule computes the attribute of the left side
+// of a production from the ones of its right side
+type SemanticRule func(r *Reduction) interface{}
+
+// TranslationScheme attaches semantic rules to the productions of the
+// grammar, which the parser runs on each reduction, bottom up. It is
+// the syntax directed translation of the textbooks: each non terminal
+// has a synthesized attribute, the value returned by its rule
+type TranslationScheme struct {
+	rules   *RulesMap
+	actions map[int]SemanticRule
+}
+
+// NewTranslationScheme returns a scheme without semantic rules
+// for the productions of rules
+func NewTranslationScheme(rules *RulesMap) *TranslationScheme {
+	return &TranslationScheme{rules: rules, actions: make(map[int]SemanticRule)}
+}
+
+// On attaches action to production, written like on the BNF grammar:
+// "LD -> LD opm TERMO". A production without a rule passes the
+// attribute of its only symbol on, and has none when it has more
+func (t *TranslationScheme) On(production string, action SemanticRule) error {
+	number, found := t.find(production)
+	if !found {
+		return fmt.Errorf("%w: %s", ErrorUnknownProduction, production)
+	}
+	t.actions[number] = action
+	return nil
+}
+
+func (t *TranslationScheme) find(production string) (int, bool) {
+	sides := strings.SplitN(production, "->", 2)
+	if len(sides) != 2 {
+		return 0, false
+	}
+	left := strings.TrimSpace(sides[0])
+	right := strings.Join(strings.Fields(sides[1]), " ")
+	for number, rule := range *t.rules {
+		if rule.Left == left && strings.Join(rule.Right, " ") == right {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// run returns the attribute of the left side of reduction
+func (t *TranslationScheme) run(reduction *Reduction) interface{} {
+	if action, found := t.actions[reduction.Rule.Number]; found {
+		return action(reduction)
+	}
+	if len(reduction.Values) == 1 {
+		return reduction.Values[0]
+	}
+	return nil
+}
+
+// translator mirrors the parser stack with the attributes of the
+// symbols, running the semantic rules of its scheme on each reduction
+type translator struct {
+	scheme *TranslationScheme
+	values []interface{}
+	spans  []ast.Span
+	failed bool
+}
+
+func (t *translator) shift(token lexer.Token, start, end lexer.Position) {
+	if t.scheme == nil || t.failed {
+		return
+	}
+	t.values = append(t.values, token)
+	t.spans = append(t.spans, ast.Span{Start: start, End: end})
+}
+
+func (t *translator) reduce(rule Rule) {
+	if t.scheme == nil || t.failed {
+		return
+	}
+	size := len(rule.Right)
+	if size == 0 || size > len(t.values) {
+		t.fail()
+		return
+	}
+
+	first := len(t.values) - size
+	reduction := &Reduction{
+		Rule:   rule,
+		Values: append([]interface{}(nil), t.values[first:]...),
+		Span:   ast.Span{Start: t.spans[first].Start, End: t.spans[len(t.spans)-1].End},
+	}
+	value := t.scheme.run(reduction)
+	t.values = append(t.values[:first], value)
+	t.spans = append(t.spans[:first], reduction.Span)
+}
+
+// fail stops the translation, the attributes no
+// longer match the parser stack after a syntax error
+func (t *translator) fail() {
+	t.failed = true
+	t.values = nil
+	t.spans = nil
+}
+
+// result returns the attribute of the start symbol, nil
+// when the translation did not get to the end
+func (t *translator) result() interface{} {
+	if t.scheme == nil || t.failed || len(t.values) != 1 {
+		return nil
+	}
+	return t.values[0]
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newPostfixScheme translates the expressions written
+// by escreva to postfix notation, one per line
+func newPostfixScheme(t *testing.T) *TranslationScheme {
+	scheme := NewTranslationScheme(GetRulesMap(grammarPath))
+	lexeme := func(r *Reduction) interface{} { return r.Token(0).GetLexem() }
+	binary := func(r *Reduction) interface{} {
+		return r.Values[0].(string) + " " + r.Values[2].(string) + " " + r.Token(1).GetLexem()
+	}
+	statements := func(r *Reduction) interface{} {
+		lines, _ := r.Values[1].([]string)
+		if line, ok := r.Values[0].(string); ok {
+			lines = append([]string{line}, lines...)
+		}
+		return lines
+	}
+
+	rules := []struct {
+		production string
+		action     SemanticRule
+	}{
+		{"OPRD -> id", lexeme},
+		{"OPRD -> num", lexeme},
+		{"OPRD -> ab_p LD fc_p", func(r *Reduction) interface{} { return r.Values[1] }},
+		{"TERMO -> TERMO opmul OPRD", binary},
+		{"LD -> LD opm TERMO", binary},
+		{"ARG -> id", lexeme},
+		{"ARG -> num", lexeme},
+		{"ES -> escreva ARG pt_v", func(r *Reduction) interface{} { return r.Values[1] }},
+		{"A -> ES A", statements},
+		{"A -> CMD A", statements},
+		{"A -> fim", func(r *Reduction) interface{} { return []string{} }},
+		{"P  ->  inicio V   A", func(r *Reduction) interface{} { return strings.Join(r.Values[2].([]string), "\n") }},
+	}
+	for _, rule := range rules {
+		require.NoError(t, scheme.On(rule.production, rule.action))
+	}
+	return scheme
+}
+
+func TestTranslationScheme(t *testing.T) {
+	source := "inicio\nvarinicio\ninteiro A;\nvarfim;\nleia A;\n" +
+		"escreva A + 2 * 3;\nA <- 1;\nescreva (A - 1) / 2;\nescreva A;\nfim"
+	parsers := map[string]Analyzer{"slr": newTestParser(t, source), "descendente": newTestDescentParser(t, source)}
+	for name, analyzer := range parsers {
+		t.Run(name, func(t *testing.T) {
+			analyzer.SetTranslation(newPostfixScheme(t))
+			result := analyzer.Parse()
+			require.True(t, result.Succeeded())
+			require.Equal(t, "A 2 3 * +\nA 1 - 2 /\nA", result.Translation)
+		})
+	}
+}
+
+func TestTranslationSchemeAfterSyntaxError(t *testing.T) {
+	p := newTestParser(t, "inicio\nvarinicio\ninteiro A;\nvarfim;\nescreva A +;\nfim")
+	p.SetTranslation(newPostfixScheme(t))
+	require.Nil(t, p.Parse().Translation)
+}
+
+func TestTranslationSchemeUnknownProduction(t *testing.T) {
+	scheme := NewTranslationScheme(GetRulesMap(grammarPath))
+	for _, production := range []string{"LD -> LD opmul TERMO", "LD", ""} {
+		err := scheme.On(production, func(r *Reduction) interface{} { return nil })
+		require.True(t, errors.Is(err, ErrorUnknownProduction), production)
+	}
+}
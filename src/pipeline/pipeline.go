@@ -0,0 +1,303 @@
+// Package pipeline compiles a program split among several files in
+// stages, each behind an interface: the tokens of each file are read
+// from a lexer.TokenSource, parsed as a single program by a Parser,
+// checked by a Checker and generated by a backend.Backend.
+//
+// Each file is scanned on a goroutine of its own, started once the
+// parser reads the first token of the file before it, so file N+1 is
+// scanned while file N is parsed. The scanners stay at most
+// Lookahead tokens ahead of the parser, waiting for it past them.
+//
+//	result, err := pipeline.Pipeline{Backend: target}.Compile(ctx, files, w)
+//
+// The diagnostics of every stage go to a single stream, in the order
+// they are found, and are on the result sorted by file and position.
+//
+// The mgol command compiles through it with stages of its own, which
+// keep the parser, whose semantic actions write the C program
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"sort"
+)
+
+// DefaultLookahead is how many tokens of a file are read ahead
+// of the parser when Pipeline.Lookahead is 0
+const DefaultLookahead = 256
+
+// File is a file of the program
+type File struct {
+	Name string
+	Text string
+}
+
+// Parser is the stage that parses the tokens of every file, read
+// one source after another, as a single program. The identifiers
+// are on symbolTable, shared by every stage
+type Parser interface {
+	Parse(symbolTable *lexer.SymbolTable, sources []lexer.TokenSource) *parser.ParseResult
+}
+
+// Checker is the stage that checks the program parsed
+type Checker interface {
+	Check(program *ast.Program, symbolTable *lexer.SymbolTable) *sem.Info
+}
+
+// DescentParser is the recursive descent parser, with
+// its semantic actions off as the Checker does their work
+type DescentParser struct {
+	// Implicit declares the variables on their first assignment
+	Implicit bool
+}
+
+func (d DescentParser) Parse(symbolTable *lexer.SymbolTable, sources []lexer.TokenSource) *parser.ParseResult {
+	p := parser.NewSourceParser(symbolTable, parser.DefaultRules(), sources...)
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	p.SetImplicitDeclarations(d.Implicit)
+	return p.Parse()
+}
+
+// TypeChecker is the checker of the sem package
+type TypeChecker struct {
+	Narrowing sem.Strictness
+	Promotion sem.Strictness
+	Implicit  bool
+}
+
+func (t TypeChecker) Check(program *ast.Program, symbolTable *lexer.SymbolTable) *sem.Info {
+	checker := sem.NewChecker(symbolTable)
+	checker.SetNarrowing(t.Narrowing)
+	checker.SetPromotion(t.Promotion)
+	checker.SetImplicitDeclarations(t.Implicit)
+	return checker.Check(program)
+}
+
+// Diagnostic is a diagnostic found on File
+type Diagnostic struct {
+	File string
+	errorhandling.Diagnostic
+}
+
+func (d Diagnostic) String() string {
+	if d.File == "" {
+		return d.Diagnostic.String()
+	}
+	return fmt.Sprintf("%s: %v", d.File, d.Diagnostic)
+}
+
+// Pipeline tells the stages a program goes through. Its zero value
+// parses and checks a program on mgol, generating no code
+type Pipeline struct {
+	// Language is the dialect of the files, mgol when nil
+	Language *lexer.Language
+	// Parser is DescentParser when nil, and Checker TypeChecker
+	Parser  Parser
+	Checker Checker
+	// Backend, when set, generates the program once accepted
+	Backend backend.Backend
+	// Lookahead is how many tokens of a file may be read
+	// ahead of the parser, DefaultLookahead when 0
+	Lookahead int
+	// Sequential scans each file only as the parser reads it,
+	// on the same goroutine, like the compilers of one file do
+	Sequential bool
+	// Configure, when set, sets up the scanner of each file before
+	// it reads any token, like turning on its keyword case warning
+	Configure func(scanner *lexer.Scanner)
+	// Diagnostics, when set, gets each diagnostic as it is found
+	Diagnostics func(Diagnostic)
+}
+
+// Result is what compiling the files gave
+type Result struct {
+	// Program and Info are nil when the program could not be parsed
+	Program *ast.Program
+	Info    *sem.Info
+	// Diagnostics holds the errors and warnings found, sorted
+	// by the order of their files, then by position
+	Diagnostics []Diagnostic
+	// Accepted tells whether the program has no errors
+	Accepted bool
+}
+
+// Compile compiles files as a single program, read in order, and
+// writes on w the code of the Backend, when there is one and the
+// program is accepted. It only fails when the backend does, or when
+// ctx is done before the program is compiled
+func (p Pipeline) Compile(ctx context.Context, files []File, w io.Writer) (Result, error) {
+	language := p.Language
+	if language == nil {
+		language = lexer.DefaultLanguage()
+	}
+	parseStage := p.Parser
+	if parseStage == nil {
+		parseStage = DescentParser{}
+	}
+	checkStage := p.Checker
+	if checkStage == nil {
+		checkStage = TypeChecker{}
+	}
+
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(language.ReservedWords())
+	stream := newDiagnosticStream(files, p.Diagnostics)
+
+	sources, stop := p.sources(ctx, files, symbolTable, language, stream)
+	parsed := parseStage.Parse(symbolTable, sources)
+	stop()
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	for _, syntaxError := range parsed.Errors {
+		stream.handle(syntaxError.Span.Start.File, syntaxError.Diagnostic())
+	}
+	for _, ioError := range parsed.IOErrors {
+		stream.handle(ioError.Span.Start.File, ioError.Diagnostic())
+	}
+
+	result := Result{Program: parsed.Program}
+	if parsed.Program != nil {
+		result.Info = checkStage.Check(parsed.Program, symbolTable)
+		for _, warning := range result.Info.Warnings {
+			stream.handle(warning.Span.Start.File, warning.Diagnostic())
+		}
+		for _, semanticError := range result.Info.Errors {
+			stream.handle(semanticError.Span.Start.File, semanticError.Diagnostic())
+		}
+	}
+	result.Diagnostics = stream.sorted()
+	result.Accepted = result.Info != nil && parsed.Accepted && !stream.errorFound
+
+	if p.Backend != nil && result.Accepted {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		if err := p.Backend.Generate(result.Program, result.Info, w); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// sources returns the sources of the tokens of files, along with
+// a function stopping the scanners still reading ahead
+func (p Pipeline) sources(ctx context.Context, files []File, symbolTable *lexer.SymbolTable, language *lexer.Language, stream *diagnosticStream) ([]lexer.TokenSource, func()) {
+	lookahead := p.Lookahead
+	if lookahead == 0 {
+		lookahead = DefaultLookahead
+	}
+	scanners := make([]*lexer.Scanner, len(files))
+	for index, file := range files {
+		scanner := lexer.NewStringScanner(file.Text, symbolTable)
+		scanner.SetLanguage(language)
+		scanner.SetName(file.Name)
+		scanner.SetDiagnosticHandler(stream.file(file.Name))
+		if p.Configure != nil {
+			p.Configure(scanner)
+		}
+		scanners[index] = scanner
+	}
+
+	sources := make([]lexer.TokenSource, len(files))
+	if p.Sequential {
+		for index, scanner := range scanners {
+			sources[index] = cancelable{ctx, scanner, nil}
+		}
+		return sources, func() {}
+	}
+	prefetched := make([]*lexer.PrefetchSource, len(files))
+	for index, scanner := range scanners {
+		prefetched[index] = lexer.NewPrefetchSource(scanner, lookahead)
+	}
+	for index, source := range prefetched {
+		var next *lexer.PrefetchSource
+		if index+1 < len(prefetched) {
+			next = prefetched[index+1]
+		}
+		sources[index] = cancelable{ctx, source, next}
+	}
+	return sources, func() {
+		for _, source := range prefetched {
+			source.Close()
+		}
+	}
+}
+
+// cancelable reads the tokens of source until ctx is done, starting
+// to read ahead the next file once the first token is read
+type cancelable struct {
+	ctx    context.Context
+	source lexer.TokenSource
+	next   *lexer.PrefetchSource
+}
+
+func (c cancelable) NextToken() lexer.ScannedToken {
+	if c.next != nil {
+		c.next.Start()
+	}
+	if c.ctx.Err() != nil {
+		return lexer.ScannedToken{Token: lexer.EOF_TOKEN}
+	}
+	return c.source.NextToken()
+}
+
+// diagnosticStream merges the diagnostics of every stage. They
+// are only reported on the goroutine of the parser, so it needs
+// no lock: the scanners reading ahead hand theirs to it
+type diagnosticStream struct {
+	order       map[string]int
+	handler     func(Diagnostic)
+	diagnostics []Diagnostic
+	errorFound  bool
+}
+
+func newDiagnosticStream(files []File, handler func(Diagnostic)) *diagnosticStream {
+	order := make(map[string]int, len(files))
+	for index, file := range files {
+		if _, found := order[file.Name]; !found {
+			order[file.Name] = index
+		}
+	}
+	return &diagnosticStream{order: order, handler: handler}
+}
+
+func (s *diagnosticStream) handle(file string, diagnostic errorhandling.Diagnostic) {
+	found := Diagnostic{file, diagnostic}
+	s.diagnostics = append(s.diagnostics, found)
+	s.errorFound = s.errorFound || diagnostic.Severity == errorhandling.Error
+	if s.handler != nil {
+		s.handler(found)
+	}
+}
+
+// file returns the handler of the diagnostics of the scanner of file
+func (s *diagnosticStream) file(name string) errorhandling.DiagnosticHandler {
+	return errorhandling.DiagnosticHandlerFunc(func(diagnostic errorhandling.Diagnostic) {
+		s.handle(name, diagnostic)
+	})
+}
+
+// sorted returns the diagnostics sorted by file, then by position
+func (s *diagnosticStream) sorted() []Diagnostic {
+	sorted := append([]Diagnostic(nil), s.diagnostics...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if first, second := s.order[sorted[i].File], s.order[sorted[j].File]; first != second {
+			return first < second
+		}
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Column < sorted[j].Column
+	})
+	return sorted
+}
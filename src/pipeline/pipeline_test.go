@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/mgol"
+	"strings"
+	"testing"
+
+	_ "mgol-go/src/pygen"
+
+	"github.com/stretchr/testify/require"
+)
+
+// splitProgram returns a program whose statements are
+// split among files, the first one declaring the variables
+func splitProgram(files, statements int) []File {
+	split := []File{{"decl.mgol", "inicio\nvarinicio\n\tinteiro A;\n\tinteiro B;\nvarfim;\nleia B;\n"}}
+	for file := 0; file < files; file++ {
+		source := &strings.Builder{}
+		for i := 0; i < statements; i++ {
+			fmt.Fprintf(source, "leia A;\nB <- (A + %d) * B - A / 2;\n", i)
+			fmt.Fprintf(source, "se (A > %d) entao\n\tescreva B;\nsenao\n\tA <- A + 1;\nfimse\n", i)
+			source.WriteString("repita (A < 10)\n\tA <- A + 1;\nfimrepita\n")
+		}
+		split = append(split, File{fmt.Sprintf("parte%d.mgol", file), source.String()})
+	}
+	return append(split, File{"fim.mgol", "fim\n"})
+}
+
+func join(files []File) string {
+	joined := ""
+	for _, file := range files {
+		joined += file.Text
+	}
+	return joined
+}
+
+func TestCompile(t *testing.T) {
+	target, found := backend.Lookup("python")
+	require.True(t, found)
+	files := splitProgram(3, 5)
+
+	var expected bytes.Buffer
+	compiled := mgol.Compile(join(files), mgol.Options{})
+	require.True(t, compiled.Accepted)
+	require.NoError(t, target.Generate(compiled.Program, compiled.Info, &expected))
+
+	for name, pipeline := range map[string]Pipeline{
+		"pipelined":  {Backend: target},
+		"lookahead":  {Backend: target, Lookahead: 1},
+		"sequential": {Backend: target, Sequential: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var code bytes.Buffer
+			result, err := pipeline.Compile(context.Background(), files, &code)
+			require.NoError(t, err)
+			require.True(t, result.Accepted)
+			require.Empty(t, result.Diagnostics)
+			require.Equal(t, expected.String(), code.String())
+			require.Equal(t, "parte1.mgol", result.Program.Body[21].Pos().File)
+		})
+	}
+}
+
+func TestCompileDiagnostics(t *testing.T) {
+	files := []File{
+		{"a.mgol", "inicio varinicio inteiro A; varfim;\n"},
+		{"b.mgol", "leia A;\nescreva C;\n"},
+		{"c.mgol", "escreva A $;\nfim\n"},
+	}
+	undeclared := "b.mgol: erro na linha 2 coluna 9, variável não declarada: 'C'"
+	invalid := "c.mgol: erro na linha 1 coluna 11, palavra $ inexistente na linguagem"
+
+	for _, sequential := range []bool{false, true} {
+		var streamed []string
+		result, err := Pipeline{
+			Lookahead:   1,
+			Sequential:  sequential,
+			Diagnostics: func(diagnostic Diagnostic) { streamed = append(streamed, diagnostic.String()) },
+		}.Compile(context.Background(), files, nil)
+		require.NoError(t, err)
+		require.False(t, result.Accepted)
+		// The stream has them as found, the scanners before the checker
+		require.Equal(t, []string{invalid, undeclared}, streamed)
+		require.Len(t, result.Diagnostics, 2)
+		require.Equal(t, undeclared, result.Diagnostics[0].String())
+		require.Equal(t, invalid, result.Diagnostics[1].String())
+	}
+}
+
+func TestCompileConfigure(t *testing.T) {
+	files := []File{{"a.mgol", "inicio varinicio inteiro Leia; varfim;\nleia Leia;\nescreva Leia;\nfim\n"}}
+	for _, warn := range []bool{false, true} {
+		result, err := Pipeline{
+			Configure: func(scanner *lexer.Scanner) { scanner.SetKeywordCaseWarning(warn) },
+		}.Compile(context.Background(), files, nil)
+		require.NoError(t, err)
+		require.True(t, result.Accepted)
+		if warn {
+			require.Len(t, result.Diagnostics, 3)
+		} else {
+			require.Empty(t, result.Diagnostics)
+		}
+	}
+}
+
+func TestCompileCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Pipeline{}.Compile(ctx, splitProgram(2, 5), nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func benchmarkCompile(b *testing.B, pipeline Pipeline, files int) {
+	split := splitProgram(files, 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := pipeline.Compile(context.Background(), split, nil)
+		if err != nil || !result.Accepted {
+			b.Fatal("the program was not accepted")
+		}
+	}
+}
+
+func BenchmarkCompileSequential4Files(b *testing.B) {
+	benchmarkCompile(b, Pipeline{Sequential: true}, 4)
+}
+
+func BenchmarkCompilePipelined4Files(b *testing.B) {
+	benchmarkCompile(b, Pipeline{}, 4)
+}
+
+func BenchmarkCompileSequential32Files(b *testing.B) {
+	benchmarkCompile(b, Pipeline{Sequential: true}, 32)
+}
+
+func BenchmarkCompilePipelined32Files(b *testing.B) {
+	benchmarkCompile(b, Pipeline{}, 32)
+}
@@ -0,0 +1,267 @@
+// Package playground serves the compiler over HTTP, for a web page
+// where students write and run programs without installing Go. A
+// program is sent as json to /compile:
+//
+//	POST /compile
+//	{"source": "inicio varinicio varfim; escreva \"oi\"; fim", "target": "python", "run": true}
+//
+// and the answer holds its tokens, syntax tree, diagnostics, the code
+// generated for the target and, when run, what it wrote. The programs
+// are always run on the sandbox profile of the limits package, as
+// anyone may send them, and at most Handler.MaxConcurrent at once.
+// GET /targets lists the targets
+package playground
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/mgol"
+	"mgol-go/src/sem"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	ErrorUnknownTarget    = fmt.Errorf("alvo desconhecido")
+	ErrorMethodNotAllowed = fmt.Errorf("método não permitido")
+	ErrorBusy             = fmt.Errorf("serviço ocupado, tente de novo em instantes")
+)
+
+// Defaults of a Handler
+const (
+	DefaultMaxRequest = 64 << 10
+)
+
+// Request is a program sent to /compile
+type Request struct {
+	Source string `json:"source"`
+	// Input is what the program reads with leia, when run
+	Input string `json:"input"`
+	// Target is the one the code is generated for, none when empty
+	Target       string `json:"target"`
+	Run          bool   `json:"run"`
+	DecimalComma bool   `json:"decimal_comma"`
+	Implicit     bool   `json:"implicit"`
+}
+
+// Diagnostic is an error or warning found on the program
+type Diagnostic struct {
+	Severity errorhandling.Severity `json:"severity"`
+	Code     string                 `json:"code"`
+	Line     int                    `json:"line"`
+	Column   int                    `json:"column"`
+	Message  string                 `json:"message"`
+	Count    int                    `json:"count"`
+}
+
+// Response is the answer to a Request
+type Response struct {
+	// Tokens are the ones of lexer.DumpTokens on json, and AST the
+	// tree of ast.EncodeJSON, null when the program was not parsed
+	Tokens      json.RawMessage `json:"tokens"`
+	AST         json.RawMessage `json:"ast"`
+	Diagnostics []Diagnostic    `json:"diagnostics"`
+	// Accepted tells whether the program has no errors, so it
+	// was generated for the target and run when asked to
+	Accepted bool   `json:"accepted"`
+	Code     string `json:"code,omitempty"`
+	// CodeBase64 tells that Code is encoded on base64, as the
+	// target writes a binary file, like the bytecode
+	CodeBase64 bool `json:"code_base64,omitempty"`
+	// Status is the one of mgol.Status, when the program was run
+	Status string `json:"status,omitempty"`
+	Output string `json:"output,omitempty"`
+	// Error is the one that stopped the program that was run
+	Error string `json:"error,omitempty"`
+}
+
+// Handler answers the requests of the playground
+type Handler struct {
+	// MaxRequest is the size, in bytes, of the largest
+	// request read, and MaxOutput of the output kept
+	MaxRequest int64
+	MaxOutput  int
+	// AllowOrigin is sent on Access-Control-Allow-Origin, for a
+	// page served from another origin, like "*". None when empty
+	AllowOrigin string
+	// MaxConcurrent is how many programs sent to /compile are
+	// handled at once, runtime.NumCPU() when 0. The ones sent
+	// past it are answered with 503 Service Unavailable at once
+	MaxConcurrent int
+
+	once  sync.Once
+	slots chan struct{}
+}
+
+// NewHandler returns a Handler with the default limits
+func NewHandler() *Handler {
+	return &Handler{MaxRequest: DefaultMaxRequest, MaxOutput: limits.DefaultMaxOutput}
+}
+
+func (h *Handler) init() {
+	h.once.Do(func() {
+		concurrent := h.MaxConcurrent
+		if concurrent <= 0 {
+			concurrent = runtime.NumCPU()
+		}
+		h.slots = make(chan struct{}, concurrent)
+	})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.init()
+	if h.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", h.AllowOrigin)
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	switch r.URL.Path {
+	case "/compile":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, ErrorMethodNotAllowed)
+			return
+		}
+		select {
+		case h.slots <- struct{}{}:
+			defer func() { <-h.slots }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusServiceUnavailable, ErrorBusy)
+			return
+		}
+		var request Request
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.MaxRequest))
+		if err := decoder.Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		response, err := h.Compile(r.Context(), request)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	case "/targets":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, ErrorMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, backend.Names())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Compile answers request, failing only when it is wrong,
+// like when it asks for an unknown target
+func (h *Handler) Compile(ctx context.Context, request Request) (*Response, error) {
+	var target backend.Backend
+	if request.Target != "" {
+		var found bool
+		if target, found = backend.Lookup(request.Target); !found {
+			return nil, fmt.Errorf("%w: %s, os disponíveis são %s", ErrorUnknownTarget, request.Target, strings.Join(backend.Names(), ", "))
+		}
+		if configurable, found := target.(backend.Configurable); found {
+			target = configurable.WithOptions(backend.Options{DecimalComma: request.DecimalComma})
+		}
+	}
+
+	// The warnings of the implicit conversions are shown,
+	// as the students are learning when they happen
+	options := mgol.Options{
+		Narrowing:    sem.Warn,
+		Promotion:    sem.Warn,
+		Implicit:     request.Implicit,
+		DecimalComma: request.DecimalComma,
+		Sandbox:      true,
+	}
+	compiled := mgol.Compile(request.Source, options)
+	response := &Response{Diagnostics: make([]Diagnostic, len(compiled.Diagnostics)), Accepted: compiled.Accepted}
+	for index, diagnostic := range compiled.Diagnostics {
+		response.Diagnostics[index] = Diagnostic{diagnostic.Severity, diagnostic.Code, diagnostic.Line, diagnostic.Column, diagnostic.Message, diagnostic.Count}
+	}
+
+	var encoded bytes.Buffer
+	if err := lexer.DumpTokens(&encoded, scan(request.Source), lexer.JSONFormat); err != nil {
+		return nil, err
+	}
+	response.Tokens = append(json.RawMessage(nil), encoded.Bytes()...)
+	response.AST = json.RawMessage("null")
+	if compiled.Program != nil {
+		encoded.Reset()
+		if err := ast.EncodeJSON(&encoded, compiled.Program); err != nil {
+			return nil, err
+		}
+		response.AST = append(json.RawMessage(nil), encoded.Bytes()...)
+	}
+	if !compiled.Accepted {
+		return response, nil
+	}
+
+	if target != nil {
+		var code bytes.Buffer
+		if err := target.Generate(compiled.Program, compiled.Info, &code); err != nil {
+			return nil, err
+		}
+		response.Code = code.String()
+		if !utf8.Valid(code.Bytes()) {
+			response.Code = base64.StdEncoding.EncodeToString(code.Bytes())
+			response.CodeBase64 = true
+		}
+	}
+	if request.Run {
+		output := limits.NewOutputBuffer(h.MaxOutput)
+		result := compiled.Run(ctx, strings.NewReader(request.Input), output, options)
+		response.Status = result.Status.String()
+		response.Output = output.String()
+		if output.Exceeded() {
+			response.Status = mgol.Failed.String()
+			result.Err = limits.ErrorOutputLimit
+		}
+		if result.Err != nil {
+			response.Error = result.Err.Error()
+		}
+	}
+	return response, nil
+}
+
+// scan returns the tokens of source, the comments and errors
+// included, as the diagnostics were already found
+func scan(source string) []lexer.ScannedToken {
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	scanner := lexer.NewStringScanner(source, symbolTable)
+	scanner.SetDiagnosticHandler(errorhandling.DiagnosticHandlerFunc(func(errorhandling.Diagnostic) {}))
+	tokens := []lexer.ScannedToken{}
+	for {
+		token, line, column := scanner.Scan()
+		if token == lexer.EOF_TOKEN {
+			return tokens
+		}
+		tokens = append(tokens, lexer.ScannedToken{Token: token, Start: scanner.TokenStart(), End: lexer.Position{Line: line, Column: column}})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
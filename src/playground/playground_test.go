@@ -0,0 +1,129 @@
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"mgol-go/src/limits"
+	"mgol-go/src/mgol"
+	_ "mgol-go/src/pygen"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	handler := NewHandler()
+	response, err := handler.Compile(context.Background(), Request{
+		Source: "inicio varinicio inteiro A; varfim; leia A; escreva A; fim",
+		Input:  "42\n",
+		Target: "python",
+		Run:    true,
+	})
+	require.NoError(t, err)
+	require.True(t, response.Accepted)
+	require.Empty(t, response.Diagnostics)
+	require.Equal(t, mgol.Succeeded.String(), response.Status)
+	require.Equal(t, "42", response.Output)
+	require.Contains(t, response.Code, "input()")
+	require.False(t, response.CodeBase64)
+
+	var tokens []map[string]interface{}
+	require.NoError(t, json.Unmarshal(response.Tokens, &tokens))
+	require.Len(t, tokens, 14)
+	require.Equal(t, "inicio", tokens[0]["lexeme"])
+	require.True(t, strings.HasPrefix(string(response.AST), "{"))
+
+	_, err = handler.Compile(context.Background(), Request{Source: "inicio varinicio varfim; fim", Target: "cobol"})
+	require.ErrorIs(t, err, ErrorUnknownTarget)
+}
+
+func TestCompileRejected(t *testing.T) {
+	response, err := NewHandler().Compile(context.Background(), Request{Source: "inicio varinicio varfim;\nescreva B;\nfim", Target: "python", Run: true})
+	require.NoError(t, err)
+	require.False(t, response.Accepted)
+	require.Equal(t, []Diagnostic{{"erro", "S001", 2, 9, "variável não declarada: 'B'", 1}}, response.Diagnostics)
+	require.Empty(t, response.Code)
+	require.Empty(t, response.Status)
+}
+
+func TestCompileSandbox(t *testing.T) {
+	source := "inicio varinicio inteiro A; varfim; repita (A = A) escreva \"mgol\"; fimrepita fim"
+	handler := NewHandler()
+	handler.MaxOutput = 10
+	response, err := handler.Compile(context.Background(), Request{Source: source, Run: true})
+	require.NoError(t, err)
+	require.Equal(t, mgol.Failed.String(), response.Status)
+	require.Equal(t, "mgolmgolmg", response.Output)
+	require.Equal(t, limits.ErrorOutputLimit.Error(), response.Error)
+
+	source = "inicio varinicio inteiro A; varfim; repita (A = A) A <- A + 1; fimrepita fim"
+	response, err = NewHandler().Compile(context.Background(), Request{Source: source, Run: true})
+	require.NoError(t, err)
+	require.Equal(t, mgol.Failed.String(), response.Status)
+	require.Contains(t, response.Error, string(limits.Iterations))
+}
+
+func TestServeHTTP(t *testing.T) {
+	handler := NewHandler()
+	handler.AllowOrigin = "*"
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	answer, err := http.Post(server.URL+"/compile", "application/json", strings.NewReader(`{"source": "inicio varinicio varfim; escreva \"oi\"; fim", "run": true}`))
+	require.NoError(t, err)
+	defer answer.Body.Close()
+	require.Equal(t, http.StatusOK, answer.StatusCode)
+	require.Equal(t, "*", answer.Header.Get("Access-Control-Allow-Origin"))
+	var response Response
+	require.NoError(t, json.NewDecoder(answer.Body).Decode(&response))
+	require.Equal(t, "oi", response.Output)
+
+	testCases := []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{http.MethodGet, "/targets", "", http.StatusOK},
+		{http.MethodGet, "/compile", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/compile", "{", http.StatusBadRequest},
+		{http.MethodPost, "/compile", `{"source": "` + strings.Repeat("a", DefaultMaxRequest) + `"}`, http.StatusBadRequest},
+		{http.MethodPost, "/compile", `{"source": "fim", "target": "cobol"}`, http.StatusBadRequest},
+		{http.MethodGet, "/", "", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		request, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+		require.NoError(t, err)
+		answer, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		answer.Body.Close()
+		require.Equal(t, tc.expected, answer.StatusCode, tc.method+" "+tc.path)
+	}
+}
+
+func TestServeHTTPBusy(t *testing.T) {
+	handler := NewHandler()
+	handler.MaxConcurrent = 1
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	post := func() *http.Response {
+		answer, err := http.Post(server.URL+"/compile", "application/json", strings.NewReader(`{"source": "inicio varinicio varfim; fim"}`))
+		require.NoError(t, err)
+		answer.Body.Close()
+		return answer
+	}
+
+	// A program being compiled takes the only slot
+	handler.init()
+	handler.slots <- struct{}{}
+	answer := post()
+	require.Equal(t, http.StatusServiceUnavailable, answer.StatusCode)
+	require.Equal(t, "1", answer.Header.Get("Retry-After"))
+
+	<-handler.slots
+	require.Equal(t, http.StatusOK, post().StatusCode)
+	require.Empty(t, handler.slots)
+}
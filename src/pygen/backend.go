@@ -0,0 +1,34 @@
+package pygen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(pythonBackend{})
+}
+
+// pythonBackend is the Python target on the registry of backends
+type pythonBackend struct {
+	options backend.Options
+}
+
+func (pythonBackend) Name() string {
+	return "python"
+}
+
+func (pythonBackend) FileExtension() string {
+	return ".py"
+}
+
+func (b pythonBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return GenerateWithOptions(w, program, info, b.options)
+}
+
+func (b pythonBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
@@ -0,0 +1,306 @@
+// Package pygen is a backend that writes a program as Python 3, to
+// run where there is no C compiler, only an interpreter: leia is read
+// with input and escreva is written with print
+package pygen
+
+import (
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrorBadNode = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+
+// readers holds the helper leia is translated to for each type
+var readers = map[lexer.DataType]string{
+	lexer.INTEGER: "leia_inteiro",
+	lexer.REAL:    "leia_real",
+	lexer.LITERAL: "leia_literal",
+}
+
+// helpers holds the source of the functions leia is translated to,
+// which are only written when used. A value is read on each line, and
+// a number is read from its start, what can not be read taken as 0,
+// like scanf leaves it
+var helpers = map[string]string{
+	"leia": `def leia():
+    try:
+        return input().strip()
+    except EOFError:
+        return ""`,
+	"leia_inteiro": `def leia_inteiro():
+    numero = re.match(r"[+-]?[0-9]+", leia())
+    return int(numero.group()) if numero else 0`,
+	"leia_real": `def leia_real():
+    numero = re.match(r"[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?", leia())
+    return float(numero.group()) if numero else 0.0`,
+	"leia_literal": `def leia_literal():
+    return leia()`,
+}
+
+// initial holds the value the variables of each type start with
+var initial = map[lexer.DataType]string{
+	lexer.INTEGER: "0",
+	lexer.REAL:    "0.0",
+	lexer.LITERAL: `""`,
+}
+
+// pyOperators holds the relational operators written differently in Python
+var pyOperators = map[string]string{
+	"=":  "==",
+	"<>": "!=",
+}
+
+// precedences holds how tightly each operator binds its operands
+var precedences = map[string]int{
+	"*": 2,
+	"/": 2,
+	"+": 1,
+	"-": 1,
+}
+
+// reserved holds the names a variable can not have on the generated
+// program: the keywords and the names the program uses or declares
+var reserved = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true,
+	"if": true, "import": true, "in": true, "is": true, "lambda": true,
+	"nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+	"print": true, "input": true, "int": true, "float": true, "re": true,
+	"EOFError": true, "leia": true, "leia_inteiro": true, "leia_real": true,
+	"leia_literal": true,
+}
+
+// name returns the Python name of the variable named name
+func name(variable string) string {
+	if reserved[variable] {
+		return variable + "_"
+	}
+	return variable
+}
+
+type generator struct {
+	info    *sem.Info
+	options backend.Options
+	body    strings.Builder
+	depth   int
+	used    map[string]bool
+	err     error
+}
+
+func (g *generator) line(format string, args ...interface{}) {
+	g.body.WriteString(strings.Repeat("    ", g.depth))
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteByte('\n')
+}
+
+// Generate writes program as a Python script. info holds what the
+// sem package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	return GenerateWithOptions(w, program, info, backend.Options{})
+}
+
+// GenerateWithOptions writes program like Generate, following options
+func GenerateWithOptions(w io.Writer, program *ast.Program, info *sem.Info, options backend.Options) error {
+	g := &generator{info: info, options: options, used: make(map[string]bool)}
+	g.stmts(program.Body)
+	if g.err != nil {
+		return g.err
+	}
+
+	var source strings.Builder
+	if g.used["leia_inteiro"] || g.used["leia_real"] {
+		source.WriteString("import re\n\n\n")
+	}
+	for _, helper := range sortedKeys(g.used) {
+		source.WriteString(helpers[helper] + "\n\n\n")
+	}
+
+	declarations := append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...)
+	for _, declaration := range declarations {
+		fmt.Fprintf(&source, "%s = %s\n", name(declaration.Name.Name), initial[declaration.Type])
+	}
+	if len(declarations) > 0 {
+		source.WriteByte('\n')
+	}
+	source.WriteString(g.body.String())
+
+	_, err := io.WriteString(w, source.String())
+	return err
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (g *generator) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		g.stmt(stmt)
+	}
+}
+
+func (g *generator) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		reader := readers[g.info.TypeOf(node.Target)]
+		g.used[reader], g.used["leia"] = true, true
+		g.line("%s = %s()", name(node.Target.Name), reader)
+	case *ast.Write:
+		value := g.expr(node.Value, 0)
+		if g.info.TypeOf(node.Value) == lexer.REAL {
+			// The C backend writes reals with %lf
+			if _, variable := node.Value.(*ast.Ident); !variable {
+				value = "(" + value + ")"
+			}
+			value = "\"%f\" % " + value
+			if g.options.DecimalComma {
+				value = fmt.Sprintf("(%s).replace(\".\", \",\")", value)
+			}
+		}
+		g.line("print(%s, end=\"\")", value)
+	case *ast.Assign:
+		value := g.expr(node.Value, 0)
+		if g.info.TypeOf(node.Target) == lexer.INTEGER && g.info.TypeOf(node.Value) == lexer.REAL {
+			value = fmt.Sprintf("int(%s)", value)
+		}
+		g.line("%s = %s", name(node.Target.Name), value)
+	case *ast.If:
+		g.line("if %s:", g.expr(node.Condition, 0))
+		g.block(node.Body)
+		if node.Else != nil {
+			g.line("else:")
+			g.block(node.Else)
+		}
+	case *ast.While:
+		g.line("while %s:", g.expr(node.Condition, 0))
+		g.block(node.Body)
+	default:
+		g.err = ErrorBadNode
+	}
+}
+
+// block writes stmts indented, or pass if there
+// are none, as Python needs a statement on a block
+func (g *generator) block(stmts []ast.Stmt) {
+	g.depth++
+	if len(stmts) == 0 {
+		g.line("pass")
+	}
+	g.stmts(stmts)
+	g.depth--
+}
+
+// expr returns expr written in Python as an operand
+// of an operation whose precedence is parent
+func (g *generator) expr(expr ast.Expr, parent int) string {
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		if g.wraps(node) {
+			// Python ints never overflow, so an inteiro is wrapped
+			// at 32 bits like a C int
+			return fmt.Sprintf("((%s + 2**31) %% 2**32 - 2**31)", g.binary(node, precedences["+"]))
+		}
+		return g.binary(node, parent)
+	case *ast.Literal:
+		return literal(node)
+	case *ast.Ident:
+		return name(node.Name)
+	}
+	g.err = ErrorBadNode
+	return ""
+}
+
+// binary returns node written in Python like expr, without
+// wrapping its own result
+func (g *generator) binary(node *ast.BinaryExpr, parent int) string {
+	own := precedences[node.Operator]
+	operator := node.Operator
+	if pyOperator, found := pyOperators[operator]; found {
+		operator = pyOperator
+	}
+	operand := func(operand ast.Expr, parent int) string {
+		// +, - and * keep the low bits of their operands, so
+		// only the last one of a chain of them is wrapped
+		if child, isBinary := operand.(*ast.BinaryExpr); isBinary && g.wraps(node) && g.wraps(child) {
+			return g.binary(child, parent)
+		}
+		return g.expr(operand, parent)
+	}
+	source := fmt.Sprintf("%s %s %s", operand(node.Left, own), operator, operand(node.Right, own+1))
+	// / always gives a float, and // rounds down instead of
+	// toward zero, so the division of inteiros is truncated
+	// like on a C int
+	if node.Operator == "/" && g.info.TypeOf(node) == lexer.INTEGER {
+		return fmt.Sprintf("int(%s)", source)
+	}
+	if own < parent {
+		return "(" + source + ")"
+	}
+	return source
+}
+
+// wraps tells whether node is a +, - or * on inteiros, whose result
+// may not fit in 32 bits. The quotient of two inteiros always fits
+func (g *generator) wraps(node *ast.BinaryExpr) bool {
+	return node.Operator != "/" && precedences[node.Operator] > 0 && g.info.TypeOf(node) == lexer.INTEGER
+}
+
+// literal returns the Python constant of a literal or number
+func literal(node *ast.Literal) string {
+	switch node.Type {
+	case lexer.LITERAL:
+		return quote(lexer.LiteralText(node.Value))
+	case lexer.INTEGER, lexer.REAL:
+		value, err := lexer.ParseNumber(node.Value, node.Type)
+		if err != nil {
+			break
+		}
+		if node.Type == lexer.INTEGER {
+			return strconv.FormatInt(int64(value), 10)
+		}
+		// A real is written with a point, or else Python takes it as an int
+		number := strconv.FormatFloat(value, 'g', -1, 64)
+		if !strings.ContainsAny(number, ".e") {
+			number += ".0"
+		}
+		return number
+	}
+	return node.Value
+}
+
+// quote returns text as a Python string literal
+func quote(text string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range text {
+		switch {
+		case r == '"' || r == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(r)
+		case r == '\n':
+			quoted.WriteString(`\n`)
+		case r == '\t':
+			quoted.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&quoted, `\x%02x`, r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
@@ -0,0 +1,102 @@
+package pygen
+
+import (
+	"bytes"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/backend"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// inteiro A; real B; literal print;
+	// leia A;
+	// leia print;
+	// repita (A > 0)
+	//	se (print <> "fim") entao escreva "sim\n"; senao B <- (A + 1) / 2; fimse
+	//	A <- A / 2 - 25E-1;
+	// fimrepita
+	// se (B = 1E6) entao fimse
+	// escreva B * 2;
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			asttest.Declaration(lexer.INTEGER, "A"),
+			asttest.Declaration(lexer.REAL, "B"),
+			asttest.Declaration(lexer.LITERAL, "print"),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.Read{Target: asttest.Ident("print")},
+			&ast.While{Condition: asttest.Binary(">", asttest.Ident("A"), asttest.Literal("0", lexer.INTEGER)), Body: []ast.Stmt{
+				&ast.If{
+					Condition: asttest.Binary("<>", asttest.Ident("print"), asttest.Literal(`"fim"`, lexer.LITERAL)),
+					Body:      []ast.Stmt{&ast.Write{Value: asttest.Literal(`"sim\n"`, lexer.LITERAL)}},
+					Else: []ast.Stmt{&ast.Assign{
+						Target: asttest.Ident("B"),
+						Value:  asttest.Binary("/", asttest.Binary("+", asttest.Ident("A"), asttest.Literal("1", lexer.INTEGER)), asttest.Literal("2", lexer.INTEGER)),
+					}},
+				},
+				&ast.Assign{Target: asttest.Ident("A"), Value: asttest.Binary("-", asttest.Binary("/", asttest.Ident("A"), asttest.Literal("2", lexer.INTEGER)), asttest.Literal("25E-1", lexer.INTEGER))},
+			}},
+			&ast.If{Condition: asttest.Binary("=", asttest.Ident("B"), asttest.Literal("1E6", lexer.REAL))},
+			&ast.Write{Value: asttest.Binary("*", asttest.Ident("B"), asttest.Literal("2", lexer.INTEGER))},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, program, info))
+	require.Equal(t, `import re
+
+
+def leia():
+    try:
+        return input().strip()
+    except EOFError:
+        return ""
+
+
+def leia_inteiro():
+    numero = re.match(r"[+-]?[0-9]+", leia())
+    return int(numero.group()) if numero else 0
+
+
+def leia_literal():
+    return leia()
+
+
+A = 0
+B = 0.0
+print_ = ""
+
+A = leia_inteiro()
+print_ = leia_literal()
+while A > 0:
+    if print_ != "fim":
+        print("sim\\n", end="")
+    else:
+        B = int(((A + 1 + 2**31) % 2**32 - 2**31) / 2)
+    A = ((int(A / 2) - 2 + 2**31) % 2**32 - 2**31)
+if B == 1e+06:
+    pass
+print("%f" % (B * 2), end="")
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, GenerateWithOptions(&out, program, info, backend.Options{DecimalComma: true}))
+	require.Contains(t, out.String(), `print(("%f" % (B * 2)).replace(".", ","), end="")`)
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.Write{Value: &ast.BadExpr{}}},
+	}
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, sem.NewChecker(nil).Check(program)), ErrorBadNode)
+}
@@ -0,0 +1,335 @@
+// Package repl runs mgol a line at a time: each declaration is kept
+// for the lines after it, each statement is run right away by the
+// interp package on the variables declared so far, and each
+// arithmetic expression has its value shown:
+//
+//	mgol> inteiro A;
+//	mgol> leia A;
+//	41
+//	mgol> A + 1
+//	42
+//
+// A se, repita or enquanto left open continues on the next lines, up
+// to its fimse, fimrepita or fimenquanto. The lines starting with a
+// colon are commands, like :tokens, :ast and :symbols, that show what
+// the compiler sees
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/console"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/interp"
+	"mgol-go/src/lexer"
+	"mgol-go/src/parser"
+	"mgol-go/src/sem"
+	"strings"
+)
+
+var ErrorUnknownCommand = fmt.Errorf("comando desconhecido")
+
+// The prompts shown before an entry and before each
+// line that goes on with a block left open
+const (
+	Prompt             = "mgol> "
+	ContinuationPrompt = "....> "
+)
+
+// help describes the commands
+const help = `:tokens TEXTO   mostra os tokens de TEXTO
+:ast TEXTO      mostra a árvore sintática de TEXTO, sem executá-lo
+:symbols        mostra as variáveis declaradas, com seus valores
+:ajuda          mostra esta ajuda
+:sair           termina a sessão
+`
+
+// REPL is a session, keeping the declarations and the values of the
+// variables from an entry to the next
+type REPL struct {
+	rules       *parser.RulesMap
+	input       *bufio.Reader
+	output      *console.LineWriter
+	prompts     bool
+	symbolTable *lexer.SymbolTable
+	environment *interp.Environment
+	// declarations holds the source of the entries that declared
+	// variables, and declared the declarations parsed from them
+	declarations []string
+	declared     []*ast.VarDecl
+}
+
+// New returns a session that reads the entries from r, and the values
+// of leia as well, writing on w, the reals with options. The entries
+// are parsed with the rules of grammar.json
+func New(rules *parser.RulesMap, r io.Reader, w io.Writer, options backend.Options) *REPL {
+	input := bufio.NewReader(r)
+	output := console.NewLineWriter(w)
+	symbolTable := lexer.NewSymbolTable()
+	symbolTable.SetReservedWords(lexer.DefaultReservedWords())
+	return &REPL{
+		rules:       rules,
+		input:       input,
+		output:      output,
+		prompts:     true,
+		symbolTable: symbolTable,
+		environment: interp.NewEnvironment(console.New(input, output, options), options),
+	}
+}
+
+// SetPrompts turns the prompts on or off, for input
+// that does not come from a terminal
+func (r *REPL) SetPrompts(enabled bool) {
+	r.prompts = enabled
+}
+
+// Run reads and runs the entries until the end of the input or
+// :sair. The errors of an entry are shown and the session goes on,
+// so Run only fails when the output can not be written
+func (r *REPL) Run() error {
+	for {
+		entry, err := r.readEntry()
+		if entry == ":sair" {
+			return nil
+		}
+		if entry != "" {
+			if entryErr := r.Eval(entry); entryErr != nil {
+				fmt.Fprintln(r.output, entryErr)
+			}
+			r.output.EndLine()
+		}
+		if r.output.Err() != nil {
+			return r.output.Err()
+		}
+		if err != nil {
+			// Leaves the terminal on a new line after the last prompt
+			r.showPrompt("\n")
+			return r.output.Err()
+		}
+	}
+}
+
+// readEntry reads the next line that is not empty, along with the
+// lines after it while there are blocks left open
+func (r *REPL) readEntry() (string, error) {
+	r.showPrompt(Prompt)
+	var lines []string
+	for {
+		line, err := r.input.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		entry := strings.Join(lines, "\n")
+		if err != nil || (entry != "" && (strings.HasPrefix(entry, ":") || r.openBlocks(entry) <= 0)) {
+			return entry, err
+		}
+		if entry == "" {
+			r.showPrompt(Prompt)
+		} else {
+			r.showPrompt(ContinuationPrompt)
+		}
+	}
+}
+
+func (r *REPL) showPrompt(prompt string) {
+	if r.prompts {
+		io.WriteString(r.output, prompt)
+		// The line is ended by the entry typed after the prompt
+		r.output.LineEnded()
+	}
+}
+
+// openBlocks returns how many se and loop blocks of entry are open
+func (r *REPL) openBlocks(entry string) int {
+	open := 0
+	for _, token := range r.scan(entry) {
+		switch token.Token.GetClass() {
+		case "se", "repita", "enquanto":
+			open++
+		case "fimse", "fimrepita", "fimenquanto":
+			open--
+		}
+	}
+	return open
+}
+
+// Eval runs entry, a command, declarations, statements or an
+// arithmetic expression, whose value is written on its own line
+func (r *REPL) Eval(entry string) error {
+	if strings.HasPrefix(entry, ":") {
+		return r.command(entry)
+	}
+	if expr, err := parser.ParseExpression(entry); err == nil {
+		info, err := r.checkExpression(expr)
+		if err != nil {
+			return err
+		}
+		result, err := r.environment.Eval(expr, info)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.output, result)
+		return nil
+	}
+
+	snapshot := r.symbolTable.Snapshot()
+	program, info, err := r.parse(entry)
+	if err != nil {
+		r.symbolTable.Restore(snapshot)
+		return err
+	}
+	if len(program.Declarations) > len(r.declared) {
+		r.declarations = append(r.declarations, entry)
+		r.declared = program.Declarations
+		r.environment.Declare(program.Declarations)
+	}
+	return r.environment.Exec(program.Body, info)
+}
+
+func (r *REPL) command(entry string) error {
+	name, argument := entry, ""
+	if space := strings.IndexAny(entry, " \t"); space >= 0 {
+		name, argument = entry[:space], strings.TrimSpace(entry[space:])
+	}
+	switch name {
+	case ":tokens":
+		for _, token := range r.scan(argument) {
+			fmt.Fprintf(r.output, "%d:%d %s\n", token.Start.Line, token.Start.Column, token.Token)
+		}
+	case ":ast":
+		return r.showAST(argument)
+	case ":symbols":
+		for _, variable := range r.environment.Variables() {
+			fmt.Fprintln(r.output, variable)
+		}
+	case ":ajuda":
+		io.WriteString(r.output, help)
+	default:
+		return fmt.Errorf("%w: %s, :ajuda lista os comandos", ErrorUnknownCommand, name)
+	}
+	return nil
+}
+
+// showAST writes the syntax tree of the expression, or of the
+// declarations and statements, of text as json
+func (r *REPL) showAST(text string) error {
+	if expr, err := parser.ParseExpression(text); err == nil {
+		return ast.EncodeJSON(r.output, expr)
+	}
+	snapshot := r.symbolTable.Snapshot()
+	defer r.symbolTable.Restore(snapshot)
+	program, _, err := r.parse(text)
+	if err != nil {
+		return err
+	}
+	for _, declaration := range program.Declarations[len(r.declared):] {
+		if err := ast.EncodeJSON(r.output, declaration); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range program.Body {
+		if err := ast.EncodeJSON(r.output, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan returns the tokens of text, the comments and errors included,
+// leaving the symbol table as it was
+func (r *REPL) scan(text string) []lexer.ScannedToken {
+	snapshot := r.symbolTable.Snapshot()
+	defer r.symbolTable.Restore(snapshot)
+	scanner := lexer.NewStringScanner(text, r.symbolTable)
+	scanner.SetDiagnosticHandler(errorhandling.NewDiagnosticBuffer())
+
+	var tokens []lexer.ScannedToken
+	for {
+		token, line, column := scanner.Scan()
+		if token == lexer.EOF_TOKEN {
+			return tokens
+		}
+		tokens = append(tokens, lexer.ScannedToken{Token: token, Start: scanner.TokenStart(), End: lexer.Position{Line: line, Column: column}})
+	}
+}
+
+// parse parses entry as the declarations or the body of a program
+// that has the declarations made so far, and checks it. A declaration
+// entry starts with a type
+func (r *REPL) parse(entry string) (*ast.Program, *sem.Info, error) {
+	declaration := false
+	if tokens := r.scan(entry); len(tokens) > 0 {
+		switch tokens[0].Token.GetClass() {
+		case "inteiro", "real", "literal":
+			declaration = true
+		}
+	}
+	prelude := "inicio\nvarinicio\n" + strings.Join(r.declarations, "\n") + "\n"
+	closing := "varfim;\nfim\n"
+	if !declaration {
+		prelude, closing = prelude+"varfim;\n", "fim\n"
+	}
+
+	diagnostics := errorhandling.NewDiagnosticBuffer()
+	entryScanner := lexer.NewStringScanner(entry, r.symbolTable)
+	entryScanner.SetDiagnosticHandler(diagnostics)
+	p := parser.NewRecursiveDescentParser(lexer.NewStringScanner(prelude, r.symbolTable), r.rules)
+	p.AddSource(entryScanner)
+	p.AddSource(lexer.NewStringScanner(closing, r.symbolTable))
+	p.SetQuiet(true)
+	p.SetSemanticActions(false)
+	result := p.Parse()
+
+	var errors entryError
+	for _, diagnostic := range diagnostics.Diagnostics() {
+		if diagnostic.Severity == errorhandling.Error {
+			errors = append(errors, diagnostic.String())
+		}
+	}
+	for _, syntaxError := range result.Errors {
+		errors = append(errors, syntaxError.String())
+	}
+	for _, ioError := range result.IOErrors {
+		errors = append(errors, ioError.Error())
+	}
+	if len(errors) > 0 {
+		return nil, nil, errors
+	}
+
+	info := sem.NewChecker(r.symbolTable).Check(result.Program)
+	if err := semanticErrors(info); err != nil {
+		return nil, nil, err
+	}
+	return result.Program, info, nil
+}
+
+// checkExpression checks expr as the value of an escreva
+// on a program with the declarations made so far
+func (r *REPL) checkExpression(expr ast.Expr) (*sem.Info, error) {
+	program := &ast.Program{Declarations: r.declared, Body: []ast.Stmt{&ast.Write{Value: expr}}}
+	info := sem.NewChecker(nil).Check(program)
+	return info, semanticErrors(info)
+}
+
+// semanticErrors returns the errors of info, leaving the warnings out,
+// as most are about variables not used by a single entry
+func semanticErrors(info *sem.Info) error {
+	if len(info.Errors) == 0 {
+		return nil
+	}
+	errors := make(entryError, len(info.Errors))
+	for index, semanticError := range info.Errors {
+		errors[index] = semanticError.Error()
+	}
+	return errors
+}
+
+// entryError holds the errors found on an entry
+type entryError []string
+
+func (e entryError) Error() string {
+	return strings.Join(e, "\n")
+}
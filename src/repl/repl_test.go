@@ -0,0 +1,90 @@
+package repl
+
+import (
+	"bytes"
+	"mgol-go/src/backend"
+	"mgol-go/src/parser"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const grammarPath = "../parser/grammar.json"
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			"declarations kept between entries",
+			"inteiro A;\nreal B;\nleia A;\n41\nA + 1\nB <- A / 2;\nescreva B;\n",
+			"42\n20.000000\n",
+		},
+		{
+			"block continued on the next lines",
+			"inteiro A;\nA <- 3;\nrepita (A > 0)\nescreva A;\nA <- A - 1;\nfimrepita\n",
+			"321\n",
+		},
+		{
+			"errors do not end the session",
+			"inteiro A;\nA <- ;\nC + 1\ninteiro A;\nA <- 7;\nA\n",
+			"Erro: esperava um operando na expressão na linha 1, coluna 6, esperava identificador, número ou '(', encontrou ';'\n" +
+				"Erro semântico na linha 1, coluna 1: variável não declarada: 'C'\n" +
+				"Erro semântico na linha 1, coluna 1: variável declarada mais de uma vez: 'A' já foi declarada na linha 3, coluna 1\n" +
+				"7\n",
+		},
+		{
+			"symbols",
+			"inteiro A;\nliteral NOME;\nleia NOME;\nAna Maria\nA <- 2;\n:symbols\n",
+			"inteiro A = 2\nliteral NOME = \"Ana Maria\"\n",
+		},
+		{
+			"tokens",
+			":tokens A <- 1;\n",
+			"1:1 Classe: id, Lexema: A, Tipo: NULO\n" +
+				"1:3 Classe: RCB, Lexema: <-, Tipo: NULO\n" +
+				"1:6 Classe: Num, Lexema: 1, Tipo: inteiro\n" +
+				"1:7 Classe: PT_V, Lexema: ;, Tipo: NULO\n",
+		},
+		{
+			"sair",
+			"inteiro A;\n:sair\nescreva \"depois\";\n",
+			"",
+		},
+		{
+			"unknown command",
+			":limpar\n",
+			"comando desconhecido: :limpar, :ajuda lista os comandos\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			session := New(parser.GetRulesMap(grammarPath), strings.NewReader(tc.input), &output, backend.Options{})
+			session.SetPrompts(false)
+			require.NoError(t, session.Run())
+			require.Equal(t, tc.output, output.String())
+		})
+	}
+}
+
+func TestRunAST(t *testing.T) {
+	var output bytes.Buffer
+	session := New(parser.GetRulesMap(grammarPath), strings.NewReader("inteiro A;\n:ast A <- 1;\nA\n"), &output, backend.Options{})
+	session.SetPrompts(false)
+	require.NoError(t, session.Run())
+	require.Contains(t, output.String(), `"kind": "Assign"`)
+	// The assignment is only shown, A is still zero
+	require.True(t, strings.HasSuffix(output.String(), "}\n0\n"))
+}
+
+func TestRunPrompts(t *testing.T) {
+	var output bytes.Buffer
+	session := New(parser.GetRulesMap(grammarPath), strings.NewReader("inteiro A;\nse (A = 0) entao\nescreva \"zero\";\nfimse\n"), &output, backend.Options{})
+	require.NoError(t, session.Run())
+	require.Equal(t, "mgol> mgol> ....> ....> zero\nmgol> \n", output.String())
+}
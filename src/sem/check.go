@@ -0,0 +1,518 @@
+// Package sem is the semantic analysis of mgol. It walks the syntax
+// tree built by the parser, resolves each identifier to its
+// declaration and finds the type of each expression, reporting the
+// programs that use variables or operands the wrong way
+package sem
+
+import (
+	"fmt"
+	"mgol-go/src/ast"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"strings"
+)
+
+// Posible errors
+var (
+	ErrorUndeclared     = fmt.Errorf("variável não declarada")
+	ErrorRedeclared     = fmt.Errorf("variável declarada mais de uma vez")
+	ErrorAssignType     = fmt.Errorf("tipos diferentes para a atribuição")
+	ErrorNarrowing      = fmt.Errorf("atribuição de um real a um inteiro perde a parte fracionária")
+	ErrorPromotion      = fmt.Errorf("conversão implícita de inteiro para real")
+	ErrorOperandTypes   = fmt.Errorf("operandos com tipos incompatíveis")
+	ErrorOperatorType   = fmt.Errorf("operador aritmético aplicado a um tipo não numérico")
+	ErrorConditionType  = fmt.Errorf("condição não é uma comparação")
+	ErrorComparison     = fmt.Errorf("comparação fora de uma condição")
+	ErrorDivisionByZero = fmt.Errorf("divisão por zero")
+	ErrorUnused         = fmt.Errorf("variável declarada mas nunca usada")
+	ErrorNeverRead      = fmt.Errorf("variável recebe valores mas nunca é lida")
+	ErrorUninitialized  = fmt.Errorf("variável pode ser lida antes de receber um valor")
+	ErrorShadowed       = fmt.Errorf("declaração esconde outra variável com o mesmo nome")
+)
+
+// Boolean is the type of the relational expressions. No variable
+// can be declared with it, so it is only found on conditions
+const Boolean lexer.DataType = "lógico"
+
+// relationalOperators are the operators whose result is Boolean,
+// every other binary operator is arithmetic
+var relationalOperators = map[string]bool{
+	"<":  true,
+	">":  true,
+	"<=": true,
+	">=": true,
+	"=":  true,
+	"<>": true,
+}
+
+// Error is a semantic error, or warning, found on Span. Err is
+// one of the errors above, wrapped with the names and types involved
+type Error struct {
+	Span     ast.Span
+	Severity errorhandling.Severity
+	Err      error
+	// Suggestion is a declared variable named like the
+	// undeclared one, if there is any
+	Suggestion string
+	// Original is where a variable declared twice was
+	// declared first, while Span is the duplicate
+	Original *ast.Span
+}
+
+func (e Error) Error() string {
+	kind := "Erro semântico"
+	if e.Severity == errorhandling.Warning {
+		kind = "Aviso semântico"
+	}
+	return fmt.Sprintf("%s na linha %d, coluna %d: %s", kind, e.Span.Start.Line, e.Span.Start.Column, e.message())
+}
+
+// message describes the error without its position
+func (e Error) message() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%v, você quis dizer '%s'?", e.Err, e.Suggestion)
+	}
+	return e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Usage counts how many times a variable is read, on expressions
+// and escreva, and written, by leia and assignments
+type Usage struct {
+	Reads  int
+	Writes int
+}
+
+// Info is what the checker found out about a program
+type Info struct {
+	// Types holds the type of each expression of the body. It is
+	// NULL on the expressions whose type could not be found
+	// because of an error reported before, or of a syntax error
+	Types map[ast.Expr]lexer.DataType
+	// Uses maps each identifier of the body to its declaration.
+	// Undeclared identifiers are left out
+	Uses map[*ast.Ident]*ast.VarDecl
+	// Usage holds how each declared variable is used. The
+	// warnings about unused variables, of the checker and of
+	// the linter, come from it alone
+	Usage map[*ast.VarDecl]Usage
+	// Errors holds the semantic errors found, in source order
+	Errors []Error
+	// Warnings holds what is allowed but may be a mistake,
+	// like the implicit conversions when they only warn
+	Warnings []Error
+	// Conversions maps the expressions converted implicitly,
+	// the inteiro operands promoted to real, to their new type
+	Conversions map[ast.Expr]lexer.DataType
+	// Constants holds the value of the arithmetic expressions made
+	// only of numbers. Operations on inteiro truncate like in C
+	Constants map[ast.Expr]float64
+	// Implicit holds the declarations synthesized for the variables
+	// declared on their first assignment, spanning its target
+	Implicit []*ast.VarDecl
+}
+
+// TypeOf returns the type found for expr, NULL if it is unknown
+func (i *Info) TypeOf(expr ast.Expr) lexer.DataType {
+	if dataType, found := i.Types[expr]; found {
+		return dataType
+	}
+	return lexer.NULL
+}
+
+// Checker runs the semantic analysis with its settings
+type Checker struct {
+	symbolTable *lexer.SymbolTable
+	narrowing   Strictness
+	promotion   Strictness
+	implicit    bool
+}
+
+// NewChecker returns a checker that records the type of each
+// declared variable on symbolTable, when it is not nil
+func NewChecker(symbolTable *lexer.SymbolTable) *Checker {
+	return &Checker{symbolTable: symbolTable, narrowing: Warn, promotion: Warn}
+}
+
+// SetNarrowing sets how the assignments of a real
+// value to an inteiro variable are treated
+func (c *Checker) SetNarrowing(strictness Strictness) {
+	c.narrowing = strictness
+}
+
+// SetPromotion sets how the inteiro values used as real, on
+// operations mixing both or assignments to a real variable,
+// are treated
+func (c *Checker) SetPromotion(strictness Strictness) {
+	c.promotion = strictness
+}
+
+// SetImplicitDeclarations makes the first assignment to an
+// undeclared variable declare it with the type of the value.
+// Reading it before that is still an error
+func (c *Checker) SetImplicitDeclarations(enabled bool) {
+	c.implicit = enabled
+}
+
+// Check analyzes program, which may be a partial tree: its
+// error nodes are skipped
+func (c *Checker) Check(program *ast.Program) *Info {
+	p := &pass{
+		Checker: c,
+		info: &Info{
+			Types:       make(map[ast.Expr]lexer.DataType),
+			Uses:        make(map[*ast.Ident]*ast.VarDecl),
+			Usage:       make(map[*ast.VarDecl]Usage),
+			Conversions: make(map[ast.Expr]lexer.DataType),
+			Constants:   make(map[ast.Expr]float64),
+		},
+		scope:         NewScope(nil),
+		uninitialized: make(map[*ast.VarDecl]bool),
+	}
+
+	for _, declaration := range program.Declarations {
+		p.declare(declaration)
+	}
+	p.stmts(program.Body)
+	p.flow(program.Body, make(assigned))
+	p.unused()
+	return p.info
+}
+
+// Check analyzes program with the default settings
+func Check(program *ast.Program, symbolTable *lexer.SymbolTable) *Info {
+	return NewChecker(symbolTable).Check(program)
+}
+
+// pass is a run of a Checker over a program
+type pass struct {
+	*Checker
+	info  *Info
+	scope *Scope
+	// declared holds the declarations of scope in source order
+	declared []*ast.VarDecl
+	// uninitialized holds the variables already warned
+	// about being read before receiving a value
+	uninitialized map[*ast.VarDecl]bool
+}
+
+func (p *pass) errorf(span ast.Span, err error, format string, args ...interface{}) {
+	wrapped := fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...))
+	p.info.Errors = append(p.info.Errors, Error{Span: span, Severity: errorhandling.Error, Err: wrapped})
+}
+
+func (p *pass) warnf(span ast.Span, err error, format string, args ...interface{}) {
+	wrapped := fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...))
+	p.info.Warnings = append(p.info.Warnings, Error{Span: span, Severity: errorhandling.Warning, Err: wrapped})
+}
+
+// report reports what strictness tells to
+func (p *pass) report(strictness Strictness, span ast.Span, err error, format string, args ...interface{}) {
+	switch strictness {
+	case Warn:
+		p.warnf(span, err, format, args...)
+	case Strict:
+		p.errorf(span, err, format, args...)
+	}
+}
+
+// promote converts expr, of type dataType, to real when it is inteiro
+func (p *pass) promote(expr ast.Expr, dataType lexer.DataType) {
+	if dataType != lexer.INTEGER {
+		return
+	}
+	p.info.Conversions[expr] = lexer.REAL
+	p.report(p.promotion, expr.GetSpan(), ErrorPromotion, "'%s' é usado como real", source(expr))
+}
+
+func (p *pass) declare(declaration *ast.VarDecl) {
+	if declaration == nil || declaration.Name == nil {
+		return
+	}
+	name := declaration.Name.Name
+	original, inserted := p.scope.Insert(declaration)
+	if !inserted {
+		p.info.Errors = append(p.info.Errors, Error{
+			Span:     declaration.Span,
+			Severity: errorhandling.Error,
+			Err:      fmt.Errorf("%w: '%s' já foi declarada na linha %d, coluna %d", ErrorRedeclared, name, original.Pos().Line, original.Pos().Column),
+			Original: &original.Span,
+		})
+		return
+	}
+	if outer := p.scope.Parent(); outer != nil {
+		if shadowed, found := outer.Lookup(name); found {
+			p.warnf(declaration.Span, ErrorShadowed, "'%s' de um bloco externo, declarada na linha %d, coluna %d", name, shadowed.Pos().Line, shadowed.Pos().Column)
+		}
+	}
+	p.declared = append(p.declared, declaration)
+	p.info.Usage[declaration] = Usage{}
+
+	// The symbol table has a single type for each name,
+	// so it gets the one of the outermost declaration
+	if p.symbolTable != nil && p.scope.Parent() == nil {
+		// The parser may not have inserted the name, on a tree
+		// built by other means, so a missing symbol is fine
+		p.symbolTable.SetType(name, declaration.Type)
+	}
+}
+
+// declareOnFirstUse declares ident with dataType, the type of the
+// value assigned to it, unless it was declared before
+func (p *pass) declareOnFirstUse(ident *ast.Ident, dataType lexer.DataType) {
+	if ident == nil || dataType == lexer.NULL {
+		return
+	}
+	if _, found := p.scope.Lookup(ident.Name); found {
+		return
+	}
+	declaration := &ast.VarDecl{Span: ident.Span, Type: dataType, Name: &ast.Ident{Span: ident.Span, Name: ident.Name}}
+	p.declare(declaration)
+	p.info.Implicit = append(p.info.Implicit, declaration)
+}
+
+// enter opens a scope nested on the current one, for a
+// block with its own declarations. Each call must be
+// paired with one to leave
+func (p *pass) enter() {
+	p.scope = NewScope(p.scope)
+}
+
+// leave closes the scope opened by the last call to enter
+func (p *pass) leave() {
+	p.scope = p.scope.Parent()
+}
+
+// resolve returns the type of the variable ident refers to
+func (p *pass) resolve(ident *ast.Ident) lexer.DataType {
+	if ident == nil {
+		return lexer.NULL
+	}
+	declaration, found := p.scope.Lookup(ident.Name)
+	if !found {
+		p.info.Errors = append(p.info.Errors, Error{
+			Span:       ident.Span,
+			Severity:   errorhandling.Error,
+			Err:        fmt.Errorf("%w: '%s'", ErrorUndeclared, ident.Name),
+			Suggestion: p.suggest(ident.Name),
+		})
+		return lexer.NULL
+	}
+	p.info.Uses[ident] = declaration
+	return declaration.Type
+}
+
+// target returns the type of the variable written by a statement
+func (p *pass) target(ident *ast.Ident) lexer.DataType {
+	if ident == nil {
+		return lexer.NULL
+	}
+	dataType := p.resolve(ident)
+	p.info.Types[ident] = dataType
+	if declaration, found := p.info.Uses[ident]; found {
+		usage := p.info.Usage[declaration]
+		usage.Writes++
+		p.info.Usage[declaration] = usage
+	}
+	return dataType
+}
+
+// unused warns about the variables never read, telling
+// apart the ones that are written from the ones never used.
+// The lookups of the symbol table count the writes as well,
+// so the reads are counted on the tree instead
+func (p *pass) unused() {
+	for _, declaration := range p.declared {
+		usage := p.info.Usage[declaration]
+		switch {
+		case usage.Reads > 0:
+		case usage.Writes > 0:
+			p.warnf(declaration.Span, ErrorNeverRead, "'%s'", declaration.Name.Name)
+		default:
+			p.warnf(declaration.Span, ErrorUnused, "'%s'", declaration.Name.Name)
+		}
+	}
+}
+
+func (p *pass) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		p.stmt(stmt)
+	}
+}
+
+func (p *pass) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Assign:
+		p.assign(node)
+	case *ast.Read:
+		p.target(node.Target)
+	case *ast.Write:
+		p.value(node.Value)
+	case *ast.If:
+		p.condition(node.Condition)
+		p.stmts(node.Body)
+		p.stmts(node.Else)
+	case *ast.While:
+		p.condition(node.Condition)
+		p.stmts(node.Body)
+	}
+}
+
+// assign checks that the value fits on the target. An inteiro value
+// is promoted to real, while a real one is narrowed to inteiro,
+// each as allowed by the strictness of the checker
+func (p *pass) assign(node *ast.Assign) {
+	value := p.value(node.Value)
+	if p.implicit {
+		p.declareOnFirstUse(node.Target, value)
+	}
+	target := p.target(node.Target)
+	if target == lexer.NULL || value == lexer.NULL || target == value {
+		return
+	}
+
+	switch {
+	case target == lexer.REAL && value == lexer.INTEGER:
+		p.promote(node.Value, value)
+	case target == lexer.INTEGER && value == lexer.REAL:
+		p.report(p.narrowing, node.Span, ErrorNarrowing, "'%s' é do tipo '%s'", node.Target.Name, target)
+	default:
+		p.errorf(node.Span, ErrorAssignType, "'%s' é do tipo '%s', enquanto que o valor é do tipo '%s'", node.Target.Name, target, value)
+	}
+}
+
+func (p *pass) condition(condition ast.Expr) {
+	dataType := p.expr(condition)
+	if dataType != lexer.NULL && dataType != Boolean {
+		p.errorf(condition.GetSpan(), ErrorConditionType, "a expressão é do tipo '%s'", dataType)
+	}
+}
+
+// expr returns the type of expr, recording it on the info
+func (p *pass) expr(expr ast.Expr) lexer.DataType {
+	dataType := lexer.NULL
+	switch node := expr.(type) {
+	case *ast.Ident:
+		dataType = p.resolve(node)
+		if declaration, found := p.info.Uses[node]; found {
+			usage := p.info.Usage[declaration]
+			usage.Reads++
+			p.info.Usage[declaration] = usage
+		}
+	case *ast.Literal:
+		dataType = node.Type
+		if numeric(dataType) {
+			if value, err := lexer.ParseNumber(node.Value, dataType); err == nil {
+				p.info.Constants[node] = value
+			}
+		}
+	case *ast.BinaryExpr:
+		dataType = p.binary(node)
+	case nil, *ast.BadExpr:
+		return lexer.NULL
+	}
+	p.info.Types[expr] = dataType
+	return dataType
+}
+
+// value returns the type of expr, which is used as a value: written,
+// assigned or operated on. Comparisons are only allowed on conditions
+func (p *pass) value(expr ast.Expr) lexer.DataType {
+	dataType := p.expr(expr)
+	if dataType == Boolean {
+		p.errorf(expr.GetSpan(), ErrorComparison, "'%s' só pode ser usada como condição de se ou repita", source(expr))
+		return lexer.NULL
+	}
+	return dataType
+}
+
+// binary returns the type of an operation. Numbers are compared with
+// numbers and literals with literals, while arithmetic takes numbers
+func (p *pass) binary(node *ast.BinaryExpr) lexer.DataType {
+	left := p.value(node.Left)
+	right := p.value(node.Right)
+	// An unknown operand was already reported
+	if left == lexer.NULL || right == lexer.NULL {
+		return lexer.NULL
+	}
+	if left != right && numeric(left) && numeric(right) {
+		p.promote(node.Left, left)
+		p.promote(node.Right, right)
+		left, right = lexer.REAL, lexer.REAL
+	}
+	if left != right {
+		p.errorf(node.Span, ErrorOperandTypes, "'%s' é do tipo '%s', enquanto que '%s' é do tipo '%s'", source(node.Left), left, source(node.Right), right)
+		return lexer.NULL
+	}
+
+	if relationalOperators[node.Operator] {
+		return Boolean
+	}
+	if !numeric(left) {
+		p.errorf(node.Span, ErrorOperatorType, "'%s' com operandos do tipo '%s'", node.Operator, left)
+		return lexer.NULL
+	}
+	p.fold(node, left)
+	return left
+}
+
+// fold records the value of an arithmetic operation on constants,
+// rejecting the divisions whose divisor is always zero
+func (p *pass) fold(node *ast.BinaryExpr, dataType lexer.DataType) {
+	left, leftConstant := p.info.Constants[node.Left]
+	right, rightConstant := p.info.Constants[node.Right]
+	if node.Operator == "/" && rightConstant && right == 0 {
+		p.errorf(node.Right.GetSpan(), ErrorDivisionByZero, "'%s' é sempre zero", source(node.Right))
+		return
+	}
+	if !leftConstant || !rightConstant {
+		return
+	}
+
+	if dataType == lexer.INTEGER {
+		p.info.Constants[node] = float64(foldInteger(node.Operator, int32(int64(left)), int32(int64(right))))
+		return
+	}
+	var value float64
+	switch node.Operator {
+	case "+":
+		value = left + right
+	case "-":
+		value = left - right
+	case "*":
+		value = left * right
+	case "/":
+		value = left / right
+	default:
+		return
+	}
+	p.info.Constants[node] = value
+}
+
+// foldInteger returns left operator right as an inteiro, which
+// wraps at 32 bits like the int of the C backend
+func foldInteger(operator string, left, right int32) int32 {
+	switch operator {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	}
+	return left / right
+}
+
+func numeric(dataType lexer.DataType) bool {
+	return dataType == lexer.INTEGER || dataType == lexer.REAL
+}
+
+// source returns expr as it is written on mgol
+func source(expr ast.Expr) string {
+	var builder strings.Builder
+	ast.Fprint(&builder, expr)
+	return builder.String()
+}
@@ -0,0 +1,625 @@
+package sem
+
+import (
+	"errors"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	errorhandling "mgol-go/src/error_handling"
+	"mgol-go/src/lexer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ident(name string, line, column int) *ast.Ident {
+	return &ast.Ident{Span: asttest.At(line, column), Name: name}
+}
+
+func literal(value string, dataType lexer.DataType, line, column int) *ast.Literal {
+	return &ast.Literal{Span: asttest.At(line, column), Value: value, Type: dataType}
+}
+
+func declaration(dataType lexer.DataType, name string, line int) *ast.VarDecl {
+	return &ast.VarDecl{Span: asttest.At(line, 1), Type: dataType, Name: ident(name, line, 9)}
+}
+
+// program declares A inteiro, B real and C literal
+// on lines 2 to 4, the body starting on line 6
+func program(body ...ast.Stmt) *ast.Program {
+	return &ast.Program{
+		Declarations: []*ast.VarDecl{
+			declaration(lexer.INTEGER, "A", 2),
+			declaration(lexer.REAL, "B", 3),
+			declaration(lexer.LITERAL, "C", 4),
+		},
+		Body: body,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type expectedError struct {
+		err  error
+		line int
+	}
+
+	tests := []struct {
+		name     string
+		program  *ast.Program
+		expected []expectedError
+	}{
+		{
+			name: "Well typed program",
+			program: program(
+				&ast.Read{Span: asttest.At(6, 1), Target: ident("C", 6, 6)},
+				&ast.Assign{Span: asttest.At(7, 1), Target: ident("A", 7, 1), Value: asttest.Binary("+", ident("A", 7, 6), literal("1", lexer.INTEGER, 7, 10))},
+				&ast.If{
+					Span:      asttest.At(8, 1),
+					Condition: asttest.Binary("<>", ident("B", 8, 5), literal("2.5", lexer.REAL, 8, 10)),
+					Body:      []ast.Stmt{&ast.Write{Span: asttest.At(9, 2), Value: ident("C", 9, 10)}},
+					Else:      []ast.Stmt{&ast.Write{Span: asttest.At(11, 2), Value: literal("\"nada\"", lexer.LITERAL, 11, 10)}},
+				},
+			),
+		},
+		{
+			name: "Undeclared variables",
+			program: program(
+				&ast.Read{Span: asttest.At(6, 1), Target: ident("D", 6, 6)},
+				&ast.Assign{Span: asttest.At(7, 1), Target: ident("A", 7, 1), Value: asttest.Binary("*", ident("E", 7, 6), ident("A", 7, 10))},
+			),
+			expected: []expectedError{{ErrorUndeclared, 6}, {ErrorUndeclared, 7}},
+		},
+		{
+			name: "Variable declared twice",
+			program: &ast.Program{
+				Declarations: []*ast.VarDecl{
+					declaration(lexer.INTEGER, "A", 2),
+					declaration(lexer.REAL, "A", 3),
+				},
+			},
+			expected: []expectedError{{ErrorRedeclared, 3}},
+		},
+		{
+			name: "Assignment of another type",
+			program: program(
+				&ast.Assign{Span: asttest.At(6, 1), Target: ident("A", 6, 1), Value: ident("C", 6, 6)},
+				&ast.Assign{Span: asttest.At(7, 1), Target: ident("C", 7, 1), Value: literal("3", lexer.INTEGER, 7, 6)},
+				&ast.Assign{Span: asttest.At(8, 1), Target: ident("B", 8, 1), Value: ident("A", 8, 6)},
+			),
+			expected: []expectedError{{ErrorAssignType, 6}, {ErrorAssignType, 7}},
+		},
+		{
+			name: "Operands of different types are reported once",
+			program: program(
+				&ast.Assign{Span: asttest.At(6, 1), Target: ident("A", 6, 1), Value: asttest.Binary("+", asttest.Binary("-", ident("A", 6, 6), ident("C", 6, 10)), ident("A", 6, 14))},
+			),
+			expected: []expectedError{{ErrorOperandTypes, 6}},
+		},
+		{
+			name: "Arithmetic on literals",
+			program: program(
+				&ast.Write{Span: asttest.At(6, 1), Value: asttest.Binary("+", ident("C", 6, 9), literal("\"x\"", lexer.LITERAL, 6, 13))},
+			),
+			expected: []expectedError{{ErrorOperatorType, 6}},
+		},
+		{
+			name: "Conditions must be comparisons",
+			program: program(
+				&ast.While{Span: asttest.At(6, 1), Condition: ident("A", 6, 9)},
+				&ast.If{Span: asttest.At(8, 1), Condition: asttest.Binary("=", ident("A", 8, 5), ident("C", 8, 9))},
+			),
+			expected: []expectedError{{ErrorConditionType, 6}, {ErrorOperandTypes, 8}},
+		},
+		{
+			name: "Error nodes are skipped",
+			program: program(
+				&ast.BadStmt{Span: asttest.At(6, 1)},
+				&ast.Assign{Span: asttest.At(7, 1), Target: ident("A", 7, 1), Value: &ast.BadExpr{Span: asttest.At(7, 6)}},
+				&ast.If{Span: asttest.At(8, 1), Condition: &ast.BadExpr{Span: asttest.At(8, 5)}},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Check(tt.program, nil)
+
+			require.Len(t, info.Errors, len(tt.expected))
+			for idx, expected := range tt.expected {
+				require.True(t, errors.Is(info.Errors[idx], expected.err), info.Errors[idx].Error())
+				require.Equal(t, expected.line, info.Errors[idx].Span.Start.Line)
+			}
+		})
+	}
+}
+
+func TestCheckRecordsTypesAndUses(t *testing.T) {
+	symbolTable := lexer.NewSymbolTable()
+	for _, name := range []string{"A", "B", "C"} {
+		symbolTable.Insert(name, lexer.NewToken("id", name, lexer.NULL))
+	}
+
+	sum := asttest.Binary("+", ident("B", 6, 6), literal("1.5", lexer.REAL, 6, 10))
+	comparison := asttest.Binary(">", ident("A", 7, 9), literal("0", lexer.INTEGER, 7, 13))
+	target := ident("B", 6, 1)
+	p := program(
+		&ast.Assign{Span: asttest.At(6, 1), Target: target, Value: sum},
+		&ast.While{Span: asttest.At(7, 1), Condition: comparison},
+	)
+
+	info := Check(p, symbolTable)
+	require.Empty(t, info.Errors)
+	require.Equal(t, lexer.REAL, info.TypeOf(sum))
+	require.Equal(t, Boolean, info.TypeOf(comparison))
+	require.Equal(t, lexer.INTEGER, info.TypeOf(comparison.Left))
+	require.Equal(t, lexer.NULL, info.TypeOf(&ast.BadExpr{}))
+	require.Same(t, p.Declarations[1], info.Uses[target])
+
+	for _, declared := range p.Declarations {
+		dataType, err := symbolTable.GetType(declared.Name.Name)
+		require.NoError(t, err)
+		require.Equal(t, declared.Type, dataType)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	info := Check(program(
+		&ast.Assign{Span: asttest.At(6, 1), Target: ident("A", 6, 1), Value: asttest.Binary("+", ident("A", 6, 6), ident("C", 6, 10))},
+	), nil)
+
+	require.Len(t, info.Errors, 1)
+	require.Equal(t,
+		"Erro semântico na linha 6, coluna 6: operandos com tipos incompatíveis: 'A' é do tipo 'inteiro', enquanto que 'C' é do tipo 'literal'",
+		info.Errors[0].Error(),
+	)
+
+	info = Check(&ast.Program{
+		Declarations: []*ast.VarDecl{declaration(lexer.REAL, "media", 2)},
+		Body:         []ast.Stmt{&ast.Write{Span: asttest.At(4, 1), Value: ident("mdia", 4, 9)}},
+	}, nil)
+	require.Len(t, info.Errors, 1)
+	require.Equal(t, "Erro semântico na linha 4, coluna 9: variável não declarada: 'mdia', você quis dizer 'media'?", info.Errors[0].Error())
+}
+
+func TestDuplicateDeclaration(t *testing.T) {
+	info := Check(&ast.Program{
+		Declarations: []*ast.VarDecl{
+			declaration(lexer.INTEGER, "A", 2),
+			declaration(lexer.REAL, "B", 3),
+			declaration(lexer.REAL, "A", 4),
+			declaration(lexer.LITERAL, "A", 5),
+		},
+		Body: []ast.Stmt{&ast.Read{Span: asttest.At(7, 1), Target: ident("A", 7, 6)}},
+	}, nil)
+
+	require.Len(t, info.Errors, 2)
+	for idx, line := range []int{4, 5} {
+		duplicate := info.Errors[idx]
+		require.True(t, errors.Is(duplicate, ErrorRedeclared))
+		require.Equal(t, asttest.At(line, 1), duplicate.Span)
+		require.NotNil(t, duplicate.Original)
+		require.Equal(t, asttest.At(2, 1), *duplicate.Original)
+	}
+	require.Equal(t, "Erro semântico na linha 4, coluna 1: variável declarada mais de uma vez: 'A' já foi declarada na linha 2, coluna 1", info.Errors[0].Error())
+
+	// The uses refer to the first declaration
+	require.Len(t, info.Uses, 1)
+	for _, declaration := range info.Uses {
+		require.Equal(t, lexer.INTEGER, declaration.Type)
+	}
+}
+
+func TestNarrowingStrictness(t *testing.T) {
+	p := program(
+		&ast.Read{Span: asttest.At(5, 1), Target: ident("A", 5, 6)},
+		&ast.Read{Span: asttest.At(5, 9), Target: ident("C", 5, 14)},
+		&ast.Assign{Span: asttest.At(6, 1), Target: ident("B", 6, 1), Value: ident("A", 6, 6)},
+		&ast.Assign{Span: asttest.At(7, 1), Target: ident("A", 7, 1), Value: asttest.Binary("*", ident("B", 7, 6), literal("2.0", lexer.REAL, 7, 10))},
+		&ast.Write{Span: asttest.At(8, 1), Value: ident("C", 8, 9)},
+	)
+
+	tests := []struct {
+		strictness Strictness
+		errors     int
+		warnings   int
+	}{
+		{Permissive, 0, 0},
+		{Warn, 0, 1},
+		{Strict, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strictness.String(), func(t *testing.T) {
+			checker := NewChecker(nil)
+			checker.SetPromotion(Permissive)
+			checker.SetNarrowing(tt.strictness)
+			info := checker.Check(p)
+
+			require.Len(t, info.Errors, tt.errors)
+			require.Len(t, info.Warnings, tt.warnings)
+			for _, narrowing := range append(info.Errors, info.Warnings...) {
+				require.True(t, errors.Is(narrowing, ErrorNarrowing))
+				require.Equal(t, 7, narrowing.Span.Start.Line)
+			}
+		})
+	}
+
+	// By default the promotion of A is warned about as well
+	info := Check(p, nil)
+	require.Len(t, info.Warnings, 2)
+	require.Equal(t, "Aviso semântico na linha 7, coluna 1: atribuição de um real a um inteiro perde a parte fracionária: 'A' é do tipo 'inteiro'", info.Warnings[1].Error())
+}
+
+func TestPromotion(t *testing.T) {
+	product := asttest.Binary("*", ident("A", 6, 6), literal("2.5", lexer.REAL, 6, 10))
+	sum := asttest.Binary("+", ident("A", 7, 6), literal("1", lexer.INTEGER, 7, 10))
+	comparison := asttest.Binary("<", ident("B", 8, 9), ident("A", 8, 13))
+	p := program(
+		&ast.Read{Span: asttest.At(5, 1), Target: ident("A", 5, 6)},
+		&ast.Read{Span: asttest.At(5, 9), Target: ident("C", 5, 14)},
+		&ast.Assign{Span: asttest.At(6, 1), Target: ident("B", 6, 1), Value: product},
+		&ast.Assign{Span: asttest.At(7, 1), Target: ident("B", 7, 1), Value: sum},
+		&ast.While{Span: asttest.At(8, 1), Condition: comparison},
+		&ast.Write{Span: asttest.At(9, 1), Value: ident("C", 9, 9)},
+	)
+
+	tests := []struct {
+		strictness Strictness
+		errors     int
+		warnings   int
+	}{
+		{Permissive, 0, 0},
+		{Warn, 0, 3},
+		{Strict, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strictness.String(), func(t *testing.T) {
+			checker := NewChecker(nil)
+			checker.SetPromotion(tt.strictness)
+			info := checker.Check(p)
+
+			require.Len(t, info.Errors, tt.errors)
+			require.Len(t, info.Warnings, tt.warnings)
+			for _, promotion := range append(info.Errors, info.Warnings...) {
+				require.True(t, errors.Is(promotion, ErrorPromotion))
+			}
+
+			// The promoted operands are known whatever the strictness
+			require.Equal(t, lexer.REAL, info.TypeOf(product))
+			require.Equal(t, lexer.INTEGER, info.TypeOf(sum))
+			require.Equal(t, Boolean, info.TypeOf(comparison))
+			require.Equal(t, map[ast.Expr]lexer.DataType{
+				product.Left:     lexer.REAL,
+				sum:              lexer.REAL,
+				comparison.Right: lexer.REAL,
+			}, info.Conversions)
+		})
+	}
+
+	info := Check(p, nil)
+	require.Equal(t, "Aviso semântico na linha 6, coluna 6: conversão implícita de inteiro para real: 'A' é usado como real", info.Warnings[0].Error())
+	require.Equal(t, sum.Span, info.Warnings[1].Span)
+}
+
+func TestRelationalOperands(t *testing.T) {
+	compare := func(operator string, left, right ast.Expr) ast.Stmt {
+		return &ast.If{Span: asttest.At(6, 1), Condition: asttest.Binary(operator, left, right)}
+	}
+
+	tests := []struct {
+		name     string
+		stmt     ast.Stmt
+		expected []error
+	}{
+		{
+			name: "Numbers",
+			stmt: compare("<=", ident("A", 6, 5), ident("B", 6, 10)),
+		},
+		{
+			name: "Literals",
+			stmt: compare("<>", ident("C", 6, 5), literal("\"fim\"", lexer.LITERAL, 6, 10)),
+		},
+		{
+			name:     "Number and literal",
+			stmt:     compare("=", ident("A", 6, 5), ident("C", 6, 9)),
+			expected: []error{ErrorOperandTypes},
+		},
+		{
+			name:     "Comparisons",
+			stmt:     compare("=", asttest.Binary("<", ident("A", 6, 5), ident("B", 6, 9)), asttest.Binary(">", ident("A", 6, 14), ident("B", 6, 18))),
+			expected: []error{ErrorComparison, ErrorComparison},
+		},
+		{
+			name:     "Comparison on an operation",
+			stmt:     compare(">", asttest.Binary("+", asttest.Binary("<", ident("A", 6, 5), ident("B", 6, 9)), ident("A", 6, 14)), ident("B", 6, 18)),
+			expected: []error{ErrorComparison},
+		},
+		{
+			name:     "Comparison assigned",
+			stmt:     &ast.Assign{Span: asttest.At(6, 1), Target: ident("A", 6, 1), Value: asttest.Binary("<", ident("A", 6, 6), literal("1", lexer.INTEGER, 6, 10))},
+			expected: []error{ErrorComparison},
+		},
+		{
+			name:     "Comparison written",
+			stmt:     &ast.Write{Span: asttest.At(6, 1), Value: asttest.Binary(">", ident("B", 6, 9), ident("B", 6, 13))},
+			expected: []error{ErrorComparison},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Check(program(tt.stmt), nil)
+
+			require.Len(t, info.Errors, len(tt.expected))
+			for idx, expected := range tt.expected {
+				require.True(t, errors.Is(info.Errors[idx], expected), info.Errors[idx].Error())
+			}
+		})
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	number := func(value string, column int) *ast.Literal {
+		dataType := lexer.INTEGER
+		if strings.Contains(value, ".") {
+			dataType = lexer.REAL
+		}
+		return literal(value, dataType, 6, column)
+	}
+
+	tests := []struct {
+		name    string
+		value   ast.Expr
+		divisor *ast.Span
+	}{
+		{
+			name:  "Variable divisor",
+			value: asttest.Binary("/", ident("A", 6, 6), ident("A", 6, 10)),
+		},
+		{
+			name:  "Zero dividend",
+			value: asttest.Binary("/", number("0", 6), ident("A", 6, 10)),
+		},
+		{
+			name:    "Zero",
+			value:   asttest.Binary("/", ident("A", 6, 6), number("0", 10)),
+			divisor: &ast.Span{Start: lexer.Position{Line: 6, Column: 10}, End: lexer.Position{Line: 6, Column: 10}},
+		},
+		{
+			name:    "Real zero",
+			value:   asttest.Binary("/", ident("B", 6, 6), number("0.0", 10)),
+			divisor: &ast.Span{Start: lexer.Position{Line: 6, Column: 10}, End: lexer.Position{Line: 6, Column: 10}},
+		},
+		{
+			name:    "Folded to zero",
+			value:   asttest.Binary("/", ident("A", 6, 6), asttest.Binary("-", number("2", 11), number("2", 15))),
+			divisor: &ast.Span{Start: lexer.Position{Line: 6, Column: 11}, End: lexer.Position{Line: 6, Column: 15}},
+		},
+		{
+			name:    "Inteiro division truncated to zero",
+			value:   asttest.Binary("/", ident("A", 6, 6), asttest.Binary("/", number("1", 11), number("2", 15))),
+			divisor: &ast.Span{Start: lexer.Position{Line: 6, Column: 11}, End: lexer.Position{Line: 6, Column: 15}},
+		},
+		{
+			name:  "Real division is not truncated",
+			value: asttest.Binary("/", ident("B", 6, 6), asttest.Binary("/", number("1.0", 11), number("2", 17))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(nil)
+			checker.SetPromotion(Permissive)
+			checker.SetNarrowing(Permissive)
+			info := checker.Check(program(&ast.Write{Span: asttest.At(6, 1), Value: tt.value}))
+
+			if tt.divisor == nil {
+				require.Empty(t, info.Errors)
+				return
+			}
+			require.Len(t, info.Errors, 1)
+			require.True(t, errors.Is(info.Errors[0], ErrorDivisionByZero))
+			require.Equal(t, *tt.divisor, info.Errors[0].Span)
+		})
+	}
+}
+
+func TestConstants(t *testing.T) {
+	sum := asttest.Binary("+", literal("2", lexer.INTEGER, 6, 7), literal("3", lexer.INTEGER, 6, 11))
+	product := asttest.Binary("*", sum, literal("4", lexer.INTEGER, 6, 16))
+	quotient := asttest.Binary("/", literal("7", lexer.INTEGER, 7, 6), literal("2", lexer.INTEGER, 7, 10))
+	mixed := asttest.Binary("/", literal("7", lexer.INTEGER, 8, 6), literal("2.0", lexer.REAL, 8, 10))
+	variable := asttest.Binary("+", ident("A", 9, 6), literal("1", lexer.INTEGER, 9, 10))
+
+	info := Check(program(
+		&ast.Write{Span: asttest.At(6, 1), Value: product},
+		&ast.Write{Span: asttest.At(7, 1), Value: quotient},
+		&ast.Write{Span: asttest.At(8, 1), Value: mixed},
+		&ast.Write{Span: asttest.At(9, 1), Value: variable},
+	), nil)
+
+	require.Empty(t, info.Errors)
+	require.Equal(t, 20.0, info.Constants[product])
+	require.Equal(t, 3.0, info.Constants[quotient])
+	require.Equal(t, 3.5, info.Constants[mixed])
+	_, found := info.Constants[variable]
+	require.False(t, found)
+}
+
+func TestUnusedVariables(t *testing.T) {
+	p := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			declaration(lexer.INTEGER, "lido", 2),
+			declaration(lexer.INTEGER, "escrito", 3),
+			declaration(lexer.LITERAL, "esquecido", 4),
+			declaration(lexer.INTEGER, "contador", 5),
+			declaration(lexer.INTEGER, "lido", 6),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Span: asttest.At(8, 1), Target: ident("lido", 8, 6)},
+			&ast.Assign{Span: asttest.At(9, 1), Target: ident("escrito", 9, 1), Value: ident("lido", 9, 12)},
+			&ast.Assign{Span: asttest.At(10, 1), Target: ident("contador", 10, 1), Value: asttest.Binary("+", ident("contador", 10, 13), literal("1", lexer.INTEGER, 10, 24))},
+		},
+	}
+
+	info := Check(p, nil)
+	require.Len(t, info.Errors, 1)
+	require.True(t, errors.Is(info.Errors[0], ErrorRedeclared))
+
+	// contador is also read before receiving a value
+	require.Len(t, info.Warnings, 3)
+	require.True(t, errors.Is(info.Warnings[0], ErrorUninitialized))
+	require.True(t, errors.Is(info.Warnings[1], ErrorNeverRead))
+	require.Equal(t, asttest.At(3, 1), info.Warnings[1].Span)
+	require.True(t, errors.Is(info.Warnings[2], ErrorUnused))
+	require.Equal(t, asttest.At(4, 1), info.Warnings[2].Span)
+
+	require.Equal(t, Usage{Reads: 1, Writes: 1}, info.Usage[p.Declarations[0]])
+	require.Equal(t, Usage{Writes: 1}, info.Usage[p.Declarations[1]])
+	require.Equal(t, Usage{}, info.Usage[p.Declarations[2]])
+	require.Equal(t, Usage{Reads: 1, Writes: 1}, info.Usage[p.Declarations[3]])
+}
+
+func TestUseBeforeAssignment(t *testing.T) {
+	read := func(name string, line int) ast.Stmt {
+		return &ast.Read{Span: asttest.At(line, 1), Target: ident(name, line, 6)}
+	}
+	write := func(name string, line int) ast.Stmt {
+		return &ast.Write{Span: asttest.At(line, 1), Value: ident(name, line, 9)}
+	}
+	assign := func(name string, value ast.Expr, line int) ast.Stmt {
+		return &ast.Assign{Span: asttest.At(line, 1), Target: ident(name, line, 1), Value: value}
+	}
+	condition := func(line int) ast.Expr {
+		return asttest.Binary(">", ident("B", line, 5), literal("0.0", lexer.REAL, line, 9))
+	}
+
+	tests := []struct {
+		name     string
+		body     []ast.Stmt
+		expected []ast.Span
+	}{
+		{
+			name: "Assigned before read",
+			body: []ast.Stmt{read("B", 6), assign("A", literal("1", lexer.INTEGER, 7, 6), 7), write("A", 8), write("B", 9)},
+		},
+		{
+			name:     "Read before assigned",
+			body:     []ast.Stmt{write("A", 6), read("A", 7), write("A", 8)},
+			expected: []ast.Span{asttest.At(6, 9)},
+		},
+		{
+			name:     "Assigned from itself",
+			body:     []ast.Stmt{assign("A", asttest.Binary("+", ident("A", 6, 6), literal("1", lexer.INTEGER, 6, 10)), 6)},
+			expected: []ast.Span{asttest.At(6, 6)},
+		},
+		{
+			name: "Assigned on both branches",
+			body: []ast.Stmt{
+				read("B", 6),
+				&ast.If{Span: asttest.At(7, 1), Condition: condition(7), Body: []ast.Stmt{read("A", 8)}, Else: []ast.Stmt{read("A", 10)}},
+				write("A", 12),
+			},
+		},
+		{
+			name: "Assigned on one branch",
+			body: []ast.Stmt{
+				read("B", 6),
+				&ast.If{Span: asttest.At(7, 1), Condition: condition(7), Body: []ast.Stmt{read("A", 8)}},
+				write("A", 10),
+			},
+			expected: []ast.Span{asttest.At(10, 9)},
+		},
+		{
+			name: "Assigned inside a loop",
+			body: []ast.Stmt{
+				read("B", 6),
+				&ast.While{Span: asttest.At(7, 1), Condition: condition(7), Body: []ast.Stmt{read("A", 8), write("A", 9)}},
+				write("A", 11),
+			},
+			expected: []ast.Span{asttest.At(11, 9)},
+		},
+		{
+			name: "Condition read before assigned",
+			body: []ast.Stmt{
+				&ast.While{Span: asttest.At(6, 1), Condition: condition(6), Body: []ast.Stmt{read("B", 7)}},
+			},
+			expected: []ast.Span{asttest.At(6, 5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Check(&ast.Program{
+				Declarations: []*ast.VarDecl{declaration(lexer.INTEGER, "A", 2), declaration(lexer.REAL, "B", 3)},
+				Body:         tt.body,
+			}, nil)
+
+			uninitialized := []ast.Span{}
+			for _, warning := range info.Warnings {
+				if errors.Is(warning, ErrorUninitialized) {
+					uninitialized = append(uninitialized, warning.Span)
+				}
+			}
+			require.Equal(t, append([]ast.Span{}, tt.expected...), uninitialized)
+		})
+	}
+}
+
+func TestImplicitDeclarations(t *testing.T) {
+	body := []ast.Stmt{
+		&ast.Assign{Span: asttest.At(6, 1), Target: ident("D", 6, 1), Value: literal("2.5", lexer.REAL, 6, 6)},
+		&ast.Assign{Span: asttest.At(7, 1), Target: ident("E", 7, 1), Value: asttest.Binary("*", ident("A", 7, 6), literal("2", lexer.INTEGER, 7, 10))},
+		&ast.Assign{Span: asttest.At(8, 1), Target: ident("A", 8, 1), Value: ident("E", 8, 6)},
+		&ast.Write{Span: asttest.At(9, 1), Value: ident("D", 9, 9)},
+		&ast.Read{Span: asttest.At(10, 1), Target: ident("F", 10, 6)},
+		&ast.Assign{Span: asttest.At(11, 1), Target: ident("G", 11, 1), Value: ident("G", 11, 6)},
+	}
+
+	checker := NewChecker(nil)
+	checker.SetImplicitDeclarations(true)
+	info := checker.Check(program(body...))
+
+	// A was declared, while F is only read and G only assigned from itself
+	require.Len(t, info.Implicit, 2)
+	require.Equal(t, "D", info.Implicit[0].Name.Name)
+	require.Equal(t, lexer.REAL, info.Implicit[0].Type)
+	require.Equal(t, asttest.At(6, 1), info.Implicit[0].Span)
+	require.Equal(t, "E", info.Implicit[1].Name.Name)
+	require.Equal(t, lexer.INTEGER, info.Implicit[1].Type)
+	require.Equal(t, info.Implicit[1], info.Uses[body[2].(*ast.Assign).Value.(*ast.Ident)])
+
+	require.Len(t, info.Errors, 3)
+	for idx, span := range []ast.Span{asttest.At(10, 6), asttest.At(11, 6), asttest.At(11, 1)} {
+		require.True(t, errors.Is(info.Errors[idx], ErrorUndeclared))
+		require.Equal(t, span, info.Errors[idx].Span)
+	}
+
+	// Without them, every variable must be declared
+	info = Check(program(body...), nil)
+	require.Empty(t, info.Implicit)
+	require.Len(t, info.Errors, 7)
+}
+
+func TestDiagnostics(t *testing.T) {
+	info := Check(program(
+		&ast.Read{Span: asttest.At(6, 1), Target: ident("A", 6, 6)},
+		&ast.Write{Span: asttest.At(7, 1), Value: ident("B", 7, 9)},
+		&ast.Assign{Span: asttest.At(8, 1), Target: ident("A", 8, 1), Value: ident("AA", 8, 6)},
+	), nil)
+
+	buffer := errorhandling.NewDiagnosticBuffer()
+	info.Report(buffer)
+	diagnostics := []string{}
+	for _, diagnostic := range buffer.Diagnostics() {
+		diagnostics = append(diagnostics, diagnostic.Code+" "+diagnostic.String())
+	}
+	// Sorted by position, whatever their severity
+	require.Equal(t, []string{
+		"S012 aviso na linha 2 coluna 1, variável recebe valores mas nunca é lida: 'A'",
+		"S011 aviso na linha 4 coluna 1, variável declarada mas nunca usada: 'C'",
+		"S013 aviso na linha 7 coluna 9, variável pode ser lida antes de receber um valor: 'B'",
+		"S001 erro na linha 8 coluna 6, variável não declarada: 'AA'",
+	}, diagnostics)
+}
@@ -0,0 +1,60 @@
+package sem
+
+import (
+	"errors"
+	errorhandling "mgol-go/src/error_handling"
+)
+
+// codes holds the diagnostic code of each semantic error
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrorUndeclared, "S001"},
+	{ErrorRedeclared, "S002"},
+	{ErrorAssignType, "S003"},
+	{ErrorNarrowing, "S004"},
+	{ErrorPromotion, "S005"},
+	{ErrorOperandTypes, "S006"},
+	{ErrorOperatorType, "S007"},
+	{ErrorConditionType, "S008"},
+	{ErrorComparison, "S009"},
+	{ErrorDivisionByZero, "S010"},
+	{ErrorUnused, "S011"},
+	{ErrorNeverRead, "S012"},
+	{ErrorUninitialized, "S013"},
+	{ErrorShadowed, "S014"},
+}
+
+// Code returns the diagnostic code of err, one of the
+// semantic errors or an error wrapping it
+func Code(err error) string {
+	for _, entry := range codes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return ""
+}
+
+// Code returns the diagnostic code of the error
+func (e Error) Code() string {
+	return Code(e.Err)
+}
+
+// Diagnostic returns the error as a diagnostic, which is
+// reported and shown like the lexical ones
+func (e Error) Diagnostic() errorhandling.Diagnostic {
+	diagnostic := errorhandling.NewDiagnostic(e.Severity, e.Span.Start.Line, e.Span.Start.Column, e.message())
+	return diagnostic.WithCode(e.Code())
+}
+
+// Report sends the errors and warnings found to handler
+func (i *Info) Report(handler errorhandling.DiagnosticHandler) {
+	for _, warning := range i.Warnings {
+		handler.Handle(warning.Diagnostic())
+	}
+	for _, semanticError := range i.Errors {
+		handler.Handle(semanticError.Diagnostic())
+	}
+}
@@ -0,0 +1,78 @@
+package sem
+
+import "mgol-go/src/ast"
+
+// assigned is the set of variables that surely hold a value
+// at some point of the program
+type assigned map[*ast.VarDecl]bool
+
+func (a assigned) copy() assigned {
+	clone := make(assigned, len(a))
+	for declaration := range a {
+		clone[declaration] = true
+	}
+	return clone
+}
+
+// intersect returns the variables assigned on both a and other
+func (a assigned) intersect(other assigned) assigned {
+	result := make(assigned)
+	for declaration := range a {
+		if other[declaration] {
+			result[declaration] = true
+		}
+	}
+	return result
+}
+
+// flow follows the body of the program in order, warning once
+// about each variable that may be read before leia or an
+// assignment gives it a value. A block of se may or may not
+// run, and the body of repita may not run at all
+func (p *pass) flow(stmts []ast.Stmt, in assigned) assigned {
+	for _, stmt := range stmts {
+		switch node := stmt.(type) {
+		case *ast.Assign:
+			p.reads(node.Value, in)
+			p.assigns(node.Target, in)
+		case *ast.Read:
+			p.assigns(node.Target, in)
+		case *ast.Write:
+			p.reads(node.Value, in)
+		case *ast.If:
+			p.reads(node.Condition, in)
+			body := p.flow(node.Body, in.copy())
+			otherwise := p.flow(node.Else, in.copy())
+			in = body.intersect(otherwise)
+		case *ast.While:
+			p.reads(node.Condition, in)
+			p.flow(node.Body, in.copy())
+		}
+	}
+	return in
+}
+
+func (p *pass) assigns(target *ast.Ident, in assigned) {
+	if declaration, found := p.info.Uses[target]; found {
+		in[declaration] = true
+	}
+}
+
+// reads warns about the variables of expr that may not hold a value
+func (p *pass) reads(expr ast.Expr, in assigned) {
+	if expr == nil {
+		return
+	}
+	ast.Inspect(expr, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		declaration, found := p.info.Uses[ident]
+		if found && !in[declaration] && !p.uninitialized[declaration] {
+			p.uninitialized[declaration] = true
+			p.warnf(ident.Span, ErrorUninitialized, "'%s'", ident.Name)
+		}
+		return false
+	})
+}
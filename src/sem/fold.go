@@ -0,0 +1,54 @@
+package sem
+
+import (
+	"math"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"strconv"
+	"strings"
+)
+
+// Fold replaces each operation on constants of program, like 2*3+1,
+// by a literal with its value, found by Check on info. The literal
+// takes over the span of the operation and its entries on info. Values
+// mgol can't write, the negative ones, are left as operations
+func Fold(program *ast.Program, info *Info) *ast.Program {
+	ast.Rewrite(program, func(node ast.Node) ast.Node {
+		operation, ok := node.(*ast.BinaryExpr)
+		if !ok {
+			return node
+		}
+		value, constant := info.Constants[operation]
+		if !constant || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return node
+		}
+
+		dataType := info.Types[operation]
+		folded := &ast.Literal{Span: operation.Span, Value: formatNumber(value, dataType), Type: dataType}
+		info.replace(operation, folded)
+		return folded
+	})
+	return program
+}
+
+// replace moves what is known about old to new
+func (i *Info) replace(old, new ast.Expr) {
+	i.Types[new] = i.Types[old]
+	delete(i.Types, old)
+	i.Constants[new] = i.Constants[old]
+	delete(i.Constants, old)
+	if conversion, found := i.Conversions[old]; found {
+		i.Conversions[new] = conversion
+		delete(i.Conversions, old)
+	}
+}
+
+// formatNumber writes value as a number of mgol. A real
+// always has a decimal point, so it is read back as real
+func formatNumber(value float64, dataType lexer.DataType) string {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	if dataType == lexer.REAL && !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
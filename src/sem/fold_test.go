@@ -0,0 +1,95 @@
+package sem
+
+import (
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFold(t *testing.T) {
+	integer := func(value string, column int) *ast.Literal {
+		return literal(value, lexer.INTEGER, 6, column)
+	}
+
+	tests := []struct {
+		name     string
+		value    ast.Expr
+		expected string
+	}{
+		{
+			name:     "Operations on constants",
+			value:    asttest.Binary("+", asttest.Binary("*", integer("2", 6), integer("3", 8)), integer("1", 10)),
+			expected: "escreva 7;",
+		},
+		{
+			name:     "Exponents",
+			value:    asttest.Binary("*", integer("2E2", 6), literal("1.5E-1", lexer.REAL, 6, 12)),
+			expected: "escreva 30.0;",
+		},
+		{
+			name:     "Inteiro division",
+			value:    asttest.Binary("/", integer("7", 6), integer("2", 10)),
+			expected: "escreva 3;",
+		},
+		{
+			name:     "Inteiro overflow wraps",
+			value:    asttest.Binary("*", integer("429981696", 6), integer("429981696", 18)),
+			expected: "escreva 0;",
+		},
+		{
+			name: "Inteiro division after overflow",
+			value: asttest.Binary("/",
+				asttest.Binary("-", asttest.Binary("+", integer("2147483647", 6), integer("1", 19)), integer("2", 23)),
+				integer("4", 27)),
+			expected: "escreva 536870911;",
+		},
+		{
+			name:     "Negative overflow is kept",
+			value:    asttest.Binary("+", integer("2147483647", 6), integer("1", 19)),
+			expected: "escreva 2147483647 + 1;",
+		},
+		{
+			name:     "Only the constant part",
+			value:    asttest.Binary("+", ident("B", 6, 6), asttest.Binary("-", integer("4", 11), integer("1", 15))),
+			expected: "escreva B + 3;",
+		},
+		{
+			name:     "Negative values are kept",
+			value:    asttest.Binary("*", ident("A", 6, 6), asttest.Binary("-", integer("1", 11), integer("2", 15))),
+			expected: "escreva A * (1 - 2);",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program(&ast.Write{Span: asttest.At(6, 1), Value: tt.value})
+			info := Check(p, nil)
+			require.Empty(t, info.Errors)
+
+			Fold(p, info)
+			var builder strings.Builder
+			require.NoError(t, ast.Fprint(&builder, p.Body[0]))
+			require.Equal(t, tt.expected+"\n", builder.String())
+		})
+	}
+}
+
+func TestFoldMovesInfo(t *testing.T) {
+	constant := asttest.Binary("-", literal("4", lexer.INTEGER, 6, 11), literal("1", lexer.INTEGER, 6, 15))
+	p := program(&ast.Assign{Span: asttest.At(6, 1), Target: ident("B", 6, 1), Value: asttest.Binary("*", ident("B", 6, 6), constant)})
+
+	info := Check(p, nil)
+	require.Equal(t, lexer.REAL, info.Conversions[constant])
+
+	Fold(p, info)
+	folded := p.Body[0].(*ast.Assign).Value.(*ast.BinaryExpr).Right
+	require.Equal(t, &ast.Literal{Span: constant.Span, Value: "3", Type: lexer.INTEGER}, folded)
+	require.Equal(t, lexer.INTEGER, info.TypeOf(folded))
+	require.Equal(t, 3.0, info.Constants[folded])
+	require.Equal(t, lexer.REAL, info.Conversions[folded])
+	require.Equal(t, lexer.NULL, info.TypeOf(constant))
+}
@@ -0,0 +1,53 @@
+package sem
+
+import "mgol-go/src/ast"
+
+// Scope holds the variables declared on a block. Names are looked
+// up from the innermost scope out, so a declaration on a nested
+// block shadows the ones with its name on the enclosing ones.
+// mgol only has the scope of the program for now, the nested ones
+// are for when blocks with their own declarations are added
+type Scope struct {
+	parent       *Scope
+	declarations map[string]*ast.VarDecl
+}
+
+// NewScope returns an empty scope nested on parent,
+// which is nil for the outermost one
+func NewScope(parent *Scope) *Scope {
+	return &Scope{parent: parent, declarations: make(map[string]*ast.VarDecl)}
+}
+
+// Parent returns the scope s is nested on
+func (s *Scope) Parent() *Scope {
+	return s.parent
+}
+
+// Insert declares the variable of declaration on s. If the name was
+// already declared on s, nothing changes and that declaration is
+// returned instead
+func (s *Scope) Insert(declaration *ast.VarDecl) (previous *ast.VarDecl, inserted bool) {
+	name := declaration.Name.Name
+	if previous, found := s.declarations[name]; found {
+		return previous, false
+	}
+	s.declarations[name] = declaration
+	return declaration, true
+}
+
+// LookupLocal returns the declaration of name on s itself
+func (s *Scope) LookupLocal(name string) (*ast.VarDecl, bool) {
+	declaration, found := s.declarations[name]
+	return declaration, found
+}
+
+// Lookup returns the declaration of name visible on s, from
+// s itself or, if it is not declared there, the enclosing scopes
+func (s *Scope) Lookup(name string) (*ast.VarDecl, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if declaration, found := scope.declarations[name]; found {
+			return declaration, true
+		}
+	}
+	return nil, false
+}
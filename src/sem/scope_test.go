@@ -0,0 +1,69 @@
+package sem
+
+import (
+	"errors"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+	outer := NewScope(nil)
+	a := declaration(lexer.INTEGER, "A", 2)
+	b := declaration(lexer.REAL, "B", 3)
+	_, inserted := outer.Insert(a)
+	require.True(t, inserted)
+	outer.Insert(b)
+
+	inner := NewScope(outer)
+	innerA := declaration(lexer.LITERAL, "A", 6)
+	_, inserted = inner.Insert(innerA)
+	require.True(t, inserted)
+	previous, inserted := inner.Insert(declaration(lexer.REAL, "A", 7))
+	require.False(t, inserted)
+	require.Equal(t, innerA, previous)
+
+	found, _ := inner.Lookup("A")
+	require.Equal(t, innerA, found)
+	found, _ = inner.Lookup("B")
+	require.Equal(t, b, found)
+	_, local := inner.LookupLocal("B")
+	require.False(t, local)
+	found, _ = outer.Lookup("A")
+	require.Equal(t, a, found)
+	_, visible := inner.Lookup("C")
+	require.False(t, visible)
+	require.Equal(t, outer, inner.Parent())
+}
+
+func TestShadowing(t *testing.T) {
+	p := &pass{
+		Checker: NewChecker(nil),
+		info:    &Info{Uses: make(map[*ast.Ident]*ast.VarDecl), Usage: make(map[*ast.VarDecl]Usage)},
+		scope:   NewScope(nil),
+	}
+	outer := declaration(lexer.INTEGER, "A", 2)
+	p.declare(outer)
+
+	p.enter()
+	inner := declaration(lexer.REAL, "A", 6)
+	p.declare(inner)
+	p.declare(declaration(lexer.REAL, "B", 7))
+	use := ident("A", 8, 1)
+	require.Equal(t, lexer.REAL, p.resolve(use))
+	require.Equal(t, inner, p.info.Uses[use])
+	p.leave()
+
+	use = ident("A", 10, 1)
+	require.Equal(t, lexer.INTEGER, p.resolve(use))
+	require.Equal(t, outer, p.info.Uses[use])
+	p.resolve(ident("B", 11, 1))
+
+	require.Len(t, p.info.Warnings, 1)
+	require.True(t, errors.Is(p.info.Warnings[0], ErrorShadowed))
+	require.Equal(t, inner.Span, p.info.Warnings[0].Span)
+	require.Len(t, p.info.Errors, 1)
+	require.True(t, errors.Is(p.info.Errors[0], ErrorUndeclared))
+}
@@ -0,0 +1,39 @@
+package sem
+
+import "fmt"
+
+var ErrorUnknownStrictness = fmt.Errorf("rigor desconhecido")
+
+// Strictness tells how the checker treats what is allowed
+// by the language but may lose information
+type Strictness int
+
+const (
+	// Permissive accepts it silently
+	Permissive Strictness = iota
+	// Warn accepts it with a warning
+	Warn
+	// Strict rejects it with an error
+	Strict
+)
+
+var strictnessNames = map[Strictness]string{
+	Permissive: "permitir",
+	Warn:       "avisar",
+	Strict:     "proibir",
+}
+
+func (s Strictness) String() string {
+	return strictnessNames[s]
+}
+
+// ParseStrictness returns the strictness named name,
+// as written by String
+func ParseStrictness(name string) (Strictness, error) {
+	for strictness, strictnessName := range strictnessNames {
+		if strictnessName == name {
+			return strictness, nil
+		}
+	}
+	return Permissive, fmt.Errorf("%w: %s", ErrorUnknownStrictness, name)
+}
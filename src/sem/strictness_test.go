@@ -0,0 +1,19 @@
+package sem
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStrictness(t *testing.T) {
+	for _, strictness := range []Strictness{Permissive, Warn, Strict} {
+		parsed, err := ParseStrictness(strictness.String())
+		require.NoError(t, err)
+		require.Equal(t, strictness, parsed)
+	}
+
+	_, err := ParseStrictness("talvez")
+	require.True(t, errors.Is(err, ErrorUnknownStrictness))
+}
@@ -0,0 +1,54 @@
+package sem
+
+// maxSuggestionDistance is how many characters may be inserted,
+// removed or replaced on a name for it to be suggested
+const maxSuggestionDistance = 2
+
+// editDistance returns the Levenshtein distance between a and b:
+// the fewest insertions, deletions and replacements of a character
+// that turn one into the other
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// suggest returns the declared name closest to name, or an empty
+// string when none is close enough. A name is never more than half
+// replaced, so short names don't match anything of their length
+func (p *pass) suggest(name string) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, declaration := range p.declared {
+		candidate := declaration.Name.Name
+		distance := editDistance(name, candidate)
+		if distance < bestDistance && 2*distance <= len(candidate) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
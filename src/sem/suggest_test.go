@@ -0,0 +1,66 @@
+package sem
+
+import (
+	"errors"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"media", "media", 0},
+		{"", "abc", 3},
+		{"media", "Media", 1},
+		{"soma", "somaa", 1},
+		{"total", "totl", 1},
+		{"contador", "cotnador", 2},
+		{"A", "B", 1},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, editDistance(tt.a, tt.b), "%s %s", tt.a, tt.b)
+		require.Equal(t, tt.expected, editDistance(tt.b, tt.a), "%s %s", tt.b, tt.a)
+	}
+}
+
+func TestUndeclaredSuggestion(t *testing.T) {
+	p := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			declaration(lexer.INTEGER, "contador", 2),
+			declaration(lexer.REAL, "media", 3),
+			declaration(lexer.REAL, "X", 4),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		suggestion string
+	}{
+		{"contdor", "contador"},
+		{"Media", "media"},
+		{"medias", "media"},
+		{"total", ""},
+		// Too short to tell a typo from another name
+		{"Y", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Body = []ast.Stmt{&ast.Read{Span: asttest.At(6, 1), Target: ident(tt.name, 6, 6)}}
+			info := Check(p, nil)
+
+			require.Len(t, info.Errors, 1)
+			require.True(t, errors.Is(info.Errors[0], ErrorUndeclared))
+			require.Equal(t, asttest.At(6, 6), info.Errors[0].Span)
+			require.Equal(t, tt.suggestion, info.Errors[0].Suggestion)
+		})
+	}
+}
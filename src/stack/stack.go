@@ -81,6 +81,14 @@ func (s *Stack) String() string {
 	return fmt.Sprintf("{\n\tData:%v,\n\tCapacity:%v,\n\tLength:%v,\n}", s.data, s.capacity, s.length)
 }
 
+//Returns a copy of the elements of the stack,
+//from the bottom to the top
+func (s *Stack) Elements() []interface{} {
+	elements := make([]interface{}, s.length)
+	copy(elements, s.data)
+	return elements
+}
+
 func (s *Stack) Clone() *Stack {
 	s_copy := NewStack(s.capacity)
 
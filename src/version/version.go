@@ -0,0 +1,97 @@
+// Package version tells which compiler is running, so a report of a
+// problem or a grader can tell which one produced a program. The
+// version and commit are given when the compiler is built:
+//
+//	go build -ldflags "-X mgol-go/src/version.Version=v1.2.0 -X mgol-go/src/version.Commit=$(git rev-parse HEAD)" ./src/cmd/mgol
+//
+// Without them, the version of the module is read from the build
+// information, when the compiler was installed with go install
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mgol-go/src/parser"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Set with -ldflags -X when the compiler is built
+var (
+	Version = ""
+	Commit  = ""
+)
+
+// Language is the revision of the language accepted, raised each time
+// a program is accepted, rejected or run differently than before
+const Language = 1
+
+// unknown is written for what the build did not tell
+const unknown = "desconhecido"
+
+// Info describes the compiler running
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	// Language is the revision of the language and Grammar the
+	// digest of the rules of grammar.json the parser follows
+	Language int    `json:"language"`
+	Grammar  string `json:"grammar"`
+	Go       string `json:"go"`
+}
+
+// Get returns the information of the compiler running
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Language: Language, Grammar: Grammar(), Go: runtime.Version()}
+	if info.Version == "" {
+		info.Version = unknown
+		if build, found := debug.ReadBuildInfo(); found && build.Main.Version != "" {
+			info.Version = build.Main.Version
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	return info
+}
+
+// Grammar returns the first 12 hexadecimal digits of the SHA-256
+// of the rules of the parser, one per line in their order, which
+// change whenever a rule does
+func Grammar() string {
+	rules := parser.DefaultRules()
+	numbers := make([]int, 0, len(*rules))
+	for number := range *rules {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	digest := sha256.New()
+	for _, number := range numbers {
+		rule := (*rules)[number]
+		fmt.Fprintf(digest, "%d %s -> %s\n", rule.Number, rule.Left, strings.Join(rule.Right, " "))
+	}
+	return hex.EncodeToString(digest.Sum(nil))[:12]
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("mgol %s (commit %s, linguagem %d, gramática %s, %s)", i.Version, i.Commit, i.Language, i.Grammar, i.Go)
+}
+
+// Write writes i on w, a field per line
+func (i Info) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "versão:     %s\ncommit:     %s\nlinguagem:  %d\ngramática:  %s\ngo:         %s\n", i.Version, i.Commit, i.Language, i.Grammar, i.Go)
+	return err
+}
+
+// EncodeJSON writes i on w as a json object
+func (i Info) EncodeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(i)
+}
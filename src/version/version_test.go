@@ -0,0 +1,34 @@
+package version
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	Version, Commit = "v1.2.0", "4f2a9c1"
+	defer func() { Version, Commit = "", "" }()
+
+	info := Get()
+	require.Equal(t, "v1.2.0", info.Version)
+	require.Equal(t, "4f2a9c1", info.Commit)
+	require.Equal(t, Language, info.Language)
+	require.Len(t, info.Grammar, 12)
+	require.Equal(t, Grammar(), info.Grammar)
+	require.True(t, strings.HasPrefix(info.String(), "mgol v1.2.0 (commit 4f2a9c1, linguagem 1, gramática "+info.Grammar))
+
+	var text strings.Builder
+	require.NoError(t, info.EncodeJSON(&text))
+	var decoded Info
+	require.NoError(t, json.Unmarshal([]byte(text.String()), &decoded))
+	require.Equal(t, info, decoded)
+}
+
+func TestGetUnknown(t *testing.T) {
+	info := Get()
+	require.NotEmpty(t, info.Version)
+	require.Equal(t, unknown, info.Commit)
+}
@@ -0,0 +1,408 @@
+// Package vm is the virtual machine that runs the bytecode of the
+// bytecode package. The values are kept on a stack, and the variables
+// on the globals of the program and on the locals of the frame it runs
+// on. The code is checked once, when the machine is made, so that no
+// instruction takes more values than there are on the stack, and the
+// jumps go to instruction indexes, which makes the loops much faster
+// than walking the syntax tree with the interp package:
+//
+//	err := vm.Run(program, os.Stdin, os.Stdout)
+package vm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/console"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+)
+
+var (
+	ErrorDivisionByZero = fmt.Errorf("divisão de inteiro por zero")
+	ErrorUnbalanced     = fmt.Errorf("a pilha do bytecode não está balanceada")
+)
+
+// Value is a value on the stack or on a variable, on the field of its
+// type. The types are not kept, as the compiled code knows them
+type Value struct {
+	Integer int32
+	Real    float64
+	Literal string
+}
+
+// frame holds the locals of a function being run, and where to go
+// on once it returns. The program runs on the first one
+type frame struct {
+	locals        []Value
+	returnAddress int
+}
+
+// instruction is a decoded instruction whose jump, if any, goes
+// to the index of an instruction, and whose PushConst carries
+// the constant itself
+type instruction struct {
+	op       bytecode.Opcode
+	operand  int
+	relation bytecode.Relation
+	constant Value
+}
+
+// Machine runs a program
+type Machine struct {
+	code    []instruction
+	io      console.IO
+	globals []Value
+	stack   []Value
+	frames  []frame
+	// types holds the type of each global, for the memory it takes
+	types  []lexer.DataType
+	ctx    context.Context
+	limits limits.Limits
+	// program and addresses, the one of each instruction on its
+	// code, tell where the runtime errors are on the source
+	program   *bytecode.Program
+	addresses []int
+}
+
+// New returns a machine that runs program with io. It fails when the
+// code does not keep the stack balanced: each instruction must find the
+// values it takes, and the stack must have the same height whichever
+// way an instruction is reached
+func New(program *bytecode.Program, io console.IO) (*Machine, error) {
+	decoded, err := program.Instructions()
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[int]int, len(decoded)+1)
+	addresses := make([]int, len(decoded))
+	for index, decodedInstruction := range decoded {
+		indexes[decodedInstruction.Address] = index
+		addresses[index] = decodedInstruction.Address
+	}
+	indexes[len(program.Code)] = len(decoded)
+
+	code := make([]instruction, len(decoded))
+	for index, decodedInstruction := range decoded {
+		code[index] = instruction{op: decodedInstruction.Op, operand: int(decodedInstruction.Operand), relation: decodedInstruction.Relation}
+		switch decodedInstruction.Op {
+		case bytecode.Jump, bytecode.JumpUnless:
+			code[index].operand = indexes[int(decodedInstruction.Operand)]
+		case bytecode.PushConst:
+			constant := program.Constants[decodedInstruction.Operand]
+			code[index].constant = Value{Real: constant.Real, Literal: constant.Literal}
+		}
+	}
+	depth, err := maxDepth(code)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]lexer.DataType, len(program.Globals))
+	for index, global := range program.Globals {
+		types[index] = global.Type
+	}
+	return &Machine{
+		code:      code,
+		io:        io,
+		globals:   make([]Value, len(program.Globals)),
+		stack:     make([]Value, 0, depth),
+		frames:    []frame{{locals: make([]Value, program.Locals)}},
+		types:     types,
+		program:   program,
+		addresses: addresses,
+	}, nil
+}
+
+// SetLimits makes Run stop once the program goes over bounds, its
+// globals being the variables and its instructions the steps, or
+// ctx is done
+func (m *Machine) SetLimits(ctx context.Context, bounds limits.Limits) {
+	m.ctx, m.limits = ctx, bounds
+}
+
+// effects holds how many values each opcode pops and pushes
+var effects = map[bytecode.Opcode][2]int{
+	bytecode.Halt:         {0, 0},
+	bytecode.PushInt:      {0, 1},
+	bytecode.PushConst:    {0, 1},
+	bytecode.Load:         {0, 1},
+	bytecode.Store:        {1, 0},
+	bytecode.LoadLocal:    {0, 1},
+	bytecode.StoreLocal:   {1, 0},
+	bytecode.AddInt:       {2, 1},
+	bytecode.SubInt:       {2, 1},
+	bytecode.MulInt:       {2, 1},
+	bytecode.DivInt:       {2, 1},
+	bytecode.AddReal:      {2, 1},
+	bytecode.SubReal:      {2, 1},
+	bytecode.MulReal:      {2, 1},
+	bytecode.DivReal:      {2, 1},
+	bytecode.ToReal:       {1, 1},
+	bytecode.ToInt:        {1, 1},
+	bytecode.CmpInt:       {2, 1},
+	bytecode.CmpReal:      {2, 1},
+	bytecode.CmpLiteral:   {2, 1},
+	bytecode.Jump:         {0, 0},
+	bytecode.JumpUnless:   {1, 0},
+	bytecode.ReadInt:      {0, 1},
+	bytecode.ReadReal:     {0, 1},
+	bytecode.ReadLiteral:  {0, 1},
+	bytecode.WriteInt:     {1, 0},
+	bytecode.WriteReal:    {1, 0},
+	bytecode.WriteLiteral: {1, 0},
+}
+
+// maxDepth follows each way through code, returning the largest
+// height the stack gets to, or an error if it is not balanced
+func maxDepth(code []instruction) (int, error) {
+	depths := make([]int, len(code)+1)
+	for index := range depths {
+		depths[index] = -1
+	}
+	deepest := 0
+	pending := []int{0}
+	depths[0] = 0
+	for len(pending) > 0 {
+		index := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if index == len(code) {
+			continue
+		}
+		effect := effects[code[index].op]
+		depth := depths[index] - effect[0]
+		if depth < 0 {
+			return 0, fmt.Errorf("%w: a instrução %d, %s, não tem valores na pilha", ErrorUnbalanced, index, code[index].op)
+		}
+		depth += effect[1]
+		if depth > deepest {
+			deepest = depth
+		}
+
+		var next []int
+		switch code[index].op {
+		case bytecode.Halt:
+		case bytecode.Jump:
+			next = []int{code[index].operand}
+		case bytecode.JumpUnless:
+			next = []int{index + 1, code[index].operand}
+		default:
+			next = []int{index + 1}
+		}
+		for _, successor := range next {
+			switch depths[successor] {
+			case -1:
+				depths[successor] = depth
+				pending = append(pending, successor)
+			case depth:
+			default:
+				return 0, fmt.Errorf("%w: a instrução %d é alcançada com alturas diferentes da pilha", ErrorUnbalanced, successor)
+			}
+		}
+	}
+	return deepest, nil
+}
+
+// Run runs program reading from r and writing on w
+func Run(program *bytecode.Program, r io.Reader, w io.Writer) error {
+	return RunWithOptions(program, r, w, backend.Options{})
+}
+
+// RunWithOptions runs program like Run, writing like the targets do with options
+func RunWithOptions(program *bytecode.Program, r io.Reader, w io.Writer, options backend.Options) error {
+	return RunIO(program, console.New(r, w, options))
+}
+
+// RunIO runs program like Run, reading and writing with io
+func RunIO(program *bytecode.Program, io console.IO) error {
+	return RunContext(context.Background(), program, io, limits.Limits{})
+}
+
+// RunContext runs program like RunIO, stopping it with an
+// limits.ExceededError once it goes over bounds or the deadline
+// of ctx passes. A leia waiting for its value is not stopped
+func RunContext(ctx context.Context, program *bytecode.Program, io console.IO, bounds limits.Limits) error {
+	machine, err := New(program, io)
+	if err != nil {
+		return err
+	}
+	machine.SetLimits(ctx, bounds)
+	return machine.Run()
+}
+
+// Run runs the program until Halt or the end of its code. It stops on
+// an inteiro divided by zero, which ends the C program, returning
+// ErrorDivisionByZero
+func (m *Machine) Run() error {
+	err := m.run()
+	if flushErr := m.io.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func (m *Machine) run() error {
+	if err := m.limits.Check(limits.Variables, int64(len(m.globals))); err != nil {
+		return err
+	}
+	// The memory only changes when a literal is stored, and the steps
+	// are only counted with a limit, keeping the loop fast without them
+	memory := int64(0)
+	for index, global := range m.globals {
+		memory += limits.Size(m.types[index], global.Literal)
+	}
+	if err := m.limits.Check(limits.Memory, memory); err != nil {
+		return err
+	}
+	counting := m.limits.Steps > 0 || (m.ctx != nil && m.ctx.Done() != nil)
+	steps, iterations := int64(0), int64(0)
+
+	code, stack, globals := m.code, m.stack, m.globals
+	locals := m.frames[len(m.frames)-1].locals
+	for pc := 0; pc < len(code); pc++ {
+		current := &code[pc]
+		top := len(stack) - 1
+		if counting {
+			steps++
+			if err := m.step(steps); err != nil {
+				return err
+			}
+		}
+		switch current.op {
+		case bytecode.Halt:
+			return nil
+		case bytecode.PushInt:
+			stack = append(stack, Value{Integer: int32(current.operand)})
+		case bytecode.PushConst:
+			stack = append(stack, current.constant)
+		case bytecode.Load:
+			stack = append(stack, globals[current.operand])
+		case bytecode.Store:
+			if m.types[current.operand] == lexer.LITERAL {
+				memory += int64(len(stack[top].Literal) - len(globals[current.operand].Literal))
+				if err := m.limits.Check(limits.Memory, memory); err != nil {
+					return err
+				}
+				if err := m.limits.Check(limits.Literal, int64(len(stack[top].Literal))); err != nil {
+					return err
+				}
+			}
+			globals[current.operand] = stack[top]
+			stack = stack[:top]
+		case bytecode.LoadLocal:
+			stack = append(stack, locals[current.operand])
+		case bytecode.StoreLocal:
+			locals[current.operand] = stack[top]
+			stack = stack[:top]
+		case bytecode.AddInt:
+			stack[top-1].Integer += stack[top].Integer
+			stack = stack[:top]
+		case bytecode.SubInt:
+			stack[top-1].Integer -= stack[top].Integer
+			stack = stack[:top]
+		case bytecode.MulInt:
+			stack[top-1].Integer *= stack[top].Integer
+			stack = stack[:top]
+		case bytecode.DivInt:
+			if stack[top].Integer == 0 {
+				return m.runtimeError(ErrorDivisionByZero, pc)
+			}
+			stack[top-1].Integer /= stack[top].Integer
+			stack = stack[:top]
+		case bytecode.AddReal:
+			stack[top-1].Real += stack[top].Real
+			stack = stack[:top]
+		case bytecode.SubReal:
+			stack[top-1].Real -= stack[top].Real
+			stack = stack[:top]
+		case bytecode.MulReal:
+			stack[top-1].Real *= stack[top].Real
+			stack = stack[:top]
+		case bytecode.DivReal:
+			stack[top-1].Real /= stack[top].Real
+			stack = stack[:top]
+		case bytecode.ToReal:
+			stack[top] = Value{Real: float64(stack[top].Integer)}
+		case bytecode.ToInt:
+			stack[top] = Value{Integer: int32(stack[top].Real)}
+		case bytecode.CmpInt:
+			stack[top-1] = Value{Integer: order(stack[top-1].Integer < stack[top].Integer, stack[top-1].Integer == stack[top].Integer)}
+			stack = stack[:top]
+		case bytecode.CmpReal:
+			left, right := stack[top-1].Real, stack[top].Real
+			if math.IsNaN(left) || math.IsNaN(right) {
+				stack[top-1] = Value{Integer: bytecode.Unordered}
+			} else {
+				stack[top-1] = Value{Integer: order(left < right, left == right)}
+			}
+			stack = stack[:top]
+		case bytecode.CmpLiteral:
+			stack[top-1] = Value{Integer: order(stack[top-1].Literal < stack[top].Literal, stack[top-1].Literal == stack[top].Literal)}
+			stack = stack[:top]
+		case bytecode.Jump:
+			// Each iteration of a repita ends jumping back to its condition
+			if current.operand <= pc && m.limits.Iterations > 0 {
+				iterations++
+				if err := m.limits.Check(limits.Iterations, iterations); err != nil {
+					return err
+				}
+			}
+			pc = current.operand - 1
+		case bytecode.JumpUnless:
+			if !current.relation.Holds(stack[top].Integer) {
+				pc = current.operand - 1
+			}
+			stack = stack[:top]
+		case bytecode.ReadInt:
+			stack = append(stack, Value{Integer: m.io.ReadInteger()})
+		case bytecode.ReadReal:
+			stack = append(stack, Value{Real: m.io.ReadReal()})
+		case bytecode.ReadLiteral:
+			stack = append(stack, Value{Literal: m.io.ReadLiteral()})
+		case bytecode.WriteInt:
+			m.io.WriteInteger(stack[top].Integer)
+			stack = stack[:top]
+		case bytecode.WriteReal:
+			m.io.WriteReal(stack[top].Real)
+			stack = stack[:top]
+		case bytecode.WriteLiteral:
+			m.io.WriteLiteral(stack[top].Literal)
+			stack = stack[:top]
+		}
+	}
+	return nil
+}
+
+// step fails when steps are more than the limits allow
+// or the context is done
+// runtimeError returns err on the instruction pc, telling where it
+// is on the source when the program knows it, like the interpreter
+func (m *Machine) runtimeError(err error, pc int) error {
+	if position, found := m.program.PositionAt(m.addresses[pc]); found {
+		return fmt.Errorf("%w na linha %d, coluna %d", err, position.Line, position.Column)
+	}
+	return fmt.Errorf("%w na instrução %d", err, pc)
+}
+
+func (m *Machine) step(steps int64) error {
+	if err := m.limits.Check(limits.Steps, steps); err != nil {
+		return err
+	}
+	if m.ctx != nil && steps%limits.CheckInterval == 1 {
+		return limits.Context(m.ctx)
+	}
+	return nil
+}
+
+// order returns the order pushed by the comparisons
+func order(less, equal bool) int32 {
+	switch {
+	case less:
+		return -1
+	case equal:
+		return 0
+	}
+	return 1
+}
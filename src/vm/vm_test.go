@@ -0,0 +1,308 @@
+package vm
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/backend"
+	"mgol-go/src/bytecode"
+	"mgol-go/src/console"
+	"mgol-go/src/interp"
+	"mgol-go/src/ir"
+	"mgol-go/src/lexer"
+	"mgol-go/src/limits"
+	"mgol-go/src/sem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// compile compiles a listing of three-address code
+func compile(t testing.TB, listing string) *bytecode.Program {
+	lowered, err := ir.Parse(listing)
+	require.NoError(t, err)
+	program, err := bytecode.Compile(lowered)
+	require.NoError(t, err)
+	return program
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name    string
+		listing string
+		input   string
+		output  string
+	}{
+		{
+			"read and write each type",
+			`literal NOME
+inteiro A
+real B
+	leia NOME
+	leia A
+	leia B
+	escreva NOME
+	escreva " "
+	%t1 = A + 1
+	escreva %t1
+	escreva "\t"
+	escreva B
+`,
+			"\nAna Maria\n41 2.5\n",
+			"Ana Maria 42\\t2.500000",
+		},
+		{
+			"arithmetic and conversions",
+			`inteiro A
+real B
+	%t1 = 0 - 7
+	%t2 = %t1 / 2
+	escreva %t2
+	escreva " "
+	%t3 = (real) 7
+	%t4 = %t3 / 2.0
+	escreva %t4
+	escreva " "
+	%t5 = 0.0 - 2.75
+	%t6 = (inteiro) %t5
+	A = %t6
+	escreva A
+	escreva " "
+	%t7 = 2147483647 + 1
+	escreva %t7
+`,
+			"",
+			"-3 3.500000 -2 -2147483648",
+		},
+		{
+			"loop and conditional",
+			`inteiro A
+	leia A
+L1:
+	ifFalse A > 0 goto L2
+	ifFalse A = 2 goto L3
+	escreva "dois "
+	goto L4
+L3:
+	escreva A
+	escreva " "
+L4:
+	%t1 = A - 1
+	A = %t1
+	goto L1
+L2:
+`,
+			"3",
+			"3 dois 1 ",
+		},
+		{
+			"literals compared like strcmp",
+			`literal NOME
+	leia NOME
+	ifFalse NOME < "b" goto L1
+	escreva "antes"
+L1:
+`,
+			"B\n",
+			"antes",
+		},
+		{
+			"nan compared",
+			`real B
+	%t1 = 0.0 / 0.0
+	B = %t1
+	ifFalse B <> B goto L1
+	escreva "diferente"
+L1:
+	ifFalse B >= B goto L2
+	escreva "igual"
+L2:
+`,
+			"",
+			"diferente",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			require.NoError(t, Run(compile(t, tc.listing), strings.NewReader(tc.input), &output))
+			require.Equal(t, tc.output, output.String())
+		})
+	}
+}
+
+func TestRunDecimalComma(t *testing.T) {
+	program := compile(t, "real B\n\tleia B\n\tescreva B\n")
+	var output bytes.Buffer
+	require.NoError(t, RunWithOptions(program, strings.NewReader("3.14"), &output, backend.Options{DecimalComma: true}))
+	require.Equal(t, "3,140000", output.String())
+}
+
+func TestRunIO(t *testing.T) {
+	program := compile(t, "real B\n\tleia B\n\t%t1 = B * 2.0\n\tescreva %t1\n")
+	var written []string
+	io := console.Callbacks(func(dataType lexer.DataType) string {
+		require.Equal(t, lexer.REAL, dataType)
+		return "1.25"
+	}, func(text string) {
+		written = append(written, text)
+	}, backend.Options{})
+	require.NoError(t, RunIO(program, io))
+	require.Equal(t, []string{"2.500000"}, written)
+}
+
+func TestRunDivisionByZero(t *testing.T) {
+	program := compile(t, "inteiro A\n\tleia A\n\tescreva \"antes\"\n\t%t1 = 1 / A\n\tescreva %t1\n")
+	var output bytes.Buffer
+	err := Run(program, strings.NewReader("0"), &output)
+	require.ErrorIs(t, err, ErrorDivisionByZero)
+	require.Equal(t, "antes", output.String())
+}
+
+func TestRunDivisionByZeroPosition(t *testing.T) {
+	lowered, err := ir.Parse("inteiro A\n\tleia A\n\t%t1 = 1 / A\n\tescreva %t1\n")
+	require.NoError(t, err)
+	lowered.Instructions[1].Pos = lexer.Position{Line: 7, Column: 6}
+	program, err := bytecode.Compile(lowered)
+	require.NoError(t, err)
+
+	err = Run(program, strings.NewReader("0"), ioutil.Discard)
+	require.ErrorIs(t, err, ErrorDivisionByZero)
+	require.Equal(t, "divisão de inteiro por zero na linha 7, coluna 6", err.Error())
+}
+
+func TestRunContext(t *testing.T) {
+	endless := "inteiro A\nL1:\n\t%t1 = A + 1\n\tA = %t1\n\tgoto L1\n"
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	testCases := []struct {
+		name    string
+		ctx     context.Context
+		bounds  limits.Limits
+		listing string
+		err     error
+	}{
+		{"steps", context.Background(), limits.Limits{Steps: 100}, endless, &limits.ExceededError{Resource: limits.Steps, Limit: 100}},
+		{"time", expired, limits.Limits{}, endless, &limits.ExceededError{Resource: limits.Time}},
+		{"variables", context.Background(), limits.Limits{Variables: 1}, "inteiro A\nreal B\n", &limits.ExceededError{Resource: limits.Variables, Limit: 1}},
+		{"memory", context.Background(), limits.Limits{Memory: 12}, "real B\nliteral NOME\n\tleia NOME\n", &limits.ExceededError{Resource: limits.Memory, Limit: 12}},
+		{"literal", context.Background(), limits.Limits{Literal: 8}, "literal NOME\n\tleia NOME\n", &limits.ExceededError{Resource: limits.Literal, Limit: 8}},
+		{"iterations", context.Background(), limits.Limits{Iterations: 10}, endless, &limits.ExceededError{Resource: limits.Iterations, Limit: 10}},
+		{"within the limits", context.Background(), limits.Limits{Steps: 3, Variables: 2, Memory: 17, Literal: 9}, "real B\nliteral NOME\n\tleia NOME\n", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			io := console.New(strings.NewReader("Ana Maria\n"), &bytes.Buffer{}, backend.Options{})
+			err := RunContext(tc.ctx, compile(t, tc.listing), io, tc.bounds)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, limits.ErrorLimitExceeded)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestNewUnbalanced(t *testing.T) {
+	testCases := []struct {
+		name    string
+		listing string
+	}{
+		{"empty stack", "\tadd.inteiro\n"},
+		{"one operand", "\tint 1\n\tsub.inteiro\n"},
+		{"different heights", "\tint 0\n\tint 0\n\tcmp.inteiro\n\tjump.unless = fim\n\tint 1\nfim:\n\thalt\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := bytecode.Assemble(tc.listing)
+			require.NoError(t, err)
+			_, err = New(program, nil)
+			require.ErrorIs(t, err, ErrorUnbalanced)
+		})
+	}
+}
+
+// loop is the syntax tree of a program that goes through the numbers
+// below the one read, adding the halves of the even ones and taking
+// away the halves of the odd ones:
+//
+//	leia N;
+//	repita (I < N)
+//	  se (I / 2 * 2 = I) entao S <- S + I / 2; senao S <- S - I / 2; fimse
+//	  I <- I + 1;
+//	fimrepita
+//	escreva S;
+func loop() *ast.Program {
+	integer := func(value string) *ast.Literal { return asttest.Literal(value, lexer.INTEGER) }
+	half := func() ast.Expr { return asttest.Binary("/", asttest.Ident("I"), integer("2")) }
+	return &ast.Program{
+		Declarations: []*ast.VarDecl{
+			{Type: lexer.INTEGER, Name: asttest.Ident("N")},
+			{Type: lexer.INTEGER, Name: asttest.Ident("I")},
+			{Type: lexer.INTEGER, Name: asttest.Ident("S")},
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("N")},
+			&ast.While{
+				Condition: asttest.Binary("<", asttest.Ident("I"), asttest.Ident("N")),
+				Body: []ast.Stmt{
+					&ast.If{
+						Condition: asttest.Binary("=", asttest.Binary("*", half(), integer("2")), asttest.Ident("I")),
+						Body:      []ast.Stmt{&ast.Assign{Target: asttest.Ident("S"), Value: asttest.Binary("+", asttest.Ident("S"), half())}},
+						Else:      []ast.Stmt{&ast.Assign{Target: asttest.Ident("S"), Value: asttest.Binary("-", asttest.Ident("S"), half())}},
+					},
+					&ast.Assign{Target: asttest.Ident("I"), Value: asttest.Binary("+", asttest.Ident("I"), integer("1"))},
+				},
+			},
+			&ast.Write{Value: asttest.Ident("S")},
+		},
+	}
+}
+
+func TestRunAgreesWithInterpreter(t *testing.T) {
+	program := loop()
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+	lowered, err := ir.Lower(program, info)
+	require.NoError(t, err)
+	compiled, err := bytecode.Compile(lowered)
+	require.NoError(t, err)
+
+	var interpreted, run bytes.Buffer
+	require.NoError(t, interp.Run(program, info, strings.NewReader("1001"), &interpreted))
+	require.NoError(t, Run(compiled, strings.NewReader("1001"), &run))
+	require.Equal(t, "500", run.String())
+	require.Equal(t, interpreted.String(), run.String())
+}
+
+// BenchmarkLoop runs a loop of a million iterations on the virtual
+// machine, from the optimized code, and on the interpreter
+func BenchmarkLoop(b *testing.B) {
+	program := loop()
+	info := sem.NewChecker(nil).Check(program)
+	lowered, err := ir.Lower(program, info)
+	require.NoError(b, err)
+	optimized, _ := ir.Optimize(lowered, ir.O2)
+	compiled, err := bytecode.Compile(optimized)
+	require.NoError(b, err)
+
+	b.Run("vm", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			require.NoError(b, Run(compiled, strings.NewReader("1000000"), ioutil.Discard))
+		}
+	})
+	b.Run("interp", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			require.NoError(b, interp.Run(program, info, strings.NewReader("1000000"), ioutil.Discard))
+		}
+	})
+}
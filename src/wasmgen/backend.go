@@ -0,0 +1,45 @@
+package wasmgen
+
+import (
+	"io"
+	"mgol-go/src/ast"
+	"mgol-go/src/backend"
+	"mgol-go/src/sem"
+)
+
+func init() {
+	backend.Register(wasmBackend{})
+}
+
+// wasmBackend is the WebAssembly target on the registry of backends
+type wasmBackend struct {
+	options backend.Options
+}
+
+func (wasmBackend) Name() string {
+	return "wasm"
+}
+
+func (wasmBackend) FileExtension() string {
+	return ".wasm"
+}
+
+func (wasmBackend) Generate(program *ast.Program, info *sem.Info, w io.Writer) error {
+	return Generate(w, program, info)
+}
+
+func (b wasmBackend) WithOptions(options backend.Options) backend.Backend {
+	b.options = options
+	return b
+}
+
+// Runtime returns the glue layer, which the generated module is
+// loaded with on a page. The module is the same with any options, as
+// the glue is what writes the values
+func (b wasmBackend) Runtime() map[string]string {
+	glue := Glue
+	if b.options.DecimalComma {
+		glue = GlueDecimalComma
+	}
+	return map[string]string{"mgol.js": glue}
+}
@@ -0,0 +1,66 @@
+package wasmgen
+
+import "strings"
+
+// Glue is the JavaScript that runs a module, giving it the functions
+// it imports. executaMgol takes the bytes of the module and, optionally,
+// the functions reading and writing text, prompt and console.log by
+// default. The literals are on the memory of the module, ended by a 0
+const Glue = `"use strict";
+
+async function executaMgol(bytes, leia, escreva) {
+  const decoder = new TextDecoder();
+  const encoder = new TextEncoder();
+  let memoria = null;
+  let saida = "";
+
+  const ler = () => {
+    const valor = (leia || prompt)();
+    return valor === null ? "" : valor.trim();
+  };
+  // console.log ends each call with a new line, so the
+  // text is only written by it as the lines end
+  const escrever = escreva || ((texto) => {
+    const linhas = (saida + texto).split("\n");
+    saida = linhas.pop();
+    for (const linha of linhas) {
+      console.log(linha);
+    }
+  });
+  const literal = (endereco) => {
+    const bytes = new Uint8Array(memoria.buffer, endereco);
+    return decoder.decode(bytes.subarray(0, bytes.indexOf(0)));
+  };
+
+  const { instance } = await WebAssembly.instantiate(bytes, {
+    mgol: {
+      leia_inteiro: () => parseInt(ler(), 10) || 0,
+      leia_real: () => parseFloat(ler()) || 0,
+      leia_literal: (endereco) => {
+        const destino = new Uint8Array(memoria.buffer, endereco, 256).fill(0);
+        destino.set(encoder.encode(ler()).subarray(0, 255));
+      },
+      escreva_inteiro: (valor) => escrever(String(valor)),
+      escreva_real: (valor) => escrever(valor.toFixed(6)),
+      escreva_literal: (endereco) => escrever(literal(endereco)),
+      compara_literais: (a, b) => {
+        const [x, y] = [literal(a), literal(b)];
+        return x < y ? -1 : x > y ? 1 : 0;
+      },
+    },
+  });
+  memoria = instance.exports.memoria;
+  instance.exports.main();
+  if (!escreva && saida !== "") {
+    console.log(saida);
+  }
+}
+
+if (typeof module !== "undefined") {
+  module.exports = { executaMgol };
+}
+`
+
+// GlueDecimalComma is Glue writing the reals with
+// a comma as the decimal separator, like 3,140000
+var GlueDecimalComma = strings.Replace(Glue, "valor.toFixed(6)", `valor.toFixed(6).replace(".", ",")`, 1)
@@ -0,0 +1,418 @@
+// Package wasmgen is a backend that writes a program as a WebAssembly
+// module, to run on the teaching web UI. The module imports the reading
+// and writing of each type from the glue layer of Glue, and exports
+// its entry point as main and its memory as memoria
+package wasmgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"mgol-go/src/ast"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+)
+
+var ErrorBadNode = fmt.Errorf("a árvore sintática tem nós com erros de sintaxe")
+
+// literalSize is the size of the memory of each literal variable,
+// with the byte ending it, like the char[256] of the C backend
+const literalSize = 256
+
+const pageSize = 65536
+
+// Value types and sections of the binary format
+const (
+	typeI32 = 0x7f
+	typeF64 = 0x7c
+
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionMemory   = 5
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionCode     = 10
+	sectionData     = 11
+)
+
+// Instructions of the binary format
+const (
+	opBlock        = 0x02
+	opLoop         = 0x03
+	opIf           = 0x04
+	opElse         = 0x05
+	opEnd          = 0x0b
+	opBr           = 0x0c
+	opBrIf         = 0x0d
+	opCall         = 0x10
+	opGlobalGet    = 0x23
+	opGlobalSet    = 0x24
+	opI32Const     = 0x41
+	opF64Const     = 0x44
+	opI32Eqz       = 0x45
+	opConvertI32   = 0xb7
+	opPrefix       = 0xfc
+	opTruncSatF64  = 0x02
+	opMemoryCopy   = 0x0a
+	blockTypeEmpty = 0x40
+)
+
+// integerOperations and realOperations hold the instruction of each operator
+var integerOperations = map[string]byte{
+	"+": 0x6a, "-": 0x6b, "*": 0x6c, "/": 0x6d,
+	"=": 0x46, "<>": 0x47, "<": 0x48, ">": 0x4a, "<=": 0x4c, ">=": 0x4e,
+}
+
+var realOperations = map[string]byte{
+	"+": 0xa0, "-": 0xa1, "*": 0xa2, "/": 0xa3,
+	"=": 0x61, "<>": 0x62, "<": 0x63, ">": 0x64, "<=": 0x65, ">=": 0x66,
+}
+
+// arithmetic holds the operators whose result has the type of the operands
+var arithmetic = map[string]bool{"+": true, "-": true, "*": true, "/": true}
+
+// signature is the type of a function: its parameters and results
+type signature struct {
+	params  []byte
+	results []byte
+}
+
+// function is a function imported from the glue layer
+type function struct {
+	name string
+	signature
+}
+
+// imports holds the functions of the glue layer, whose index is
+// their position. The literals are passed by their address
+var imports = []function{
+	{"leia_inteiro", signature{nil, []byte{typeI32}}},
+	{"leia_real", signature{nil, []byte{typeF64}}},
+	{"leia_literal", signature{[]byte{typeI32}, nil}},
+	{"escreva_inteiro", signature{[]byte{typeI32}, nil}},
+	{"escreva_real", signature{[]byte{typeF64}, nil}},
+	{"escreva_literal", signature{[]byte{typeI32}, nil}},
+	{"compara_literais", signature{[]byte{typeI32, typeI32}, []byte{typeI32}}},
+}
+
+// importIndexes holds the index of each imported function
+var importIndexes = func() map[string]uint64 {
+	indexes := make(map[string]uint64)
+	for index, imported := range imports {
+		indexes[imported.name] = uint64(index)
+	}
+	return indexes
+}()
+
+// readers and writers hold the imported function reading and writing each type
+var readers = map[lexer.DataType]string{
+	lexer.INTEGER: "leia_inteiro",
+	lexer.REAL:    "leia_real",
+	lexer.LITERAL: "leia_literal",
+}
+
+var writers = map[lexer.DataType]string{
+	lexer.INTEGER: "escreva_inteiro",
+	lexer.REAL:    "escreva_real",
+	lexer.LITERAL: "escreva_literal",
+}
+
+// appendUnsigned and appendSigned append value encoded as LEB128
+func appendUnsigned(b []byte, value uint64) []byte {
+	for {
+		next := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(b, next)
+		}
+		b = append(b, next|0x80)
+	}
+}
+
+func appendSigned(b []byte, value int64) []byte {
+	for {
+		next := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && next&0x40 == 0) || (value == -1 && next&0x40 != 0) {
+			return append(b, next)
+		}
+		b = append(b, next|0x80)
+	}
+}
+
+// appendVector appends the count of items and then items
+func appendVector(b []byte, count int, items []byte) []byte {
+	return append(appendUnsigned(b, uint64(count)), items...)
+}
+
+func appendName(b []byte, name string) []byte {
+	return appendVector(b, len(name), []byte(name))
+}
+
+// variable is where the value of a variable is kept: a global for
+// the numbers and an address on the memory for the literals
+type variable struct {
+	dataType lexer.DataType
+	index    uint64
+}
+
+type generator struct {
+	info      *sem.Info
+	variables map[string]variable
+	// data holds the memory after the literal variables, with the
+	// literal constants found, which start at their offset on constants
+	dataStart int
+	data      []byte
+	constants map[string]int
+	code      []byte
+	err       error
+}
+
+func (g *generator) op(bytes ...byte) {
+	g.code = append(g.code, bytes...)
+}
+
+func (g *generator) i32(value int64) {
+	g.code = appendSigned(append(g.code, opI32Const), value)
+}
+
+func (g *generator) call(name string) {
+	g.code = appendUnsigned(append(g.code, opCall), importIndexes[name])
+}
+
+// Generate writes program as a WebAssembly module. info holds what
+// the sem package found on it, which must have been without errors
+func Generate(w io.Writer, program *ast.Program, info *sem.Info) error {
+	g := &generator{info: info, variables: make(map[string]variable), constants: make(map[string]int)}
+	var globals []byte
+	count, literals := 0, 0
+	for _, declaration := range append(append([]*ast.VarDecl{}, program.Declarations...), info.Implicit...) {
+		switch declaration.Type {
+		case lexer.INTEGER:
+			g.variables[declaration.Name.Name] = variable{lexer.INTEGER, uint64(count)}
+			globals = append(globals, typeI32, 1, opI32Const, 0, opEnd)
+			count++
+		case lexer.REAL:
+			g.variables[declaration.Name.Name] = variable{lexer.REAL, uint64(count)}
+			globals = append(globals, typeF64, 1, opF64Const, 0, 0, 0, 0, 0, 0, 0, 0, opEnd)
+			count++
+		case lexer.LITERAL:
+			g.variables[declaration.Name.Name] = variable{lexer.LITERAL, uint64(literals * literalSize)}
+			literals++
+		}
+	}
+	g.dataStart = literals * literalSize
+
+	g.stmts(program.Body)
+	if g.err != nil {
+		return g.err
+	}
+	g.op(opEnd)
+
+	module := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+	// Each imported function has its own type, and main the last one
+	var types []byte
+	for _, imported := range imports {
+		types = append(types, 0x60)
+		types = appendVector(types, len(imported.params), imported.params)
+		types = appendVector(types, len(imported.results), imported.results)
+	}
+	types = append(types, 0x60, 0, 0)
+	module = section(module, sectionType, appendVector(nil, len(imports)+1, types))
+
+	var importEntries []byte
+	for index, imported := range imports {
+		importEntries = appendName(importEntries, "mgol")
+		importEntries = appendName(importEntries, imported.name)
+		importEntries = appendUnsigned(append(importEntries, 0x00), uint64(index))
+	}
+	module = section(module, sectionImport, appendVector(nil, len(imports), importEntries))
+	module = section(module, sectionFunction, appendUnsigned([]byte{1}, uint64(len(imports))))
+
+	pages := (g.dataStart + len(g.data) + pageSize - 1) / pageSize
+	if pages == 0 {
+		pages = 1
+	}
+	module = section(module, sectionMemory, appendUnsigned([]byte{1, 0x00}, uint64(pages)))
+	if count > 0 {
+		module = section(module, sectionGlobal, appendVector(nil, count, globals))
+	}
+
+	exports := appendUnsigned(append(appendName(nil, "main"), 0x00), uint64(len(imports)))
+	exports = append(appendName(exports, "memoria"), 0x02, 0)
+	module = section(module, sectionExport, appendVector(nil, 2, exports))
+
+	body := append([]byte{0}, g.code...)
+	module = section(module, sectionCode, appendVector(nil, 1, appendVector(nil, len(body), body)))
+
+	if len(g.data) > 0 {
+		segment := appendSigned([]byte{0x00, opI32Const}, int64(g.dataStart))
+		segment = appendVector(append(segment, opEnd), len(g.data), g.data)
+		module = section(module, sectionData, appendVector(nil, 1, segment))
+	}
+
+	_, err := w.Write(module)
+	return err
+}
+
+// section appends the section with id and contents to module
+func section(module []byte, id byte, contents []byte) []byte {
+	return appendVector(append(module, id), len(contents), contents)
+}
+
+func (g *generator) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		g.stmt(stmt)
+	}
+}
+
+func (g *generator) stmt(stmt ast.Stmt) {
+	switch node := stmt.(type) {
+	case *ast.Read:
+		target := g.variables[node.Target.Name]
+		if target.dataType == lexer.LITERAL {
+			g.i32(int64(target.index))
+			g.call(readers[lexer.LITERAL])
+			return
+		}
+		g.call(readers[target.dataType])
+		g.code = appendUnsigned(append(g.code, opGlobalSet), target.index)
+	case *ast.Write:
+		g.call(writers[g.expr(node.Value)])
+	case *ast.Assign:
+		target := g.variables[node.Target.Name]
+		if target.dataType == lexer.LITERAL {
+			// memory.copy takes the destination, the source and the size
+			g.i32(int64(target.index))
+			g.expr(node.Value)
+			g.i32(literalSize)
+			g.op(opPrefix, opMemoryCopy, 0, 0)
+			return
+		}
+		assigned := g.expr(node.Value)
+		if target.dataType == lexer.INTEGER && assigned == lexer.REAL {
+			// Like on a C int, the fractional part is dropped
+			g.op(opPrefix, opTruncSatF64)
+		} else if target.dataType == lexer.REAL && assigned == lexer.INTEGER {
+			g.op(opConvertI32)
+		}
+		g.code = appendUnsigned(append(g.code, opGlobalSet), target.index)
+	case *ast.If:
+		g.expr(node.Condition)
+		g.op(opIf, blockTypeEmpty)
+		g.stmts(node.Body)
+		if node.Else != nil {
+			g.op(opElse)
+			g.stmts(node.Else)
+		}
+		g.op(opEnd)
+	case *ast.While:
+		// The loop goes on to its start, and the block around
+		// it to its end, which is where a false condition goes
+		g.op(opBlock, blockTypeEmpty, opLoop, blockTypeEmpty)
+		g.expr(node.Condition)
+		g.op(opI32Eqz, opBrIf, 1)
+		g.stmts(node.Body)
+		g.op(opBr, 0, opEnd, opEnd)
+	default:
+		g.err = ErrorBadNode
+	}
+}
+
+// expr emits the instructions leaving the value of expr on the
+// stack, returning its type. A comparison leaves an i32 and NULL
+func (g *generator) expr(expr ast.Expr) lexer.DataType {
+	var dataType lexer.DataType
+	switch node := expr.(type) {
+	case *ast.BinaryExpr:
+		dataType = g.binary(node)
+	case *ast.Literal:
+		dataType = g.constant(node)
+	case *ast.Ident:
+		target := g.variables[node.Name]
+		dataType = target.dataType
+		if dataType == lexer.LITERAL {
+			g.i32(int64(target.index))
+			break
+		}
+		g.code = appendUnsigned(append(g.code, opGlobalGet), target.index)
+	default:
+		g.err = ErrorBadNode
+		return lexer.NULL
+	}
+
+	if g.info.Conversions[expr] == lexer.REAL && dataType == lexer.INTEGER {
+		g.op(opConvertI32)
+		return lexer.REAL
+	}
+	return dataType
+}
+
+func (g *generator) binary(node *ast.BinaryExpr) lexer.DataType {
+	left := g.expr(node.Left)
+	mark := len(g.code)
+	right := g.expr(node.Right)
+
+	switch {
+	case left == lexer.LITERAL:
+		// Literals are compared by the sign of what the glue
+		// layer returns, like strcmp on the C backend
+		g.call("compara_literais")
+		g.i32(0)
+		g.op(integerOperations[node.Operator])
+		return lexer.NULL
+	case left == lexer.REAL || right == lexer.REAL:
+		// The sem package promotes one of the operands, unless the
+		// tree was folded after it, so both are made real here
+		if right == lexer.INTEGER {
+			g.op(opConvertI32)
+		}
+		if left == lexer.INTEGER {
+			rightCode := append([]byte(nil), g.code[mark:]...)
+			g.code = append(append(g.code[:mark], opConvertI32), rightCode...)
+		}
+		g.op(realOperations[node.Operator])
+		left = lexer.REAL
+	default:
+		g.op(integerOperations[node.Operator])
+	}
+
+	if arithmetic[node.Operator] {
+		return left
+	}
+	return lexer.NULL
+}
+
+// constant emits a number, or the address of a literal, which is added
+// to the data of the module once for each text
+func (g *generator) constant(node *ast.Literal) lexer.DataType {
+	if node.Type == lexer.LITERAL {
+		text := lexer.LiteralText(node.Value)
+		offset, found := g.constants[text]
+		if !found {
+			offset = g.dataStart + len(g.data)
+			g.constants[text] = offset
+			g.data = append(append(g.data, text...), 0)
+		}
+		g.i32(int64(offset))
+		return lexer.LITERAL
+	}
+
+	number, err := lexer.ParseNumber(node.Value, node.Type)
+	if err != nil {
+		g.err = err
+		return node.Type
+	}
+	if node.Type == lexer.INTEGER {
+		g.i32(int64(int32(number)))
+		return lexer.INTEGER
+	}
+	var bits [8]byte
+	binary.LittleEndian.PutUint64(bits[:], math.Float64bits(number))
+	g.op(opF64Const)
+	g.op(bits[:]...)
+	return lexer.REAL
+}
@@ -0,0 +1,123 @@
+package wasmgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mgol-go/src/ast"
+	"mgol-go/src/ast/asttest"
+	"mgol-go/src/lexer"
+	"mgol-go/src/sem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLEB128(t *testing.T) {
+	testCases := []struct {
+		value    int64
+		unsigned []byte
+		signed   []byte
+	}{
+		{0, []byte{0x00}, []byte{0x00}},
+		{63, []byte{0x3f}, []byte{0x3f}},
+		{64, []byte{0x40}, []byte{0xc0, 0x00}},
+		{624485, []byte{0xe5, 0x8e, 0x26}, []byte{0xe5, 0x8e, 0x26}},
+		{-1, nil, []byte{0x7f}},
+		{-123456, nil, []byte{0xc0, 0xbb, 0x78}},
+	}
+	for _, testCase := range testCases {
+		if testCase.unsigned != nil {
+			require.Equal(t, testCase.unsigned, appendUnsigned(nil, uint64(testCase.value)))
+		}
+		require.Equal(t, testCase.signed, appendSigned(nil, testCase.value))
+	}
+}
+
+// TestGenerate runs the module with node, when it is found
+func TestGenerate(t *testing.T) {
+	// literal NOME; inteiro A; real B;
+	// leia NOME;
+	// leia A;
+	// repita (A > 0)
+	//	se (NOME = "fim") entao B <- B + 0.5; senao B <- A * 1.5; fimse
+	//	A <- A - 1;
+	// fimrepita
+	// escreva NOME;
+	// escreva " ";
+	// escreva B;
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{
+			asttest.Declaration(lexer.LITERAL, "NOME"),
+			asttest.Declaration(lexer.INTEGER, "A"),
+			asttest.Declaration(lexer.REAL, "B"),
+		},
+		Body: []ast.Stmt{
+			&ast.Read{Target: asttest.Ident("NOME")},
+			&ast.Read{Target: asttest.Ident("A")},
+			&ast.While{Condition: &ast.BinaryExpr{Operator: ">", Left: asttest.Ident("A"), Right: asttest.Literal("0", lexer.INTEGER)}, Body: []ast.Stmt{
+				&ast.If{
+					Condition: &ast.BinaryExpr{Operator: "=", Left: asttest.Ident("NOME"), Right: asttest.Literal(`"fim"`, lexer.LITERAL)},
+					Body: []ast.Stmt{&ast.Assign{Target: asttest.Ident("B"), Value: &ast.BinaryExpr{
+						Operator: "+", Left: asttest.Ident("B"), Right: asttest.Literal("0.5", lexer.REAL),
+					}}},
+					Else: []ast.Stmt{&ast.Assign{Target: asttest.Ident("B"), Value: &ast.BinaryExpr{
+						Operator: "*", Left: asttest.Ident("A"), Right: asttest.Literal("1.5", lexer.REAL),
+					}}},
+				},
+				&ast.Assign{Target: asttest.Ident("A"), Value: &ast.BinaryExpr{
+					Operator: "-", Left: asttest.Ident("A"), Right: asttest.Literal("1", lexer.INTEGER),
+				}},
+			}},
+			&ast.Write{Value: asttest.Ident("NOME")},
+			&ast.Write{Value: asttest.Literal(`" "`, lexer.LITERAL)},
+			&ast.Write{Value: asttest.Ident("B")},
+		},
+	}
+	info := sem.NewChecker(nil).Check(program)
+	require.Empty(t, info.Errors)
+
+	var module bytes.Buffer
+	require.NoError(t, Generate(&module, program, info))
+	require.True(t, bytes.HasPrefix(module.Bytes(), []byte("\x00asm\x01\x00\x00\x00")))
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node não encontrado")
+	}
+	dir, err := ioutil.TempDir("", "programa")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "programa.wasm"), module.Bytes(), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "programa.js"), []byte(Glue), 0644))
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"fim\n3\n", "fim 1.500000\n"},
+		{"outro nome\n2\n", "outro nome 1.500000\n"},
+	}
+	for _, testCase := range testCases {
+		script := `const { executaMgol } = require("./programa.js");
+const entrada = ` + "`" + testCase.input + "`" + `.split("\n");
+executaMgol(require("fs").readFileSync("programa.wasm"), () => entrada.shift());`
+		run := exec.Command(node, "-e", script)
+		run.Dir = dir
+		output, err := run.CombinedOutput()
+		require.NoError(t, err, string(output))
+		require.Equal(t, testCase.expected, strings.Replace(string(output), "\r\n", "\n", -1))
+	}
+}
+
+func TestGenerateBadNode(t *testing.T) {
+	program := &ast.Program{
+		Declarations: []*ast.VarDecl{asttest.Declaration(lexer.INTEGER, "A")},
+		Body:         []ast.Stmt{&ast.BadStmt{}},
+	}
+
+	require.ErrorIs(t, Generate(&bytes.Buffer{}, program, sem.NewChecker(nil).Check(program)), ErrorBadNode)
+}